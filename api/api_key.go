@@ -0,0 +1,246 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
+	"github.com/danglebary/beatstore-backend-go/policy"
+	"github.com/danglebary/beatstore-backend-go/token"
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// apiKeyPrefixTag starts every key so leaked keys are easy to recognize
+	apiKeyPrefixTag  = "bsk_"
+	apiKeyIDSize     = 6
+	apiKeySecretSize = 32
+)
+
+var (
+	errInvalidAPIKey = errors.New("api key is invalid")
+	errExpiredAPIKey = errors.New("api key has expired")
+)
+
+// newAPIKey generates a key of the form bsk_<id>.<secret>, returning the public
+// prefix used to look the key up and the full key which is only shown once
+func newAPIKey() (string, string, error) {
+	id := make([]byte, apiKeyIDSize)
+	if _, err := rand.Read(id); err != nil {
+		return "", "", err
+	}
+	secret, err := util.GenerateSecretToken(apiKeySecretSize)
+	if err != nil {
+		return "", "", err
+	}
+
+	prefix := apiKeyPrefixTag + hex.EncodeToString(id)
+	return prefix, prefix + "." + secret, nil
+}
+
+// verifyAPIKey finds the stored key matching rawKey and checks that it has not expired
+func verifyAPIKey(ctx context.Context, store db.Store, rawKey string) (db.GetAPIKeyByPrefixRow, error) {
+	parts := strings.SplitN(rawKey, ".", 2)
+	if len(parts) != 2 || !strings.HasPrefix(parts[0], apiKeyPrefixTag) {
+		return db.GetAPIKeyByPrefixRow{}, errInvalidAPIKey
+	}
+
+	key, err := store.GetAPIKeyByPrefix(ctx, parts[0])
+	if err != nil {
+		return db.GetAPIKeyByPrefixRow{}, err
+	}
+
+	hashedKey := util.HashSecretToken(rawKey)
+	if subtle.ConstantTimeCompare([]byte(hashedKey), []byte(key.HashedKey)) != 1 {
+		return db.GetAPIKeyByPrefixRow{}, errInvalidAPIKey
+	}
+	if key.ExpiresAt.Valid && time.Now().After(key.ExpiresAt.Time) {
+		return db.GetAPIKeyByPrefixRow{}, errExpiredAPIKey
+	}
+	return key, nil
+}
+
+// newAPIKeyPayload describes the owner of key the same way an access token would
+func newAPIKeyPayload(key db.GetAPIKeyByPrefixRow) *token.Payload {
+	payload := &token.Payload{
+		UserID:   key.UserID,
+		Username: key.Username,
+		Role:     key.Role,
+		IssuedAt: key.CreatedAt,
+	}
+	if key.ExpiresAt.Valid {
+		payload.ExpiredAt = key.ExpiresAt.Time
+	}
+	return payload
+}
+
+type apiKeyResponse struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func newAPIKeyResponse(key db.ApiKey) apiKeyResponse {
+	rsp := apiKeyResponse{
+		ID:        key.ID,
+		Name:      key.Name,
+		Prefix:    key.Prefix,
+		Scopes:    key.Scopes,
+		CreatedAt: key.CreatedAt,
+	}
+	if key.ExpiresAt.Valid {
+		rsp.ExpiresAt = &key.ExpiresAt.Time
+	}
+	if key.LastUsedAt.Valid {
+		rsp.LastUsedAt = &key.LastUsedAt.Time
+	}
+	return rsp
+}
+
+type apiKeyUserRequest struct {
+	UserID int32 `uri:"id" binding:"required,min=1"`
+}
+
+type createAPIKeyRequest struct {
+	Name      string     `json:"name" binding:"required"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1,dive,oneof=beats:write likes:write"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type createAPIKeyResponse struct {
+	Key    string         `json:"key"`
+	APIKey apiKeyResponse `json:"api_key"`
+}
+
+func (server *Server) createAPIKey(ctx *gin.Context) {
+	var uri apiKeyUserRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req createAPIKeyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if err := policy.CanCreateAPIKey(getAuthSubject(ctx), uri.UserID); err != nil {
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+
+	var expiresAt sql.NullTime
+	if req.ExpiresAt != nil {
+		if !req.ExpiresAt.After(time.Now()) {
+			err := errors.New("expires_at must be in the future")
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		expiresAt = sql.NullTime{Time: *req.ExpiresAt, Valid: true}
+	}
+
+	prefix, rawKey, err := newAPIKey()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	arg := db.CreateAPIKeyParams{
+		UserID:    uri.UserID,
+		Name:      req.Name,
+		Prefix:    prefix,
+		HashedKey: util.HashSecretToken(rawKey),
+		Scopes:    req.Scopes,
+		ExpiresAt: expiresAt,
+	}
+
+	key, err := server.store.CreateAPIKey(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := createAPIKeyResponse{
+		Key:    rawKey,
+		APIKey: newAPIKeyResponse(key),
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+func (server *Server) listAPIKeys(ctx *gin.Context) {
+	var uri apiKeyUserRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if err := policy.CanModifyUser(getAuthSubject(ctx), uri.UserID); err != nil {
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+
+	keys, err := server.store.ListAPIKeysByUser(ctx, uri.UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]apiKeyResponse, len(keys))
+	for i, key := range keys {
+		rsp[i] = newAPIKeyResponse(key)
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type deleteAPIKeyRequest struct {
+	UserID int32 `uri:"id" binding:"required,min=1"`
+	KeyID  int64 `uri:"key_id" binding:"required,min=1"`
+}
+
+func (server *Server) deleteAPIKey(ctx *gin.Context) {
+	var req deleteAPIKeyRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	key, err := server.store.GetAPIKey(ctx, req.KeyID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// Keys are addressed through their owner, a key of another user does not exist here
+	if key.UserID != req.UserID {
+		ctx.JSON(http.StatusNotFound, errorResponse(sql.ErrNoRows))
+		return
+	}
+
+	if err := policy.CanModifyAPIKey(getAuthSubject(ctx), key); err != nil {
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+
+	if err := server.store.DeleteAPIKey(ctx, key.ID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
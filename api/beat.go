@@ -4,20 +4,117 @@ import (
 	"database/sql"
 	"fmt"
 	"net/http"
+	"time"
 
+	"github.com/danglebary/beatstore-backend-go/artwork"
 	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
+	"github.com/danglebary/beatstore-backend-go/media"
+	"github.com/danglebary/beatstore-backend-go/policy"
 	"github.com/gin-gonic/gin"
 )
 
+// beatResponse is the public representation of a db.Beat. The storage keys of the audio and artwork
+// are never exposed, clients get time limited URLs to the preview and the artwork thumbnails instead.
+// A beat whose preview is still being generated has no preview URL,
+// one whose audio hasn't been analysed yet has no analysis.
+type beatResponse struct {
+	ID         int32  `json:"id"`
+	CreatorID  int32  `json:"creator_id"`
+	Title      string `json:"title"`
+	Genre      string `json:"genre"`
+	Key        string `json:"key"`
+	Bpm        int16  `json:"bpm"`
+	Tags       string `json:"tags"`
+	PreviewURL string `json:"preview_url,omitempty"`
+	// Artwork maps the edge length of each artwork thumbnail to its URL
+	Artwork   map[int]string `json:"artwork,omitempty"`
+	PlayCount int64          `json:"play_count"`
+	CreatedAt time.Time      `json:"created_at"`
+
+	Analysis *beatAnalysisResponse `json:"analysis,omitempty"`
+	// AssetTypes lists the types of the files attached to the beat, only getBeat fills it in
+	AssetTypes []string `json:"asset_types,omitempty"`
+	// Licenses lists the tiers the beat can be bought in, only getBeat fills it in
+	Licenses []licenseResponse `json:"licenses,omitempty"`
+}
+
+// beatAnalysisResponse holds tempo and key detected from the audio of a beat.
+// The mismatch flags are set when a confident detection disagrees with the entered values.
+type beatAnalysisResponse struct {
+	Bpm           float32 `json:"bpm"`
+	BpmConfidence float32 `json:"bpm_confidence"`
+	BpmMismatch   bool    `json:"bpm_mismatch"`
+	Key           string  `json:"key"`
+	KeyConfidence float32 `json:"key_confidence"`
+	KeyMismatch   bool    `json:"key_mismatch"`
+}
+
+// newBeatResponse converts beat, a failure to sign the preview or artwork URLs is attached
+// to the context and leaves the URL out rather than failing the whole request
+func (server *Server) newBeatResponse(ctx *gin.Context, beat db.Beat) beatResponse {
+	res := beatResponse{
+		ID:        beat.ID,
+		CreatorID: beat.CreatorID,
+		Title:     beat.Title,
+		Genre:     beat.Genre,
+		Key:       beat.Key,
+		Bpm:       beat.Bpm,
+		Tags:      beat.Tags,
+		PlayCount: beat.PlayCount,
+		CreatedAt: beat.CreatedAt,
+	}
+
+	if beat.PreviewKey != "" {
+		url, err := server.fileURL(ctx, beat.PreviewKey)
+		if err != nil {
+			_ = ctx.Error(err)
+		}
+		res.PreviewURL = url
+	}
+
+	if beat.ArtworkKey != "" {
+		res.Artwork = make(map[int]string, len(artwork.Sizes))
+		for _, size := range artwork.Sizes {
+			url, err := server.fileURL(ctx, artworkThumbnailKey(beat.ArtworkKey, size))
+			if err != nil {
+				_ = ctx.Error(err)
+				continue
+			}
+			res.Artwork[size] = url
+		}
+	}
+
+	if beat.DetectedBpm > 0 || beat.DetectedKey != "" {
+		res.Analysis = &beatAnalysisResponse{
+			Bpm:           beat.DetectedBpm,
+			BpmConfidence: beat.DetectedBpmConfidence,
+			BpmMismatch:   media.BpmMismatch(beat),
+			Key:           beat.DetectedKey,
+			KeyConfidence: beat.DetectedKeyConfidence,
+			KeyMismatch:   media.KeyMismatch(beat),
+		}
+	}
+	return res
+}
+
+func (server *Server) newBeatResponses(ctx *gin.Context, beats []db.Beat) []beatResponse {
+	res := make([]beatResponse, 0, len(beats))
+	for _, beat := range beats {
+		res = append(res, server.newBeatResponse(ctx, beat))
+	}
+	return res
+}
+
 type createBeatRequestParams struct {
-	CreatorID int32  `json:"creator_id" binding:"required,min=1"`
-	Title     string `json:"title"      binding:"required"`
-	Genre     string `json:"genre"      binding:"required"`
-	Key       string `json:"key"        binding:"required"`
-	Bpm       int16  `json:"bpm"        binding:"required"`
-	Tags      string `json:"tags"       binding:"required"`
+	Title string `json:"title" binding:"required"`
+	Genre string `json:"genre" binding:"required"`
+	Key   string `json:"key"   binding:"required"`
+	Bpm   int16  `json:"bpm"   binding:"required"`
+	Tags  string `json:"tags"  binding:"required"`
 }
 
+// createBeat stores the metadata of a new beat,
+// the audio is uploaded separately with uploadBeatAudio
 func (server *Server) createBeat(ctx *gin.Context) {
 	var req createBeatRequestParams
 	if err := ctx.ShouldBindJSON(&req); err != nil {
@@ -25,23 +122,21 @@ func (server *Server) createBeat(ctx *gin.Context) {
 		return
 	}
 
-	s3Key := "not implemented"
-
+	authPayload := getAuthPayload(ctx)
 	arg := db.CreateBeatParams{
-		CreatorID: req.CreatorID,
+		CreatorID: authPayload.UserID,
 		Title:     req.Title,
 		Genre:     req.Genre,
 		Key:       req.Key,
 		Bpm:       req.Bpm,
 		Tags:      req.Tags,
-		S3Key:     s3Key,
 	}
 	beat, err := server.store.CreateBeat(ctx, arg)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
-	ctx.JSON(http.StatusOK, beat)
+	ctx.JSON(http.StatusOK, server.newBeatResponse(ctx, beat))
 }
 
 type updateBeatRequestUri struct {
@@ -68,7 +163,20 @@ func (server *Server) updateBeat(ctx *gin.Context) {
 		return
 	}
 
-	s3Key := "not implemented"
+	beat, err := server.store.GetBeatById(ctx, bid.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if err := policy.CanModifyBeat(getAuthSubject(ctx), beat); err != nil {
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
 
 	arg := db.UpdateBeatParams{
 		ID:    bid.ID,
@@ -77,15 +185,14 @@ func (server *Server) updateBeat(ctx *gin.Context) {
 		Key:   req.Key,
 		Bpm:   req.Bpm,
 		Tags:  req.Tags,
-		S3Key: s3Key,
 	}
 
-	beat, err := server.store.UpdateBeat(ctx, arg)
+	beat, err = server.store.UpdateBeat(ctx, arg)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
-	ctx.JSON(http.StatusOK, beat)
+	ctx.JSON(http.StatusOK, server.newBeatResponse(ctx, beat))
 }
 
 type getBeatByIdRequest struct {
@@ -107,7 +214,27 @@ func (server *Server) getBeat(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
-	ctx.JSON(http.StatusOK, beat)
+
+	files, err := server.store.ListBeatFiles(ctx, beat.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	licenses, err := server.store.ListLicensesByBeat(ctx, beat.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	res := server.newBeatResponse(ctx, beat)
+	for _, file := range files {
+		res.AssetTypes = append(res.AssetTypes, file.FileType)
+	}
+	if len(licenses) > 0 {
+		res.Licenses = newLicenseResponses(licenses)
+	}
+	ctx.JSON(http.StatusOK, res)
 }
 
 type listBeatsByIdRequest struct {
@@ -158,7 +285,7 @@ func (server *Server) listBeatsById(ctx *gin.Context) {
 			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 			return
 		}
-		ctx.JSON(http.StatusOK, beats)
+		ctx.JSON(http.StatusOK, server.newBeatResponses(ctx, beats))
 	case "BPM":
 		arg := db.ListBeatsByBpmRangeParams{
 			Bpm:    req.BpmMin,
@@ -171,7 +298,7 @@ func (server *Server) listBeatsById(ctx *gin.Context) {
 			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 			return
 		}
-		ctx.JSON(http.StatusOK, beats)
+		ctx.JSON(http.StatusOK, server.newBeatResponses(ctx, beats))
 	case "KEY":
 		arg := db.ListBeatsByKeyParams{
 			Key:    req.Key,
@@ -183,7 +310,7 @@ func (server *Server) listBeatsById(ctx *gin.Context) {
 			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 			return
 		}
-		ctx.JSON(http.StatusOK, beats)
+		ctx.JSON(http.StatusOK, server.newBeatResponses(ctx, beats))
 	case "GENRE":
 		arg := db.ListBeatsByGenreParams{
 			Genre:  req.Genre,
@@ -195,7 +322,7 @@ func (server *Server) listBeatsById(ctx *gin.Context) {
 			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 			return
 		}
-		ctx.JSON(http.StatusOK, beats)
+		ctx.JSON(http.StatusOK, server.newBeatResponses(ctx, beats))
 	}
 }
 
@@ -257,7 +384,7 @@ func (server *Server) listBeatsByCreatorId(ctx *gin.Context) {
 			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 			return
 		}
-		ctx.JSON(http.StatusOK, beats)
+		ctx.JSON(http.StatusOK, server.newBeatResponses(ctx, beats))
 	case "BPM":
 		arg := db.ListBeatsByCreatorIdAndBpmRangeParams{
 			CreatorID: uid.ID,
@@ -271,7 +398,7 @@ func (server *Server) listBeatsByCreatorId(ctx *gin.Context) {
 			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 			return
 		}
-		ctx.JSON(http.StatusOK, beats)
+		ctx.JSON(http.StatusOK, server.newBeatResponses(ctx, beats))
 	case "KEY":
 		arg := db.ListBeatsByCreatorIdAndKeyParams{
 			CreatorID: uid.ID,
@@ -284,7 +411,7 @@ func (server *Server) listBeatsByCreatorId(ctx *gin.Context) {
 			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 			return
 		}
-		ctx.JSON(http.StatusOK, beats)
+		ctx.JSON(http.StatusOK, server.newBeatResponses(ctx, beats))
 	case "GENRE":
 		arg := db.ListBeatsByCreatorIdAndGenreParams{
 			CreatorID: uid.ID,
@@ -297,6 +424,6 @@ func (server *Server) listBeatsByCreatorId(ctx *gin.Context) {
 			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 			return
 		}
-		ctx.JSON(http.StatusOK, beats)
+		ctx.JSON(http.StatusOK, server.newBeatResponses(ctx, beats))
 	}
 }
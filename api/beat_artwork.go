@@ -0,0 +1,142 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"image"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/danglebary/beatstore-backend-go/artwork"
+	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
+	"github.com/danglebary/beatstore-backend-go/policy"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// beatArtworkKey returns a new key prefix for the artwork thumbnails of a beat,
+// every upload gets its own so cached thumbnails of replaced artwork are never served for the new one
+func beatArtworkKey(beatID int32) string {
+	return fmt.Sprintf("beats/%d/artwork/%s", beatID, uuid.New())
+}
+
+// artworkThumbnailKey returns the key of the thumbnail of the given size below artworkKey
+func artworkThumbnailKey(artworkKey string, size int) string {
+	return fmt.Sprintf("%s/%d.jpg", artworkKey, size)
+}
+
+// storeArtwork stores the thumbnails of img below artworkKey, if one fails the stored ones are deleted again
+func (server *Server) storeArtwork(ctx *gin.Context, artworkKey string, img image.Image) error {
+	for i, thumbnail := range artwork.Thumbnails(img) {
+		var buf bytes.Buffer
+		if err := artwork.Encode(&buf, thumbnail); err != nil {
+			server.deleteArtwork(ctx, artworkKey)
+			return err
+		}
+
+		key := artworkThumbnailKey(artworkKey, artwork.Sizes[i])
+		if err := server.blobs.Put(ctx, key, &buf, int64(buf.Len()), artwork.ContentType); err != nil {
+			server.deleteArtwork(ctx, artworkKey)
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteArtwork deletes the thumbnails stored below artworkKey, failures only leave orphaned objects
+func (server *Server) deleteArtwork(ctx *gin.Context, artworkKey string) {
+	for _, size := range artwork.Sizes {
+		if err := server.blobs.Delete(ctx, artworkThumbnailKey(artworkKey, size)); err != nil {
+			_ = ctx.Error(err)
+		}
+	}
+}
+
+type uploadBeatArtworkRequest struct {
+	ID int32 `uri:"id" binding:"required,min=1"`
+}
+
+// uploadBeatArtwork accepts a JPEG or PNG cover image as multipart form data and stores square thumbnails
+// of it in every size of artwork.Sizes. Only the thumbnails are kept, re-encoding them drops the metadata
+// of the uploaded file. The thumbnails of the replaced artwork are deleted.
+func (server *Server) uploadBeatArtwork(ctx *gin.Context) {
+	var req uploadBeatArtworkRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	beat, err := server.store.GetBeatById(ctx, req.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if err := policy.CanModifyBeat(getAuthSubject(ctx), beat); err != nil {
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+
+	header, ok := formFile(ctx, server.config.MaxArtworkUploadSize, "artwork")
+	if !ok {
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	defer file.Close()
+
+	data, err := ioutil.ReadAll(file)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	img, err := artwork.Decode(data)
+	if err != nil {
+		switch {
+		case errors.Is(err, artwork.ErrUnsupportedFormat):
+			ctx.JSON(http.StatusUnsupportedMediaType, errorResponse(err))
+		case errors.Is(err, artwork.ErrInvalidDimensions):
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		default:
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		}
+		return
+	}
+
+	artworkKey := beatArtworkKey(beat.ID)
+	if err := server.storeArtwork(ctx, artworkKey, img); err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	updated, err := server.store.UpdateBeatArtworkKey(ctx, db.UpdateBeatArtworkKeyParams{
+		ID:         beat.ID,
+		ArtworkKey: artworkKey,
+	})
+	if err != nil {
+		// Don't leave thumbnails behind which no beat points at
+		server.deleteArtwork(ctx, artworkKey)
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if beat.ArtworkKey != "" {
+		server.deleteArtwork(ctx, beat.ArtworkKey)
+	}
+	ctx.JSON(http.StatusOK, server.newBeatResponse(ctx, updated))
+}
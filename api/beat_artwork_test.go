@@ -0,0 +1,314 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/danglebary/beatstore-backend-go/artwork"
+	mockdb "github.com/danglebary/beatstore-backend-go/db/mock"
+	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
+	"github.com/danglebary/beatstore-backend-go/storage"
+	"github.com/danglebary/beatstore-backend-go/token"
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+// testImage returns a w by h gradient encoded as PNG or JPEG
+func testImage(t *testing.T, format string, w, h int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	if format == "png" {
+		require.NoError(t, png.Encode(&buf, img))
+	} else {
+		require.NoError(t, jpeg.Encode(&buf, img, nil))
+	}
+	return buf.Bytes()
+}
+
+// requireArtwork checks that a JPEG thumbnail of every size is stored below artworkKey
+func requireArtwork(t *testing.T, blobs storage.BlobStore, artworkKey string) {
+	for _, size := range artwork.Sizes {
+		r, err := blobs.Get(context.Background(), artworkThumbnailKey(artworkKey, size))
+		require.NoError(t, err)
+
+		thumbnail, err := jpeg.Decode(r)
+		require.NoError(t, r.Close())
+		require.NoError(t, err)
+		require.Equal(t, image.Rect(0, 0, size, size), thumbnail.Bounds())
+	}
+}
+
+// requireNoArtwork checks that no thumbnail is stored below artworkKey
+func requireNoArtwork(t *testing.T, blobs storage.BlobStore, artworkKey string) {
+	objects, err := blobs.List(context.Background(), artworkKey+"/")
+	require.NoError(t, err)
+	require.Empty(t, objects)
+}
+
+func TestUploadBeatArtwork(t *testing.T) {
+	beat := randomBeat()
+	beat.ArtworkKey = ""
+	withArtwork := randomBeat()
+	oldArtwork := withArtwork.ArtworkKey
+	pngData := testImage(t, "png", 800, 600)
+
+	// artworkKey is set by the UpdateBeatArtworkKey stubs
+	var artworkKey string
+	updateArtwork := func(store *mockdb.MockStore, beat db.Beat) {
+		store.EXPECT().
+			UpdateBeatArtworkKey(gomock.Any(), gomock.Any()).
+			Times(1).
+			DoAndReturn(func(_ context.Context, arg db.UpdateBeatArtworkKeyParams) (db.Beat, error) {
+				require.Equal(t, beat.ID, arg.ID)
+				artworkKey = arg.ArtworkKey
+				updated := beat
+				updated.ArtworkKey = arg.ArtworkKey
+				return updated, nil
+			})
+	}
+
+	testCases := []struct {
+		name          string
+		beat          db.Beat
+		data          []byte
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore)
+	}{
+		{
+			name: "PNG",
+			beat: beat,
+			data: pngData,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(beat, nil)
+				updateArtwork(store, beat)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Regexp(t, fmt.Sprintf(`^beats/%d/artwork/[0-9a-f-]{36}$`, beat.ID), artworkKey)
+				requireArtwork(t, blobs, artworkKey)
+
+				updated := beat
+				updated.ArtworkKey = artworkKey
+				var got beatResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				requireBeatResponse(t, updated, got)
+			},
+		},
+		{
+			name: "ReplaceJPEG",
+			beat: withArtwork,
+			data: testImage(t, "jpeg", 600, 900),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, withArtwork.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(withArtwork.ID)).
+					Times(1).
+					Return(withArtwork, nil)
+				updateArtwork(store, withArtwork)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireArtwork(t, blobs, artworkKey)
+
+				// The thumbnails of the replaced artwork are deleted
+				requireNoArtwork(t, blobs, oldArtwork)
+			},
+		},
+		{
+			name: "NotAnImage",
+			beat: beat,
+			data: randomWAV(1024),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(beat, nil)
+				store.EXPECT().
+					UpdateBeatArtworkKey(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusUnsupportedMediaType, recorder.Code)
+			},
+		},
+		{
+			name: "TooSmall",
+			beat: beat,
+			data: testImage(t, "png", 600, 400),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(beat, nil)
+				store.EXPECT().
+					UpdateBeatArtworkKey(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "TooLarge",
+			beat: beat,
+			data: append(pngData, make([]byte, 1<<20)...),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(beat, nil)
+				store.EXPECT().
+					UpdateBeatArtworkKey(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+			},
+		},
+		{
+			name: "NotFound",
+			beat: beat,
+			data: pngData,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(db.Beat{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "Forbidden",
+			beat: beat,
+			data: pngData,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID+1, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(beat, nil)
+				store.EXPECT().
+					UpdateBeatArtworkKey(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "NoAuthorization",
+			beat: beat,
+			data: pngData,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			beat: withArtwork,
+			data: pngData,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, withArtwork.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(withArtwork.ID)).
+					Times(1).
+					Return(withArtwork, nil)
+				store.EXPECT().
+					UpdateBeatArtworkKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.UpdateBeatArtworkKeyParams) (db.Beat, error) {
+						artworkKey = arg.ArtworkKey
+						return db.Beat{}, sql.ErrConnDone
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+
+				// The new thumbnails are removed again and the old ones are kept
+				requireNoArtwork(t, blobs, artworkKey)
+				requireArtwork(t, blobs, oldArtwork)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			artworkKey = ""
+			// Init controller and store
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			// Build stub
+			tc.buildStubs(store)
+			stubVerifiedUsers(store)
+			// Start test server with the current artwork stored, build request, and send
+			server := newTestServer(t, store)
+			img, err := artwork.Decode(pngData)
+			require.NoError(t, err)
+			require.NoError(t, server.storeArtwork(&gin.Context{}, oldArtwork, img))
+			recorder := httptest.NewRecorder()
+			url := fmt.Sprintf("/beats/%d/artwork", tc.beat.ID)
+			request := newUploadRequest(t, url, uploadFormField, "cover.img", tc.data)
+			tc.setupAuth(t, request, server.tokenMaker)
+			// Server http response
+			server.router.ServeHTTP(recorder, request)
+			// check response
+			tc.checkResponse(t, recorder, server.blobs)
+		})
+	}
+}
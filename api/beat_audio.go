@@ -0,0 +1,176 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
+	"github.com/danglebary/beatstore-backend-go/jobs"
+	"github.com/danglebary/beatstore-backend-go/policy"
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// errDuplicateAudio is returned for audio identical to audio another creator uploaded first
+var errDuplicateAudio = errors.New("this audio was already uploaded by another creator")
+
+type uploadBeatAudioRequest struct {
+	ID int32 `uri:"id" binding:"required,min=1"`
+}
+
+// beatAudioKey returns a new object key for audio of a beat, every upload gets its own key
+// so caches and signed URLs of a replaced file never serve the new one
+func beatAudioKey(beatID int32, format string) string {
+	return fmt.Sprintf("beats/%d/audio/%s.%s", beatID, uuid.New(), format)
+}
+
+// uploadBeatAudio accepts a WAV or MP3 file as multipart form data and stores it in the blob store.
+// The file is attached as master WAV or MP3 and replaces the previous file of its type, a beat can come with both.
+// A WAV master becomes the audio of the beat, an MP3 only for beats without one.
+// The tagged preview and the waveform of a WAV master are generated in the background.
+// MP3 files can't be decoded, so they get no preview, the untagged master is never streamed.
+// Audio another creator uploaded first is refused and put into the moderation queue.
+func (server *Server) uploadBeatAudio(ctx *gin.Context) {
+	var req uploadBeatAudioRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	beat, err := server.store.GetBeatById(ctx, req.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if err := policy.CanModifyBeat(getAuthSubject(ctx), beat); err != nil {
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+
+	header, ok := formFile(ctx, server.config.MaxAudioUploadSize, "audio file")
+	if !ok {
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	defer file.Close()
+
+	stored, err := server.storeBeatUpload(ctx, beat.ID, util.AudioUpload, header.Filename, file, header.Size)
+	if err != nil {
+		if unsupportedFormat(err) {
+			ctx.JSON(http.StatusUnsupportedMediaType, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	result, err := server.attachBeatAudio(ctx, beat, stored)
+	if err != nil {
+		if err == errDuplicateAudio {
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, server.newBeatResponse(ctx, result.Beat))
+}
+
+// attachBeatAudio attaches audio stored by storeBeatUpload to beat and schedules the processing of a WAV master.
+// The objects of the replaced files are deleted, if attaching fails the new object is deleted instead.
+// errDuplicateAudio is returned for audio another creator uploaded first.
+func (server *Server) attachBeatAudio(ctx *gin.Context, beat db.Beat, file db.UpsertBeatFileParams) (db.UpdateBeatAudioTxResult, error) {
+	if err := server.checkDuplicateAudio(ctx, beat, file); err != nil {
+		// Refused audio is not kept
+		if err := server.blobs.Delete(ctx, file.S3Key); err != nil {
+			_ = ctx.Error(err)
+		}
+		return db.UpdateBeatAudioTxResult{}, err
+	}
+
+	result, err := server.store.UpdateBeatAudioTx(ctx, db.UpdateBeatAudioTxParams{
+		BeatID: beat.ID,
+		File:   file,
+	})
+	if err != nil {
+		// Don't leave an object behind which no beat points at
+		if err := server.blobs.Delete(ctx, file.S3Key); err != nil {
+			_ = ctx.Error(err)
+		}
+		return db.UpdateBeatAudioTxResult{}, err
+	}
+
+	key := file.S3Key
+
+	// Until the jobs have run the beat has no preview, waveform, analysis or fingerprint,
+	// a failure to schedule them doesn't undo the upload
+	if file.FileType == util.MasterWAVFile {
+		for _, job := range []jobs.Job{
+			server.media.PreviewJob(beat.ID, key),
+			server.media.WaveformJob(beat.ID, key),
+			server.media.AnalysisJob(beat.ID, key),
+			server.media.FingerprintJob(beat.ID, key),
+		} {
+			if err := server.jobs.Enqueue(job); err != nil {
+				_ = ctx.Error(fmt.Errorf("cannot schedule %s: %w", job.Name, err))
+			}
+		}
+	}
+
+	// A failed cleanup only leaves an orphaned object, the upload itself succeeded.
+	// The previous audio of the beat is one of the removed files unless it is kept next to the new one.
+	for _, old := range result.RemovedFiles {
+		if old.S3Key == key {
+			continue
+		}
+		if err := server.blobs.Delete(ctx, old.S3Key); err != nil {
+			_ = ctx.Error(err)
+		}
+	}
+	return result, nil
+}
+
+// checkDuplicateAudio returns errDuplicateAudio if the content of file was first uploaded to a beat
+// of another creator, the match is recorded for the admins. Uploading the same content again to beats
+// of the creator who uploaded it first is fine, audio which merely sounds the same is flagged later
+// by the fingerprint job. MP3 masters can't be decoded and get no fingerprint, only their exact content is checked.
+func (server *Server) checkDuplicateAudio(ctx *gin.Context, beat db.Beat, file db.UpsertBeatFileParams) error {
+	first, err := server.store.GetFirstAudioByContentHash(ctx, file.Checksum)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return err
+	}
+	if first.CreatorID == beat.CreatorID {
+		return nil
+	}
+
+	_, err = server.store.CreateDuplicateMatch(ctx, db.CreateDuplicateMatchParams{
+		BeatID:        beat.ID,
+		MatchedBeatID: first.BeatID,
+		Kind:          util.ContentHashMatch,
+		Rejected:      true,
+	})
+	if err != nil {
+		return err
+	}
+	return errDuplicateAudio
+}
@@ -0,0 +1,553 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danglebary/beatstore-backend-go/audio"
+	mockdb "github.com/danglebary/beatstore-backend-go/db/mock"
+	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
+	"github.com/danglebary/beatstore-backend-go/storage"
+	"github.com/danglebary/beatstore-backend-go/token"
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+// randomWAV returns the RIFF header of a WAV file followed by n random bytes
+func randomWAV(n int) []byte {
+	return append([]byte("RIFF\x24\x08\x00\x00WAVEfmt "), []byte(util.RandomString(n))...)
+}
+
+// toneWAV returns a WAV file which decodes, playing a tone for d
+func toneWAV(t *testing.T, d time.Duration) []byte {
+	var file bytes.Buffer
+	require.NoError(t, audio.EncodeWAV(&file, audio.Tone(8000, 2, 440, d, 0.5)))
+	return file.Bytes()
+}
+
+// randomMP3 returns an ID3v2 tag followed by n random bytes
+func randomMP3(n int) []byte {
+	return append([]byte("ID3\x04\x00\x00\x00\x00\x00\x00"), []byte(util.RandomString(n))...)
+}
+
+func newAudioUploadRequest(t *testing.T, url string, field string, data []byte) *http.Request {
+	return newUploadRequest(t, url, field, "beat.bin", data)
+}
+
+// newUploadRequest posts data as file named filename in the multipart form field, no file is sent without a field
+func newUploadRequest(t *testing.T, url string, field string, filename string, data []byte) *http.Request {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	if field != "" {
+		part, err := writer.CreateFormFile(field, filename)
+		require.NoError(t, err)
+		_, err = part.Write(data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+
+	request, err := http.NewRequest(http.MethodPost, url, body)
+	require.NoError(t, err)
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	return request
+}
+
+func requireBlob(t *testing.T, blobs storage.BlobStore, key string, data []byte) {
+	r, err := blobs.Get(context.Background(), key)
+	require.NoError(t, err)
+	defer r.Close()
+
+	got, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, data, got)
+}
+
+func TestUploadBeatAudio(t *testing.T) {
+	beat := randomBeat()
+	wav := toneWAV(t, 3*time.Second)
+	mp3 := randomMP3(1024)
+	oldAudio := randomWAV(16)
+	oldPreview := randomWAV(16)
+	oldMP3 := randomMP3(16)
+
+	// The beat comes with a master WAV, its tagged preview and an MP3
+	oldMP3File := db.BeatFile{BeatID: beat.ID, FileType: util.MP3File, S3Key: util.RandomS3Key()}
+
+	// uploadedKey and uploadedFile are set by the UpdateBeatAudioTx stubs,
+	// generatedPreviewKey by the UpdateBeatPreviewTx stubs of the preview job
+	var uploadedKey, generatedPreviewKey string
+	var uploadedFile db.UpsertBeatFileParams
+	// waveformDuration is set by the UpsertBeatWaveform stub of the waveform job,
+	// analyzedKey by the UpdateBeatAnalysis stub of the analysis job
+	var waveformDuration int64
+	var analyzedKey, fingerprintedKey string
+	// firstAudio stubs the lookup of the beat the content of data was first uploaded to
+	firstAudio := func(store *mockdb.MockStore, data []byte, first db.GetFirstAudioByContentHashRow, err error) {
+		checksum := sha256.Sum256(data)
+		store.EXPECT().
+			GetFirstAudioByContentHash(gomock.Any(), gomock.Eq(hex.EncodeToString(checksum[:]))).
+			Times(1).
+			Return(first, err)
+	}
+	updateAudio := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			UpdateBeatAudioTx(gomock.Any(), gomock.Any()).
+			Times(1).
+			DoAndReturn(func(_ context.Context, arg db.UpdateBeatAudioTxParams) (db.UpdateBeatAudioTxResult, error) {
+				uploadedKey = arg.File.S3Key
+				uploadedFile = arg.File
+				file := db.BeatFile{BeatID: beat.ID, FileType: arg.File.FileType, S3Key: arg.File.S3Key}
+				if arg.File.FileType == util.MP3File {
+					// Next to the master WAV an MP3 only replaces the previous MP3
+					return db.UpdateBeatAudioTxResult{
+						Beat:         beat,
+						File:         file,
+						RemovedFiles: []db.BeatFile{oldMP3File},
+					}, nil
+				}
+				updated := beat
+				updated.S3Key = arg.File.S3Key
+				updated.PreviewKey = ""
+				// New audio resets the analysis
+				updated.DetectedBpm = 0
+				updated.DetectedBpmConfidence = 0
+				updated.DetectedKey = ""
+				updated.DetectedKeyConfidence = 0
+				return db.UpdateBeatAudioTxResult{
+					Beat: updated,
+					File: file,
+					RemovedFiles: []db.BeatFile{
+						{BeatID: beat.ID, FileType: util.MasterWAVFile, S3Key: beat.S3Key},
+						{BeatID: beat.ID, FileType: util.TaggedPreviewFile, S3Key: beat.PreviewKey},
+					},
+				}, nil
+			})
+	}
+	updatePreview := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			UpdateBeatPreviewTx(gomock.Any(), gomock.Any()).
+			Times(1).
+			DoAndReturn(func(_ context.Context, arg db.UpdateBeatPreviewTxParams) (db.UpdateBeatPreviewTxResult, error) {
+				if arg.BeatID != beat.ID || arg.S3Key != uploadedKey {
+					return db.UpdateBeatPreviewTxResult{}, sql.ErrNoRows
+				}
+				generatedPreviewKey = arg.PreviewKey
+				return db.UpdateBeatPreviewTxResult{Beat: beat}, nil
+			})
+	}
+
+	testCases := []struct {
+		name          string
+		beatID        int32
+		field         string
+		data          []byte
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore)
+	}{
+		{
+			name:   "WAV",
+			beatID: beat.ID,
+			field:  uploadFormField,
+			data:   wav,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(beat, nil)
+				firstAudio(store, wav, db.GetFirstAudioByContentHashRow{}, sql.ErrNoRows)
+				updateAudio(store)
+				updatePreview(store)
+				store.EXPECT().
+					UpsertBeatWaveform(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.UpsertBeatWaveformParams) (db.BeatWaveform, error) {
+						if arg.BeatID != beat.ID || arg.S3Key != uploadedKey {
+							return db.BeatWaveform{}, sql.ErrNoRows
+						}
+						waveformDuration = arg.DurationMs
+						return db.BeatWaveform{}, nil
+					})
+				store.EXPECT().
+					UpdateBeatAnalysis(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.UpdateBeatAnalysisParams) (db.Beat, error) {
+						if arg.ID != beat.ID || arg.S3Key != uploadedKey {
+							return db.Beat{}, sql.ErrNoRows
+						}
+						analyzedKey = arg.S3Key
+						return db.Beat{}, nil
+					})
+				store.EXPECT().
+					UpdateAudioFingerprint(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.UpdateAudioFingerprintParams) (db.AudioFingerprint, error) {
+						if arg.BeatID != beat.ID || arg.S3Key != uploadedKey {
+							return db.AudioFingerprint{}, sql.ErrNoRows
+						}
+						fingerprintedKey = arg.S3Key
+						return db.AudioFingerprint{}, nil
+					})
+				// A steady tone has no sub-fingerprints to look up
+				store.EXPECT().
+					ListFingerprintCandidates(gomock.Any(), gomock.Any()).
+					AnyTimes().
+					Return([]db.ListFingerprintCandidatesRow{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Regexp(t, fmt.Sprintf(`^beats/%d/audio/[0-9a-f-]{36}\.wav$`, beat.ID), uploadedKey)
+
+				// The master is never served, the preview doesn't exist yet
+				var gotBeat beatResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &gotBeat)
+				require.NoError(t, err)
+				require.Empty(t, gotBeat.PreviewURL)
+				require.Nil(t, gotBeat.Analysis)
+				require.NotContains(t, recorder.Body.String(), uploadedKey)
+
+				requireBlob(t, blobs, uploadedKey, wav)
+
+				// The master is attached with its size and checksum
+				checksum := sha256.Sum256(wav)
+				require.Equal(t, util.MasterWAVFile, uploadedFile.FileType)
+				require.Equal(t, uploadedKey, uploadedFile.S3Key)
+				require.Equal(t, audio.ContentTypeWAV, uploadedFile.ContentType)
+				require.Equal(t, int64(len(wav)), uploadedFile.Size)
+				require.Equal(t, hex.EncodeToString(checksum[:]), uploadedFile.Checksum)
+
+				// The job stored a preview of the master
+				require.Regexp(t, fmt.Sprintf(`^beats/%d/preview/[0-9a-f-]{36}\.wav$`, beat.ID), generatedPreviewKey)
+				info, err := blobs.Stat(context.Background(), generatedPreviewKey)
+				require.NoError(t, err)
+				require.Equal(t, audio.ContentTypeWAV, info.ContentType)
+
+				// The job computed the waveform of the whole master
+				require.Equal(t, int64(3000), waveformDuration)
+
+				// The job analysed the master
+				require.Equal(t, uploadedKey, analyzedKey)
+
+				// The job fingerprinted the master
+				require.Equal(t, uploadedKey, fingerprintedKey)
+
+				// The replaced master and its preview are deleted, the MP3 is kept
+				_, err = blobs.Get(context.Background(), beat.S3Key)
+				require.ErrorIs(t, err, storage.ErrNotFound)
+				_, err = blobs.Get(context.Background(), beat.PreviewKey)
+				require.ErrorIs(t, err, storage.ErrNotFound)
+				requireBlob(t, blobs, oldMP3File.S3Key, oldMP3)
+			},
+		},
+		{
+			name:   "MP3",
+			beatID: beat.ID,
+			field:  uploadFormField,
+			data:   mp3,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(beat, nil)
+				firstAudio(store, mp3, db.GetFirstAudioByContentHashRow{}, sql.ErrNoRows)
+				updateAudio(store)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.True(t, strings.HasSuffix(uploadedKey, ".mp3"))
+				requireBlob(t, blobs, uploadedKey, mp3)
+
+				// The untagged MP3 is not published as preview
+				require.Equal(t, util.MP3File, uploadedFile.FileType)
+				require.Equal(t, audio.ContentTypeMP3, uploadedFile.ContentType)
+				require.NotContains(t, recorder.Body.String(), uploadedKey)
+
+				// Only the previous MP3 is replaced, the master WAV and its preview are kept
+				_, err := blobs.Get(context.Background(), oldMP3File.S3Key)
+				require.ErrorIs(t, err, storage.ErrNotFound)
+				requireBlob(t, blobs, beat.S3Key, oldAudio)
+				requireBlob(t, blobs, beat.PreviewKey, oldPreview)
+			},
+		},
+		{
+			name:   "ReuploadOwnAudio",
+			beatID: beat.ID,
+			field:  uploadFormField,
+			data:   mp3,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(beat, nil)
+				firstAudio(store, mp3, db.GetFirstAudioByContentHashRow{BeatID: beat.ID + 1, CreatorID: beat.CreatorID}, nil)
+				store.EXPECT().
+					CreateDuplicateMatch(gomock.Any(), gomock.Any()).
+					Times(0)
+				updateAudio(store)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBlob(t, blobs, uploadedKey, mp3)
+			},
+		},
+		{
+			name:   "DuplicateOfOtherCreator",
+			beatID: beat.ID,
+			field:  uploadFormField,
+			data:   mp3,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(beat, nil)
+				firstAudio(store, mp3, db.GetFirstAudioByContentHashRow{BeatID: beat.ID + 1, CreatorID: beat.CreatorID + 1}, nil)
+				store.EXPECT().
+					CreateDuplicateMatch(gomock.Any(), gomock.Eq(db.CreateDuplicateMatchParams{
+						BeatID:        beat.ID,
+						MatchedBeatID: beat.ID + 1,
+						Kind:          util.ContentHashMatch,
+						Rejected:      true,
+					})).
+					Times(1).
+					Return(db.DuplicateMatch{}, nil)
+				store.EXPECT().
+					UpdateBeatAudioTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+
+				// The refused file is removed again and the old one is kept
+				objects, err := blobs.List(context.Background(), fmt.Sprintf("beats/%d/audio/", beat.ID))
+				require.NoError(t, err)
+				require.Empty(t, objects)
+				requireBlob(t, blobs, beat.S3Key, oldAudio)
+			},
+		},
+		{
+			name:   "UnsupportedFormat",
+			beatID: beat.ID,
+			field:  uploadFormField,
+			data:   []byte("\x89PNG\r\n\x1a\n" + util.RandomString(64)),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(beat, nil)
+				store.EXPECT().
+					UpdateBeatAudioTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusUnsupportedMediaType, recorder.Code)
+				requireBlob(t, blobs, beat.S3Key, oldAudio)
+			},
+		},
+		{
+			name:   "TooLarge",
+			beatID: beat.ID,
+			field:  uploadFormField,
+			data:   randomWAV(1 << 20),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(beat, nil)
+				store.EXPECT().
+					UpdateBeatAudioTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+			},
+		},
+		{
+			name:   "MissingFile",
+			beatID: beat.ID,
+			field:  "",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(beat, nil)
+				store.EXPECT().
+					UpdateBeatAudioTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:   "NotFound",
+			beatID: beat.ID,
+			field:  uploadFormField,
+			data:   wav,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(db.Beat{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:   "Forbidden",
+			beatID: beat.ID,
+			field:  uploadFormField,
+			data:   wav,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID+1, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(beat, nil)
+				store.EXPECT().
+					UpdateBeatAudioTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:   "NoAuthorization",
+			beatID: beat.ID,
+			field:  uploadFormField,
+			data:   wav,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:   "InvalidID",
+			beatID: 0,
+			field:  uploadFormField,
+			data:   wav,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:   "InternalError",
+			beatID: beat.ID,
+			field:  uploadFormField,
+			data:   wav,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(beat, nil)
+				firstAudio(store, wav, db.GetFirstAudioByContentHashRow{}, sql.ErrNoRows)
+				store.EXPECT().
+					UpdateBeatAudioTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.UpdateBeatAudioTxParams) (db.UpdateBeatAudioTxResult, error) {
+						uploadedKey = arg.File.S3Key
+						return db.UpdateBeatAudioTxResult{}, sql.ErrConnDone
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+
+				// The new file is removed again and the old one is kept
+				_, err := blobs.Get(context.Background(), uploadedKey)
+				require.ErrorIs(t, err, storage.ErrNotFound)
+				requireBlob(t, blobs, beat.S3Key, oldAudio)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			uploadedKey, generatedPreviewKey = "", ""
+			uploadedFile = db.UpsertBeatFileParams{}
+			waveformDuration, analyzedKey, fingerprintedKey = 0, "", ""
+			// Init controller and store
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			// Build stub
+			tc.buildStubs(store)
+			stubVerifiedUsers(store)
+			// Start test server with the current audio of the beat stored, build request, and send
+			server := newTestServer(t, store)
+			err := server.blobs.Put(context.Background(), beat.S3Key, bytes.NewReader(oldAudio), int64(len(oldAudio)), "audio/wav")
+			require.NoError(t, err)
+			err = server.blobs.Put(context.Background(), beat.PreviewKey, bytes.NewReader(oldPreview), int64(len(oldPreview)), "audio/wav")
+			require.NoError(t, err)
+			err = server.blobs.Put(context.Background(), oldMP3File.S3Key, bytes.NewReader(oldMP3), int64(len(oldMP3)), audio.ContentTypeMP3)
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+			url := fmt.Sprintf("/beats/%d/audio", tc.beatID)
+			request := newAudioUploadRequest(t, url, tc.field, tc.data)
+			tc.setupAuth(t, request, server.tokenMaker)
+			// Server http response
+			server.router.ServeHTTP(recorder, request)
+			server.jobs.Wait()
+			// check response
+			tc.checkResponse(t, recorder, server.blobs)
+		})
+	}
+}
@@ -0,0 +1,264 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
+	"github.com/danglebary/beatstore-backend-go/policy"
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Leading bytes of a ZIP archive, the second one is an archive without entries
+var zipSignatures = [][]byte{[]byte("PK\x03\x04"), []byte("PK\x05\x06")}
+
+// projectExtension matches the extensions of project files kept in their object key, like .flp or .als
+var projectExtension = regexp.MustCompile(`^\.[a-z0-9]{1,10}$`)
+
+var errStemsNotZIP = errors.New("stems must be uploaded as a zip archive")
+
+// beatFileResponse describes a file attached to a beat, its storage key is never exposed
+type beatFileResponse struct {
+	Type        string    `json:"type"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	Checksum    string    `json:"checksum"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func newBeatFileResponse(file db.BeatFile) beatFileResponse {
+	return beatFileResponse{
+		Type:        file.FileType,
+		ContentType: file.ContentType,
+		Size:        file.Size,
+		Checksum:    file.Checksum,
+		CreatedAt:   file.CreatedAt,
+	}
+}
+
+// beatFileKey returns a new object key for a file of a beat, every upload gets its own key
+func beatFileKey(beatID int32, fileType string, extension string) string {
+	return fmt.Sprintf("beats/%d/files/%s/%s%s", beatID, fileType, uuid.New(), extension)
+}
+
+// beatFileFormat checks the leading bytes of an uploaded file of the given type
+// and returns its content type and the extension of its object key
+func beatFileFormat(fileType string, filename string, head []byte) (contentType string, extension string, err error) {
+	if fileType == util.StemsFile {
+		for _, signature := range zipSignatures {
+			if bytes.HasPrefix(head, signature) {
+				return "application/zip", ".zip", nil
+			}
+		}
+		return "", "", errStemsNotZIP
+	}
+
+	// Every DAW has its own project format, they are stored as they are
+	extension = strings.ToLower(filepath.Ext(filename))
+	if !projectExtension.MatchString(extension) {
+		extension = ""
+	}
+	return http.DetectContentType(head), extension, nil
+}
+
+type uploadBeatFileRequestUri struct {
+	ID   int32  `uri:"id" binding:"required,min=1"`
+	Type string `uri:"type" binding:"required,oneof=stems_zip project_file mp3"`
+}
+
+// uploadBeatFile attaches stems, a project file or an MP3 to a beat, replacing the previous file of the type.
+// An MP3 is attached like one uploaded through uploadBeatAudio, next to the master WAV if the beat has one.
+// The master WAV and the tagged preview are managed through uploadBeatAudio and the preview job instead.
+func (server *Server) uploadBeatFile(ctx *gin.Context) {
+	var req uploadBeatFileRequestUri
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	beat, err := server.store.GetBeatById(ctx, req.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if err := policy.CanModifyBeat(getAuthSubject(ctx), beat); err != nil {
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+
+	header, ok := formFile(ctx, server.config.MaxFileUploadSize, "file")
+	if !ok {
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	defer file.Close()
+
+	stored, err := server.storeBeatUpload(ctx, beat.ID, req.Type, header.Filename, file, header.Size)
+	if err != nil {
+		if unsupportedFormat(err) {
+			ctx.JSON(http.StatusUnsupportedMediaType, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if stored.FileType == util.MP3File {
+		result, err := server.attachBeatAudio(ctx, beat, stored)
+		if err != nil {
+			if err == errDuplicateAudio {
+				ctx.JSON(http.StatusConflict, errorResponse(err))
+				return
+			}
+			if err == sql.ErrNoRows {
+				ctx.JSON(http.StatusNotFound, errorResponse(err))
+				return
+			}
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusOK, newBeatFileResponse(result.File))
+		return
+	}
+
+	attached, err := server.attachBeatFile(ctx, stored)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, newBeatFileResponse(attached))
+}
+
+// attachBeatFile attaches a file stored by storeBeatUpload to its beat and deletes the object of the file
+// it replaces. If attaching fails the new object is deleted instead.
+func (server *Server) attachBeatFile(ctx *gin.Context, file db.UpsertBeatFileParams) (db.BeatFile, error) {
+	old, err := server.store.GetBeatFile(ctx, db.GetBeatFileParams{
+		BeatID:   file.BeatID,
+		FileType: file.FileType,
+	})
+	if err != nil && err != sql.ErrNoRows {
+		if err := server.blobs.Delete(ctx, file.S3Key); err != nil {
+			_ = ctx.Error(err)
+		}
+		return db.BeatFile{}, err
+	}
+
+	attached, err := server.store.UpsertBeatFile(ctx, file)
+	if err != nil {
+		// Don't leave an object behind which no file points at
+		if err := server.blobs.Delete(ctx, file.S3Key); err != nil {
+			_ = ctx.Error(err)
+		}
+		return db.BeatFile{}, err
+	}
+
+	// A failed cleanup only leaves an orphaned object, the upload itself succeeded
+	if old.S3Key != "" && old.S3Key != file.S3Key {
+		if err := server.blobs.Delete(ctx, old.S3Key); err != nil {
+			_ = ctx.Error(err)
+		}
+	}
+	return attached, nil
+}
+
+type listBeatFilesRequestUri struct {
+	ID int32 `uri:"id" binding:"required,min=1"`
+}
+
+// listBeatFiles lists the files attached to a beat. Anyone may see which files a beat comes with,
+// downloading them is up to the buyers.
+func (server *Server) listBeatFiles(ctx *gin.Context) {
+	var req listBeatFilesRequestUri
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if _, err := server.store.GetBeatById(ctx, req.ID); err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	files, err := server.store.ListBeatFiles(ctx, req.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	res := make([]beatFileResponse, 0, len(files))
+	for _, file := range files {
+		res = append(res, newBeatFileResponse(file))
+	}
+	ctx.JSON(http.StatusOK, res)
+}
+
+type deleteBeatFileRequestUri struct {
+	ID   int32  `uri:"id" binding:"required,min=1"`
+	Type string `uri:"type" binding:"required,oneof=stems_zip project_file"`
+}
+
+// deleteBeatFile detaches stems or a project file from a beat and deletes the object
+func (server *Server) deleteBeatFile(ctx *gin.Context) {
+	var req deleteBeatFileRequestUri
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	beat, err := server.store.GetBeatById(ctx, req.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if err := policy.CanModifyBeat(getAuthSubject(ctx), beat); err != nil {
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+
+	file, err := server.store.DeleteBeatFile(ctx, db.DeleteBeatFileParams{
+		BeatID:   beat.ID,
+		FileType: req.Type,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// The file is detached, a failed cleanup only leaves an orphaned object
+	if err := server.blobs.Delete(ctx, file.S3Key); err != nil {
+		_ = ctx.Error(err)
+	}
+	ctx.Status(http.StatusNoContent)
+}
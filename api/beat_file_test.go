@@ -0,0 +1,703 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danglebary/beatstore-backend-go/audio"
+	mockdb "github.com/danglebary/beatstore-backend-go/db/mock"
+	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
+	"github.com/danglebary/beatstore-backend-go/storage"
+	"github.com/danglebary/beatstore-backend-go/token"
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+// randomZIP returns the signature of a ZIP archive followed by n random bytes
+func randomZIP(n int) []byte {
+	return append([]byte("PK\x03\x04"), []byte(util.RandomString(n))...)
+}
+
+func randomBeatFile(beat db.Beat, fileType string) db.BeatFile {
+	return db.BeatFile{
+		BeatID:      beat.ID,
+		FileType:    fileType,
+		S3Key:       util.RandomS3Key(),
+		ContentType: "application/zip",
+		Size:        util.RandomInt(1, 1<<30),
+		Checksum:    util.RandomString(64),
+		CreatedAt:   time.Now().UTC().Truncate(time.Second),
+	}
+}
+
+func requireBeatFileResponse(t *testing.T, file db.BeatFile, got beatFileResponse) {
+	require.Equal(t, file.FileType, got.Type)
+	require.Equal(t, file.ContentType, got.ContentType)
+	require.Equal(t, file.Size, got.Size)
+	require.Equal(t, file.Checksum, got.Checksum)
+	require.Equal(t, file.CreatedAt, got.CreatedAt)
+}
+
+func TestUploadBeatFile(t *testing.T) {
+	beat := randomBeat()
+	stems := randomZIP(1024)
+	project := append([]byte("FLhd\x06\x00\x00\x00\x00\x00\x01\x00"), []byte(util.RandomString(256))...)
+	mp3 := randomMP3(1024)
+	oldStems := randomBeatFile(beat, util.StemsFile)
+
+	// uploaded is set by the UpsertBeatFile and UpdateBeatAudioTx stubs
+	var uploaded db.UpsertBeatFileParams
+	upsertFile := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			UpsertBeatFile(gomock.Any(), gomock.Any()).
+			Times(1).
+			DoAndReturn(func(_ context.Context, arg db.UpsertBeatFileParams) (db.BeatFile, error) {
+				uploaded = arg
+				return db.BeatFile{
+					BeatID:      arg.BeatID,
+					FileType:    arg.FileType,
+					S3Key:       arg.S3Key,
+					ContentType: arg.ContentType,
+					Size:        arg.Size,
+					Checksum:    arg.Checksum,
+				}, nil
+			})
+	}
+
+	testCases := []struct {
+		name          string
+		beatID        int32
+		fileType      string
+		filename      string
+		data          []byte
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore)
+	}{
+		{
+			name:     "Stems",
+			beatID:   beat.ID,
+			fileType: util.StemsFile,
+			filename: "stems.zip",
+			data:     stems,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(beat, nil)
+				store.EXPECT().
+					GetBeatFile(gomock.Any(), gomock.Eq(db.GetBeatFileParams{BeatID: beat.ID, FileType: util.StemsFile})).
+					Times(1).
+					Return(oldStems, nil)
+				upsertFile(store)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Regexp(t, fmt.Sprintf(`^beats/%d/files/stems_zip/[0-9a-f-]{36}\.zip$`, beat.ID), uploaded.S3Key)
+				requireBlob(t, blobs, uploaded.S3Key, stems)
+
+				checksum := sha256.Sum256(stems)
+				require.Equal(t, "application/zip", uploaded.ContentType)
+				require.Equal(t, int64(len(stems)), uploaded.Size)
+				require.Equal(t, hex.EncodeToString(checksum[:]), uploaded.Checksum)
+
+				var got beatFileResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, util.StemsFile, got.Type)
+				require.Equal(t, uploaded.Checksum, got.Checksum)
+				require.NotContains(t, recorder.Body.String(), uploaded.S3Key)
+
+				// The replaced stems are deleted
+				_, err := blobs.Get(context.Background(), oldStems.S3Key)
+				require.ErrorIs(t, err, storage.ErrNotFound)
+			},
+		},
+		{
+			name:     "ProjectFile",
+			beatID:   beat.ID,
+			fileType: util.ProjectFile,
+			filename: "My Beat.FLP",
+			data:     project,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(beat, nil)
+				store.EXPECT().
+					GetBeatFile(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.BeatFile{}, sql.ErrNoRows)
+				upsertFile(store)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, util.ProjectFile, uploaded.FileType)
+				require.True(t, strings.HasSuffix(uploaded.S3Key, ".flp"), uploaded.S3Key)
+				require.Equal(t, "application/octet-stream", uploaded.ContentType)
+				requireBlob(t, blobs, uploaded.S3Key, project)
+			},
+		},
+		{
+			name:     "StemsNotZIP",
+			beatID:   beat.ID,
+			fileType: util.StemsFile,
+			filename: "stems.zip",
+			data:     randomWAV(64),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(beat, nil)
+				store.EXPECT().
+					UpsertBeatFile(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusUnsupportedMediaType, recorder.Code)
+			},
+		},
+		{
+			name:     "MP3",
+			beatID:   beat.ID,
+			fileType: util.MP3File,
+			filename: "beat.mp3",
+			data:     mp3,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(beat, nil)
+				store.EXPECT().
+					GetFirstAudioByContentHash(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.GetFirstAudioByContentHashRow{}, sql.ErrNoRows)
+				// Attached next to the master WAV like an MP3 uploaded as beat audio
+				store.EXPECT().
+					UpdateBeatAudioTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.UpdateBeatAudioTxParams) (db.UpdateBeatAudioTxResult, error) {
+						require.Equal(t, beat.ID, arg.BeatID)
+						uploaded = arg.File
+						return db.UpdateBeatAudioTxResult{
+							Beat: beat,
+							File: db.BeatFile{
+								BeatID:      beat.ID,
+								FileType:    arg.File.FileType,
+								S3Key:       arg.File.S3Key,
+								ContentType: arg.File.ContentType,
+								Size:        arg.File.Size,
+								Checksum:    arg.File.Checksum,
+							},
+						}, nil
+					})
+				store.EXPECT().
+					UpsertBeatFile(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, util.MP3File, uploaded.FileType)
+				require.Equal(t, audio.ContentTypeMP3, uploaded.ContentType)
+				requireBlob(t, blobs, uploaded.S3Key, mp3)
+
+				var got beatFileResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, util.MP3File, got.Type)
+				require.NotContains(t, recorder.Body.String(), uploaded.S3Key)
+			},
+		},
+		{
+			name:     "MP3NotMP3",
+			beatID:   beat.ID,
+			fileType: util.MP3File,
+			filename: "beat.mp3",
+			data:     randomWAV(64),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(beat, nil)
+				store.EXPECT().
+					UpdateBeatAudioTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusUnsupportedMediaType, recorder.Code)
+			},
+		},
+		{
+			name:     "AudioType",
+			beatID:   beat.ID,
+			fileType: util.MasterWAVFile,
+			filename: "master.wav",
+			data:     randomWAV(64),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "TooLarge",
+			beatID:   beat.ID,
+			fileType: util.StemsFile,
+			filename: "stems.zip",
+			data:     randomZIP(1 << 20),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(beat, nil)
+				store.EXPECT().
+					UpsertBeatFile(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+			},
+		},
+		{
+			name:     "NotFound",
+			beatID:   beat.ID,
+			fileType: util.StemsFile,
+			filename: "stems.zip",
+			data:     stems,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(db.Beat{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:     "Forbidden",
+			beatID:   beat.ID,
+			fileType: util.StemsFile,
+			filename: "stems.zip",
+			data:     stems,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID+1, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(beat, nil)
+				store.EXPECT().
+					UpsertBeatFile(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "NoAuthorization",
+			beatID:   beat.ID,
+			fileType: util.StemsFile,
+			filename: "stems.zip",
+			data:     stems,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "InternalError",
+			beatID:   beat.ID,
+			fileType: util.StemsFile,
+			filename: "stems.zip",
+			data:     stems,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(beat, nil)
+				store.EXPECT().
+					GetBeatFile(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(oldStems, nil)
+				store.EXPECT().
+					UpsertBeatFile(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.UpsertBeatFileParams) (db.BeatFile, error) {
+						uploaded = arg
+						return db.BeatFile{}, sql.ErrConnDone
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+
+				// The new file is removed again and the old one is kept
+				_, err := blobs.Get(context.Background(), uploaded.S3Key)
+				require.ErrorIs(t, err, storage.ErrNotFound)
+				requireBlob(t, blobs, oldStems.S3Key, stems)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			uploaded = db.UpsertBeatFileParams{}
+			// Init controller and store
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			// Build stub
+			tc.buildStubs(store)
+			stubVerifiedUsers(store)
+			// Start test server with the current stems stored, build request, and send
+			server := newTestServer(t, store)
+			err := server.blobs.Put(context.Background(), oldStems.S3Key, bytes.NewReader(stems), int64(len(stems)), "application/zip")
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+			url := fmt.Sprintf("/beats/%d/files/%s", tc.beatID, tc.fileType)
+			request := newUploadRequest(t, url, uploadFormField, tc.filename, tc.data)
+			tc.setupAuth(t, request, server.tokenMaker)
+			// Server http response
+			server.router.ServeHTTP(recorder, request)
+			// check response
+			tc.checkResponse(t, recorder, server.blobs)
+		})
+	}
+}
+
+func TestListBeatFiles(t *testing.T) {
+	beat := randomBeat()
+	files := []db.BeatFile{
+		randomBeatFile(beat, util.MasterWAVFile),
+		randomBeatFile(beat, util.StemsFile),
+	}
+
+	testCases := []struct {
+		name          string
+		beatID        int32
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "OK",
+			beatID: beat.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(beat, nil)
+				store.EXPECT().
+					ListBeatFiles(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(files, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got []beatFileResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Len(t, got, len(files))
+				for i, file := range files {
+					requireBeatFileResponse(t, file, got[i])
+					require.NotContains(t, recorder.Body.String(), file.S3Key)
+				}
+			},
+		},
+		{
+			name:   "NoFiles",
+			beatID: beat.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(beat, nil)
+				store.EXPECT().
+					ListBeatFiles(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return([]db.BeatFile{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.JSONEq(t, "[]", recorder.Body.String())
+			},
+		},
+		{
+			name:   "NotFound",
+			beatID: beat.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(db.Beat{}, sql.ErrNoRows)
+				store.EXPECT().
+					ListBeatFiles(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:   "InternalError",
+			beatID: beat.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(beat, nil)
+				store.EXPECT().
+					ListBeatFiles(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name:   "InvalidID",
+			beatID: 0,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			// Init controller and store
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			// Build stub
+			tc.buildStubs(store)
+			// Start test server, build request, and send
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+			url := fmt.Sprintf("/beats/%d/files", tc.beatID)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+			// Server http response
+			server.router.ServeHTTP(recorder, request)
+			// check response
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestDeleteBeatFile(t *testing.T) {
+	beat := randomBeat()
+	stems := randomBeatFile(beat, util.StemsFile)
+	data := randomZIP(64)
+
+	testCases := []struct {
+		name          string
+		beatID        int32
+		fileType      string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore)
+	}{
+		{
+			name:     "OK",
+			beatID:   beat.ID,
+			fileType: util.StemsFile,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(beat, nil)
+				store.EXPECT().
+					DeleteBeatFile(gomock.Any(), gomock.Eq(db.DeleteBeatFileParams{BeatID: beat.ID, FileType: util.StemsFile})).
+					Times(1).
+					Return(stems, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+				_, err := blobs.Get(context.Background(), stems.S3Key)
+				require.ErrorIs(t, err, storage.ErrNotFound)
+			},
+		},
+		{
+			name:     "FileNotFound",
+			beatID:   beat.ID,
+			fileType: util.ProjectFile,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(beat, nil)
+				store.EXPECT().
+					DeleteBeatFile(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.BeatFile{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:     "AudioType",
+			beatID:   beat.ID,
+			fileType: util.TaggedPreviewFile,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "Forbidden",
+			beatID:   beat.ID,
+			fileType: util.StemsFile,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID+1, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(beat, nil)
+				store.EXPECT().
+					DeleteBeatFile(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				requireBlob(t, blobs, stems.S3Key, data)
+			},
+		},
+		{
+			name:     "NoAuthorization",
+			beatID:   beat.ID,
+			fileType: util.StemsFile,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "InternalError",
+			beatID:   beat.ID,
+			fileType: util.StemsFile,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(beat, nil)
+				store.EXPECT().
+					DeleteBeatFile(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.BeatFile{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+				requireBlob(t, blobs, stems.S3Key, data)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			// Init controller and store
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			// Build stub
+			tc.buildStubs(store)
+			stubVerifiedUsers(store)
+			// Start test server with the stems stored, build request, and send
+			server := newTestServer(t, store)
+			err := server.blobs.Put(context.Background(), stems.S3Key, bytes.NewReader(data), int64(len(data)), "application/zip")
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+			url := fmt.Sprintf("/beats/%d/files/%s", tc.beatID, tc.fileType)
+			request, err := http.NewRequest(http.MethodDelete, url, nil)
+			require.NoError(t, err)
+			tc.setupAuth(t, request, server.tokenMaker)
+			// Server http response
+			server.router.ServeHTTP(recorder, request)
+			// check response
+			tc.checkResponse(t, recorder, server.blobs)
+		})
+	}
+}
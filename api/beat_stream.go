@@ -0,0 +1,187 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/danglebary/beatstore-backend-go/audio"
+	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
+	"github.com/danglebary/beatstore-backend-go/storage"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// streamSessionCookie identifies a listening session, plays are counted once per session
+const streamSessionCookie = "stream_session"
+
+var errBeatHasNoPreview = errors.New("beat has no preview")
+
+// blobReadSeeker reads an object through ranged reads, so seeking to the start of
+// a Range request doesn't have to download everything before it
+type blobReadSeeker struct {
+	ctx    context.Context
+	blobs  storage.BlobStore
+	key    string
+	size   int64
+	offset int64
+	body   io.ReadCloser
+}
+
+func (r *blobReadSeeker) Read(p []byte) (int, error) {
+	if r.offset >= r.size {
+		return 0, io.EOF
+	}
+	if r.body == nil {
+		body, err := r.blobs.GetRange(r.ctx, r.key, r.offset, r.size-r.offset)
+		if err != nil {
+			return 0, err
+		}
+		r.body = body
+	}
+
+	n, err := r.body.Read(p)
+	r.offset += int64(n)
+	return n, err
+}
+
+func (r *blobReadSeeker) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.size
+	}
+	if offset < 0 {
+		return 0, errors.New("seek to negative offset")
+	}
+
+	if offset != r.offset {
+		r.Close()
+		r.offset = offset
+	}
+	return offset, nil
+}
+
+func (r *blobReadSeeker) Close() error {
+	if r.body == nil {
+		return nil
+	}
+	err := r.body.Close()
+	r.body = nil
+	return err
+}
+
+// streamSessionID returns the listening session of the client, starting a new one if needed
+func (server *Server) streamSessionID(ctx *gin.Context) uuid.UUID {
+	if cookie, err := ctx.Cookie(streamSessionCookie); err == nil {
+		if id, err := uuid.Parse(cookie); err == nil {
+			return id
+		}
+	}
+
+	id := uuid.New()
+	secure := strings.HasPrefix(server.config.AppBaseURL, "https://")
+	ctx.SetSameSite(http.SameSiteLaxMode)
+	// Without max age the cookie lasts until the browser is closed
+	ctx.SetCookie(streamSessionCookie, id.String(), 0, "/beats", "", secure, true)
+	return id
+}
+
+// audioContentType returns the content type of a stored audio object. Objects stored
+// without a proper audio type, e.g. by older uploads, are sniffed instead.
+func (server *Server) audioContentType(ctx *gin.Context, info storage.ObjectInfo) string {
+	if info.ContentType == audio.ContentTypeWAV || info.ContentType == audio.ContentTypeMP3 {
+		return info.ContentType
+	}
+
+	r, err := server.blobs.GetRange(ctx, info.Key, 0, audio.SniffLen)
+	if err != nil {
+		return info.ContentType
+	}
+	defer r.Close()
+
+	header, err := ioutil.ReadAll(r)
+	if err != nil {
+		return info.ContentType
+	}
+	if _, contentType, err := audio.Sniff(header); err == nil {
+		return contentType
+	}
+	return info.ContentType
+}
+
+type streamBeatRequest struct {
+	ID int32 `uri:"id" binding:"required,min=1"`
+}
+
+// streamBeat serves the preview of a beat for playback in the browser.
+// Range, If-Range and the other conditional headers are handled by http.ServeContent,
+// the bytes sent are added to the listening session to count plays.
+func (server *Server) streamBeat(ctx *gin.Context) {
+	var req streamBeatRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	beat, err := server.store.GetBeatById(ctx, req.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if beat.PreviewKey == "" {
+		ctx.JSON(http.StatusNotFound, errorResponse(errBeatHasNoPreview))
+		return
+	}
+
+	info, err := server.blobs.Stat(ctx, beat.PreviewKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(errBeatHasNoPreview))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	sessionID := server.streamSessionID(ctx)
+
+	header := ctx.Writer.Header()
+	header.Set("Content-Type", server.audioContentType(ctx, info))
+	header.Set("ETag", `"`+info.ETag+`"`)
+	// The URL stays the same when new audio is uploaded, caches have to revalidate
+	header.Set("Cache-Control", "no-cache")
+
+	content := &blobReadSeeker{ctx: ctx, blobs: server.blobs, key: info.Key, size: info.Size}
+	defer content.Close()
+	http.ServeContent(ctx.Writer, ctx.Request, "", info.ModTime, content)
+
+	// Not modified and unsatisfiable range responses don't carry any audio
+	status := ctx.Writer.Status()
+	if status != http.StatusOK && status != http.StatusPartialContent {
+		return
+	}
+	if ctx.Writer.Size() <= 0 {
+		return
+	}
+
+	// The response has been sent, a failure to record it can only be logged
+	_, err = server.store.RecordStreamTx(ctx, db.RecordStreamTxParams{
+		BeatID:        beat.ID,
+		SessionID:     sessionID,
+		BytesStreamed: int64(ctx.Writer.Size()),
+		PlayThreshold: server.config.PlayCountThreshold,
+	})
+	if err != nil {
+		_ = ctx.Error(err)
+	}
+}
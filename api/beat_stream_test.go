@@ -0,0 +1,375 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/danglebary/beatstore-backend-go/db/mock"
+	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
+	"github.com/danglebary/beatstore-backend-go/storage"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// streamSessionOf returns the session cookie set by the response, if any
+func streamSessionOf(recorder *httptest.ResponseRecorder) *http.Cookie {
+	for _, cookie := range recorder.Result().Cookies() {
+		if cookie.Name == streamSessionCookie {
+			return cookie
+		}
+	}
+	return nil
+}
+
+// expectRecordStream expects the bytes of one response to be recorded for the beat
+func expectRecordStream(store *mockdb.MockStore, beat db.Beat, bytes int64) *gomock.Call {
+	return store.EXPECT().
+		RecordStreamTx(gomock.Any(), gomock.AssignableToTypeOf(db.RecordStreamTxParams{})).
+		Times(1).
+		DoAndReturn(func(ctx context.Context, arg db.RecordStreamTxParams) (db.RecordStreamTxResult, error) {
+			if arg.BeatID != beat.ID || arg.BytesStreamed != bytes || arg.PlayThreshold != 1<<10 {
+				return db.RecordStreamTxResult{}, fmt.Errorf("unexpected stream record %+v", arg)
+			}
+			return db.RecordStreamTxResult{}, nil
+		})
+}
+
+func TestStreamBeat(t *testing.T) {
+	mp3 := randomMP3(4096)
+	wav := randomWAV(4096)
+	sessionID := uuid.New()
+
+	testCases := []struct {
+		name          string
+		method        string
+		data          []byte
+		contentType   string
+		setupRequest  func(request *http.Request, info storage.ObjectInfo)
+		buildStubs    func(store *mockdb.MockStore, beat db.Beat)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder, data []byte, info storage.ObjectInfo)
+	}{
+		{
+			name:         "OK",
+			method:       http.MethodGet,
+			data:         mp3,
+			contentType:  "audio/mpeg",
+			setupRequest: func(request *http.Request, info storage.ObjectInfo) {},
+			buildStubs: func(store *mockdb.MockStore, beat db.Beat) {
+				store.EXPECT().GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).Times(1).Return(beat, nil)
+				expectRecordStream(store, beat, int64(len(mp3)))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, data []byte, info storage.ObjectInfo) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, data, recorder.Body.Bytes())
+				require.Equal(t, "audio/mpeg", recorder.Header().Get("Content-Type"))
+				require.Equal(t, "bytes", recorder.Header().Get("Accept-Ranges"))
+				require.Equal(t, `"`+info.ETag+`"`, recorder.Header().Get("ETag"))
+				require.Equal(t, info.ModTime.Format(http.TimeFormat), recorder.Header().Get("Last-Modified"))
+
+				cookie := streamSessionOf(recorder)
+				require.NotNil(t, cookie)
+				require.True(t, cookie.HttpOnly)
+				_, err := uuid.Parse(cookie.Value)
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:        "Range",
+			method:      http.MethodGet,
+			data:        mp3,
+			contentType: "audio/mpeg",
+			setupRequest: func(request *http.Request, info storage.ObjectInfo) {
+				request.Header.Set("Range", "bytes=100-199")
+			},
+			buildStubs: func(store *mockdb.MockStore, beat db.Beat) {
+				store.EXPECT().GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).Times(1).Return(beat, nil)
+				expectRecordStream(store, beat, 100)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, data []byte, info storage.ObjectInfo) {
+				require.Equal(t, http.StatusPartialContent, recorder.Code)
+				require.Equal(t, data[100:200], recorder.Body.Bytes())
+				require.Equal(t, fmt.Sprintf("bytes 100-199/%d", len(data)), recorder.Header().Get("Content-Range"))
+				require.Equal(t, "audio/mpeg", recorder.Header().Get("Content-Type"))
+			},
+		},
+		{
+			name:        "OpenEndedRange",
+			method:      http.MethodGet,
+			data:        mp3,
+			contentType: "audio/mpeg",
+			setupRequest: func(request *http.Request, info storage.ObjectInfo) {
+				request.Header.Set("Range", "bytes=4000-")
+			},
+			buildStubs: func(store *mockdb.MockStore, beat db.Beat) {
+				store.EXPECT().GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).Times(1).Return(beat, nil)
+				expectRecordStream(store, beat, int64(len(mp3)-4000))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, data []byte, info storage.ObjectInfo) {
+				require.Equal(t, http.StatusPartialContent, recorder.Code)
+				require.Equal(t, data[4000:], recorder.Body.Bytes())
+			},
+		},
+		{
+			name:        "UnsatisfiableRange",
+			method:      http.MethodGet,
+			data:        mp3,
+			contentType: "audio/mpeg",
+			setupRequest: func(request *http.Request, info storage.ObjectInfo) {
+				request.Header.Set("Range", "bytes=100000-")
+			},
+			buildStubs: func(store *mockdb.MockStore, beat db.Beat) {
+				store.EXPECT().GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).Times(1).Return(beat, nil)
+				store.EXPECT().RecordStreamTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, data []byte, info storage.ObjectInfo) {
+				require.Equal(t, http.StatusRequestedRangeNotSatisfiable, recorder.Code)
+			},
+		},
+		{
+			name:        "IfRangeMatches",
+			method:      http.MethodGet,
+			data:        mp3,
+			contentType: "audio/mpeg",
+			setupRequest: func(request *http.Request, info storage.ObjectInfo) {
+				request.Header.Set("Range", "bytes=0-9")
+				request.Header.Set("If-Range", `"`+info.ETag+`"`)
+			},
+			buildStubs: func(store *mockdb.MockStore, beat db.Beat) {
+				store.EXPECT().GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).Times(1).Return(beat, nil)
+				expectRecordStream(store, beat, 10)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, data []byte, info storage.ObjectInfo) {
+				require.Equal(t, http.StatusPartialContent, recorder.Code)
+				require.Equal(t, data[:10], recorder.Body.Bytes())
+			},
+		},
+		{
+			name:        "IfRangeStale",
+			method:      http.MethodGet,
+			data:        mp3,
+			contentType: "audio/mpeg",
+			setupRequest: func(request *http.Request, info storage.ObjectInfo) {
+				// The audio was replaced since the client started playing, it gets the new file
+				request.Header.Set("Range", "bytes=0-9")
+				request.Header.Set("If-Range", `"stale"`)
+			},
+			buildStubs: func(store *mockdb.MockStore, beat db.Beat) {
+				store.EXPECT().GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).Times(1).Return(beat, nil)
+				expectRecordStream(store, beat, int64(len(mp3)))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, data []byte, info storage.ObjectInfo) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, data, recorder.Body.Bytes())
+			},
+		},
+		{
+			name:        "IfNoneMatch",
+			method:      http.MethodGet,
+			data:        mp3,
+			contentType: "audio/mpeg",
+			setupRequest: func(request *http.Request, info storage.ObjectInfo) {
+				request.Header.Set("If-None-Match", `"`+info.ETag+`"`)
+			},
+			buildStubs: func(store *mockdb.MockStore, beat db.Beat) {
+				store.EXPECT().GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).Times(1).Return(beat, nil)
+				store.EXPECT().RecordStreamTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, data []byte, info storage.ObjectInfo) {
+				require.Equal(t, http.StatusNotModified, recorder.Code)
+				require.Empty(t, recorder.Body.Bytes())
+			},
+		},
+		{
+			name:        "IfModifiedSince",
+			method:      http.MethodGet,
+			data:        mp3,
+			contentType: "audio/mpeg",
+			setupRequest: func(request *http.Request, info storage.ObjectInfo) {
+				request.Header.Set("If-Modified-Since", info.ModTime.Add(time.Second).Format(http.TimeFormat))
+			},
+			buildStubs: func(store *mockdb.MockStore, beat db.Beat) {
+				store.EXPECT().GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).Times(1).Return(beat, nil)
+				store.EXPECT().RecordStreamTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, data []byte, info storage.ObjectInfo) {
+				require.Equal(t, http.StatusNotModified, recorder.Code)
+			},
+		},
+		{
+			name:        "ExistingSession",
+			method:      http.MethodGet,
+			data:        mp3,
+			contentType: "audio/mpeg",
+			setupRequest: func(request *http.Request, info storage.ObjectInfo) {
+				request.AddCookie(&http.Cookie{Name: streamSessionCookie, Value: sessionID.String()})
+			},
+			buildStubs: func(store *mockdb.MockStore, beat db.Beat) {
+				store.EXPECT().GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).Times(1).Return(beat, nil)
+				arg := db.RecordStreamTxParams{
+					BeatID:        beat.ID,
+					SessionID:     sessionID,
+					BytesStreamed: int64(len(mp3)),
+					PlayThreshold: 1 << 10,
+				}
+				store.EXPECT().RecordStreamTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(db.RecordStreamTxResult{Played: true}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, data []byte, info storage.ObjectInfo) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Nil(t, streamSessionOf(recorder))
+			},
+		},
+		{
+			name:        "SniffedContentType",
+			method:      http.MethodGet,
+			data:        wav,
+			contentType: "application/octet-stream",
+			setupRequest: func(request *http.Request, info storage.ObjectInfo) {
+				request.Header.Set("Range", "bytes=1000-1099")
+			},
+			buildStubs: func(store *mockdb.MockStore, beat db.Beat) {
+				store.EXPECT().GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).Times(1).Return(beat, nil)
+				expectRecordStream(store, beat, 100)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, data []byte, info storage.ObjectInfo) {
+				require.Equal(t, http.StatusPartialContent, recorder.Code)
+				require.Equal(t, "audio/wav", recorder.Header().Get("Content-Type"))
+				require.Equal(t, data[1000:1100], recorder.Body.Bytes())
+			},
+		},
+		{
+			name:         "Head",
+			method:       http.MethodHead,
+			data:         mp3,
+			contentType:  "audio/mpeg",
+			setupRequest: func(request *http.Request, info storage.ObjectInfo) {},
+			buildStubs: func(store *mockdb.MockStore, beat db.Beat) {
+				store.EXPECT().GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).Times(1).Return(beat, nil)
+				store.EXPECT().RecordStreamTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, data []byte, info storage.ObjectInfo) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, fmt.Sprint(len(data)), recorder.Header().Get("Content-Length"))
+				require.Empty(t, recorder.Body.Bytes())
+			},
+		},
+		{
+			name:         "RecordError",
+			method:       http.MethodGet,
+			data:         mp3,
+			contentType:  "audio/mpeg",
+			setupRequest: func(request *http.Request, info storage.ObjectInfo) {},
+			buildStubs: func(store *mockdb.MockStore, beat db.Beat) {
+				store.EXPECT().GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).Times(1).Return(beat, nil)
+				store.EXPECT().RecordStreamTx(gomock.Any(), gomock.Any()).Times(1).Return(db.RecordStreamTxResult{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, data []byte, info storage.ObjectInfo) {
+				// The audio was already sent
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, data, recorder.Body.Bytes())
+			},
+		},
+		{
+			name:         "MissingObject",
+			method:       http.MethodGet,
+			setupRequest: func(request *http.Request, info storage.ObjectInfo) {},
+			buildStubs: func(store *mockdb.MockStore, beat db.Beat) {
+				store.EXPECT().GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).Times(1).Return(beat, nil)
+				store.EXPECT().RecordStreamTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, data []byte, info storage.ObjectInfo) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:         "NoPreview",
+			method:       http.MethodGet,
+			setupRequest: func(request *http.Request, info storage.ObjectInfo) {},
+			buildStubs: func(store *mockdb.MockStore, beat db.Beat) {
+				// The preview is still being generated
+				beat.PreviewKey = ""
+				store.EXPECT().GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).Times(1).Return(beat, nil)
+				store.EXPECT().RecordStreamTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, data []byte, info storage.ObjectInfo) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:         "NotFound",
+			method:       http.MethodGet,
+			setupRequest: func(request *http.Request, info storage.ObjectInfo) {},
+			buildStubs: func(store *mockdb.MockStore, beat db.Beat) {
+				store.EXPECT().GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).Times(1).Return(db.Beat{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, data []byte, info storage.ObjectInfo) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:         "InternalError",
+			method:       http.MethodGet,
+			setupRequest: func(request *http.Request, info storage.ObjectInfo) {},
+			buildStubs: func(store *mockdb.MockStore, beat db.Beat) {
+				store.EXPECT().GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).Times(1).Return(db.Beat{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, data []byte, info storage.ObjectInfo) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			beat := randomBeat()
+
+			// Init controller and store
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			// Build stub
+			tc.buildStubs(store, beat)
+			// Start test server with the preview stored, build request, and send
+			server := newTestServer(t, store)
+			var info storage.ObjectInfo
+			if tc.data != nil {
+				err := server.blobs.Put(context.Background(), beat.PreviewKey, bytes.NewReader(tc.data), int64(len(tc.data)), tc.contentType)
+				require.NoError(t, err)
+				info, err = server.blobs.Stat(context.Background(), beat.PreviewKey)
+				require.NoError(t, err)
+			}
+			recorder := httptest.NewRecorder()
+			url := fmt.Sprintf("/beats/%d/stream", beat.ID)
+			request, err := http.NewRequest(tc.method, url, nil)
+			require.NoError(t, err)
+			tc.setupRequest(request, info)
+			// Server http response
+			server.router.ServeHTTP(recorder, request)
+			// check response
+			tc.checkResponse(t, recorder, tc.data, info)
+		})
+	}
+}
+
+func TestStreamBeatInvalidID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetBeatById(gomock.Any(), gomock.Any()).Times(0)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/beats/0/stream", nil)
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+}
@@ -8,25 +8,38 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/danglebary/beatstore-backend-go/artwork"
 	mockdb "github.com/danglebary/beatstore-backend-go/db/mock"
 	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
+	"github.com/danglebary/beatstore-backend-go/token"
 	"github.com/danglebary/beatstore-backend-go/util"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
 )
 
 func randomBeat() db.Beat {
+	key := util.RandomKey()
+	bpm := util.RandomBpm()
 	return db.Beat{
-		ID:        int32(util.RandomInt(1, 1000)),
-		CreatorID: int32(util.RandomInt(1, 1000)),
-		Title:     util.RandomTitle(),
-		Genre:     util.RandomGenre(),
-		Key:       util.RandomKey(),
-		Bpm:       util.RandomBpm(),
-		Tags:      util.RandomTags(),
-		S3Key:     "not implemented",
+		ID:                    int32(util.RandomInt(1, 1000)),
+		CreatorID:             int32(util.RandomInt(1, 1000)),
+		Title:                 util.RandomTitle(),
+		Genre:                 util.RandomGenre(),
+		Key:                   key,
+		Bpm:                   bpm,
+		Tags:                  util.RandomTags(),
+		S3Key:                 util.RandomS3Key(),
+		PreviewKey:            util.RandomS3Key(),
+		ArtworkKey:            util.RandomS3Key(),
+		PlayCount:             util.RandomInt(0, 1000),
+		DetectedBpm:           float32(bpm),
+		DetectedBpmConfidence: 0.9,
+		DetectedKey:           key,
+		DetectedKeyConfidence: 0.8,
 	}
 }
 
@@ -39,54 +52,111 @@ func randomBeats(n int) []db.Beat {
 	return beats
 }
 
+// requireBeatResponse checks that got describes beat and links to its preview
+// without exposing the storage key
+func requireBeatResponse(t *testing.T, beat db.Beat, got beatResponse) {
+	require.Equal(t, beat.ID, got.ID)
+	require.Equal(t, beat.CreatorID, got.CreatorID)
+	require.Equal(t, beat.Title, got.Title)
+	require.Equal(t, beat.Genre, got.Genre)
+	require.Equal(t, beat.Key, got.Key)
+	require.Equal(t, beat.Bpm, got.Bpm)
+	require.Equal(t, beat.Tags, got.Tags)
+	require.Equal(t, beat.PlayCount, got.PlayCount)
+	require.Equal(t, beat.CreatedAt, got.CreatedAt)
+
+	if beat.DetectedBpm == 0 && beat.DetectedKey == "" {
+		require.Nil(t, got.Analysis)
+	} else {
+		require.NotNil(t, got.Analysis)
+		require.Equal(t, beat.DetectedBpm, got.Analysis.Bpm)
+		require.Equal(t, beat.DetectedBpmConfidence, got.Analysis.BpmConfidence)
+		require.Equal(t, beat.DetectedKey, got.Analysis.Key)
+		require.Equal(t, beat.DetectedKeyConfidence, got.Analysis.KeyConfidence)
+	}
+
+	if beat.ArtworkKey == "" {
+		require.Empty(t, got.Artwork)
+	} else {
+		require.Len(t, got.Artwork, len(artwork.Sizes))
+		for _, size := range artwork.Sizes {
+			require.True(t, strings.HasPrefix(got.Artwork[size], "http://localhost:1337/files/"), got.Artwork[size])
+			require.NotContains(t, got.Artwork[size], beat.ArtworkKey)
+		}
+	}
+
+	if beat.PreviewKey == "" {
+		require.Empty(t, got.PreviewURL)
+		return
+	}
+	require.True(t, strings.HasPrefix(got.PreviewURL, "http://localhost:1337/files/"), got.PreviewURL)
+	require.NotContains(t, got.PreviewURL, beat.PreviewKey)
+	require.NotContains(t, got.PreviewURL, beat.S3Key)
+}
+
 func requireBodyMatchBeat(t *testing.T, body *bytes.Buffer, beat db.Beat) {
 	// Read bytes buffer
 	data, err := ioutil.ReadAll(body)
 	require.NoError(t, err)
+	require.NotContains(t, string(data), "s3_key")
 
-	// Unmarshal byte data to db.Beat struct
-	var gotBeat db.Beat
+	// Unmarshal byte data to beatResponse struct
+	var gotBeat beatResponse
 	err = json.Unmarshal(data, &gotBeat)
 	require.NoError(t, err)
 
-	require.Equal(t, beat, gotBeat)
+	requireBeatResponse(t, beat, gotBeat)
 }
 
 func requireBodyMatchBeats(t *testing.T, body *bytes.Buffer, beats []db.Beat) {
 	// Read bytes buffer
 	data, err := ioutil.ReadAll(body)
 	require.NoError(t, err)
+	require.NotContains(t, string(data), "s3_key")
 
-	// Unmarshal byte data to []db.Beat
-	var gotBeats []db.Beat
+	// Unmarshal byte data to []beatResponse
+	var gotBeats []beatResponse
 	err = json.Unmarshal(data, &gotBeats)
 	require.NoError(t, err)
 
+	require.Len(t, gotBeats, len(beats))
 	for i := range beats {
-		require.Equal(t, beats[i], gotBeats[i])
+		requireBeatResponse(t, beats[i], gotBeats[i])
 	}
 }
 
 func TestCreateBeat(t *testing.T) {
 	beat := randomBeat()
+	producer, _ := randomUser(t)
+	producer.ID = beat.CreatorID
+	producer.Role = util.ProducerRole
+	key, rawKey := randomAPIKey(t, producer, util.BeatsWriteScope)
 
 	testCases := []struct {
 		name          string
 		body          createBeatRequestParams
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
 		buildStubs    func(store *mockdb.MockStore)
 		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
 	}{
 		{
 			name: "OK",
 			body: createBeatRequestParams{
-				CreatorID: beat.CreatorID,
-				Title:     beat.Title,
-				Genre:     beat.Genre,
-				Key:       beat.Key,
-				Bpm:       beat.Bpm,
-				Tags:      beat.Tags,
+				Title: beat.Title,
+				Genre: beat.Genre,
+				Key:   beat.Key,
+				Bpm:   beat.Bpm,
+				Tags:  beat.Tags,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserById(gomock.Any(), gomock.Eq(producer.ID)).
+					Times(1).
+					Return(producer, nil)
+
 				arg := db.CreateBeatParams{
 					CreatorID: beat.CreatorID,
 					Title:     beat.Title,
@@ -94,7 +164,6 @@ func TestCreateBeat(t *testing.T) {
 					Key:       beat.Key,
 					Bpm:       beat.Bpm,
 					Tags:      beat.Tags,
-					S3Key:     beat.S3Key,
 				}
 
 				store.EXPECT().
@@ -110,13 +179,19 @@ func TestCreateBeat(t *testing.T) {
 		{
 			name: "BadRequest-body",
 			body: createBeatRequestParams{
-				CreatorID: beat.CreatorID,
-				Title:     beat.Title,
-				Genre:     beat.Genre,
-				Key:       beat.Key,
-				Bpm:       beat.Bpm,
+				Title: beat.Title,
+				Genre: beat.Genre,
+				Key:   beat.Key,
+				Bpm:   beat.Bpm,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserById(gomock.Any(), gomock.Eq(producer.ID)).
+					Times(1).
+					Return(producer, nil)
 				store.EXPECT().
 					CreateBeat(gomock.Any(), gomock.Any()).
 					Times(0)
@@ -128,14 +203,21 @@ func TestCreateBeat(t *testing.T) {
 		{
 			name: "InternalError",
 			body: createBeatRequestParams{
-				CreatorID: beat.CreatorID,
-				Title:     beat.Title,
-				Genre:     beat.Genre,
-				Key:       beat.Key,
-				Bpm:       beat.Bpm,
-				Tags:      beat.Tags,
+				Title: beat.Title,
+				Genre: beat.Genre,
+				Key:   beat.Key,
+				Bpm:   beat.Bpm,
+				Tags:  beat.Tags,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserById(gomock.Any(), gomock.Eq(producer.ID)).
+					Times(1).
+					Return(producer, nil)
+
 				arg := db.CreateBeatParams{
 					CreatorID: beat.CreatorID,
 					Title:     beat.Title,
@@ -143,7 +225,6 @@ func TestCreateBeat(t *testing.T) {
 					Key:       beat.Key,
 					Bpm:       beat.Bpm,
 					Tags:      beat.Tags,
-					S3Key:     beat.S3Key,
 				}
 
 				store.EXPECT().
@@ -155,6 +236,110 @@ func TestCreateBeat(t *testing.T) {
 				require.Equal(t, http.StatusInternalServerError, recorder.Code)
 			},
 		},
+		{
+			name: "NoAuthorization",
+			body: createBeatRequestParams{
+				Title: beat.Title,
+				Genre: beat.Genre,
+				Key:   beat.Key,
+				Bpm:   beat.Bpm,
+				Tags:  beat.Tags,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateBeat(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "Forbidden",
+			body: createBeatRequestParams{
+				Title: beat.Title,
+				Genre: beat.Genre,
+				Key:   beat.Key,
+				Bpm:   beat.Bpm,
+				Tags:  beat.Tags,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ListenerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateBeat(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "APIKey",
+			body: createBeatRequestParams{
+				Title: beat.Title,
+				Genre: beat.Genre,
+				Key:   beat.Key,
+				Bpm:   beat.Bpm,
+				Tags:  beat.Tags,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				request.Header.Set(authorizationHeaderKey, "ApiKey "+rawKey)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAPIKeyByPrefix(gomock.Any(), gomock.Eq(key.Prefix)).
+					Times(1).
+					Return(apiKeyRow(key, producer), nil)
+				store.EXPECT().
+					UpdateAPIKeyLastUsed(gomock.Any(), gomock.Eq(key.ID)).
+					Times(1).
+					Return(nil)
+				store.EXPECT().
+					GetUserById(gomock.Any(), gomock.Eq(producer.ID)).
+					Times(1).
+					Return(producer, nil)
+				store.EXPECT().
+					CreateBeat(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(beat, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchBeat(t, recorder.Body, beat)
+			},
+		},
+		{
+			name: "EmailNotVerified",
+			body: createBeatRequestParams{
+				Title: beat.Title,
+				Genre: beat.Genre,
+				Key:   beat.Key,
+				Bpm:   beat.Bpm,
+				Tags:  beat.Tags,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, producer.ID, producer.Username, util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				unverified := producer
+				unverified.VerifiedAt = sql.NullTime{}
+
+				store.EXPECT().
+					GetUserById(gomock.Any(), gomock.Eq(producer.ID)).
+					Times(1).
+					Return(unverified, nil)
+				store.EXPECT().
+					CreateBeat(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
 	}
 
 	for i := range testCases {
@@ -168,13 +353,14 @@ func TestCreateBeat(t *testing.T) {
 			// Build stub
 			tc.buildStubs(store)
 			// Start test server, build request, and send
-			server := NewServer(store)
+			server := newTestServer(t, store)
 			recorder := httptest.NewRecorder()
 			url := "/beats"
 			data, err := json.Marshal(tc.body)
 			require.NoError(t, err)
 			request, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(data))
 			require.NoError(t, err)
+			tc.setupAuth(t, request, server.tokenMaker)
 			// Server http response
 			server.router.ServeHTTP(recorder, request)
 			// check response
@@ -190,6 +376,7 @@ func TestUpdateBeat(t *testing.T) {
 		name          string
 		beatID        int32
 		body          updateBeatRequestParams
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
 		buildStubs    func(store *mockdb.MockStore)
 		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
 	}{
@@ -203,7 +390,15 @@ func TestUpdateBeat(t *testing.T) {
 				Bpm:   beat.Bpm,
 				Tags:  beat.Tags,
 			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
 			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(beat, nil)
+
 				arg := db.UpdateBeatParams{
 					ID:    beat.ID,
 					Title: beat.Title,
@@ -211,7 +406,6 @@ func TestUpdateBeat(t *testing.T) {
 					Key:   beat.Key,
 					Bpm:   beat.Bpm,
 					Tags:  beat.Tags,
-					S3Key: beat.S3Key,
 				}
 
 				store.EXPECT().
@@ -234,6 +428,9 @@ func TestUpdateBeat(t *testing.T) {
 				Bpm:   beat.Bpm,
 				Tags:  beat.Tags,
 			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ListenerRole, time.Minute)
+			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
 					UpdateUser(gomock.Any(), gomock.Any()).
@@ -252,6 +449,9 @@ func TestUpdateBeat(t *testing.T) {
 				Key:   beat.Key,
 				Bpm:   beat.Bpm,
 			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ListenerRole, time.Minute)
+			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
 					UpdateBeat(gomock.Any(), gomock.Any()).
@@ -271,7 +471,14 @@ func TestUpdateBeat(t *testing.T) {
 				Bpm:   beat.Bpm,
 				Tags:  beat.Tags,
 			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
 			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(beat, nil)
 				store.EXPECT().
 					UpdateBeat(gomock.Any(), gomock.Any()).
 					Times(1).
@@ -281,6 +488,85 @@ func TestUpdateBeat(t *testing.T) {
 				require.Equal(t, http.StatusInternalServerError, recorder.Code)
 			},
 		},
+		{
+			name:   "AdminOverride",
+			beatID: beat.ID,
+			body: updateBeatRequestParams{
+				Title: beat.Title,
+				Genre: beat.Genre,
+				Key:   beat.Key,
+				Bpm:   beat.Bpm,
+				Tags:  beat.Tags,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID+1, util.RandomUsername(), util.AdminRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(beat, nil)
+				store.EXPECT().
+					UpdateBeat(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(beat, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "Forbidden",
+			beatID: beat.ID,
+			body: updateBeatRequestParams{
+				Title: beat.Title,
+				Genre: beat.Genre,
+				Key:   beat.Key,
+				Bpm:   beat.Bpm,
+				Tags:  beat.Tags,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID+1, util.RandomUsername(), util.ListenerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(beat, nil)
+				store.EXPECT().
+					UpdateBeat(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:   "NotFound",
+			beatID: beat.ID,
+			body: updateBeatRequestParams{
+				Title: beat.Title,
+				Genre: beat.Genre,
+				Key:   beat.Key,
+				Bpm:   beat.Bpm,
+				Tags:  beat.Tags,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(db.Beat{}, sql.ErrNoRows)
+				store.EXPECT().
+					UpdateBeat(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
 	}
 
 	for i := range testCases {
@@ -294,13 +580,14 @@ func TestUpdateBeat(t *testing.T) {
 			// Build stub
 			tc.buildStubs(store)
 			// Start test server, build request, and send
-			server := NewServer(store)
+			server := newTestServer(t, store)
 			recorder := httptest.NewRecorder()
 			url := fmt.Sprintf("/beats/%d", tc.beatID)
 			data, err := json.Marshal(tc.body)
 			require.NoError(t, err)
 			request, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(data))
 			require.NoError(t, err)
+			tc.setupAuth(t, request, server.tokenMaker)
 			// Server http response
 			server.router.ServeHTTP(recorder, request)
 			// check response
@@ -312,6 +599,25 @@ func TestUpdateBeat(t *testing.T) {
 func TestGetBeatByIDApi(t *testing.T) {
 	beat := randomBeat()
 
+	mismatched := randomBeat()
+	mismatched.Bpm = 140
+	mismatched.DetectedBpm = 97
+	mismatched.Key = "C major"
+	mismatched.DetectedKey = "F# minor"
+
+	notAnalyzed := randomBeat()
+	notAnalyzed.DetectedBpm = 0
+	notAnalyzed.DetectedBpmConfidence = 0
+	notAnalyzed.DetectedKey = ""
+	notAnalyzed.DetectedKeyConfidence = 0
+
+	files := []db.BeatFile{
+		{BeatID: beat.ID, FileType: util.MasterWAVFile, S3Key: util.RandomS3Key()},
+		{BeatID: beat.ID, FileType: util.StemsFile, S3Key: util.RandomS3Key()},
+		{BeatID: beat.ID, FileType: util.TaggedPreviewFile, S3Key: util.RandomS3Key()},
+	}
+	licenses := []db.License{randomLicense(beat.ID, util.BasicLicense), randomLicense(beat.ID, util.ExclusiveLicense)}
+
 	testCases := []struct {
 		name          string
 		beatId        int32
@@ -326,10 +632,87 @@ func TestGetBeatByIDApi(t *testing.T) {
 					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
 					Times(1).
 					Return(beat, nil)
+				store.EXPECT().
+					ListBeatFiles(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(files, nil)
+				store.EXPECT().
+					ListLicensesByBeat(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(licenses, nil)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
-				requireBodyMatchBeat(t, recorder.Body, beat)
+
+				var got beatResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				requireBeatResponse(t, beat, got)
+				require.False(t, got.Analysis.BpmMismatch)
+				require.False(t, got.Analysis.KeyMismatch)
+
+				// The available files are listed by type only
+				require.Equal(t, []string{util.MasterWAVFile, util.StemsFile, util.TaggedPreviewFile}, got.AssetTypes)
+				for _, file := range files {
+					require.NotContains(t, recorder.Body.String(), file.S3Key)
+				}
+
+				// The license tiers are embedded
+				require.Len(t, got.Licenses, len(licenses))
+				for i, license := range licenses {
+					requireLicenseResponse(t, license, got.Licenses[i])
+				}
+			},
+		},
+		{
+			name:   "AnalysisMismatch",
+			beatId: mismatched.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(mismatched.ID)).
+					Times(1).
+					Return(mismatched, nil)
+				store.EXPECT().
+					ListBeatFiles(gomock.Any(), gomock.Eq(mismatched.ID)).
+					Times(1).
+					Return([]db.BeatFile{}, nil)
+				store.EXPECT().
+					ListLicensesByBeat(gomock.Any(), gomock.Eq(mismatched.ID)).
+					Times(1).
+					Return([]db.License{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got beatResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				requireBeatResponse(t, mismatched, got)
+				require.True(t, got.Analysis.BpmMismatch)
+				require.True(t, got.Analysis.KeyMismatch)
+			},
+		},
+		{
+			name:   "NotAnalyzed",
+			beatId: notAnalyzed.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(notAnalyzed.ID)).
+					Times(1).
+					Return(notAnalyzed, nil)
+				store.EXPECT().
+					ListBeatFiles(gomock.Any(), gomock.Eq(notAnalyzed.ID)).
+					Times(1).
+					Return([]db.BeatFile{}, nil)
+				store.EXPECT().
+					ListLicensesByBeat(gomock.Any(), gomock.Eq(notAnalyzed.ID)).
+					Times(1).
+					Return([]db.License{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchBeat(t, recorder.Body, notAnalyzed)
+				require.NotContains(t, recorder.Body.String(), "analysis")
+				require.NotContains(t, recorder.Body.String(), "asset_types")
+				require.NotContains(t, recorder.Body.String(), "licenses")
 			},
 		},
 		{
@@ -358,6 +741,44 @@ func TestGetBeatByIDApi(t *testing.T) {
 				require.Equal(t, http.StatusInternalServerError, recorder.Code)
 			},
 		},
+		{
+			name:   "ListFilesError",
+			beatId: beat.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(beat, nil)
+				store.EXPECT().
+					ListBeatFiles(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name:   "ListLicensesError",
+			beatId: beat.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(beat, nil)
+				store.EXPECT().
+					ListBeatFiles(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(files, nil)
+				store.EXPECT().
+					ListLicensesByBeat(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
 		{
 			name:   "IntvalidID",
 			beatId: 0,
@@ -383,7 +804,7 @@ func TestGetBeatByIDApi(t *testing.T) {
 			// Build stub
 			tc.buildStubs(store)
 			// Start test server, build request, and send
-			server := NewServer(store)
+			server := newTestServer(t, store)
 			recorder := httptest.NewRecorder()
 			url := fmt.Sprintf("/beats/%d", tc.beatId)
 			request, err := http.NewRequest(http.MethodGet, url, nil)
@@ -487,7 +908,7 @@ func TestListBeatsByIDorderIDApi(t *testing.T) {
 			// Build stub
 			tc.buildStubs(store)
 			// Start test server, build request, and send
-			server := NewServer(store)
+			server := newTestServer(t, store)
 			recorder := httptest.NewRecorder()
 
 			url := fmt.Sprintf("/beats?page_id=%d&page_size=%d&order=ID", tc.pageID, tc.pageSize)
@@ -590,7 +1011,7 @@ func TestListBeatsByIDorderBPMApi(t *testing.T) {
 			// Build stub
 			tc.buildStubs(store)
 			// Start test server, build request, and send
-			server := NewServer(store)
+			server := newTestServer(t, store)
 			recorder := httptest.NewRecorder()
 
 			url := fmt.Sprintf("/beats?page_id=%d&page_size=%d&order=BPM&min=%d&max=%d", tc.pageID, tc.pageSize, tc.bpmMin, tc.bpmMax)
@@ -674,7 +1095,7 @@ func TestListBeatsByIDorderKEYApi(t *testing.T) {
 			// Build stub
 			tc.buildStubs(store)
 			// Start test server, build request, and send
-			server := NewServer(store)
+			server := newTestServer(t, store)
 			recorder := httptest.NewRecorder()
 
 			url := fmt.Sprintf("/beats?page_id=%d&page_size=%d&order=KEY&key=%s", tc.pageID, tc.pageSize, tc.key)
@@ -758,7 +1179,7 @@ func TestListBeatsByIDorderGENREApi(t *testing.T) {
 			// Build stub
 			tc.buildStubs(store)
 			// Start test server, build request, and send
-			server := NewServer(store)
+			server := newTestServer(t, store)
 			recorder := httptest.NewRecorder()
 
 			url := fmt.Sprintf("/beats?page_id=%d&page_size=%d&order=GENRE&genre=%s", tc.pageID, tc.pageSize, tc.genre)
@@ -774,7 +1195,7 @@ func TestListBeatsByIDorderGENREApi(t *testing.T) {
 }
 
 func TestListBeatsByCreatorIDorderIDApi(t *testing.T) {
-	user := randomUser()
+	user, _ := randomUser(t)
 	beats := randomBeats(5)
 
 	testCases := []struct {
@@ -871,7 +1292,7 @@ func TestListBeatsByCreatorIDorderIDApi(t *testing.T) {
 			// Build stub
 			tc.buildStubs(store)
 			// Start test server, build request, and send
-			server := NewServer(store)
+			server := newTestServer(t, store)
 			recorder := httptest.NewRecorder()
 
 			url := fmt.Sprintf("/users/%d/beats?page_id=%d&page_size=%d&order=ID", tc.creatorID, tc.pageID, tc.pageSize)
@@ -887,7 +1308,7 @@ func TestListBeatsByCreatorIDorderIDApi(t *testing.T) {
 }
 
 func TestListBeatsByCreatorIDorderBPMApi(t *testing.T) {
-	user := randomUser()
+	user, _ := randomUser(t)
 	beats := randomBeats(5)
 
 	testCases := []struct {
@@ -980,7 +1401,7 @@ func TestListBeatsByCreatorIDorderBPMApi(t *testing.T) {
 			// Build stub
 			tc.buildStubs(store)
 			// Start test server, build request, and send
-			server := NewServer(store)
+			server := newTestServer(t, store)
 			recorder := httptest.NewRecorder()
 
 			url := fmt.Sprintf("/users/%d/beats?page_id=%d&page_size=%d&order=BPM&min=%d&max=%d", tc.creatorID, tc.pageID, tc.pageSize, tc.bpmMin, tc.bpmMax)
@@ -996,7 +1417,7 @@ func TestListBeatsByCreatorIDorderBPMApi(t *testing.T) {
 }
 
 func TestListBeatsByCreatorIDorderKEYApi(t *testing.T) {
-	user := randomUser()
+	user, _ := randomUser(t)
 	beats := randomBeats(5)
 
 	testCases := []struct {
@@ -1069,7 +1490,7 @@ func TestListBeatsByCreatorIDorderKEYApi(t *testing.T) {
 			// Build stub
 			tc.buildStubs(store)
 			// Start test server, build request, and send
-			server := NewServer(store)
+			server := newTestServer(t, store)
 			recorder := httptest.NewRecorder()
 
 			url := fmt.Sprintf("/users/%d/beats?page_id=%d&page_size=%d&order=KEY&key=%s", tc.creatorID, tc.pageID, tc.pageSize, tc.key)
@@ -1085,7 +1506,7 @@ func TestListBeatsByCreatorIDorderKEYApi(t *testing.T) {
 }
 
 func TestListBeatsByCreatorIDorderGENREApi(t *testing.T) {
-	user := randomUser()
+	user, _ := randomUser(t)
 	beats := randomBeats(5)
 
 	testCases := []struct {
@@ -1158,7 +1579,7 @@ func TestListBeatsByCreatorIDorderGENREApi(t *testing.T) {
 			// Build stub
 			tc.buildStubs(store)
 			// Start test server, build request, and send
-			server := NewServer(store)
+			server := newTestServer(t, store)
 			recorder := httptest.NewRecorder()
 
 			url := fmt.Sprintf("/users/%d/beats?page_id=%d&page_size=%d&order=GENRE&genre=%s", tc.creatorID, tc.pageID, tc.pageSize, tc.genre)
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/danglebary/beatstore-backend-go/audio"
+	"github.com/gin-gonic/gin"
+)
+
+var errWaveformNotAvailable = errors.New("waveform not available")
+
+type getBeatWaveformRequestUri struct {
+	ID int32 `uri:"id" binding:"required,min=1"`
+}
+
+type getBeatWaveformRequestParams struct {
+	// The upper bound is media.WaveformResolution
+	Resolution int `form:"resolution,default=1024" binding:"min=1,max=4096"`
+}
+
+type waveformResponse struct {
+	BeatID     int32 `json:"beat_id"`
+	Resolution int   `json:"resolution"`
+	DurationMs int64 `json:"duration_ms"`
+	// Data holds the minimum and the maximum of every peak in turn, scaled to -127 to 127
+	Data []int8 `json:"data"`
+}
+
+// getBeatWaveform serves the peaks of the current audio of a beat, reduced to the requested resolution.
+// Waveforms are computed from WAV uploads in the background, until then there is none.
+func (server *Server) getBeatWaveform(ctx *gin.Context) {
+	var uri getBeatWaveformRequestUri
+	var req getBeatWaveformRequestParams
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	waveform, err := server.store.GetBeatWaveform(ctx, uri.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(errWaveformNotAvailable))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	peaks := audio.DownsamplePeaks(audio.DecodePeaks(waveform.Peaks), req.Resolution)
+	data := make([]int8, 0, 2*len(peaks))
+	for _, b := range audio.EncodePeaks(peaks) {
+		data = append(data, int8(b))
+	}
+
+	ctx.JSON(http.StatusOK, waveformResponse{
+		BeatID:     waveform.BeatID,
+		Resolution: len(peaks),
+		DurationMs: waveform.DurationMs,
+		Data:       data,
+	})
+}
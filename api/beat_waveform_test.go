@@ -0,0 +1,177 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/danglebary/beatstore-backend-go/audio"
+	mockdb "github.com/danglebary/beatstore-backend-go/db/mock"
+	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+// randomWaveform returns a waveform of 2048 peaks, the first half quiet and the second half loud
+func randomWaveform(beat db.Beat) db.BeatWaveform {
+	peaks := make([]audio.Peak, 2048)
+	for i := range peaks {
+		peaks[i] = audio.Peak{Min: -0.25, Max: 0.25}
+		if i >= 1024 {
+			peaks[i] = audio.Peak{Min: -1, Max: 1}
+		}
+	}
+
+	return db.BeatWaveform{
+		BeatID:     beat.ID,
+		S3Key:      beat.S3Key,
+		Resolution: int32(len(peaks)),
+		DurationMs: util.RandomInt(1000, 300000),
+		Peaks:      audio.EncodePeaks(peaks),
+	}
+}
+
+func TestGetBeatWaveform(t *testing.T) {
+	beat := randomBeat()
+	waveform := randomWaveform(beat)
+
+	testCases := []struct {
+		name          string
+		beatID        int32
+		query         string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "DefaultResolution",
+			beatID: beat.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetBeatWaveform(gomock.Any(), gomock.Eq(beat.ID)).Times(1).Return(waveform, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				got := requireBodyMatchWaveform(t, recorder, waveform, 1024)
+				require.Equal(t, []int8{-32, 32}, got.Data[0:2])
+				require.Equal(t, []int8{-127, 127}, got.Data[2046:2048])
+			},
+		},
+		{
+			name:   "LowResolution",
+			beatID: beat.ID,
+			query:  "?resolution=4",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetBeatWaveform(gomock.Any(), gomock.Eq(beat.ID)).Times(1).Return(waveform, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				got := requireBodyMatchWaveform(t, recorder, waveform, 4)
+				require.Equal(t, []int8{-32, 32, -32, 32, -127, 127, -127, 127}, got.Data)
+			},
+		},
+		{
+			// Asking for more peaks than stored returns all of them
+			name:   "MaxResolution",
+			beatID: beat.ID,
+			query:  "?resolution=4096",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetBeatWaveform(gomock.Any(), gomock.Eq(beat.ID)).Times(1).Return(waveform, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchWaveform(t, recorder, waveform, 2048)
+			},
+		},
+		{
+			name:   "ResolutionTooHigh",
+			beatID: beat.ID,
+			query:  "?resolution=4097",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetBeatWaveform(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:   "InvalidResolution",
+			beatID: beat.ID,
+			query:  "?resolution=0",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetBeatWaveform(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:   "InvalidID",
+			beatID: 0,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetBeatWaveform(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			// Also the case while the waveform of new audio is being computed
+			name:   "NotFound",
+			beatID: beat.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetBeatWaveform(gomock.Any(), gomock.Eq(beat.ID)).Times(1).Return(db.BeatWaveform{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:   "InternalError",
+			beatID: beat.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetBeatWaveform(gomock.Any(), gomock.Eq(beat.ID)).Times(1).Return(db.BeatWaveform{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			// Init controller and store
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			// Build stub
+			tc.buildStubs(store)
+			// Start test server, build request, and send
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+			url := fmt.Sprintf("/beats/%d/waveform%s", tc.beatID, tc.query)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+			// Server http response
+			server.router.ServeHTTP(recorder, request)
+			// check response
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func requireBodyMatchWaveform(t *testing.T, recorder *httptest.ResponseRecorder, waveform db.BeatWaveform, resolution int) waveformResponse {
+	var got waveformResponse
+	err := json.Unmarshal(recorder.Body.Bytes(), &got)
+	require.NoError(t, err)
+
+	require.Equal(t, waveform.BeatID, got.BeatID)
+	require.Equal(t, waveform.DurationMs, got.DurationMs)
+	require.Equal(t, resolution, got.Resolution)
+	require.Len(t, got.Data, 2*resolution)
+	return got
+}
@@ -0,0 +1,286 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// cartCookie holds the ID of the cart of an anonymous client, the cart is merged into the user's cart on login
+const cartCookie = "cart"
+
+// cartCookieMaxAge is how long, in seconds, a browser keeps the anonymous cart
+const cartCookieMaxAge = 30 * 24 * 60 * 60
+
+var (
+	errLicenseNotOffered = errors.New("the beat is not offered under this license")
+	errOwnBeat           = errors.New("beats can't be bought by their creator")
+)
+
+// cartItemResponse is a beat in a cart under the license the buyer chose.
+// The price is the one of the license when the item was added.
+type cartItemResponse struct {
+	ID        int64  `json:"id"`
+	BeatID    int32  `json:"beat_id"`
+	BeatTitle string `json:"beat_title"`
+	LicenseID int64  `json:"license_id"`
+	Tier      string `json:"tier"`
+	Price     int64  `json:"price"`
+	Currency  string `json:"currency"`
+	// PriceChanged is set when the creator changed the price of the license since the item was added
+	PriceChanged bool      `json:"price_changed"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type cartResponse struct {
+	Items []cartItemResponse `json:"items"`
+	// Totals maps each currency in the cart to the sum of the prices of its items
+	Totals map[string]int64 `json:"totals"`
+}
+
+func newCartResponse(rows []db.ListCartItemsRow) cartResponse {
+	res := cartResponse{
+		Items:  make([]cartItemResponse, len(rows)),
+		Totals: make(map[string]int64),
+	}
+	for i, row := range rows {
+		res.Items[i] = cartItemResponse{
+			ID:           row.ID,
+			BeatID:       row.BeatID,
+			BeatTitle:    row.BeatTitle,
+			LicenseID:    row.LicenseID,
+			Tier:         row.Tier,
+			Price:        row.Price,
+			Currency:     row.Currency,
+			PriceChanged: row.Price != row.CurrentPrice || row.Currency != row.CurrentCurrency,
+			CreatedAt:    row.CreatedAt,
+		}
+		res.Totals[row.Currency] += row.Price
+	}
+	return res
+}
+
+// cartCookieID returns the anonymous cart ID in the cart cookie of the request
+func cartCookieID(ctx *gin.Context) (uuid.UUID, bool) {
+	cookie, err := ctx.Cookie(cartCookie)
+	if err != nil {
+		return uuid.UUID{}, false
+	}
+	id, err := uuid.Parse(cookie)
+	if err != nil {
+		return uuid.UUID{}, false
+	}
+	return id, true
+}
+
+// setCartCookie hands the anonymous cart ID to the client, a negative max age removes the cookie
+func (server *Server) setCartCookie(ctx *gin.Context, value string, maxAge int) {
+	secure := strings.HasPrefix(server.config.AppBaseURL, "https://")
+	ctx.SetSameSite(http.SameSiteLaxMode)
+	// The cookie is needed by the login routes too, which merge the cart
+	ctx.SetCookie(cartCookie, value, maxAge, "/", "", secure, true)
+}
+
+// findCart returns the cart of the caller, the user's cart for authenticated requests
+// and the cart in the cart cookie otherwise. sql.ErrNoRows is returned when there is none yet.
+func (server *Server) findCart(ctx *gin.Context) (db.Cart, error) {
+	if authPayload := getOptionalAuthPayload(ctx); authPayload != nil {
+		return server.store.GetCartByUser(ctx, sql.NullInt32{Int32: authPayload.UserID, Valid: true})
+	}
+
+	id, ok := cartCookieID(ctx)
+	if !ok {
+		return db.Cart{}, sql.ErrNoRows
+	}
+	cart, err := server.store.GetCart(ctx, id)
+	if err != nil {
+		return db.Cart{}, err
+	}
+	// Knowing the ID of a user's cart doesn't give anonymous access to it
+	if cart.UserID.Valid {
+		return db.Cart{}, sql.ErrNoRows
+	}
+	return cart, nil
+}
+
+// findOrCreateCart returns the cart of the caller like findCart and creates it if there is none yet,
+// a new anonymous cart is handed to the client in the cart cookie
+func (server *Server) findOrCreateCart(ctx *gin.Context) (db.Cart, error) {
+	if authPayload := getOptionalAuthPayload(ctx); authPayload != nil {
+		return server.store.UpsertUserCart(ctx, db.UpsertUserCartParams{
+			ID:     uuid.New(),
+			UserID: sql.NullInt32{Int32: authPayload.UserID, Valid: true},
+		})
+	}
+
+	cart, err := server.findCart(ctx)
+	if err != sql.ErrNoRows {
+		return cart, err
+	}
+
+	cart, err = server.store.CreateCart(ctx, db.CreateCartParams{ID: uuid.New()})
+	if err != nil {
+		return db.Cart{}, err
+	}
+	server.setCartCookie(ctx, cart.ID.String(), cartCookieMaxAge)
+	return cart, nil
+}
+
+// mergeAnonymousCart moves the anonymous cart of the client into the cart of the user and removes the cart cookie.
+// Failing to merge must not prevent the login, the error is attached to the context instead.
+func (server *Server) mergeAnonymousCart(ctx *gin.Context, userID int32) {
+	id, ok := cartCookieID(ctx)
+	if !ok {
+		return
+	}
+
+	_, err := server.store.MergeCartTx(ctx, db.MergeCartTxParams{
+		AnonymousCartID: id,
+		UserID:          userID,
+	})
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	server.setCartCookie(ctx, "", -1)
+}
+
+// getCart returns the items in the cart of the caller, an empty cart if they haven't added anything yet
+func (server *Server) getCart(ctx *gin.Context) {
+	cart, err := server.findCart(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusOK, newCartResponse(nil))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rows, err := server.store.ListCartItems(ctx, cart.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, newCartResponse(rows))
+}
+
+type addCartItemRequestParams struct {
+	BeatID    int32 `json:"beat_id" binding:"required,min=1"`
+	LicenseID int64 `json:"license_id" binding:"required,min=1"`
+}
+
+// addCartItem puts a beat into the cart of the caller under one of its licenses at the current price of the license.
+// A beat already in the cart is switched to the new license, sold exclusive licenses are refused.
+func (server *Server) addCartItem(ctx *gin.Context) {
+	var req addCartItemRequestParams
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	beat, err := server.store.GetBeatById(ctx, req.BeatID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	license, err := server.store.GetLicense(ctx, req.LicenseID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(errLicenseNotOffered))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if license.BeatID != beat.ID {
+		ctx.JSON(http.StatusNotFound, errorResponse(errLicenseNotOffered))
+		return
+	}
+	if license.SoldAt.Valid {
+		ctx.JSON(http.StatusConflict, errorResponse(db.ErrLicenseSold))
+		return
+	}
+
+	if authPayload := getOptionalAuthPayload(ctx); authPayload != nil && authPayload.UserID == beat.CreatorID {
+		ctx.JSON(http.StatusForbidden, errorResponse(errOwnBeat))
+		return
+	}
+
+	cart, err := server.findOrCreateCart(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	item, err := server.store.UpsertCartItem(ctx, db.UpsertCartItemParams{
+		CartID:    cart.ID,
+		BeatID:    beat.ID,
+		LicenseID: license.ID,
+		Price:     license.Price,
+		Currency:  license.Currency,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, cartItemResponse{
+		ID:        item.ID,
+		BeatID:    item.BeatID,
+		BeatTitle: beat.Title,
+		LicenseID: item.LicenseID,
+		Tier:      license.Tier,
+		Price:     item.Price,
+		Currency:  item.Currency,
+		CreatedAt: item.CreatedAt,
+	})
+}
+
+type removeCartItemRequestUri struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// removeCartItem takes an item out of the cart of the caller
+func (server *Server) removeCartItem(ctx *gin.Context) {
+	var uri removeCartItemRequestUri
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	cart, err := server.findCart(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	_, err = server.store.DeleteCartItem(ctx, db.DeleteCartItemParams{
+		ID:     uri.ID,
+		CartID: cart.ID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
@@ -0,0 +1,833 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/danglebary/beatstore-backend-go/db/mock"
+	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
+	"github.com/danglebary/beatstore-backend-go/token"
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func randomCart(userID int32) db.Cart {
+	return db.Cart{
+		ID:        uuid.New(),
+		UserID:    sql.NullInt32{Int32: userID, Valid: userID != 0},
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+	}
+}
+
+func randomCartItemRow(cart db.Cart, license db.License) db.ListCartItemsRow {
+	return db.ListCartItemsRow{
+		ID:              util.RandomInt(1, 1000),
+		CartID:          cart.ID,
+		BeatID:          license.BeatID,
+		LicenseID:       license.ID,
+		Price:           license.Price,
+		Currency:        license.Currency,
+		CreatedAt:       time.Now().UTC().Truncate(time.Second),
+		BeatTitle:       util.RandomTitle(),
+		Tier:            license.Tier,
+		CurrentPrice:    license.Price,
+		CurrentCurrency: license.Currency,
+	}
+}
+
+// cartCookieOf returns the cart cookie set by the response, nil if it wasn't touched
+func cartCookieOf(recorder *httptest.ResponseRecorder) *http.Cookie {
+	for _, cookie := range recorder.Result().Cookies() {
+		if cookie.Name == cartCookie {
+			return cookie
+		}
+	}
+	return nil
+}
+
+func TestGetCart(t *testing.T) {
+	user, _ := randomUser(t)
+	anonymousCart := randomCart(0)
+	userCart := randomCart(user.ID)
+
+	basic := randomLicense(int32(util.RandomInt(1, 1000)), util.BasicLicense)
+	basic.Currency = "USD"
+	premium := randomLicense(int32(util.RandomInt(1, 1000)), util.PremiumLicense)
+	premium.Currency = "USD"
+	exclusive := randomLicense(int32(util.RandomInt(1, 1000)), util.ExclusiveLicense)
+	exclusive.Currency = "EUR"
+
+	rows := []db.ListCartItemsRow{
+		randomCartItemRow(anonymousCart, basic),
+		randomCartItemRow(anonymousCart, premium),
+		randomCartItemRow(anonymousCart, exclusive),
+	}
+	rows[1].CurrentPrice = rows[1].Price + 100
+
+	testCases := []struct {
+		name          string
+		cookie        string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "Anonymous",
+			cookie: anonymousCart.ID.String(),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetCart(gomock.Any(), gomock.Eq(anonymousCart.ID)).
+					Times(1).
+					Return(anonymousCart, nil)
+				store.EXPECT().
+					ListCartItems(gomock.Any(), gomock.Eq(anonymousCart.ID)).
+					Times(1).
+					Return(rows, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got cartResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Len(t, got.Items, len(rows))
+				for i, row := range rows {
+					require.Equal(t, row.ID, got.Items[i].ID)
+					require.Equal(t, row.BeatID, got.Items[i].BeatID)
+					require.Equal(t, row.BeatTitle, got.Items[i].BeatTitle)
+					require.Equal(t, row.LicenseID, got.Items[i].LicenseID)
+					require.Equal(t, row.Tier, got.Items[i].Tier)
+					require.Equal(t, row.Price, got.Items[i].Price)
+					require.Equal(t, row.Currency, got.Items[i].Currency)
+				}
+
+				// Items keep the price they were added at
+				require.False(t, got.Items[0].PriceChanged)
+				require.True(t, got.Items[1].PriceChanged)
+				require.Equal(t, map[string]int64{
+					"USD": basic.Price + premium.Price,
+					"EUR": exclusive.Price,
+				}, got.Totals)
+			},
+		},
+		{
+			name: "User",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetCartByUser(gomock.Any(), gomock.Eq(sql.NullInt32{Int32: user.ID, Valid: true})).
+					Times(1).
+					Return(userCart, nil)
+				store.EXPECT().
+					ListCartItems(gomock.Any(), gomock.Eq(userCart.ID)).
+					Times(1).
+					Return(rows[:1], nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got cartResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Len(t, got.Items, 1)
+				require.Equal(t, rows[0].ID, got.Items[0].ID)
+			},
+		},
+		{
+			name: "UserWithoutCart",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetCartByUser(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Cart{}, sql.ErrNoRows)
+				store.EXPECT().
+					ListCartItems(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.JSONEq(t, `{"items": [], "totals": {}}`, recorder.Body.String())
+			},
+		},
+		{
+			name: "AnonymousWithoutCookie",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetCart(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.JSONEq(t, `{"items": [], "totals": {}}`, recorder.Body.String())
+				require.Nil(t, cartCookieOf(recorder))
+			},
+		},
+		{
+			name:   "CookieOfUserCart",
+			cookie: userCart.ID.String(),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetCart(gomock.Any(), gomock.Eq(userCart.ID)).
+					Times(1).
+					Return(userCart, nil)
+				store.EXPECT().
+					ListCartItems(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.JSONEq(t, `{"items": [], "totals": {}}`, recorder.Body.String())
+			},
+		},
+		{
+			name: "InvalidToken",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, user.Role, -time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetCartByUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:   "InternalError",
+			cookie: anonymousCart.ID.String(),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetCart(gomock.Any(), gomock.Eq(anonymousCart.ID)).
+					Times(1).
+					Return(anonymousCart, nil)
+				store.EXPECT().
+					ListCartItems(gomock.Any(), gomock.Eq(anonymousCart.ID)).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			// Init controller and store
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			// Build stub
+			tc.buildStubs(store)
+			// Start test server, build request, and send
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, "/cart", nil)
+			require.NoError(t, err)
+			if tc.cookie != "" {
+				request.AddCookie(&http.Cookie{Name: cartCookie, Value: tc.cookie})
+			}
+			tc.setupAuth(t, request, server.tokenMaker)
+			// Server http response
+			server.router.ServeHTTP(recorder, request)
+			// check response
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestAddCartItem(t *testing.T) {
+	user, _ := randomUser(t)
+	beat := randomBeat()
+	license := randomLicense(beat.ID, util.PremiumLicense)
+	otherLicense := randomLicense(beat.ID+1, util.PremiumLicense)
+	soldLicense := randomLicense(beat.ID, util.ExclusiveLicense)
+	soldLicense.SoldAt = sql.NullTime{Time: time.Now(), Valid: true}
+	anonymousCart := randomCart(0)
+	userCart := randomCart(user.ID)
+	body := gin.H{"beat_id": beat.ID, "license_id": license.ID}
+
+	// upsertItem expects the beat to be added to cart at the price of the license
+	upsertItem := func(store *mockdb.MockStore, cart db.Cart) {
+		arg := db.UpsertCartItemParams{
+			CartID:    cart.ID,
+			BeatID:    beat.ID,
+			LicenseID: license.ID,
+			Price:     license.Price,
+			Currency:  license.Currency,
+		}
+		store.EXPECT().
+			UpsertCartItem(gomock.Any(), gomock.Eq(arg)).
+			Times(1).
+			Return(db.CartItem{
+				ID:        1,
+				CartID:    arg.CartID,
+				BeatID:    arg.BeatID,
+				LicenseID: arg.LicenseID,
+				Price:     arg.Price,
+				Currency:  arg.Currency,
+				CreatedAt: time.Now(),
+			}, nil)
+	}
+	findBeat := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+			Times(1).
+			Return(beat, nil)
+		store.EXPECT().
+			GetLicense(gomock.Any(), gomock.Eq(license.ID)).
+			Times(1).
+			Return(license, nil)
+	}
+	requireItem := func(t *testing.T, recorder *httptest.ResponseRecorder) {
+		var got cartItemResponse
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+		require.Equal(t, beat.ID, got.BeatID)
+		require.Equal(t, beat.Title, got.BeatTitle)
+		require.Equal(t, license.ID, got.LicenseID)
+		require.Equal(t, license.Tier, got.Tier)
+		require.Equal(t, license.Price, got.Price)
+		require.Equal(t, license.Currency, got.Currency)
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		cookie        string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "AnonymousNewCart",
+			body: body,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				findBeat(store)
+				store.EXPECT().
+					CreateCart(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateCartParams) (db.Cart, error) {
+						require.False(t, arg.UserID.Valid)
+						return anonymousCart, nil
+					})
+				upsertItem(store, anonymousCart)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireItem(t, recorder)
+
+				// The client keeps the cart in a cookie
+				cookie := cartCookieOf(recorder)
+				require.NotNil(t, cookie)
+				require.Equal(t, anonymousCart.ID.String(), cookie.Value)
+				require.True(t, cookie.HttpOnly)
+				require.Equal(t, cartCookieMaxAge, cookie.MaxAge)
+			},
+		},
+		{
+			name:   "AnonymousExistingCart",
+			body:   body,
+			cookie: anonymousCart.ID.String(),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				findBeat(store)
+				store.EXPECT().
+					GetCart(gomock.Any(), gomock.Eq(anonymousCart.ID)).
+					Times(1).
+					Return(anonymousCart, nil)
+				store.EXPECT().
+					CreateCart(gomock.Any(), gomock.Any()).
+					Times(0)
+				upsertItem(store, anonymousCart)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireItem(t, recorder)
+				require.Nil(t, cartCookieOf(recorder))
+			},
+		},
+		{
+			name:   "AnonymousExpiredCart",
+			body:   body,
+			cookie: anonymousCart.ID.String(),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				newCart := randomCart(0)
+				findBeat(store)
+				store.EXPECT().
+					GetCart(gomock.Any(), gomock.Eq(anonymousCart.ID)).
+					Times(1).
+					Return(db.Cart{}, sql.ErrNoRows)
+				store.EXPECT().
+					CreateCart(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(newCart, nil)
+				upsertItem(store, newCart)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				cookie := cartCookieOf(recorder)
+				require.NotNil(t, cookie)
+				require.NotEqual(t, anonymousCart.ID.String(), cookie.Value)
+			},
+		},
+		{
+			name: "User",
+			body: body,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				findBeat(store)
+				store.EXPECT().
+					UpsertUserCart(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.UpsertUserCartParams) (db.Cart, error) {
+						require.Equal(t, sql.NullInt32{Int32: user.ID, Valid: true}, arg.UserID)
+						return userCart, nil
+					})
+				upsertItem(store, userCart)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireItem(t, recorder)
+				require.Nil(t, cartCookieOf(recorder))
+			},
+		},
+		{
+			name: "OwnBeat",
+			body: body,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				findBeat(store)
+				store.EXPECT().
+					UpsertUserCart(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					UpsertCartItem(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "LicenseOfOtherBeat",
+			body: gin.H{"beat_id": beat.ID, "license_id": otherLicense.ID},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(beat, nil)
+				store.EXPECT().
+					GetLicense(gomock.Any(), gomock.Eq(otherLicense.ID)).
+					Times(1).
+					Return(otherLicense, nil)
+				store.EXPECT().
+					UpsertCartItem(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "SoldLicense",
+			body: gin.H{"beat_id": beat.ID, "license_id": soldLicense.ID},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(beat, nil)
+				store.EXPECT().
+					GetLicense(gomock.Any(), gomock.Eq(soldLicense.ID)).
+					Times(1).
+					Return(soldLicense, nil)
+				store.EXPECT().
+					UpsertCartItem(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "LicenseNotFound",
+			body: body,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(beat, nil)
+				store.EXPECT().
+					GetLicense(gomock.Any(), gomock.Eq(license.ID)).
+					Times(1).
+					Return(db.License{}, sql.ErrNoRows)
+				store.EXPECT().
+					UpsertCartItem(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "BeatNotFound",
+			body: body,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(db.Beat{}, sql.ErrNoRows)
+				store.EXPECT().
+					GetLicense(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidBody",
+			body: gin.H{"beat_id": beat.ID},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: body,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				findBeat(store)
+				store.EXPECT().
+					UpsertUserCart(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Cart{}, sql.ErrConnDone)
+				store.EXPECT().
+					UpsertCartItem(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			// Init controller and store
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			// Build stub
+			tc.buildStubs(store)
+			// Start test server, build request, and send
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPost, "/cart/items", bytes.NewReader(data))
+			require.NoError(t, err)
+			if tc.cookie != "" {
+				request.AddCookie(&http.Cookie{Name: cartCookie, Value: tc.cookie})
+			}
+			tc.setupAuth(t, request, server.tokenMaker)
+			// Server http response
+			server.router.ServeHTTP(recorder, request)
+			// check response
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestRemoveCartItem(t *testing.T) {
+	user, _ := randomUser(t)
+	anonymousCart := randomCart(0)
+	userCart := randomCart(user.ID)
+	var itemID int64 = 42
+
+	testCases := []struct {
+		name          string
+		itemID        int64
+		cookie        string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "Anonymous",
+			itemID: itemID,
+			cookie: anonymousCart.ID.String(),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetCart(gomock.Any(), gomock.Eq(anonymousCart.ID)).
+					Times(1).
+					Return(anonymousCart, nil)
+				store.EXPECT().
+					DeleteCartItem(gomock.Any(), gomock.Eq(db.DeleteCartItemParams{ID: itemID, CartID: anonymousCart.ID})).
+					Times(1).
+					Return(db.CartItem{ID: itemID, CartID: anonymousCart.ID}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+			},
+		},
+		{
+			name:   "User",
+			itemID: itemID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetCartByUser(gomock.Any(), gomock.Eq(sql.NullInt32{Int32: user.ID, Valid: true})).
+					Times(1).
+					Return(userCart, nil)
+				store.EXPECT().
+					DeleteCartItem(gomock.Any(), gomock.Eq(db.DeleteCartItemParams{ID: itemID, CartID: userCart.ID})).
+					Times(1).
+					Return(db.CartItem{ID: itemID, CartID: userCart.ID}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+			},
+		},
+		{
+			name:   "ItemNotInCart",
+			itemID: itemID,
+			cookie: anonymousCart.ID.String(),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetCart(gomock.Any(), gomock.Eq(anonymousCart.ID)).
+					Times(1).
+					Return(anonymousCart, nil)
+				store.EXPECT().
+					DeleteCartItem(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CartItem{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:   "NoCart",
+			itemID: itemID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					DeleteCartItem(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:   "InvalidID",
+			itemID: 0,
+			cookie: anonymousCart.ID.String(),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetCart(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:   "InternalError",
+			itemID: itemID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetCartByUser(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Cart{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			// Init controller and store
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			// Build stub
+			tc.buildStubs(store)
+			// Start test server, build request, and send
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+			url := fmt.Sprintf("/cart/items/%d", tc.itemID)
+			request, err := http.NewRequest(http.MethodDelete, url, nil)
+			require.NoError(t, err)
+			if tc.cookie != "" {
+				request.AddCookie(&http.Cookie{Name: cartCookie, Value: tc.cookie})
+			}
+			tc.setupAuth(t, request, server.tokenMaker)
+			// Server http response
+			server.router.ServeHTTP(recorder, request)
+			// check response
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestLoginMergesAnonymousCart(t *testing.T) {
+	user, password := randomUser(t)
+	anonymousCart := randomCart(0)
+
+	testCases := []struct {
+		name          string
+		cookie        string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "Merged",
+			cookie: anonymousCart.ID.String(),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					MergeCartTx(gomock.Any(), gomock.Eq(db.MergeCartTxParams{AnonymousCartID: anonymousCart.ID, UserID: user.ID})).
+					Times(1).
+					Return(db.MergeCartTxResult{Cart: randomCart(user.ID), Moved: 2}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				// The anonymous cart is forgotten
+				cookie := cartCookieOf(recorder)
+				require.NotNil(t, cookie)
+				require.Empty(t, cookie.Value)
+				require.Negative(t, cookie.MaxAge)
+			},
+		},
+		{
+			name: "NoAnonymousCart",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					MergeCartTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Nil(t, cartCookieOf(recorder))
+			},
+		},
+		{
+			name:   "MergeError",
+			cookie: anonymousCart.ID.String(),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					MergeCartTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.MergeCartTxResult{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				// The login succeeds and the cart is merged on the next one
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Nil(t, cartCookieOf(recorder))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			// Init controller and store
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			// Build stub
+			store.EXPECT().
+				GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+				Times(1).
+				Return(user, nil)
+			store.EXPECT().
+				CreateSession(gomock.Any(), gomock.Any()).
+				Times(1)
+			tc.buildStubs(store)
+			// Start test server, build request, and send
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+			data, err := json.Marshal(loginUserRequest{Username: user.Username, Password: password})
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPost, "/users/login", bytes.NewReader(data))
+			require.NoError(t, err)
+			if tc.cookie != "" {
+				request.AddCookie(&http.Cookie{Name: cartCookie, Value: tc.cookie})
+			}
+			// Server http response
+			server.router.ServeHTTP(recorder, request)
+			// check response
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
+	"github.com/danglebary/beatstore-backend-go/mail"
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/gin-gonic/gin"
+)
+
+// emailTokenSize is the number of random bytes in tokens sent by email
+const emailTokenSize = 32
+
+var errEmailNotVerified = errors.New("email address is not verified")
+
+// newEmailToken generates a one-time email token valid for duration, returning the token
+// sent to the user, its hash which is stored in the database, and its expiration time
+func newEmailToken(duration time.Duration) (string, string, time.Time, error) {
+	emailToken, err := util.GenerateSecretToken(emailTokenSize)
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+	expiresAt := time.Now().Add(duration)
+	return emailToken, util.HashSecretToken(emailToken), expiresAt, nil
+}
+
+func (server *Server) sendVerificationEmail(ctx *gin.Context, user db.User, emailToken string) error {
+	link := fmt.Sprintf("%s/users/verify?token=%s", server.config.AppBaseURL, url.QueryEscape(emailToken))
+	msg := mail.Message{
+		To:      user.Email,
+		Subject: "Verify your BeatStore email address",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nplease verify your email address by opening the link below:\n\n%s\n\nThe link expires in %s.",
+			user.Username, link, server.config.EmailTokenDuration,
+		),
+	}
+	return server.mailer.Send(ctx, msg)
+}
+
+// startEmailVerification stores a new verification for the current email of user and mails it
+func (server *Server) startEmailVerification(ctx *gin.Context, user db.User) error {
+	emailToken, hashedToken, expiresAt, err := newEmailToken(server.config.EmailTokenDuration)
+	if err != nil {
+		return err
+	}
+
+	_, err = server.store.CreateEmailVerification(ctx, db.CreateEmailVerificationParams{
+		UserID:      user.ID,
+		Email:       user.Email,
+		HashedToken: hashedToken,
+		ExpiresAt:   expiresAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	return server.sendVerificationEmail(ctx, user, emailToken)
+}
+
+type verifyEmailRequest struct {
+	Token string `form:"token" binding:"required"`
+}
+
+func (server *Server) verifyEmail(ctx *gin.Context) {
+	var req verifyEmailRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	invalidTokenErr := errors.New("invalid or expired verification token")
+
+	verification, err := server.store.GetEmailVerificationByHashedToken(ctx, util.HashSecretToken(req.Token))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusBadRequest, errorResponse(invalidTokenErr))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if verification.UsedAt.Valid || time.Now().After(verification.ExpiresAt) {
+		ctx.JSON(http.StatusBadRequest, errorResponse(invalidTokenErr))
+		return
+	}
+
+	result, err := server.store.VerifyEmailTx(ctx, db.VerifyEmailTxParams{
+		VerificationID: verification.ID,
+		UserID:         verification.UserID,
+		Email:          verification.Email,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusBadRequest, errorResponse(invalidTokenErr))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, newPrivateUserResponse(result.User))
+}
+
+// resendVerificationEmail sends a new verification link to the authenticated user
+func (server *Server) resendVerificationEmail(ctx *gin.Context) {
+	authPayload := getAuthPayload(ctx)
+
+	user, err := server.store.GetUserById(ctx, authPayload.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if user.VerifiedAt.Valid {
+		err := errors.New("email address is already verified")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if err := server.startEmailVerification(ctx, user); err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.Status(http.StatusAccepted)
+}
+
+// requireVerifiedEmail rejects authenticated users who have not verified their email address,
+// it must be registered after authMiddleware
+func (server *Server) requireVerifiedEmail(ctx *gin.Context) {
+	authPayload := getAuthPayload(ctx)
+
+	user, err := server.store.GetUserById(ctx, authPayload.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if !user.VerifiedAt.Valid {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, errorResponse(errEmailNotVerified))
+		return
+	}
+	ctx.Next()
+}
@@ -0,0 +1,332 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	mockdb "github.com/danglebary/beatstore-backend-go/db/mock"
+	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
+	"github.com/danglebary/beatstore-backend-go/mail"
+	"github.com/danglebary/beatstore-backend-go/token"
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+// stubVerifiedUsers lets requireVerifiedEmail pass for every user. It has to be set up after
+// the stubs of a test case, so a case can still expect the lookup of an unverified user.
+func stubVerifiedUsers(store *mockdb.MockStore) {
+	store.EXPECT().
+		GetUserById(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		DoAndReturn(func(_ context.Context, id int32) (db.User, error) {
+			return db.User{
+				ID:         id,
+				Role:       util.ProducerRole,
+				VerifiedAt: sql.NullTime{Time: time.Now(), Valid: true},
+			}, nil
+		})
+}
+
+func randomEmailVerification(t *testing.T, user db.User) (db.EmailVerification, string) {
+	emailToken, err := util.GenerateSecretToken(emailTokenSize)
+	require.NoError(t, err)
+
+	verification := db.EmailVerification{
+		ID:          util.RandomInt(1, 1000),
+		UserID:      user.ID,
+		Email:       user.Email,
+		HashedToken: util.HashSecretToken(emailToken),
+		ExpiresAt:   time.Now().Add(time.Hour),
+		CreatedAt:   time.Now(),
+	}
+	return verification, emailToken
+}
+
+func TestVerifyEmail(t *testing.T) {
+	user, _ := randomUser(t)
+	user.VerifiedAt = sql.NullTime{}
+	verification, emailToken := randomEmailVerification(t, user)
+
+	verifiedUser := user
+	verifiedUser.VerifiedAt = sql.NullTime{Time: time.Now(), Valid: true}
+
+	testCases := []struct {
+		name          string
+		token         string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			token: emailToken,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetEmailVerificationByHashedToken(gomock.Any(), gomock.Eq(verification.HashedToken)).
+					Times(1).
+					Return(verification, nil)
+
+				arg := db.VerifyEmailTxParams{
+					VerificationID: verification.ID,
+					UserID:         user.ID,
+					Email:          user.Email,
+				}
+				store.EXPECT().
+					VerifyEmailTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.VerifyEmailTxResult{User: verifiedUser, EmailVerification: verification}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, recorder.Body.String(), `"email_verified":true`)
+			},
+		},
+		{
+			name:  "MissingToken",
+			token: "",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetEmailVerificationByHashedToken(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "UnknownToken",
+			token: emailToken,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetEmailVerificationByHashedToken(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.EmailVerification{}, sql.ErrNoRows)
+				store.EXPECT().
+					VerifyEmailTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "ExpiredToken",
+			token: emailToken,
+			buildStubs: func(store *mockdb.MockStore) {
+				expired := verification
+				expired.ExpiresAt = time.Now().Add(-time.Minute)
+
+				store.EXPECT().
+					GetEmailVerificationByHashedToken(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(expired, nil)
+				store.EXPECT().
+					VerifyEmailTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "UsedToken",
+			token: emailToken,
+			buildStubs: func(store *mockdb.MockStore) {
+				used := verification
+				used.UsedAt = sql.NullTime{Time: time.Now(), Valid: true}
+
+				store.EXPECT().
+					GetEmailVerificationByHashedToken(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(used, nil)
+				store.EXPECT().
+					VerifyEmailTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "EmailChangedSinceIssued",
+			token: emailToken,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetEmailVerificationByHashedToken(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(verification, nil)
+				store.EXPECT().
+					VerifyEmailTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.VerifyEmailTxResult{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "InternalError",
+			token: emailToken,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetEmailVerificationByHashedToken(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(verification, nil)
+				store.EXPECT().
+					VerifyEmailTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.VerifyEmailTxResult{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			// Init controller and store
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			// Build stub
+			tc.buildStubs(store)
+			// Start test server, build request, and send
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+			url := fmt.Sprintf("/users/verify?token=%s", url.QueryEscape(tc.token))
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+			// Server http response
+			server.router.ServeHTTP(recorder, request)
+			// check response
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestResendVerificationEmail(t *testing.T) {
+	user, _ := randomUser(t)
+	unverifiedUser := user
+	unverifiedUser.VerifiedAt = sql.NullTime{}
+
+	testCases := []struct {
+		name          string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder, sent *bytes.Buffer)
+	}{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserById(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return(unverifiedUser, nil)
+				store.EXPECT().
+					CreateEmailVerification(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateEmailVerificationParams) (db.EmailVerification, error) {
+						require.Equal(t, user.ID, arg.UserID)
+						require.Equal(t, user.Email, arg.Email)
+						require.WithinDuration(t, time.Now().Add(time.Hour), arg.ExpiresAt, time.Second)
+						return db.EmailVerification{UserID: arg.UserID, Email: arg.Email, HashedToken: arg.HashedToken}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, sent *bytes.Buffer) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+				require.Contains(t, sent.String(), "To: "+user.Email)
+				require.Contains(t, sent.String(), "/users/verify?token=")
+			},
+		},
+		{
+			name: "AlreadyVerified",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserById(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					CreateEmailVerification(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, sent *bytes.Buffer) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Zero(t, sent.Len())
+			},
+		},
+		{
+			name: "NoAuthorization",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserById(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, sent *bytes.Buffer) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserById(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return(unverifiedUser, nil)
+				store.EXPECT().
+					CreateEmailVerification(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.EmailVerification{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, sent *bytes.Buffer) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+				require.Zero(t, sent.Len())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			// Init controller and store
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			// Build stub
+			tc.buildStubs(store)
+			// Start test server with a mailer we can inspect
+			server := newTestServer(t, store)
+			sent := &bytes.Buffer{}
+			server.mailer = mail.NewWriterMailer("noreply@beatstore.test", sent)
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodPost, "/users/verify/resend", nil)
+			require.NoError(t, err)
+			tc.setupAuth(t, request, server.tokenMaker)
+			// Server http response
+			server.router.ServeHTTP(recorder, request)
+			// check response
+			tc.checkResponse(t, recorder, sent)
+		})
+	}
+}
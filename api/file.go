@@ -0,0 +1,81 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/danglebary/beatstore-backend-go/storage"
+	"github.com/gin-gonic/gin"
+)
+
+// fileURL returns a time limited download URL for the object stored under key.
+// Backends which can sign URLs themselves are used directly,
+// all others are served by the API through serveFile.
+func (server *Server) fileURL(ctx *gin.Context, key string) (string, error) {
+	url, err := server.blobs.SignedURL(ctx, key, server.config.FileURLDuration)
+	if err == nil {
+		return url, nil
+	}
+	if !errors.Is(err, storage.ErrSignedURLNotSupported) {
+		return "", err
+	}
+
+	token := server.fileSigner.Sign(key, server.now().Add(server.config.FileURLDuration))
+	return fmt.Sprintf("%s/files/%s", server.config.AppBaseURL, token), nil
+}
+
+type serveFileRequest struct {
+	Token string `uri:"token" binding:"required"`
+}
+
+// serveFile streams the object a signed file URL points at
+func (server *Server) serveFile(ctx *gin.Context) {
+	var req serveFileRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	key, err := server.fileSigner.Verify(req.Token, server.now())
+	if err != nil {
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+
+	info, err := server.blobs.Stat(ctx, key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	r, err := server.blobs.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	defer r.Close()
+
+	header := ctx.Writer.Header()
+	header.Set("Content-Type", info.ContentType)
+	header.Set("Content-Length", strconv.FormatInt(info.Size, 10))
+	header.Set("ETag", `"`+info.ETag+`"`)
+	// The URL stops working when the token expires, shared caches must not outlive it
+	header.Set("Cache-Control", "private")
+	ctx.Status(http.StatusOK)
+
+	// The status has been sent, a failed copy can only be logged
+	if _, err := io.Copy(ctx.Writer, r); err != nil {
+		_ = ctx.Error(err)
+	}
+}
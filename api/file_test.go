@@ -0,0 +1,137 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	mockdb "github.com/danglebary/beatstore-backend-go/db/mock"
+	"github.com/danglebary/beatstore-backend-go/storage"
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+// presigningStore stands in for a backend which signs its own URLs, such as S3
+type presigningStore struct {
+	*storage.MemoryStore
+}
+
+func (store presigningStore) SignedURL(ctx context.Context, key string, expires time.Duration) (string, error) {
+	return fmt.Sprintf("https://s3.example.com/%s?expires=%d", key, int(expires.Seconds())), nil
+}
+
+func TestServeFile(t *testing.T) {
+	key := "beats/1/audio/" + util.RandomString(8) + ".mp3"
+	data := randomMP3(256)
+	now := mfaTestTime
+
+	testCases := []struct {
+		name          string
+		buildToken    func(signer *storage.URLSigner) string
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildToken: func(signer *storage.URLSigner) string {
+				return signer.Sign(key, now.Add(time.Minute))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "audio/mpeg", recorder.Header().Get("Content-Type"))
+				require.Equal(t, fmt.Sprint(len(data)), recorder.Header().Get("Content-Length"))
+				require.NotEmpty(t, recorder.Header().Get("ETag"))
+				require.Equal(t, data, recorder.Body.Bytes())
+			},
+		},
+		{
+			name: "Expired",
+			buildToken: func(signer *storage.URLSigner) string {
+				return signer.Sign(key, now.Add(-time.Second))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidSignature",
+			buildToken: func(signer *storage.URLSigner) string {
+				other, err := storage.NewURLSigner(util.RandomString(32))
+				require.NoError(t, err)
+				return other.Sign(key, now.Add(time.Minute))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "NotFound",
+			buildToken: func(signer *storage.URLSigner) string {
+				return signer.Sign("beats/1/audio/deleted.mp3", now.Add(time.Minute))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			// Init controller and store
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			// Start test server with the file stored, build request, and send
+			server := newTestServer(t, store)
+			server.now = func() time.Time { return now }
+			err := server.blobs.Put(context.Background(), key, bytes.NewReader(data), int64(len(data)), "audio/mpeg")
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+			url := "/files/" + tc.buildToken(server.fileSigner)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+			// Server http response
+			server.router.ServeHTTP(recorder, request)
+			// check response
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestFileURL(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	server := newTestServer(t, store)
+	server.now = func() time.Time { return mfaTestTime }
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	key := "beats/1/audio/master.wav"
+
+	// Served by the API for backends without URLs of their own
+	url, err := server.fileURL(ctx, key)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(url, "http://localhost:1337/files/"))
+
+	token := strings.TrimPrefix(url, "http://localhost:1337/files/")
+	got, err := server.fileSigner.Verify(token, mfaTestTime)
+	require.NoError(t, err)
+	require.Equal(t, key, got)
+
+	_, err = server.fileSigner.Verify(token, mfaTestTime.Add(server.config.FileURLDuration))
+	require.ErrorIs(t, err, storage.ErrExpiredURLToken)
+
+	// Delegated to backends which sign URLs themselves
+	server.blobs = presigningStore{storage.NewMemoryStore()}
+	url, err = server.fileURL(ctx, key)
+	require.NoError(t, err)
+	require.Equal(t, "https://s3.example.com/beats/1/audio/master.wav?expires=60", url)
+}
@@ -0,0 +1,280 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
+	"github.com/danglebary/beatstore-backend-go/policy"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+var errLicenseTierExists = errors.New("the beat already has a license of this tier")
+
+// licenseResponse is a tier a beat can be bought in. The price is in the minor unit of the currency,
+// a missing cap means the buyer may distribute or stream without limit.
+type licenseResponse struct {
+	ID              int64    `json:"id"`
+	BeatID          int32    `json:"beat_id"`
+	Tier            string   `json:"tier"`
+	Price           int64    `json:"price"`
+	Currency        string   `json:"currency"`
+	FileTypes       []string `json:"file_types"`
+	DistributionCap *int64   `json:"distribution_cap,omitempty"`
+	StreamCap       *int64   `json:"stream_cap,omitempty"`
+	Terms           string   `json:"terms"`
+	// Sold is set once an exclusive license was bought, it isn't offered anymore
+	Sold      bool      `json:"sold"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func newLicenseResponse(license db.License) licenseResponse {
+	res := licenseResponse{
+		ID:        license.ID,
+		BeatID:    license.BeatID,
+		Tier:      license.Tier,
+		Price:     license.Price,
+		Currency:  license.Currency,
+		FileTypes: license.FileTypes,
+		Terms:     license.Terms,
+		Sold:      license.SoldAt.Valid,
+		CreatedAt: license.CreatedAt,
+		UpdatedAt: license.UpdatedAt,
+	}
+	if license.DistributionCap.Valid {
+		distributionCap := license.DistributionCap.Int64
+		res.DistributionCap = &distributionCap
+	}
+	if license.StreamCap.Valid {
+		streamCap := license.StreamCap.Int64
+		res.StreamCap = &streamCap
+	}
+	return res
+}
+
+func newLicenseResponses(licenses []db.License) []licenseResponse {
+	res := make([]licenseResponse, len(licenses))
+	for i, license := range licenses {
+		res[i] = newLicenseResponse(license)
+	}
+	return res
+}
+
+// nullInt64 converts an optional request value into a nullable column
+func nullInt64(value *int64) sql.NullInt64 {
+	if value == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: *value, Valid: true}
+}
+
+// isUniqueViolation reports whether err was caused by a unique constraint of the database
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code.Name() == "unique_violation"
+}
+
+type licenseRequestParams struct {
+	Tier            string   `json:"tier" binding:"required,oneof=basic premium unlimited exclusive"`
+	Price           int64    `json:"price" binding:"min=0"`
+	Currency        string   `json:"currency" binding:"required,iso4217"`
+	FileTypes       []string `json:"file_types" binding:"required,min=1,unique,dive,oneof=master_wav mp3 tagged_preview stems_zip project_file"`
+	DistributionCap *int64   `json:"distribution_cap" binding:"omitempty,min=1"`
+	StreamCap       *int64   `json:"stream_cap" binding:"omitempty,min=1"`
+	Terms           string   `json:"terms" binding:"required"`
+}
+
+type createLicenseRequestUri struct {
+	ID int32 `uri:"id" binding:"required,min=1"`
+}
+
+// createLicense offers a beat in a new tier, a beat has at most one license per tier
+func (server *Server) createLicense(ctx *gin.Context) {
+	var uri createLicenseRequestUri
+	var req licenseRequestParams
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	beat, err := server.store.GetBeatById(ctx, uri.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if err := policy.CanModifyBeat(getAuthSubject(ctx), beat); err != nil {
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+
+	license, err := server.store.CreateLicense(ctx, db.CreateLicenseParams{
+		BeatID:          beat.ID,
+		Tier:            req.Tier,
+		Price:           req.Price,
+		Currency:        req.Currency,
+		FileTypes:       req.FileTypes,
+		DistributionCap: nullInt64(req.DistributionCap),
+		StreamCap:       nullInt64(req.StreamCap),
+		Terms:           req.Terms,
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			ctx.JSON(http.StatusConflict, errorResponse(errLicenseTierExists))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, newLicenseResponse(license))
+}
+
+type listLicensesRequestUri struct {
+	ID int32 `uri:"id" binding:"required,min=1"`
+}
+
+// listLicenses returns the tiers a beat can be bought in, cheapest first
+func (server *Server) listLicenses(ctx *gin.Context) {
+	var uri listLicensesRequestUri
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	beat, err := server.store.GetBeatById(ctx, uri.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	licenses, err := server.store.ListLicensesByBeat(ctx, beat.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, newLicenseResponses(licenses))
+}
+
+type licenseRequestUri struct {
+	ID        int32 `uri:"id" binding:"required,min=1"`
+	LicenseID int64 `uri:"license_id" binding:"required,min=1"`
+}
+
+// getBeatLicense looks up the license in the uri and checks that the caller may modify its beat,
+// it writes the error response and returns false otherwise. A license of another beat is not found.
+func (server *Server) getBeatLicense(ctx *gin.Context, uri licenseRequestUri) (db.License, bool) {
+	beat, err := server.store.GetBeatById(ctx, uri.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return db.License{}, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return db.License{}, false
+	}
+
+	if err := policy.CanModifyBeat(getAuthSubject(ctx), beat); err != nil {
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return db.License{}, false
+	}
+
+	license, err := server.store.GetLicense(ctx, uri.LicenseID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return db.License{}, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return db.License{}, false
+	}
+	if license.BeatID != beat.ID {
+		ctx.JSON(http.StatusNotFound, errorResponse(sql.ErrNoRows))
+		return db.License{}, false
+	}
+	return license, true
+}
+
+// updateLicense replaces the tier, price and terms of a license. A sold exclusive license
+// can't be changed anymore, the buyer owns it under the terms it was sold with.
+func (server *Server) updateLicense(ctx *gin.Context) {
+	var uri licenseRequestUri
+	var req licenseRequestParams
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	license, ok := server.getBeatLicense(ctx, uri)
+	if !ok {
+		return
+	}
+	if license.SoldAt.Valid {
+		ctx.JSON(http.StatusConflict, errorResponse(db.ErrLicenseSold))
+		return
+	}
+
+	license, err := server.store.UpdateLicense(ctx, db.UpdateLicenseParams{
+		ID:              license.ID,
+		Tier:            req.Tier,
+		Price:           req.Price,
+		Currency:        req.Currency,
+		FileTypes:       req.FileTypes,
+		DistributionCap: nullInt64(req.DistributionCap),
+		StreamCap:       nullInt64(req.StreamCap),
+		Terms:           req.Terms,
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			ctx.JSON(http.StatusConflict, errorResponse(errLicenseTierExists))
+			return
+		}
+		// Sold since it was read
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusConflict, errorResponse(db.ErrLicenseSold))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, newLicenseResponse(license))
+}
+
+// deleteLicense stops offering a beat in the tier of a license
+func (server *Server) deleteLicense(ctx *gin.Context) {
+	var uri licenseRequestUri
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	license, ok := server.getBeatLicense(ctx, uri)
+	if !ok {
+		return
+	}
+
+	if err := server.store.DeleteLicense(ctx, license.ID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
@@ -5,11 +5,11 @@ import (
 	"net/http"
 
 	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
+	"github.com/danglebary/beatstore-backend-go/policy"
 	"github.com/gin-gonic/gin"
 )
 
 type createLikeRequest struct {
-	UserID int32 `json:"user_id" binding:"required,min=1"`
 	BeatID int32 `json:"beat_id" binding:"required,min=1"`
 }
 
@@ -19,8 +19,9 @@ func (server *Server) createLike(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
+	authPayload := getAuthPayload(ctx)
 	arg := db.CreateLikeParams{
-		UserID: req.UserID,
+		UserID: authPayload.UserID,
 		BeatID: req.BeatID,
 	}
 	like, err := server.store.CreateLike(ctx, arg)
@@ -58,6 +59,47 @@ func (server *Server) getLike(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, like)
 }
 
+type deleteLikeRequest struct {
+	UserID int32 `uri:"uid" binding:"required,min=1"`
+	BeatID int32 `uri:"bid" binding:"required,min=1"`
+}
+
+func (server *Server) deleteLike(ctx *gin.Context) {
+	var req deleteLikeRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	like, err := server.store.GetLikeByUserAndBeat(ctx, db.GetLikeByUserAndBeatParams{
+		UserID: req.UserID,
+		BeatID: req.BeatID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if err := policy.CanModifyLike(getAuthSubject(ctx), like); err != nil {
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+
+	arg := db.DeleteLikeParams{
+		UserID: like.UserID,
+		BeatID: like.BeatID,
+	}
+	if err := server.store.DeleteLike(ctx, arg); err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
 type listLikesByUserIDRequestUri struct {
 	ID int32 `uri:"id" binding:"required,min=1"`
 }
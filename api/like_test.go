@@ -9,9 +9,11 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	mockdb "github.com/danglebary/beatstore-backend-go/db/mock"
 	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
+	"github.com/danglebary/beatstore-backend-go/token"
 	"github.com/danglebary/beatstore-backend-go/util"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
@@ -77,22 +79,25 @@ func requireBodyMatchLikes(t *testing.T, body *bytes.Buffer, likes []db.Like) {
 }
 
 func TestCreateLike(t *testing.T) {
-	user := randomUser()
+	user, _ := randomUser(t)
 	beat := randomBeat()
 	like := randomLike(user.ID, beat.ID)
 
 	testCases := []struct {
 		name          string
 		body          createLikeRequest
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
 		buildStubs    func(store *mockdb.MockStore)
 		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
 	}{
 		{
 			name: "OK",
 			body: createLikeRequest{
-				UserID: user.ID,
 				BeatID: beat.ID,
 			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, util.ListenerRole, time.Minute)
+			},
 			buildStubs: func(store *mockdb.MockStore) {
 				arg := db.CreateLikeParams{
 					UserID: user.ID,
@@ -112,9 +117,11 @@ func TestCreateLike(t *testing.T) {
 		{
 			name: "BadRequest",
 			body: createLikeRequest{
-				UserID: 0,
 				BeatID: 0,
 			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, util.ListenerRole, time.Minute)
+			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
 					CreateLike(gomock.Any(), gomock.Any()).
@@ -127,9 +134,11 @@ func TestCreateLike(t *testing.T) {
 		{
 			name: "InternalError",
 			body: createLikeRequest{
-				UserID: user.ID,
 				BeatID: beat.ID,
 			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, util.ListenerRole, time.Minute)
+			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
 					CreateLike(gomock.Any(), gomock.Any()).
@@ -140,6 +149,22 @@ func TestCreateLike(t *testing.T) {
 				require.Equal(t, http.StatusInternalServerError, recorder.Code)
 			},
 		},
+		{
+			name: "NoAuthorization",
+			body: createLikeRequest{
+				BeatID: beat.ID,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateLike(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
 	}
 
 	for i := range testCases {
@@ -153,13 +178,14 @@ func TestCreateLike(t *testing.T) {
 			// Build stub
 			tc.buildStubs(store)
 			// Start test server, build request, and send
-			server := NewServer(store)
+			server := newTestServer(t, store)
 			recorder := httptest.NewRecorder()
 			url := "/likes"
 			data, err := json.Marshal(tc.body)
 			require.NoError(t, err)
 			request, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(data))
 			require.NoError(t, err)
+			tc.setupAuth(t, request, server.tokenMaker)
 			// Server http response
 			server.router.ServeHTTP(recorder, request)
 			// check response
@@ -169,7 +195,7 @@ func TestCreateLike(t *testing.T) {
 }
 
 func TestGetLike(t *testing.T) {
-	user := randomUser()
+	user, _ := randomUser(t)
 	beat := randomBeat()
 	like := randomLike(user.ID, beat.ID)
 
@@ -254,7 +280,7 @@ func TestGetLike(t *testing.T) {
 			// Build stub
 			tc.buildStubs(store)
 			// Start test server, build request, and send
-			server := NewServer(store)
+			server := newTestServer(t, store)
 			recorder := httptest.NewRecorder()
 			url := fmt.Sprintf("/likes/%d/%d", tc.userID, tc.beatID)
 			request, err := http.NewRequest(http.MethodGet, url, nil)
@@ -267,6 +293,147 @@ func TestGetLike(t *testing.T) {
 	}
 }
 
+func TestDeleteLike(t *testing.T) {
+	user, _ := randomUser(t)
+	beat := randomBeat()
+	like := randomLike(user.ID, beat.ID)
+
+	testCases := []struct {
+		name          string
+		userID        int32
+		beatID        int32
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "OK",
+			userID: user.ID,
+			beatID: beat.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, util.ListenerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLikeByUserAndBeat(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(like, nil)
+				arg := db.DeleteLikeParams{
+					UserID: user.ID,
+					BeatID: beat.ID,
+				}
+				store.EXPECT().
+					DeleteLike(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+			},
+		},
+		{
+			name:   "Forbidden",
+			userID: user.ID,
+			beatID: beat.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID+1, util.RandomUsername(), util.ListenerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLikeByUserAndBeat(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(like, nil)
+				store.EXPECT().
+					DeleteLike(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:   "AdminOverride",
+			userID: user.ID,
+			beatID: beat.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID+1, util.RandomUsername(), util.AdminRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLikeByUserAndBeat(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(like, nil)
+				store.EXPECT().
+					DeleteLike(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+			},
+		},
+		{
+			name:   "NotFound",
+			userID: user.ID,
+			beatID: beat.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, util.ListenerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLikeByUserAndBeat(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Like{}, sql.ErrNoRows)
+				store.EXPECT().
+					DeleteLike(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:   "NoAuthorization",
+			userID: user.ID,
+			beatID: beat.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLikeByUserAndBeat(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			// Init controller and store
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			// Build stub
+			tc.buildStubs(store)
+			// Start test server, build request, and send
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+			url := fmt.Sprintf("/likes/%d/%d", tc.userID, tc.beatID)
+			request, err := http.NewRequest(http.MethodDelete, url, nil)
+			require.NoError(t, err)
+			tc.setupAuth(t, request, server.tokenMaker)
+			// Server http response
+			server.router.ServeHTTP(recorder, request)
+			// check response
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
 func TestListLikesByBeatID(t *testing.T) {
 	n := 5
 	beat := randomBeat()
@@ -365,7 +532,7 @@ func TestListLikesByBeatID(t *testing.T) {
 			// Build stub
 			tc.buildStubs(store)
 			// Start test server, build request, and send
-			server := NewServer(store)
+			server := newTestServer(t, store)
 			recorder := httptest.NewRecorder()
 
 			url := fmt.Sprintf("/beats/%d/likes?page_id=%d&page_size=%d", tc.beatID, tc.pageID, tc.pageSize)
@@ -382,7 +549,7 @@ func TestListLikesByBeatID(t *testing.T) {
 
 func TestListLikesByCreatorID(t *testing.T) {
 	n := 5
-	user := randomUser()
+	user, _ := randomUser(t)
 	beatIDs := randomIDs(n)
 	likes := randomLikesForUser(user.ID, beatIDs, n)
 
@@ -478,7 +645,7 @@ func TestListLikesByCreatorID(t *testing.T) {
 			// Build stub
 			tc.buildStubs(store)
 			// Start test server, build request, and send
-			server := NewServer(store)
+			server := newTestServer(t, store)
 			recorder := httptest.NewRecorder()
 
 			url := fmt.Sprintf("/users/%d/likes?page_id=%d&page_size=%d", tc.userID, tc.pageID, tc.pageSize)
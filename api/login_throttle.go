@@ -0,0 +1,246 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
+	"github.com/danglebary/beatstore-backend-go/limiter"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	loginEventFailed    = "login_failed"
+	loginEventLockedOut = "locked_out"
+	loginEventUnlocked  = "unlocked"
+)
+
+var errTooManyLoginAttempts = errors.New("too many failed login attempts, try again later")
+
+func usernameLimiterKey(username string) string {
+	return "username:" + username
+}
+
+func ipLimiterKey(ip string) string {
+	return "ip:" + ip
+}
+
+// userLoginPolicy is applied to the failures of a single username
+func (server *Server) userLoginPolicy() limiter.Policy {
+	return limiter.Policy{
+		LockoutThreshold: server.config.LoginUserLockoutThreshold,
+		LockoutDuration:  server.config.LoginLockoutDuration,
+		BackoffBase:      server.config.LoginBackoffBase,
+		BackoffMax:       server.config.LoginBackoffMax,
+		Window:           server.config.LoginFailureWindow,
+	}
+}
+
+// ipLoginPolicy is applied to the failures of a single client IP.
+// It only locks out, a backoff per IP would slow down every user behind a shared address.
+func (server *Server) ipLoginPolicy() limiter.Policy {
+	return limiter.Policy{
+		LockoutThreshold: server.config.LoginIPLockoutThreshold,
+		LockoutDuration:  server.config.LoginLockoutDuration,
+		Window:           server.config.LoginFailureWindow,
+	}
+}
+
+// checkLoginAllowed responds with 429 and returns false if either the username
+// or the client IP of the request is still blocked by earlier failures
+func (server *Server) checkLoginAllowed(ctx *gin.Context, username string) bool {
+	now := server.now()
+
+	checks := []struct {
+		key    string
+		policy limiter.Policy
+	}{
+		{usernameLimiterKey(username), server.userLoginPolicy()},
+		{ipLimiterKey(ctx.ClientIP()), server.ipLoginPolicy()},
+	}
+
+	var blockedUntil time.Time
+	for _, check := range checks {
+		status, err := server.loginLimiter.Get(ctx, check.key)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return false
+		}
+		// Failures outside of the window no longer count, but a lockout lasts
+		// its full duration from the failure which caused it, however long the window is
+		if !check.policy.IsLockedOut(status) && now.Sub(status.LastFailureAt) > check.policy.Window {
+			continue
+		}
+		if until := check.policy.BlockedUntil(status); until.After(blockedUntil) {
+			blockedUntil = until
+		}
+	}
+
+	if !blockedUntil.After(now) {
+		return true
+	}
+
+	retryAfter := int(math.Ceil(blockedUntil.Sub(now).Seconds()))
+	ctx.Header("Retry-After", strconv.Itoa(retryAfter))
+	ctx.JSON(http.StatusTooManyRequests, errorResponse(errTooManyLoginAttempts))
+	return false
+}
+
+// recordLoginFailure counts a failed login against the username and the client IP
+// and records it in the login events. Errors are attached to the context,
+// the client gets the same answer as for any other failed login.
+func (server *Server) recordLoginFailure(ctx *gin.Context, username string) {
+	now := server.now()
+	clientIP := ctx.ClientIP()
+
+	if _, err := server.loginLimiter.Fail(ctx, ipLimiterKey(clientIP), now, server.config.LoginFailureWindow); err != nil {
+		_ = ctx.Error(err)
+	}
+
+	status, err := server.loginLimiter.Fail(ctx, usernameLimiterKey(username), now, server.config.LoginFailureWindow)
+	if err != nil {
+		_ = ctx.Error(err)
+	}
+
+	events := []string{loginEventFailed}
+	// Only the failure reaching the threshold is recorded as lockout
+	if threshold := server.config.LoginUserLockoutThreshold; threshold > 0 && status.Failures == threshold {
+		events = append(events, loginEventLockedOut)
+	}
+
+	for _, event := range events {
+		_, err := server.store.CreateLoginEvent(ctx, db.CreateLoginEventParams{
+			Username: username,
+			ClientIp: clientIP,
+			Event:    event,
+		})
+		if err != nil {
+			_ = ctx.Error(err)
+		}
+	}
+}
+
+// resetLoginFailures forgets the failures of username after a successful login
+func (server *Server) resetLoginFailures(ctx *gin.Context, username string) {
+	if err := server.loginLimiter.Reset(ctx, usernameLimiterKey(username)); err != nil {
+		_ = ctx.Error(err)
+	}
+}
+
+type unlockUserRequest struct {
+	ID int32 `uri:"id" binding:"required,min=1"`
+}
+
+// unlockUser lets an admin lift the lockout of a user before it expires
+func (server *Server) unlockUser(ctx *gin.Context) {
+	var req unlockUserRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	user, err := server.store.GetUserById(ctx, req.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if err := server.loginLimiter.Reset(ctx, usernameLimiterKey(user.Username)); err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	authPayload := getAuthPayload(ctx)
+	_, err = server.store.CreateLoginEvent(ctx, db.CreateLoginEventParams{
+		Username: user.Username,
+		ClientIp: ctx.ClientIP(),
+		Event:    loginEventUnlocked,
+		ActorID:  sql.NullInt32{Int32: authPayload.UserID, Valid: true},
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+type listLoginEventsRequestUri struct {
+	ID int32 `uri:"id" binding:"required,min=1"`
+}
+
+type listLoginEventsRequestParams struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+type loginEventResponse struct {
+	ID        int64     `json:"id"`
+	Username  string    `json:"username"`
+	ClientIP  string    `json:"client_ip"`
+	Event     string    `json:"event"`
+	ActorID   *int32    `json:"actor_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func newLoginEventResponse(event db.LoginEvent) loginEventResponse {
+	res := loginEventResponse{
+		ID:        event.ID,
+		Username:  event.Username,
+		ClientIP:  event.ClientIp,
+		Event:     event.Event,
+		CreatedAt: event.CreatedAt,
+	}
+	if event.ActorID.Valid {
+		actorID := event.ActorID.Int32
+		res.ActorID = &actorID
+	}
+	return res
+}
+
+// listLoginEvents returns the failed logins, lockouts and unlocks of a user, newest first
+func (server *Server) listLoginEvents(ctx *gin.Context) {
+	var uid listLoginEventsRequestUri
+	var req listLoginEventsRequestParams
+	if err := ctx.ShouldBindUri(&uid); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	user, err := server.store.GetUserById(ctx, uid.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	events, err := server.store.ListLoginEventsByUsername(ctx, db.ListLoginEventsByUsernameParams{
+		Username: user.Username,
+		Limit:    req.PageSize,
+		Offset:   (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	res := make([]loginEventResponse, 0, len(events))
+	for _, event := range events {
+		res = append(res, newLoginEventResponse(event))
+	}
+	ctx.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,484 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/danglebary/beatstore-backend-go/db/mock"
+	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
+	"github.com/danglebary/beatstore-backend-go/token"
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+// newThrottledTestServer returns a test server which backs off after the first failure
+// and locks a username out after three failures
+func newThrottledTestServer(t *testing.T, store db.Store, now *time.Time) *Server {
+	server := newTestServer(t, store)
+	server.config.LoginFailureWindow = 15 * time.Minute
+	server.config.LoginBackoffBase = time.Second
+	server.config.LoginBackoffMax = 10 * time.Second
+	server.config.LoginLockoutDuration = 15 * time.Minute
+	server.config.LoginUserLockoutThreshold = 3
+	server.config.LoginIPLockoutThreshold = 10
+	server.now = func() time.Time { return *now }
+	return server
+}
+
+func sendLogin(t *testing.T, server *Server, username, password string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	data, err := json.Marshal(loginUserRequest{Username: username, Password: password})
+	require.NoError(t, err)
+	request, err := http.NewRequest(http.MethodPost, "/users/login", bytes.NewBuffer(data))
+	require.NoError(t, err)
+	server.router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestLoginUserLockout(t *testing.T) {
+	user, password := randomUser(t)
+	now := mfaTestTime
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	var events []string
+	store.EXPECT().
+		GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+		AnyTimes().
+		Return(user, nil)
+	store.EXPECT().
+		CreateLoginEvent(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		DoAndReturn(func(_ context.Context, arg db.CreateLoginEventParams) (db.LoginEvent, error) {
+			require.Equal(t, user.Username, arg.Username)
+			events = append(events, arg.Event)
+			return db.LoginEvent{Username: arg.Username, Event: arg.Event}, nil
+		})
+
+	server := newThrottledTestServer(t, store, &now)
+
+	// First failure, the next attempt has to wait a second
+	recorder := sendLogin(t, server, user.Username, "incorrect")
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	recorder = sendLogin(t, server, user.Username, password)
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	require.Equal(t, "1", recorder.Header().Get("Retry-After"))
+
+	// Second failure doubles the delay
+	now = now.Add(time.Second)
+	recorder = sendLogin(t, server, user.Username, "incorrect")
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	now = now.Add(time.Second)
+	recorder = sendLogin(t, server, user.Username, password)
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+
+	// Third failure locks the username out
+	now = now.Add(time.Second)
+	recorder = sendLogin(t, server, user.Username, "incorrect")
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	now = now.Add(10 * time.Minute)
+	recorder = sendLogin(t, server, user.Username, password)
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	require.Equal(t, "300", recorder.Header().Get("Retry-After"))
+
+	require.Equal(t, []string{loginEventFailed, loginEventFailed, loginEventFailed, loginEventLockedOut}, events)
+
+	// The lockout expires
+	now = now.Add(5 * time.Minute)
+	store.EXPECT().
+		CreateSession(gomock.Any(), gomock.Any()).
+		Times(2).
+		Return(db.Session{}, nil)
+
+	recorder = sendLogin(t, server, user.Username, password)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	// A successful login clears the failures of the username
+	recorder = sendLogin(t, server, user.Username, password)
+	require.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestLoginUserLockoutLongerThanWindow(t *testing.T) {
+	user, password := randomUser(t)
+	now := mfaTestTime
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	store.EXPECT().
+		GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+		Times(3).
+		Return(user, nil)
+	store.EXPECT().
+		CreateLoginEvent(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		Return(db.LoginEvent{}, nil)
+
+	server := newThrottledTestServer(t, store, &now)
+	server.config.LoginFailureWindow = 5 * time.Minute
+	server.config.LoginLockoutDuration = time.Hour
+
+	for i := 0; i < 3; i++ {
+		now = now.Add(10 * time.Second)
+		recorder := sendLogin(t, server, user.Username, "incorrect")
+		require.Equal(t, http.StatusUnauthorized, recorder.Code)
+	}
+
+	// The failures left the window but the lockout still holds
+	now = now.Add(30 * time.Minute)
+	recorder := sendLogin(t, server, user.Username, password)
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	require.Equal(t, "1800", recorder.Header().Get("Retry-After"))
+}
+
+func TestLoginUserIPLockout(t *testing.T) {
+	now := mfaTestTime
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	store.EXPECT().
+		GetUserByUsername(gomock.Any(), gomock.Any()).
+		Times(10).
+		Return(db.User{}, sql.ErrNoRows)
+	store.EXPECT().
+		CreateLoginEvent(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		Return(db.LoginEvent{}, nil)
+
+	server := newThrottledTestServer(t, store, &now)
+
+	// Every attempt uses another username so only the IP limit applies
+	for i := 0; i < 10; i++ {
+		recorder := sendLogin(t, server, util.RandomUsername(), util.RandomPassword())
+		require.Equal(t, http.StatusUnauthorized, recorder.Code)
+	}
+
+	recorder := sendLogin(t, server, util.RandomUsername(), util.RandomPassword())
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+}
+
+func TestUnlockUser(t *testing.T) {
+	user, password := randomUser(t)
+	admin, _ := randomUser(t)
+	admin.Role = util.AdminRole
+
+	testCases := []struct {
+		name          string
+		userID        int32
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder, server *Server)
+	}{
+		{
+			name:   "OK",
+			userID: user.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin.ID, admin.Username, util.AdminRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserById(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					CreateLoginEvent(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateLoginEventParams) (db.LoginEvent, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.Equal(t, loginEventUnlocked, arg.Event)
+						require.Equal(t, sql.NullInt32{Int32: admin.ID, Valid: true}, arg.ActorID)
+						return db.LoginEvent{Username: arg.Username, Event: arg.Event, ActorID: arg.ActorID}, nil
+					})
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Session{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, server *Server) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+
+				// The user can log in again right away
+				recorder = sendLogin(t, server, user.Username, password)
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "Forbidden",
+			userID: user.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserById(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, server *Server) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:   "NotFound",
+			userID: user.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin.ID, admin.Username, util.AdminRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserById(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().
+					CreateLoginEvent(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, server *Server) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:   "InvalidID",
+			userID: 0,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin.ID, admin.Username, util.AdminRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserById(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, server *Server) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:   "InternalError",
+			userID: user.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin.ID, admin.Username, util.AdminRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserById(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					CreateLoginEvent(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.LoginEvent{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, server *Server) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			// Init controller and store
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			// Build stub
+			tc.buildStubs(store)
+			// Start test server with the user locked out, build request, and send
+			now := mfaTestTime
+			server := newThrottledTestServer(t, store, &now)
+			for i := 0; i < server.config.LoginUserLockoutThreshold; i++ {
+				_, err := server.loginLimiter.Fail(context.Background(), usernameLimiterKey(user.Username), now, server.config.LoginFailureWindow)
+				require.NoError(t, err)
+			}
+			recorder := httptest.NewRecorder()
+			url := fmt.Sprintf("/users/%d/unlock", tc.userID)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+			tc.setupAuth(t, request, server.tokenMaker)
+			// Server http response
+			server.router.ServeHTTP(recorder, request)
+			// check response
+			tc.checkResponse(t, recorder, server)
+		})
+	}
+}
+
+func TestListLoginEvents(t *testing.T) {
+	user, _ := randomUser(t)
+	admin, _ := randomUser(t)
+	admin.Role = util.AdminRole
+
+	events := []db.LoginEvent{
+		{
+			ID:       2,
+			Username: user.Username,
+			ClientIp: "192.0.2.1",
+			Event:    loginEventUnlocked,
+			ActorID:  sql.NullInt32{Int32: admin.ID, Valid: true},
+		},
+		{
+			ID:       1,
+			Username: user.Username,
+			ClientIp: "192.0.2.2",
+			Event:    loginEventFailed,
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		query         string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			query: "page_id=1&page_size=5",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin.ID, admin.Username, util.AdminRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserById(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return(user, nil)
+
+				arg := db.ListLoginEventsByUsernameParams{
+					Username: user.Username,
+					Limit:    5,
+					Offset:   0,
+				}
+				store.EXPECT().
+					ListLoginEventsByUsername(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(events, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got []loginEventResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Len(t, got, 2)
+				require.Equal(t, loginEventUnlocked, got[0].Event)
+				require.NotNil(t, got[0].ActorID)
+				require.Equal(t, admin.ID, *got[0].ActorID)
+				require.Equal(t, loginEventFailed, got[1].Event)
+				require.Nil(t, got[1].ActorID)
+			},
+		},
+		{
+			name:  "Forbidden",
+			query: "page_id=1&page_size=5",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserById(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:  "InvalidPageSize",
+			query: "page_id=1&page_size=100",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin.ID, admin.Username, util.AdminRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserById(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "NotFound",
+			query: "page_id=1&page_size=5",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin.ID, admin.Username, util.AdminRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserById(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().
+					ListLoginEventsByUsername(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:  "InternalError",
+			query: "page_id=1&page_size=5",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin.ID, admin.Username, util.AdminRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserById(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					ListLoginEventsByUsername(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			// Init controller and store
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			// Build stub
+			tc.buildStubs(store)
+			// Start test server, build request, and send
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+			url := fmt.Sprintf("/users/%d/login-events?%s", user.ID, tc.query)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+			tc.setupAuth(t, request, server.tokenMaker)
+			// Server http response
+			server.router.ServeHTTP(recorder, request)
+			// check response
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
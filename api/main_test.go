@@ -1,12 +1,57 @@
 package api
 
 import (
+	"io/ioutil"
 	"os"
 	"testing"
+	"time"
 
+	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
+	"github.com/danglebary/beatstore-backend-go/mail"
+	"github.com/danglebary/beatstore-backend-go/payment"
+	"github.com/danglebary/beatstore-backend-go/storage"
+	"github.com/danglebary/beatstore-backend-go/util"
 	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
 )
 
+func newTestServer(t *testing.T, store db.Store) *Server {
+	config := util.Config{
+		BcryptCost:                 bcrypt.MinCost,
+		TokenSymmetricKey:          util.RandomString(32),
+		AccessTokenDuration:        time.Minute,
+		RefreshTokenDuration:       time.Hour,
+		AppBaseURL:                 "http://localhost:1337",
+		EmailTokenDuration:         time.Hour,
+		PasswordResetTokenDuration: time.Hour,
+		MFATokenDuration:           time.Minute,
+		FileURLSigningKey:          util.RandomString(32),
+		FileURLDuration:            time.Minute,
+		MaxAudioUploadSize:         1 << 20,
+		MaxFileUploadSize:          1 << 20,
+		MaxArtworkUploadSize:       1 << 20,
+		MaxUploadChunkSize:         1 << 16,
+		UploadExpiry:               time.Hour,
+		PlayCountThreshold:         1 << 10,
+		PreviewDuration:            2 * time.Second,
+		PreviewTagInterval:         time.Second,
+		PreviewTagGain:             0.5,
+		JobWorkers:                 1,
+		JobQueueSize:               10,
+		JobTimeout:                 time.Minute,
+		PaymentWebhookSecret:       util.RandomString(32),
+		PaymentWebhookTolerance:    time.Minute,
+	}
+
+	mailer := mail.NewWriterMailer("noreply@beatstore.test", ioutil.Discard)
+	server, err := NewServer(config, store, mailer, storage.NewMemoryStore(), payment.NewFakeProvider())
+	require.NoError(t, err)
+	t.Cleanup(server.jobs.Close)
+
+	return server
+}
+
 func TestMain(m *testing.M) {
 	gin.SetMode(gin.TestMode)
 	os.Exit(m.Run())
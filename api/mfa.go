@@ -0,0 +1,313 @@
+package api
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
+	"github.com/danglebary/beatstore-backend-go/policy"
+	"github.com/danglebary/beatstore-backend-go/totp"
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	totpIssuer = "BeatStore"
+	// recoveryCodeCount is the number of recovery codes handed out when TOTP is enabled
+	recoveryCodeCount = 10
+	// mfaMaxAttempts is the number of codes an MFA challenge may be answered with before it is discarded
+	mfaMaxAttempts = 5
+)
+
+var (
+	errInvalidMFAToken    = errors.New("invalid or expired mfa token")
+	errInvalidMFACode     = errors.New("invalid authentication code")
+	errTOTPAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+)
+
+// newRecoveryCode returns a random code of the form xxxxx-xxxxx
+func newRecoveryCode() (string, error) {
+	b := make([]byte, 7)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	code := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b))[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+// hashRecoveryCode hashes code ignoring case, spaces and dashes the user may type differently
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.NewReplacer("-", "", " ", "").Replace(code)
+	return util.HashSecretToken(code)
+}
+
+// isTOTPCode checks if code looks like a TOTP code rather than a recovery code
+func isTOTPCode(code string) bool {
+	if len(code) != totp.Digits {
+		return false
+	}
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+type mfaUserRequest struct {
+	UserID int32 `uri:"id" binding:"required,min=1"`
+}
+
+type enrollTOTPResponse struct {
+	Secret     string `json:"secret"`
+	OtpauthURI string `json:"otpauth_uri"`
+}
+
+// enrollTOTP generates a new TOTP secret for the user, it is only used
+// for logins once the user confirmed it with a first code
+func (server *Server) enrollTOTP(ctx *gin.Context) {
+	var uri mfaUserRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if err := policy.CanManageMFA(getAuthSubject(ctx), uri.UserID); err != nil {
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	user, err := server.store.UpdateUserTOTPSecret(ctx, db.UpdateUserTOTPSecretParams{
+		ID:         uri.UserID,
+		TotpSecret: sql.NullString{String: secret, Valid: true},
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusConflict, errorResponse(errTOTPAlreadyEnabled))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := enrollTOTPResponse{
+		Secret:     secret,
+		OtpauthURI: totp.URI(totpIssuer, user.Username, secret),
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type confirmTOTPRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type confirmTOTPResponse struct {
+	RecoveryCodes []string            `json:"recovery_codes"`
+	User          privateUserResponse `json:"user"`
+}
+
+// confirmTOTP enables the enrolled secret once the user proves their authenticator
+// produces valid codes, the recovery codes are only shown in this response
+func (server *Server) confirmTOTP(ctx *gin.Context) {
+	var uri mfaUserRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req confirmTOTPRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if err := policy.CanManageMFA(getAuthSubject(ctx), uri.UserID); err != nil {
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+
+	user, err := server.store.GetUserById(ctx, uri.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if user.TotpEnabledAt.Valid {
+		ctx.JSON(http.StatusConflict, errorResponse(errTOTPAlreadyEnabled))
+		return
+	}
+	if !user.TotpSecret.Valid {
+		err := errors.New("two-factor enrollment has not been started")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if !totp.Validate(req.Code, user.TotpSecret.String, server.now()) {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errInvalidMFACode))
+		return
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	hashedCodes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		codes[i], err = newRecoveryCode()
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		hashedCodes[i] = hashRecoveryCode(codes[i])
+	}
+
+	result, err := server.store.EnableTOTPTx(ctx, db.EnableTOTPTxParams{
+		UserID:              user.ID,
+		HashedRecoveryCodes: hashedCodes,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusConflict, errorResponse(errTOTPAlreadyEnabled))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := confirmTOTPResponse{
+		RecoveryCodes: codes,
+		User:          newPrivateUserResponse(result.User),
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type mfaRequiredResponse struct {
+	MFARequired       bool      `json:"mfa_required"`
+	MFAToken          string    `json:"mfa_token"`
+	MFATokenExpiresAt time.Time `json:"mfa_token_expires_at"`
+}
+
+// startMFAChallenge stores a short-lived challenge the user answers with a second factor
+func (server *Server) startMFAChallenge(ctx *gin.Context, user db.User) (mfaRequiredResponse, error) {
+	mfaToken, err := util.GenerateSecretToken(emailTokenSize)
+	if err != nil {
+		return mfaRequiredResponse{}, err
+	}
+
+	challenge, err := server.store.CreateMFAChallenge(ctx, db.CreateMFAChallengeParams{
+		UserID:      user.ID,
+		HashedToken: util.HashSecretToken(mfaToken),
+		ExpiresAt:   server.now().Add(server.config.MFATokenDuration),
+	})
+	if err != nil {
+		return mfaRequiredResponse{}, err
+	}
+
+	rsp := mfaRequiredResponse{
+		MFARequired:       true,
+		MFAToken:          mfaToken,
+		MFATokenExpiresAt: challenge.ExpiresAt,
+	}
+	return rsp, nil
+}
+
+type loginUserMFARequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	// Code is either a TOTP code or one of the recovery codes
+	Code string `json:"code" binding:"required"`
+}
+
+// loginUserMFA is the second step of the login of users with TOTP enabled,
+// it exchanges the mfa token and a valid code for access and refresh tokens.
+// A TOTP code is accepted only once, even while it is still valid.
+func (server *Server) loginUserMFA(ctx *gin.Context) {
+	var req loginUserMFARequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	challenge, err := server.store.GetMFAChallengeByHashedToken(ctx, util.HashSecretToken(req.MFAToken))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusUnauthorized, errorResponse(errInvalidMFAToken))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if challenge.UsedAt.Valid || server.now().After(challenge.ExpiresAt) {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errInvalidMFAToken))
+		return
+	}
+
+	// Every answer is counted before it is checked so concurrent guesses can't exceed the limit
+	challenge, err = server.store.IncrementMFAChallengeAttempts(ctx, db.IncrementMFAChallengeAttemptsParams{
+		ID:          challenge.ID,
+		MaxAttempts: mfaMaxAttempts,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusUnauthorized, errorResponse(errInvalidMFAToken))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	user, err := server.store.GetUserById(ctx, challenge.UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	arg := db.CompleteMFALoginTxParams{
+		ChallengeID: challenge.ID,
+		UserID:      user.ID,
+	}
+	if isTOTPCode(req.Code) {
+		if !user.TotpSecret.Valid {
+			ctx.JSON(http.StatusUnauthorized, errorResponse(errInvalidMFACode))
+			return
+		}
+		step, ok := totp.ValidateStep(req.Code, user.TotpSecret.String, server.now())
+		if !ok {
+			ctx.JSON(http.StatusUnauthorized, errorResponse(errInvalidMFACode))
+			return
+		}
+		arg.TOTPStep = step
+	} else {
+		arg.HashedRecoveryCode = hashRecoveryCode(req.Code)
+	}
+
+	_, err = server.store.CompleteMFALoginTx(ctx, arg)
+	if err != nil {
+		// The challenge was answered concurrently or the code was used before
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusUnauthorized, errorResponse(errInvalidMFACode))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	res, err := server.createLoginSession(ctx, user)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,633 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	mockdb "github.com/danglebary/beatstore-backend-go/db/mock"
+	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
+	"github.com/danglebary/beatstore-backend-go/token"
+	"github.com/danglebary/beatstore-backend-go/totp"
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+// mfaTestTime is the fixed clock of the server in MFA tests
+var mfaTestTime = time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func randomMFAUser(t *testing.T) (db.User, string) {
+	user, password := randomUser(t)
+	user.Role = util.ProducerRole
+
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+
+	user.TotpSecret = sql.NullString{String: secret, Valid: true}
+	user.TotpEnabledAt = sql.NullTime{Time: mfaTestTime.Add(-time.Hour), Valid: true}
+	return user, password
+}
+
+func totpCode(t *testing.T, user db.User, at time.Time) string {
+	code, err := totp.GenerateCode(user.TotpSecret.String, at)
+	require.NoError(t, err)
+	return code
+}
+
+func TestEnrollTOTP(t *testing.T) {
+	user, _ := randomUser(t)
+	user.Role = util.ProducerRole
+
+	testCases := []struct {
+		name          string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUserTOTPSecret(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.UpdateUserTOTPSecretParams) (db.User, error) {
+						require.Equal(t, user.ID, arg.ID)
+						require.True(t, arg.TotpSecret.Valid)
+						enrolled := user
+						enrolled.TotpSecret = arg.TotpSecret
+						return enrolled, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp enrollTOTPResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.NotEmpty(t, rsp.Secret)
+
+				uri, err := url.Parse(rsp.OtpauthURI)
+				require.NoError(t, err)
+				require.Equal(t, "otpauth", uri.Scheme)
+				require.Equal(t, rsp.Secret, uri.Query().Get("secret"))
+				require.Equal(t, totpIssuer, uri.Query().Get("issuer"))
+			},
+		},
+		{
+			name: "AlreadyEnabled",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUserTOTPSecret(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "AdminForOtherUser",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID+1, util.RandomUsername(), util.AdminRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUserTOTPSecret(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "ListenerRole",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, util.ListenerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUserTOTPSecret(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUserTOTPSecret(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			// Init controller and store
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			// Build stub
+			tc.buildStubs(store)
+			// Start test server, build request, and send
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+			url := fmt.Sprintf("/users/%d/mfa/totp", user.ID)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+			tc.setupAuth(t, request, server.tokenMaker)
+			// Server http response
+			server.router.ServeHTTP(recorder, request)
+			// check response
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestConfirmTOTP(t *testing.T) {
+	enabledUser, _ := randomMFAUser(t)
+	user := enabledUser
+	user.TotpEnabledAt = sql.NullTime{}
+
+	testCases := []struct {
+		name          string
+		code          string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			code: totpCode(t, user, mfaTestTime),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserById(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					EnableTOTPTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.EnableTOTPTxParams) (db.EnableTOTPTxResult, error) {
+						require.Equal(t, user.ID, arg.UserID)
+						require.Len(t, arg.HashedRecoveryCodes, recoveryCodeCount)
+						return db.EnableTOTPTxResult{User: enabledUser}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp confirmTOTPResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Len(t, rsp.RecoveryCodes, recoveryCodeCount)
+				require.True(t, rsp.User.MFAEnabled)
+				require.NotContains(t, recorder.Body.String(), user.TotpSecret.String)
+			},
+		},
+		{
+			name: "InvalidCode",
+			code: totpCode(t, user, mfaTestTime.Add(time.Hour)),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserById(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					EnableTOTPTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NotEnrolled",
+			code: totpCode(t, user, mfaTestTime),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				notEnrolled := user
+				notEnrolled.TotpSecret = sql.NullString{}
+
+				store.EXPECT().
+					GetUserById(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return(notEnrolled, nil)
+				store.EXPECT().
+					EnableTOTPTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "AlreadyEnabled",
+			code: totpCode(t, user, mfaTestTime),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserById(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return(enabledUser, nil)
+				store.EXPECT().
+					EnableTOTPTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "Forbidden",
+			code: totpCode(t, user, mfaTestTime),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID+1, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserById(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			code: totpCode(t, user, mfaTestTime),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserById(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					EnableTOTPTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.EnableTOTPTxResult{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			// Init controller and store
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			// Build stub
+			tc.buildStubs(store)
+			// Start test server with a fixed clock, build request, and send
+			server := newTestServer(t, store)
+			server.now = func() time.Time { return mfaTestTime }
+			recorder := httptest.NewRecorder()
+			url := fmt.Sprintf("/users/%d/mfa/totp/confirm", user.ID)
+			data, err := json.Marshal(gin.H{"code": tc.code})
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(data))
+			require.NoError(t, err)
+			tc.setupAuth(t, request, server.tokenMaker)
+			// Server http response
+			server.router.ServeHTTP(recorder, request)
+			// check response
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestLoginUserMFA(t *testing.T) {
+	user, _ := randomMFAUser(t)
+
+	mfaToken, err := util.GenerateSecretToken(emailTokenSize)
+	require.NoError(t, err)
+	challenge := db.MfaChallenge{
+		ID:          util.RandomInt(1, 1000),
+		UserID:      user.ID,
+		HashedToken: util.HashSecretToken(mfaToken),
+		ExpiresAt:   mfaTestTime.Add(time.Minute),
+		CreatedAt:   mfaTestTime,
+	}
+
+	recoveryCode, err := newRecoveryCode()
+	require.NoError(t, err)
+
+	// The step of the code generated at mfaTestTime
+	step := mfaTestTime.Unix() / totp.Period
+
+	// countAttempt expects the answer to be counted against the challenge before it is checked
+	countAttempt := func(store *mockdb.MockStore) {
+		counted := challenge
+		counted.Attempts++
+		store.EXPECT().
+			IncrementMFAChallengeAttempts(gomock.Any(), gomock.Eq(db.IncrementMFAChallengeAttemptsParams{
+				ID:          challenge.ID,
+				MaxAttempts: mfaMaxAttempts,
+			})).
+			Times(1).
+			Return(counted, nil)
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{"mfa_token": mfaToken, "code": totpCode(t, user, mfaTestTime)},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetMFAChallengeByHashedToken(gomock.Any(), gomock.Eq(challenge.HashedToken)).
+					Times(1).
+					Return(challenge, nil)
+				countAttempt(store)
+				store.EXPECT().
+					GetUserById(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return(user, nil)
+				arg := db.CompleteMFALoginTxParams{
+					ChallengeID: challenge.ID,
+					UserID:      user.ID,
+					TOTPStep:    step,
+				}
+				store.EXPECT().
+					CompleteMFALoginTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.CompleteMFALoginTxResult{Challenge: challenge}, nil)
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp loginUserResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.NotEmpty(t, rsp.AccessToken)
+				require.NotEmpty(t, rsp.RefreshToken)
+				require.True(t, rsp.User.MFAEnabled)
+			},
+		},
+		{
+			name: "RecoveryCode",
+			body: gin.H{"mfa_token": mfaToken, "code": recoveryCode},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetMFAChallengeByHashedToken(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(challenge, nil)
+				countAttempt(store)
+				store.EXPECT().
+					GetUserById(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return(user, nil)
+				arg := db.CompleteMFALoginTxParams{
+					ChallengeID:        challenge.ID,
+					UserID:             user.ID,
+					HashedRecoveryCode: hashRecoveryCode(recoveryCode),
+				}
+				store.EXPECT().
+					CompleteMFALoginTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.CompleteMFALoginTxResult{Challenge: challenge}, nil)
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "UsedRecoveryCode",
+			body: gin.H{"mfa_token": mfaToken, "code": recoveryCode},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetMFAChallengeByHashedToken(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(challenge, nil)
+				countAttempt(store)
+				store.EXPECT().
+					GetUserById(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					CompleteMFALoginTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CompleteMFALoginTxResult{}, sql.ErrNoRows)
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "ReusedCode",
+			body: gin.H{"mfa_token": mfaToken, "code": totpCode(t, user, mfaTestTime)},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetMFAChallengeByHashedToken(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(challenge, nil)
+				countAttempt(store)
+				store.EXPECT().
+					GetUserById(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return(user, nil)
+				// A code of this step was accepted before
+				store.EXPECT().
+					CompleteMFALoginTx(gomock.Any(), gomock.Eq(db.CompleteMFALoginTxParams{
+						ChallengeID: challenge.ID,
+						UserID:      user.ID,
+						TOTPStep:    step,
+					})).
+					Times(1).
+					Return(db.CompleteMFALoginTxResult{}, sql.ErrNoRows)
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "WrongCode",
+			body: gin.H{"mfa_token": mfaToken, "code": totpCode(t, user, mfaTestTime.Add(time.Hour))},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetMFAChallengeByHashedToken(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(challenge, nil)
+				countAttempt(store)
+				store.EXPECT().
+					GetUserById(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					CompleteMFALoginTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "TooManyAttempts",
+			body: gin.H{"mfa_token": mfaToken, "code": totpCode(t, user, mfaTestTime)},
+			buildStubs: func(store *mockdb.MockStore) {
+				exhausted := challenge
+				exhausted.Attempts = mfaMaxAttempts
+
+				store.EXPECT().
+					GetMFAChallengeByHashedToken(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(exhausted, nil)
+				// The counter refuses answers beyond the limit, even concurrent ones
+				store.EXPECT().
+					IncrementMFAChallengeAttempts(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.MfaChallenge{}, sql.ErrNoRows)
+				store.EXPECT().
+					GetUserById(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					CompleteMFALoginTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "ExpiredToken",
+			body: gin.H{"mfa_token": mfaToken, "code": totpCode(t, user, mfaTestTime)},
+			buildStubs: func(store *mockdb.MockStore) {
+				expired := challenge
+				expired.ExpiresAt = mfaTestTime.Add(-time.Second)
+
+				store.EXPECT().
+					GetMFAChallengeByHashedToken(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(expired, nil)
+				store.EXPECT().
+					IncrementMFAChallengeAttempts(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					CompleteMFALoginTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "UnknownToken",
+			body: gin.H{"mfa_token": mfaToken, "code": totpCode(t, user, mfaTestTime)},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetMFAChallengeByHashedToken(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.MfaChallenge{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "BadRequest",
+			body: gin.H{"mfa_token": mfaToken},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetMFAChallengeByHashedToken(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			// Init controller and store
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			// Build stub
+			tc.buildStubs(store)
+			// Start test server with a fixed clock, build request, and send
+			server := newTestServer(t, store)
+			server.now = func() time.Time { return mfaTestTime }
+			recorder := httptest.NewRecorder()
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPost, "/users/login/mfa", bytes.NewBuffer(data))
+			require.NoError(t, err)
+			// Server http response
+			server.router.ServeHTTP(recorder, request)
+			// check response
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestHashRecoveryCode(t *testing.T) {
+	code, err := newRecoveryCode()
+	require.NoError(t, err)
+	require.Len(t, code, 11)
+	require.False(t, isTOTPCode(code))
+
+	// Codes are compared regardless of how the user typed them
+	require.Equal(t, hashRecoveryCode(code), hashRecoveryCode(code[:5]+code[6:]))
+	require.Equal(t, hashRecoveryCode(code), hashRecoveryCode(" "+code+" "))
+}
@@ -0,0 +1,145 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
+	"github.com/danglebary/beatstore-backend-go/policy"
+	"github.com/danglebary/beatstore-backend-go/token"
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	authorizationHeaderKey  = "authorization"
+	authorizationTypeBearer = "bearer"
+	authorizationTypeAPIKey = "apikey"
+	authorizationPayloadKey = "authorization_payload"
+)
+
+// authMiddleware rejects requests without a valid bearer token or API key and stores
+// the authenticated user in the context under authorizationPayloadKey.
+// API keys are only accepted when scopes are given and the key was granted all of them.
+func authMiddleware(tokenMaker token.Maker, store db.Store, scopes ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		authorizationHeader := ctx.GetHeader(authorizationHeaderKey)
+		if len(authorizationHeader) == 0 {
+			err := errors.New("authorization header is not provided")
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+
+		fields := strings.Fields(authorizationHeader)
+		if len(fields) != 2 {
+			err := errors.New("invalid authorization header format")
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+
+		authorizationType := strings.ToLower(fields[0])
+		switch authorizationType {
+		case authorizationTypeBearer:
+			accessToken := fields[1]
+			payload, err := tokenMaker.VerifyToken(accessToken, token.AccessToken)
+			if err != nil {
+				ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+				return
+			}
+			ctx.Set(authorizationPayloadKey, payload)
+		case authorizationTypeAPIKey:
+			if len(scopes) == 0 {
+				err := errors.New("api keys are not accepted for this resource")
+				ctx.AbortWithStatusJSON(http.StatusForbidden, errorResponse(err))
+				return
+			}
+
+			key, err := verifyAPIKey(ctx, store, fields[1])
+			if err != nil {
+				if err == sql.ErrNoRows {
+					err = errInvalidAPIKey
+				}
+				if err == errInvalidAPIKey || err == errExpiredAPIKey {
+					ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+					return
+				}
+				ctx.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+				return
+			}
+
+			if !util.HasScopes(key.Scopes, scopes...) {
+				err := fmt.Errorf("api key is missing scopes %s", strings.Join(scopes, ", "))
+				ctx.AbortWithStatusJSON(http.StatusForbidden, errorResponse(err))
+				return
+			}
+
+			// Failing to record the usage must not fail the request
+			if err := store.UpdateAPIKeyLastUsed(ctx, key.ID); err != nil {
+				_ = ctx.Error(err)
+			}
+			ctx.Set(authorizationPayloadKey, newAPIKeyPayload(key))
+		default:
+			err := fmt.Errorf("unsupported authorization type %s", authorizationType)
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// optionalAuthMiddleware authenticates requests carrying an authorization header like authMiddleware
+// and lets anonymous requests through, handlers tell them apart with getOptionalAuthPayload
+func optionalAuthMiddleware(tokenMaker token.Maker, store db.Store) gin.HandlerFunc {
+	auth := authMiddleware(tokenMaker, store)
+	return func(ctx *gin.Context) {
+		if len(ctx.GetHeader(authorizationHeaderKey)) == 0 {
+			ctx.Next()
+			return
+		}
+		auth(ctx)
+	}
+}
+
+// requireRoles rejects authenticated requests whose user does not have one of roles,
+// it must be registered after authMiddleware
+func requireRoles(roles ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		authPayload := getAuthPayload(ctx)
+		for _, role := range roles {
+			if authPayload.Role == role {
+				ctx.Next()
+				return
+			}
+		}
+
+		err := fmt.Errorf("role %s is not allowed to access this resource", authPayload.Role)
+		ctx.AbortWithStatusJSON(http.StatusForbidden, errorResponse(err))
+	}
+}
+
+// getAuthPayload returns the token payload stored by authMiddleware
+func getAuthPayload(ctx *gin.Context) *token.Payload {
+	return ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+}
+
+// getOptionalAuthPayload returns the token payload stored by optionalAuthMiddleware, nil for anonymous requests
+func getOptionalAuthPayload(ctx *gin.Context) *token.Payload {
+	payload, ok := ctx.Get(authorizationPayloadKey)
+	if !ok {
+		return nil
+	}
+	return payload.(*token.Payload)
+}
+
+// getAuthSubject returns the authenticated user as a policy subject
+func getAuthSubject(ctx *gin.Context) policy.Subject {
+	authPayload := getAuthPayload(ctx)
+	return policy.Subject{
+		UserID: authPayload.UserID,
+		Role:   authPayload.Role,
+	}
+}
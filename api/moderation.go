@@ -0,0 +1,148 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/gin-gonic/gin"
+)
+
+type duplicateMatchResponse struct {
+	ID            int64      `json:"id"`
+	BeatID        int32      `json:"beat_id"`
+	MatchedBeatID int32      `json:"matched_beat_id"`
+	Kind          string     `json:"kind"`
+	BitErrorRate  float32    `json:"bit_error_rate"`
+	Rejected      bool       `json:"rejected"`
+	Status        string     `json:"status"`
+	ReviewedBy    *int32     `json:"reviewed_by,omitempty"`
+	ReviewedAt    *time.Time `json:"reviewed_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+func newDuplicateMatchResponse(match db.DuplicateMatch) duplicateMatchResponse {
+	res := duplicateMatchResponse{
+		ID:            match.ID,
+		BeatID:        match.BeatID,
+		MatchedBeatID: match.MatchedBeatID,
+		Kind:          match.Kind,
+		BitErrorRate:  match.BitErrorRate,
+		Rejected:      match.Rejected,
+		Status:        match.Status,
+		CreatedAt:     match.CreatedAt,
+	}
+	if match.ReviewedBy.Valid {
+		reviewedBy := match.ReviewedBy.Int32
+		res.ReviewedBy = &reviewedBy
+	}
+	if match.ReviewedAt.Valid {
+		res.ReviewedAt = &match.ReviewedAt.Time
+	}
+	return res
+}
+
+// moderationQueueResponse is a match in the moderation queue along with the beats involved
+type moderationQueueResponse struct {
+	duplicateMatchResponse
+	BeatTitle            string `json:"beat_title"`
+	BeatCreatorID        int32  `json:"beat_creator_id"`
+	MatchedBeatTitle     string `json:"matched_beat_title"`
+	MatchedBeatCreatorID int32  `json:"matched_beat_creator_id"`
+}
+
+func newModerationQueueResponse(row db.ListDuplicateMatchesRow) moderationQueueResponse {
+	return moderationQueueResponse{
+		duplicateMatchResponse: newDuplicateMatchResponse(db.DuplicateMatch{
+			ID:            row.ID,
+			BeatID:        row.BeatID,
+			MatchedBeatID: row.MatchedBeatID,
+			Kind:          row.Kind,
+			BitErrorRate:  row.BitErrorRate,
+			Rejected:      row.Rejected,
+			Status:        row.Status,
+			ReviewedBy:    row.ReviewedBy,
+			ReviewedAt:    row.ReviewedAt,
+			CreatedAt:     row.CreatedAt,
+		}),
+		BeatTitle:            row.BeatTitle,
+		BeatCreatorID:        row.BeatCreatorID,
+		MatchedBeatTitle:     row.MatchedBeatTitle,
+		MatchedBeatCreatorID: row.MatchedBeatCreatorID,
+	}
+}
+
+type listDuplicateMatchesRequest struct {
+	Status   string `form:"status" binding:"omitempty,oneof=pending confirmed dismissed"`
+	PageID   int32  `form:"page_id" binding:"required,min=1"`
+	PageSize int32  `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+// listDuplicateMatches returns the moderation queue, beats whose audio matches audio uploaded earlier
+// to a beat of another creator, oldest first. Pending matches are listed unless another status is asked for.
+func (server *Server) listDuplicateMatches(ctx *gin.Context) {
+	var req listDuplicateMatchesRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if req.Status == "" {
+		req.Status = util.PendingMatch
+	}
+
+	rows, err := server.store.ListDuplicateMatches(ctx, db.ListDuplicateMatchesParams{
+		Status: req.Status,
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	res := make([]moderationQueueResponse, len(rows))
+	for i, row := range rows {
+		res[i] = newModerationQueueResponse(row)
+	}
+	ctx.JSON(http.StatusOK, res)
+}
+
+type reviewDuplicateMatchRequestUri struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type reviewDuplicateMatchRequestParams struct {
+	Status string `json:"status" binding:"required,oneof=confirmed dismissed"`
+}
+
+// reviewDuplicateMatch records whether an admin confirmed a match as a re-upload or dismissed it,
+// the decision is recorded in the audit log. Acting on a confirmed re-upload is left to the admin.
+func (server *Server) reviewDuplicateMatch(ctx *gin.Context) {
+	var uri reviewDuplicateMatchRequestUri
+	var req reviewDuplicateMatchRequestParams
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	result, err := server.store.ReviewDuplicateMatchTx(ctx, db.ReviewDuplicateMatchTxParams{
+		ActorID: getAuthPayload(ctx).UserID,
+		MatchID: uri.ID,
+		Status:  req.Status,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, newDuplicateMatchResponse(result.Match))
+}
@@ -0,0 +1,342 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/danglebary/beatstore-backend-go/db/mock"
+	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
+	"github.com/danglebary/beatstore-backend-go/token"
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListDuplicateMatches(t *testing.T) {
+	admin, _ := randomUser(t)
+	admin.Role = util.AdminRole
+	producer, _ := randomUser(t)
+	producer.Role = util.ProducerRole
+	beat := randomBeat()
+	matched := randomBeat()
+
+	rows := []db.ListDuplicateMatchesRow{
+		{
+			ID:                   1,
+			BeatID:               beat.ID,
+			MatchedBeatID:        matched.ID,
+			Kind:                 util.ContentHashMatch,
+			Rejected:             true,
+			Status:               util.PendingMatch,
+			BeatTitle:            beat.Title,
+			BeatCreatorID:        beat.CreatorID,
+			MatchedBeatTitle:     matched.Title,
+			MatchedBeatCreatorID: matched.CreatorID,
+		},
+		{
+			ID:            2,
+			BeatID:        beat.ID,
+			MatchedBeatID: matched.ID + 1,
+			Kind:          util.FingerprintMatch,
+			BitErrorRate:  0.15,
+			Status:        util.PendingMatch,
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		query         string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			query: "page_id=1&page_size=5",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin.ID, admin.Username, util.AdminRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListDuplicateMatchesParams{
+					Status: util.PendingMatch,
+					Limit:  5,
+					Offset: 0,
+				}
+				store.EXPECT().
+					ListDuplicateMatches(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(rows, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got []moderationQueueResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Len(t, got, 2)
+				require.Equal(t, int64(1), got[0].ID)
+				require.Equal(t, util.ContentHashMatch, got[0].Kind)
+				require.True(t, got[0].Rejected)
+				require.Equal(t, beat.Title, got[0].BeatTitle)
+				require.Equal(t, beat.CreatorID, got[0].BeatCreatorID)
+				require.Equal(t, matched.ID, got[0].MatchedBeatID)
+				require.Equal(t, matched.Title, got[0].MatchedBeatTitle)
+				require.Equal(t, matched.CreatorID, got[0].MatchedBeatCreatorID)
+				require.Nil(t, got[0].ReviewedBy)
+				require.Equal(t, util.FingerprintMatch, got[1].Kind)
+				require.Equal(t, float32(0.15), got[1].BitErrorRate)
+			},
+		},
+		{
+			name:  "Status",
+			query: "status=dismissed&page_id=2&page_size=5",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin.ID, admin.Username, util.AdminRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListDuplicateMatchesParams{
+					Status: util.DismissedMatch,
+					Limit:  5,
+					Offset: 5,
+				}
+				store.EXPECT().
+					ListDuplicateMatches(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return([]db.ListDuplicateMatchesRow{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.JSONEq(t, "[]", recorder.Body.String())
+			},
+		},
+		{
+			name:  "InvalidStatus",
+			query: "status=deleted&page_id=1&page_size=5",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin.ID, admin.Username, util.AdminRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListDuplicateMatches(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "Forbidden",
+			query: "page_id=1&page_size=5",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, producer.ID, producer.Username, producer.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListDuplicateMatches(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:  "InternalError",
+			query: "page_id=1&page_size=5",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin.ID, admin.Username, util.AdminRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListDuplicateMatches(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			// Init controller and store
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			// Build stub
+			tc.buildStubs(store)
+			// Start test server, build request, and send
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+			url := "/moderation/duplicates?" + tc.query
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+			tc.setupAuth(t, request, server.tokenMaker)
+			// Server http response
+			server.router.ServeHTTP(recorder, request)
+			// check response
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestReviewDuplicateMatch(t *testing.T) {
+	admin, _ := randomUser(t)
+	admin.Role = util.AdminRole
+	producer, _ := randomUser(t)
+	producer.Role = util.ProducerRole
+
+	match := db.DuplicateMatch{
+		ID:            util.RandomInt(1, 1000),
+		BeatID:        int32(util.RandomInt(1, 1000)),
+		MatchedBeatID: int32(util.RandomInt(1, 1000)),
+		Kind:          util.FingerprintMatch,
+		BitErrorRate:  0.1,
+		Status:        util.PendingMatch,
+	}
+
+	testCases := []struct {
+		name          string
+		matchID       int64
+		body          reviewDuplicateMatchRequestParams
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:    "OK",
+			matchID: match.ID,
+			body:    reviewDuplicateMatchRequestParams{Status: util.ConfirmedMatch},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin.ID, admin.Username, util.AdminRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ReviewDuplicateMatchTxParams{
+					ActorID: admin.ID,
+					MatchID: match.ID,
+					Status:  util.ConfirmedMatch,
+				}
+				reviewed := match
+				reviewed.Status = util.ConfirmedMatch
+				reviewed.ReviewedBy = sql.NullInt32{Int32: admin.ID, Valid: true}
+				reviewed.ReviewedAt = sql.NullTime{Time: time.Now(), Valid: true}
+				store.EXPECT().
+					ReviewDuplicateMatchTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.ReviewDuplicateMatchTxResult{Match: reviewed}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got duplicateMatchResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Equal(t, match.ID, got.ID)
+				require.Equal(t, util.ConfirmedMatch, got.Status)
+				require.NotNil(t, got.ReviewedBy)
+				require.Equal(t, admin.ID, *got.ReviewedBy)
+				require.NotNil(t, got.ReviewedAt)
+			},
+		},
+		{
+			name:    "InvalidStatus",
+			matchID: match.ID,
+			body:    reviewDuplicateMatchRequestParams{Status: util.PendingMatch},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin.ID, admin.Username, util.AdminRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ReviewDuplicateMatchTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:    "NotFound",
+			matchID: match.ID,
+			body:    reviewDuplicateMatchRequestParams{Status: util.DismissedMatch},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin.ID, admin.Username, util.AdminRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ReviewDuplicateMatchTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ReviewDuplicateMatchTxResult{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:    "Forbidden",
+			matchID: match.ID,
+			body:    reviewDuplicateMatchRequestParams{Status: util.DismissedMatch},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, producer.ID, producer.Username, producer.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ReviewDuplicateMatchTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:    "InternalError",
+			matchID: match.ID,
+			body:    reviewDuplicateMatchRequestParams{Status: util.DismissedMatch},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin.ID, admin.Username, util.AdminRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ReviewDuplicateMatchTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ReviewDuplicateMatchTxResult{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			// Init controller and store
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			// Build stub
+			tc.buildStubs(store)
+			// Start test server, build request, and send
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+			url := fmt.Sprintf("/moderation/duplicates/%d", tc.matchID)
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(data))
+			require.NoError(t, err)
+			tc.setupAuth(t, request, server.tokenMaker)
+			// Server http response
+			server.router.ServeHTTP(recorder, request)
+			// check response
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -0,0 +1,286 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
+	"github.com/danglebary/beatstore-backend-go/payment"
+	"github.com/danglebary/beatstore-backend-go/policy"
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/gin-gonic/gin"
+)
+
+// orderItemResponse is a license bought with an order, as it was when the order was placed.
+// The beat and license are missing once their creator deleted them.
+type orderItemResponse struct {
+	ID        int64  `json:"id"`
+	BeatID    *int32 `json:"beat_id,omitempty"`
+	LicenseID *int64 `json:"license_id,omitempty"`
+	BeatTitle string `json:"beat_title"`
+	Tier      string `json:"tier"`
+	Price     int64  `json:"price"`
+	Currency  string `json:"currency"`
+}
+
+type orderResponse struct {
+	ID            int64               `json:"id"`
+	BuyerID       int32               `json:"buyer_id"`
+	Status        string              `json:"status"`
+	Total         int64               `json:"total"`
+	Currency      string              `json:"currency"`
+	FailureReason string              `json:"failure_reason,omitempty"`
+	Items         []orderItemResponse `json:"items"`
+	CreatedAt     time.Time           `json:"created_at"`
+	UpdatedAt     time.Time           `json:"updated_at"`
+}
+
+func newOrderResponse(order db.Order, items []db.OrderItem) orderResponse {
+	res := orderResponse{
+		ID:            order.ID,
+		BuyerID:       order.BuyerID,
+		Status:        order.Status,
+		Total:         order.Total,
+		Currency:      order.Currency,
+		FailureReason: order.FailureReason,
+		Items:         make([]orderItemResponse, len(items)),
+		CreatedAt:     order.CreatedAt,
+		UpdatedAt:     order.UpdatedAt,
+	}
+	for i, item := range items {
+		res.Items[i] = orderItemResponse{
+			ID:        item.ID,
+			BeatTitle: item.BeatTitle,
+			Tier:      item.Tier,
+			Price:     item.Price,
+			Currency:  item.Currency,
+		}
+		if item.BeatID.Valid {
+			beatID := item.BeatID.Int32
+			res.Items[i].BeatID = &beatID
+		}
+		if item.LicenseID.Valid {
+			licenseID := item.LicenseID.Int64
+			res.Items[i].LicenseID = &licenseID
+		}
+	}
+	return res
+}
+
+// paymentIdempotencyKey identifies the payment of an order at the provider, retries never charge twice
+func paymentIdempotencyKey(order db.Order) string {
+	return fmt.Sprintf("order-%d", order.ID)
+}
+
+// chargeOrder pays a pending order through the payment provider and marks it paid. A payment which can't be
+// created, declined or not, fails the order and the error is returned along with the failed order, the buyer
+// checks out again with a new order. If an exclusive license went to another order first, the payment is
+// refunded and the order fails with db.ErrLicenseSold. Once the payment exists, other errors leave the order
+// pending with the payment recorded, the webhook of the provider settles it later on.
+func (server *Server) chargeOrder(ctx *gin.Context, order db.Order, paymentMethod string) (db.Order, error) {
+	intent, err := server.payments.CreateIntent(ctx, payment.IntentParams{
+		Amount:         order.Total,
+		Currency:       order.Currency,
+		PaymentMethod:  paymentMethod,
+		IdempotencyKey: paymentIdempotencyKey(order),
+	})
+	if err != nil {
+		// Nothing could match a late event of the provider to the order, it fails right away
+		return server.failOrder(ctx, order, err)
+	}
+
+	order, err = server.store.SetOrderPaymentIntent(ctx, db.SetOrderPaymentIntentParams{
+		ID:              order.ID,
+		PaymentIntentID: sql.NullString{String: intent.ID, Valid: true},
+	})
+	if err != nil {
+		return order, err
+	}
+
+	if _, err := server.payments.Capture(ctx, intent.ID); err != nil {
+		if errors.Is(err, payment.ErrDeclined) {
+			return server.failOrder(ctx, order, err)
+		}
+		return order, err
+	}
+
+	result, err := server.store.TransitionOrderTx(ctx, db.TransitionOrderTxParams{
+		OrderID: order.ID,
+		Status:  util.OrderPaid,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrLicenseSold) {
+			if _, err := server.payments.Refund(ctx, intent.ID); err != nil {
+				return order, err
+			}
+			return server.failOrder(ctx, order, err)
+		}
+		return order, err
+	}
+	return result.Order, nil
+}
+
+// failOrder marks order failed because of reason, which is returned unless the transition itself fails
+func (server *Server) failOrder(ctx *gin.Context, order db.Order, reason error) (db.Order, error) {
+	result, err := server.store.TransitionOrderTx(ctx, db.TransitionOrderTxParams{
+		OrderID:       order.ID,
+		Status:        util.OrderFailed,
+		FailureReason: reason.Error(),
+	})
+	if err != nil {
+		return order, err
+	}
+	return result.Order, reason
+}
+
+type checkoutRequest struct {
+	// PaymentMethod is the token of the buyer's card from the payment provider
+	PaymentMethod string `json:"payment_method" binding:"required"`
+}
+
+// checkout places an order for everything in the cart of the user and charges it. The items are taken out
+// of the cart once the order is paid, a declined payment leaves them in the cart for another attempt.
+// Nothing is charged if a price changed since the items were added or an exclusive license was sold or ordered.
+func (server *Server) checkout(ctx *gin.Context) {
+	var req checkoutRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := getAuthPayload(ctx)
+	cart, err := server.store.GetCartByUser(ctx, sql.NullInt32{Int32: authPayload.UserID, Valid: true})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(db.ErrEmptyCart))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	result, err := server.store.CreateOrderTx(ctx, db.CreateOrderTxParams{
+		BuyerID: authPayload.UserID,
+		CartID:  cart.ID,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrEmptyCart) || errors.Is(err, db.ErrMixedCurrencies) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+		// The buyer has to look at the cart again before paying
+		if errors.Is(err, db.ErrPriceChanged) || errors.Is(err, db.ErrLicenseSold) || errors.Is(err, db.ErrLicenseReserved) {
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	order, err := server.chargeOrder(ctx, result.Order, req.PaymentMethod)
+	if err != nil {
+		if errors.Is(err, payment.ErrDeclined) {
+			ctx.JSON(http.StatusPaymentRequired, errorResponse(err))
+			return
+		}
+		if errors.Is(err, db.ErrLicenseSold) {
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, newOrderResponse(order, result.Items))
+}
+
+type orderRequestUri struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// getOrder returns an order of the user along with the bought licenses
+func (server *Server) getOrder(ctx *gin.Context) {
+	var uri orderRequestUri
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	order, err := server.store.GetOrder(ctx, uri.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if err := policy.CanAccessOrder(getAuthSubject(ctx), order); err != nil {
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+
+	items, err := server.store.ListOrderItems(ctx, order.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, newOrderResponse(order, items))
+}
+
+// refundOrder returns the money of a paid order to the buyer, the refund is recorded in the audit log
+func (server *Server) refundOrder(ctx *gin.Context) {
+	var uri orderRequestUri
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	order, err := server.store.GetOrder(ctx, uri.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if !util.CanTransitionOrder(order.Status, util.OrderRefunded) || !order.PaymentIntentID.Valid {
+		ctx.JSON(http.StatusConflict, errorResponse(db.ErrInvalidOrderTransition))
+		return
+	}
+
+	if _, err := server.payments.Refund(ctx, order.PaymentIntentID.String); err != nil {
+		if errors.Is(err, payment.ErrInvalidIntentStatus) {
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	result, err := server.store.TransitionOrderTx(ctx, db.TransitionOrderTxParams{
+		OrderID: order.ID,
+		Status:  util.OrderRefunded,
+		ActorID: getAuthPayload(ctx).UserID,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrInvalidOrderTransition) {
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	items, err := server.store.ListOrderItems(ctx, order.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, newOrderResponse(result.Order, items))
+}
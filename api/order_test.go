@@ -0,0 +1,856 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/danglebary/beatstore-backend-go/db/mock"
+	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
+	"github.com/danglebary/beatstore-backend-go/payment"
+	"github.com/danglebary/beatstore-backend-go/token"
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func randomOrder(buyerID int32, status string) (db.Order, []db.OrderItem) {
+	order := db.Order{
+		ID:        util.RandomInt(1, 1000),
+		BuyerID:   buyerID,
+		Status:    status,
+		Currency:  "USD",
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+		UpdatedAt: time.Now().UTC().Truncate(time.Second),
+	}
+	items := make([]db.OrderItem, 2)
+	for i := range items {
+		items[i] = db.OrderItem{
+			ID:        util.RandomInt(1, 1000),
+			OrderID:   order.ID,
+			BeatID:    sql.NullInt32{Int32: int32(util.RandomInt(1, 1000)), Valid: true},
+			LicenseID: sql.NullInt64{Int64: util.RandomInt(1, 1000), Valid: true},
+			BeatTitle: util.RandomTitle(),
+			Tier:      util.BasicLicense,
+			Price:     util.RandomInt(100, 10000),
+			Currency:  order.Currency,
+		}
+		order.Total += items[i].Price
+	}
+	return order, items
+}
+
+func requireOrderResponse(t *testing.T, body *bytes.Buffer, order db.Order, items []db.OrderItem) {
+	var got orderResponse
+	require.NoError(t, json.Unmarshal(body.Bytes(), &got))
+	require.Equal(t, newOrderResponse(order, items), got)
+}
+
+func TestCheckout(t *testing.T) {
+	user, _ := randomUser(t)
+	cart := randomCart(user.ID)
+	order, items := randomOrder(user.ID, util.OrderPending)
+	intentID := fmt.Sprintf("pi_fake_%s", paymentIdempotencyKey(order))
+
+	withIntent := order
+	withIntent.PaymentIntentID = sql.NullString{String: intentID, Valid: true}
+	paid := withIntent
+	paid.Status = util.OrderPaid
+	failed := order
+	failed.Status = util.OrderFailed
+	failed.FailureReason = payment.ErrDeclined.Error()
+	unavailable := order
+	unavailable.Status = util.OrderFailed
+	unavailable.FailureReason = payment.ErrFakeUnavailable.Error()
+	soldOut := withIntent
+	soldOut.Status = util.OrderFailed
+	soldOut.FailureReason = db.ErrLicenseSold.Error()
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder, provider *payment.FakeProvider)
+	}{
+		{
+			name: "OK",
+			body: gin.H{"payment_method": payment.FakeCardSucceeds},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetCartByUser(gomock.Any(), gomock.Eq(sql.NullInt32{Int32: user.ID, Valid: true})).
+					Times(1).
+					Return(cart, nil)
+				store.EXPECT().
+					CreateOrderTx(gomock.Any(), gomock.Eq(db.CreateOrderTxParams{BuyerID: user.ID, CartID: cart.ID})).
+					Times(1).
+					Return(db.CreateOrderTxResult{Order: order, Items: items}, nil)
+				store.EXPECT().
+					SetOrderPaymentIntent(gomock.Any(), gomock.Eq(db.SetOrderPaymentIntentParams{
+						ID:              order.ID,
+						PaymentIntentID: withIntent.PaymentIntentID,
+					})).
+					Times(1).
+					Return(withIntent, nil)
+				store.EXPECT().
+					TransitionOrderTx(gomock.Any(), gomock.Eq(db.TransitionOrderTxParams{OrderID: order.ID, Status: util.OrderPaid})).
+					Times(1).
+					Return(db.TransitionOrderTxResult{Order: paid}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, provider *payment.FakeProvider) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireOrderResponse(t, recorder.Body, paid, items)
+
+				intent, ok := provider.Intent(intentID)
+				require.True(t, ok)
+				require.Equal(t, payment.IntentSucceeded, intent.Status)
+				require.Equal(t, order.Total, intent.Amount)
+				require.Equal(t, order.Currency, intent.Currency)
+			},
+		},
+		{
+			name: "EmailNotVerified",
+			body: gin.H{"payment_method": payment.FakeCardSucceeds},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				unverified := user
+				unverified.VerifiedAt = sql.NullTime{}
+				store.EXPECT().
+					GetUserById(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return(unverified, nil)
+				store.EXPECT().
+					GetCartByUser(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					CreateOrderTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, provider *payment.FakeProvider) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "Declined",
+			body: gin.H{"payment_method": payment.FakeCardDeclined},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetCartByUser(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(cart, nil)
+				store.EXPECT().
+					CreateOrderTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CreateOrderTxResult{Order: order, Items: items}, nil)
+				store.EXPECT().
+					SetOrderPaymentIntent(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					TransitionOrderTx(gomock.Any(), gomock.Eq(db.TransitionOrderTxParams{
+						OrderID:       order.ID,
+						Status:        util.OrderFailed,
+						FailureReason: payment.ErrDeclined.Error(),
+					})).
+					Times(1).
+					Return(db.TransitionOrderTxResult{Order: failed}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, provider *payment.FakeProvider) {
+				require.Equal(t, http.StatusPaymentRequired, recorder.Code)
+
+				_, ok := provider.Intent(intentID)
+				require.False(t, ok)
+			},
+		},
+		{
+			name: "ProviderUnavailable",
+			body: gin.H{"payment_method": payment.FakeCardUnavailable},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetCartByUser(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(cart, nil)
+				store.EXPECT().
+					CreateOrderTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CreateOrderTxResult{Order: order, Items: items}, nil)
+				store.EXPECT().
+					SetOrderPaymentIntent(gomock.Any(), gomock.Any()).
+					Times(0)
+				// The order can't be left pending without a payment
+				store.EXPECT().
+					TransitionOrderTx(gomock.Any(), gomock.Eq(db.TransitionOrderTxParams{
+						OrderID:       order.ID,
+						Status:        util.OrderFailed,
+						FailureReason: payment.ErrFakeUnavailable.Error(),
+					})).
+					Times(1).
+					Return(db.TransitionOrderTxResult{Order: unavailable}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, provider *payment.FakeProvider) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+
+				_, ok := provider.Intent(intentID)
+				require.False(t, ok)
+			},
+		},
+		{
+			name: "CaptureDeclined",
+			body: gin.H{"payment_method": payment.FakeCardCaptureDeclined},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetCartByUser(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(cart, nil)
+				store.EXPECT().
+					CreateOrderTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CreateOrderTxResult{Order: order, Items: items}, nil)
+				store.EXPECT().
+					SetOrderPaymentIntent(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(withIntent, nil)
+				store.EXPECT().
+					TransitionOrderTx(gomock.Any(), gomock.Eq(db.TransitionOrderTxParams{
+						OrderID:       order.ID,
+						Status:        util.OrderFailed,
+						FailureReason: payment.ErrDeclined.Error(),
+					})).
+					Times(1).
+					Return(db.TransitionOrderTxResult{Order: failed}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, provider *payment.FakeProvider) {
+				require.Equal(t, http.StatusPaymentRequired, recorder.Code)
+
+				intent, ok := provider.Intent(intentID)
+				require.True(t, ok)
+				require.Equal(t, payment.IntentRequiresCapture, intent.Status)
+			},
+		},
+		{
+			name: "NoCart",
+			body: gin.H{"payment_method": payment.FakeCardSucceeds},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetCartByUser(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Cart{}, sql.ErrNoRows)
+				store.EXPECT().
+					CreateOrderTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, provider *payment.FakeProvider) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "EmptyCart",
+			body: gin.H{"payment_method": payment.FakeCardSucceeds},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetCartByUser(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(cart, nil)
+				store.EXPECT().
+					CreateOrderTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CreateOrderTxResult{}, db.ErrEmptyCart)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, provider *payment.FakeProvider) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "MixedCurrencies",
+			body: gin.H{"payment_method": payment.FakeCardSucceeds},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetCartByUser(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(cart, nil)
+				store.EXPECT().
+					CreateOrderTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CreateOrderTxResult{}, db.ErrMixedCurrencies)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, provider *payment.FakeProvider) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "PriceChanged",
+			body: gin.H{"payment_method": payment.FakeCardSucceeds},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetCartByUser(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(cart, nil)
+				store.EXPECT().
+					CreateOrderTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CreateOrderTxResult{}, db.ErrPriceChanged)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, provider *payment.FakeProvider) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "LicenseSold",
+			body: gin.H{"payment_method": payment.FakeCardSucceeds},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetCartByUser(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(cart, nil)
+				store.EXPECT().
+					CreateOrderTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CreateOrderTxResult{}, db.ErrLicenseSold)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, provider *payment.FakeProvider) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "LicenseReserved",
+			body: gin.H{"payment_method": payment.FakeCardSucceeds},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetCartByUser(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(cart, nil)
+				store.EXPECT().
+					CreateOrderTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CreateOrderTxResult{}, db.ErrLicenseReserved)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, provider *payment.FakeProvider) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "SoldWhilePaying",
+			body: gin.H{"payment_method": payment.FakeCardSucceeds},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetCartByUser(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(cart, nil)
+				store.EXPECT().
+					CreateOrderTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CreateOrderTxResult{Order: order, Items: items}, nil)
+				store.EXPECT().
+					SetOrderPaymentIntent(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(withIntent, nil)
+				store.EXPECT().
+					TransitionOrderTx(gomock.Any(), gomock.Eq(db.TransitionOrderTxParams{OrderID: order.ID, Status: util.OrderPaid})).
+					Times(1).
+					Return(db.TransitionOrderTxResult{}, db.ErrLicenseSold)
+				store.EXPECT().
+					TransitionOrderTx(gomock.Any(), gomock.Eq(db.TransitionOrderTxParams{
+						OrderID:       order.ID,
+						Status:        util.OrderFailed,
+						FailureReason: db.ErrLicenseSold.Error(),
+					})).
+					Times(1).
+					Return(db.TransitionOrderTxResult{Order: soldOut}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, provider *payment.FakeProvider) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+
+				// The buyer gets the money back
+				intent, ok := provider.Intent(intentID)
+				require.True(t, ok)
+				require.Equal(t, payment.IntentRefunded, intent.Status)
+			},
+		},
+		{
+			name: "MissingPaymentMethod",
+			body: gin.H{},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetCartByUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, provider *payment.FakeProvider) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NoAuthorization",
+			body: gin.H{"payment_method": payment.FakeCardSucceeds},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetCartByUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, provider *payment.FakeProvider) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: gin.H{"payment_method": payment.FakeCardSucceeds},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetCartByUser(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(cart, nil)
+				store.EXPECT().
+					CreateOrderTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CreateOrderTxResult{Order: order, Items: items}, nil)
+				store.EXPECT().
+					SetOrderPaymentIntent(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Order{}, sql.ErrConnDone)
+				store.EXPECT().
+					TransitionOrderTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, provider *payment.FakeProvider) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			// Init controller and store
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			// Build stub
+			tc.buildStubs(store)
+			stubVerifiedUsers(store)
+			// Start test server, build request, and send
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPost, "/checkout", bytes.NewReader(data))
+			require.NoError(t, err)
+			tc.setupAuth(t, request, server.tokenMaker)
+			// Server http response
+			server.router.ServeHTTP(recorder, request)
+			// check response
+			tc.checkResponse(t, recorder, server.payments.(*payment.FakeProvider))
+		})
+	}
+}
+
+func TestGetOrder(t *testing.T) {
+	user, _ := randomUser(t)
+	order, items := randomOrder(user.ID, util.OrderPaid)
+
+	testCases := []struct {
+		name          string
+		orderID       int64
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:    "OK",
+			orderID: order.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetOrder(gomock.Any(), gomock.Eq(order.ID)).
+					Times(1).
+					Return(order, nil)
+				store.EXPECT().
+					ListOrderItems(gomock.Any(), gomock.Eq(order.ID)).
+					Times(1).
+					Return(items, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireOrderResponse(t, recorder.Body, order, items)
+			},
+		},
+		{
+			name:    "DeletedBeat",
+			orderID: order.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				deleted := []db.OrderItem{items[0]}
+				deleted[0].BeatID = sql.NullInt32{}
+				deleted[0].LicenseID = sql.NullInt64{}
+				store.EXPECT().
+					GetOrder(gomock.Any(), gomock.Eq(order.ID)).
+					Times(1).
+					Return(order, nil)
+				store.EXPECT().
+					ListOrderItems(gomock.Any(), gomock.Eq(order.ID)).
+					Times(1).
+					Return(deleted, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got orderResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Len(t, got.Items, 1)
+				require.Nil(t, got.Items[0].BeatID)
+				require.Nil(t, got.Items[0].LicenseID)
+				require.Equal(t, items[0].BeatTitle, got.Items[0].BeatTitle)
+				require.Equal(t, items[0].Price, got.Items[0].Price)
+			},
+		},
+		{
+			name:    "AdminOK",
+			orderID: order.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID+1, util.RandomUsername(), util.AdminRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetOrder(gomock.Any(), gomock.Eq(order.ID)).
+					Times(1).
+					Return(order, nil)
+				store.EXPECT().
+					ListOrderItems(gomock.Any(), gomock.Eq(order.ID)).
+					Times(1).
+					Return(items, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:    "Forbidden",
+			orderID: order.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID+1, util.RandomUsername(), util.ListenerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetOrder(gomock.Any(), gomock.Eq(order.ID)).
+					Times(1).
+					Return(order, nil)
+				store.EXPECT().
+					ListOrderItems(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:    "NotFound",
+			orderID: order.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetOrder(gomock.Any(), gomock.Eq(order.ID)).
+					Times(1).
+					Return(db.Order{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:    "InvalidID",
+			orderID: 0,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetOrder(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:    "NoAuthorization",
+			orderID: order.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetOrder(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			// Init controller and store
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			// Build stub
+			tc.buildStubs(store)
+			// Start test server, build request, and send
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+			url := fmt.Sprintf("/orders/%d", tc.orderID)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+			tc.setupAuth(t, request, server.tokenMaker)
+			// Server http response
+			server.router.ServeHTTP(recorder, request)
+			// check response
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestRefundOrder(t *testing.T) {
+	admin, _ := randomUser(t)
+	admin.Role = util.AdminRole
+	buyer, _ := randomUser(t)
+	order, items := randomOrder(buyer.ID, util.OrderPaid)
+	order.PaymentIntentID = sql.NullString{
+		String: fmt.Sprintf("pi_fake_%s", paymentIdempotencyKey(order)),
+		Valid:  true,
+	}
+	refunded := order
+	refunded.Status = util.OrderRefunded
+	pending := order
+	pending.Status = util.OrderPending
+
+	// capture charges the order at the provider like a checkout would
+	capture := func(t *testing.T, provider *payment.FakeProvider) {
+		intent, err := provider.CreateIntent(context.Background(), payment.IntentParams{
+			Amount:         order.Total,
+			Currency:       order.Currency,
+			PaymentMethod:  payment.FakeCardSucceeds,
+			IdempotencyKey: paymentIdempotencyKey(order),
+		})
+		require.NoError(t, err)
+		_, err = provider.Capture(context.Background(), intent.ID)
+		require.NoError(t, err)
+	}
+
+	testCases := []struct {
+		name          string
+		setupPayments func(t *testing.T, provider *payment.FakeProvider)
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder, provider *payment.FakeProvider)
+	}{
+		{
+			name:          "OK",
+			setupPayments: capture,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin.ID, admin.Username, admin.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetOrder(gomock.Any(), gomock.Eq(order.ID)).
+					Times(1).
+					Return(order, nil)
+				store.EXPECT().
+					TransitionOrderTx(gomock.Any(), gomock.Eq(db.TransitionOrderTxParams{
+						OrderID: order.ID,
+						Status:  util.OrderRefunded,
+						ActorID: admin.ID,
+					})).
+					Times(1).
+					Return(db.TransitionOrderTxResult{Order: refunded}, nil)
+				store.EXPECT().
+					ListOrderItems(gomock.Any(), gomock.Eq(order.ID)).
+					Times(1).
+					Return(items, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, provider *payment.FakeProvider) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireOrderResponse(t, recorder.Body, refunded, items)
+
+				intent, ok := provider.Intent(order.PaymentIntentID.String)
+				require.True(t, ok)
+				require.Equal(t, payment.IntentRefunded, intent.Status)
+			},
+		},
+		{
+			name:          "NotPaid",
+			setupPayments: func(t *testing.T, provider *payment.FakeProvider) {},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin.ID, admin.Username, admin.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetOrder(gomock.Any(), gomock.Eq(order.ID)).
+					Times(1).
+					Return(pending, nil)
+				store.EXPECT().
+					TransitionOrderTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, provider *payment.FakeProvider) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:          "RefundedConcurrently",
+			setupPayments: capture,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin.ID, admin.Username, admin.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetOrder(gomock.Any(), gomock.Eq(order.ID)).
+					Times(1).
+					Return(order, nil)
+				store.EXPECT().
+					TransitionOrderTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TransitionOrderTxResult{}, db.ErrInvalidOrderTransition)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, provider *payment.FakeProvider) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:          "NotCapturedAtProvider",
+			setupPayments: func(t *testing.T, provider *payment.FakeProvider) {},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin.ID, admin.Username, admin.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetOrder(gomock.Any(), gomock.Eq(order.ID)).
+					Times(1).
+					Return(order, nil)
+				store.EXPECT().
+					TransitionOrderTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, provider *payment.FakeProvider) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name:          "NotFound",
+			setupPayments: func(t *testing.T, provider *payment.FakeProvider) {},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin.ID, admin.Username, admin.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetOrder(gomock.Any(), gomock.Eq(order.ID)).
+					Times(1).
+					Return(db.Order{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, provider *payment.FakeProvider) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:          "NotAdmin",
+			setupPayments: capture,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, buyer.ID, buyer.Username, buyer.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetOrder(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, provider *payment.FakeProvider) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+
+				intent, ok := provider.Intent(order.PaymentIntentID.String)
+				require.True(t, ok)
+				require.Equal(t, payment.IntentSucceeded, intent.Status)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			// Init controller and store
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			// Build stub
+			tc.buildStubs(store)
+			// Start test server, build request, and send
+			server := newTestServer(t, store)
+			provider := server.payments.(*payment.FakeProvider)
+			tc.setupPayments(t, provider)
+			recorder := httptest.NewRecorder()
+			url := fmt.Sprintf("/orders/%d/refund", order.ID)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+			tc.setupAuth(t, request, server.tokenMaker)
+			// Server http response
+			server.router.ServeHTTP(recorder, request)
+			// check response
+			tc.checkResponse(t, recorder, provider)
+		})
+	}
+}
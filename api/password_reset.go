@@ -0,0 +1,136 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
+	"github.com/danglebary/beatstore-backend-go/jobs"
+	"github.com/danglebary/beatstore-backend-go/mail"
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/gin-gonic/gin"
+)
+
+var errInvalidResetToken = errors.New("invalid or expired password reset token")
+
+func (server *Server) sendPasswordResetEmail(ctx context.Context, user db.User, resetToken string) error {
+	link := fmt.Sprintf("%s/users/password/reset?token=%s", server.config.AppBaseURL, url.QueryEscape(resetToken))
+	msg := mail.Message{
+		To:      user.Email,
+		Subject: "Reset your BeatStore password",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nsomeone asked to reset the password of your account. Open the link below to choose a new one:\n\n%s\n\nThe link expires in %s. If you did not ask for a reset you can ignore this email.",
+			user.Username, link, server.config.PasswordResetTokenDuration,
+		),
+	}
+	return server.mailer.Send(ctx, msg)
+}
+
+// passwordResetJob creates a password reset for the account of email and mails its link,
+// addresses which don't belong to an account are ignored
+func (server *Server) passwordResetJob(email string) jobs.Job {
+	return jobs.Job{
+		Name: "password reset",
+		Run: func(ctx context.Context) error {
+			user, err := server.store.GetUserByEmail(ctx, email)
+			if err != nil {
+				if err == sql.ErrNoRows {
+					return nil
+				}
+				return err
+			}
+
+			resetToken, hashedToken, expiresAt, err := newEmailToken(server.config.PasswordResetTokenDuration)
+			if err != nil {
+				return err
+			}
+
+			_, err = server.store.CreatePasswordReset(ctx, db.CreatePasswordResetParams{
+				UserID:      user.ID,
+				HashedToken: hashedToken,
+				ExpiresAt:   expiresAt,
+			})
+			if err != nil {
+				return err
+			}
+
+			return server.sendPasswordResetEmail(ctx, user, resetToken)
+		},
+	}
+}
+
+type forgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// forgotPassword mails a password reset link to the owner of an email address.
+// The account is looked up in the background, so neither the answer nor its timing
+// tells the caller whether the address belongs to an account.
+func (server *Server) forgotPassword(ctx *gin.Context) {
+	var req forgotPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	job := server.passwordResetJob(req.Email)
+	if err := server.jobs.Enqueue(job); err != nil {
+		_ = ctx.Error(fmt.Errorf("cannot schedule %s: %w", job.Name, err))
+	}
+	ctx.Status(http.StatusAccepted)
+}
+
+type resetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// resetPassword sets a new password using a reset token and logs the user out everywhere
+func (server *Server) resetPassword(ctx *gin.Context) {
+	var req resetPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	reset, err := server.store.GetPasswordResetByHashedToken(ctx, util.HashSecretToken(req.Token))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusBadRequest, errorResponse(errInvalidResetToken))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if reset.UsedAt.Valid || time.Now().After(reset.ExpiresAt) {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errInvalidResetToken))
+		return
+	}
+
+	hashedPassword, err := util.HashPassword(req.Password, server.config.BcryptCost)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	_, err = server.store.ResetPasswordTx(ctx, db.ResetPasswordTxParams{
+		PasswordResetID: reset.ID,
+		UserID:          reset.UserID,
+		HashedPassword:  hashedPassword,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusBadRequest, errorResponse(errInvalidResetToken))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
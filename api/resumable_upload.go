@@ -0,0 +1,406 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
+	"github.com/danglebary/beatstore-backend-go/policy"
+	"github.com/danglebary/beatstore-backend-go/uploads"
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Headers of the resumable upload protocol, named like their tus counterparts
+const (
+	uploadOffsetHeader  = "Upload-Offset"
+	uploadLengthHeader  = "Upload-Length"
+	uploadExpiresHeader = "Upload-Expires"
+)
+
+// chunkContentType is the content type of the body of every chunk
+const chunkContentType = "application/offset+octet-stream"
+
+var (
+	errInvalidOffset     = errors.New("Upload-Offset header must be a non-negative integer")
+	errOffsetMismatch    = errors.New("chunk doesn't start at the current offset of the upload")
+	errChunkPastEnd      = errors.New("chunk reaches past the announced size of the upload")
+	errEmptyChunk        = errors.New("chunk is empty")
+	errUploadIncomplete  = errors.New("upload hasn't received all of its bytes")
+	errUploadFinalizing  = errors.New("upload is being finalized")
+	errChecksumMismatch  = errors.New("checksum of the uploaded file doesn't match the announced one")
+	errChunkLengthNeeded = errors.New("chunk must be sent with a Content-Length")
+)
+
+// uploadResponse describes the progress of a resumable upload
+type uploadResponse struct {
+	ID       uuid.UUID `json:"id"`
+	BeatID   int32     `json:"beat_id"`
+	Type     string    `json:"type"`
+	Filename string    `json:"filename"`
+	Size     int64     `json:"size"`
+	// Offset is the number of bytes received, the next chunk starts there
+	Offset    int64     `json:"offset"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (server *Server) newUploadResponse(upload db.Upload) uploadResponse {
+	return uploadResponse{
+		ID:        upload.ID,
+		BeatID:    upload.BeatID,
+		Type:      upload.FileType,
+		Filename:  upload.Filename,
+		Size:      upload.Size,
+		Offset:    upload.Received,
+		ExpiresAt: upload.UpdatedAt.Add(server.config.UploadExpiry),
+	}
+}
+
+// setUploadHeaders reports the progress of upload in the headers of the protocol
+func (server *Server) setUploadHeaders(ctx *gin.Context, upload db.Upload) {
+	ctx.Header(uploadOffsetHeader, strconv.FormatInt(upload.Received, 10))
+	ctx.Header(uploadLengthHeader, strconv.FormatInt(upload.Size, 10))
+	ctx.Header(uploadExpiresHeader, upload.UpdatedAt.Add(server.config.UploadExpiry).UTC().Format(http.TimeFormat))
+	ctx.Header("Cache-Control", "no-store")
+}
+
+type createUploadRequest struct {
+	BeatID   int32  `json:"beat_id" binding:"required,min=1"`
+	Type     string `json:"type" binding:"required,oneof=audio stems_zip project_file"`
+	Filename string `json:"filename" binding:"required,max=255"`
+	Size     int64  `json:"size" binding:"required,min=1"`
+	// Checksum is the hex encoded SHA-256 of the whole file, it is verified at finalize
+	Checksum string `json:"checksum" binding:"required,len=64,hexadecimal"`
+}
+
+// createUpload starts a resumable upload of audio, stems or a project file of a beat.
+// The file is sent in chunks with uploadChunk and attached to the beat by finalizeUpload,
+// uploads which receive nothing for the configured expiry are deleted by the sweeper.
+func (server *Server) createUpload(ctx *gin.Context) {
+	var req createUploadRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	beat, err := server.store.GetBeatById(ctx, req.BeatID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	subject := getAuthSubject(ctx)
+	if err := policy.CanModifyBeat(subject, beat); err != nil {
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+
+	maxSize := server.config.MaxFileUploadSize
+	if req.Type == util.AudioUpload {
+		maxSize = server.config.MaxAudioUploadSize
+	}
+	if req.Size > maxSize {
+		err := fmt.Errorf("file exceeds the limit of %d bytes", maxSize)
+		ctx.JSON(http.StatusRequestEntityTooLarge, errorResponse(err))
+		return
+	}
+
+	upload, err := server.store.CreateUpload(ctx, db.CreateUploadParams{
+		ID:       uuid.New(),
+		UserID:   subject.UserID,
+		BeatID:   beat.ID,
+		FileType: req.Type,
+		Filename: req.Filename,
+		Size:     req.Size,
+		Checksum: strings.ToLower(req.Checksum),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.Header("Location", "/uploads/"+upload.ID.String())
+	ctx.JSON(http.StatusCreated, server.newUploadResponse(upload))
+}
+
+type uploadRequestUri struct {
+	ID string `uri:"id" binding:"required,uuid"`
+}
+
+// authorizedUpload returns the upload named in the URI if the user may modify it.
+// If ok is false the error response has already been sent.
+func (server *Server) authorizedUpload(ctx *gin.Context) (upload db.Upload, ok bool) {
+	var req uploadRequestUri
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return db.Upload{}, false
+	}
+
+	upload, err := server.store.GetUpload(ctx, uuid.MustParse(req.ID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return db.Upload{}, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return db.Upload{}, false
+	}
+
+	if err := policy.CanModifyUpload(getAuthSubject(ctx), upload); err != nil {
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return db.Upload{}, false
+	}
+	return upload, true
+}
+
+// getUploadOffset tells a client resuming an upload where to continue
+func (server *Server) getUploadOffset(ctx *gin.Context) {
+	upload, ok := server.authorizedUpload(ctx)
+	if !ok {
+		return
+	}
+
+	server.setUploadHeaders(ctx, upload)
+	ctx.Status(http.StatusOK)
+}
+
+// uploadChunk stores the request body as the chunk of an upload starting at the Upload-Offset header.
+// Chunks must be sent in order, a chunk which doesn't start at the current offset is rejected
+// with the current offset in the response headers.
+func (server *Server) uploadChunk(ctx *gin.Context) {
+	if ctx.ContentType() != chunkContentType {
+		err := fmt.Errorf("chunks must be sent as %s", chunkContentType)
+		ctx.JSON(http.StatusUnsupportedMediaType, errorResponse(err))
+		return
+	}
+
+	offset, err := strconv.ParseInt(ctx.GetHeader(uploadOffsetHeader), 10, 64)
+	if err != nil || offset < 0 {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errInvalidOffset))
+		return
+	}
+
+	length := ctx.Request.ContentLength
+	switch {
+	case length < 0:
+		ctx.JSON(http.StatusLengthRequired, errorResponse(errChunkLengthNeeded))
+		return
+	case length == 0:
+		ctx.JSON(http.StatusBadRequest, errorResponse(errEmptyChunk))
+		return
+	case length > server.config.MaxUploadChunkSize:
+		err := fmt.Errorf("chunk exceeds the limit of %d bytes", server.config.MaxUploadChunkSize)
+		ctx.JSON(http.StatusRequestEntityTooLarge, errorResponse(err))
+		return
+	}
+
+	upload, ok := server.authorizedUpload(ctx)
+	if !ok {
+		return
+	}
+
+	if upload.Finalizing {
+		ctx.JSON(http.StatusConflict, errorResponse(errUploadFinalizing))
+		return
+	}
+	if offset != upload.Received {
+		server.setUploadHeaders(ctx, upload)
+		ctx.JSON(http.StatusConflict, errorResponse(errOffsetMismatch))
+		return
+	}
+	if offset+length > upload.Size {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errChunkPastEnd))
+		return
+	}
+
+	key := uploads.ChunkKey(upload.ID, offset)
+	body := http.MaxBytesReader(ctx.Writer, ctx.Request.Body, length)
+	if err := server.blobs.Put(ctx, key, body, length, chunkContentType); err != nil {
+		if err := server.blobs.Delete(ctx, key); err != nil {
+			_ = ctx.Error(err)
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	advanced, err := server.store.AdvanceUpload(ctx, db.AdvanceUploadParams{
+		ID:          upload.ID,
+		ChunkOffset: offset,
+		Length:      length,
+	})
+	if err != nil {
+		// The chunk isn't counted, it must not end up in the file
+		if err := server.blobs.Delete(ctx, key); err != nil {
+			_ = ctx.Error(err)
+		}
+		if err == sql.ErrNoRows {
+			// Another request for the same offset got there first
+			ctx.JSON(http.StatusConflict, errorResponse(errOffsetMismatch))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	server.setUploadHeaders(ctx, advanced)
+	ctx.Status(http.StatusNoContent)
+}
+
+// finalizeUpload assembles the chunks of a complete upload, verifies the checksum announced at its creation
+// and attaches the file to the beat like a direct upload would. The response is the one of the direct upload,
+// the updated beat for audio and the attached file otherwise.
+// Files which fail the checksum, don't match their type or duplicate audio of another creator
+// are discarded together with the upload.
+func (server *Server) finalizeUpload(ctx *gin.Context) {
+	upload, ok := server.authorizedUpload(ctx)
+	if !ok {
+		return
+	}
+
+	if upload.Received != upload.Size {
+		server.setUploadHeaders(ctx, upload)
+		ctx.JSON(http.StatusConflict, errorResponse(errUploadIncomplete))
+		return
+	}
+
+	beat, err := server.store.GetBeatById(ctx, upload.BeatID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if err := policy.CanModifyBeat(getAuthSubject(ctx), beat); err != nil {
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+
+	// Only one request assembles the file, the others are turned away until it is done
+	if _, err := server.store.StartFinalizingUpload(ctx, upload.ID); err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusConflict, errorResponse(errUploadFinalizing))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	chunks, err := uploads.Open(ctx, server.blobs, upload.ID, upload.Size)
+	if err != nil {
+		if errors.Is(err, uploads.ErrMissingChunk) {
+			server.discardUpload(ctx, upload.ID)
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+		server.stopFinalizing(ctx, upload.ID)
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	defer chunks.Close()
+
+	stored, err := server.storeBeatUpload(ctx, beat.ID, upload.FileType, upload.Filename, chunks, upload.Size)
+	if err != nil {
+		if unsupportedFormat(err) {
+			server.discardUpload(ctx, upload.ID)
+			ctx.JSON(http.StatusUnsupportedMediaType, errorResponse(err))
+			return
+		}
+		server.stopFinalizing(ctx, upload.ID)
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if stored.Checksum != upload.Checksum {
+		if err := server.blobs.Delete(ctx, stored.S3Key); err != nil {
+			_ = ctx.Error(err)
+		}
+		server.discardUpload(ctx, upload.ID)
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(errChecksumMismatch))
+		return
+	}
+
+	var res interface{}
+	if upload.FileType == util.AudioUpload {
+		result, err := server.attachBeatAudio(ctx, beat, stored)
+		if err != nil {
+			if err == errDuplicateAudio {
+				server.discardUpload(ctx, upload.ID)
+				ctx.JSON(http.StatusConflict, errorResponse(err))
+				return
+			}
+			server.stopFinalizing(ctx, upload.ID)
+			if err == sql.ErrNoRows {
+				ctx.JSON(http.StatusNotFound, errorResponse(err))
+				return
+			}
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		res = server.newBeatResponse(ctx, result.Beat)
+	} else {
+		attached, err := server.attachBeatFile(ctx, stored)
+		if err != nil {
+			server.stopFinalizing(ctx, upload.ID)
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		res = newBeatFileResponse(attached)
+	}
+
+	server.discardUpload(ctx, upload.ID)
+	ctx.JSON(http.StatusOK, res)
+}
+
+// cancelUpload deletes an upload and the chunks received so far
+func (server *Server) cancelUpload(ctx *gin.Context) {
+	upload, ok := server.authorizedUpload(ctx)
+	if !ok {
+		return
+	}
+
+	if upload.Finalizing {
+		ctx.JSON(http.StatusConflict, errorResponse(errUploadFinalizing))
+		return
+	}
+
+	if err := server.store.DeleteUpload(ctx, upload.ID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// Without the upload the chunks are orphans, the sweeper deletes whatever is left behind
+	if err := uploads.DeleteChunks(ctx, server.blobs, upload.ID); err != nil {
+		_ = ctx.Error(err)
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// discardUpload deletes an upload and its chunks, whatever a failure leaves behind is deleted by the sweeper
+func (server *Server) discardUpload(ctx *gin.Context, id uuid.UUID) {
+	if err := server.store.DeleteUpload(ctx, id); err != nil {
+		_ = ctx.Error(err)
+	}
+	if err := uploads.DeleteChunks(ctx, server.blobs, id); err != nil {
+		_ = ctx.Error(err)
+	}
+}
+
+// stopFinalizing lets the client retry a finalization which failed for reasons other than the file itself
+func (server *Server) stopFinalizing(ctx *gin.Context, id uuid.UUID) {
+	if err := server.store.StopFinalizingUpload(ctx, id); err != nil {
+		_ = ctx.Error(err)
+	}
+}
@@ -0,0 +1,1293 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	mockdb "github.com/danglebary/beatstore-backend-go/db/mock"
+	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
+	"github.com/danglebary/beatstore-backend-go/storage"
+	"github.com/danglebary/beatstore-backend-go/token"
+	"github.com/danglebary/beatstore-backend-go/uploads"
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// randomUpload returns an upload of data to beat which received received bytes
+func randomUpload(beat db.Beat, fileType string, data []byte, received int) db.Upload {
+	return db.Upload{
+		ID:        uuid.New(),
+		UserID:    beat.CreatorID,
+		BeatID:    beat.ID,
+		FileType:  fileType,
+		Filename:  util.RandomString(8) + ".zip",
+		Size:      int64(len(data)),
+		Received:  int64(received),
+		Checksum:  sha256Hex(data),
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+		UpdatedAt: time.Now().UTC().Truncate(time.Second),
+	}
+}
+
+// putChunks stores the first received bytes of data as chunks of upload, chunkSize bytes each
+func putChunks(t *testing.T, blobs storage.BlobStore, upload db.Upload, data []byte, chunkSize int) {
+	for offset := 0; offset < int(upload.Received); offset += chunkSize {
+		end := offset + chunkSize
+		if end > int(upload.Received) {
+			end = int(upload.Received)
+		}
+		key := uploads.ChunkKey(upload.ID, int64(offset))
+		err := blobs.Put(context.Background(), key, bytes.NewReader(data[offset:end]), int64(end-offset), chunkContentType)
+		require.NoError(t, err)
+	}
+}
+
+// requireNoChunks checks that no chunk of upload is left in blobs
+func requireNoChunks(t *testing.T, blobs storage.BlobStore, upload db.Upload) {
+	objects, err := blobs.List(context.Background(), "uploads/"+upload.ID.String()+"/")
+	require.NoError(t, err)
+	require.Empty(t, objects)
+}
+
+func newChunkRequest(t *testing.T, id uuid.UUID, offset string, data []byte) *http.Request {
+	request, err := http.NewRequest(http.MethodPatch, "/uploads/"+id.String(), bytes.NewReader(data))
+	require.NoError(t, err)
+	request.Header.Set("Content-Type", chunkContentType)
+	request.Header.Set(uploadOffsetHeader, offset)
+	return request
+}
+
+func TestCreateUploadApi(t *testing.T) {
+	beat := randomBeat()
+	checksum := sha256Hex(randomZIP(64))
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"beat_id":  beat.ID,
+				"type":     util.StemsFile,
+				"filename": "stems.zip",
+				"size":     1 << 20,
+				"checksum": checksum,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(beat, nil)
+				store.EXPECT().
+					CreateUpload(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateUploadParams) (db.Upload, error) {
+						require.Equal(t, beat.CreatorID, arg.UserID)
+						require.Equal(t, beat.ID, arg.BeatID)
+						require.Equal(t, util.StemsFile, arg.FileType)
+						require.Equal(t, "stems.zip", arg.Filename)
+						require.Equal(t, int64(1<<20), arg.Size)
+						require.Equal(t, checksum, arg.Checksum)
+						return db.Upload{
+							ID:        arg.ID,
+							UserID:    arg.UserID,
+							BeatID:    arg.BeatID,
+							FileType:  arg.FileType,
+							Filename:  arg.Filename,
+							Size:      arg.Size,
+							Checksum:  arg.Checksum,
+							UpdatedAt: time.Now(),
+						}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+
+				var got uploadResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, "/uploads/"+got.ID.String(), recorder.Header().Get("Location"))
+				require.Equal(t, beat.ID, got.BeatID)
+				require.Equal(t, util.StemsFile, got.Type)
+				require.Equal(t, int64(1<<20), got.Size)
+				require.Zero(t, got.Offset)
+				require.WithinDuration(t, time.Now().Add(time.Hour), got.ExpiresAt, time.Minute)
+			},
+		},
+		{
+			name: "AudioTooLarge",
+			body: gin.H{
+				"beat_id":  beat.ID,
+				"type":     util.AudioUpload,
+				"filename": "master.wav",
+				"size":     1<<20 + 1,
+				"checksum": checksum,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(beat, nil)
+				store.EXPECT().
+					CreateUpload(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidChecksum",
+			body: gin.H{
+				"beat_id":  beat.ID,
+				"type":     util.StemsFile,
+				"filename": "stems.zip",
+				"size":     1024,
+				"checksum": "not-a-sha256",
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidType",
+			body: gin.H{
+				"beat_id":  beat.ID,
+				"type":     util.TaggedPreviewFile,
+				"filename": "preview.wav",
+				"size":     1024,
+				"checksum": checksum,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "BeatNotFound",
+			body: gin.H{
+				"beat_id":  beat.ID,
+				"type":     util.StemsFile,
+				"filename": "stems.zip",
+				"size":     1024,
+				"checksum": checksum,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(db.Beat{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "Forbidden",
+			body: gin.H{
+				"beat_id":  beat.ID,
+				"type":     util.StemsFile,
+				"filename": "stems.zip",
+				"size":     1024,
+				"checksum": checksum,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID+1, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(beat, nil)
+				store.EXPECT().
+					CreateUpload(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "ListenerRole",
+			body: gin.H{
+				"beat_id":  beat.ID,
+				"type":     util.StemsFile,
+				"filename": "stems.zip",
+				"size":     1024,
+				"checksum": checksum,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ListenerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					CreateUpload(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "EmailNotVerified",
+			body: gin.H{
+				"beat_id":  beat.ID,
+				"type":     util.StemsFile,
+				"filename": "stems.zip",
+				"size":     1024,
+				"checksum": checksum,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserById(gomock.Any(), gomock.Eq(beat.CreatorID)).
+					Times(1).
+					Return(db.User{ID: beat.CreatorID, Role: util.ProducerRole}, nil)
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					CreateUpload(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "NoAuthorization",
+			body: gin.H{
+				"beat_id":  beat.ID,
+				"type":     util.StemsFile,
+				"filename": "stems.zip",
+				"size":     1024,
+				"checksum": checksum,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: gin.H{
+				"beat_id":  beat.ID,
+				"type":     util.StemsFile,
+				"filename": "stems.zip",
+				"size":     1024,
+				"checksum": checksum,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(beat, nil)
+				store.EXPECT().
+					CreateUpload(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Upload{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			// Init controller and store
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			// Build stub
+			tc.buildStubs(store)
+			stubVerifiedUsers(store)
+			// Start test server, build request, and send
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPost, "/uploads", bytes.NewReader(data))
+			require.NoError(t, err)
+			tc.setupAuth(t, request, server.tokenMaker)
+			// Server http response
+			server.router.ServeHTTP(recorder, request)
+			// check response
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestUploadChunkApi(t *testing.T) {
+	beat := randomBeat()
+	data := randomZIP(300)
+	upload := randomUpload(beat, util.StemsFile, data, 100)
+	chunk := data[100:200]
+
+	testCases := []struct {
+		name          string
+		offset        string
+		chunk         []byte
+		contentType   string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore)
+	}{
+		{
+			name:   "OK",
+			offset: "100",
+			chunk:  chunk,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUpload(gomock.Any(), gomock.Eq(upload.ID)).
+					Times(1).
+					Return(upload, nil)
+				store.EXPECT().
+					AdvanceUpload(gomock.Any(), gomock.Eq(db.AdvanceUploadParams{
+						ID:          upload.ID,
+						ChunkOffset: 100,
+						Length:      int64(len(chunk)),
+					})).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.AdvanceUploadParams) (db.Upload, error) {
+						advanced := upload
+						advanced.Received += arg.Length
+						return advanced, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+				require.Equal(t, "200", recorder.Header().Get(uploadOffsetHeader))
+				require.Equal(t, strconv.FormatInt(upload.Size, 10), recorder.Header().Get(uploadLengthHeader))
+
+				// Together with the chunk received before the file can be read back up to the new offset
+				r, err := uploads.Open(context.Background(), blobs, upload.ID, 200)
+				require.NoError(t, err)
+				defer r.Close()
+				var got bytes.Buffer
+				_, err = got.ReadFrom(r)
+				require.NoError(t, err)
+				require.Equal(t, data[:200], got.Bytes())
+			},
+		},
+		{
+			name:   "OffsetMismatch",
+			offset: "0",
+			chunk:  chunk,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUpload(gomock.Any(), gomock.Eq(upload.ID)).
+					Times(1).
+					Return(upload, nil)
+				store.EXPECT().
+					AdvanceUpload(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				// The client learns where to continue
+				require.Equal(t, "100", recorder.Header().Get(uploadOffsetHeader))
+			},
+		},
+		{
+			name:   "ConcurrentChunk",
+			offset: "100",
+			chunk:  chunk,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUpload(gomock.Any(), gomock.Eq(upload.ID)).
+					Times(1).
+					Return(upload, nil)
+				store.EXPECT().
+					AdvanceUpload(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Upload{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+
+				// The chunk which wasn't counted is deleted again
+				objects, err := blobs.List(context.Background(), "uploads/"+upload.ID.String()+"/")
+				require.NoError(t, err)
+				require.Len(t, objects, 1)
+			},
+		},
+		{
+			name:   "PastEnd",
+			offset: "100",
+			chunk:  randomZIP(300),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUpload(gomock.Any(), gomock.Eq(upload.ID)).
+					Times(1).
+					Return(upload, nil)
+				store.EXPECT().
+					AdvanceUpload(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:   "ChunkTooLarge",
+			offset: "100",
+			chunk:  randomZIP(1 << 16),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUpload(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+			},
+		},
+		{
+			name:   "InvalidOffset",
+			offset: "-1",
+			chunk:  chunk,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUpload(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:        "WrongContentType",
+			offset:      "100",
+			chunk:       chunk,
+			contentType: "application/zip",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUpload(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusUnsupportedMediaType, recorder.Code)
+			},
+		},
+		{
+			name:   "Finalizing",
+			offset: "100",
+			chunk:  chunk,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				finalizing := upload
+				finalizing.Finalizing = true
+				store.EXPECT().
+					GetUpload(gomock.Any(), gomock.Eq(upload.ID)).
+					Times(1).
+					Return(finalizing, nil)
+				store.EXPECT().
+					AdvanceUpload(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:   "NotFound",
+			offset: "100",
+			chunk:  chunk,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUpload(gomock.Any(), gomock.Eq(upload.ID)).
+					Times(1).
+					Return(db.Upload{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:   "Forbidden",
+			offset: "100",
+			chunk:  chunk,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID+1, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUpload(gomock.Any(), gomock.Eq(upload.ID)).
+					Times(1).
+					Return(upload, nil)
+				store.EXPECT().
+					AdvanceUpload(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:   "NoAuthorization",
+			offset: "100",
+			chunk:  chunk,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUpload(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:   "InternalError",
+			offset: "100",
+			chunk:  chunk,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUpload(gomock.Any(), gomock.Eq(upload.ID)).
+					Times(1).
+					Return(upload, nil)
+				store.EXPECT().
+					AdvanceUpload(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Upload{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			// Init controller and store
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			// Build stub
+			tc.buildStubs(store)
+			stubVerifiedUsers(store)
+			// Start test server with the first chunk received, build request, and send
+			server := newTestServer(t, store)
+			putChunks(t, server.blobs, upload, data, 100)
+			recorder := httptest.NewRecorder()
+			request := newChunkRequest(t, upload.ID, tc.offset, tc.chunk)
+			if tc.contentType != "" {
+				request.Header.Set("Content-Type", tc.contentType)
+			}
+			tc.setupAuth(t, request, server.tokenMaker)
+			// Server http response
+			server.router.ServeHTTP(recorder, request)
+			// check response
+			tc.checkResponse(t, recorder, server.blobs)
+		})
+	}
+}
+
+func TestGetUploadOffsetApi(t *testing.T) {
+	beat := randomBeat()
+	upload := randomUpload(beat, util.StemsFile, randomZIP(300), 120)
+
+	testCases := []struct {
+		name          string
+		uploadID      string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			uploadID: upload.ID.String(),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUpload(gomock.Any(), gomock.Eq(upload.ID)).
+					Times(1).
+					Return(upload, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "120", recorder.Header().Get(uploadOffsetHeader))
+				require.Equal(t, strconv.FormatInt(upload.Size, 10), recorder.Header().Get(uploadLengthHeader))
+				require.Equal(t, "no-store", recorder.Header().Get("Cache-Control"))
+
+				expires, err := http.ParseTime(recorder.Header().Get(uploadExpiresHeader))
+				require.NoError(t, err)
+				require.Equal(t, upload.UpdatedAt.Add(time.Hour), expires)
+			},
+		},
+		{
+			name:     "InvalidID",
+			uploadID: "42",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUpload(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "NotFound",
+			uploadID: upload.ID.String(),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUpload(gomock.Any(), gomock.Eq(upload.ID)).
+					Times(1).
+					Return(db.Upload{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:     "Forbidden",
+			uploadID: upload.ID.String(),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID+1, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUpload(gomock.Any(), gomock.Eq(upload.ID)).
+					Times(1).
+					Return(upload, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				require.Empty(t, recorder.Header().Get(uploadOffsetHeader))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			// Init controller and store
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			// Build stub
+			tc.buildStubs(store)
+			stubVerifiedUsers(store)
+			// Start test server, build request, and send
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodHead, "/uploads/"+tc.uploadID, nil)
+			require.NoError(t, err)
+			tc.setupAuth(t, request, server.tokenMaker)
+			// Server http response
+			server.router.ServeHTTP(recorder, request)
+			// check response
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestFinalizeUploadApi(t *testing.T) {
+	beat := randomBeat()
+	stems := randomZIP(1000)
+	mp3 := randomMP3(1000)
+
+	// mismatched announced the checksum of other content than it received
+	mismatched := randomUpload(beat, util.StemsFile, stems, len(stems))
+	mismatched.Checksum = sha256Hex(mp3)
+
+	// uploaded is set by the stubs attaching the file
+	var uploaded db.UpsertBeatFileParams
+
+	testCases := []struct {
+		name          string
+		upload        db.Upload
+		data          []byte
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore, upload db.Upload)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore, upload db.Upload)
+	}{
+		{
+			name:   "Stems",
+			upload: randomUpload(beat, util.StemsFile, stems, len(stems)),
+			data:   stems,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore, upload db.Upload) {
+				store.EXPECT().
+					GetUpload(gomock.Any(), gomock.Eq(upload.ID)).
+					Times(1).
+					Return(upload, nil)
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(beat, nil)
+				store.EXPECT().
+					StartFinalizingUpload(gomock.Any(), gomock.Eq(upload.ID)).
+					Times(1).
+					Return(upload, nil)
+				store.EXPECT().
+					GetBeatFile(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.BeatFile{}, sql.ErrNoRows)
+				store.EXPECT().
+					UpsertBeatFile(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.UpsertBeatFileParams) (db.BeatFile, error) {
+						uploaded = arg
+						return db.BeatFile{
+							BeatID:      arg.BeatID,
+							FileType:    arg.FileType,
+							S3Key:       arg.S3Key,
+							ContentType: arg.ContentType,
+							Size:        arg.Size,
+							Checksum:    arg.Checksum,
+						}, nil
+					})
+				store.EXPECT().
+					DeleteUpload(gomock.Any(), gomock.Eq(upload.ID)).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore, upload db.Upload) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, util.StemsFile, uploaded.FileType)
+				require.Equal(t, beat.ID, uploaded.BeatID)
+				require.Equal(t, upload.Checksum, uploaded.Checksum)
+				require.Equal(t, "application/zip", uploaded.ContentType)
+				requireBlob(t, blobs, uploaded.S3Key, stems)
+				requireNoChunks(t, blobs, upload)
+
+				var got beatFileResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, util.StemsFile, got.Type)
+				require.Equal(t, upload.Checksum, got.Checksum)
+			},
+		},
+		{
+			name:   "Audio",
+			upload: randomUpload(beat, util.AudioUpload, mp3, len(mp3)),
+			data:   mp3,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore, upload db.Upload) {
+				store.EXPECT().
+					GetUpload(gomock.Any(), gomock.Eq(upload.ID)).
+					Times(1).
+					Return(upload, nil)
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(beat, nil)
+				store.EXPECT().
+					StartFinalizingUpload(gomock.Any(), gomock.Eq(upload.ID)).
+					Times(1).
+					Return(upload, nil)
+				store.EXPECT().
+					GetFirstAudioByContentHash(gomock.Any(), gomock.Eq(upload.Checksum)).
+					Times(1).
+					Return(db.GetFirstAudioByContentHashRow{}, sql.ErrNoRows)
+				store.EXPECT().
+					UpdateBeatAudioTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.UpdateBeatAudioTxParams) (db.UpdateBeatAudioTxResult, error) {
+						uploaded = arg.File
+						updated := beat
+						updated.S3Key = arg.File.S3Key
+						updated.PreviewKey = ""
+						return db.UpdateBeatAudioTxResult{Beat: updated}, nil
+					})
+				store.EXPECT().
+					DeleteUpload(gomock.Any(), gomock.Eq(upload.ID)).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore, upload db.Upload) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, util.MP3File, uploaded.FileType)
+				require.Regexp(t, fmt.Sprintf(`^beats/%d/audio/[0-9a-f-]{36}\.mp3$`, beat.ID), uploaded.S3Key)
+				requireBlob(t, blobs, uploaded.S3Key, mp3)
+				requireNoChunks(t, blobs, upload)
+
+				var got beatResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, beat.ID, got.ID)
+			},
+		},
+		{
+			name:   "DuplicateAudio",
+			upload: randomUpload(beat, util.AudioUpload, mp3, len(mp3)),
+			data:   mp3,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore, upload db.Upload) {
+				store.EXPECT().
+					GetUpload(gomock.Any(), gomock.Eq(upload.ID)).
+					Times(1).
+					Return(upload, nil)
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(beat, nil)
+				store.EXPECT().
+					StartFinalizingUpload(gomock.Any(), gomock.Eq(upload.ID)).
+					Times(1).
+					Return(upload, nil)
+				store.EXPECT().
+					GetFirstAudioByContentHash(gomock.Any(), gomock.Eq(upload.Checksum)).
+					Times(1).
+					Return(db.GetFirstAudioByContentHashRow{BeatID: beat.ID + 1, CreatorID: beat.CreatorID + 1}, nil)
+				store.EXPECT().
+					CreateDuplicateMatch(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.DuplicateMatch{}, nil)
+				store.EXPECT().
+					UpdateBeatAudioTx(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					DeleteUpload(gomock.Any(), gomock.Eq(upload.ID)).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore, upload db.Upload) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				requireNoChunks(t, blobs, upload)
+
+				// The assembled file is deleted as well
+				objects, err := blobs.List(context.Background(), fmt.Sprintf("beats/%d/", beat.ID))
+				require.NoError(t, err)
+				require.Empty(t, objects)
+			},
+		},
+		{
+			name:   "ChecksumMismatch",
+			upload: mismatched,
+			data:   stems,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore, upload db.Upload) {
+				store.EXPECT().
+					GetUpload(gomock.Any(), gomock.Eq(upload.ID)).
+					Times(1).
+					Return(upload, nil)
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(beat, nil)
+				store.EXPECT().
+					StartFinalizingUpload(gomock.Any(), gomock.Eq(upload.ID)).
+					Times(1).
+					Return(upload, nil)
+				store.EXPECT().
+					UpsertBeatFile(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					DeleteUpload(gomock.Any(), gomock.Eq(upload.ID)).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore, upload db.Upload) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				requireNoChunks(t, blobs, upload)
+
+				// The assembled file is deleted as well
+				objects, err := blobs.List(context.Background(), fmt.Sprintf("beats/%d/", beat.ID))
+				require.NoError(t, err)
+				require.Empty(t, objects)
+			},
+		},
+		{
+			name:   "StemsNotZIP",
+			upload: randomUpload(beat, util.StemsFile, mp3, len(mp3)),
+			data:   mp3,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore, upload db.Upload) {
+				store.EXPECT().
+					GetUpload(gomock.Any(), gomock.Eq(upload.ID)).
+					Times(1).
+					Return(upload, nil)
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(beat, nil)
+				store.EXPECT().
+					StartFinalizingUpload(gomock.Any(), gomock.Eq(upload.ID)).
+					Times(1).
+					Return(upload, nil)
+				store.EXPECT().
+					DeleteUpload(gomock.Any(), gomock.Eq(upload.ID)).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore, upload db.Upload) {
+				require.Equal(t, http.StatusUnsupportedMediaType, recorder.Code)
+				requireNoChunks(t, blobs, upload)
+			},
+		},
+		{
+			name:   "Incomplete",
+			upload: randomUpload(beat, util.StemsFile, stems, 500),
+			data:   stems,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore, upload db.Upload) {
+				store.EXPECT().
+					GetUpload(gomock.Any(), gomock.Eq(upload.ID)).
+					Times(1).
+					Return(upload, nil)
+				store.EXPECT().
+					StartFinalizingUpload(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore, upload db.Upload) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				require.Equal(t, "500", recorder.Header().Get(uploadOffsetHeader))
+			},
+		},
+		{
+			name:   "AlreadyFinalizing",
+			upload: randomUpload(beat, util.StemsFile, stems, len(stems)),
+			data:   stems,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore, upload db.Upload) {
+				store.EXPECT().
+					GetUpload(gomock.Any(), gomock.Eq(upload.ID)).
+					Times(1).
+					Return(upload, nil)
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(beat, nil)
+				store.EXPECT().
+					StartFinalizingUpload(gomock.Any(), gomock.Eq(upload.ID)).
+					Times(1).
+					Return(db.Upload{}, sql.ErrNoRows)
+				store.EXPECT().
+					DeleteUpload(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore, upload db.Upload) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:   "Forbidden",
+			upload: randomUpload(beat, util.StemsFile, stems, len(stems)),
+			data:   stems,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID+1, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore, upload db.Upload) {
+				store.EXPECT().
+					GetUpload(gomock.Any(), gomock.Eq(upload.ID)).
+					Times(1).
+					Return(upload, nil)
+				store.EXPECT().
+					StartFinalizingUpload(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore, upload db.Upload) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:   "AttachError",
+			upload: randomUpload(beat, util.StemsFile, stems, len(stems)),
+			data:   stems,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore, upload db.Upload) {
+				store.EXPECT().
+					GetUpload(gomock.Any(), gomock.Eq(upload.ID)).
+					Times(1).
+					Return(upload, nil)
+				store.EXPECT().
+					GetBeatById(gomock.Any(), gomock.Eq(beat.ID)).
+					Times(1).
+					Return(beat, nil)
+				store.EXPECT().
+					StartFinalizingUpload(gomock.Any(), gomock.Eq(upload.ID)).
+					Times(1).
+					Return(upload, nil)
+				store.EXPECT().
+					GetBeatFile(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.BeatFile{}, sql.ErrNoRows)
+				store.EXPECT().
+					UpsertBeatFile(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.BeatFile{}, sql.ErrConnDone)
+				store.EXPECT().
+					StopFinalizingUpload(gomock.Any(), gomock.Eq(upload.ID)).
+					Times(1).
+					Return(nil)
+				store.EXPECT().
+					DeleteUpload(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore, upload db.Upload) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+
+				// The chunks are kept so finalizing can be retried
+				r, err := uploads.Open(context.Background(), blobs, upload.ID, upload.Size)
+				require.NoError(t, err)
+				require.NoError(t, r.Close())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			uploaded = db.UpsertBeatFileParams{}
+			// Init controller and store
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			// Build stub
+			tc.buildStubs(store, tc.upload)
+			stubVerifiedUsers(store)
+			// Start test server with the received chunks stored, build request, and send
+			server := newTestServer(t, store)
+			putChunks(t, server.blobs, tc.upload, tc.data, 300)
+			recorder := httptest.NewRecorder()
+			url := fmt.Sprintf("/uploads/%s/finalize", tc.upload.ID)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+			tc.setupAuth(t, request, server.tokenMaker)
+			// Server http response
+			server.router.ServeHTTP(recorder, request)
+			// check response
+			tc.checkResponse(t, recorder, server.blobs, tc.upload)
+		})
+	}
+}
+
+func TestCancelUploadApi(t *testing.T) {
+	beat := randomBeat()
+	data := randomZIP(300)
+	upload := randomUpload(beat, util.StemsFile, data, 200)
+
+	testCases := []struct {
+		name          string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore)
+	}{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUpload(gomock.Any(), gomock.Eq(upload.ID)).
+					Times(1).
+					Return(upload, nil)
+				store.EXPECT().
+					DeleteUpload(gomock.Any(), gomock.Eq(upload.ID)).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+				requireNoChunks(t, blobs, upload)
+			},
+		},
+		{
+			name: "Finalizing",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				finalizing := upload
+				finalizing.Finalizing = true
+				store.EXPECT().
+					GetUpload(gomock.Any(), gomock.Eq(upload.ID)).
+					Times(1).
+					Return(finalizing, nil)
+				store.EXPECT().
+					DeleteUpload(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "Forbidden",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID+1, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUpload(gomock.Any(), gomock.Eq(upload.ID)).
+					Times(1).
+					Return(upload, nil)
+				store.EXPECT().
+					DeleteUpload(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, beat.CreatorID, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUpload(gomock.Any(), gomock.Eq(upload.ID)).
+					Times(1).
+					Return(upload, nil)
+				store.EXPECT().
+					DeleteUpload(gomock.Any(), gomock.Eq(upload.ID)).
+					Times(1).
+					Return(sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, blobs storage.BlobStore) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+
+				// The upload can still be resumed
+				_, err := uploads.Open(context.Background(), blobs, upload.ID, upload.Received)
+				require.NoError(t, err)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			// Init controller and store
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			// Build stub
+			tc.buildStubs(store)
+			stubVerifiedUsers(store)
+			// Start test server with the received chunks stored, build request, and send
+			server := newTestServer(t, store)
+			putChunks(t, server.blobs, upload, data, 100)
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodDelete, "/uploads/"+upload.ID.String(), nil)
+			require.NoError(t, err)
+			tc.setupAuth(t, request, server.tokenMaker)
+			// Server http response
+			server.router.ServeHTTP(recorder, request)
+			// check response
+			tc.checkResponse(t, recorder, server.blobs)
+		})
+	}
+}
@@ -1,26 +1,109 @@
 package api
 
-import "github.com/gin-gonic/gin"
+import (
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/gin-gonic/gin"
+)
 
 func newRouter(server *Server) *gin.Engine {
 	router := gin.Default()
+	authRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker, server.store))
+	producerRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker, server.store, util.BeatsWriteScope), requireRoles(util.ProducerRole, util.AdminRole), server.requireVerifiedEmail)
+	verifiedRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker, server.store), server.requireVerifiedEmail)
+	adminRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker, server.store), requireRoles(util.AdminRole))
+	mfaRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker, server.store), requireRoles(util.ProducerRole, util.AdminRole))
+
+	// Routes which can also be called with an API key granted the given scope
+	beatWriteRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker, server.store, util.BeatsWriteScope))
+	likeWriteRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker, server.store, util.LikesWriteScope))
+
+	// Routes which anonymous clients can call as well, authenticated requests act on the user's resources
+	optionalAuthRoutes := router.Group("/").Use(optionalAuthMiddleware(server.tokenMaker, server.store))
 
 	// User routes
 	router.POST("/users", server.createUser)
-	router.POST("/users/:id", server.updateUser)
+	router.POST("/users/login", server.loginUser)
+	router.POST("/users/login/mfa", server.loginUserMFA)
+	router.POST("/users/logout", server.logoutUser)
+	authRoutes.POST("/users/logout/all", server.logoutAllUserSessions)
+	router.GET("/users/verify", server.verifyEmail)
+	authRoutes.POST("/users/verify/resend", server.resendVerificationEmail)
+	router.POST("/users/password/forgot", server.forgotPassword)
+	router.POST("/users/password/reset", server.resetPassword)
+	authRoutes.POST("/users/:id", server.updateUser)
+	authRoutes.POST("/users/:id/credentials", server.updateUserCredentials)
+	adminRoutes.POST("/users/:id/role", server.updateUserRole)
+	adminRoutes.POST("/users/:id/unlock", server.unlockUser)
+	adminRoutes.GET("/users/:id/login-events", server.listLoginEvents)
 	router.GET("/users/:id", server.getUserById)
-	router.GET("/users", server.listUsers)
+	adminRoutes.GET("/users", server.listUsers)
+
+	// Two-factor authentication routes
+	mfaRoutes.POST("/users/:id/mfa/totp", server.enrollTOTP)
+	mfaRoutes.POST("/users/:id/mfa/totp/confirm", server.confirmTOTP)
+
+	// API key routes
+	authRoutes.POST("/users/:id/api-keys", server.createAPIKey)
+	authRoutes.GET("/users/:id/api-keys", server.listAPIKeys)
+	authRoutes.DELETE("/users/:id/api-keys/:key_id", server.deleteAPIKey)
+
+	// File routes
+	router.GET("/files/:token", server.serveFile)
+
+	// Token routes
+	router.POST("/tokens/renew", server.renewAccessToken)
 
 	// Beat routes
-	router.POST("/beats", server.createBeat)
-	router.POST("/beats/:id", server.updateBeat)
+	producerRoutes.POST("/beats", server.createBeat)
+	beatWriteRoutes.POST("/beats/:id", server.updateBeat)
+	producerRoutes.POST("/beats/:id/audio", server.uploadBeatAudio)
+	producerRoutes.POST("/beats/:id/artwork", server.uploadBeatArtwork)
 	router.GET("/beats/:id", server.getBeat)
+	router.GET("/beats/:id/stream", server.streamBeat)
+	router.HEAD("/beats/:id/stream", server.streamBeat)
+	router.GET("/beats/:id/waveform", server.getBeatWaveform)
+	producerRoutes.POST("/beats/:id/files/:type", server.uploadBeatFile)
+	router.GET("/beats/:id/files", server.listBeatFiles)
+	producerRoutes.DELETE("/beats/:id/files/:type", server.deleteBeatFile)
 	router.GET("/beats", server.listBeatsById)
 	router.GET("/users/:id/beats", server.listBeatsByCreatorId)
 
+	// License routes
+	beatWriteRoutes.POST("/beats/:id/licenses", server.createLicense)
+	router.GET("/beats/:id/licenses", server.listLicenses)
+	beatWriteRoutes.POST("/beats/:id/licenses/:license_id", server.updateLicense)
+	beatWriteRoutes.DELETE("/beats/:id/licenses/:license_id", server.deleteLicense)
+
+	// Resumable upload routes
+	producerRoutes.POST("/uploads", server.createUpload)
+	producerRoutes.HEAD("/uploads/:id", server.getUploadOffset)
+	producerRoutes.PATCH("/uploads/:id", server.uploadChunk)
+	producerRoutes.POST("/uploads/:id/finalize", server.finalizeUpload)
+	producerRoutes.DELETE("/uploads/:id", server.cancelUpload)
+
+	// Cart routes
+	optionalAuthRoutes.GET("/cart", server.getCart)
+	optionalAuthRoutes.POST("/cart/items", server.addCartItem)
+	optionalAuthRoutes.DELETE("/cart/items/:id", server.removeCartItem)
+
+	// Order routes
+	verifiedRoutes.POST("/checkout", server.checkout)
+	authRoutes.GET("/orders/:id", server.getOrder)
+	adminRoutes.POST("/orders/:id/refund", server.refundOrder)
+
+	// Payment webhook routes
+	router.POST("/webhooks/payments", server.paymentWebhook)
+	adminRoutes.GET("/webhooks/payments/events", server.listWebhookEvents)
+	adminRoutes.POST("/webhooks/payments/events/:id/replay", server.replayWebhookEvent)
+
+	// Moderation routes
+	adminRoutes.GET("/moderation/duplicates", server.listDuplicateMatches)
+	adminRoutes.POST("/moderation/duplicates/:id", server.reviewDuplicateMatch)
+
 	// Like routes
-	router.POST("/likes", server.createLike)
+	likeWriteRoutes.POST("/likes", server.createLike)
 	router.GET("/likes/:uid/:bid", server.getLike)
+	likeWriteRoutes.DELETE("/likes/:uid/:bid", server.deleteLike)
 	router.GET("/beats/:id/likes", server.listLikesByBeatID)
 	router.GET("/users/:id/likes", server.listLikesByUserID)
 
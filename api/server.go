@@ -1,23 +1,102 @@
 package api
 
 import (
+	"fmt"
+	"time"
+
 	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
+	"github.com/danglebary/beatstore-backend-go/jobs"
+	"github.com/danglebary/beatstore-backend-go/limiter"
+	"github.com/danglebary/beatstore-backend-go/mail"
+	"github.com/danglebary/beatstore-backend-go/media"
+	"github.com/danglebary/beatstore-backend-go/payment"
+	"github.com/danglebary/beatstore-backend-go/storage"
+	"github.com/danglebary/beatstore-backend-go/token"
+	"github.com/danglebary/beatstore-backend-go/uploads"
+	"github.com/danglebary/beatstore-backend-go/util"
 	"github.com/gin-gonic/gin"
 )
 
 // Serves all HTTP requests for our service
 type Server struct {
-	store  db.Store
-	router *gin.Engine
+	config     util.Config
+	store      db.Store
+	tokenMaker token.Maker
+	mailer     mail.Mailer
+	blobs      storage.BlobStore
+	payments   payment.Provider
+	// webhookVerifier checks the signatures of the events the payment provider sends
+	webhookVerifier *payment.WebhookVerifier
+	// fileSigner signs the download URLs of objects in backends without URLs of their own
+	fileSigner *storage.URLSigner
+	// jobs runs the processing of uploaded audio by media in the background
+	jobs  *jobs.Queue
+	media *media.Processor
+	// loginLimiter counts failed logins per username and per client IP
+	loginLimiter limiter.Limiter
+	router       *gin.Engine
+	// now returns the current time, tests replace it to get a fixed clock
+	now func() time.Time
 }
 
 // Creates a new HTTP server instance and initializes routing
-func NewServer(store db.Store) *Server {
-	server := &Server{store: store}
+func NewServer(config util.Config, store db.Store, mailer mail.Mailer, blobs storage.BlobStore, payments payment.Provider) (*Server, error) {
+	tokenMaker, err := token.NewJWTMaker(config.TokenSymmetricKey)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create token maker: %w", err)
+	}
+
+	fileSigner, err := storage.NewURLSigner(config.FileURLSigningKey)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create file url signer: %w", err)
+	}
+
+	webhookVerifier, err := payment.NewWebhookVerifier(config.PaymentWebhookSecret, config.PaymentWebhookTolerance)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create payment webhook verifier: %w", err)
+	}
+
+	loginLimiter, err := limiter.New(config, store)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create login limiter: %w", err)
+	}
+
+	processor, err := media.NewProcessor(config, store, blobs)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create media processor: %w", err)
+	}
+
+	jobQueue, err := jobs.NewQueue(config.JobWorkers, config.JobQueueSize, config.JobTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create job queue: %w", err)
+	}
+
+	// Without an interval uploads are never swept, which is what tests want
+	if config.UploadSweepInterval > 0 {
+		sweeper := uploads.NewSweeper(store, blobs, config.UploadExpiry)
+		if err := jobQueue.Every(config.UploadSweepInterval, sweeper.Job()); err != nil {
+			return nil, fmt.Errorf("cannot schedule upload sweeper: %w", err)
+		}
+	}
+
+	server := &Server{
+		config:          config,
+		store:           store,
+		tokenMaker:      tokenMaker,
+		mailer:          mailer,
+		blobs:           blobs,
+		payments:        payments,
+		webhookVerifier: webhookVerifier,
+		fileSigner:      fileSigner,
+		jobs:            jobQueue,
+		media:           processor,
+		loginLimiter:    loginLimiter,
+		now:             time.Now,
+	}
 	router := newRouter(server)
 
 	server.router = router
-	return server
+	return server, nil
 }
 
 // Runs HTTP server on specified address
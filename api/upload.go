@@ -0,0 +1,138 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/danglebary/beatstore-backend-go/audio"
+	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/gin-gonic/gin"
+)
+
+// uploadFormField is the multipart field carrying an uploaded file
+const uploadFormField = "file"
+
+// multipartOverhead leaves room for the boundaries and headers around the file
+const multipartOverhead = 64 << 10
+
+// sniffLen is the number of leading bytes read to tell the format of an uploaded file,
+// enough for audio.Sniff and http.DetectContentType
+const sniffLen = 512
+
+// errBodyTooLarge matches the error http.MaxBytesReader returns once the limit is hit,
+// the typed http.MaxBytesError only exists in newer Go releases
+const errBodyTooLarge = "http: request body too large"
+
+// formFile returns the file uploaded as multipart form data, files larger than maxSize are rejected
+// before they are read in full. If ok is false the error response has already been sent.
+func formFile(ctx *gin.Context, maxSize int64, what string) (header *multipart.FileHeader, ok bool) {
+	errTooLarge := fmt.Errorf("%s exceeds the limit of %d bytes", what, maxSize)
+	if ctx.Request.ContentLength > maxSize+multipartOverhead {
+		ctx.JSON(http.StatusRequestEntityTooLarge, errorResponse(errTooLarge))
+		return nil, false
+	}
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxSize+multipartOverhead)
+
+	header, err := ctx.FormFile(uploadFormField)
+	if err != nil {
+		if err.Error() == errBodyTooLarge {
+			ctx.JSON(http.StatusRequestEntityTooLarge, errorResponse(errTooLarge))
+			return nil, false
+		}
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return nil, false
+	}
+	if header.Size > maxSize {
+		ctx.JSON(http.StatusRequestEntityTooLarge, errorResponse(errTooLarge))
+		return nil, false
+	}
+	return header, true
+}
+
+// readHead reads up to n leading bytes of r for sniffing its content,
+// the returned slice is shorter for smaller files
+func readHead(r io.Reader, n int) ([]byte, error) {
+	head := make([]byte, n)
+	read, err := io.ReadFull(r, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	return head[:read], nil
+}
+
+// putWithChecksum stores r under key and returns the hex encoded SHA-256 of what was stored
+func (server *Server) putWithChecksum(ctx *gin.Context, key string, r io.Reader, size int64, contentType string) (string, error) {
+	hash := sha256.New()
+	if err := server.blobs.Put(ctx, key, io.TeeReader(r, hash), size, contentType); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// storeBeatUpload stores the content of an uploaded file of fileType, util.AudioUpload or a beat file type,
+// under a new key of the beat. The content type is sniffed from the file itself, the one sent by the client
+// is ignored. The returned params attach the stored file to the beat, uploaded audio becomes the master WAV
+// or MP3 depending on its format. Check unsupportedFormat for content which doesn't match fileType.
+func (server *Server) storeBeatUpload(
+	ctx *gin.Context,
+	beatID int32,
+	fileType string,
+	filename string,
+	r io.Reader,
+	size int64,
+) (db.UpsertBeatFileParams, error) {
+	head, err := readHead(r, sniffLen)
+	if err != nil {
+		return db.UpsertBeatFileParams{}, err
+	}
+
+	var key, contentType string
+	if fileType == util.AudioUpload || fileType == util.MP3File {
+		var format string
+		format, contentType, err = audio.Sniff(head)
+		if err != nil {
+			return db.UpsertBeatFileParams{}, err
+		}
+		if fileType == util.MP3File && format != audio.FormatMP3 {
+			return db.UpsertBeatFileParams{}, fmt.Errorf("%w: expected an MP3 file", audio.ErrUnsupportedFormat)
+		}
+		key = beatAudioKey(beatID, format)
+		fileType = util.MasterWAVFile
+		if format == audio.FormatMP3 {
+			fileType = util.MP3File
+		}
+	} else {
+		var extension string
+		contentType, extension, err = beatFileFormat(fileType, filename, head)
+		if err != nil {
+			return db.UpsertBeatFileParams{}, err
+		}
+		key = beatFileKey(beatID, fileType, extension)
+	}
+
+	checksum, err := server.putWithChecksum(ctx, key, io.MultiReader(bytes.NewReader(head), r), size, contentType)
+	if err != nil {
+		return db.UpsertBeatFileParams{}, err
+	}
+
+	return db.UpsertBeatFileParams{
+		BeatID:      beatID,
+		FileType:    fileType,
+		S3Key:       key,
+		ContentType: contentType,
+		Size:        size,
+		Checksum:    checksum,
+	}, nil
+}
+
+// unsupportedFormat reports whether storeBeatUpload failed because the content doesn't match the type
+func unsupportedFormat(err error) bool {
+	return errors.Is(err, audio.ErrUnsupportedFormat) || errors.Is(err, errStemsNotZIP)
+}
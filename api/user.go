@@ -2,18 +2,76 @@ package api
 
 import (
 	"database/sql"
+	"errors"
 	"net/http"
+	"time"
 
 	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
+	"github.com/danglebary/beatstore-backend-go/policy"
+	"github.com/danglebary/beatstore-backend-go/token"
+	"github.com/danglebary/beatstore-backend-go/util"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
+// userResponse is the public representation of a db.User, visible to everyone
+type userResponse struct {
+	ID        int32     `json:"id"`
+	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func newUserResponse(user db.User) userResponse {
+	return userResponse{
+		ID:        user.ID,
+		Username:  user.Username,
+		Role:      user.Role,
+		CreatedAt: user.CreatedAt,
+	}
+}
+
+// privateUserResponse includes the private fields of a db.User, it is only returned
+// to the account owner and to admins and never includes the password hash
+type privateUserResponse struct {
+	ID                int32     `json:"id"`
+	Username          string    `json:"username"`
+	Email             string    `json:"email"`
+	EmailVerified     bool      `json:"email_verified"`
+	MFAEnabled        bool      `json:"mfa_enabled"`
+	Role              string    `json:"role"`
+	PasswordChangedAt time.Time `json:"password_changed_at"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+func newPrivateUserResponse(user db.User) privateUserResponse {
+	return privateUserResponse{
+		ID:                user.ID,
+		Username:          user.Username,
+		Email:             user.Email,
+		EmailVerified:     user.VerifiedAt.Valid,
+		MFAEnabled:        user.TotpEnabledAt.Valid,
+		Role:              user.Role,
+		PasswordChangedAt: user.PasswordChangedAt,
+		CreatedAt:         user.CreatedAt,
+	}
+}
+
+func newPrivateUserResponses(users []db.User) []privateUserResponse {
+	res := make([]privateUserResponse, 0, len(users))
+	for _, user := range users {
+		res = append(res, newPrivateUserResponse(user))
+	}
+	return res
+}
+
 type createUserRequest struct {
 	Username string `json:"username" binding:"required"`
-	Email    string `json:"email" binding:"required"`
+	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required"`
 }
 
+// createUser signs up a listener, producers are promoted by an admin through updateUserRole
 func (server *Server) createUser(ctx *gin.Context) {
 	var req createUserRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
@@ -21,18 +79,204 @@ func (server *Server) createUser(ctx *gin.Context) {
 		return
 	}
 
-	arg := db.CreateUserParams{
-		Username: req.Username,
-		Email:    req.Email,
-		Password: req.Password,
+	hashedPassword, err := util.HashPassword(req.Password, server.config.BcryptCost)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	emailToken, hashedToken, expiresAt, err := newEmailToken(server.config.EmailTokenDuration)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	arg := db.CreateUserTxParams{
+		CreateUserParams: db.CreateUserParams{
+			Username:       req.Username,
+			Email:          req.Email,
+			HashedPassword: hashedPassword,
+			Role:           util.ListenerRole,
+		},
+		VerificationHashedToken: hashedToken,
+		VerificationExpiresAt:   expiresAt,
+	}
+
+	result, err := server.store.CreateUserTx(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// The account exists at this point, a failed delivery is logged
+	// and the user can ask for a new link later on.
+	if err := server.sendVerificationEmail(ctx, result.User, emailToken); err != nil {
+		_ = ctx.Error(err)
+	}
+	ctx.JSON(http.StatusOK, newPrivateUserResponse(result.User))
+}
+
+type loginUserRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type loginUserResponse struct {
+	SessionID             uuid.UUID           `json:"session_id"`
+	AccessToken           string              `json:"access_token"`
+	AccessTokenExpiresAt  time.Time           `json:"access_token_expires_at"`
+	RefreshToken          string              `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time           `json:"refresh_token_expires_at"`
+	User                  privateUserResponse `json:"user"`
+}
+
+var errInvalidCredentials = errors.New("invalid username or password")
+
+func (server *Server) loginUser(ctx *gin.Context) {
+	var req loginUserRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if !server.checkLoginAllowed(ctx, req.Username) {
+		return
+	}
+
+	user, err := server.store.GetUserByUsername(ctx, req.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			server.recordLoginFailure(ctx, req.Username)
+			ctx.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if err := util.CheckPassword(req.Password, user.HashedPassword); err != nil {
+		server.recordLoginFailure(ctx, req.Username)
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
+		return
+	}
+	server.resetLoginFailures(ctx, user.Username)
+
+	// A failed rehash must not prevent the user from logging in,
+	// the error is attached to the context so it shows up in the request log.
+	if err := server.rehashPasswordIfNeeded(ctx, user, req.Password); err != nil {
+		_ = ctx.Error(err)
+	}
+
+	// Users with a second factor only get tokens after answering an MFA challenge
+	if user.TotpEnabledAt.Valid {
+		res, err := server.startMFAChallenge(ctx, user)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusOK, res)
+		return
+	}
+
+	res, err := server.createLoginSession(ctx, user)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, res)
+}
+
+// createLoginSession issues an access and refresh token pair for user
+// and stores the refresh token in a new session. The cart the client filled anonymously becomes the user's.
+func (server *Server) createLoginSession(ctx *gin.Context, user db.User) (loginUserResponse, error) {
+	accessToken, accessPayload, err := server.tokenMaker.CreateToken(user.ID, user.Username, user.Role, token.AccessToken, server.config.AccessTokenDuration)
+	if err != nil {
+		return loginUserResponse{}, err
+	}
+
+	refreshToken, refreshPayload, err := server.tokenMaker.CreateToken(user.ID, user.Username, user.Role, token.RefreshToken, server.config.RefreshTokenDuration)
+	if err != nil {
+		return loginUserResponse{}, err
+	}
+
+	session, err := server.store.CreateSession(ctx, db.CreateSessionParams{
+		ID:           refreshPayload.ID,
+		UserID:       user.ID,
+		RefreshToken: refreshToken,
+		UserAgent:    ctx.Request.UserAgent(),
+		ClientIp:     ctx.ClientIP(),
+		IsBlocked:    false,
+		ExpiresAt:    refreshPayload.ExpiredAt,
+	})
+	if err != nil {
+		return loginUserResponse{}, err
+	}
+
+	server.mergeAnonymousCart(ctx, user.ID)
+
+	res := loginUserResponse{
+		SessionID:             session.ID,
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  accessPayload.ExpiredAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: refreshPayload.ExpiredAt,
+		User:                  newPrivateUserResponse(user),
+	}
+	return res, nil
+}
+
+type logoutUserRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// logoutUser blocks the session the refresh token belongs to,
+// the refresh token can no longer be used to renew access tokens afterwards
+func (server *Server) logoutUser(ctx *gin.Context) {
+	var req logoutUserRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	refreshPayload, err := server.tokenMaker.VerifyToken(req.RefreshToken, token.RefreshToken)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
 	}
 
-	user, err := server.store.CreateUser(ctx, arg)
+	session, err := server.store.GetSession(ctx, refreshPayload.ID)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
-	ctx.JSON(http.StatusOK, user)
+
+	if session.RefreshToken != req.RefreshToken {
+		err := errors.New("mismatched session token")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	if err := server.store.BlockSession(ctx, session.ID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// logoutAllUserSessions blocks every session of the authenticated user,
+// logging them out of all devices once their access tokens expire
+func (server *Server) logoutAllUserSessions(ctx *gin.Context) {
+	authPayload := getAuthPayload(ctx)
+
+	if err := server.store.BlockUserSessions(ctx, authPayload.UserID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.Status(http.StatusNoContent)
 }
 
 type updateUserRequestUri struct {
@@ -41,10 +285,10 @@ type updateUserRequestUri struct {
 
 type updateUserRequestParams struct {
 	Username string `json:"username" binding:"required"`
-	Email    string `json:"email" binding:"required"`
-	Password string `json:"password" binding:"required"`
 }
 
+// updateUser changes the profile of a user, the email address and password are changed
+// through updateUserCredentials
 func (server *Server) updateUser(ctx *gin.Context) {
 	var uid updateUserRequestUri
 	var req updateUserRequestParams
@@ -57,18 +301,126 @@ func (server *Server) updateUser(ctx *gin.Context) {
 		return
 	}
 
-	arg := db.UpdateUserParams{
+	if err := policy.CanModifyUser(getAuthSubject(ctx), uid.ID); err != nil {
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+
+	user, err := server.store.UpdateUser(ctx, db.UpdateUserParams{
 		ID:       uid.ID,
 		Username: req.Username,
-		Email:    req.Email,
-		Password: req.Password,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, newPrivateUserResponse(user))
+}
+
+type updateUserCredentialsRequestUri struct {
+	ID int32 `uri:"id" binding:"required,min=1"`
+}
+
+// updateUserCredentialsRequestParams leaves out what stays as it is, at least one change is required
+type updateUserCredentialsRequestParams struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	Email           string `json:"email" binding:"omitempty,email"`
+	NewPassword     string `json:"new_password"`
+}
+
+var (
+	errNoCredentialChange = errors.New("neither a new email address nor a new password was given")
+	errWrongPassword      = errors.New("the current password is wrong")
+)
+
+// updateUserCredentials changes the email address or password of the caller after checking their current password.
+// Wrong passwords count as failed logins. Every session opened with the old credentials is blocked,
+// the caller gets a new one in return.
+func (server *Server) updateUserCredentials(ctx *gin.Context) {
+	var uid updateUserCredentialsRequestUri
+	var req updateUserCredentialsRequestParams
+	if err := ctx.ShouldBindUri(&uid); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if req.Email == "" && req.NewPassword == "" {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errNoCredentialChange))
+		return
 	}
-	user, err := server.store.UpdateUser(ctx, arg)
+
+	if err := policy.CanChangeCredentials(getAuthSubject(ctx), uid.ID); err != nil {
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+
+	user, err := server.store.GetUserById(ctx, uid.ID)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
-	ctx.JSON(http.StatusOK, user)
+
+	if !server.checkLoginAllowed(ctx, user.Username) {
+		return
+	}
+	if err := util.CheckPassword(req.CurrentPassword, user.HashedPassword); err != nil {
+		server.recordLoginFailure(ctx, user.Username)
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errWrongPassword))
+		return
+	}
+
+	arg := db.UpdateUserCredentialsTxParams{
+		UpdateUserCredentialsParams: db.UpdateUserCredentialsParams{
+			ID:             user.ID,
+			HashedPassword: user.HashedPassword,
+			Email:          user.Email,
+		},
+	}
+	if req.Email != "" {
+		arg.Email = req.Email
+	}
+	if req.NewPassword != "" {
+		arg.HashedPassword, err = util.HashPassword(req.NewPassword, server.config.BcryptCost)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+	}
+
+	result, err := server.store.UpdateUserCredentialsTx(ctx, arg)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// UpdateUserCredentials clears the verification when the email address changed
+	if result.User.Email != user.Email {
+		if err := server.startEmailVerification(ctx, result.User); err != nil {
+			_ = ctx.Error(err)
+		}
+	}
+
+	res, err := server.createLoginSession(ctx, result.User)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, res)
 }
 
 type getUserByIdRequest struct {
@@ -92,7 +444,7 @@ func (server *Server) getUserById(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
-	ctx.JSON(http.StatusOK, user)
+	ctx.JSON(http.StatusOK, newUserResponse(user))
 }
 
 type listUsersParams struct {
@@ -117,5 +469,69 @@ func (server *Server) listUsers(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
-	ctx.JSON(http.StatusOK, users)
+	ctx.JSON(http.StatusOK, newPrivateUserResponses(users))
+}
+
+type updateUserRoleRequestUri struct {
+	ID int32 `uri:"id" binding:"required,min=1"`
+}
+
+type updateUserRoleRequestParams struct {
+	Role string `json:"role" binding:"required,oneof=listener producer admin"`
+}
+
+// updateUserRole promotes or demotes a user, the change is recorded in the audit log
+func (server *Server) updateUserRole(ctx *gin.Context) {
+	var uid updateUserRoleRequestUri
+	var req updateUserRoleRequestParams
+	if err := ctx.ShouldBindUri(&uid); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := getAuthPayload(ctx)
+	if authPayload.UserID == uid.ID {
+		err := errors.New("admins cannot change their own role")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	result, err := server.store.UpdateUserRoleTx(ctx, db.UpdateUserRoleTxParams{
+		ActorID: authPayload.UserID,
+		UserID:  uid.ID,
+		Role:    req.Role,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, newPrivateUserResponse(result.User))
+}
+
+// rehashPasswordIfNeeded upgrades the stored hash of user when it was generated
+// with a different bcrypt cost than the one currently configured.
+// It must only be called after password has been verified against the stored hash.
+func (server *Server) rehashPasswordIfNeeded(ctx *gin.Context, user db.User, password string) error {
+	if !util.PasswordNeedsRehash(user.HashedPassword, server.config.BcryptCost) {
+		return nil
+	}
+
+	hashedPassword, err := util.HashPassword(password, server.config.BcryptCost)
+	if err != nil {
+		return err
+	}
+
+	arg := db.UpdateUserHashedPasswordParams{
+		ID:             user.ID,
+		HashedPassword: hashedPassword,
+	}
+	return server.store.UpdateUserHashedPassword(ctx, arg)
 }
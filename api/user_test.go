@@ -2,35 +2,97 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"testing"
+	"time"
 
 	mockdb "github.com/danglebary/beatstore-backend-go/db/mock"
 	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
+	"github.com/danglebary/beatstore-backend-go/token"
 	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
 )
 
-func randomUser() db.User {
-	return db.User{
-		ID:       int32(util.RandomInt(1, 1000)),
-		Username: util.RandomUsername(),
-		Email:    util.RandomEmail(),
-		Password: util.RandomPassword(),
+func randomUser(t *testing.T) (db.User, string) {
+	password := util.RandomPassword()
+	hashedPassword, err := util.HashPassword(password, bcrypt.MinCost)
+	require.NoError(t, err)
+
+	user := db.User{
+		ID:             int32(util.RandomInt(1, 1000)),
+		Username:       util.RandomUsername(),
+		Email:          util.RandomEmail(),
+		HashedPassword: hashedPassword,
+		Role:           util.ListenerRole,
+		VerifiedAt:     sql.NullTime{Time: time.Now(), Valid: true},
+	}
+	return user, password
+}
+
+type eqHashedPasswordMatcher struct {
+	arg      interface{}
+	password string
+}
+
+// Matches create user and update credentials params whose hashed password belongs to password,
+// every other field must be equal to the expected params. Create user tx params
+// must also carry a pending email verification.
+func (e eqHashedPasswordMatcher) Matches(x interface{}) bool {
+	switch expected := e.arg.(type) {
+	case db.CreateUserParams:
+		arg, ok := x.(db.CreateUserParams)
+		if !ok || util.CheckPassword(e.password, arg.HashedPassword) != nil {
+			return false
+		}
+		expected.HashedPassword = arg.HashedPassword
+		return reflect.DeepEqual(expected, arg)
+	case db.CreateUserTxParams:
+		arg, ok := x.(db.CreateUserTxParams)
+		if !ok || arg.VerificationHashedToken == "" || !arg.VerificationExpiresAt.After(time.Now()) {
+			return false
+		}
+		return eqHashedPasswordMatcher{expected.CreateUserParams, e.password}.Matches(arg.CreateUserParams)
+	case db.UpdateUserCredentialsParams:
+		arg, ok := x.(db.UpdateUserCredentialsParams)
+		if !ok || util.CheckPassword(e.password, arg.HashedPassword) != nil {
+			return false
+		}
+		expected.HashedPassword = arg.HashedPassword
+		return reflect.DeepEqual(expected, arg)
+	case db.UpdateUserCredentialsTxParams:
+		arg, ok := x.(db.UpdateUserCredentialsTxParams)
+		if !ok {
+			return false
+		}
+		return eqHashedPasswordMatcher{expected.UpdateUserCredentialsParams, e.password}.Matches(arg.UpdateUserCredentialsParams)
 	}
+	return false
 }
 
-func randomUsers(n int) []db.User {
+func (e eqHashedPasswordMatcher) String() string {
+	return fmt.Sprintf("matches arg %v and password %v", e.arg, e.password)
+}
+
+func EqHashedPassword(arg interface{}, password string) gomock.Matcher {
+	return eqHashedPasswordMatcher{arg, password}
+}
+
+func randomUsers(t *testing.T, n int) []db.User {
 	var users []db.User
 
 	for i := 0; i < n; i++ {
-		users = append(users, randomUser())
+		user, _ := randomUser(t)
+		users = append(users, user)
 	}
 	return users
 }
@@ -45,7 +107,10 @@ func requireBodyMatchUser(t *testing.T, body *bytes.Buffer, user db.User) {
 	err = json.Unmarshal(data, &gotUser)
 	require.NoError(t, err)
 
-	require.Equal(t, user, gotUser)
+	require.Equal(t, user.ID, gotUser.ID)
+	require.Equal(t, user.Username, gotUser.Username)
+	require.Equal(t, user.Role, gotUser.Role)
+	require.Empty(t, gotUser.HashedPassword)
 }
 
 func requireBodyMatchUsers(t *testing.T, body *bytes.Buffer, users []db.User) {
@@ -59,52 +124,649 @@ func requireBodyMatchUsers(t *testing.T, body *bytes.Buffer, users []db.User) {
 	require.NoError(t, err)
 
 	for i := range users {
-		require.Equal(t, users[i], gotUsers[i])
+		require.Equal(t, users[i].ID, gotUsers[i].ID)
+		require.Equal(t, users[i].Username, gotUsers[i].Username)
+		require.Equal(t, users[i].Email, gotUsers[i].Email)
+		require.Equal(t, users[i].Role, gotUsers[i].Role)
+		require.Empty(t, gotUsers[i].HashedPassword)
 	}
 }
 
 func TestCreateUser(t *testing.T) {
-	user := randomUser()
+	user, password := randomUser(t)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{"username": user.Username, "email": user.Email, "password": password},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.CreateUserTxParams{
+					CreateUserParams: db.CreateUserParams{
+						Username: user.Username,
+						Email:    user.Email,
+						Role:     util.ListenerRole,
+					},
+				}
+
+				store.EXPECT().
+					CreateUserTx(gomock.Any(), EqHashedPassword(arg, password)).
+					Times(1).
+					Return(db.CreateUserTxResult{User: user}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchUser(t, recorder.Body, user)
+			},
+		},
+		{
+			name: "BadRequest",
+			body: gin.H{"username": user.Username, "email": user.Email},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: gin.H{"username": user.Username, "email": user.Email, "password": password},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.CreateUserTxParams{
+					CreateUserParams: db.CreateUserParams{
+						Username: user.Username,
+						Email:    user.Email,
+						Role:     util.ListenerRole,
+					},
+				}
+
+				store.EXPECT().
+					CreateUserTx(gomock.Any(), EqHashedPassword(arg, password)).
+					Times(1).
+					Return(db.CreateUserTxResult{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name: "ProducerSignup",
+			body: gin.H{"username": user.Username, "email": user.Email, "password": password, "role": util.ProducerRole},
+			buildStubs: func(store *mockdb.MockStore) {
+				// Producers are only promoted by an admin, a requested role is ignored
+				arg := db.CreateUserTxParams{
+					CreateUserParams: db.CreateUserParams{
+						Username: user.Username,
+						Email:    user.Email,
+						Role:     util.ListenerRole,
+					},
+				}
+
+				store.EXPECT().
+					CreateUserTx(gomock.Any(), EqHashedPassword(arg, password)).
+					Times(1).
+					Return(db.CreateUserTxResult{User: user}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got privateUserResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Equal(t, util.ListenerRole, got.Role)
+			},
+		},
+		{
+			name: "InvalidEmail",
+			body: gin.H{"username": user.Username, "email": "invalid-email", "password": password},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			// Init controller and store
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			// Build stub
+			tc.buildStubs(store)
+			// Start test server, build request, and send
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+			url := "/users"
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(data))
+			require.NoError(t, err)
+			// Server http response
+			server.router.ServeHTTP(recorder, request)
+			// check response
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestLoginUser(t *testing.T) {
+	user, password := randomUser(t)
+	mfaUser, mfaPassword := randomMFAUser(t)
+
+	testCases := []struct {
+		name          string
+		body          loginUserRequest
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: loginUserRequest{
+				Username: user.Username,
+				Password: password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					UpdateUserHashedPassword(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var res loginUserResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &res)
+				require.NoError(t, err)
+				require.NotEmpty(t, res.AccessToken)
+				require.NotEmpty(t, res.RefreshToken)
+				require.Equal(t, user.ID, res.User.ID)
+			},
+		},
+		{
+			name: "MFARequired",
+			body: loginUserRequest{
+				Username: mfaUser.Username,
+				Password: mfaPassword,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(mfaUser.Username)).
+					Times(1).
+					Return(mfaUser, nil)
+				store.EXPECT().
+					CreateMFAChallenge(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateMFAChallengeParams) (db.MfaChallenge, error) {
+						require.Equal(t, mfaUser.ID, arg.UserID)
+						require.NotEmpty(t, arg.HashedToken)
+						return db.MfaChallenge{UserID: arg.UserID, HashedToken: arg.HashedToken, ExpiresAt: arg.ExpiresAt}, nil
+					})
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var res mfaRequiredResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &res)
+				require.NoError(t, err)
+				require.True(t, res.MFARequired)
+				require.NotEmpty(t, res.MFAToken)
+				require.NotContains(t, recorder.Body.String(), "access_token")
+			},
+		},
+		{
+			name: "UserNotFound",
+			body: loginUserRequest{
+				Username: user.Username,
+				Password: password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().
+					CreateLoginEvent(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.LoginEvent{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "IncorrectPassword",
+			body: loginUserRequest{
+				Username: user.Username,
+				Password: "incorrect",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					CreateLoginEvent(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.LoginEvent{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "RehashPassword",
+			body: loginUserRequest{
+				Username: user.Username,
+				Password: password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				outdatedHash, err := util.HashPassword(password, bcrypt.MinCost+1)
+				require.NoError(t, err)
+				outdatedUser := user
+				outdatedUser.HashedPassword = outdatedHash
+
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(outdatedUser, nil)
+				store.EXPECT().
+					UpdateUserHashedPassword(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.UpdateUserHashedPasswordParams) error {
+						require.Equal(t, user.ID, arg.ID)
+						require.False(t, util.PasswordNeedsRehash(arg.HashedPassword, bcrypt.MinCost))
+						require.NoError(t, util.CheckPassword(password, arg.HashedPassword))
+						return nil
+					})
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: loginUserRequest{
+				Username: user.Username,
+				Password: password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name: "BadRequest",
+			body: loginUserRequest{
+				Username: user.Username,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			// Init controller and store
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			// Build stub
+			tc.buildStubs(store)
+			// Start test server, build request, and send
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+			url := "/users/login"
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(data))
+			require.NoError(t, err)
+			// Server http response
+			server.router.ServeHTTP(recorder, request)
+			// check response
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestLogoutUser(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name          string
+		buildBody     func(refreshToken string) logoutUserRequest
+		buildStubs    func(store *mockdb.MockStore, session db.Session)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildBody: func(refreshToken string) logoutUserRequest {
+				return logoutUserRequest{RefreshToken: refreshToken}
+			},
+			buildStubs: func(store *mockdb.MockStore, session db.Session) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(session.ID)).
+					Times(1).
+					Return(session, nil)
+				store.EXPECT().
+					BlockSession(gomock.Any(), gomock.Eq(session.ID)).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidToken",
+			buildBody: func(refreshToken string) logoutUserRequest {
+				return logoutUserRequest{RefreshToken: "invalid"}
+			},
+			buildStubs: func(store *mockdb.MockStore, session db.Session) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					BlockSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "SessionNotFound",
+			buildBody: func(refreshToken string) logoutUserRequest {
+				return logoutUserRequest{RefreshToken: refreshToken}
+			},
+			buildStubs: func(store *mockdb.MockStore, session db.Session) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Session{}, sql.ErrNoRows)
+				store.EXPECT().
+					BlockSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "BadRequest",
+			buildBody: func(refreshToken string) logoutUserRequest {
+				return logoutUserRequest{}
+			},
+			buildStubs: func(store *mockdb.MockStore, session db.Session) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			// Init controller and store
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			// Start test server, build stub, build request, and send
+			server := newTestServer(t, store)
+			refreshToken, session := createRefreshToken(t, server.tokenMaker, user)
+			tc.buildStubs(store, session)
+			recorder := httptest.NewRecorder()
+			url := "/users/logout"
+			data, err := json.Marshal(tc.buildBody(refreshToken))
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(data))
+			require.NoError(t, err)
+			// Server http response
+			server.router.ServeHTTP(recorder, request)
+			// check response
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestLogoutAllUserSessions(t *testing.T) {
+	user, _ := randomUser(t)
 
 	testCases := []struct {
 		name          string
-		body          createUserRequest
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
 		buildStubs    func(store *mockdb.MockStore)
 		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
 	}{
 		{
-			name: "OK",
-			body: createUserRequest{
-				Username: user.Username,
-				Email:    user.Email,
-				Password: user.Password,
+			name: "OK",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, util.ListenerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					BlockUserSessions(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+			},
+		},
+		{
+			name: "NoAuthorization",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					BlockUserSessions(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, util.ListenerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					BlockUserSessions(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return(sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			// Init controller and store
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			// Build stub
+			tc.buildStubs(store)
+			// Start test server, build request, and send
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+			url := "/users/logout/all"
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+			tc.setupAuth(t, request, server.tokenMaker)
+			// Server http response
+			server.router.ServeHTTP(recorder, request)
+			// check response
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestUpdateUser(t *testing.T) {
+	user, _ := randomUser(t)
+	newUsername := util.RandomUsername()
+
+	testCases := []struct {
+		name          string
+		userID        int32
+		body          gin.H
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "OK",
+			userID: user.ID,
+			body:   gin.H{"username": newUsername},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, util.ListenerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				updated := user
+				updated.Username = newUsername
+
+				store.EXPECT().
+					UpdateUser(gomock.Any(), gomock.Eq(db.UpdateUserParams{ID: user.ID, Username: newUsername})).
+					Times(1).
+					Return(updated, nil)
+				store.EXPECT().
+					UpdateUserCredentialsTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got privateUserResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Equal(t, newUsername, got.Username)
+				require.Equal(t, user.Email, got.Email)
+			},
+		},
+		{
+			name:   "AdminOverride",
+			userID: user.ID,
+			body:   gin.H{"username": newUsername},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID+1, util.RandomUsername(), util.AdminRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUser(gomock.Any(), gomock.Eq(db.UpdateUserParams{ID: user.ID, Username: newUsername})).
+					Times(1).
+					Return(user, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "Forbidden",
+			userID: user.ID,
+			body:   gin.H{"username": newUsername},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID+1, util.RandomUsername(), util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:   "NoAuthorization",
+			userID: user.ID,
+			body:   gin.H{"username": newUsername},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
-				arg := db.CreateUserParams{
-					Username: user.Username,
-					Email:    user.Email,
-					Password: user.Password,
-				}
-
 				store.EXPECT().
-					CreateUser(gomock.Any(), gomock.Eq(arg)).
+					UpdateUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:   "NotFound",
+			userID: user.ID,
+			body:   gin.H{"username": newUsername},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID+1, util.RandomUsername(), util.AdminRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUser(gomock.Any(), gomock.Any()).
 					Times(1).
-					Return(user, nil)
+					Return(db.User{}, sql.ErrNoRows)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusOK, recorder.Code)
-				requireBodyMatchUser(t, recorder.Body, user)
+				require.Equal(t, http.StatusNotFound, recorder.Code)
 			},
 		},
 		{
-			name: "BadRequest",
-			body: createUserRequest{
-				Username: user.Username,
-				Email:    user.Email,
+			name:   "BadRequest-Uri",
+			userID: 0,
+			body:   gin.H{"username": newUsername},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, util.ListenerRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					CreateUser(gomock.Any(), gomock.Any()).
+					UpdateUser(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
@@ -112,21 +774,31 @@ func TestCreateUser(t *testing.T) {
 			},
 		},
 		{
-			name: "InternalError",
-			body: createUserRequest{
-				Username: user.Username,
-				Email:    user.Email,
-				Password: user.Password,
+			name:   "BadRequest-Body",
+			userID: user.ID,
+			body:   gin.H{},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, util.ListenerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:   "InternalError",
+			userID: user.ID,
+			body:   gin.H{"username": newUsername},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, util.ListenerRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
-				arg := db.CreateUserParams{
-					Username: user.Username,
-					Email:    user.Email,
-					Password: user.Password,
-				}
-
 				store.EXPECT().
-					CreateUser(gomock.Any(), gomock.Eq(arg)).
+					UpdateUser(gomock.Any(), gomock.Any()).
 					Times(1).
 					Return(db.User{}, sql.ErrConnDone)
 			},
@@ -147,13 +819,14 @@ func TestCreateUser(t *testing.T) {
 			// Build stub
 			tc.buildStubs(store)
 			// Start test server, build request, and send
-			server := NewServer(store)
+			server := newTestServer(t, store)
 			recorder := httptest.NewRecorder()
-			url := "/users"
+			url := fmt.Sprintf("/users/%d", tc.userID)
 			data, err := json.Marshal(tc.body)
 			require.NoError(t, err)
 			request, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(data))
 			require.NoError(t, err)
+			tc.setupAuth(t, request, server.tokenMaker)
 			// Server http response
 			server.router.ServeHTTP(recorder, request)
 			// check response
@@ -162,53 +835,155 @@ func TestCreateUser(t *testing.T) {
 	}
 }
 
-func TestUpdateUser(t *testing.T) {
-	user := randomUser()
+func TestUpdateUserCredentials(t *testing.T) {
+	user, password := randomUser(t)
+	newPassword := util.RandomPassword()
+	newEmail := util.RandomEmail()
 
 	testCases := []struct {
 		name          string
 		userID        int32
-		body          updateUserRequestParams
+		body          gin.H
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
 		buildStubs    func(store *mockdb.MockStore)
 		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
 	}{
 		{
-			name:   "OK",
+			name:   "NewPassword",
 			userID: user.ID,
-			body: updateUserRequestParams{
-				Username: user.Username,
-				Email:    user.Email,
-				Password: user.Password,
+			body:   gin.H{"current_password": password, "new_password": newPassword},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, util.ListenerRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
-				arg := db.UpdateUserParams{
-					ID:       user.ID,
-					Username: user.Username,
-					Email:    user.Email,
-					Password: user.Password,
+				arg := db.UpdateUserCredentialsTxParams{
+					UpdateUserCredentialsParams: db.UpdateUserCredentialsParams{
+						ID:    user.ID,
+						Email: user.Email,
+					},
 				}
 
 				store.EXPECT().
-					UpdateUser(gomock.Any(), gomock.Eq(arg)).
+					GetUserById(gomock.Any(), gomock.Eq(user.ID)).
 					Times(1).
 					Return(user, nil)
+				store.EXPECT().
+					UpdateUserCredentialsTx(gomock.Any(), EqHashedPassword(arg, newPassword)).
+					Times(1).
+					Return(db.UpdateUserCredentialsTxResult{User: user}, nil)
+				// The sessions of the old password are blocked, the caller gets a new one
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateSessionParams) (db.Session, error) {
+						require.Equal(t, user.ID, arg.UserID)
+						return db.Session{ID: arg.ID, UserID: arg.UserID}, nil
+					})
+				store.EXPECT().
+					CreateEmailVerification(gomock.Any(), gomock.Any()).
+					Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
-				requireBodyMatchUser(t, recorder.Body, user)
+
+				var got loginUserResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.NotEmpty(t, got.AccessToken)
+				require.NotEmpty(t, got.RefreshToken)
+				require.Equal(t, user.ID, got.User.ID)
 			},
 		},
 		{
-			name:   "BadRequest-Uri",
-			userID: 0,
-			body: updateUserRequestParams{
-				Username: user.Username,
-				Email:    user.Email,
-				Password: user.Password,
+			name:   "NewEmail",
+			userID: user.ID,
+			body:   gin.H{"current_password": password, "email": newEmail},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, util.ListenerRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				updated := user
+				updated.Email = newEmail
+				updated.VerifiedAt = sql.NullTime{}
+
 				store.EXPECT().
-					UpdateUser(gomock.Any(), gomock.Any()).
+					GetUserById(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return(user, nil)
+				// The password stays as it is
+				store.EXPECT().
+					UpdateUserCredentialsTx(gomock.Any(), gomock.Eq(db.UpdateUserCredentialsTxParams{
+						UpdateUserCredentialsParams: db.UpdateUserCredentialsParams{
+							ID:             user.ID,
+							HashedPassword: user.HashedPassword,
+							Email:          newEmail,
+						},
+					})).
+					Times(1).
+					Return(db.UpdateUserCredentialsTxResult{User: updated}, nil)
+				store.EXPECT().
+					CreateEmailVerification(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateEmailVerificationParams) (db.EmailVerification, error) {
+						require.Equal(t, user.ID, arg.UserID)
+						require.Equal(t, newEmail, arg.Email)
+						require.NotEmpty(t, arg.HashedToken)
+						return db.EmailVerification{UserID: arg.UserID, Email: arg.Email}, nil
+					})
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Session{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got loginUserResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Equal(t, newEmail, got.User.Email)
+				require.False(t, got.User.EmailVerified)
+			},
+		},
+		{
+			name:   "WrongPassword",
+			userID: user.ID,
+			body:   gin.H{"current_password": "wrong" + password, "new_password": newPassword},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, util.ListenerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserById(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return(user, nil)
+				// Counted like a failed login
+				store.EXPECT().
+					CreateLoginEvent(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateLoginEventParams) (db.LoginEvent, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.Equal(t, loginEventFailed, arg.Event)
+						return db.LoginEvent{}, nil
+					})
+				store.EXPECT().
+					UpdateUserCredentialsTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:   "NothingToChange",
+			userID: user.ID,
+			body:   gin.H{"current_password": password},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, util.ListenerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUserCredentialsTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
@@ -216,34 +991,59 @@ func TestUpdateUser(t *testing.T) {
 			},
 		},
 		{
-			name:   "BadRequest-Body",
+			name:   "MissingCurrentPassword",
 			userID: user.ID,
-			body: updateUserRequestParams{
-				Username: user.Username,
-				Email:    user.Email,
+			body:   gin.H{"new_password": newPassword},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, util.ListenerRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					UpdateUser(gomock.Any(), gomock.Any()).
+					UpdateUserCredentialsTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusBadRequest, recorder.Code)
 			},
 		},
+		{
+			name:   "AdminForbidden",
+			userID: user.ID,
+			body:   gin.H{"current_password": password, "new_password": newPassword},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID+1, util.RandomUsername(), util.AdminRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserById(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					UpdateUserCredentialsTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
 		{
 			name:   "InternalError",
 			userID: user.ID,
-			body: updateUserRequestParams{
-				Username: user.Username,
-				Email:    user.Email,
-				Password: user.Password,
+			body:   gin.H{"current_password": password, "new_password": newPassword},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, util.ListenerRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					UpdateUser(gomock.Any(), gomock.Any()).
+					GetUserById(gomock.Any(), gomock.Eq(user.ID)).
 					Times(1).
-					Return(db.User{}, sql.ErrConnDone)
+					Return(user, nil)
+				store.EXPECT().
+					UpdateUserCredentialsTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.UpdateUserCredentialsTxResult{}, sql.ErrConnDone)
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusInternalServerError, recorder.Code)
@@ -262,13 +1062,14 @@ func TestUpdateUser(t *testing.T) {
 			// Build stub
 			tc.buildStubs(store)
 			// Start test server, build request, and send
-			server := NewServer(store)
+			server := newTestServer(t, store)
 			recorder := httptest.NewRecorder()
-			url := fmt.Sprintf("/users/%d", tc.userID)
+			url := fmt.Sprintf("/users/%d/credentials", tc.userID)
 			data, err := json.Marshal(tc.body)
 			require.NoError(t, err)
 			request, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(data))
 			require.NoError(t, err)
+			tc.setupAuth(t, request, server.tokenMaker)
 			// Server http response
 			server.router.ServeHTTP(recorder, request)
 			// check response
@@ -276,9 +1077,8 @@ func TestUpdateUser(t *testing.T) {
 		})
 	}
 }
-
 func TestGetUserByIDApi(t *testing.T) {
-	user := randomUser()
+	user, _ := randomUser(t)
 
 	testCases := []struct {
 		name          string
@@ -351,7 +1151,7 @@ func TestGetUserByIDApi(t *testing.T) {
 			// Build stub
 			tc.buildStubs(store)
 			// Start test server, build request, and send
-			server := NewServer(store)
+			server := newTestServer(t, store)
 			recorder := httptest.NewRecorder()
 			url := fmt.Sprintf("/users/%d", tc.userId)
 			request, err := http.NewRequest(http.MethodGet, url, nil)
@@ -365,12 +1165,15 @@ func TestGetUserByIDApi(t *testing.T) {
 }
 
 func TestListUsers(t *testing.T) {
-	users := randomUsers(5)
+	users := randomUsers(t, 5)
+	admin, _ := randomUser(t)
+	admin.Role = util.AdminRole
 
 	testCases := []struct {
 		name          string
 		pageID        int32
 		pageSize      int32
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
 		buildStubs    func(store *mockdb.MockStore)
 		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
 	}{
@@ -378,6 +1181,9 @@ func TestListUsers(t *testing.T) {
 			name:     "OK",
 			pageID:   1,
 			pageSize: 5,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin.ID, admin.Username, util.AdminRole, time.Minute)
+			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
 					ListUsers(gomock.Any(), gomock.Any()).
@@ -393,6 +1199,9 @@ func TestListUsers(t *testing.T) {
 			name:     "InternalError",
 			pageID:   1,
 			pageSize: 5,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin.ID, admin.Username, util.AdminRole, time.Minute)
+			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
 					ListUsers(gomock.Any(), gomock.Any()).
@@ -407,6 +1216,9 @@ func TestListUsers(t *testing.T) {
 			name:     "BadRequest",
 			pageID:   0,
 			pageSize: 100,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin.ID, admin.Username, util.AdminRole, time.Minute)
+			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
 					ListUsers(gomock.Any(), gomock.Any()).
@@ -416,6 +1228,22 @@ func TestListUsers(t *testing.T) {
 				require.Equal(t, http.StatusBadRequest, recorder.Code)
 			},
 		},
+		{
+			name:     "Forbidden",
+			pageID:   1,
+			pageSize: 5,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, users[0].ID, users[0].Username, util.ListenerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListUsers(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
 	}
 
 	for i := range testCases {
@@ -429,13 +1257,151 @@ func TestListUsers(t *testing.T) {
 			// Build stub
 			tc.buildStubs(store)
 			// Start test server, build request, and send
-			server := NewServer(store)
+			server := newTestServer(t, store)
 			recorder := httptest.NewRecorder()
 
 			url := fmt.Sprintf("/users?page_id=%d&page_size=%d", tc.pageID, tc.pageSize)
 
 			request, err := http.NewRequest(http.MethodGet, url, nil)
 			require.NoError(t, err)
+			tc.setupAuth(t, request, server.tokenMaker)
+			// Server http response
+			server.router.ServeHTTP(recorder, request)
+			// check response
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestUpdateUserRole(t *testing.T) {
+	user, _ := randomUser(t)
+	admin, _ := randomUser(t)
+	admin.Role = util.AdminRole
+
+	testCases := []struct {
+		name          string
+		userID        int32
+		body          updateUserRoleRequestParams
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "OK",
+			userID: user.ID,
+			body:   updateUserRoleRequestParams{Role: util.ProducerRole},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin.ID, admin.Username, util.AdminRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.UpdateUserRoleTxParams{
+					ActorID: admin.ID,
+					UserID:  user.ID,
+					Role:    util.ProducerRole,
+				}
+
+				promoted := user
+				promoted.Role = util.ProducerRole
+				store.EXPECT().
+					UpdateUserRoleTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.UpdateUserRoleTxResult{User: promoted}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var gotUser db.User
+				err := json.Unmarshal(recorder.Body.Bytes(), &gotUser)
+				require.NoError(t, err)
+				require.Equal(t, util.ProducerRole, gotUser.Role)
+			},
+		},
+		{
+			name:   "Forbidden",
+			userID: user.ID,
+			body:   updateUserRoleRequestParams{Role: util.AdminRole},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.ID, user.Username, util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUserRoleTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:   "OwnRole",
+			userID: admin.ID,
+			body:   updateUserRoleRequestParams{Role: util.ListenerRole},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin.ID, admin.Username, util.AdminRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUserRoleTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:   "InvalidRole",
+			userID: user.ID,
+			body:   updateUserRoleRequestParams{Role: "superuser"},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin.ID, admin.Username, util.AdminRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUserRoleTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:   "NotFound",
+			userID: user.ID,
+			body:   updateUserRoleRequestParams{Role: util.ProducerRole},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin.ID, admin.Username, util.AdminRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUserRoleTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.UpdateUserRoleTxResult{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			// Init controller and store
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			// Build stub
+			tc.buildStubs(store)
+			// Start test server, build request, and send
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+			url := fmt.Sprintf("/users/%d/role", tc.userID)
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(data))
+			require.NoError(t, err)
+			tc.setupAuth(t, request, server.tokenMaker)
 			// Server http response
 			server.router.ServeHTTP(recorder, request)
 			// check response
@@ -443,3 +1409,26 @@ func TestListUsers(t *testing.T) {
 		})
 	}
 }
+
+func TestLogoutUserWithAccessToken(t *testing.T) {
+	user, _ := randomUser(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		BlockSession(gomock.Any(), gomock.Any()).
+		Times(0)
+
+	server := newTestServer(t, store)
+	accessToken, _, err := server.tokenMaker.CreateToken(user.ID, user.Username, user.Role, token.AccessToken, time.Minute)
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	data, err := json.Marshal(logoutUserRequest{RefreshToken: accessToken})
+	require.NoError(t, err)
+	request, err := http.NewRequest(http.MethodPost, "/users/logout", bytes.NewBuffer(data))
+	require.NoError(t, err)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
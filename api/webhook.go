@@ -0,0 +1,224 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
+	"github.com/danglebary/beatstore-backend-go/payment"
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/gin-gonic/gin"
+)
+
+// maxWebhookPayloadSize is far above the size of any event the provider sends
+const maxWebhookPayloadSize = 64 << 10
+
+// paymentEventStatuses maps the types of the events which settle orders to the status the order moves to,
+// events of other types are recorded and ignored
+var paymentEventStatuses = map[string]string{
+	payment.EventIntentSucceeded: util.OrderPaid,
+	payment.EventIntentFailed:    util.OrderFailed,
+	payment.EventIntentRefunded:  util.OrderRefunded,
+}
+
+var errWebhookEventSettled = errors.New("webhook event was processed already")
+
+type webhookEventResponse struct {
+	ID          string     `json:"id"`
+	Type        string     `json:"type"`
+	Status      string     `json:"status"`
+	Attempts    int32      `json:"attempts"`
+	LastError   string     `json:"last_error,omitempty"`
+	OrderID     *int64     `json:"order_id,omitempty"`
+	ReceivedAt  time.Time  `json:"received_at"`
+	ProcessedAt *time.Time `json:"processed_at,omitempty"`
+}
+
+func newWebhookEventResponse(event db.WebhookEvent) webhookEventResponse {
+	res := webhookEventResponse{
+		ID:         event.ID,
+		Type:       event.Type,
+		Status:     event.Status,
+		Attempts:   event.Attempts,
+		LastError:  event.LastError,
+		ReceivedAt: event.ReceivedAt,
+	}
+	if event.OrderID.Valid {
+		orderID := event.OrderID.Int64
+		res.OrderID = &orderID
+	}
+	if event.ProcessedAt.Valid {
+		processedAt := event.ProcessedAt.Time
+		res.ProcessedAt = &processedAt
+	}
+	return res
+}
+
+// isPermanentEventError reports whether processing an event failed in a way redelivering it won't fix,
+// like an intent without an order or a transition the order doesn't allow
+func isPermanentEventError(err error) bool {
+	return errors.Is(err, sql.ErrNoRows) || errors.Is(err, db.ErrInvalidOrderTransition)
+}
+
+// processWebhookEvent applies a recorded event to its order. A payment for an exclusive license which went
+// to another order first is refunded and fails the order instead. A failure is recorded on the event
+// and returned along with the failed event, so it can be replayed once the cause is fixed.
+func (server *Server) processWebhookEvent(ctx *gin.Context, event db.WebhookEvent) (db.WebhookEvent, error) {
+	parsed, err := payment.ParseEvent(event.Payload)
+	if err != nil {
+		return event, err
+	}
+
+	arg := db.ProcessWebhookEventTxParams{
+		EventID:       event.ID,
+		IntentID:      parsed.IntentID,
+		OrderStatus:   paymentEventStatuses[parsed.Type],
+		FailureReason: parsed.FailureReason,
+	}
+	result, err := server.store.ProcessWebhookEventTx(ctx, arg)
+	if errors.Is(err, db.ErrLicenseSold) {
+		if _, err = server.payments.Refund(ctx, parsed.IntentID); err == nil {
+			arg.OrderStatus = util.OrderFailed
+			arg.FailureReason = db.ErrLicenseSold.Error()
+			result, err = server.store.ProcessWebhookEventTx(ctx, arg)
+		}
+	}
+	if err != nil {
+		failed, markErr := server.store.MarkWebhookEventFailed(ctx, db.MarkWebhookEventFailedParams{
+			ID:        event.ID,
+			LastError: err.Error(),
+		})
+		if markErr != nil {
+			_ = ctx.Error(markErr)
+			return event, err
+		}
+		return failed, err
+	}
+	return result.Event, nil
+}
+
+// paymentWebhook receives the events of the payment provider. Every event is recorded by its ID before it is
+// processed, redeliveries of an event are acknowledged without processing it again. Events which can't be
+// applied are recorded as failed and acknowledged unless retrying could help, admins replay them later on.
+func (server *Server) paymentWebhook(ctx *gin.Context) {
+	if ctx.Request.ContentLength > maxWebhookPayloadSize {
+		ctx.JSON(http.StatusRequestEntityTooLarge, errorResponse(fmt.Errorf("event exceeds the limit of %d bytes", maxWebhookPayloadSize)))
+		return
+	}
+	payload, err := ioutil.ReadAll(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxWebhookPayloadSize))
+	if err != nil {
+		if err.Error() == errBodyTooLarge {
+			ctx.JSON(http.StatusRequestEntityTooLarge, errorResponse(fmt.Errorf("event exceeds the limit of %d bytes", maxWebhookPayloadSize)))
+			return
+		}
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if err := server.webhookVerifier.Verify(payload, ctx.GetHeader(payment.SignatureHeader), server.now()); err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	parsed, err := payment.ParseEvent(payload)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	event, err := server.store.RecordWebhookEvent(ctx, db.RecordWebhookEventParams{
+		ID:      parsed.ID,
+		Type:    parsed.Type,
+		Payload: payload,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	event, err = server.processWebhookEvent(ctx, event)
+	if err != nil && !isPermanentEventError(err) {
+		// The provider delivers the event again
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, newWebhookEventResponse(event))
+}
+
+type listWebhookEventsRequest struct {
+	Status   string `form:"status" binding:"omitempty,oneof=pending processed ignored failed"`
+	PageID   int32  `form:"page_id" binding:"required,min=1"`
+	PageSize int32  `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+// listWebhookEvents returns the received payment events, oldest first.
+// Failed events are listed unless another status is asked for.
+func (server *Server) listWebhookEvents(ctx *gin.Context) {
+	var req listWebhookEventsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if req.Status == "" {
+		req.Status = util.WebhookEventFailed
+	}
+
+	events, err := server.store.ListWebhookEvents(ctx, db.ListWebhookEventsParams{
+		Status: req.Status,
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	res := make([]webhookEventResponse, len(events))
+	for i, event := range events {
+		res[i] = newWebhookEventResponse(event)
+	}
+	ctx.JSON(http.StatusOK, res)
+}
+
+type replayWebhookEventRequestUri struct {
+	ID string `uri:"id" binding:"required"`
+}
+
+// replayWebhookEvent processes a failed or pending event again from its recorded payload,
+// for instance once the order of its intent exists. Another failure is recorded on the event.
+func (server *Server) replayWebhookEvent(ctx *gin.Context) {
+	var uri replayWebhookEventRequestUri
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	event, err := server.store.GetWebhookEvent(ctx, uri.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if event.Status == util.WebhookEventProcessed || event.Status == util.WebhookEventIgnored {
+		ctx.JSON(http.StatusConflict, errorResponse(errWebhookEventSettled))
+		return
+	}
+
+	event, err = server.processWebhookEvent(ctx, event)
+	if err != nil {
+		if isPermanentEventError(err) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, newWebhookEventResponse(event))
+}
@@ -0,0 +1,763 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/danglebary/beatstore-backend-go/db/mock"
+	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
+	"github.com/danglebary/beatstore-backend-go/payment"
+	"github.com/danglebary/beatstore-backend-go/token"
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func randomPaymentEvent(eventType string) (payment.Event, []byte) {
+	event := payment.Event{
+		ID:       "evt_" + util.RandomString(16),
+		Type:     eventType,
+		IntentID: "pi_" + util.RandomString(16),
+	}
+	if eventType == payment.EventIntentFailed {
+		event.FailureReason = "insufficient funds"
+	}
+	payload, _ := json.Marshal(event)
+	return event, payload
+}
+
+func randomWebhookEvent(event payment.Event, payload []byte, status string) db.WebhookEvent {
+	return db.WebhookEvent{
+		ID:         event.ID,
+		Type:       event.Type,
+		Payload:    payload,
+		Status:     status,
+		ReceivedAt: time.Now().UTC().Truncate(time.Second),
+	}
+}
+
+// processedWebhookEvent returns event as ProcessWebhookEventTx leaves it after settling order
+func processedWebhookEvent(event db.WebhookEvent, status string, orderID int64) db.WebhookEvent {
+	event.Status = status
+	event.Attempts++
+	if orderID != 0 {
+		event.OrderID = sql.NullInt64{Int64: orderID, Valid: true}
+	}
+	event.ProcessedAt = sql.NullTime{Time: time.Now().UTC().Truncate(time.Second), Valid: true}
+	return event
+}
+
+func failedWebhookEvent(event db.WebhookEvent, err error) db.WebhookEvent {
+	event.Status = util.WebhookEventFailed
+	event.Attempts++
+	event.LastError = err.Error()
+	return event
+}
+
+func requireWebhookEventResponse(t *testing.T, body *bytes.Buffer, event db.WebhookEvent) {
+	var got webhookEventResponse
+	require.NoError(t, json.Unmarshal(body.Bytes(), &got))
+	require.Equal(t, newWebhookEventResponse(event), got)
+}
+
+func TestPaymentWebhook(t *testing.T) {
+	user, _ := randomUser(t)
+	order, _ := randomOrder(user.ID, util.OrderPaid)
+
+	succeeded, succeededPayload := randomPaymentEvent(payment.EventIntentSucceeded)
+	pending := randomWebhookEvent(succeeded, succeededPayload, util.WebhookEventPending)
+	processed := processedWebhookEvent(pending, util.WebhookEventProcessed, order.ID)
+
+	failedPayment, failedPaymentPayload := randomPaymentEvent(payment.EventIntentFailed)
+	failedPending := randomWebhookEvent(failedPayment, failedPaymentPayload, util.WebhookEventPending)
+
+	other, otherPayload := randomPaymentEvent("payment_intent.created")
+	otherPending := randomWebhookEvent(other, otherPayload, util.WebhookEventPending)
+
+	// An exclusive license of the order went to another order first
+	soldOutOrder, _ := randomOrder(user.ID, util.OrderPending)
+	soldOut, _ := randomPaymentEvent(payment.EventIntentSucceeded)
+	soldOut.IntentID = fmt.Sprintf("pi_fake_%s", paymentIdempotencyKey(soldOutOrder))
+	soldOutPayload, _ := json.Marshal(soldOut)
+	soldOutPending := randomWebhookEvent(soldOut, soldOutPayload, util.WebhookEventPending)
+	soldOutOrder.Status = util.OrderFailed
+	soldOutOrder.FailureReason = db.ErrLicenseSold.Error()
+	soldOutProcessed := processedWebhookEvent(soldOutPending, util.WebhookEventProcessed, soldOutOrder.ID)
+
+	// capture charges soldOutOrder at the provider like a checkout would
+	capture := func(t *testing.T, provider *payment.FakeProvider) {
+		intent, err := provider.CreateIntent(context.Background(), payment.IntentParams{
+			Amount:         soldOutOrder.Total,
+			Currency:       soldOutOrder.Currency,
+			PaymentMethod:  payment.FakeCardSucceeds,
+			IdempotencyKey: paymentIdempotencyKey(soldOutOrder),
+		})
+		require.NoError(t, err)
+		_, err = provider.Capture(context.Background(), intent.ID)
+		require.NoError(t, err)
+	}
+
+	// sign signs payload with the secret of the server, at the current time
+	sign := func(t *testing.T, verifier *payment.WebhookVerifier, payload []byte) string {
+		return verifier.Sign(payload, time.Now())
+	}
+
+	testCases := []struct {
+		name          string
+		payload       []byte
+		signature     func(t *testing.T, verifier *payment.WebhookVerifier, payload []byte) string
+		setupPayments func(t *testing.T, provider *payment.FakeProvider)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder, provider *payment.FakeProvider)
+	}{
+		{
+			name:      "OK",
+			payload:   succeededPayload,
+			signature: sign,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RecordWebhookEvent(gomock.Any(), gomock.Eq(db.RecordWebhookEventParams{
+						ID:      succeeded.ID,
+						Type:    succeeded.Type,
+						Payload: succeededPayload,
+					})).
+					Times(1).
+					Return(pending, nil)
+				store.EXPECT().
+					ProcessWebhookEventTx(gomock.Any(), gomock.Eq(db.ProcessWebhookEventTxParams{
+						EventID:     succeeded.ID,
+						IntentID:    succeeded.IntentID,
+						OrderStatus: util.OrderPaid,
+					})).
+					Times(1).
+					Return(db.ProcessWebhookEventTxResult{Event: processed, Order: order}, nil)
+				store.EXPECT().
+					MarkWebhookEventFailed(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, provider *payment.FakeProvider) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireWebhookEventResponse(t, recorder.Body, processed)
+			},
+		},
+		{
+			name:      "Redelivered",
+			payload:   succeededPayload,
+			signature: sign,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RecordWebhookEvent(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(processed, nil)
+				store.EXPECT().
+					ProcessWebhookEventTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ProcessWebhookEventTxResult{Event: processed, Duplicate: true}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, provider *payment.FakeProvider) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireWebhookEventResponse(t, recorder.Body, processed)
+			},
+		},
+		{
+			name:      "PaymentFailed",
+			payload:   failedPaymentPayload,
+			signature: sign,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RecordWebhookEvent(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(failedPending, nil)
+				store.EXPECT().
+					ProcessWebhookEventTx(gomock.Any(), gomock.Eq(db.ProcessWebhookEventTxParams{
+						EventID:       failedPayment.ID,
+						IntentID:      failedPayment.IntentID,
+						OrderStatus:   util.OrderFailed,
+						FailureReason: failedPayment.FailureReason,
+					})).
+					Times(1).
+					Return(db.ProcessWebhookEventTxResult{Event: processedWebhookEvent(failedPending, util.WebhookEventProcessed, order.ID)}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, provider *payment.FakeProvider) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:      "IgnoredType",
+			payload:   otherPayload,
+			signature: sign,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RecordWebhookEvent(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(otherPending, nil)
+				store.EXPECT().
+					ProcessWebhookEventTx(gomock.Any(), gomock.Eq(db.ProcessWebhookEventTxParams{
+						EventID:  other.ID,
+						IntentID: other.IntentID,
+					})).
+					Times(1).
+					Return(db.ProcessWebhookEventTxResult{Event: processedWebhookEvent(otherPending, util.WebhookEventIgnored, 0)}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, provider *payment.FakeProvider) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got webhookEventResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, util.WebhookEventIgnored, got.Status)
+				require.Nil(t, got.OrderID)
+			},
+		},
+		{
+			name:      "UnknownIntent",
+			payload:   succeededPayload,
+			signature: sign,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RecordWebhookEvent(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(pending, nil)
+				store.EXPECT().
+					ProcessWebhookEventTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ProcessWebhookEventTxResult{}, sql.ErrNoRows)
+				store.EXPECT().
+					MarkWebhookEventFailed(gomock.Any(), gomock.Eq(db.MarkWebhookEventFailedParams{
+						ID:        succeeded.ID,
+						LastError: sql.ErrNoRows.Error(),
+					})).
+					Times(1).
+					Return(failedWebhookEvent(pending, sql.ErrNoRows), nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, provider *payment.FakeProvider) {
+				// Redelivering won't help, the event waits for a replay
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireWebhookEventResponse(t, recorder.Body, failedWebhookEvent(pending, sql.ErrNoRows))
+			},
+		},
+		{
+			name:      "InvalidTransition",
+			payload:   succeededPayload,
+			signature: sign,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RecordWebhookEvent(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(pending, nil)
+				store.EXPECT().
+					ProcessWebhookEventTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ProcessWebhookEventTxResult{}, db.ErrInvalidOrderTransition)
+				store.EXPECT().
+					MarkWebhookEventFailed(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(failedWebhookEvent(pending, db.ErrInvalidOrderTransition), nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, provider *payment.FakeProvider) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:          "LicenseSold",
+			payload:       soldOutPayload,
+			signature:     sign,
+			setupPayments: capture,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RecordWebhookEvent(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(soldOutPending, nil)
+				store.EXPECT().
+					ProcessWebhookEventTx(gomock.Any(), gomock.Eq(db.ProcessWebhookEventTxParams{
+						EventID:     soldOut.ID,
+						IntentID:    soldOut.IntentID,
+						OrderStatus: util.OrderPaid,
+					})).
+					Times(1).
+					Return(db.ProcessWebhookEventTxResult{}, db.ErrLicenseSold)
+				store.EXPECT().
+					ProcessWebhookEventTx(gomock.Any(), gomock.Eq(db.ProcessWebhookEventTxParams{
+						EventID:       soldOut.ID,
+						IntentID:      soldOut.IntentID,
+						OrderStatus:   util.OrderFailed,
+						FailureReason: db.ErrLicenseSold.Error(),
+					})).
+					Times(1).
+					Return(db.ProcessWebhookEventTxResult{Event: soldOutProcessed, Order: soldOutOrder}, nil)
+				store.EXPECT().
+					MarkWebhookEventFailed(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, provider *payment.FakeProvider) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireWebhookEventResponse(t, recorder.Body, soldOutProcessed)
+
+				// The buyer gets the money back
+				intent, ok := provider.Intent(soldOut.IntentID)
+				require.True(t, ok)
+				require.Equal(t, payment.IntentRefunded, intent.Status)
+			},
+		},
+		{
+			name:      "ProcessError",
+			payload:   succeededPayload,
+			signature: sign,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RecordWebhookEvent(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(pending, nil)
+				store.EXPECT().
+					ProcessWebhookEventTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ProcessWebhookEventTxResult{}, sql.ErrConnDone)
+				store.EXPECT().
+					MarkWebhookEventFailed(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(failedWebhookEvent(pending, sql.ErrConnDone), nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, provider *payment.FakeProvider) {
+				// The provider delivers the event again
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name:      "RecordError",
+			payload:   succeededPayload,
+			signature: sign,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RecordWebhookEvent(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.WebhookEvent{}, sql.ErrConnDone)
+				store.EXPECT().
+					ProcessWebhookEventTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, provider *payment.FakeProvider) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name:    "InvalidSignature",
+			payload: succeededPayload,
+			signature: func(t *testing.T, verifier *payment.WebhookVerifier, payload []byte) string {
+				other, err := payment.NewWebhookVerifier(util.RandomString(32), time.Minute)
+				require.NoError(t, err)
+				return other.Sign(payload, time.Now())
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RecordWebhookEvent(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, provider *payment.FakeProvider) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:    "ExpiredSignature",
+			payload: succeededPayload,
+			signature: func(t *testing.T, verifier *payment.WebhookVerifier, payload []byte) string {
+				return verifier.Sign(payload, time.Now().Add(-time.Hour))
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RecordWebhookEvent(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, provider *payment.FakeProvider) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:    "NoSignature",
+			payload: succeededPayload,
+			signature: func(t *testing.T, verifier *payment.WebhookVerifier, payload []byte) string {
+				return ""
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RecordWebhookEvent(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, provider *payment.FakeProvider) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:      "InvalidEvent",
+			payload:   []byte(`{"id":"evt_1","type":"payment_intent.succeeded"}`),
+			signature: sign,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RecordWebhookEvent(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, provider *payment.FakeProvider) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "TooLarge",
+			payload:   bytes.Repeat([]byte(" "), maxWebhookPayloadSize+1),
+			signature: sign,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RecordWebhookEvent(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, provider *payment.FakeProvider) {
+				require.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			// Init controller and store
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			// Build stub
+			tc.buildStubs(store)
+			// Start test server, build request, and send
+			server := newTestServer(t, store)
+			provider := server.payments.(*payment.FakeProvider)
+			if tc.setupPayments != nil {
+				tc.setupPayments(t, provider)
+			}
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodPost, "/webhooks/payments", bytes.NewReader(tc.payload))
+			require.NoError(t, err)
+			if signature := tc.signature(t, server.webhookVerifier, tc.payload); signature != "" {
+				request.Header.Set(payment.SignatureHeader, signature)
+			}
+			// Server http response
+			server.router.ServeHTTP(recorder, request)
+			// check response
+			tc.checkResponse(t, recorder, provider)
+		})
+	}
+}
+
+func TestListWebhookEvents(t *testing.T) {
+	admin, _ := randomUser(t)
+	admin.Role = util.AdminRole
+
+	events := make([]db.WebhookEvent, 3)
+	for i := range events {
+		event, payload := randomPaymentEvent(payment.EventIntentSucceeded)
+		events[i] = failedWebhookEvent(randomWebhookEvent(event, payload, util.WebhookEventPending), sql.ErrNoRows)
+	}
+
+	testCases := []struct {
+		name          string
+		query         string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			query: "page_id=1&page_size=5",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin.ID, admin.Username, admin.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListWebhookEvents(gomock.Any(), gomock.Eq(db.ListWebhookEventsParams{
+						Status: util.WebhookEventFailed,
+						Limit:  5,
+						Offset: 0,
+					})).
+					Times(1).
+					Return(events, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got []webhookEventResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Len(t, got, len(events))
+				for i, event := range events {
+					require.Equal(t, newWebhookEventResponse(event), got[i])
+				}
+			},
+		},
+		{
+			name:  "Status",
+			query: "status=pending&page_id=2&page_size=5",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin.ID, admin.Username, admin.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListWebhookEvents(gomock.Any(), gomock.Eq(db.ListWebhookEventsParams{
+						Status: util.WebhookEventPending,
+						Limit:  5,
+						Offset: 5,
+					})).
+					Times(1).
+					Return([]db.WebhookEvent{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:  "InvalidStatus",
+			query: "status=unknown&page_id=1&page_size=5",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin.ID, admin.Username, admin.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListWebhookEvents(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "NotAdmin",
+			query: "page_id=1&page_size=5",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin.ID, admin.Username, util.ProducerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListWebhookEvents(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:  "InternalError",
+			query: "page_id=1&page_size=5",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin.ID, admin.Username, admin.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListWebhookEvents(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			// Init controller and store
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			// Build stub
+			tc.buildStubs(store)
+			// Start test server, build request, and send
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, "/webhooks/payments/events?"+tc.query, nil)
+			require.NoError(t, err)
+			tc.setupAuth(t, request, server.tokenMaker)
+			// Server http response
+			server.router.ServeHTTP(recorder, request)
+			// check response
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestReplayWebhookEvent(t *testing.T) {
+	admin, _ := randomUser(t)
+	admin.Role = util.AdminRole
+	order, _ := randomOrder(admin.ID+1, util.OrderPaid)
+
+	event, payload := randomPaymentEvent(payment.EventIntentSucceeded)
+	failed := failedWebhookEvent(randomWebhookEvent(event, payload, util.WebhookEventPending), sql.ErrNoRows)
+	processed := processedWebhookEvent(failed, util.WebhookEventProcessed, order.ID)
+	processed.LastError = ""
+
+	testCases := []struct {
+		name          string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin.ID, admin.Username, admin.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetWebhookEvent(gomock.Any(), gomock.Eq(event.ID)).
+					Times(1).
+					Return(failed, nil)
+				store.EXPECT().
+					ProcessWebhookEventTx(gomock.Any(), gomock.Eq(db.ProcessWebhookEventTxParams{
+						EventID:     event.ID,
+						IntentID:    event.IntentID,
+						OrderStatus: util.OrderPaid,
+					})).
+					Times(1).
+					Return(db.ProcessWebhookEventTxResult{Event: processed, Order: order}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireWebhookEventResponse(t, recorder.Body, processed)
+			},
+		},
+		{
+			name: "StillFailing",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin.ID, admin.Username, admin.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetWebhookEvent(gomock.Any(), gomock.Eq(event.ID)).
+					Times(1).
+					Return(failed, nil)
+				store.EXPECT().
+					ProcessWebhookEventTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ProcessWebhookEventTxResult{}, sql.ErrNoRows)
+				store.EXPECT().
+					MarkWebhookEventFailed(gomock.Any(), gomock.Eq(db.MarkWebhookEventFailedParams{
+						ID:        event.ID,
+						LastError: sql.ErrNoRows.Error(),
+					})).
+					Times(1).
+					Return(failedWebhookEvent(failed, sql.ErrNoRows), nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "AlreadyProcessed",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin.ID, admin.Username, admin.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetWebhookEvent(gomock.Any(), gomock.Eq(event.ID)).
+					Times(1).
+					Return(processed, nil)
+				store.EXPECT().
+					ProcessWebhookEventTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "NotFound",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin.ID, admin.Username, admin.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetWebhookEvent(gomock.Any(), gomock.Eq(event.ID)).
+					Times(1).
+					Return(db.WebhookEvent{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "ProcessError",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin.ID, admin.Username, admin.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetWebhookEvent(gomock.Any(), gomock.Eq(event.ID)).
+					Times(1).
+					Return(failed, nil)
+				store.EXPECT().
+					ProcessWebhookEventTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ProcessWebhookEventTxResult{}, sql.ErrConnDone)
+				store.EXPECT().
+					MarkWebhookEventFailed(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.WebhookEvent{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name: "NotAdmin",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, admin.ID, admin.Username, util.ListenerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetWebhookEvent(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "NoAuthorization",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetWebhookEvent(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			// Init controller and store
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			// Build stub
+			tc.buildStubs(store)
+			// Start test server, build request, and send
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+			url := fmt.Sprintf("/webhooks/payments/events/%s/replay", event.ID)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+			tc.setupAuth(t, request, server.tokenMaker)
+			// Server http response
+			server.router.ServeHTTP(recorder, request)
+			// check response
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
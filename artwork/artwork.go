@@ -0,0 +1,124 @@
+// Package artwork turns the cover images producers upload into thumbnails.
+package artwork
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"io"
+
+	// Registers the PNG decoder with image.Decode, JPEG is registered by image/jpeg
+	_ "image/png"
+)
+
+// Sizes are the edge lengths of the square thumbnails generated for every artwork, in pixels
+var Sizes = [...]int{150, 300, 600}
+
+// MinDimension is the smallest width and height accepted, thumbnails are never scaled up
+const MinDimension = 600
+
+// MaxPixels bounds the decoded size of an image, a small file can claim huge dimensions
+const MaxPixels = 40_000_000
+
+// ContentType is the content type of the encoded thumbnails
+const ContentType = "image/jpeg"
+
+// jpegQuality is the quality thumbnails are encoded with
+const jpegQuality = 85
+
+// ErrUnsupportedFormat is returned for images which are neither JPEG nor PNG
+var ErrUnsupportedFormat = errors.New("unsupported image format, expected JPEG or PNG")
+
+// ErrInvalidDimensions is wrapped by the errors for images which are too small or too large
+var ErrInvalidDimensions = errors.New("invalid image dimensions")
+
+// Decode decodes a JPEG or PNG image. The dimensions are checked before the pixels are decoded.
+func Decode(data []byte) (image.Image, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || (format != "jpeg" && format != "png") {
+		return nil, ErrUnsupportedFormat
+	}
+
+	if config.Width < MinDimension || config.Height < MinDimension {
+		return nil, fmt.Errorf("%w: image is %dx%d, at least %dx%d is required",
+			ErrInvalidDimensions, config.Width, config.Height, MinDimension, MinDimension)
+	}
+	if config.Width*config.Height > MaxPixels {
+		return nil, fmt.Errorf("%w: image has more than %d pixels", ErrInvalidDimensions, MaxPixels)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+// Thumbnails returns a square thumbnail of img for every entry of Sizes.
+// The image is cropped to its centered square, transparent parts are put on white.
+func Thumbnails(img image.Image) []*image.RGBA {
+	bounds := img.Bounds()
+	side := bounds.Dx()
+	if bounds.Dy() < side {
+		side = bounds.Dy()
+	}
+	crop := image.Rect(0, 0, side, side).Add(image.Point{
+		X: bounds.Min.X + (bounds.Dx()-side)/2,
+		Y: bounds.Min.Y + (bounds.Dy()-side)/2,
+	})
+
+	// Converting once lets every size read the pixels directly
+	square := image.NewRGBA(image.Rect(0, 0, side, side))
+	draw.Draw(square, square.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(square, square.Bounds(), img, crop.Min, draw.Over)
+
+	thumbnails := make([]*image.RGBA, 0, len(Sizes))
+	for _, size := range Sizes {
+		thumbnails = append(thumbnails, shrink(square, size))
+	}
+	return thumbnails
+}
+
+// Encode writes thumbnail as JPEG. Nothing but the pixels is written,
+// the metadata of the uploaded image like EXIF location data is left behind.
+func Encode(w io.Writer, thumbnail image.Image) error {
+	return jpeg.Encode(w, thumbnail, &jpeg.Options{Quality: jpegQuality})
+}
+
+// shrink scales the square src down to size by averaging the source pixels covered by each target pixel
+func shrink(src *image.RGBA, size int) *image.RGBA {
+	side := src.Bounds().Dx()
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	if size >= side {
+		draw.Draw(dst, dst.Bounds(), src, image.Point{}, draw.Src)
+		return dst
+	}
+
+	for y := 0; y < size; y++ {
+		y0, y1 := y*side/size, (y+1)*side/size
+		for x := 0; x < size; x++ {
+			x0, x1 := x*side/size, (x+1)*side/size
+
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride+x0*4 : sy*src.Stride+x1*4]
+				for i := 0; i < len(row); i += 4 {
+					sum[0] += int(row[i])
+					sum[1] += int(row[i+1])
+					sum[2] += int(row[i+2])
+					sum[3] += int(row[i+3])
+				}
+			}
+
+			n := (y1 - y0) * (x1 - x0)
+			offset := dst.PixOffset(x, y)
+			for c := 0; c < 4; c++ {
+				dst.Pix[offset+c] = uint8((sum[c] + n/2) / n)
+			}
+		}
+	}
+	return dst
+}
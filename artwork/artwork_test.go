@@ -0,0 +1,174 @@
+package artwork
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// split returns a w by h image, red on the left half and blue on the right half
+func split(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if x < w/2 {
+				img.Set(x, y, color.NRGBA{R: 255, A: 255})
+			} else {
+				img.Set(x, y, color.NRGBA{B: 255, A: 255})
+			}
+		}
+	}
+	return img
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func encodeJPEG(t *testing.T, img image.Image) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, img, nil))
+	return buf.Bytes()
+}
+
+// withExif inserts an APP1 segment carrying EXIF data right after the start of image marker
+func withExif(data []byte) []byte {
+	payload := []byte("Exif\x00\x00GPS 52.5200 N 13.4050 E")
+	segment := []byte{0xFF, 0xE1, byte((len(payload) + 2) >> 8), byte(len(payload) + 2)}
+	segment = append(segment, payload...)
+
+	out := append([]byte{}, data[:2]...)
+	out = append(out, segment...)
+	return append(out, data[2:]...)
+}
+
+func TestDecode(t *testing.T) {
+	var gifData bytes.Buffer
+	require.NoError(t, gif.Encode(&gifData, split(600, 600), nil))
+
+	testCases := []struct {
+		name    string
+		data    []byte
+		wantErr error
+	}{
+		{
+			name: "PNG",
+			data: encodePNG(t, split(800, 600)),
+		},
+		{
+			name: "JPEG",
+			data: encodeJPEG(t, split(600, 900)),
+		},
+		{
+			name: "JPEGWithExif",
+			data: withExif(encodeJPEG(t, split(600, 600))),
+		},
+		{
+			name:    "GIF",
+			data:    gifData.Bytes(),
+			wantErr: ErrUnsupportedFormat,
+		},
+		{
+			name:    "NotAnImage",
+			data:    []byte("RIFF\x24\x08\x00\x00WAVEfmt "),
+			wantErr: ErrUnsupportedFormat,
+		},
+		{
+			name:    "TooSmall",
+			data:    encodePNG(t, split(1000, 599)),
+			wantErr: ErrInvalidDimensions,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			img, err := Decode(tc.data)
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.NotNil(t, img)
+		})
+	}
+}
+
+func TestDecodeTooManyPixels(t *testing.T) {
+	// A tiny PNG whose header claims 10000x10000 pixels, it is rejected before any pixel is decoded
+	data := encodePNG(t, image.NewGray(image.Rect(0, 0, 1, 1)))
+	ihdr := data[12:29]
+	binary.BigEndian.PutUint32(ihdr[4:8], 10000)
+	binary.BigEndian.PutUint32(ihdr[8:12], 10000)
+	binary.BigEndian.PutUint32(data[29:33], crc32.ChecksumIEEE(ihdr))
+
+	_, err := Decode(data)
+	require.ErrorIs(t, err, ErrInvalidDimensions)
+}
+
+func TestThumbnails(t *testing.T) {
+	// The centered square of a wide image is half red, half blue
+	img := split(1200, 600)
+	// Outside of the crop
+	img.Set(0, 0, color.NRGBA{G: 255, A: 255})
+
+	thumbnails := Thumbnails(img)
+	require.Len(t, thumbnails, len(Sizes))
+
+	for i, thumbnail := range thumbnails {
+		size := Sizes[i]
+		require.Equal(t, image.Rect(0, 0, size, size), thumbnail.Bounds())
+
+		require.Equal(t, color.RGBA{R: 255, A: 255}, thumbnail.RGBAAt(0, 0))
+		require.Equal(t, color.RGBA{R: 255, A: 255}, thumbnail.RGBAAt(size/2-1, size-1))
+		require.Equal(t, color.RGBA{B: 255, A: 255}, thumbnail.RGBAAt(size/2, 0))
+		require.Equal(t, color.RGBA{B: 255, A: 255}, thumbnail.RGBAAt(size-1, size-1))
+	}
+}
+
+func TestThumbnailsTransparency(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 600, 600))
+	for y := 0; y < 600; y++ {
+		for x := 0; x < 600; x++ {
+			// The top half is transparent, the bottom half half-transparent black
+			if y >= 300 {
+				img.Set(x, y, color.NRGBA{A: 128})
+			}
+		}
+	}
+
+	thumbnail := Thumbnails(img)[0]
+	require.Equal(t, color.RGBA{R: 255, G: 255, B: 255, A: 255}, thumbnail.RGBAAt(10, 10))
+	got := thumbnail.RGBAAt(10, 140)
+	require.InDelta(t, 127, int(got.R), 1)
+	require.Equal(t, uint8(255), got.A)
+}
+
+func TestEncode(t *testing.T) {
+	img, err := Decode(withExif(encodeJPEG(t, split(600, 600))))
+	require.NoError(t, err)
+
+	for _, thumbnail := range Thumbnails(img) {
+		var buf bytes.Buffer
+		require.NoError(t, Encode(&buf, thumbnail))
+
+		// The metadata of the upload is gone
+		require.NotContains(t, buf.String(), "Exif")
+		require.NotContains(t, buf.String(), "GPS")
+
+		decoded, err := jpeg.Decode(&buf)
+		require.NoError(t, err)
+		require.Equal(t, thumbnail.Bounds(), decoded.Bounds())
+	}
+}
@@ -0,0 +1,268 @@
+package audio
+
+import (
+	"math"
+	"strings"
+)
+
+// Audio is analysed as mono at a low sample rate, which keeps everything up to about 5 kHz
+const analysisSampleRate = 11025
+
+// The onset envelope is built from short frames with a small hop for a fine time resolution
+const (
+	onsetFrameSize = 512
+	onsetHopSize   = 128
+	// onsetMinRise is the smallest rise of a bin in log magnitude counted as an onset,
+	// smaller ones are the wobble of notes held across frames
+	onsetMinRise = 0.5
+)
+
+// Chroma is built from long frames for a frequency resolution fine enough to tell semitones apart
+const (
+	chromaFrameSize = 4096
+	chromaHopSize   = 2048
+	chromaMinFreq   = 55
+	chromaMaxFreq   = 2000
+)
+
+// Tempo range searched by DetectTempo, in beats per minute
+const (
+	MinTempo = 60
+	MaxTempo = 200
+)
+
+// tempoHarmonics is the number of multiples of the beat period DetectTempo scores,
+// which favours the actual tempo over half of it
+const tempoHarmonics = 4
+
+var pitchClasses = [12]string{"C", "C#", "D", "D#", "E", "F", "F#", "G", "G#", "A", "A#", "B"}
+
+// Krumhansl-Kessler key profiles, starting at the tonic
+var (
+	majorProfile = [12]float64{6.35, 2.23, 3.48, 2.33, 4.38, 4.09, 2.52, 5.19, 2.39, 3.66, 2.29, 2.88}
+	minorProfile = [12]float64{6.33, 2.68, 3.52, 5.38, 2.60, 3.53, 2.54, 4.75, 3.98, 2.69, 3.34, 3.17}
+)
+
+// DetectTempo estimates the tempo of buf in beats per minute from the autocorrelation of its onsets.
+// The confidence is between 0 and 1, audio without a steady pulse gets a confidence close to 0.
+func DetectTempo(buf *Buffer) (bpm float64, confidence float64) {
+	env := onsetEnvelope(analysisSamples(buf))
+	rate := float64(analysisSampleRate) / onsetHopSize
+
+	maxLag := int(math.Ceil(tempoHarmonics*60*rate/MinTempo)) + 1
+	if maxLag >= len(env) {
+		maxLag = len(env) - 1
+	}
+	if maxLag <= int(60*rate/MaxTempo) {
+		return 0, 0
+	}
+
+	mean := 0.0
+	for _, v := range env {
+		mean += v
+	}
+	mean /= float64(len(env))
+	for i := range env {
+		env[i] -= mean
+	}
+
+	ac := make([]float64, maxLag+1)
+	for lag := range ac {
+		for i := lag; i < len(env); i++ {
+			ac[lag] += env[i] * env[i-lag]
+		}
+	}
+	if ac[0] <= 0 {
+		return 0, 0
+	}
+	for lag := range ac {
+		ac[lag] /= ac[0]
+	}
+
+	bestScore := math.Inf(-1)
+	for tenths := MinTempo * 10; tenths <= MaxTempo*10; tenths++ {
+		candidate := float64(tenths) / 10
+		lag := 60 * rate / candidate
+		score := 0.0
+		for k := 1; k <= tempoHarmonics; k++ {
+			score += interpolate(ac, float64(k)*lag)
+		}
+		if score > bestScore {
+			bestScore = score
+			bpm = candidate
+		}
+	}
+
+	confidence = math.Max(0, math.Min(1, interpolate(ac, 60*rate/bpm)))
+	return bpm, confidence
+}
+
+// DetectKey picks the key out of candidates whose profile correlates best with the pitch classes of buf.
+// Candidates are named like util.MusicalKeys, e.g. "F# minor", names which cannot be parsed are skipped.
+// The confidence is the correlation clamped to between 0 and 1, an empty key is returned for silence.
+func DetectKey(buf *Buffer, candidates []string) (key string, confidence float64) {
+	chroma := chromagram(analysisSamples(buf))
+
+	best := math.Inf(-1)
+	for _, candidate := range candidates {
+		tonic, minor, ok := ParseKey(candidate)
+		if !ok {
+			continue
+		}
+		profile := majorProfile
+		if minor {
+			profile = minorProfile
+		}
+		var rotated [12]float64
+		for i := range rotated {
+			rotated[(i+tonic)%12] = profile[i]
+		}
+
+		corr, ok := correlation(chroma[:], rotated[:])
+		if ok && corr > best {
+			best = corr
+			key = candidate
+		}
+	}
+
+	if key == "" {
+		return "", 0
+	}
+	return key, math.Max(0, math.Min(1, best))
+}
+
+// ParseKey splits a key name like "C# minor" into the pitch class of its tonic, with C being 0, and its mode
+func ParseKey(name string) (tonic int, minor bool, ok bool) {
+	fields := strings.Fields(name)
+	if len(fields) != 2 {
+		return 0, false, false
+	}
+	switch strings.ToLower(fields[1]) {
+	case "major":
+	case "minor":
+		minor = true
+	default:
+		return 0, false, false
+	}
+	for i, pc := range pitchClasses {
+		if strings.EqualFold(fields[0], pc) {
+			return i, minor, true
+		}
+	}
+	return 0, false, false
+}
+
+// RelativeKeys reports whether a and b share their notes, like C major and A minor
+func RelativeKeys(a, b string) bool {
+	tonicA, minorA, okA := ParseKey(a)
+	tonicB, minorB, okB := ParseKey(b)
+	if !okA || !okB || minorA == minorB {
+		return false
+	}
+	if minorA {
+		tonicA, tonicB = tonicB, tonicA
+	}
+	// The relative minor starts a minor third below the major tonic
+	return (tonicA+9)%12 == tonicB
+}
+
+// analysisSamples returns buf as mono samples at analysisSampleRate
+func analysisSamples(buf *Buffer) []float32 {
+	if buf.Frames() == 0 {
+		return nil
+	}
+	return buf.WithChannels(1).Resample(analysisSampleRate).Samples
+}
+
+// onsetEnvelope returns the spectral flux per hop, which rises whenever new sound starts
+func onsetEnvelope(samples []float32) []float64 {
+	if len(samples) < onsetFrameSize {
+		return nil
+	}
+	spec := newSpectrum(onsetFrameSize)
+	prev := make([]float64, onsetFrameSize/2+1)
+	env := make([]float64, 0, (len(samples)-onsetFrameSize)/onsetHopSize+1)
+
+	for start := 0; start+onsetFrameSize <= len(samples); start += onsetHopSize {
+		flux := 0.0
+		for k, m := range spec.compute(samples[start : start+onsetFrameSize]) {
+			// Log compression keeps loud bass notes from drowning out the hi-hats
+			v := math.Log1p(100 * m)
+			if d := v - prev[k]; d > onsetMinRise {
+				flux += d
+			}
+			prev[k] = v
+		}
+		env = append(env, flux)
+	}
+	// The first frame is compared against silence
+	if len(env) > 0 {
+		env[0] = 0
+	}
+	return env
+}
+
+// chromagram sums the spectral energy of samples per pitch class
+func chromagram(samples []float32) [12]float64 {
+	var chroma [12]float64
+	if len(samples) < chromaFrameSize {
+		return chroma
+	}
+
+	// Map every bin in range to its nearest pitch class once, -1 marks bins out of range
+	bins := make([]int, chromaFrameSize/2+1)
+	for k := range bins {
+		freq := float64(k) * analysisSampleRate / chromaFrameSize
+		if freq < chromaMinFreq || freq > chromaMaxFreq {
+			bins[k] = -1
+			continue
+		}
+		// MIDI note 69 is A4 at 440 Hz
+		note := int(math.Round(69 + 12*math.Log2(freq/440)))
+		bins[k] = note % 12
+	}
+
+	spec := newSpectrum(chromaFrameSize)
+	for start := 0; start+chromaFrameSize <= len(samples); start += chromaHopSize {
+		for k, m := range spec.compute(samples[start : start+chromaFrameSize]) {
+			if bins[k] >= 0 {
+				chroma[bins[k]] += m * m
+			}
+		}
+	}
+	return chroma
+}
+
+// correlation returns the Pearson correlation of a and b, ok is false if either is constant
+func correlation(a, b []float64) (float64, bool) {
+	var meanA, meanB float64
+	for i := range a {
+		meanA += a[i]
+		meanB += b[i]
+	}
+	meanA /= float64(len(a))
+	meanB /= float64(len(b))
+
+	var cov, varA, varB float64
+	for i := range a {
+		da, db := a[i]-meanA, b[i]-meanB
+		cov += da * db
+		varA += da * da
+		varB += db * db
+	}
+	if varA == 0 || varB == 0 {
+		return 0, false
+	}
+	return cov / math.Sqrt(varA*varB), true
+}
+
+// interpolate returns values at the fractional index pos, linearly interpolated.
+// Positions past the end count as zero.
+func interpolate(values []float64, pos float64) float64 {
+	i := int(pos)
+	if i >= len(values)-1 {
+		return 0
+	}
+	frac := pos - float64(i)
+	return values[i] + (values[i+1]-values[i])*frac
+}
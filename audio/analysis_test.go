@@ -0,0 +1,189 @@
+package audio
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// clickTrack returns d of short decaying noise bursts at bpm, like a bare hi-hat pattern
+func clickTrack(bpm float64, d time.Duration) *Buffer {
+	const sampleRate = 44100
+	buf := &Buffer{SampleRate: sampleRate, Channels: 2}
+	frames := buf.framesIn(d)
+	buf.Samples = make([]float32, frames*2)
+
+	period := 60 / bpm * sampleRate
+	burst := sampleRate / 50
+	for beat := 0.0; int(beat) < frames; beat += period {
+		start := int(beat)
+		for i := 0; i < burst && start+i < frames; i++ {
+			s := float32((rand.Float64()*2 - 1) * math.Exp(-float64(i)/float64(burst)*5) * 0.8)
+			buf.Samples[(start+i)*2] = s
+			buf.Samples[(start+i)*2+1] = s
+		}
+	}
+	return buf
+}
+
+// chords returns the chords played one after the other for a second each, every note given in Hz
+func chords(chords ...[]float64) *Buffer {
+	const sampleRate = 44100
+	buf := &Buffer{SampleRate: sampleRate, Channels: 1}
+	for _, chord := range chords {
+		mixed := make([]float32, sampleRate)
+		for _, freq := range chord {
+			tone := Tone(sampleRate, 1, freq, time.Second, 0.2)
+			for i := range mixed {
+				mixed[i] += tone.Samples[i]
+			}
+		}
+		buf.Samples = append(buf.Samples, mixed...)
+	}
+	return buf
+}
+
+func TestDetectTempo(t *testing.T) {
+	for _, bpm := range []float64{72, 90, 128, 140, 174} {
+		detected, confidence := DetectTempo(clickTrack(bpm, 20*time.Second))
+		require.InDelta(t, bpm, detected, 1, "tempo %v", bpm)
+		require.Greater(t, confidence, 0.5, "tempo %v", bpm)
+	}
+
+	// A held bass note under the pulse must not throw it off
+	buf := clickTrack(93, 20*time.Second)
+	bass := Tone(buf.SampleRate, buf.Channels, 55, 20*time.Second, 0.5)
+	for i := range buf.Samples {
+		buf.Samples[i] += bass.Samples[i]
+	}
+	detected, confidence := DetectTempo(buf)
+	require.InDelta(t, 93, detected, 1)
+	require.Greater(t, confidence, 0.5)
+}
+
+func TestDetectTempoWithoutPulse(t *testing.T) {
+	silence := &Buffer{SampleRate: 44100, Channels: 1, Samples: make([]float32, 44100*10)}
+	_, confidence := DetectTempo(silence)
+	require.Zero(t, confidence)
+
+	tone := Tone(44100, 1, 440, 10*time.Second, 0.5)
+	_, confidence = DetectTempo(tone)
+	require.Less(t, confidence, 0.2)
+
+	short := Tone(44100, 1, 440, time.Second, 0.5)
+	bpm, confidence := DetectTempo(short)
+	require.Zero(t, bpm)
+	require.Zero(t, confidence)
+}
+
+// Frequencies of the notes around the fourth octave
+const (
+	c4  = 261.63
+	d4  = 293.66
+	e4  = 329.63
+	f4  = 349.23
+	g4  = 392.00
+	gs4 = 415.30
+	a4  = 440.00
+	b4  = 493.88
+	a3  = 220.00
+	e3  = 164.81
+)
+
+func TestDetectKey(t *testing.T) {
+	keys := []string{
+		"C major", "C minor", "C# major", "C# minor", "D major", "D minor", "D# major", "D# minor",
+		"E major", "E minor", "F major", "F minor", "F# major", "F# minor", "G major", "G minor",
+		"G# major", "G# minor", "A major", "A minor", "A# major", "A# minor", "B major", "B minor",
+	}
+
+	testCases := []struct {
+		name string
+		buf  *Buffer
+		key  string
+	}{
+		{
+			name: "CMajor",
+			// I IV V I
+			buf: chords(
+				[]float64{c4, e4, g4}, []float64{f4, a4, c4 * 2},
+				[]float64{g4, b4, d4 * 2}, []float64{c4, e4, g4},
+			),
+			key: "C major",
+		},
+		{
+			name: "AMinor",
+			// i iv V i
+			buf: chords(
+				[]float64{a3, c4, e4}, []float64{d4, f4, a4},
+				[]float64{e3, gs4, b4}, []float64{a3, c4, e4},
+			),
+			key: "A minor",
+		},
+		{
+			name: "Transposed",
+			// The C major progression a whole tone up
+			buf: chords(
+				[]float64{d4, d4 * 1.25992, a4}, []float64{g4, b4, d4 * 2},
+				[]float64{a4, a4 * 1.25992, e4 * 2}, []float64{d4, d4 * 1.25992, a4},
+			),
+			key: "D major",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			key, confidence := DetectKey(tc.buf, keys)
+			require.Equal(t, tc.key, key)
+			require.Greater(t, confidence, 0.5)
+		})
+	}
+}
+
+func TestDetectKeyWithoutCandidates(t *testing.T) {
+	buf := chords([]float64{c4, e4, g4})
+
+	key, confidence := DetectKey(buf, []string{"H major", "C"})
+	require.Empty(t, key)
+	require.Zero(t, confidence)
+
+	silence := &Buffer{SampleRate: 44100, Channels: 1, Samples: make([]float32, 44100)}
+	key, confidence = DetectKey(silence, []string{"C major"})
+	require.Empty(t, key)
+	require.Zero(t, confidence)
+}
+
+func TestParseKey(t *testing.T) {
+	testCases := []struct {
+		name  string
+		tonic int
+		minor bool
+		ok    bool
+	}{
+		{name: "C major", tonic: 0, ok: true},
+		{name: "F# minor", tonic: 6, minor: true, ok: true},
+		{name: "b Minor", tonic: 11, minor: true, ok: true},
+		{name: "H major"},
+		{name: "C"},
+		{name: "C dorian"},
+	}
+
+	for _, tc := range testCases {
+		tonic, minor, ok := ParseKey(tc.name)
+		require.Equal(t, tc.ok, ok, tc.name)
+		require.Equal(t, tc.tonic, tonic, tc.name)
+		require.Equal(t, tc.minor, minor, tc.name)
+	}
+}
+
+func TestRelativeKeys(t *testing.T) {
+	require.True(t, RelativeKeys("C major", "A minor"))
+	require.True(t, RelativeKeys("A minor", "C major"))
+	require.True(t, RelativeKeys("D# major", "C minor"))
+	require.False(t, RelativeKeys("C major", "C minor"))
+	require.False(t, RelativeKeys("C major", "G major"))
+	require.False(t, RelativeKeys("C major", "nonsense"))
+}
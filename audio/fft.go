@@ -0,0 +1,69 @@
+package audio
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// fft transforms x in place, its length has to be a power of two
+func fft(x []complex128) {
+	n := len(x)
+
+	// Bit reversal permutation
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Rect(1, -2*math.Pi/float64(size))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				a := x[start+k]
+				b := w * x[start+k+size/2]
+				x[start+k] = a + b
+				x[start+k+size/2] = a - b
+				w *= step
+			}
+		}
+	}
+}
+
+// spectrum computes the magnitudes of the frequencies in frame, which is weighted by a Hann window.
+// Bin k of the result is the frequency k * sampleRate / len(frame).
+type spectrum struct {
+	window []float64
+	buf    []complex128
+	mag    []float64
+}
+
+func newSpectrum(size int) *spectrum {
+	window := make([]float64, size)
+	for i := range window {
+		window[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(size))
+	}
+	return &spectrum{
+		window: window,
+		buf:    make([]complex128, size),
+		mag:    make([]float64, size/2+1),
+	}
+}
+
+// compute returns the magnitudes of frame, the slice is reused by the next call
+func (s *spectrum) compute(frame []float32) []float64 {
+	for i := range s.buf {
+		s.buf[i] = complex(float64(frame[i])*s.window[i], 0)
+	}
+	fft(s.buf)
+	for k := range s.mag {
+		s.mag[k] = cmplx.Abs(s.buf[k])
+	}
+	return s.mag
+}
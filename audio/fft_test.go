@@ -0,0 +1,43 @@
+package audio
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFFT(t *testing.T) {
+	x := []complex128{1, 2, 3, 4, 0, -1, -2, 5}
+
+	// Compare against the definition of the discrete Fourier transform
+	want := make([]complex128, len(x))
+	for k := range want {
+		for n, v := range x {
+			want[k] += v * cmplx.Rect(1, -2*math.Pi*float64(k*n)/float64(len(x)))
+		}
+	}
+
+	fft(x)
+	for k := range want {
+		require.InDelta(t, real(want[k]), real(x[k]), 1e-9)
+		require.InDelta(t, imag(want[k]), imag(x[k]), 1e-9)
+	}
+}
+
+func TestSpectrum(t *testing.T) {
+	// 1000 Hz falls exactly on bin 128
+	tone := Tone(8000, 1, 1000, time.Second, 0.5)
+	mag := newSpectrum(1024).compute(tone.Samples[:1024])
+	require.Len(t, mag, 513)
+
+	peak := 0
+	for k := range mag {
+		if mag[k] > mag[peak] {
+			peak = k
+		}
+	}
+	require.Equal(t, 128, peak)
+}
@@ -0,0 +1,184 @@
+package audio
+
+import (
+	"encoding/binary"
+	"math"
+	"math/bits"
+	"sort"
+)
+
+// Fingerprints are built from long frames with a small hop, so a shift of the audio
+// by less than a hop barely changes them
+const (
+	fingerprintFrameSize = 4096
+	fingerprintHopSize   = 512
+	// fingerprintBands is the number of frequency bands compared, neighbouring bands give one bit each
+	fingerprintBands   = 33
+	fingerprintMinFreq = 300
+	fingerprintMaxFreq = 2000
+	// fingerprintSilence is the mean square of a frame below which it is silent, -60 dBFS
+	fingerprintSilence = 1e-6
+)
+
+// MaxBitErrorRate is the share of differing bits up to which two fingerprints are the same audio,
+// unrelated audio differs in about half of the bits
+const MaxBitErrorRate = 0.3
+
+const (
+	// matchMinFrames is the number of frames fingerprints have to overlap by to match, about 10 seconds
+	matchMinFrames = 215
+	// matchOffsets is the number of most promising alignments compared bit by bit
+	matchOffsets = 5
+)
+
+// Fingerprint condenses audio into one 32 bit sub-fingerprint per hop. Each bit tells whether the difference
+// in energy between two neighbouring frequency bands grew or shrank since the previous frame,
+// which survives lossy encoding, resampling, changes in volume and mild equalizing.
+// Silent frames are 0 and never match.
+type Fingerprint []uint32
+
+// NewFingerprint computes the fingerprint of buf
+func NewFingerprint(buf *Buffer) Fingerprint {
+	samples := analysisSamples(buf)
+	if len(samples) < fingerprintFrameSize {
+		return Fingerprint{}
+	}
+
+	// Map every bin in range to its band once, -1 marks bins out of range
+	bins := make([]int, fingerprintFrameSize/2+1)
+	for k := range bins {
+		bins[k] = -1
+		freq := float64(k) * analysisSampleRate / fingerprintFrameSize
+		if freq < fingerprintMinFreq || freq >= fingerprintMaxFreq {
+			continue
+		}
+		// Bands are spaced logarithmically, like pitch is heard
+		band := int(fingerprintBands * math.Log(freq/fingerprintMinFreq) / math.Log(fingerprintMaxFreq/fingerprintMinFreq))
+		if band < fingerprintBands {
+			bins[k] = band
+		}
+	}
+
+	spec := newSpectrum(fingerprintFrameSize)
+	var energy, prev [fingerprintBands]float64
+	fingerprint := make(Fingerprint, 0, (len(samples)-fingerprintFrameSize)/fingerprintHopSize+1)
+
+	for start := 0; start+fingerprintFrameSize <= len(samples); start += fingerprintHopSize {
+		frame := samples[start : start+fingerprintFrameSize]
+		power := 0.0
+		for _, s := range frame {
+			power += float64(s) * float64(s)
+		}
+
+		energy = [fingerprintBands]float64{}
+		for k, m := range spec.compute(frame) {
+			if bins[k] >= 0 {
+				energy[bins[k]] += m * m
+			}
+		}
+
+		var sub uint32
+		// The first frame has nothing to be compared with
+		if power/fingerprintFrameSize >= fingerprintSilence && start > 0 {
+			for m := 0; m < fingerprintBands-1; m++ {
+				if energy[m]-energy[m+1]-(prev[m]-prev[m+1]) > 0 {
+					sub |= 1 << m
+				}
+			}
+		}
+		fingerprint = append(fingerprint, sub)
+		prev = energy
+	}
+	return fingerprint
+}
+
+// Hashes returns the distinct sub-fingerprints of fp except for silence, in ascending order.
+// Audio matching fp shares at least a few of them exactly, which makes them a good index.
+func (fp Fingerprint) Hashes() []int32 {
+	seen := make(map[uint32]bool, len(fp))
+	hashes := make([]int32, 0, len(fp))
+	for _, sub := range fp {
+		if sub == 0 || seen[sub] {
+			continue
+		}
+		seen[sub] = true
+		hashes = append(hashes, int32(sub))
+	}
+	sort.Slice(hashes, func(i, j int) bool { return hashes[i] < hashes[j] })
+	return hashes
+}
+
+// EncodeFingerprint stores fp as big endian sub-fingerprints
+func EncodeFingerprint(fp Fingerprint) []byte {
+	data := make([]byte, 4*len(fp))
+	for i, sub := range fp {
+		binary.BigEndian.PutUint32(data[4*i:], sub)
+	}
+	return data
+}
+
+// DecodeFingerprint reverses EncodeFingerprint
+func DecodeFingerprint(data []byte) Fingerprint {
+	fp := make(Fingerprint, len(data)/4)
+	for i := range fp {
+		fp[i] = binary.BigEndian.Uint32(data[4*i:])
+	}
+	return fp
+}
+
+// MatchFingerprints aligns a and b at the offsets where they share the most sub-fingerprints
+// and returns the lowest bit error rate of their overlap, 1 if they don't overlap far enough.
+// match is true if the audio of a is found in b or the other way around,
+// e.g. a re-upload of b with a few seconds cut off.
+func MatchFingerprints(a, b Fingerprint) (bitErrorRate float64, match bool) {
+	positions := make(map[uint32][]int)
+	for j, sub := range b {
+		if sub != 0 {
+			positions[sub] = append(positions[sub], j)
+		}
+	}
+
+	votes := make(map[int]int)
+	for i, sub := range a {
+		if sub == 0 {
+			continue
+		}
+		for _, j := range positions[sub] {
+			votes[j-i]++
+		}
+	}
+
+	offsets := make([]int, 0, len(votes))
+	for offset := range votes {
+		offsets = append(offsets, offset)
+	}
+	sort.Slice(offsets, func(i, j int) bool {
+		if votes[offsets[i]] != votes[offsets[j]] {
+			return votes[offsets[i]] > votes[offsets[j]]
+		}
+		return offsets[i] < offsets[j]
+	})
+	if len(offsets) > matchOffsets {
+		offsets = offsets[:matchOffsets]
+	}
+
+	bitErrorRate = 1
+	for _, offset := range offsets {
+		errors, frames := 0, 0
+		for i, sub := range a {
+			j := i + offset
+			if j < 0 || j >= len(b) || sub == 0 || b[j] == 0 {
+				continue
+			}
+			errors += bits.OnesCount32(sub ^ b[j])
+			frames++
+		}
+		if frames < matchMinFrames {
+			continue
+		}
+		if rate := float64(errors) / float64(32*frames); rate < bitErrorRate {
+			bitErrorRate = rate
+		}
+	}
+	return bitErrorRate, bitErrorRate <= MaxBitErrorRate
+}
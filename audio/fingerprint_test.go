@@ -0,0 +1,112 @@
+package audio
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// melody returns d of random notes, eight per second, over soft noise, the same for the same seed
+func melody(seed int64, d time.Duration) *Buffer {
+	const sampleRate = 44100
+	rng := rand.New(rand.NewSource(seed))
+	buf := &Buffer{SampleRate: sampleRate, Channels: 2}
+	frames := buf.framesIn(d)
+	buf.Samples = make([]float32, frames*2)
+
+	noteFrames := sampleRate / 8
+	var freqs [2]float64
+	for i := 0; i < frames; i++ {
+		if i%noteFrames == 0 {
+			for n := range freqs {
+				// Somewhere between 200 Hz and 2.5 kHz
+				freqs[n] = 200 * math.Pow(2, rng.Float64()*3.6)
+			}
+		}
+		t := float64(i) / sampleRate
+		envelope := math.Exp(-float64(i%noteFrames) / float64(noteFrames) * 3)
+		s := 0.3*envelope*(math.Sin(2*math.Pi*freqs[0]*t)+math.Sin(2*math.Pi*freqs[1]*t)) + 0.02*(rng.Float64()*2-1)
+		buf.Samples[2*i] = float32(s)
+		buf.Samples[2*i+1] = float32(s)
+	}
+	return buf
+}
+
+// degrade returns buf resampled to sampleRate at gain with noise added, like a re-encoded copy
+func degrade(buf *Buffer, sampleRate int, gain float32) *Buffer {
+	rng := rand.New(rand.NewSource(99))
+	out := buf.Resample(sampleRate)
+	samples := make([]float32, len(out.Samples))
+	for i, s := range out.Samples {
+		samples[i] = s*gain + float32(0.01*(rng.Float64()*2-1))
+	}
+	return &Buffer{SampleRate: out.SampleRate, Channels: out.Channels, Samples: samples}
+}
+
+// cut returns buf without its first d
+func cut(buf *Buffer, d time.Duration) *Buffer {
+	start := buf.framesIn(d) * buf.Channels
+	return &Buffer{SampleRate: buf.SampleRate, Channels: buf.Channels, Samples: buf.Samples[start:]}
+}
+
+func TestMatchFingerprints(t *testing.T) {
+	original := melody(1, 40*time.Second)
+	fp := NewFingerprint(original)
+
+	testCases := []struct {
+		name  string
+		other *Buffer
+		match bool
+	}{
+		{name: "Identical", other: original, match: true},
+		{name: "ReEncoded", other: degrade(original, 48000, 0.6), match: true},
+		{name: "IntroCut", other: cut(degrade(original, 44100, 1.2), 3325*time.Millisecond), match: true},
+		{name: "DifferentAudio", other: melody(2, 40*time.Second)},
+		{name: "TooShort", other: melody(1, 5*time.Second)},
+		{name: "Silence", other: Tone(44100, 2, 0, 40*time.Second, 0)},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			other := NewFingerprint(tc.other)
+			ber, match := MatchFingerprints(fp, other)
+			require.Equal(t, tc.match, match, "bit error rate %.3f", ber)
+
+			// Matching is symmetric
+			_, reverseMatch := MatchFingerprints(other, fp)
+			require.Equal(t, match, reverseMatch)
+		})
+	}
+}
+
+func TestFingerprintHashes(t *testing.T) {
+	fp := NewFingerprint(melody(1, 10*time.Second))
+	require.InDelta(t, 10*analysisSampleRate/fingerprintHopSize, len(fp), 10)
+
+	hashes := fp.Hashes()
+	require.NotEmpty(t, hashes)
+	for i := 1; i < len(hashes); i++ {
+		require.Less(t, hashes[i-1], hashes[i])
+	}
+	for _, hash := range hashes {
+		require.NotZero(t, hash)
+	}
+
+	silence := NewFingerprint(Tone(44100, 1, 0, 10*time.Second, 0))
+	require.NotEmpty(t, silence)
+	require.Empty(t, silence.Hashes())
+}
+
+func TestEncodeFingerprint(t *testing.T) {
+	fp := Fingerprint{0, 1, 0xdeadbeef, math.MaxUint32}
+	data := EncodeFingerprint(fp)
+	require.Len(t, data, 16)
+	require.Equal(t, fp, DecodeFingerprint(data))
+
+	require.Empty(t, DecodeFingerprint(nil))
+}
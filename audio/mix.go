@@ -0,0 +1,136 @@
+package audio
+
+import (
+	"math"
+	"time"
+)
+
+// Resample returns buf converted to sampleRate by linear interpolation.
+// That is good enough for mixing in a voice tag, it is not meant for mastering.
+func (buf *Buffer) Resample(sampleRate int) *Buffer {
+	if buf.SampleRate == sampleRate {
+		return buf
+	}
+
+	frames := buf.Frames()
+	outFrames := int(int64(frames) * int64(sampleRate) / int64(buf.SampleRate))
+	out := &Buffer{
+		SampleRate: sampleRate,
+		Channels:   buf.Channels,
+		Samples:    make([]float32, outFrames*buf.Channels),
+	}
+
+	step := float64(buf.SampleRate) / float64(sampleRate)
+	for i := 0; i < outFrames; i++ {
+		pos := float64(i) * step
+		j := int(pos)
+		frac := float32(pos - float64(j))
+		next := j + 1
+		if next >= frames {
+			next = frames - 1
+		}
+		for c := 0; c < buf.Channels; c++ {
+			a := buf.Samples[j*buf.Channels+c]
+			b := buf.Samples[next*buf.Channels+c]
+			out.Samples[i*buf.Channels+c] = a + (b-a)*frac
+		}
+	}
+	return out
+}
+
+// WithChannels returns buf converted to the given number of channels.
+// Mono is spread to every channel, everything else is mixed down to mono first.
+func (buf *Buffer) WithChannels(channels int) *Buffer {
+	if buf.Channels == channels {
+		return buf
+	}
+
+	frames := buf.Frames()
+	out := &Buffer{
+		SampleRate: buf.SampleRate,
+		Channels:   channels,
+		Samples:    make([]float32, frames*channels),
+	}
+	for i := 0; i < frames; i++ {
+		var sum float32
+		for c := 0; c < buf.Channels; c++ {
+			sum += buf.Samples[i*buf.Channels+c]
+		}
+		mono := sum / float32(buf.Channels)
+		for c := 0; c < channels; c++ {
+			out.Samples[i*channels+c] = mono
+		}
+	}
+	return out
+}
+
+// Trim cuts buf down to at most d
+func (buf *Buffer) Trim(d time.Duration) {
+	if frames := buf.framesIn(d); frames < buf.Frames() {
+		buf.Samples = buf.Samples[:frames*buf.Channels]
+	}
+}
+
+// FadeOut lowers the volume of the last d of buf linearly to silence,
+// so a trimmed preview doesn't end with a click
+func (buf *Buffer) FadeOut(d time.Duration) {
+	frames := buf.Frames()
+	fade := buf.framesIn(d)
+	if fade > frames {
+		fade = frames
+	}
+	if fade == 0 {
+		return
+	}
+
+	start := frames - fade
+	for i := start; i < frames; i++ {
+		gain := float32(frames-i-1) / float32(fade)
+		for c := 0; c < buf.Channels; c++ {
+			buf.Samples[i*buf.Channels+c] *= gain
+		}
+	}
+}
+
+// MixTag adds tag to buf at gain, first at offset first and then every interval until buf ends.
+// The tag is converted to the sample rate and channels of buf, the result is clipped to -1 to 1.
+func (buf *Buffer) MixTag(tag *Buffer, first, interval time.Duration, gain float32) {
+	if tag.Frames() == 0 || interval <= 0 {
+		return
+	}
+	tag = tag.Resample(buf.SampleRate).WithChannels(buf.Channels)
+
+	frames := buf.Frames()
+	step := buf.framesIn(interval)
+	if step < 1 {
+		step = 1
+	}
+	for start := buf.framesIn(first); start < frames; start += step {
+		end := start + tag.Frames()
+		if end > frames {
+			end = frames
+		}
+		dst := buf.Samples[start*buf.Channels : end*buf.Channels]
+		for i := range dst {
+			dst[i] = clip(dst[i] + tag.Samples[i]*gain)
+		}
+	}
+}
+
+func clip(s float32) float32 {
+	return float32(math.Max(-1, math.Min(1, float64(s))))
+}
+
+// Tone returns a sine wave of the given frequency, duration and amplitude
+func Tone(sampleRate, channels int, frequency float64, d time.Duration, amplitude float32) *Buffer {
+	buf := &Buffer{SampleRate: sampleRate, Channels: channels}
+	frames := buf.framesIn(d)
+	buf.Samples = make([]float32, frames*channels)
+	for i := 0; i < frames; i++ {
+		s := amplitude * float32(math.Sin(2*math.Pi*frequency*float64(i)/float64(sampleRate)))
+		for c := 0; c < channels; c++ {
+			buf.Samples[i*channels+c] = s
+		}
+	}
+	return buf
+}
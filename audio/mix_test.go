@@ -0,0 +1,70 @@
+package audio
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResample(t *testing.T) {
+	buf := &Buffer{SampleRate: 4, Channels: 1, Samples: []float32{0, 1, 0, -1}}
+
+	up := buf.Resample(8)
+	require.Equal(t, 8, up.SampleRate)
+	require.InDeltaSlice(t, []float32{0, 0.5, 1, 0.5, 0, -0.5, -1, -1}, up.Samples, 1e-6)
+	require.Equal(t, buf.Duration(), up.Duration())
+
+	down := buf.Resample(2)
+	require.InDeltaSlice(t, []float32{0, 0}, down.Samples, 1e-6)
+
+	require.Same(t, buf, buf.Resample(4))
+}
+
+func TestWithChannels(t *testing.T) {
+	mono := &Buffer{SampleRate: 8000, Channels: 1, Samples: []float32{0.5, -0.25}}
+	stereo := mono.WithChannels(2)
+	require.Equal(t, []float32{0.5, 0.5, -0.25, -0.25}, stereo.Samples)
+
+	stereo = &Buffer{SampleRate: 8000, Channels: 2, Samples: []float32{1, 0, 0.5, -0.5}}
+	require.Equal(t, []float32{0.5, 0}, stereo.WithChannels(1).Samples)
+
+	require.Same(t, stereo, stereo.WithChannels(2))
+}
+
+func TestTrimAndFadeOut(t *testing.T) {
+	buf := Tone(1000, 2, 100, 2*time.Second, 0.5)
+	buf.Trim(time.Second)
+	require.Equal(t, 1000, buf.Frames())
+
+	// Trimming to a longer duration leaves the buffer alone
+	buf.Trim(time.Minute)
+	require.Equal(t, 1000, buf.Frames())
+
+	buf = &Buffer{SampleRate: 4, Channels: 1, Samples: []float32{1, 1, 1, 1, 1, 1}}
+	buf.FadeOut(time.Second)
+	require.InDeltaSlice(t, []float32{1, 1, 0.75, 0.5, 0.25, 0}, buf.Samples, 1e-6)
+}
+
+func TestMixTag(t *testing.T) {
+	buf := &Buffer{SampleRate: 4, Channels: 2, Samples: make([]float32, 2*12)}
+	buf.Samples[0] = 0.9
+	// A mono tag at twice the sample rate, half a second long
+	tag := &Buffer{SampleRate: 8, Channels: 1, Samples: []float32{0.5, 0.5, 0.5, 0.5}}
+
+	buf.MixTag(tag, 0, time.Second, 0.5)
+
+	want := make([]float32, 2*12)
+	for _, frame := range []int{0, 1, 4, 5, 8, 9} {
+		want[frame*2] = 0.25
+		want[frame*2+1] = 0.25
+	}
+	// Clipped
+	want[0] = 1
+	require.InDeltaSlice(t, want, buf.Samples, 1e-6)
+
+	// A tag starting late and reaching past the end is cut short
+	buf = &Buffer{SampleRate: 4, Channels: 1, Samples: make([]float32, 5)}
+	buf.MixTag(&Buffer{SampleRate: 4, Channels: 1, Samples: []float32{1, 1, 1}}, time.Second, 10*time.Second, 1)
+	require.Equal(t, []float32{0, 0, 0, 0, 1}, buf.Samples)
+}
@@ -0,0 +1,108 @@
+package audio
+
+import (
+	"io"
+	"math"
+	"time"
+)
+
+// peakBlockFrames is the number of frames ReadPeaks condenses into one peak
+// before the peaks are reduced to the requested resolution
+const peakBlockFrames = 64
+
+// Peak is the lowest and the highest sample of a stretch of audio, over all channels
+type Peak struct {
+	Min float32
+	Max float32
+}
+
+func (p Peak) merge(other Peak) Peak {
+	if other.Min < p.Min {
+		p.Min = other.Min
+	}
+	if other.Max > p.Max {
+		p.Max = other.Max
+	}
+	return p
+}
+
+// ReadPeaks decodes the WAV file read from r and returns at most resolution peaks
+// covering the whole file, along with its duration. The file is never held in memory as a whole.
+func ReadPeaks(r io.Reader, resolution int) ([]Peak, time.Duration, error) {
+	wr, err := NewWAVReader(r)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var blocks []Peak
+	frames := 0
+	chunk := make([]float32, peakBlockFrames*wr.Channels)
+	for {
+		n, err := wr.Read(chunk)
+		if n > 0 {
+			block := Peak{Min: chunk[0], Max: chunk[0]}
+			for _, s := range chunk[1:n] {
+				block = block.merge(Peak{Min: s, Max: s})
+			}
+			blocks = append(blocks, block)
+			frames += n / wr.Channels
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	if frames == 0 {
+		return nil, 0, ErrInvalidWAV
+	}
+	duration := time.Duration(frames) * time.Second / time.Duration(wr.SampleRate)
+	return DownsamplePeaks(blocks, resolution), duration, nil
+}
+
+// DownsamplePeaks merges peaks into at most n peaks, each covering an equal share of the input
+func DownsamplePeaks(peaks []Peak, n int) []Peak {
+	if n <= 0 || len(peaks) <= n {
+		return peaks
+	}
+
+	out := make([]Peak, n)
+	for i := range out {
+		start := i * len(peaks) / n
+		end := (i + 1) * len(peaks) / n
+		peak := peaks[start]
+		for _, p := range peaks[start+1 : end] {
+			peak = peak.merge(p)
+		}
+		out[i] = peak
+	}
+	return out
+}
+
+// EncodePeaks stores peaks as interleaved minimum and maximum bytes,
+// 8 bits are plenty for drawing a waveform
+func EncodePeaks(peaks []Peak) []byte {
+	data := make([]byte, 0, 2*len(peaks))
+	for _, p := range peaks {
+		data = append(data, byte(quantize(p.Min)), byte(quantize(p.Max)))
+	}
+	return data
+}
+
+// DecodePeaks reverses EncodePeaks
+func DecodePeaks(data []byte) []Peak {
+	peaks := make([]Peak, len(data)/2)
+	for i := range peaks {
+		peaks[i] = Peak{
+			Min: float32(int8(data[2*i])) / 127,
+			Max: float32(int8(data[2*i+1])) / 127,
+		}
+	}
+	return peaks
+}
+
+func quantize(s float32) int8 {
+	return int8(math.Round(float64(clip(s)) * 127))
+}
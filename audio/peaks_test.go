@@ -0,0 +1,85 @@
+package audio
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadPeaks(t *testing.T) {
+	// One second each of a quiet and of a loud tone
+	buf := Tone(44100, 2, 440, time.Second, 0.25)
+	buf.Samples = append(buf.Samples, Tone(44100, 2, 440, time.Second, 0.75).Samples...)
+
+	var file bytes.Buffer
+	require.NoError(t, EncodeWAV(&file, buf))
+
+	peaks, duration, err := ReadPeaks(bytes.NewReader(file.Bytes()), 100)
+	require.NoError(t, err)
+	require.Equal(t, 2*time.Second, duration)
+	require.Len(t, peaks, 100)
+
+	for i, peak := range peaks {
+		amplitude := float32(0.25)
+		if i >= 50 {
+			amplitude = 0.75
+		}
+		require.InDelta(t, amplitude, peak.Max, 0.01, "peak %d", i)
+		require.InDelta(t, -amplitude, peak.Min, 0.01, "peak %d", i)
+	}
+
+	// Short files have fewer peaks than asked for
+	file.Reset()
+	require.NoError(t, EncodeWAV(&file, Tone(8000, 1, 440, 100*time.Millisecond, 0.5)))
+	peaks, duration, err = ReadPeaks(bytes.NewReader(file.Bytes()), 100)
+	require.NoError(t, err)
+	require.Equal(t, 100*time.Millisecond, duration)
+	require.Len(t, peaks, 13)
+
+	_, _, err = ReadPeaks(bytes.NewReader([]byte("ID3\x04\x00\x00\x00\x00\x00\x00")), 100)
+	require.ErrorIs(t, err, ErrInvalidWAV)
+}
+
+func TestDownsamplePeaks(t *testing.T) {
+	peaks := []Peak{
+		{Min: -0.1, Max: 0.1},
+		{Min: -0.5, Max: 0.2},
+		{Min: -0.2, Max: 0.6},
+		{Min: 0, Max: 0},
+		{Min: -0.3, Max: 0.3},
+	}
+
+	require.Equal(t, []Peak{
+		{Min: -0.5, Max: 0.2},
+		{Min: -0.3, Max: 0.6},
+	}, DownsamplePeaks(peaks, 2))
+
+	require.Equal(t, []Peak{{Min: -0.5, Max: 0.6}}, DownsamplePeaks(peaks, 1))
+
+	// Nothing to merge
+	require.Equal(t, peaks, DownsamplePeaks(peaks, 5))
+	require.Equal(t, peaks, DownsamplePeaks(peaks, 10))
+}
+
+func TestEncodePeaks(t *testing.T) {
+	peaks := []Peak{
+		{Min: -1, Max: 1},
+		{Min: -0.5, Max: 0.25},
+		{Min: 0, Max: 0},
+		// Out of range samples are clipped
+		{Min: -2, Max: 2},
+	}
+
+	data := EncodePeaks(peaks)
+	require.Len(t, data, 8)
+	require.Equal(t, []byte{0x81, 0x7F}, data[0:2])
+
+	decoded := DecodePeaks(data)
+	require.Len(t, decoded, len(peaks))
+	for i, want := range []Peak{{-1, 1}, {-0.5, 0.25}, {0, 0}, {-1, 1}} {
+		require.InDelta(t, want.Min, decoded[i].Min, 1.0/127)
+		require.InDelta(t, want.Max, decoded[i].Max, 1.0/127)
+	}
+}
@@ -0,0 +1,67 @@
+// Package audio inspects the audio files producers upload.
+package audio
+
+import (
+	"bytes"
+	"errors"
+)
+
+// Supported formats
+const (
+	FormatWAV = "wav"
+	FormatMP3 = "mp3"
+)
+
+// Content types of the supported formats
+const (
+	ContentTypeWAV = "audio/wav"
+	ContentTypeMP3 = "audio/mpeg"
+)
+
+// SniffLen is the number of leading bytes Sniff looks at
+const SniffLen = 512
+
+// ErrUnsupportedFormat is returned for files which are neither WAV nor MP3
+var ErrUnsupportedFormat = errors.New("unsupported audio format, expected WAV or MP3")
+
+// Sniff detects the format of an audio file from its leading bytes.
+// The content type sent by the client is never trusted.
+func Sniff(header []byte) (format string, contentType string, err error) {
+	switch {
+	case isWAV(header):
+		return FormatWAV, ContentTypeWAV, nil
+	case isMP3(header):
+		return FormatMP3, ContentTypeMP3, nil
+	default:
+		return "", "", ErrUnsupportedFormat
+	}
+}
+
+// isWAV checks for a RIFF container of type WAVE
+func isWAV(header []byte) bool {
+	return len(header) >= 12 &&
+		bytes.Equal(header[0:4], []byte("RIFF")) &&
+		bytes.Equal(header[8:12], []byte("WAVE"))
+}
+
+// isMP3 checks for an ID3v2 tag or an MPEG audio layer III frame header
+func isMP3(header []byte) bool {
+	if len(header) >= 3 && bytes.Equal(header[0:3], []byte("ID3")) {
+		return true
+	}
+	if len(header) < 4 {
+		return false
+	}
+
+	// 11 bit frame sync
+	if header[0] != 0xFF || header[1]&0xE0 != 0xE0 {
+		return false
+	}
+	version := (header[1] >> 3) & 0x03
+	layer := (header[1] >> 1) & 0x03
+	bitrate := header[2] >> 4
+	sampleRate := (header[2] >> 2) & 0x03
+
+	// Reject the reserved version, anything but layer III and invalid bitrate or sample rate indices
+	return version != 0x01 && layer == 0x01 && bitrate != 0x00 && bitrate != 0x0F && sampleRate != 0x03
+}
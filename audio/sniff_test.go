@@ -0,0 +1,73 @@
+package audio
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSniff(t *testing.T) {
+	testCases := []struct {
+		name        string
+		header      []byte
+		format      string
+		contentType string
+	}{
+		{
+			name:        "WAV",
+			header:      []byte("RIFF\x24\x08\x00\x00WAVEfmt "),
+			format:      FormatWAV,
+			contentType: ContentTypeWAV,
+		},
+		{
+			name:        "MP3WithID3",
+			header:      []byte("ID3\x04\x00\x00\x00\x00\x00\x00"),
+			format:      FormatMP3,
+			contentType: ContentTypeMP3,
+		},
+		{
+			// MPEG-1 layer III, 128 kbit/s, 44.1 kHz
+			name:        "MP3FrameSync",
+			header:      []byte{0xFF, 0xFB, 0x90, 0x64},
+			format:      FormatMP3,
+			contentType: ContentTypeMP3,
+		},
+		{
+			// RIFF container of another type
+			name:   "AVI",
+			header: []byte("RIFF\x24\x08\x00\x00AVI LIST"),
+		},
+		{
+			// MPEG-1 layer II
+			name:   "MP2",
+			header: []byte{0xFF, 0xFD, 0x90, 0x64},
+		},
+		{
+			name:   "InvalidBitrate",
+			header: []byte{0xFF, 0xFB, 0xF0, 0x64},
+		},
+		{
+			name:   "PNG",
+			header: []byte("\x89PNG\r\n\x1a\n"),
+		},
+		{
+			name:   "Empty",
+			header: nil,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			format, contentType, err := Sniff(tc.header)
+			if tc.format == "" {
+				require.ErrorIs(t, err, ErrUnsupportedFormat)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.format, format)
+			require.Equal(t, tc.contentType, contentType)
+		})
+	}
+}
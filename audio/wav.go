@@ -0,0 +1,338 @@
+package audio
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"time"
+)
+
+// WAV format tags of the sample encodings DecodeWAV understands
+const (
+	wavFormatPCM        = 0x0001
+	wavFormatFloat      = 0x0003
+	wavFormatExtensible = 0xFFFE
+)
+
+// Limits of the headers DecodeWAV accepts, anything outside of them is rather
+// a crafted file than a recording and would blow up the buffers sized by the header
+const (
+	maxWAVFormatSize = 1024
+	maxWAVChannels   = 8
+	minWAVSampleRate = 8000
+	maxWAVSampleRate = 384000
+)
+
+// ErrInvalidWAV is returned for files which are not a WAV file DecodeWAV can read
+var ErrInvalidWAV = errors.New("invalid or unsupported wav file")
+
+// Buffer holds decoded audio as interleaved samples between -1 and 1
+type Buffer struct {
+	SampleRate int
+	Channels   int
+	Samples    []float32
+}
+
+// Frames returns the number of samples per channel
+func (buf *Buffer) Frames() int {
+	if buf.Channels == 0 {
+		return 0
+	}
+	return len(buf.Samples) / buf.Channels
+}
+
+// Duration returns the playing time of the buffer
+func (buf *Buffer) Duration() time.Duration {
+	if buf.SampleRate == 0 {
+		return 0
+	}
+	return time.Duration(buf.Frames()) * time.Second / time.Duration(buf.SampleRate)
+}
+
+// framesIn returns the number of frames played in d
+func (buf *Buffer) framesIn(d time.Duration) int {
+	// Whole seconds and the remainder are scaled apart so long durations don't overflow
+	rate := time.Duration(buf.SampleRate)
+	return int(d/time.Second*rate + d%time.Second*rate/time.Second)
+}
+
+type wavFormat struct {
+	tag           uint16
+	channels      int
+	sampleRate    int
+	bitsPerSample int
+}
+
+// WAVReader decodes the samples of a WAV file as they are read
+type WAVReader struct {
+	SampleRate int
+	Channels   int
+	r          io.Reader
+	format     *wavFormat
+	// remaining is the number of frames left in the data chunk, -1 if its size is unknown
+	remaining int64
+	frame     []byte
+}
+
+// NewWAVReader reads the header of a WAV file up to the start of the samples.
+// Integer PCM with 8, 16, 24 or 32 bits per sample and 32 bit float samples are supported.
+func NewWAVReader(r io.Reader) (*WAVReader, error) {
+	br := bufio.NewReader(r)
+
+	var riff [12]byte
+	if _, err := io.ReadFull(br, riff[:]); err != nil {
+		return nil, ErrInvalidWAV
+	}
+	if string(riff[0:4]) != "RIFF" || string(riff[8:12]) != "WAVE" {
+		return nil, ErrInvalidWAV
+	}
+
+	var format *wavFormat
+	for {
+		var header [8]byte
+		if _, err := io.ReadFull(br, header[:]); err != nil {
+			// The file ended without a data chunk
+			return nil, ErrInvalidWAV
+		}
+		id := string(header[0:4])
+		size := int64(binary.LittleEndian.Uint32(header[4:8]))
+
+		switch id {
+		case "fmt ":
+			f, err := readWAVFormat(br, size)
+			if err != nil {
+				return nil, err
+			}
+			format = f
+		case "data":
+			if format == nil {
+				return nil, ErrInvalidWAV
+			}
+			frameSize := int64(format.bitsPerSample / 8 * format.channels)
+			remaining := size / frameSize
+			// Streaming writers leave the size at zero or at its maximum, read until the file ends
+			if size == 0 || size == math.MaxUint32 {
+				remaining = -1
+			}
+			return &WAVReader{
+				SampleRate: format.sampleRate,
+				Channels:   format.channels,
+				r:          br,
+				format:     format,
+				remaining:  remaining,
+				frame:      make([]byte, frameSize),
+			}, nil
+		default:
+			if err := skipWAVChunk(br, size); err != nil {
+				return nil, ErrInvalidWAV
+			}
+		}
+	}
+}
+
+// Read decodes whole frames into samples and returns the number of samples decoded,
+// io.EOF is returned once all frames have been read. A data chunk cut short,
+// as left behind by an interrupted recording, ends where the file ends.
+func (wr *WAVReader) Read(samples []float32) (int, error) {
+	n := 0
+	for n+wr.Channels <= len(samples) {
+		if wr.remaining == 0 {
+			break
+		}
+		if _, err := io.ReadFull(wr.r, wr.frame); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				wr.remaining = 0
+				break
+			}
+			return n, err
+		}
+		if wr.remaining > 0 {
+			wr.remaining--
+		}
+
+		bytesPerSample := wr.format.bitsPerSample / 8
+		for c := 0; c < wr.Channels; c++ {
+			samples[n] = decodeSample(wr.frame[c*bytesPerSample:(c+1)*bytesPerSample], wr.format)
+			n++
+		}
+	}
+
+	if n == 0 && wr.remaining == 0 {
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+// DecodeWAV reads a whole WAV file into memory, see NewWAVReader for the supported formats.
+// Decoding stops after maxDuration, a maxDuration of zero reads the whole file.
+func DecodeWAV(r io.Reader, maxDuration time.Duration) (*Buffer, error) {
+	wr, err := NewWAVReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := &Buffer{SampleRate: wr.SampleRate, Channels: wr.Channels}
+	frames := wr.remaining
+	if maxDuration > 0 {
+		if max := int64(buf.framesIn(maxDuration)); frames < 0 || max < frames {
+			frames = max
+		}
+	}
+	buf.Samples = make([]float32, 0, capacityHint(frames, wr.Channels))
+
+	chunk := make([]float32, 4096*wr.Channels)
+	for frames < 0 || int64(buf.Frames()) < frames {
+		if frames >= 0 {
+			if left := (frames - int64(buf.Frames())) * int64(wr.Channels); left < int64(len(chunk)) {
+				chunk = chunk[:left]
+			}
+		}
+		n, err := wr.Read(chunk)
+		buf.Samples = append(buf.Samples, chunk[:n]...)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(buf.Samples) == 0 {
+		return nil, fmt.Errorf("%w: no samples", ErrInvalidWAV)
+	}
+	return buf, nil
+}
+
+func readWAVFormat(r io.Reader, size int64) (*wavFormat, error) {
+	if size < 16 || size > maxWAVFormatSize {
+		return nil, ErrInvalidWAV
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, ErrInvalidWAV
+	}
+	// Chunks are padded to an even size
+	if size%2 == 1 {
+		if err := skipWAVChunk(r, 1); err != nil {
+			return nil, ErrInvalidWAV
+		}
+	}
+
+	format := &wavFormat{
+		tag:           binary.LittleEndian.Uint16(data[0:2]),
+		channels:      int(binary.LittleEndian.Uint16(data[2:4])),
+		sampleRate:    int(binary.LittleEndian.Uint32(data[4:8])),
+		bitsPerSample: int(binary.LittleEndian.Uint16(data[14:16])),
+	}
+	// WAVE_FORMAT_EXTENSIBLE keeps the actual format in the first two bytes of the sub format GUID
+	if format.tag == wavFormatExtensible {
+		if size < 40 {
+			return nil, ErrInvalidWAV
+		}
+		format.tag = binary.LittleEndian.Uint16(data[24:26])
+	}
+
+	if format.channels < 1 || format.channels > maxWAVChannels {
+		return nil, fmt.Errorf("%w: %d channels", ErrInvalidWAV, format.channels)
+	}
+	if format.sampleRate < minWAVSampleRate || format.sampleRate > maxWAVSampleRate {
+		return nil, fmt.Errorf("%w: sample rate %d", ErrInvalidWAV, format.sampleRate)
+	}
+	switch {
+	case format.tag == wavFormatPCM && (format.bitsPerSample == 8 || format.bitsPerSample == 16 ||
+		format.bitsPerSample == 24 || format.bitsPerSample == 32):
+	case format.tag == wavFormatFloat && format.bitsPerSample == 32:
+	default:
+		return nil, fmt.Errorf("%w: format %#x with %d bits per sample", ErrInvalidWAV, format.tag, format.bitsPerSample)
+	}
+	return format, nil
+}
+
+func skipWAVChunk(r io.Reader, size int64) error {
+	// Chunks are padded to an even size
+	_, err := io.CopyN(ioutil.Discard, r, size+size%2)
+	return err
+}
+
+// capacityHint keeps a bogus data chunk size from allocating huge buffers up front
+func capacityHint(frames int64, channels int) int {
+	const maxHint = 1 << 22
+	if frames < 0 || frames*int64(channels) > maxHint {
+		return maxHint
+	}
+	return int(frames) * channels
+}
+
+func decodeSample(b []byte, format *wavFormat) float32 {
+	if format.tag == wavFormatFloat {
+		return math.Float32frombits(binary.LittleEndian.Uint32(b))
+	}
+
+	switch format.bitsPerSample {
+	case 8:
+		// 8 bit samples are unsigned
+		return float32(int(b[0])-128) / 128
+	case 16:
+		return float32(int16(binary.LittleEndian.Uint16(b))) / (1 << 15)
+	case 24:
+		v := int32(b[0]) | int32(b[1])<<8 | int32(b[2])<<16
+		// Sign extend
+		v = v << 8 >> 8
+		return float32(v) / (1 << 23)
+	default:
+		return float32(float64(int32(binary.LittleEndian.Uint32(b))) / (1 << 31))
+	}
+}
+
+// EncodeWAV writes buf as a 16 bit PCM WAV file, samples outside of -1 to 1 are clipped
+func EncodeWAV(w io.Writer, buf *Buffer) error {
+	if buf.Channels < 1 || buf.SampleRate < 1 {
+		return fmt.Errorf("cannot encode %d channels at %d Hz", buf.Channels, buf.SampleRate)
+	}
+
+	const bitsPerSample = 16
+	dataSize := len(buf.Samples) * bitsPerSample / 8
+	blockAlign := buf.Channels * bitsPerSample / 8
+
+	bw := bufio.NewWriter(w)
+	header := make([]byte, 44)
+	copy(header[0:4], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:8], uint32(36+dataSize))
+	copy(header[8:12], "WAVE")
+	copy(header[12:16], "fmt ")
+	binary.LittleEndian.PutUint32(header[16:20], 16)
+	binary.LittleEndian.PutUint16(header[20:22], wavFormatPCM)
+	binary.LittleEndian.PutUint16(header[22:24], uint16(buf.Channels))
+	binary.LittleEndian.PutUint32(header[24:28], uint32(buf.SampleRate))
+	binary.LittleEndian.PutUint32(header[28:32], uint32(buf.SampleRate*blockAlign))
+	binary.LittleEndian.PutUint16(header[32:34], uint16(blockAlign))
+	binary.LittleEndian.PutUint16(header[34:36], bitsPerSample)
+	copy(header[36:40], "data")
+	binary.LittleEndian.PutUint32(header[40:44], uint32(dataSize))
+	if _, err := bw.Write(header); err != nil {
+		return err
+	}
+
+	var sample [2]byte
+	for _, s := range buf.Samples {
+		binary.LittleEndian.PutUint16(sample[:], uint16(encodeSample16(s)))
+		if _, err := bw.Write(sample[:]); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+func encodeSample16(s float32) int16 {
+	if s >= 1 {
+		return math.MaxInt16
+	}
+	if s <= -1 {
+		return math.MinInt16
+	}
+	return int16(math.Round(float64(s) * (1 << 15)))
+}
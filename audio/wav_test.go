@@ -0,0 +1,209 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// buildWAV assembles a WAV file with the given fmt chunk body and sample data,
+// an extra chunk in between checks that unknown chunks are skipped
+func buildWAV(fmtChunk []byte, data []byte, dataSize uint32) []byte {
+	var b bytes.Buffer
+	b.WriteString("RIFF")
+	binary.Write(&b, binary.LittleEndian, uint32(0))
+	b.WriteString("WAVE")
+	b.WriteString("fmt ")
+	binary.Write(&b, binary.LittleEndian, uint32(len(fmtChunk)))
+	b.Write(fmtChunk)
+	b.WriteString("LIST")
+	binary.Write(&b, binary.LittleEndian, uint32(3))
+	b.WriteString("abc\x00")
+	b.WriteString("data")
+	binary.Write(&b, binary.LittleEndian, dataSize)
+	b.Write(data)
+	return b.Bytes()
+}
+
+func fmtChunk(tag uint16, channels, sampleRate, bitsPerSample int) []byte {
+	var b bytes.Buffer
+	blockAlign := channels * bitsPerSample / 8
+	binary.Write(&b, binary.LittleEndian, tag)
+	binary.Write(&b, binary.LittleEndian, uint16(channels))
+	binary.Write(&b, binary.LittleEndian, uint32(sampleRate))
+	binary.Write(&b, binary.LittleEndian, uint32(sampleRate*blockAlign))
+	binary.Write(&b, binary.LittleEndian, uint16(blockAlign))
+	binary.Write(&b, binary.LittleEndian, uint16(bitsPerSample))
+	return b.Bytes()
+}
+
+func extensibleFmtChunk(subFormat uint16, channels, sampleRate, bitsPerSample int) []byte {
+	b := bytes.NewBuffer(fmtChunk(wavFormatExtensible, channels, sampleRate, bitsPerSample))
+	binary.Write(b, binary.LittleEndian, uint16(22))
+	binary.Write(b, binary.LittleEndian, uint16(bitsPerSample))
+	binary.Write(b, binary.LittleEndian, uint32(3))
+	binary.Write(b, binary.LittleEndian, subFormat)
+	b.Write([]byte("\x00\x00\x00\x00\x10\x00\x80\x00\x00\xAA\x00\x38\x9B\x71"))
+	return b.Bytes()
+}
+
+func TestDecodeWAV(t *testing.T) {
+	float := func(values ...float32) []byte {
+		var b bytes.Buffer
+		for _, v := range values {
+			binary.Write(&b, binary.LittleEndian, math.Float32bits(v))
+		}
+		return b.Bytes()
+	}
+
+	testCases := []struct {
+		name       string
+		file       []byte
+		channels   int
+		sampleRate int
+		samples    []float32
+	}{
+		{
+			name:       "PCM8",
+			file:       buildWAV(fmtChunk(wavFormatPCM, 1, 8000, 8), []byte{128, 255, 0, 64}, 4),
+			channels:   1,
+			sampleRate: 8000,
+			samples:    []float32{0, 127.0 / 128, -1, -0.5},
+		},
+		{
+			name:       "PCM16",
+			file:       buildWAV(fmtChunk(wavFormatPCM, 2, 44100, 16), []byte{0x00, 0x40, 0x00, 0xC0, 0xFF, 0x7F, 0x00, 0x80}, 8),
+			channels:   2,
+			sampleRate: 44100,
+			samples:    []float32{0.5, -0.5, 32767.0 / 32768, -1},
+		},
+		{
+			name:       "PCM24",
+			file:       buildWAV(fmtChunk(wavFormatPCM, 1, 48000, 24), []byte{0x00, 0x00, 0x40, 0x00, 0x00, 0xC0}, 6),
+			channels:   1,
+			sampleRate: 48000,
+			samples:    []float32{0.5, -0.5},
+		},
+		{
+			name:       "PCM32",
+			file:       buildWAV(fmtChunk(wavFormatPCM, 1, 48000, 32), []byte{0x00, 0x00, 0x00, 0x40, 0x00, 0x00, 0x00, 0xC0}, 8),
+			channels:   1,
+			sampleRate: 48000,
+			samples:    []float32{0.5, -0.5},
+		},
+		{
+			name:       "Float",
+			file:       buildWAV(fmtChunk(wavFormatFloat, 1, 96000, 32), float(0.25, -0.75), 8),
+			channels:   1,
+			sampleRate: 96000,
+			samples:    []float32{0.25, -0.75},
+		},
+		{
+			name:       "Extensible",
+			file:       buildWAV(extensibleFmtChunk(wavFormatPCM, 2, 44100, 16), []byte{0x00, 0x40, 0x00, 0xC0}, 4),
+			channels:   2,
+			sampleRate: 44100,
+			samples:    []float32{0.5, -0.5},
+		},
+		{
+			// Recording software which streams the file leaves the data size at its maximum
+			name:       "StreamedSize",
+			file:       buildWAV(fmtChunk(wavFormatPCM, 1, 8000, 16), []byte{0x00, 0x40, 0x00, 0xC0}, math.MaxUint32),
+			channels:   1,
+			sampleRate: 8000,
+			samples:    []float32{0.5, -0.5},
+		},
+		{
+			name:       "Truncated",
+			file:       buildWAV(fmtChunk(wavFormatPCM, 1, 8000, 16), []byte{0x00, 0x40, 0x00}, 100),
+			channels:   1,
+			sampleRate: 8000,
+			samples:    []float32{0.5},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			buf, err := DecodeWAV(bytes.NewReader(tc.file), 0)
+			require.NoError(t, err)
+			require.Equal(t, tc.channels, buf.Channels)
+			require.Equal(t, tc.sampleRate, buf.SampleRate)
+			require.InDeltaSlice(t, tc.samples, buf.Samples, 1e-6)
+		})
+	}
+}
+
+func TestDecodeWAVInvalid(t *testing.T) {
+	testCases := []struct {
+		name string
+		file []byte
+	}{
+		{name: "Empty", file: nil},
+		{name: "NotRIFF", file: []byte("ID3\x04\x00\x00\x00\x00\x00\x00\x00\x00")},
+		{name: "NoFormat", file: []byte("RIFF\x00\x00\x00\x00WAVEdata\x02\x00\x00\x00\x00\x40")},
+		{name: "NoData", file: []byte("RIFF\x00\x00\x00\x00WAVE")},
+		{name: "ADPCM", file: buildWAV(fmtChunk(0x0002, 1, 8000, 4), []byte{0x00, 0x00}, 2)},
+		{name: "Float64", file: buildWAV(fmtChunk(wavFormatFloat, 1, 8000, 64), make([]byte, 8), 8)},
+		{name: "NoChannels", file: buildWAV(fmtChunk(wavFormatPCM, 0, 8000, 16), make([]byte, 4), 4)},
+		{name: "TooManyChannels", file: buildWAV(fmtChunk(wavFormatPCM, 65535, 8000, 16), make([]byte, 4), 4)},
+		{name: "LowSampleRate", file: buildWAV(fmtChunk(wavFormatPCM, 1, 4000, 16), make([]byte, 4), 4)},
+		{name: "HugeSampleRate", file: buildWAV(fmtChunk(wavFormatPCM, 1, 0xFFFFFFFF, 16), make([]byte, 4), 0xFFFFFFFF)},
+		{name: "HugeFormat", file: buildWAV(append(fmtChunk(wavFormatPCM, 1, 8000, 16), make([]byte, 4096)...), make([]byte, 4), 4)},
+		{name: "NoSamples", file: buildWAV(fmtChunk(wavFormatPCM, 1, 8000, 16), nil, 0)},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := DecodeWAV(bytes.NewReader(tc.file), 0)
+			require.ErrorIs(t, err, ErrInvalidWAV)
+		})
+	}
+}
+
+func TestDecodeWAVMaxDuration(t *testing.T) {
+	tone := Tone(8000, 2, 440, 3*time.Second, 0.5)
+
+	var file bytes.Buffer
+	require.NoError(t, EncodeWAV(&file, tone))
+
+	buf, err := DecodeWAV(bytes.NewReader(file.Bytes()), time.Second)
+	require.NoError(t, err)
+	require.Equal(t, 8000, buf.Frames())
+	require.Equal(t, time.Second, buf.Duration())
+}
+
+func TestEncodeWAV(t *testing.T) {
+	buf := &Buffer{
+		SampleRate: 22050,
+		Channels:   2,
+		Samples:    []float32{0, 0.5, -0.5, 1, -1, 2, -2, 0.25},
+	}
+
+	var file bytes.Buffer
+	err := EncodeWAV(&file, buf)
+	require.NoError(t, err)
+	require.Equal(t, 44+2*len(buf.Samples), file.Len())
+
+	format, contentType, err := Sniff(file.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, FormatWAV, format)
+	require.Equal(t, ContentTypeWAV, contentType)
+
+	decoded, err := DecodeWAV(bytes.NewReader(file.Bytes()), 0)
+	require.NoError(t, err)
+	require.Equal(t, buf.SampleRate, decoded.SampleRate)
+	require.Equal(t, buf.Channels, decoded.Channels)
+	// Samples out of range are clipped
+	require.InDeltaSlice(t, []float32{0, 0.5, -0.5, 1, -1, 1, -1, 0.25}, decoded.Samples, 1.0/(1<<15))
+
+	err = EncodeWAV(&file, &Buffer{})
+	require.Error(t, err)
+}
@@ -0,0 +1,6 @@
+-- Hashed passwords cannot be reverted to plaintext, the column is only renamed.
+ALTER TABLE
+    "users" DROP COLUMN IF EXISTS "password_changed_at";
+
+ALTER TABLE
+    "users" RENAME COLUMN "hashed_password" TO "password";
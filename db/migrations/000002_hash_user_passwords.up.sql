@@ -0,0 +1,16 @@
+CREATE EXTENSION IF NOT EXISTS pgcrypto;
+
+ALTER TABLE
+    "users" RENAME COLUMN "password" TO "hashed_password";
+
+-- Existing rows hold plaintext passwords; replace them with bcrypt hashes
+-- that golang.org/x/crypto/bcrypt can verify.
+UPDATE
+    "users"
+SET
+    "hashed_password" = crypt("hashed_password", gen_salt('bf', 10));
+
+ALTER TABLE
+    "users"
+ADD
+    COLUMN "password_changed_at" timestamptz NOT NULL DEFAULT ('0001-01-01 00:00:00Z');
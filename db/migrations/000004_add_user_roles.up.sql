@@ -0,0 +1,24 @@
+ALTER TABLE
+    "users"
+ADD
+    COLUMN "role" VARCHAR NOT NULL DEFAULT 'listener';
+
+ALTER TABLE
+    "users"
+ADD
+    CONSTRAINT "users_role_check" CHECK ("role" IN ('listener', 'producer', 'admin'));
+
+-- Users who already uploaded beats become producers
+UPDATE
+    "users"
+SET
+    "role" = 'producer'
+WHERE
+    EXISTS (
+        SELECT
+            1
+        FROM
+            "beats"
+        WHERE
+            "beats"."creator_id" = "users"."id"
+    );
@@ -0,0 +1,20 @@
+CREATE TABLE "audit_logs" (
+    "id" BIGSERIAL PRIMARY KEY,
+    "actor_id" integer NOT NULL,
+    "action" VARCHAR NOT NULL,
+    "target_user_id" integer NOT NULL,
+    "details" VARCHAR NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE
+    "audit_logs"
+ADD
+    FOREIGN KEY ("actor_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+ALTER TABLE
+    "audit_logs"
+ADD
+    FOREIGN KEY ("target_user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+CREATE INDEX ON "audit_logs" ("target_user_id");
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS email_verifications;
+
+ALTER TABLE
+    "users" DROP COLUMN IF EXISTS "verified_at";
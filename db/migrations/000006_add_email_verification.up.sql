@@ -0,0 +1,27 @@
+ALTER TABLE
+    "users"
+ADD
+    COLUMN "verified_at" timestamptz;
+
+-- Accounts created before verification existed are trusted as verified
+UPDATE
+    "users"
+SET
+    "verified_at" = "created_at";
+
+CREATE TABLE "email_verifications" (
+    "id" BIGSERIAL PRIMARY KEY,
+    "user_id" integer NOT NULL,
+    "email" VARCHAR NOT NULL,
+    "hashed_token" VARCHAR UNIQUE NOT NULL,
+    "expires_at" timestamptz NOT NULL,
+    "used_at" timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE
+    "email_verifications"
+ADD
+    FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+CREATE INDEX ON "email_verifications" ("user_id");
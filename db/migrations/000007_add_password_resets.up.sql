@@ -0,0 +1,15 @@
+CREATE TABLE "password_resets" (
+    "id" BIGSERIAL PRIMARY KEY,
+    "user_id" integer NOT NULL,
+    "hashed_token" VARCHAR UNIQUE NOT NULL,
+    "expires_at" timestamptz NOT NULL,
+    "used_at" timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE
+    "password_resets"
+ADD
+    FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+CREATE INDEX ON "password_resets" ("user_id");
@@ -0,0 +1,18 @@
+CREATE TABLE "api_keys" (
+    "id" BIGSERIAL PRIMARY KEY,
+    "user_id" integer NOT NULL,
+    "name" VARCHAR NOT NULL,
+    "prefix" VARCHAR UNIQUE NOT NULL,
+    "hashed_key" VARCHAR NOT NULL,
+    "scopes" VARCHAR [] NOT NULL,
+    "expires_at" timestamptz,
+    "last_used_at" timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE
+    "api_keys"
+ADD
+    FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+CREATE INDEX ON "api_keys" ("user_id");
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS mfa_challenges;
+
+DROP TABLE IF EXISTS mfa_recovery_codes;
+
+ALTER TABLE
+    "users" DROP COLUMN IF EXISTS "totp_enabled_at",
+    DROP COLUMN IF EXISTS "totp_secret";
@@ -0,0 +1,38 @@
+ALTER TABLE
+    "users"
+ADD
+    COLUMN "totp_secret" VARCHAR,
+ADD
+    COLUMN "totp_enabled_at" timestamptz;
+
+CREATE TABLE "mfa_recovery_codes" (
+    "id" BIGSERIAL PRIMARY KEY,
+    "user_id" integer NOT NULL,
+    "hashed_code" VARCHAR NOT NULL,
+    "used_at" timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "mfa_challenges" (
+    "id" BIGSERIAL PRIMARY KEY,
+    "user_id" integer NOT NULL,
+    "hashed_token" VARCHAR UNIQUE NOT NULL,
+    "attempts" integer NOT NULL DEFAULT 0,
+    "expires_at" timestamptz NOT NULL,
+    "used_at" timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE
+    "mfa_recovery_codes"
+ADD
+    FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+ALTER TABLE
+    "mfa_challenges"
+ADD
+    FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+CREATE UNIQUE INDEX ON "mfa_recovery_codes" ("user_id", "hashed_code");
+
+CREATE INDEX ON "mfa_challenges" ("user_id");
@@ -0,0 +1,23 @@
+CREATE TABLE "login_throttles" (
+    "key" VARCHAR PRIMARY KEY,
+    "failures" integer NOT NULL,
+    "last_failure_at" timestamptz NOT NULL
+);
+
+CREATE TABLE "login_events" (
+    "id" BIGSERIAL PRIMARY KEY,
+    "username" VARCHAR NOT NULL,
+    "client_ip" VARCHAR NOT NULL,
+    "event" VARCHAR NOT NULL,
+    "actor_id" integer,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE
+    "login_events"
+ADD
+    FOREIGN KEY ("actor_id") REFERENCES "users" ("id") ON DELETE SET NULL;
+
+CREATE INDEX ON "login_events" ("username");
+
+CREATE INDEX ON "login_events" ("client_ip");
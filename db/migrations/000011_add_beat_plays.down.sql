@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS stream_sessions;
+
+ALTER TABLE
+    "beats" DROP COLUMN IF EXISTS "play_count";
@@ -0,0 +1,18 @@
+ALTER TABLE
+    "beats"
+ADD
+    COLUMN "play_count" bigint NOT NULL DEFAULT 0;
+
+CREATE TABLE "stream_sessions" (
+    "beat_id" integer NOT NULL,
+    "session_id" uuid NOT NULL,
+    "bytes_streamed" bigint NOT NULL,
+    "played_at" timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    PRIMARY KEY ("beat_id", "session_id")
+);
+
+ALTER TABLE
+    "stream_sessions"
+ADD
+    FOREIGN KEY ("beat_id") REFERENCES "beats" ("id") ON DELETE CASCADE;
@@ -0,0 +1,2 @@
+ALTER TABLE
+    "beats" DROP COLUMN IF EXISTS "preview_key";
@@ -0,0 +1,10 @@
+ALTER TABLE
+    "beats"
+ADD
+    COLUMN "preview_key" VARCHAR NOT NULL DEFAULT '';
+
+-- Audio uploaded before previews existed stays playable as it is
+UPDATE
+    "beats"
+SET
+    "preview_key" = "s3_key";
@@ -0,0 +1,13 @@
+CREATE TABLE "beat_waveforms" (
+    "beat_id" integer PRIMARY KEY,
+    "s3_key" VARCHAR NOT NULL,
+    "resolution" integer NOT NULL,
+    "duration_ms" bigint NOT NULL,
+    "peaks" bytea NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE
+    "beat_waveforms"
+ADD
+    FOREIGN KEY ("beat_id") REFERENCES "beats" ("id") ON DELETE CASCADE;
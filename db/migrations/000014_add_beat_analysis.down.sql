@@ -0,0 +1,5 @@
+ALTER TABLE
+    "beats" DROP COLUMN IF EXISTS "detected_bpm",
+    DROP COLUMN IF EXISTS "detected_bpm_confidence",
+    DROP COLUMN IF EXISTS "detected_key",
+    DROP COLUMN IF EXISTS "detected_key_confidence";
@@ -0,0 +1,11 @@
+-- Tempo and key estimated from the uploaded audio, zero and empty until the analysis has run
+ALTER TABLE
+    "beats"
+ADD
+    COLUMN "detected_bpm" real NOT NULL DEFAULT 0,
+ADD
+    COLUMN "detected_bpm_confidence" real NOT NULL DEFAULT 0,
+ADD
+    COLUMN "detected_key" VARCHAR NOT NULL DEFAULT '',
+ADD
+    COLUMN "detected_key_confidence" real NOT NULL DEFAULT 0;
@@ -0,0 +1,45 @@
+CREATE TABLE "beat_files" (
+    "beat_id" integer NOT NULL,
+    "file_type" VARCHAR NOT NULL,
+    "s3_key" VARCHAR NOT NULL,
+    "content_type" VARCHAR NOT NULL,
+    "size" bigint NOT NULL,
+    -- Hex encoded SHA-256 of the content
+    "checksum" VARCHAR NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    PRIMARY KEY ("beat_id", "file_type")
+);
+
+ALTER TABLE
+    "beat_files"
+ADD
+    FOREIGN KEY ("beat_id") REFERENCES "beats" ("id") ON DELETE CASCADE;
+
+-- Audio uploaded before files were tracked, its size and checksum are unknown
+INSERT INTO
+    "beat_files" ("beat_id", "file_type", "s3_key", "content_type", "size", "checksum")
+SELECT
+    "id",
+    CASE WHEN "s3_key" LIKE '%.mp3' THEN 'mp3' ELSE 'master_wav' END,
+    "s3_key",
+    CASE WHEN "s3_key" LIKE '%.mp3' THEN 'audio/mpeg' ELSE 'audio/wav' END,
+    0,
+    ''
+FROM
+    "beats"
+WHERE
+    "s3_key" <> '';
+
+INSERT INTO
+    "beat_files" ("beat_id", "file_type", "s3_key", "content_type", "size", "checksum")
+SELECT
+    "id",
+    'tagged_preview',
+    "preview_key",
+    'audio/wav',
+    0,
+    ''
+FROM
+    "beats"
+WHERE
+    "preview_key" <> '' AND "preview_key" <> "s3_key";
@@ -0,0 +1,28 @@
+CREATE TABLE "uploads" (
+    "id" uuid PRIMARY KEY,
+    "user_id" integer NOT NULL,
+    "beat_id" integer NOT NULL,
+    -- 'audio' for master WAV or MP3, otherwise the beat file type
+    "file_type" VARCHAR NOT NULL,
+    "filename" VARCHAR NOT NULL,
+    "size" bigint NOT NULL,
+    -- Bytes received so far, the offset of the next chunk
+    "received" bigint NOT NULL DEFAULT 0,
+    -- Hex encoded SHA-256 of the whole file, announced by the client
+    "checksum" VARCHAR NOT NULL,
+    "finalizing" boolean NOT NULL DEFAULT false,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE
+    "uploads"
+ADD
+    FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+ALTER TABLE
+    "uploads"
+ADD
+    FOREIGN KEY ("beat_id") REFERENCES "beats" ("id") ON DELETE CASCADE;
+
+CREATE INDEX ON "uploads" ("updated_at");
@@ -0,0 +1,5 @@
+-- Key prefix of the artwork thumbnails, "<artwork_key>/<size>.jpg", empty for beats without artwork
+ALTER TABLE
+    "beats"
+ADD
+    COLUMN "artwork_key" VARCHAR NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "duplicate_matches";
+
+DROP TABLE IF EXISTS "audio_fingerprints";
@@ -0,0 +1,71 @@
+-- One row per beat with audio, describing its current master
+CREATE TABLE "audio_fingerprints" (
+    "beat_id" integer PRIMARY KEY,
+    "s3_key" VARCHAR NOT NULL,
+    -- Hex encoded SHA-256 of the master
+    "content_hash" VARCHAR NOT NULL,
+    -- Big endian 32 bit sub-fingerprints, empty until computed and for masters which can't be decoded
+    "fingerprint" bytea NOT NULL DEFAULT '',
+    -- Distinct sub-fingerprints, looked up to find candidate matches
+    "hashes" integer [] NOT NULL DEFAULT '{}',
+    -- When the content was first uploaded to the beat, kept while the same content is uploaded again
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE
+    "audio_fingerprints"
+ADD
+    FOREIGN KEY ("beat_id") REFERENCES "beats" ("id") ON DELETE CASCADE;
+
+CREATE INDEX ON "audio_fingerprints" ("content_hash");
+
+CREATE INDEX ON "audio_fingerprints" USING GIN ("hashes");
+
+-- Audio uploaded before duplicates were detected, masters without a known checksum are left out
+INSERT INTO
+    "audio_fingerprints" ("beat_id", "s3_key", "content_hash", "created_at")
+SELECT
+    "beat_id",
+    "s3_key",
+    "checksum",
+    "created_at"
+FROM
+    "beat_files"
+WHERE
+    "file_type" IN ('master_wav', 'mp3') AND "checksum" <> '';
+
+-- The moderation queue, audio of a beat matching audio uploaded earlier to a beat of another creator
+CREATE TABLE "duplicate_matches" (
+    "id" BIGSERIAL PRIMARY KEY,
+    "beat_id" integer NOT NULL,
+    "matched_beat_id" integer NOT NULL,
+    -- 'content_hash' for identical files, 'fingerprint' for audio which sounds the same
+    "kind" VARCHAR NOT NULL,
+    -- Share of differing fingerprint bits, 0 for identical files
+    "bit_error_rate" real NOT NULL DEFAULT 0,
+    -- Whether the upload was refused, otherwise the audio stays attached until an admin decides
+    "rejected" boolean NOT NULL DEFAULT false,
+    -- 'pending', 'confirmed' or 'dismissed'
+    "status" VARCHAR NOT NULL DEFAULT 'pending',
+    "reviewed_by" integer,
+    "reviewed_at" timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    UNIQUE ("beat_id", "matched_beat_id")
+);
+
+ALTER TABLE
+    "duplicate_matches"
+ADD
+    FOREIGN KEY ("beat_id") REFERENCES "beats" ("id") ON DELETE CASCADE;
+
+ALTER TABLE
+    "duplicate_matches"
+ADD
+    FOREIGN KEY ("matched_beat_id") REFERENCES "beats" ("id") ON DELETE CASCADE;
+
+ALTER TABLE
+    "duplicate_matches"
+ADD
+    FOREIGN KEY ("reviewed_by") REFERENCES "users" ("id") ON DELETE SET NULL;
+
+CREATE INDEX ON "duplicate_matches" ("status", "id");
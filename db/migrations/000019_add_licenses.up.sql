@@ -0,0 +1,25 @@
+-- The license tiers a beat is offered in, at most one per tier
+CREATE TABLE "licenses" (
+    "id" BIGSERIAL PRIMARY KEY,
+    "beat_id" integer NOT NULL,
+    -- 'basic', 'premium', 'unlimited' or 'exclusive'
+    "tier" VARCHAR NOT NULL,
+    -- In the minor unit of the currency, e.g. cents
+    "price" bigint NOT NULL CHECK ("price" >= 0),
+    -- ISO 4217 currency code
+    "currency" VARCHAR(3) NOT NULL,
+    -- Types of the beat files the buyer receives
+    "file_types" VARCHAR [] NOT NULL,
+    -- Copies the buyer may distribute and streams they may collect, NULL for no limit
+    "distribution_cap" bigint CHECK ("distribution_cap" > 0),
+    "stream_cap" bigint CHECK ("stream_cap" > 0),
+    "terms" text NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    "updated_at" timestamptz NOT NULL DEFAULT (now()),
+    UNIQUE ("beat_id", "tier")
+);
+
+ALTER TABLE
+    "licenses"
+ADD
+    FOREIGN KEY ("beat_id") REFERENCES "beats" ("id") ON DELETE CASCADE;
@@ -0,0 +1,39 @@
+-- A cart belongs to a user, or to an anonymous client holding its ID in a cookie until they log in
+CREATE TABLE "carts" (
+    "id" uuid PRIMARY KEY,
+    "user_id" integer UNIQUE,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+-- A beat is in a cart at most once, under the license the buyer chose
+CREATE TABLE "cart_items" (
+    "id" BIGSERIAL PRIMARY KEY,
+    "cart_id" uuid NOT NULL,
+    "beat_id" integer NOT NULL,
+    "license_id" bigint NOT NULL,
+    -- Price and currency of the license when the item was added
+    "price" bigint NOT NULL,
+    "currency" VARCHAR(3) NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    UNIQUE ("cart_id", "beat_id")
+);
+
+ALTER TABLE
+    "carts"
+ADD
+    FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+ALTER TABLE
+    "cart_items"
+ADD
+    FOREIGN KEY ("cart_id") REFERENCES "carts" ("id") ON DELETE CASCADE;
+
+ALTER TABLE
+    "cart_items"
+ADD
+    FOREIGN KEY ("beat_id") REFERENCES "beats" ("id") ON DELETE CASCADE;
+
+ALTER TABLE
+    "cart_items"
+ADD
+    FOREIGN KEY ("license_id") REFERENCES "licenses" ("id") ON DELETE CASCADE;
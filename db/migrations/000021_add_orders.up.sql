@@ -0,0 +1,52 @@
+CREATE TABLE "orders" (
+    "id" BIGSERIAL PRIMARY KEY,
+    "buyer_id" integer NOT NULL,
+    -- 'pending', 'paid', 'failed' or 'refunded'
+    "status" VARCHAR NOT NULL DEFAULT 'pending',
+    -- Sum of the item prices in the minor unit of the currency
+    "total" bigint NOT NULL,
+    "currency" VARCHAR(3) NOT NULL,
+    -- The payment at the payment provider, set once it was created
+    "payment_intent_id" VARCHAR UNIQUE,
+    -- Why the payment failed, empty unless the order failed
+    "failure_reason" VARCHAR NOT NULL DEFAULT '',
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+-- Items keep the title, tier and price they were bought at,
+-- the beat and license may be deleted later on without touching past orders
+CREATE TABLE "order_items" (
+    "id" BIGSERIAL PRIMARY KEY,
+    "order_id" bigint NOT NULL,
+    "beat_id" integer,
+    "license_id" bigint,
+    "beat_title" VARCHAR NOT NULL,
+    "tier" VARCHAR NOT NULL,
+    "price" bigint NOT NULL,
+    "currency" VARCHAR(3) NOT NULL
+);
+
+ALTER TABLE
+    "orders"
+ADD
+    FOREIGN KEY ("buyer_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+ALTER TABLE
+    "order_items"
+ADD
+    FOREIGN KEY ("order_id") REFERENCES "orders" ("id") ON DELETE CASCADE;
+
+ALTER TABLE
+    "order_items"
+ADD
+    FOREIGN KEY ("beat_id") REFERENCES "beats" ("id") ON DELETE SET NULL;
+
+ALTER TABLE
+    "order_items"
+ADD
+    FOREIGN KEY ("license_id") REFERENCES "licenses" ("id") ON DELETE SET NULL;
+
+CREATE INDEX ON "orders" ("buyer_id");
+
+CREATE INDEX ON "order_items" ("order_id");
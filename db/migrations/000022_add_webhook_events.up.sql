@@ -0,0 +1,24 @@
+-- Every webhook event received from the payment provider, keyed by the ID the provider gave it
+-- so redeliveries of an event are only processed once
+CREATE TABLE "webhook_events" (
+    "id" VARCHAR PRIMARY KEY,
+    "type" VARCHAR NOT NULL,
+    -- The payload as it was received, failed events are replayed from it
+    "payload" jsonb NOT NULL,
+    -- 'pending', 'processed', 'ignored' or 'failed'
+    "status" VARCHAR NOT NULL DEFAULT 'pending',
+    "attempts" integer NOT NULL DEFAULT 0,
+    -- Why the last attempt failed, empty unless the event failed
+    "last_error" VARCHAR NOT NULL DEFAULT '',
+    -- The order the event settled
+    "order_id" bigint,
+    "received_at" timestamptz NOT NULL DEFAULT (now()),
+    "processed_at" timestamptz
+);
+
+ALTER TABLE
+    "webhook_events"
+ADD
+    FOREIGN KEY ("order_id") REFERENCES "orders" ("id") ON DELETE SET NULL;
+
+CREATE INDEX ON "webhook_events" ("status", "received_at");
@@ -0,0 +1 @@
+-- The untagged MP3 masters are not published as previews again.
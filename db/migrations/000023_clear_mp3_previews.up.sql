@@ -0,0 +1,9 @@
+-- MP3 masters used to be their own preview, which published the untagged audio.
+-- Their beats have no preview until a WAV master is uploaded.
+UPDATE
+    "beats"
+SET
+    "preview_key" = ''
+WHERE
+    "preview_key" <> ''
+    AND "preview_key" = "s3_key";
@@ -0,0 +1,5 @@
+-- Set once an exclusive license was paid for, it can't be bought again
+ALTER TABLE
+    "licenses"
+ADD
+    COLUMN "sold_at" timestamptz;
@@ -0,0 +1,5 @@
+-- Time step of the last TOTP code accepted at login, codes of this or an earlier step are refused
+ALTER TABLE
+    "users"
+ADD
+    COLUMN "totp_last_step" bigint NOT NULL DEFAULT 0;
@@ -0,0 +1,11 @@
+-- The order an exclusive license was ordered with until the order fails,
+-- no other order can be placed for the license meanwhile
+ALTER TABLE
+    "licenses"
+ADD
+    COLUMN "reserved_order_id" bigint;
+
+ALTER TABLE
+    "licenses"
+ADD
+    FOREIGN KEY ("reserved_order_id") REFERENCES "orders" ("id") ON DELETE SET NULL;
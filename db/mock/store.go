@@ -6,10 +6,12 @@ package mockdb
 
 import (
 	context "context"
+	sql "database/sql"
 	reflect "reflect"
 
 	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
 	gomock "github.com/golang/mock/gomock"
+	uuid "github.com/google/uuid"
 )
 
 // MockStore is a mock of Store interface.
@@ -35,344 +37,2146 @@ func (m *MockStore) EXPECT() *MockStoreMockRecorder {
 	return m.recorder
 }
 
+// AddStreamedBytes mocks base method.
+func (m *MockStore) AddStreamedBytes(arg0 context.Context, arg1 db.AddStreamedBytesParams) (db.StreamSession, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddStreamedBytes", arg0, arg1)
+	ret0, _ := ret[0].(db.StreamSession)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddStreamedBytes indicates an expected call of AddStreamedBytes.
+func (mr *MockStoreMockRecorder) AddStreamedBytes(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddStreamedBytes", reflect.TypeOf((*MockStore)(nil).AddStreamedBytes), arg0, arg1)
+}
+
+// AdvanceUpload mocks base method.
+func (m *MockStore) AdvanceUpload(arg0 context.Context, arg1 db.AdvanceUploadParams) (db.Upload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AdvanceUpload", arg0, arg1)
+	ret0, _ := ret[0].(db.Upload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AdvanceUpload indicates an expected call of AdvanceUpload.
+func (mr *MockStoreMockRecorder) AdvanceUpload(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AdvanceUpload", reflect.TypeOf((*MockStore)(nil).AdvanceUpload), arg0, arg1)
+}
+
+// BlockSession mocks base method.
+func (m *MockStore) BlockSession(arg0 context.Context, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlockSession", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BlockSession indicates an expected call of BlockSession.
+func (mr *MockStoreMockRecorder) BlockSession(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlockSession", reflect.TypeOf((*MockStore)(nil).BlockSession), arg0, arg1)
+}
+
+// BlockUserSessions mocks base method.
+func (m *MockStore) BlockUserSessions(arg0 context.Context, arg1 int32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlockUserSessions", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BlockUserSessions indicates an expected call of BlockUserSessions.
+func (mr *MockStoreMockRecorder) BlockUserSessions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlockUserSessions", reflect.TypeOf((*MockStore)(nil).BlockUserSessions), arg0, arg1)
+}
+
+// CompleteMFALoginTx mocks base method.
+func (m *MockStore) CompleteMFALoginTx(arg0 context.Context, arg1 db.CompleteMFALoginTxParams) (db.CompleteMFALoginTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteMFALoginTx", arg0, arg1)
+	ret0, _ := ret[0].(db.CompleteMFALoginTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompleteMFALoginTx indicates an expected call of CompleteMFALoginTx.
+func (mr *MockStoreMockRecorder) CompleteMFALoginTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteMFALoginTx", reflect.TypeOf((*MockStore)(nil).CompleteMFALoginTx), arg0, arg1)
+}
+
+// CountOrderedExclusives mocks base method.
+func (m *MockStore) CountOrderedExclusives(arg0 context.Context, arg1 int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountOrderedExclusives", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountOrderedExclusives indicates an expected call of CountOrderedExclusives.
+func (mr *MockStoreMockRecorder) CountOrderedExclusives(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountOrderedExclusives", reflect.TypeOf((*MockStore)(nil).CountOrderedExclusives), arg0, arg1)
+}
+
+// CreateAPIKey mocks base method.
+func (m *MockStore) CreateAPIKey(arg0 context.Context, arg1 db.CreateAPIKeyParams) (db.ApiKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAPIKey", arg0, arg1)
+	ret0, _ := ret[0].(db.ApiKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAPIKey indicates an expected call of CreateAPIKey.
+func (mr *MockStoreMockRecorder) CreateAPIKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAPIKey", reflect.TypeOf((*MockStore)(nil).CreateAPIKey), arg0, arg1)
+}
+
+// CreateAuditLog mocks base method.
+func (m *MockStore) CreateAuditLog(arg0 context.Context, arg1 db.CreateAuditLogParams) (db.AuditLog, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAuditLog", arg0, arg1)
+	ret0, _ := ret[0].(db.AuditLog)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAuditLog indicates an expected call of CreateAuditLog.
+func (mr *MockStoreMockRecorder) CreateAuditLog(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAuditLog", reflect.TypeOf((*MockStore)(nil).CreateAuditLog), arg0, arg1)
+}
+
 // CreateBeat mocks base method.
 func (m *MockStore) CreateBeat(arg0 context.Context, arg1 db.CreateBeatParams) (db.Beat, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateBeat", arg0, arg1)
+	ret := m.ctrl.Call(m, "CreateBeat", arg0, arg1)
+	ret0, _ := ret[0].(db.Beat)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateBeat indicates an expected call of CreateBeat.
+func (mr *MockStoreMockRecorder) CreateBeat(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBeat", reflect.TypeOf((*MockStore)(nil).CreateBeat), arg0, arg1)
+}
+
+// CreateCart mocks base method.
+func (m *MockStore) CreateCart(arg0 context.Context, arg1 db.CreateCartParams) (db.Cart, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCart", arg0, arg1)
+	ret0, _ := ret[0].(db.Cart)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateCart indicates an expected call of CreateCart.
+func (mr *MockStoreMockRecorder) CreateCart(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCart", reflect.TypeOf((*MockStore)(nil).CreateCart), arg0, arg1)
+}
+
+// CreateDuplicateMatch mocks base method.
+func (m *MockStore) CreateDuplicateMatch(arg0 context.Context, arg1 db.CreateDuplicateMatchParams) (db.DuplicateMatch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateDuplicateMatch", arg0, arg1)
+	ret0, _ := ret[0].(db.DuplicateMatch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateDuplicateMatch indicates an expected call of CreateDuplicateMatch.
+func (mr *MockStoreMockRecorder) CreateDuplicateMatch(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDuplicateMatch", reflect.TypeOf((*MockStore)(nil).CreateDuplicateMatch), arg0, arg1)
+}
+
+// CreateEmailVerification mocks base method.
+func (m *MockStore) CreateEmailVerification(arg0 context.Context, arg1 db.CreateEmailVerificationParams) (db.EmailVerification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateEmailVerification", arg0, arg1)
+	ret0, _ := ret[0].(db.EmailVerification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateEmailVerification indicates an expected call of CreateEmailVerification.
+func (mr *MockStoreMockRecorder) CreateEmailVerification(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateEmailVerification", reflect.TypeOf((*MockStore)(nil).CreateEmailVerification), arg0, arg1)
+}
+
+// CreateLicense mocks base method.
+func (m *MockStore) CreateLicense(arg0 context.Context, arg1 db.CreateLicenseParams) (db.License, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateLicense", arg0, arg1)
+	ret0, _ := ret[0].(db.License)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateLicense indicates an expected call of CreateLicense.
+func (mr *MockStoreMockRecorder) CreateLicense(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateLicense", reflect.TypeOf((*MockStore)(nil).CreateLicense), arg0, arg1)
+}
+
+// CreateLike mocks base method.
+func (m *MockStore) CreateLike(arg0 context.Context, arg1 db.CreateLikeParams) (db.Like, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateLike", arg0, arg1)
+	ret0, _ := ret[0].(db.Like)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateLike indicates an expected call of CreateLike.
+func (mr *MockStoreMockRecorder) CreateLike(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateLike", reflect.TypeOf((*MockStore)(nil).CreateLike), arg0, arg1)
+}
+
+// CreateLoginEvent mocks base method.
+func (m *MockStore) CreateLoginEvent(arg0 context.Context, arg1 db.CreateLoginEventParams) (db.LoginEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateLoginEvent", arg0, arg1)
+	ret0, _ := ret[0].(db.LoginEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateLoginEvent indicates an expected call of CreateLoginEvent.
+func (mr *MockStoreMockRecorder) CreateLoginEvent(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateLoginEvent", reflect.TypeOf((*MockStore)(nil).CreateLoginEvent), arg0, arg1)
+}
+
+// CreateMFAChallenge mocks base method.
+func (m *MockStore) CreateMFAChallenge(arg0 context.Context, arg1 db.CreateMFAChallengeParams) (db.MfaChallenge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMFAChallenge", arg0, arg1)
+	ret0, _ := ret[0].(db.MfaChallenge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateMFAChallenge indicates an expected call of CreateMFAChallenge.
+func (mr *MockStoreMockRecorder) CreateMFAChallenge(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMFAChallenge", reflect.TypeOf((*MockStore)(nil).CreateMFAChallenge), arg0, arg1)
+}
+
+// CreateMFARecoveryCode mocks base method.
+func (m *MockStore) CreateMFARecoveryCode(arg0 context.Context, arg1 db.CreateMFARecoveryCodeParams) (db.MfaRecoveryCode, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMFARecoveryCode", arg0, arg1)
+	ret0, _ := ret[0].(db.MfaRecoveryCode)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateMFARecoveryCode indicates an expected call of CreateMFARecoveryCode.
+func (mr *MockStoreMockRecorder) CreateMFARecoveryCode(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMFARecoveryCode", reflect.TypeOf((*MockStore)(nil).CreateMFARecoveryCode), arg0, arg1)
+}
+
+// CreateOrder mocks base method.
+func (m *MockStore) CreateOrder(arg0 context.Context, arg1 db.CreateOrderParams) (db.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOrder", arg0, arg1)
+	ret0, _ := ret[0].(db.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateOrder indicates an expected call of CreateOrder.
+func (mr *MockStoreMockRecorder) CreateOrder(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrder", reflect.TypeOf((*MockStore)(nil).CreateOrder), arg0, arg1)
+}
+
+// CreateOrderItem mocks base method.
+func (m *MockStore) CreateOrderItem(arg0 context.Context, arg1 db.CreateOrderItemParams) (db.OrderItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOrderItem", arg0, arg1)
+	ret0, _ := ret[0].(db.OrderItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateOrderItem indicates an expected call of CreateOrderItem.
+func (mr *MockStoreMockRecorder) CreateOrderItem(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrderItem", reflect.TypeOf((*MockStore)(nil).CreateOrderItem), arg0, arg1)
+}
+
+// CreateOrderTx mocks base method.
+func (m *MockStore) CreateOrderTx(arg0 context.Context, arg1 db.CreateOrderTxParams) (db.CreateOrderTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOrderTx", arg0, arg1)
+	ret0, _ := ret[0].(db.CreateOrderTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateOrderTx indicates an expected call of CreateOrderTx.
+func (mr *MockStoreMockRecorder) CreateOrderTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrderTx", reflect.TypeOf((*MockStore)(nil).CreateOrderTx), arg0, arg1)
+}
+
+// CreatePasswordReset mocks base method.
+func (m *MockStore) CreatePasswordReset(arg0 context.Context, arg1 db.CreatePasswordResetParams) (db.PasswordReset, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePasswordReset", arg0, arg1)
+	ret0, _ := ret[0].(db.PasswordReset)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePasswordReset indicates an expected call of CreatePasswordReset.
+func (mr *MockStoreMockRecorder) CreatePasswordReset(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePasswordReset", reflect.TypeOf((*MockStore)(nil).CreatePasswordReset), arg0, arg1)
+}
+
+// CreateSession mocks base method.
+func (m *MockStore) CreateSession(arg0 context.Context, arg1 db.CreateSessionParams) (db.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSession", arg0, arg1)
+	ret0, _ := ret[0].(db.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSession indicates an expected call of CreateSession.
+func (mr *MockStoreMockRecorder) CreateSession(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSession", reflect.TypeOf((*MockStore)(nil).CreateSession), arg0, arg1)
+}
+
+// CreateUpload mocks base method.
+func (m *MockStore) CreateUpload(arg0 context.Context, arg1 db.CreateUploadParams) (db.Upload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUpload", arg0, arg1)
+	ret0, _ := ret[0].(db.Upload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUpload indicates an expected call of CreateUpload.
+func (mr *MockStoreMockRecorder) CreateUpload(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUpload", reflect.TypeOf((*MockStore)(nil).CreateUpload), arg0, arg1)
+}
+
+// CreateUser mocks base method.
+func (m *MockStore) CreateUser(arg0 context.Context, arg1 db.CreateUserParams) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUser", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUser indicates an expected call of CreateUser.
+func (mr *MockStoreMockRecorder) CreateUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockStore)(nil).CreateUser), arg0, arg1)
+}
+
+// CreateUserTx mocks base method.
+func (m *MockStore) CreateUserTx(arg0 context.Context, arg1 db.CreateUserTxParams) (db.CreateUserTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUserTx", arg0, arg1)
+	ret0, _ := ret[0].(db.CreateUserTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUserTx indicates an expected call of CreateUserTx.
+func (mr *MockStoreMockRecorder) CreateUserTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUserTx", reflect.TypeOf((*MockStore)(nil).CreateUserTx), arg0, arg1)
+}
+
+// DeleteAPIKey mocks base method.
+func (m *MockStore) DeleteAPIKey(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAPIKey", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAPIKey indicates an expected call of DeleteAPIKey.
+func (mr *MockStoreMockRecorder) DeleteAPIKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAPIKey", reflect.TypeOf((*MockStore)(nil).DeleteAPIKey), arg0, arg1)
+}
+
+// DeleteBeat mocks base method.
+func (m *MockStore) DeleteBeat(arg0 context.Context, arg1 int32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteBeat", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteBeat indicates an expected call of DeleteBeat.
+func (mr *MockStoreMockRecorder) DeleteBeat(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBeat", reflect.TypeOf((*MockStore)(nil).DeleteBeat), arg0, arg1)
+}
+
+// DeleteBeatFile mocks base method.
+func (m *MockStore) DeleteBeatFile(arg0 context.Context, arg1 db.DeleteBeatFileParams) (db.BeatFile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteBeatFile", arg0, arg1)
+	ret0, _ := ret[0].(db.BeatFile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteBeatFile indicates an expected call of DeleteBeatFile.
+func (mr *MockStoreMockRecorder) DeleteBeatFile(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBeatFile", reflect.TypeOf((*MockStore)(nil).DeleteBeatFile), arg0, arg1)
+}
+
+// DeleteBeatFilesByType mocks base method.
+func (m *MockStore) DeleteBeatFilesByType(arg0 context.Context, arg1 db.DeleteBeatFilesByTypeParams) ([]db.BeatFile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteBeatFilesByType", arg0, arg1)
+	ret0, _ := ret[0].([]db.BeatFile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteBeatFilesByType indicates an expected call of DeleteBeatFilesByType.
+func (mr *MockStoreMockRecorder) DeleteBeatFilesByType(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBeatFilesByType", reflect.TypeOf((*MockStore)(nil).DeleteBeatFilesByType), arg0, arg1)
+}
+
+// DeleteCart mocks base method.
+func (m *MockStore) DeleteCart(arg0 context.Context, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCart", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCart indicates an expected call of DeleteCart.
+func (mr *MockStoreMockRecorder) DeleteCart(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCart", reflect.TypeOf((*MockStore)(nil).DeleteCart), arg0, arg1)
+}
+
+// DeleteCartItem mocks base method.
+func (m *MockStore) DeleteCartItem(arg0 context.Context, arg1 db.DeleteCartItemParams) (db.CartItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCartItem", arg0, arg1)
+	ret0, _ := ret[0].(db.CartItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteCartItem indicates an expected call of DeleteCartItem.
+func (mr *MockStoreMockRecorder) DeleteCartItem(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCartItem", reflect.TypeOf((*MockStore)(nil).DeleteCartItem), arg0, arg1)
+}
+
+// DeleteLicense mocks base method.
+func (m *MockStore) DeleteLicense(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteLicense", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteLicense indicates an expected call of DeleteLicense.
+func (mr *MockStoreMockRecorder) DeleteLicense(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteLicense", reflect.TypeOf((*MockStore)(nil).DeleteLicense), arg0, arg1)
+}
+
+// DeleteLike mocks base method.
+func (m *MockStore) DeleteLike(arg0 context.Context, arg1 db.DeleteLikeParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteLike", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteLike indicates an expected call of DeleteLike.
+func (mr *MockStoreMockRecorder) DeleteLike(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteLike", reflect.TypeOf((*MockStore)(nil).DeleteLike), arg0, arg1)
+}
+
+// DeleteLoginThrottle mocks base method.
+func (m *MockStore) DeleteLoginThrottle(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteLoginThrottle", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteLoginThrottle indicates an expected call of DeleteLoginThrottle.
+func (mr *MockStoreMockRecorder) DeleteLoginThrottle(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteLoginThrottle", reflect.TypeOf((*MockStore)(nil).DeleteLoginThrottle), arg0, arg1)
+}
+
+// DeleteMFARecoveryCodesByUser mocks base method.
+func (m *MockStore) DeleteMFARecoveryCodesByUser(arg0 context.Context, arg1 int32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteMFARecoveryCodesByUser", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteMFARecoveryCodesByUser indicates an expected call of DeleteMFARecoveryCodesByUser.
+func (mr *MockStoreMockRecorder) DeleteMFARecoveryCodesByUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMFARecoveryCodesByUser", reflect.TypeOf((*MockStore)(nil).DeleteMFARecoveryCodesByUser), arg0, arg1)
+}
+
+// DeleteOrderedCartItems mocks base method.
+func (m *MockStore) DeleteOrderedCartItems(arg0 context.Context, arg1 db.DeleteOrderedCartItemsParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteOrderedCartItems", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteOrderedCartItems indicates an expected call of DeleteOrderedCartItems.
+func (mr *MockStoreMockRecorder) DeleteOrderedCartItems(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOrderedCartItems", reflect.TypeOf((*MockStore)(nil).DeleteOrderedCartItems), arg0, arg1)
+}
+
+// DeleteUpload mocks base method.
+func (m *MockStore) DeleteUpload(arg0 context.Context, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUpload", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUpload indicates an expected call of DeleteUpload.
+func (mr *MockStoreMockRecorder) DeleteUpload(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUpload", reflect.TypeOf((*MockStore)(nil).DeleteUpload), arg0, arg1)
+}
+
+// DeleteUser mocks base method.
+func (m *MockStore) DeleteUser(arg0 context.Context, arg1 int32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUser", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUser indicates an expected call of DeleteUser.
+func (mr *MockStoreMockRecorder) DeleteUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockStore)(nil).DeleteUser), arg0, arg1)
+}
+
+// EnableTOTPTx mocks base method.
+func (m *MockStore) EnableTOTPTx(arg0 context.Context, arg1 db.EnableTOTPTxParams) (db.EnableTOTPTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnableTOTPTx", arg0, arg1)
+	ret0, _ := ret[0].(db.EnableTOTPTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnableTOTPTx indicates an expected call of EnableTOTPTx.
+func (mr *MockStoreMockRecorder) EnableTOTPTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableTOTPTx", reflect.TypeOf((*MockStore)(nil).EnableTOTPTx), arg0, arg1)
+}
+
+// EnableUserTOTP mocks base method.
+func (m *MockStore) EnableUserTOTP(arg0 context.Context, arg1 int32) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnableUserTOTP", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnableUserTOTP indicates an expected call of EnableUserTOTP.
+func (mr *MockStoreMockRecorder) EnableUserTOTP(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableUserTOTP", reflect.TypeOf((*MockStore)(nil).EnableUserTOTP), arg0, arg1)
+}
+
+// GetAPIKey mocks base method.
+func (m *MockStore) GetAPIKey(arg0 context.Context, arg1 int64) (db.ApiKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPIKey", arg0, arg1)
+	ret0, _ := ret[0].(db.ApiKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPIKey indicates an expected call of GetAPIKey.
+func (mr *MockStoreMockRecorder) GetAPIKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKey", reflect.TypeOf((*MockStore)(nil).GetAPIKey), arg0, arg1)
+}
+
+// GetAPIKeyByPrefix mocks base method.
+func (m *MockStore) GetAPIKeyByPrefix(arg0 context.Context, arg1 string) (db.GetAPIKeyByPrefixRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPIKeyByPrefix", arg0, arg1)
+	ret0, _ := ret[0].(db.GetAPIKeyByPrefixRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPIKeyByPrefix indicates an expected call of GetAPIKeyByPrefix.
+func (mr *MockStoreMockRecorder) GetAPIKeyByPrefix(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKeyByPrefix", reflect.TypeOf((*MockStore)(nil).GetAPIKeyByPrefix), arg0, arg1)
+}
+
+// GetAudioFingerprint mocks base method.
+func (m *MockStore) GetAudioFingerprint(arg0 context.Context, arg1 int32) (db.AudioFingerprint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAudioFingerprint", arg0, arg1)
+	ret0, _ := ret[0].(db.AudioFingerprint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAudioFingerprint indicates an expected call of GetAudioFingerprint.
+func (mr *MockStoreMockRecorder) GetAudioFingerprint(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAudioFingerprint", reflect.TypeOf((*MockStore)(nil).GetAudioFingerprint), arg0, arg1)
+}
+
+// GetBeatById mocks base method.
+func (m *MockStore) GetBeatById(arg0 context.Context, arg1 int32) (db.Beat, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBeatById", arg0, arg1)
+	ret0, _ := ret[0].(db.Beat)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBeatById indicates an expected call of GetBeatById.
+func (mr *MockStoreMockRecorder) GetBeatById(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBeatById", reflect.TypeOf((*MockStore)(nil).GetBeatById), arg0, arg1)
+}
+
+// GetBeatByIdForUpdate mocks base method.
+func (m *MockStore) GetBeatByIdForUpdate(arg0 context.Context, arg1 int32) (db.Beat, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBeatByIdForUpdate", arg0, arg1)
+	ret0, _ := ret[0].(db.Beat)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBeatByIdForUpdate indicates an expected call of GetBeatByIdForUpdate.
+func (mr *MockStoreMockRecorder) GetBeatByIdForUpdate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBeatByIdForUpdate", reflect.TypeOf((*MockStore)(nil).GetBeatByIdForUpdate), arg0, arg1)
+}
+
+// GetBeatFile mocks base method.
+func (m *MockStore) GetBeatFile(arg0 context.Context, arg1 db.GetBeatFileParams) (db.BeatFile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBeatFile", arg0, arg1)
+	ret0, _ := ret[0].(db.BeatFile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBeatFile indicates an expected call of GetBeatFile.
+func (mr *MockStoreMockRecorder) GetBeatFile(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBeatFile", reflect.TypeOf((*MockStore)(nil).GetBeatFile), arg0, arg1)
+}
+
+// GetBeatWaveform mocks base method.
+func (m *MockStore) GetBeatWaveform(arg0 context.Context, arg1 int32) (db.BeatWaveform, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBeatWaveform", arg0, arg1)
+	ret0, _ := ret[0].(db.BeatWaveform)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBeatWaveform indicates an expected call of GetBeatWaveform.
+func (mr *MockStoreMockRecorder) GetBeatWaveform(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBeatWaveform", reflect.TypeOf((*MockStore)(nil).GetBeatWaveform), arg0, arg1)
+}
+
+// GetCart mocks base method.
+func (m *MockStore) GetCart(arg0 context.Context, arg1 uuid.UUID) (db.Cart, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCart", arg0, arg1)
+	ret0, _ := ret[0].(db.Cart)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCart indicates an expected call of GetCart.
+func (mr *MockStoreMockRecorder) GetCart(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCart", reflect.TypeOf((*MockStore)(nil).GetCart), arg0, arg1)
+}
+
+// GetCartByUser mocks base method.
+func (m *MockStore) GetCartByUser(arg0 context.Context, arg1 sql.NullInt32) (db.Cart, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCartByUser", arg0, arg1)
+	ret0, _ := ret[0].(db.Cart)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCartByUser indicates an expected call of GetCartByUser.
+func (mr *MockStoreMockRecorder) GetCartByUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCartByUser", reflect.TypeOf((*MockStore)(nil).GetCartByUser), arg0, arg1)
+}
+
+// GetDuplicateMatchForUpdate mocks base method.
+func (m *MockStore) GetDuplicateMatchForUpdate(arg0 context.Context, arg1 int64) (db.DuplicateMatch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDuplicateMatchForUpdate", arg0, arg1)
+	ret0, _ := ret[0].(db.DuplicateMatch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDuplicateMatchForUpdate indicates an expected call of GetDuplicateMatchForUpdate.
+func (mr *MockStoreMockRecorder) GetDuplicateMatchForUpdate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDuplicateMatchForUpdate", reflect.TypeOf((*MockStore)(nil).GetDuplicateMatchForUpdate), arg0, arg1)
+}
+
+// GetEmailVerificationByHashedToken mocks base method.
+func (m *MockStore) GetEmailVerificationByHashedToken(arg0 context.Context, arg1 string) (db.EmailVerification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEmailVerificationByHashedToken", arg0, arg1)
+	ret0, _ := ret[0].(db.EmailVerification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEmailVerificationByHashedToken indicates an expected call of GetEmailVerificationByHashedToken.
+func (mr *MockStoreMockRecorder) GetEmailVerificationByHashedToken(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEmailVerificationByHashedToken", reflect.TypeOf((*MockStore)(nil).GetEmailVerificationByHashedToken), arg0, arg1)
+}
+
+// GetFirstAudioByContentHash mocks base method.
+func (m *MockStore) GetFirstAudioByContentHash(arg0 context.Context, arg1 string) (db.GetFirstAudioByContentHashRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFirstAudioByContentHash", arg0, arg1)
+	ret0, _ := ret[0].(db.GetFirstAudioByContentHashRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFirstAudioByContentHash indicates an expected call of GetFirstAudioByContentHash.
+func (mr *MockStoreMockRecorder) GetFirstAudioByContentHash(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFirstAudioByContentHash", reflect.TypeOf((*MockStore)(nil).GetFirstAudioByContentHash), arg0, arg1)
+}
+
+// GetLicense mocks base method.
+func (m *MockStore) GetLicense(arg0 context.Context, arg1 int64) (db.License, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLicense", arg0, arg1)
+	ret0, _ := ret[0].(db.License)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLicense indicates an expected call of GetLicense.
+func (mr *MockStoreMockRecorder) GetLicense(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLicense", reflect.TypeOf((*MockStore)(nil).GetLicense), arg0, arg1)
+}
+
+// GetLicenseForUpdate mocks base method.
+func (m *MockStore) GetLicenseForUpdate(arg0 context.Context, arg1 int64) (db.License, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLicenseForUpdate", arg0, arg1)
+	ret0, _ := ret[0].(db.License)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLicenseForUpdate indicates an expected call of GetLicenseForUpdate.
+func (mr *MockStoreMockRecorder) GetLicenseForUpdate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLicenseForUpdate", reflect.TypeOf((*MockStore)(nil).GetLicenseForUpdate), arg0, arg1)
+}
+
+// GetLikeByUserAndBeat mocks base method.
+func (m *MockStore) GetLikeByUserAndBeat(arg0 context.Context, arg1 db.GetLikeByUserAndBeatParams) (db.Like, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLikeByUserAndBeat", arg0, arg1)
+	ret0, _ := ret[0].(db.Like)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLikeByUserAndBeat indicates an expected call of GetLikeByUserAndBeat.
+func (mr *MockStoreMockRecorder) GetLikeByUserAndBeat(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLikeByUserAndBeat", reflect.TypeOf((*MockStore)(nil).GetLikeByUserAndBeat), arg0, arg1)
+}
+
+// GetLoginThrottle mocks base method.
+func (m *MockStore) GetLoginThrottle(arg0 context.Context, arg1 string) (db.LoginThrottle, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLoginThrottle", arg0, arg1)
+	ret0, _ := ret[0].(db.LoginThrottle)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLoginThrottle indicates an expected call of GetLoginThrottle.
+func (mr *MockStoreMockRecorder) GetLoginThrottle(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoginThrottle", reflect.TypeOf((*MockStore)(nil).GetLoginThrottle), arg0, arg1)
+}
+
+// GetMFAChallengeByHashedToken mocks base method.
+func (m *MockStore) GetMFAChallengeByHashedToken(arg0 context.Context, arg1 string) (db.MfaChallenge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMFAChallengeByHashedToken", arg0, arg1)
+	ret0, _ := ret[0].(db.MfaChallenge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMFAChallengeByHashedToken indicates an expected call of GetMFAChallengeByHashedToken.
+func (mr *MockStoreMockRecorder) GetMFAChallengeByHashedToken(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMFAChallengeByHashedToken", reflect.TypeOf((*MockStore)(nil).GetMFAChallengeByHashedToken), arg0, arg1)
+}
+
+// GetOrder mocks base method.
+func (m *MockStore) GetOrder(arg0 context.Context, arg1 int64) (db.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrder", arg0, arg1)
+	ret0, _ := ret[0].(db.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrder indicates an expected call of GetOrder.
+func (mr *MockStoreMockRecorder) GetOrder(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrder", reflect.TypeOf((*MockStore)(nil).GetOrder), arg0, arg1)
+}
+
+// GetOrderByPaymentIntentForUpdate mocks base method.
+func (m *MockStore) GetOrderByPaymentIntentForUpdate(arg0 context.Context, arg1 sql.NullString) (db.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrderByPaymentIntentForUpdate", arg0, arg1)
+	ret0, _ := ret[0].(db.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrderByPaymentIntentForUpdate indicates an expected call of GetOrderByPaymentIntentForUpdate.
+func (mr *MockStoreMockRecorder) GetOrderByPaymentIntentForUpdate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderByPaymentIntentForUpdate", reflect.TypeOf((*MockStore)(nil).GetOrderByPaymentIntentForUpdate), arg0, arg1)
+}
+
+// GetOrderForUpdate mocks base method.
+func (m *MockStore) GetOrderForUpdate(arg0 context.Context, arg1 int64) (db.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrderForUpdate", arg0, arg1)
+	ret0, _ := ret[0].(db.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrderForUpdate indicates an expected call of GetOrderForUpdate.
+func (mr *MockStoreMockRecorder) GetOrderForUpdate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderForUpdate", reflect.TypeOf((*MockStore)(nil).GetOrderForUpdate), arg0, arg1)
+}
+
+// GetPasswordResetByHashedToken mocks base method.
+func (m *MockStore) GetPasswordResetByHashedToken(arg0 context.Context, arg1 string) (db.PasswordReset, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPasswordResetByHashedToken", arg0, arg1)
+	ret0, _ := ret[0].(db.PasswordReset)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPasswordResetByHashedToken indicates an expected call of GetPasswordResetByHashedToken.
+func (mr *MockStoreMockRecorder) GetPasswordResetByHashedToken(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPasswordResetByHashedToken", reflect.TypeOf((*MockStore)(nil).GetPasswordResetByHashedToken), arg0, arg1)
+}
+
+// GetSession mocks base method.
+func (m *MockStore) GetSession(arg0 context.Context, arg1 uuid.UUID) (db.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSession", arg0, arg1)
+	ret0, _ := ret[0].(db.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSession indicates an expected call of GetSession.
+func (mr *MockStoreMockRecorder) GetSession(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSession", reflect.TypeOf((*MockStore)(nil).GetSession), arg0, arg1)
+}
+
+// GetStreamSession mocks base method.
+func (m *MockStore) GetStreamSession(arg0 context.Context, arg1 db.GetStreamSessionParams) (db.StreamSession, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStreamSession", arg0, arg1)
+	ret0, _ := ret[0].(db.StreamSession)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStreamSession indicates an expected call of GetStreamSession.
+func (mr *MockStoreMockRecorder) GetStreamSession(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStreamSession", reflect.TypeOf((*MockStore)(nil).GetStreamSession), arg0, arg1)
+}
+
+// GetUpload mocks base method.
+func (m *MockStore) GetUpload(arg0 context.Context, arg1 uuid.UUID) (db.Upload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUpload", arg0, arg1)
+	ret0, _ := ret[0].(db.Upload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUpload indicates an expected call of GetUpload.
+func (mr *MockStoreMockRecorder) GetUpload(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUpload", reflect.TypeOf((*MockStore)(nil).GetUpload), arg0, arg1)
+}
+
+// GetUserByEmail mocks base method.
+func (m *MockStore) GetUserByEmail(arg0 context.Context, arg1 string) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByEmail", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByEmail indicates an expected call of GetUserByEmail.
+func (mr *MockStoreMockRecorder) GetUserByEmail(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByEmail", reflect.TypeOf((*MockStore)(nil).GetUserByEmail), arg0, arg1)
+}
+
+// GetUserById mocks base method.
+func (m *MockStore) GetUserById(arg0 context.Context, arg1 int32) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserById", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserById indicates an expected call of GetUserById.
+func (mr *MockStoreMockRecorder) GetUserById(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserById", reflect.TypeOf((*MockStore)(nil).GetUserById), arg0, arg1)
+}
+
+// GetUserByIdForUpdate mocks base method.
+func (m *MockStore) GetUserByIdForUpdate(arg0 context.Context, arg1 int32) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByIdForUpdate", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByIdForUpdate indicates an expected call of GetUserByIdForUpdate.
+func (mr *MockStoreMockRecorder) GetUserByIdForUpdate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByIdForUpdate", reflect.TypeOf((*MockStore)(nil).GetUserByIdForUpdate), arg0, arg1)
+}
+
+// GetUserByUsername mocks base method.
+func (m *MockStore) GetUserByUsername(arg0 context.Context, arg1 string) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByUsername", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByUsername indicates an expected call of GetUserByUsername.
+func (mr *MockStoreMockRecorder) GetUserByUsername(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByUsername", reflect.TypeOf((*MockStore)(nil).GetUserByUsername), arg0, arg1)
+}
+
+// GetWebhookEvent mocks base method.
+func (m *MockStore) GetWebhookEvent(arg0 context.Context, arg1 string) (db.WebhookEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookEvent", arg0, arg1)
+	ret0, _ := ret[0].(db.WebhookEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookEvent indicates an expected call of GetWebhookEvent.
+func (mr *MockStoreMockRecorder) GetWebhookEvent(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookEvent", reflect.TypeOf((*MockStore)(nil).GetWebhookEvent), arg0, arg1)
+}
+
+// GetWebhookEventForUpdate mocks base method.
+func (m *MockStore) GetWebhookEventForUpdate(arg0 context.Context, arg1 string) (db.WebhookEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookEventForUpdate", arg0, arg1)
+	ret0, _ := ret[0].(db.WebhookEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookEventForUpdate indicates an expected call of GetWebhookEventForUpdate.
+func (mr *MockStoreMockRecorder) GetWebhookEventForUpdate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookEventForUpdate", reflect.TypeOf((*MockStore)(nil).GetWebhookEventForUpdate), arg0, arg1)
+}
+
+// IncrementBeatPlayCount mocks base method.
+func (m *MockStore) IncrementBeatPlayCount(arg0 context.Context, arg1 int32) (db.Beat, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrementBeatPlayCount", arg0, arg1)
+	ret0, _ := ret[0].(db.Beat)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IncrementBeatPlayCount indicates an expected call of IncrementBeatPlayCount.
+func (mr *MockStoreMockRecorder) IncrementBeatPlayCount(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrementBeatPlayCount", reflect.TypeOf((*MockStore)(nil).IncrementBeatPlayCount), arg0, arg1)
+}
+
+// IncrementMFAChallengeAttempts mocks base method.
+func (m *MockStore) IncrementMFAChallengeAttempts(arg0 context.Context, arg1 db.IncrementMFAChallengeAttemptsParams) (db.MfaChallenge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrementMFAChallengeAttempts", arg0, arg1)
+	ret0, _ := ret[0].(db.MfaChallenge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IncrementMFAChallengeAttempts indicates an expected call of IncrementMFAChallengeAttempts.
+func (mr *MockStoreMockRecorder) IncrementMFAChallengeAttempts(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrementMFAChallengeAttempts", reflect.TypeOf((*MockStore)(nil).IncrementMFAChallengeAttempts), arg0, arg1)
+}
+
+// ListAPIKeysByUser mocks base method.
+func (m *MockStore) ListAPIKeysByUser(arg0 context.Context, arg1 int32) ([]db.ApiKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAPIKeysByUser", arg0, arg1)
+	ret0, _ := ret[0].([]db.ApiKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAPIKeysByUser indicates an expected call of ListAPIKeysByUser.
+func (mr *MockStoreMockRecorder) ListAPIKeysByUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAPIKeysByUser", reflect.TypeOf((*MockStore)(nil).ListAPIKeysByUser), arg0, arg1)
+}
+
+// ListAuditLogsByTargetUser mocks base method.
+func (m *MockStore) ListAuditLogsByTargetUser(arg0 context.Context, arg1 db.ListAuditLogsByTargetUserParams) ([]db.AuditLog, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAuditLogsByTargetUser", arg0, arg1)
+	ret0, _ := ret[0].([]db.AuditLog)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAuditLogsByTargetUser indicates an expected call of ListAuditLogsByTargetUser.
+func (mr *MockStoreMockRecorder) ListAuditLogsByTargetUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAuditLogsByTargetUser", reflect.TypeOf((*MockStore)(nil).ListAuditLogsByTargetUser), arg0, arg1)
+}
+
+// ListBeatFiles mocks base method.
+func (m *MockStore) ListBeatFiles(arg0 context.Context, arg1 int32) ([]db.BeatFile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBeatFiles", arg0, arg1)
+	ret0, _ := ret[0].([]db.BeatFile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBeatFiles indicates an expected call of ListBeatFiles.
+func (mr *MockStoreMockRecorder) ListBeatFiles(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBeatFiles", reflect.TypeOf((*MockStore)(nil).ListBeatFiles), arg0, arg1)
+}
+
+// ListBeatsByBpmRange mocks base method.
+func (m *MockStore) ListBeatsByBpmRange(arg0 context.Context, arg1 db.ListBeatsByBpmRangeParams) ([]db.Beat, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBeatsByBpmRange", arg0, arg1)
+	ret0, _ := ret[0].([]db.Beat)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBeatsByBpmRange indicates an expected call of ListBeatsByBpmRange.
+func (mr *MockStoreMockRecorder) ListBeatsByBpmRange(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBeatsByBpmRange", reflect.TypeOf((*MockStore)(nil).ListBeatsByBpmRange), arg0, arg1)
+}
+
+// ListBeatsByCreatorId mocks base method.
+func (m *MockStore) ListBeatsByCreatorId(arg0 context.Context, arg1 db.ListBeatsByCreatorIdParams) ([]db.Beat, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBeatsByCreatorId", arg0, arg1)
+	ret0, _ := ret[0].([]db.Beat)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBeatsByCreatorId indicates an expected call of ListBeatsByCreatorId.
+func (mr *MockStoreMockRecorder) ListBeatsByCreatorId(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBeatsByCreatorId", reflect.TypeOf((*MockStore)(nil).ListBeatsByCreatorId), arg0, arg1)
+}
+
+// ListBeatsByCreatorIdAndBpmRange mocks base method.
+func (m *MockStore) ListBeatsByCreatorIdAndBpmRange(arg0 context.Context, arg1 db.ListBeatsByCreatorIdAndBpmRangeParams) ([]db.Beat, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBeatsByCreatorIdAndBpmRange", arg0, arg1)
+	ret0, _ := ret[0].([]db.Beat)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBeatsByCreatorIdAndBpmRange indicates an expected call of ListBeatsByCreatorIdAndBpmRange.
+func (mr *MockStoreMockRecorder) ListBeatsByCreatorIdAndBpmRange(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBeatsByCreatorIdAndBpmRange", reflect.TypeOf((*MockStore)(nil).ListBeatsByCreatorIdAndBpmRange), arg0, arg1)
+}
+
+// ListBeatsByCreatorIdAndGenre mocks base method.
+func (m *MockStore) ListBeatsByCreatorIdAndGenre(arg0 context.Context, arg1 db.ListBeatsByCreatorIdAndGenreParams) ([]db.Beat, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBeatsByCreatorIdAndGenre", arg0, arg1)
+	ret0, _ := ret[0].([]db.Beat)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBeatsByCreatorIdAndGenre indicates an expected call of ListBeatsByCreatorIdAndGenre.
+func (mr *MockStoreMockRecorder) ListBeatsByCreatorIdAndGenre(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBeatsByCreatorIdAndGenre", reflect.TypeOf((*MockStore)(nil).ListBeatsByCreatorIdAndGenre), arg0, arg1)
+}
+
+// ListBeatsByCreatorIdAndKey mocks base method.
+func (m *MockStore) ListBeatsByCreatorIdAndKey(arg0 context.Context, arg1 db.ListBeatsByCreatorIdAndKeyParams) ([]db.Beat, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBeatsByCreatorIdAndKey", arg0, arg1)
+	ret0, _ := ret[0].([]db.Beat)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBeatsByCreatorIdAndKey indicates an expected call of ListBeatsByCreatorIdAndKey.
+func (mr *MockStoreMockRecorder) ListBeatsByCreatorIdAndKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBeatsByCreatorIdAndKey", reflect.TypeOf((*MockStore)(nil).ListBeatsByCreatorIdAndKey), arg0, arg1)
+}
+
+// ListBeatsByGenre mocks base method.
+func (m *MockStore) ListBeatsByGenre(arg0 context.Context, arg1 db.ListBeatsByGenreParams) ([]db.Beat, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBeatsByGenre", arg0, arg1)
+	ret0, _ := ret[0].([]db.Beat)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBeatsByGenre indicates an expected call of ListBeatsByGenre.
+func (mr *MockStoreMockRecorder) ListBeatsByGenre(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBeatsByGenre", reflect.TypeOf((*MockStore)(nil).ListBeatsByGenre), arg0, arg1)
+}
+
+// ListBeatsById mocks base method.
+func (m *MockStore) ListBeatsById(arg0 context.Context, arg1 db.ListBeatsByIdParams) ([]db.Beat, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBeatsById", arg0, arg1)
+	ret0, _ := ret[0].([]db.Beat)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBeatsById indicates an expected call of ListBeatsById.
+func (mr *MockStoreMockRecorder) ListBeatsById(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBeatsById", reflect.TypeOf((*MockStore)(nil).ListBeatsById), arg0, arg1)
+}
+
+// ListBeatsByKey mocks base method.
+func (m *MockStore) ListBeatsByKey(arg0 context.Context, arg1 db.ListBeatsByKeyParams) ([]db.Beat, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBeatsByKey", arg0, arg1)
+	ret0, _ := ret[0].([]db.Beat)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBeatsByKey indicates an expected call of ListBeatsByKey.
+func (mr *MockStoreMockRecorder) ListBeatsByKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBeatsByKey", reflect.TypeOf((*MockStore)(nil).ListBeatsByKey), arg0, arg1)
+}
+
+// ListCartItems mocks base method.
+func (m *MockStore) ListCartItems(arg0 context.Context, arg1 uuid.UUID) ([]db.ListCartItemsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListCartItems", arg0, arg1)
+	ret0, _ := ret[0].([]db.ListCartItemsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListCartItems indicates an expected call of ListCartItems.
+func (mr *MockStoreMockRecorder) ListCartItems(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCartItems", reflect.TypeOf((*MockStore)(nil).ListCartItems), arg0, arg1)
+}
+
+// ListDuplicateMatches mocks base method.
+func (m *MockStore) ListDuplicateMatches(arg0 context.Context, arg1 db.ListDuplicateMatchesParams) ([]db.ListDuplicateMatchesRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDuplicateMatches", arg0, arg1)
+	ret0, _ := ret[0].([]db.ListDuplicateMatchesRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDuplicateMatches indicates an expected call of ListDuplicateMatches.
+func (mr *MockStoreMockRecorder) ListDuplicateMatches(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDuplicateMatches", reflect.TypeOf((*MockStore)(nil).ListDuplicateMatches), arg0, arg1)
+}
+
+// ListFingerprintCandidates mocks base method.
+func (m *MockStore) ListFingerprintCandidates(arg0 context.Context, arg1 db.ListFingerprintCandidatesParams) ([]db.ListFingerprintCandidatesRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFingerprintCandidates", arg0, arg1)
+	ret0, _ := ret[0].([]db.ListFingerprintCandidatesRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFingerprintCandidates indicates an expected call of ListFingerprintCandidates.
+func (mr *MockStoreMockRecorder) ListFingerprintCandidates(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFingerprintCandidates", reflect.TypeOf((*MockStore)(nil).ListFingerprintCandidates), arg0, arg1)
+}
+
+// ListLicensesByBeat mocks base method.
+func (m *MockStore) ListLicensesByBeat(arg0 context.Context, arg1 int32) ([]db.License, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListLicensesByBeat", arg0, arg1)
+	ret0, _ := ret[0].([]db.License)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListLicensesByBeat indicates an expected call of ListLicensesByBeat.
+func (mr *MockStoreMockRecorder) ListLicensesByBeat(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListLicensesByBeat", reflect.TypeOf((*MockStore)(nil).ListLicensesByBeat), arg0, arg1)
+}
+
+// ListLikesByBeat mocks base method.
+func (m *MockStore) ListLikesByBeat(arg0 context.Context, arg1 db.ListLikesByBeatParams) ([]db.Like, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListLikesByBeat", arg0, arg1)
+	ret0, _ := ret[0].([]db.Like)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListLikesByBeat indicates an expected call of ListLikesByBeat.
+func (mr *MockStoreMockRecorder) ListLikesByBeat(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListLikesByBeat", reflect.TypeOf((*MockStore)(nil).ListLikesByBeat), arg0, arg1)
+}
+
+// ListLikesByUser mocks base method.
+func (m *MockStore) ListLikesByUser(arg0 context.Context, arg1 db.ListLikesByUserParams) ([]db.Like, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListLikesByUser", arg0, arg1)
+	ret0, _ := ret[0].([]db.Like)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListLikesByUser indicates an expected call of ListLikesByUser.
+func (mr *MockStoreMockRecorder) ListLikesByUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListLikesByUser", reflect.TypeOf((*MockStore)(nil).ListLikesByUser), arg0, arg1)
+}
+
+// ListLoginEventsByUsername mocks base method.
+func (m *MockStore) ListLoginEventsByUsername(arg0 context.Context, arg1 db.ListLoginEventsByUsernameParams) ([]db.LoginEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListLoginEventsByUsername", arg0, arg1)
+	ret0, _ := ret[0].([]db.LoginEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListLoginEventsByUsername indicates an expected call of ListLoginEventsByUsername.
+func (mr *MockStoreMockRecorder) ListLoginEventsByUsername(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListLoginEventsByUsername", reflect.TypeOf((*MockStore)(nil).ListLoginEventsByUsername), arg0, arg1)
+}
+
+// ListOrderItems mocks base method.
+func (m *MockStore) ListOrderItems(arg0 context.Context, arg1 int64) ([]db.OrderItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOrderItems", arg0, arg1)
+	ret0, _ := ret[0].([]db.OrderItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOrderItems indicates an expected call of ListOrderItems.
+func (mr *MockStoreMockRecorder) ListOrderItems(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOrderItems", reflect.TypeOf((*MockStore)(nil).ListOrderItems), arg0, arg1)
+}
+
+// ListStaleUploads mocks base method.
+func (m *MockStore) ListStaleUploads(arg0 context.Context, arg1 db.ListStaleUploadsParams) ([]db.Upload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListStaleUploads", arg0, arg1)
+	ret0, _ := ret[0].([]db.Upload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListStaleUploads indicates an expected call of ListStaleUploads.
+func (mr *MockStoreMockRecorder) ListStaleUploads(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListStaleUploads", reflect.TypeOf((*MockStore)(nil).ListStaleUploads), arg0, arg1)
+}
+
+// ListUsers mocks base method.
+func (m *MockStore) ListUsers(arg0 context.Context, arg1 db.ListUsersParams) ([]db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsers", arg0, arg1)
+	ret0, _ := ret[0].([]db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUsers indicates an expected call of ListUsers.
+func (mr *MockStoreMockRecorder) ListUsers(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockStore)(nil).ListUsers), arg0, arg1)
+}
+
+// ListWebhookEvents mocks base method.
+func (m *MockStore) ListWebhookEvents(arg0 context.Context, arg1 db.ListWebhookEventsParams) ([]db.WebhookEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhookEvents", arg0, arg1)
+	ret0, _ := ret[0].([]db.WebhookEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhookEvents indicates an expected call of ListWebhookEvents.
+func (mr *MockStoreMockRecorder) ListWebhookEvents(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhookEvents", reflect.TypeOf((*MockStore)(nil).ListWebhookEvents), arg0, arg1)
+}
+
+// MarkOrderedExclusivesSold mocks base method.
+func (m *MockStore) MarkOrderedExclusivesSold(arg0 context.Context, arg1 int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkOrderedExclusivesSold", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkOrderedExclusivesSold indicates an expected call of MarkOrderedExclusivesSold.
+func (mr *MockStoreMockRecorder) MarkOrderedExclusivesSold(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkOrderedExclusivesSold", reflect.TypeOf((*MockStore)(nil).MarkOrderedExclusivesSold), arg0, arg1)
+}
+
+// MarkStreamSessionPlayed mocks base method.
+func (m *MockStore) MarkStreamSessionPlayed(arg0 context.Context, arg1 db.MarkStreamSessionPlayedParams) (db.StreamSession, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkStreamSessionPlayed", arg0, arg1)
+	ret0, _ := ret[0].(db.StreamSession)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkStreamSessionPlayed indicates an expected call of MarkStreamSessionPlayed.
+func (mr *MockStoreMockRecorder) MarkStreamSessionPlayed(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkStreamSessionPlayed", reflect.TypeOf((*MockStore)(nil).MarkStreamSessionPlayed), arg0, arg1)
+}
+
+// MarkWebhookEventFailed mocks base method.
+func (m *MockStore) MarkWebhookEventFailed(arg0 context.Context, arg1 db.MarkWebhookEventFailedParams) (db.WebhookEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkWebhookEventFailed", arg0, arg1)
+	ret0, _ := ret[0].(db.WebhookEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkWebhookEventFailed indicates an expected call of MarkWebhookEventFailed.
+func (mr *MockStoreMockRecorder) MarkWebhookEventFailed(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkWebhookEventFailed", reflect.TypeOf((*MockStore)(nil).MarkWebhookEventFailed), arg0, arg1)
+}
+
+// MarkWebhookEventProcessed mocks base method.
+func (m *MockStore) MarkWebhookEventProcessed(arg0 context.Context, arg1 db.MarkWebhookEventProcessedParams) (db.WebhookEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkWebhookEventProcessed", arg0, arg1)
+	ret0, _ := ret[0].(db.WebhookEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkWebhookEventProcessed indicates an expected call of MarkWebhookEventProcessed.
+func (mr *MockStoreMockRecorder) MarkWebhookEventProcessed(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkWebhookEventProcessed", reflect.TypeOf((*MockStore)(nil).MarkWebhookEventProcessed), arg0, arg1)
+}
+
+// MergeCartTx mocks base method.
+func (m *MockStore) MergeCartTx(arg0 context.Context, arg1 db.MergeCartTxParams) (db.MergeCartTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MergeCartTx", arg0, arg1)
+	ret0, _ := ret[0].(db.MergeCartTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MergeCartTx indicates an expected call of MergeCartTx.
+func (mr *MockStoreMockRecorder) MergeCartTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MergeCartTx", reflect.TypeOf((*MockStore)(nil).MergeCartTx), arg0, arg1)
+}
+
+// MoveCartItems mocks base method.
+func (m *MockStore) MoveCartItems(arg0 context.Context, arg1 db.MoveCartItemsParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MoveCartItems", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MoveCartItems indicates an expected call of MoveCartItems.
+func (mr *MockStoreMockRecorder) MoveCartItems(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MoveCartItems", reflect.TypeOf((*MockStore)(nil).MoveCartItems), arg0, arg1)
+}
+
+// ProcessWebhookEventTx mocks base method.
+func (m *MockStore) ProcessWebhookEventTx(arg0 context.Context, arg1 db.ProcessWebhookEventTxParams) (db.ProcessWebhookEventTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProcessWebhookEventTx", arg0, arg1)
+	ret0, _ := ret[0].(db.ProcessWebhookEventTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ProcessWebhookEventTx indicates an expected call of ProcessWebhookEventTx.
+func (mr *MockStoreMockRecorder) ProcessWebhookEventTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessWebhookEventTx", reflect.TypeOf((*MockStore)(nil).ProcessWebhookEventTx), arg0, arg1)
+}
+
+// RecordLoginFailure mocks base method.
+func (m *MockStore) RecordLoginFailure(arg0 context.Context, arg1 db.RecordLoginFailureParams) (db.LoginThrottle, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordLoginFailure", arg0, arg1)
+	ret0, _ := ret[0].(db.LoginThrottle)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecordLoginFailure indicates an expected call of RecordLoginFailure.
+func (mr *MockStoreMockRecorder) RecordLoginFailure(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordLoginFailure", reflect.TypeOf((*MockStore)(nil).RecordLoginFailure), arg0, arg1)
+}
+
+// RecordStreamTx mocks base method.
+func (m *MockStore) RecordStreamTx(arg0 context.Context, arg1 db.RecordStreamTxParams) (db.RecordStreamTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordStreamTx", arg0, arg1)
+	ret0, _ := ret[0].(db.RecordStreamTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecordStreamTx indicates an expected call of RecordStreamTx.
+func (mr *MockStoreMockRecorder) RecordStreamTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordStreamTx", reflect.TypeOf((*MockStore)(nil).RecordStreamTx), arg0, arg1)
+}
+
+// RecordWebhookEvent mocks base method.
+func (m *MockStore) RecordWebhookEvent(arg0 context.Context, arg1 db.RecordWebhookEventParams) (db.WebhookEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordWebhookEvent", arg0, arg1)
+	ret0, _ := ret[0].(db.WebhookEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecordWebhookEvent indicates an expected call of RecordWebhookEvent.
+func (mr *MockStoreMockRecorder) RecordWebhookEvent(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordWebhookEvent", reflect.TypeOf((*MockStore)(nil).RecordWebhookEvent), arg0, arg1)
+}
+
+// ReleaseOrderedLicenses mocks base method.
+func (m *MockStore) ReleaseOrderedLicenses(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseOrderedLicenses", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseOrderedLicenses indicates an expected call of ReleaseOrderedLicenses.
+func (mr *MockStoreMockRecorder) ReleaseOrderedLicenses(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseOrderedLicenses", reflect.TypeOf((*MockStore)(nil).ReleaseOrderedLicenses), arg0, arg1)
+}
+
+// ReserveLicense mocks base method.
+func (m *MockStore) ReserveLicense(arg0 context.Context, arg1 db.ReserveLicenseParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReserveLicense", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReserveLicense indicates an expected call of ReserveLicense.
+func (mr *MockStoreMockRecorder) ReserveLicense(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReserveLicense", reflect.TypeOf((*MockStore)(nil).ReserveLicense), arg0, arg1)
+}
+
+// ResetPasswordTx mocks base method.
+func (m *MockStore) ResetPasswordTx(arg0 context.Context, arg1 db.ResetPasswordTxParams) (db.ResetPasswordTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetPasswordTx", arg0, arg1)
+	ret0, _ := ret[0].(db.ResetPasswordTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResetPasswordTx indicates an expected call of ResetPasswordTx.
+func (mr *MockStoreMockRecorder) ResetPasswordTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPasswordTx", reflect.TypeOf((*MockStore)(nil).ResetPasswordTx), arg0, arg1)
+}
+
+// ResetUserPassword mocks base method.
+func (m *MockStore) ResetUserPassword(arg0 context.Context, arg1 db.ResetUserPasswordParams) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetUserPassword", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResetUserPassword indicates an expected call of ResetUserPassword.
+func (mr *MockStoreMockRecorder) ResetUserPassword(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetUserPassword", reflect.TypeOf((*MockStore)(nil).ResetUserPassword), arg0, arg1)
+}
+
+// ReviewDuplicateMatch mocks base method.
+func (m *MockStore) ReviewDuplicateMatch(arg0 context.Context, arg1 db.ReviewDuplicateMatchParams) (db.DuplicateMatch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReviewDuplicateMatch", arg0, arg1)
+	ret0, _ := ret[0].(db.DuplicateMatch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReviewDuplicateMatch indicates an expected call of ReviewDuplicateMatch.
+func (mr *MockStoreMockRecorder) ReviewDuplicateMatch(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReviewDuplicateMatch", reflect.TypeOf((*MockStore)(nil).ReviewDuplicateMatch), arg0, arg1)
+}
+
+// ReviewDuplicateMatchTx mocks base method.
+func (m *MockStore) ReviewDuplicateMatchTx(arg0 context.Context, arg1 db.ReviewDuplicateMatchTxParams) (db.ReviewDuplicateMatchTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReviewDuplicateMatchTx", arg0, arg1)
+	ret0, _ := ret[0].(db.ReviewDuplicateMatchTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReviewDuplicateMatchTx indicates an expected call of ReviewDuplicateMatchTx.
+func (mr *MockStoreMockRecorder) ReviewDuplicateMatchTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReviewDuplicateMatchTx", reflect.TypeOf((*MockStore)(nil).ReviewDuplicateMatchTx), arg0, arg1)
+}
+
+// SetOrderPaymentIntent mocks base method.
+func (m *MockStore) SetOrderPaymentIntent(arg0 context.Context, arg1 db.SetOrderPaymentIntentParams) (db.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetOrderPaymentIntent", arg0, arg1)
+	ret0, _ := ret[0].(db.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetOrderPaymentIntent indicates an expected call of SetOrderPaymentIntent.
+func (mr *MockStoreMockRecorder) SetOrderPaymentIntent(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetOrderPaymentIntent", reflect.TypeOf((*MockStore)(nil).SetOrderPaymentIntent), arg0, arg1)
+}
+
+// StartFinalizingUpload mocks base method.
+func (m *MockStore) StartFinalizingUpload(arg0 context.Context, arg1 uuid.UUID) (db.Upload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartFinalizingUpload", arg0, arg1)
+	ret0, _ := ret[0].(db.Upload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StartFinalizingUpload indicates an expected call of StartFinalizingUpload.
+func (mr *MockStoreMockRecorder) StartFinalizingUpload(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartFinalizingUpload", reflect.TypeOf((*MockStore)(nil).StartFinalizingUpload), arg0, arg1)
+}
+
+// StopFinalizingUpload mocks base method.
+func (m *MockStore) StopFinalizingUpload(arg0 context.Context, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StopFinalizingUpload", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StopFinalizingUpload indicates an expected call of StopFinalizingUpload.
+func (mr *MockStoreMockRecorder) StopFinalizingUpload(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StopFinalizingUpload", reflect.TypeOf((*MockStore)(nil).StopFinalizingUpload), arg0, arg1)
+}
+
+// TransitionOrderTx mocks base method.
+func (m *MockStore) TransitionOrderTx(arg0 context.Context, arg1 db.TransitionOrderTxParams) (db.TransitionOrderTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TransitionOrderTx", arg0, arg1)
+	ret0, _ := ret[0].(db.TransitionOrderTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TransitionOrderTx indicates an expected call of TransitionOrderTx.
+func (mr *MockStoreMockRecorder) TransitionOrderTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransitionOrderTx", reflect.TypeOf((*MockStore)(nil).TransitionOrderTx), arg0, arg1)
+}
+
+// UpdateAPIKeyLastUsed mocks base method.
+func (m *MockStore) UpdateAPIKeyLastUsed(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAPIKeyLastUsed", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateAPIKeyLastUsed indicates an expected call of UpdateAPIKeyLastUsed.
+func (mr *MockStoreMockRecorder) UpdateAPIKeyLastUsed(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAPIKeyLastUsed", reflect.TypeOf((*MockStore)(nil).UpdateAPIKeyLastUsed), arg0, arg1)
+}
+
+// UpdateAudioFingerprint mocks base method.
+func (m *MockStore) UpdateAudioFingerprint(arg0 context.Context, arg1 db.UpdateAudioFingerprintParams) (db.AudioFingerprint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAudioFingerprint", arg0, arg1)
+	ret0, _ := ret[0].(db.AudioFingerprint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateAudioFingerprint indicates an expected call of UpdateAudioFingerprint.
+func (mr *MockStoreMockRecorder) UpdateAudioFingerprint(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAudioFingerprint", reflect.TypeOf((*MockStore)(nil).UpdateAudioFingerprint), arg0, arg1)
+}
+
+// UpdateBeat mocks base method.
+func (m *MockStore) UpdateBeat(arg0 context.Context, arg1 db.UpdateBeatParams) (db.Beat, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateBeat", arg0, arg1)
 	ret0, _ := ret[0].(db.Beat)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CreateBeat indicates an expected call of CreateBeat.
-func (mr *MockStoreMockRecorder) CreateBeat(arg0, arg1 interface{}) *gomock.Call {
+// UpdateBeat indicates an expected call of UpdateBeat.
+func (mr *MockStoreMockRecorder) UpdateBeat(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBeat", reflect.TypeOf((*MockStore)(nil).CreateBeat), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBeat", reflect.TypeOf((*MockStore)(nil).UpdateBeat), arg0, arg1)
 }
 
-// CreateLike mocks base method.
-func (m *MockStore) CreateLike(arg0 context.Context, arg1 db.CreateLikeParams) (db.Like, error) {
+// UpdateBeatAnalysis mocks base method.
+func (m *MockStore) UpdateBeatAnalysis(arg0 context.Context, arg1 db.UpdateBeatAnalysisParams) (db.Beat, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateLike", arg0, arg1)
-	ret0, _ := ret[0].(db.Like)
+	ret := m.ctrl.Call(m, "UpdateBeatAnalysis", arg0, arg1)
+	ret0, _ := ret[0].(db.Beat)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CreateLike indicates an expected call of CreateLike.
-func (mr *MockStoreMockRecorder) CreateLike(arg0, arg1 interface{}) *gomock.Call {
+// UpdateBeatAnalysis indicates an expected call of UpdateBeatAnalysis.
+func (mr *MockStoreMockRecorder) UpdateBeatAnalysis(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateLike", reflect.TypeOf((*MockStore)(nil).CreateLike), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBeatAnalysis", reflect.TypeOf((*MockStore)(nil).UpdateBeatAnalysis), arg0, arg1)
 }
 
-// CreateUser mocks base method.
-func (m *MockStore) CreateUser(arg0 context.Context, arg1 db.CreateUserParams) (db.User, error) {
+// UpdateBeatArtworkKey mocks base method.
+func (m *MockStore) UpdateBeatArtworkKey(arg0 context.Context, arg1 db.UpdateBeatArtworkKeyParams) (db.Beat, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateUser", arg0, arg1)
-	ret0, _ := ret[0].(db.User)
+	ret := m.ctrl.Call(m, "UpdateBeatArtworkKey", arg0, arg1)
+	ret0, _ := ret[0].(db.Beat)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CreateUser indicates an expected call of CreateUser.
-func (mr *MockStoreMockRecorder) CreateUser(arg0, arg1 interface{}) *gomock.Call {
+// UpdateBeatArtworkKey indicates an expected call of UpdateBeatArtworkKey.
+func (mr *MockStoreMockRecorder) UpdateBeatArtworkKey(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockStore)(nil).CreateUser), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBeatArtworkKey", reflect.TypeOf((*MockStore)(nil).UpdateBeatArtworkKey), arg0, arg1)
 }
 
-// DeleteBeat mocks base method.
-func (m *MockStore) DeleteBeat(arg0 context.Context, arg1 int32) error {
+// UpdateBeatAudioTx mocks base method.
+func (m *MockStore) UpdateBeatAudioTx(arg0 context.Context, arg1 db.UpdateBeatAudioTxParams) (db.UpdateBeatAudioTxResult, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteBeat", arg0, arg1)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "UpdateBeatAudioTx", arg0, arg1)
+	ret0, _ := ret[0].(db.UpdateBeatAudioTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// DeleteBeat indicates an expected call of DeleteBeat.
-func (mr *MockStoreMockRecorder) DeleteBeat(arg0, arg1 interface{}) *gomock.Call {
+// UpdateBeatAudioTx indicates an expected call of UpdateBeatAudioTx.
+func (mr *MockStoreMockRecorder) UpdateBeatAudioTx(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBeat", reflect.TypeOf((*MockStore)(nil).DeleteBeat), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBeatAudioTx", reflect.TypeOf((*MockStore)(nil).UpdateBeatAudioTx), arg0, arg1)
 }
 
-// DeleteLike mocks base method.
-func (m *MockStore) DeleteLike(arg0 context.Context, arg1 db.DeleteLikeParams) error {
+// UpdateBeatPreviewKey mocks base method.
+func (m *MockStore) UpdateBeatPreviewKey(arg0 context.Context, arg1 db.UpdateBeatPreviewKeyParams) (db.Beat, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteLike", arg0, arg1)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "UpdateBeatPreviewKey", arg0, arg1)
+	ret0, _ := ret[0].(db.Beat)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// DeleteLike indicates an expected call of DeleteLike.
-func (mr *MockStoreMockRecorder) DeleteLike(arg0, arg1 interface{}) *gomock.Call {
+// UpdateBeatPreviewKey indicates an expected call of UpdateBeatPreviewKey.
+func (mr *MockStoreMockRecorder) UpdateBeatPreviewKey(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteLike", reflect.TypeOf((*MockStore)(nil).DeleteLike), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBeatPreviewKey", reflect.TypeOf((*MockStore)(nil).UpdateBeatPreviewKey), arg0, arg1)
 }
 
-// DeleteUser mocks base method.
-func (m *MockStore) DeleteUser(arg0 context.Context, arg1 int32) error {
+// UpdateBeatPreviewTx mocks base method.
+func (m *MockStore) UpdateBeatPreviewTx(arg0 context.Context, arg1 db.UpdateBeatPreviewTxParams) (db.UpdateBeatPreviewTxResult, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteUser", arg0, arg1)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "UpdateBeatPreviewTx", arg0, arg1)
+	ret0, _ := ret[0].(db.UpdateBeatPreviewTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// DeleteUser indicates an expected call of DeleteUser.
-func (mr *MockStoreMockRecorder) DeleteUser(arg0, arg1 interface{}) *gomock.Call {
+// UpdateBeatPreviewTx indicates an expected call of UpdateBeatPreviewTx.
+func (mr *MockStoreMockRecorder) UpdateBeatPreviewTx(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockStore)(nil).DeleteUser), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBeatPreviewTx", reflect.TypeOf((*MockStore)(nil).UpdateBeatPreviewTx), arg0, arg1)
 }
 
-// GetBeatById mocks base method.
-func (m *MockStore) GetBeatById(arg0 context.Context, arg1 int32) (db.Beat, error) {
+// UpdateBeatS3Key mocks base method.
+func (m *MockStore) UpdateBeatS3Key(arg0 context.Context, arg1 db.UpdateBeatS3KeyParams) (db.Beat, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetBeatById", arg0, arg1)
+	ret := m.ctrl.Call(m, "UpdateBeatS3Key", arg0, arg1)
 	ret0, _ := ret[0].(db.Beat)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetBeatById indicates an expected call of GetBeatById.
-func (mr *MockStoreMockRecorder) GetBeatById(arg0, arg1 interface{}) *gomock.Call {
+// UpdateBeatS3Key indicates an expected call of UpdateBeatS3Key.
+func (mr *MockStoreMockRecorder) UpdateBeatS3Key(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBeatById", reflect.TypeOf((*MockStore)(nil).GetBeatById), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBeatS3Key", reflect.TypeOf((*MockStore)(nil).UpdateBeatS3Key), arg0, arg1)
 }
 
-// GetLikeByUserAndBeat mocks base method.
-func (m *MockStore) GetLikeByUserAndBeat(arg0 context.Context, arg1 db.GetLikeByUserAndBeatParams) (db.Like, error) {
+// UpdateLicense mocks base method.
+func (m *MockStore) UpdateLicense(arg0 context.Context, arg1 db.UpdateLicenseParams) (db.License, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetLikeByUserAndBeat", arg0, arg1)
-	ret0, _ := ret[0].(db.Like)
+	ret := m.ctrl.Call(m, "UpdateLicense", arg0, arg1)
+	ret0, _ := ret[0].(db.License)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetLikeByUserAndBeat indicates an expected call of GetLikeByUserAndBeat.
-func (mr *MockStoreMockRecorder) GetLikeByUserAndBeat(arg0, arg1 interface{}) *gomock.Call {
+// UpdateLicense indicates an expected call of UpdateLicense.
+func (mr *MockStoreMockRecorder) UpdateLicense(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLikeByUserAndBeat", reflect.TypeOf((*MockStore)(nil).GetLikeByUserAndBeat), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateLicense", reflect.TypeOf((*MockStore)(nil).UpdateLicense), arg0, arg1)
 }
 
-// GetUserById mocks base method.
-func (m *MockStore) GetUserById(arg0 context.Context, arg1 int32) (db.User, error) {
+// UpdateOrderStatus mocks base method.
+func (m *MockStore) UpdateOrderStatus(arg0 context.Context, arg1 db.UpdateOrderStatusParams) (db.Order, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserById", arg0, arg1)
+	ret := m.ctrl.Call(m, "UpdateOrderStatus", arg0, arg1)
+	ret0, _ := ret[0].(db.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateOrderStatus indicates an expected call of UpdateOrderStatus.
+func (mr *MockStoreMockRecorder) UpdateOrderStatus(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateOrderStatus", reflect.TypeOf((*MockStore)(nil).UpdateOrderStatus), arg0, arg1)
+}
+
+// UpdateUser mocks base method.
+func (m *MockStore) UpdateUser(arg0 context.Context, arg1 db.UpdateUserParams) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUser", arg0, arg1)
 	ret0, _ := ret[0].(db.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUserById indicates an expected call of GetUserById.
-func (mr *MockStoreMockRecorder) GetUserById(arg0, arg1 interface{}) *gomock.Call {
+// UpdateUser indicates an expected call of UpdateUser.
+func (mr *MockStoreMockRecorder) UpdateUser(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserById", reflect.TypeOf((*MockStore)(nil).GetUserById), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockStore)(nil).UpdateUser), arg0, arg1)
 }
 
-// GetUserByUsername mocks base method.
-func (m *MockStore) GetUserByUsername(arg0 context.Context, arg1 string) (db.User, error) {
+// UpdateUserCredentials mocks base method.
+func (m *MockStore) UpdateUserCredentials(arg0 context.Context, arg1 db.UpdateUserCredentialsParams) (db.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserByUsername", arg0, arg1)
+	ret := m.ctrl.Call(m, "UpdateUserCredentials", arg0, arg1)
 	ret0, _ := ret[0].(db.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUserByUsername indicates an expected call of GetUserByUsername.
-func (mr *MockStoreMockRecorder) GetUserByUsername(arg0, arg1 interface{}) *gomock.Call {
+// UpdateUserCredentials indicates an expected call of UpdateUserCredentials.
+func (mr *MockStoreMockRecorder) UpdateUserCredentials(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByUsername", reflect.TypeOf((*MockStore)(nil).GetUserByUsername), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserCredentials", reflect.TypeOf((*MockStore)(nil).UpdateUserCredentials), arg0, arg1)
 }
 
-// ListBeatsByBpmRange mocks base method.
-func (m *MockStore) ListBeatsByBpmRange(arg0 context.Context, arg1 db.ListBeatsByBpmRangeParams) ([]db.Beat, error) {
+// UpdateUserCredentialsTx mocks base method.
+func (m *MockStore) UpdateUserCredentialsTx(arg0 context.Context, arg1 db.UpdateUserCredentialsTxParams) (db.UpdateUserCredentialsTxResult, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListBeatsByBpmRange", arg0, arg1)
-	ret0, _ := ret[0].([]db.Beat)
+	ret := m.ctrl.Call(m, "UpdateUserCredentialsTx", arg0, arg1)
+	ret0, _ := ret[0].(db.UpdateUserCredentialsTxResult)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListBeatsByBpmRange indicates an expected call of ListBeatsByBpmRange.
-func (mr *MockStoreMockRecorder) ListBeatsByBpmRange(arg0, arg1 interface{}) *gomock.Call {
+// UpdateUserCredentialsTx indicates an expected call of UpdateUserCredentialsTx.
+func (mr *MockStoreMockRecorder) UpdateUserCredentialsTx(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBeatsByBpmRange", reflect.TypeOf((*MockStore)(nil).ListBeatsByBpmRange), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserCredentialsTx", reflect.TypeOf((*MockStore)(nil).UpdateUserCredentialsTx), arg0, arg1)
 }
 
-// ListBeatsByCreatorId mocks base method.
-func (m *MockStore) ListBeatsByCreatorId(arg0 context.Context, arg1 db.ListBeatsByCreatorIdParams) ([]db.Beat, error) {
+// UpdateUserHashedPassword mocks base method.
+func (m *MockStore) UpdateUserHashedPassword(arg0 context.Context, arg1 db.UpdateUserHashedPasswordParams) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListBeatsByCreatorId", arg0, arg1)
-	ret0, _ := ret[0].([]db.Beat)
+	ret := m.ctrl.Call(m, "UpdateUserHashedPassword", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateUserHashedPassword indicates an expected call of UpdateUserHashedPassword.
+func (mr *MockStoreMockRecorder) UpdateUserHashedPassword(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserHashedPassword", reflect.TypeOf((*MockStore)(nil).UpdateUserHashedPassword), arg0, arg1)
+}
+
+// UpdateUserRole mocks base method.
+func (m *MockStore) UpdateUserRole(arg0 context.Context, arg1 db.UpdateUserRoleParams) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserRole", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListBeatsByCreatorId indicates an expected call of ListBeatsByCreatorId.
-func (mr *MockStoreMockRecorder) ListBeatsByCreatorId(arg0, arg1 interface{}) *gomock.Call {
+// UpdateUserRole indicates an expected call of UpdateUserRole.
+func (mr *MockStoreMockRecorder) UpdateUserRole(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBeatsByCreatorId", reflect.TypeOf((*MockStore)(nil).ListBeatsByCreatorId), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserRole", reflect.TypeOf((*MockStore)(nil).UpdateUserRole), arg0, arg1)
 }
 
-// ListBeatsByCreatorIdAndBpmRange mocks base method.
-func (m *MockStore) ListBeatsByCreatorIdAndBpmRange(arg0 context.Context, arg1 db.ListBeatsByCreatorIdAndBpmRangeParams) ([]db.Beat, error) {
+// UpdateUserRoleTx mocks base method.
+func (m *MockStore) UpdateUserRoleTx(arg0 context.Context, arg1 db.UpdateUserRoleTxParams) (db.UpdateUserRoleTxResult, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListBeatsByCreatorIdAndBpmRange", arg0, arg1)
-	ret0, _ := ret[0].([]db.Beat)
+	ret := m.ctrl.Call(m, "UpdateUserRoleTx", arg0, arg1)
+	ret0, _ := ret[0].(db.UpdateUserRoleTxResult)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListBeatsByCreatorIdAndBpmRange indicates an expected call of ListBeatsByCreatorIdAndBpmRange.
-func (mr *MockStoreMockRecorder) ListBeatsByCreatorIdAndBpmRange(arg0, arg1 interface{}) *gomock.Call {
+// UpdateUserRoleTx indicates an expected call of UpdateUserRoleTx.
+func (mr *MockStoreMockRecorder) UpdateUserRoleTx(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBeatsByCreatorIdAndBpmRange", reflect.TypeOf((*MockStore)(nil).ListBeatsByCreatorIdAndBpmRange), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserRoleTx", reflect.TypeOf((*MockStore)(nil).UpdateUserRoleTx), arg0, arg1)
 }
 
-// ListBeatsByCreatorIdAndGenre mocks base method.
-func (m *MockStore) ListBeatsByCreatorIdAndGenre(arg0 context.Context, arg1 db.ListBeatsByCreatorIdAndGenreParams) ([]db.Beat, error) {
+// UpdateUserTOTPSecret mocks base method.
+func (m *MockStore) UpdateUserTOTPSecret(arg0 context.Context, arg1 db.UpdateUserTOTPSecretParams) (db.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListBeatsByCreatorIdAndGenre", arg0, arg1)
-	ret0, _ := ret[0].([]db.Beat)
+	ret := m.ctrl.Call(m, "UpdateUserTOTPSecret", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListBeatsByCreatorIdAndGenre indicates an expected call of ListBeatsByCreatorIdAndGenre.
-func (mr *MockStoreMockRecorder) ListBeatsByCreatorIdAndGenre(arg0, arg1 interface{}) *gomock.Call {
+// UpdateUserTOTPSecret indicates an expected call of UpdateUserTOTPSecret.
+func (mr *MockStoreMockRecorder) UpdateUserTOTPSecret(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBeatsByCreatorIdAndGenre", reflect.TypeOf((*MockStore)(nil).ListBeatsByCreatorIdAndGenre), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserTOTPSecret", reflect.TypeOf((*MockStore)(nil).UpdateUserTOTPSecret), arg0, arg1)
 }
 
-// ListBeatsByCreatorIdAndKey mocks base method.
-func (m *MockStore) ListBeatsByCreatorIdAndKey(arg0 context.Context, arg1 db.ListBeatsByCreatorIdAndKeyParams) ([]db.Beat, error) {
+// UpsertAudioFingerprint mocks base method.
+func (m *MockStore) UpsertAudioFingerprint(arg0 context.Context, arg1 db.UpsertAudioFingerprintParams) (db.AudioFingerprint, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListBeatsByCreatorIdAndKey", arg0, arg1)
-	ret0, _ := ret[0].([]db.Beat)
+	ret := m.ctrl.Call(m, "UpsertAudioFingerprint", arg0, arg1)
+	ret0, _ := ret[0].(db.AudioFingerprint)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListBeatsByCreatorIdAndKey indicates an expected call of ListBeatsByCreatorIdAndKey.
-func (mr *MockStoreMockRecorder) ListBeatsByCreatorIdAndKey(arg0, arg1 interface{}) *gomock.Call {
+// UpsertAudioFingerprint indicates an expected call of UpsertAudioFingerprint.
+func (mr *MockStoreMockRecorder) UpsertAudioFingerprint(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBeatsByCreatorIdAndKey", reflect.TypeOf((*MockStore)(nil).ListBeatsByCreatorIdAndKey), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertAudioFingerprint", reflect.TypeOf((*MockStore)(nil).UpsertAudioFingerprint), arg0, arg1)
 }
 
-// ListBeatsByGenre mocks base method.
-func (m *MockStore) ListBeatsByGenre(arg0 context.Context, arg1 db.ListBeatsByGenreParams) ([]db.Beat, error) {
+// UpsertBeatFile mocks base method.
+func (m *MockStore) UpsertBeatFile(arg0 context.Context, arg1 db.UpsertBeatFileParams) (db.BeatFile, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListBeatsByGenre", arg0, arg1)
-	ret0, _ := ret[0].([]db.Beat)
+	ret := m.ctrl.Call(m, "UpsertBeatFile", arg0, arg1)
+	ret0, _ := ret[0].(db.BeatFile)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListBeatsByGenre indicates an expected call of ListBeatsByGenre.
-func (mr *MockStoreMockRecorder) ListBeatsByGenre(arg0, arg1 interface{}) *gomock.Call {
+// UpsertBeatFile indicates an expected call of UpsertBeatFile.
+func (mr *MockStoreMockRecorder) UpsertBeatFile(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBeatsByGenre", reflect.TypeOf((*MockStore)(nil).ListBeatsByGenre), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertBeatFile", reflect.TypeOf((*MockStore)(nil).UpsertBeatFile), arg0, arg1)
 }
 
-// ListBeatsById mocks base method.
-func (m *MockStore) ListBeatsById(arg0 context.Context, arg1 db.ListBeatsByIdParams) ([]db.Beat, error) {
+// UpsertBeatWaveform mocks base method.
+func (m *MockStore) UpsertBeatWaveform(arg0 context.Context, arg1 db.UpsertBeatWaveformParams) (db.BeatWaveform, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListBeatsById", arg0, arg1)
-	ret0, _ := ret[0].([]db.Beat)
+	ret := m.ctrl.Call(m, "UpsertBeatWaveform", arg0, arg1)
+	ret0, _ := ret[0].(db.BeatWaveform)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListBeatsById indicates an expected call of ListBeatsById.
-func (mr *MockStoreMockRecorder) ListBeatsById(arg0, arg1 interface{}) *gomock.Call {
+// UpsertBeatWaveform indicates an expected call of UpsertBeatWaveform.
+func (mr *MockStoreMockRecorder) UpsertBeatWaveform(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBeatsById", reflect.TypeOf((*MockStore)(nil).ListBeatsById), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertBeatWaveform", reflect.TypeOf((*MockStore)(nil).UpsertBeatWaveform), arg0, arg1)
 }
 
-// ListBeatsByKey mocks base method.
-func (m *MockStore) ListBeatsByKey(arg0 context.Context, arg1 db.ListBeatsByKeyParams) ([]db.Beat, error) {
+// UpsertCartItem mocks base method.
+func (m *MockStore) UpsertCartItem(arg0 context.Context, arg1 db.UpsertCartItemParams) (db.CartItem, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListBeatsByKey", arg0, arg1)
-	ret0, _ := ret[0].([]db.Beat)
+	ret := m.ctrl.Call(m, "UpsertCartItem", arg0, arg1)
+	ret0, _ := ret[0].(db.CartItem)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListBeatsByKey indicates an expected call of ListBeatsByKey.
-func (mr *MockStoreMockRecorder) ListBeatsByKey(arg0, arg1 interface{}) *gomock.Call {
+// UpsertCartItem indicates an expected call of UpsertCartItem.
+func (mr *MockStoreMockRecorder) UpsertCartItem(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBeatsByKey", reflect.TypeOf((*MockStore)(nil).ListBeatsByKey), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertCartItem", reflect.TypeOf((*MockStore)(nil).UpsertCartItem), arg0, arg1)
 }
 
-// ListLikesByBeat mocks base method.
-func (m *MockStore) ListLikesByBeat(arg0 context.Context, arg1 db.ListLikesByBeatParams) ([]db.Like, error) {
+// UpsertUserCart mocks base method.
+func (m *MockStore) UpsertUserCart(arg0 context.Context, arg1 db.UpsertUserCartParams) (db.Cart, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListLikesByBeat", arg0, arg1)
-	ret0, _ := ret[0].([]db.Like)
+	ret := m.ctrl.Call(m, "UpsertUserCart", arg0, arg1)
+	ret0, _ := ret[0].(db.Cart)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListLikesByBeat indicates an expected call of ListLikesByBeat.
-func (mr *MockStoreMockRecorder) ListLikesByBeat(arg0, arg1 interface{}) *gomock.Call {
+// UpsertUserCart indicates an expected call of UpsertUserCart.
+func (mr *MockStoreMockRecorder) UpsertUserCart(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListLikesByBeat", reflect.TypeOf((*MockStore)(nil).ListLikesByBeat), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertUserCart", reflect.TypeOf((*MockStore)(nil).UpsertUserCart), arg0, arg1)
 }
 
-// ListLikesByUser mocks base method.
-func (m *MockStore) ListLikesByUser(arg0 context.Context, arg1 db.ListLikesByUserParams) ([]db.Like, error) {
+// UseEmailVerification mocks base method.
+func (m *MockStore) UseEmailVerification(arg0 context.Context, arg1 int64) (db.EmailVerification, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListLikesByUser", arg0, arg1)
-	ret0, _ := ret[0].([]db.Like)
+	ret := m.ctrl.Call(m, "UseEmailVerification", arg0, arg1)
+	ret0, _ := ret[0].(db.EmailVerification)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListLikesByUser indicates an expected call of ListLikesByUser.
-func (mr *MockStoreMockRecorder) ListLikesByUser(arg0, arg1 interface{}) *gomock.Call {
+// UseEmailVerification indicates an expected call of UseEmailVerification.
+func (mr *MockStoreMockRecorder) UseEmailVerification(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListLikesByUser", reflect.TypeOf((*MockStore)(nil).ListLikesByUser), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseEmailVerification", reflect.TypeOf((*MockStore)(nil).UseEmailVerification), arg0, arg1)
 }
 
-// ListUsers mocks base method.
-func (m *MockStore) ListUsers(arg0 context.Context, arg1 db.ListUsersParams) ([]db.User, error) {
+// UseMFAChallenge mocks base method.
+func (m *MockStore) UseMFAChallenge(arg0 context.Context, arg1 int64) (db.MfaChallenge, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListUsers", arg0, arg1)
-	ret0, _ := ret[0].([]db.User)
+	ret := m.ctrl.Call(m, "UseMFAChallenge", arg0, arg1)
+	ret0, _ := ret[0].(db.MfaChallenge)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListUsers indicates an expected call of ListUsers.
-func (mr *MockStoreMockRecorder) ListUsers(arg0, arg1 interface{}) *gomock.Call {
+// UseMFAChallenge indicates an expected call of UseMFAChallenge.
+func (mr *MockStoreMockRecorder) UseMFAChallenge(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockStore)(nil).ListUsers), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseMFAChallenge", reflect.TypeOf((*MockStore)(nil).UseMFAChallenge), arg0, arg1)
 }
 
-// UpdateBeat mocks base method.
-func (m *MockStore) UpdateBeat(arg0 context.Context, arg1 db.UpdateBeatParams) (db.Beat, error) {
+// UseMFARecoveryCode mocks base method.
+func (m *MockStore) UseMFARecoveryCode(arg0 context.Context, arg1 db.UseMFARecoveryCodeParams) (db.MfaRecoveryCode, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateBeat", arg0, arg1)
-	ret0, _ := ret[0].(db.Beat)
+	ret := m.ctrl.Call(m, "UseMFARecoveryCode", arg0, arg1)
+	ret0, _ := ret[0].(db.MfaRecoveryCode)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// UpdateBeat indicates an expected call of UpdateBeat.
-func (mr *MockStoreMockRecorder) UpdateBeat(arg0, arg1 interface{}) *gomock.Call {
+// UseMFARecoveryCode indicates an expected call of UseMFARecoveryCode.
+func (mr *MockStoreMockRecorder) UseMFARecoveryCode(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBeat", reflect.TypeOf((*MockStore)(nil).UpdateBeat), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseMFARecoveryCode", reflect.TypeOf((*MockStore)(nil).UseMFARecoveryCode), arg0, arg1)
 }
 
-// UpdateUser mocks base method.
-func (m *MockStore) UpdateUser(arg0 context.Context, arg1 db.UpdateUserParams) (db.User, error) {
+// UsePasswordReset mocks base method.
+func (m *MockStore) UsePasswordReset(arg0 context.Context, arg1 int64) (db.PasswordReset, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateUser", arg0, arg1)
+	ret := m.ctrl.Call(m, "UsePasswordReset", arg0, arg1)
+	ret0, _ := ret[0].(db.PasswordReset)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UsePasswordReset indicates an expected call of UsePasswordReset.
+func (mr *MockStoreMockRecorder) UsePasswordReset(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UsePasswordReset", reflect.TypeOf((*MockStore)(nil).UsePasswordReset), arg0, arg1)
+}
+
+// UseUserTOTPStep mocks base method.
+func (m *MockStore) UseUserTOTPStep(arg0 context.Context, arg1 db.UseUserTOTPStepParams) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseUserTOTPStep", arg0, arg1)
 	ret0, _ := ret[0].(db.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// UpdateUser indicates an expected call of UpdateUser.
-func (mr *MockStoreMockRecorder) UpdateUser(arg0, arg1 interface{}) *gomock.Call {
+// UseUserTOTPStep indicates an expected call of UseUserTOTPStep.
+func (mr *MockStoreMockRecorder) UseUserTOTPStep(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockStore)(nil).UpdateUser), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseUserTOTPStep", reflect.TypeOf((*MockStore)(nil).UseUserTOTPStep), arg0, arg1)
+}
+
+// VerifyEmailTx mocks base method.
+func (m *MockStore) VerifyEmailTx(arg0 context.Context, arg1 db.VerifyEmailTxParams) (db.VerifyEmailTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyEmailTx", arg0, arg1)
+	ret0, _ := ret[0].(db.VerifyEmailTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyEmailTx indicates an expected call of VerifyEmailTx.
+func (mr *MockStoreMockRecorder) VerifyEmailTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyEmailTx", reflect.TypeOf((*MockStore)(nil).VerifyEmailTx), arg0, arg1)
+}
+
+// VerifyUserEmail mocks base method.
+func (m *MockStore) VerifyUserEmail(arg0 context.Context, arg1 db.VerifyUserEmailParams) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyUserEmail", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyUserEmail indicates an expected call of VerifyUserEmail.
+func (mr *MockStoreMockRecorder) VerifyUserEmail(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyUserEmail", reflect.TypeOf((*MockStore)(nil).VerifyUserEmail), arg0, arg1)
 }
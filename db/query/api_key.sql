@@ -0,0 +1,37 @@
+-- name: CreateAPIKey :one
+INSERT INTO api_keys (
+    user_id,
+    name,
+    prefix,
+    hashed_key,
+    scopes,
+    expires_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+) RETURNING *;
+
+-- name: GetAPIKey :one
+SELECT * FROM api_keys
+WHERE id = $1
+LIMIT 1;
+
+-- name: GetAPIKeyByPrefix :one
+-- Also returns the owner's username and role, which are needed to authenticate requests.
+SELECT api_keys.*, users.username, users.role FROM api_keys
+JOIN users ON users.id = api_keys.user_id
+WHERE api_keys.prefix = $1
+LIMIT 1;
+
+-- name: ListAPIKeysByUser :many
+SELECT * FROM api_keys
+WHERE user_id = $1
+ORDER BY id;
+
+-- name: UpdateAPIKeyLastUsed :exec
+UPDATE api_keys
+SET last_used_at = now()
+WHERE id = $1;
+
+-- name: DeleteAPIKey :exec
+DELETE FROM api_keys
+WHERE id = $1;
@@ -0,0 +1,59 @@
+-- name: UpsertAudioFingerprint :one
+-- Records the content hash of a newly attached master, its fingerprint is computed later.
+-- Uploading the same content again keeps the time it was first uploaded.
+INSERT INTO audio_fingerprints (
+    beat_id,
+    s3_key,
+    content_hash
+) VALUES (
+    $1, $2, $3
+)
+ON CONFLICT (beat_id) DO UPDATE
+SET s3_key = EXCLUDED.s3_key,
+    content_hash = EXCLUDED.content_hash,
+    fingerprint = '',
+    hashes = '{}',
+    created_at = CASE
+        WHEN audio_fingerprints.content_hash = EXCLUDED.content_hash THEN audio_fingerprints.created_at
+        ELSE now()
+    END
+RETURNING *;
+
+-- name: GetAudioFingerprint :one
+SELECT * FROM audio_fingerprints
+WHERE beat_id = $1
+LIMIT 1;
+
+-- name: GetFirstAudioByContentHash :one
+-- Returns the beat the content was uploaded to first, along with its creator
+SELECT audio_fingerprints.beat_id, beats.creator_id FROM audio_fingerprints
+JOIN beats ON beats.id = audio_fingerprints.beat_id
+WHERE audio_fingerprints.content_hash = $1
+ORDER BY audio_fingerprints.created_at, audio_fingerprints.beat_id
+LIMIT 1;
+
+-- name: UpdateAudioFingerprint :one
+-- Stores the fingerprint computed from the master under s3_key.
+-- Returns sql.ErrNoRows if the beat got new audio in the meantime.
+UPDATE audio_fingerprints
+SET fingerprint = sqlc.arg(fingerprint),
+    hashes = sqlc.arg(hashes)::integer[]
+WHERE beat_id = sqlc.arg(beat_id) AND s3_key = sqlc.arg(s3_key)
+RETURNING *;
+
+-- name: ListFingerprintCandidates :many
+-- Lists the fingerprints sharing a sub-fingerprint with the given hashes
+-- which were uploaded to beats of other creators before the audio of the given beat.
+-- The ones sharing the most sub-fingerprints come first, so the limit cuts off the least likely matches.
+SELECT candidates.beat_id, candidates.fingerprint FROM audio_fingerprints candidates
+JOIN beats ON beats.id = candidates.beat_id
+WHERE candidates.hashes && sqlc.arg(hashes)::integer[]
+    AND beats.creator_id <> (SELECT uploaded.creator_id FROM beats uploaded WHERE uploaded.id = sqlc.arg(beat_id))
+    AND candidates.created_at < (SELECT current.created_at FROM audio_fingerprints current WHERE current.beat_id = sqlc.arg(beat_id))
+ORDER BY cardinality(ARRAY(
+        SELECT unnest(candidates.hashes)
+        INTERSECT
+        SELECT unnest(sqlc.arg(hashes)::integer[])
+    )) DESC,
+    candidates.created_at
+LIMIT sqlc.arg(max_candidates);
@@ -0,0 +1,16 @@
+-- name: CreateAuditLog :one
+INSERT INTO audit_logs (
+    actor_id,
+    action,
+    target_user_id,
+    details
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING *;
+
+-- name: ListAuditLogsByTargetUser :many
+SELECT * FROM audit_logs
+WHERE target_user_id = $1
+ORDER BY id DESC
+LIMIT $2
+OFFSET $3;
\ No newline at end of file
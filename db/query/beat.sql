@@ -16,6 +16,12 @@ SELECT * FROM beats
 WHERE id = $1
 LIMIT 1;
 
+-- name: GetBeatByIdForUpdate :one
+SELECT * FROM beats
+WHERE id = $1
+LIMIT 1
+FOR NO KEY UPDATE;
+
 -- name: ListBeatsById :many
 SELECT * FROM beats
 ORDER BY id
@@ -77,8 +83,50 @@ SET title = $2,
     genre = $3,
     key = $4,
     bpm = $5,
-    tags = $6,
-    s3_key = $7
+    tags = $6
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateBeatS3Key :one
+-- Points the beat at a newly uploaded audio object and the preview belonging to it,
+-- an empty preview key means the preview is still being generated.
+-- The analysis of the previous audio no longer applies and is reset.
+UPDATE beats
+SET s3_key = $2,
+    preview_key = $3,
+    detected_bpm = 0,
+    detected_bpm_confidence = 0,
+    detected_key = '',
+    detected_key_confidence = 0
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateBeatPreviewKey :one
+-- Returns sql.ErrNoRows if the audio the preview was generated from has been replaced since.
+UPDATE beats
+SET preview_key = sqlc.arg(preview_key)
+WHERE id = sqlc.arg(id) AND s3_key = sqlc.arg(s3_key)
+RETURNING *;
+
+-- name: UpdateBeatAnalysis :one
+-- Returns sql.ErrNoRows if the analysed audio has been replaced since.
+UPDATE beats
+SET detected_bpm = sqlc.arg(detected_bpm),
+    detected_bpm_confidence = sqlc.arg(detected_bpm_confidence),
+    detected_key = sqlc.arg(detected_key),
+    detected_key_confidence = sqlc.arg(detected_key_confidence)
+WHERE id = sqlc.arg(id) AND s3_key = sqlc.arg(s3_key)
+RETURNING *;
+
+-- name: UpdateBeatArtworkKey :one
+UPDATE beats
+SET artwork_key = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: IncrementBeatPlayCount :one
+UPDATE beats
+SET play_count = play_count + 1
 WHERE id = $1
 RETURNING *;
 
@@ -0,0 +1,39 @@
+-- name: UpsertBeatFile :one
+-- Attaches a file to a beat, replacing the file of the same type.
+INSERT INTO beat_files (
+    beat_id,
+    file_type,
+    s3_key,
+    content_type,
+    size,
+    checksum
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+)
+ON CONFLICT (beat_id, file_type) DO UPDATE
+SET s3_key = EXCLUDED.s3_key,
+    content_type = EXCLUDED.content_type,
+    size = EXCLUDED.size,
+    checksum = EXCLUDED.checksum,
+    created_at = now()
+RETURNING *;
+
+-- name: GetBeatFile :one
+SELECT * FROM beat_files
+WHERE beat_id = $1 AND file_type = $2
+LIMIT 1;
+
+-- name: ListBeatFiles :many
+SELECT * FROM beat_files
+WHERE beat_id = $1
+ORDER BY file_type;
+
+-- name: DeleteBeatFile :one
+DELETE FROM beat_files
+WHERE beat_id = $1 AND file_type = $2
+RETURNING *;
+
+-- name: DeleteBeatFilesByType :many
+DELETE FROM beat_files
+WHERE beat_id = sqlc.arg(beat_id) AND file_type = ANY(sqlc.arg(file_types)::varchar[])
+RETURNING *;
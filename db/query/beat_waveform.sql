@@ -0,0 +1,31 @@
+-- name: UpsertBeatWaveform :one
+-- Returns sql.ErrNoRows if the audio the waveform was computed from has been replaced since.
+INSERT INTO beat_waveforms (
+    beat_id,
+    s3_key,
+    resolution,
+    duration_ms,
+    peaks
+)
+SELECT
+    beats.id,
+    beats.s3_key,
+    sqlc.arg(resolution)::integer,
+    sqlc.arg(duration_ms)::bigint,
+    sqlc.arg(peaks)::bytea
+FROM beats
+WHERE beats.id = sqlc.arg(beat_id) AND beats.s3_key = sqlc.arg(s3_key)
+ON CONFLICT (beat_id) DO UPDATE
+SET s3_key = EXCLUDED.s3_key,
+    resolution = EXCLUDED.resolution,
+    duration_ms = EXCLUDED.duration_ms,
+    peaks = EXCLUDED.peaks,
+    created_at = now()
+RETURNING *;
+
+-- name: GetBeatWaveform :one
+-- Only returns the waveform if it belongs to the current audio of the beat.
+SELECT beat_waveforms.* FROM beat_waveforms
+JOIN beats ON beats.id = beat_waveforms.beat_id AND beats.s3_key = beat_waveforms.s3_key
+WHERE beat_waveforms.beat_id = $1
+LIMIT 1;
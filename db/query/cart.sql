@@ -0,0 +1,105 @@
+-- name: CreateCart :one
+INSERT INTO carts (
+    id,
+    user_id
+) VALUES (
+    $1, $2
+)
+RETURNING *;
+
+-- name: GetCart :one
+SELECT * FROM carts
+WHERE id = $1
+LIMIT 1;
+
+-- name: GetCartByUser :one
+SELECT * FROM carts
+WHERE user_id = $1
+LIMIT 1;
+
+-- name: UpsertUserCart :one
+-- Returns the cart of the user, creating it with the given ID if they don't have one yet
+INSERT INTO carts (
+    id,
+    user_id
+) VALUES (
+    $1, $2
+)
+ON CONFLICT (user_id) DO UPDATE
+SET user_id = EXCLUDED.user_id
+RETURNING *;
+
+-- name: DeleteCart :exec
+DELETE FROM carts
+WHERE id = $1;
+
+-- name: UpsertCartItem :one
+-- Adds a beat to a cart, a beat already in the cart gets the new license and price
+INSERT INTO cart_items (
+    cart_id,
+    beat_id,
+    license_id,
+    price,
+    currency
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+ON CONFLICT (cart_id, beat_id) DO UPDATE
+SET license_id = EXCLUDED.license_id,
+    price = EXCLUDED.price,
+    currency = EXCLUDED.currency,
+    created_at = now()
+RETURNING *;
+
+-- name: ListCartItems :many
+-- Lists the items of a cart in the order they were added, along with the beat and the current price of the license
+SELECT cart_items.*,
+    beats.title AS beat_title,
+    licenses.tier,
+    licenses.price AS current_price,
+    licenses.currency AS current_currency
+FROM cart_items
+JOIN beats ON beats.id = cart_items.beat_id
+JOIN licenses ON licenses.id = cart_items.license_id
+WHERE cart_items.cart_id = $1
+ORDER BY cart_items.created_at, cart_items.id;
+
+-- name: DeleteCartItem :one
+DELETE FROM cart_items
+WHERE id = $1 AND cart_id = $2
+RETURNING *;
+
+-- name: MoveCartItems :execrows
+-- Moves the items of one cart into another, the moved items replace those of the target cart for the same beat.
+-- Beats of the user owning the target cart are left behind, nobody buys their own beat.
+INSERT INTO cart_items (
+    cart_id,
+    beat_id,
+    license_id,
+    price,
+    currency
+)
+SELECT sqlc.arg(to_cart_id)::uuid,
+    moved.beat_id,
+    moved.license_id,
+    moved.price,
+    moved.currency
+FROM cart_items moved
+JOIN beats ON beats.id = moved.beat_id
+WHERE moved.cart_id = sqlc.arg(from_cart_id) AND beats.creator_id <> sqlc.arg(user_id)
+ON CONFLICT (cart_id, beat_id) DO UPDATE
+SET license_id = EXCLUDED.license_id,
+    price = EXCLUDED.price,
+    currency = EXCLUDED.currency,
+    created_at = now();
+
+-- name: DeleteOrderedCartItems :execrows
+-- Removes the licenses bought with an order from the cart of the buyer
+DELETE FROM cart_items
+USING carts
+WHERE carts.id = cart_items.cart_id
+    AND carts.user_id = sqlc.arg(buyer_id)::integer
+    AND cart_items.license_id IN (
+        SELECT license_id FROM order_items
+        WHERE order_id = sqlc.arg(order_id)
+    );
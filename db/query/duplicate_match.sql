@@ -0,0 +1,51 @@
+-- name: CreateDuplicateMatch :one
+-- Puts a match into the moderation queue. A beat matching the same beat again
+-- replaces the earlier match and awaits a new review.
+INSERT INTO duplicate_matches (
+    beat_id,
+    matched_beat_id,
+    kind,
+    bit_error_rate,
+    rejected
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+ON CONFLICT (beat_id, matched_beat_id) DO UPDATE
+SET kind = EXCLUDED.kind,
+    bit_error_rate = EXCLUDED.bit_error_rate,
+    rejected = EXCLUDED.rejected,
+    status = 'pending',
+    reviewed_by = NULL,
+    reviewed_at = NULL,
+    created_at = now()
+RETURNING *;
+
+-- name: GetDuplicateMatchForUpdate :one
+SELECT * FROM duplicate_matches
+WHERE id = $1
+LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: ListDuplicateMatches :many
+-- Lists the matches in the given status along with both beats, oldest first
+SELECT
+    duplicate_matches.*,
+    beats.title AS beat_title,
+    beats.creator_id AS beat_creator_id,
+    matched.title AS matched_beat_title,
+    matched.creator_id AS matched_beat_creator_id
+FROM duplicate_matches
+JOIN beats ON beats.id = duplicate_matches.beat_id
+JOIN beats matched ON matched.id = duplicate_matches.matched_beat_id
+WHERE duplicate_matches.status = $1
+ORDER BY duplicate_matches.id
+LIMIT $2
+OFFSET $3;
+
+-- name: ReviewDuplicateMatch :one
+UPDATE duplicate_matches
+SET status = $2,
+    reviewed_by = $3,
+    reviewed_at = now()
+WHERE id = $1
+RETURNING *;
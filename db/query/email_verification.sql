@@ -0,0 +1,20 @@
+-- name: CreateEmailVerification :one
+INSERT INTO email_verifications (
+    user_id,
+    email,
+    hashed_token,
+    expires_at
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING *;
+
+-- name: GetEmailVerificationByHashedToken :one
+SELECT * FROM email_verifications
+WHERE hashed_token = $1
+LIMIT 1;
+
+-- name: UseEmailVerification :one
+UPDATE email_verifications
+SET used_at = now()
+WHERE id = $1 AND used_at IS NULL
+RETURNING *;
\ No newline at end of file
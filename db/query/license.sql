@@ -0,0 +1,80 @@
+-- name: CreateLicense :one
+INSERT INTO licenses (
+    beat_id,
+    tier,
+    price,
+    currency,
+    file_types,
+    distribution_cap,
+    stream_cap,
+    terms
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+RETURNING *;
+
+-- name: GetLicense :one
+SELECT * FROM licenses
+WHERE id = $1
+LIMIT 1;
+
+-- name: GetLicenseForUpdate :one
+SELECT * FROM licenses
+WHERE id = $1
+LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: ReserveLicense :exec
+-- Holds an exclusive license for an order until it is paid or fails
+UPDATE licenses
+SET reserved_order_id = sqlc.arg(order_id)::bigint
+WHERE id = sqlc.arg(id);
+
+-- name: ReleaseOrderedLicenses :exec
+-- Frees the licenses reserved for a failed order
+UPDATE licenses
+SET reserved_order_id = NULL
+WHERE reserved_order_id = sqlc.arg(order_id)::bigint;
+
+-- name: CountOrderedExclusives :one
+SELECT count(*) FROM order_items
+JOIN licenses ON licenses.id = order_items.license_id
+WHERE order_items.order_id = $1
+    AND licenses.tier = 'exclusive';
+
+-- name: MarkOrderedExclusivesSold :execrows
+-- Marks the exclusive licenses bought with an order as sold,
+-- licenses which were sold or reserved for another order are left alone
+UPDATE licenses
+SET sold_at = now()
+WHERE tier = 'exclusive'
+    AND sold_at IS NULL
+    AND (reserved_order_id IS NULL OR reserved_order_id = sqlc.arg(order_id)::bigint)
+    AND id IN (
+        SELECT license_id FROM order_items
+        WHERE order_id = sqlc.arg(order_id)
+    );
+
+-- name: ListLicensesByBeat :many
+-- Lists the license tiers of a beat, cheapest first
+SELECT * FROM licenses
+WHERE beat_id = $1
+ORDER BY price, id;
+
+-- name: UpdateLicense :one
+-- Sold licenses keep the terms they were bought under
+UPDATE licenses
+SET tier = $2,
+    price = $3,
+    currency = $4,
+    file_types = $5,
+    distribution_cap = $6,
+    stream_cap = $7,
+    terms = $8,
+    updated_at = now()
+WHERE id = $1 AND sold_at IS NULL
+RETURNING *;
+
+-- name: DeleteLicense :exec
+DELETE FROM licenses
+WHERE id = $1;
@@ -0,0 +1,16 @@
+-- name: CreateLoginEvent :one
+INSERT INTO login_events (
+    username,
+    client_ip,
+    event,
+    actor_id
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING *;
+
+-- name: ListLoginEventsByUsername :many
+SELECT * FROM login_events
+WHERE username = $1
+ORDER BY id DESC
+LIMIT $2
+OFFSET $3;
@@ -0,0 +1,25 @@
+-- name: GetLoginThrottle :one
+SELECT * FROM login_throttles
+WHERE key = $1
+LIMIT 1;
+
+-- name: RecordLoginFailure :one
+-- Failures recorded before window_start are forgotten and counting starts over.
+INSERT INTO login_throttles (
+    key,
+    failures,
+    last_failure_at
+) VALUES (
+    sqlc.arg(key), 1, sqlc.arg(failed_at)
+)
+ON CONFLICT (key) DO UPDATE
+SET failures = CASE
+        WHEN login_throttles.last_failure_at < sqlc.arg(window_start) THEN 1
+        ELSE login_throttles.failures + 1
+    END,
+    last_failure_at = EXCLUDED.last_failure_at
+RETURNING *;
+
+-- name: DeleteLoginThrottle :exec
+DELETE FROM login_throttles
+WHERE key = $1;
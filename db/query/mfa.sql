@@ -0,0 +1,45 @@
+-- name: CreateMFARecoveryCode :one
+INSERT INTO mfa_recovery_codes (
+    user_id,
+    hashed_code
+) VALUES (
+    $1, $2
+) RETURNING *;
+
+-- name: UseMFARecoveryCode :one
+UPDATE mfa_recovery_codes
+SET used_at = now()
+WHERE user_id = $1 AND hashed_code = $2 AND used_at IS NULL
+RETURNING *;
+
+-- name: DeleteMFARecoveryCodesByUser :exec
+DELETE FROM mfa_recovery_codes
+WHERE user_id = $1;
+
+-- name: CreateMFAChallenge :one
+INSERT INTO mfa_challenges (
+    user_id,
+    hashed_token,
+    expires_at
+) VALUES (
+    $1, $2, $3
+) RETURNING *;
+
+-- name: GetMFAChallengeByHashedToken :one
+SELECT * FROM mfa_challenges
+WHERE hashed_token = $1
+LIMIT 1;
+
+-- name: IncrementMFAChallengeAttempts :one
+-- Counts an answer to a challenge before it is checked, concurrent answers can't exceed max_attempts.
+-- Returns sql.ErrNoRows once the challenge was used or has no attempts left.
+UPDATE mfa_challenges
+SET attempts = attempts + 1
+WHERE id = sqlc.arg(id) AND attempts < sqlc.arg(max_attempts) AND used_at IS NULL
+RETURNING *;
+
+-- name: UseMFAChallenge :one
+UPDATE mfa_challenges
+SET used_at = now()
+WHERE id = $1 AND used_at IS NULL
+RETURNING *;
@@ -0,0 +1,60 @@
+-- name: CreateOrder :one
+INSERT INTO orders (
+    buyer_id,
+    total,
+    currency
+) VALUES (
+    $1, $2, $3
+)
+RETURNING *;
+
+-- name: GetOrder :one
+SELECT * FROM orders
+WHERE id = $1
+LIMIT 1;
+
+-- name: GetOrderForUpdate :one
+SELECT * FROM orders
+WHERE id = $1
+LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: SetOrderPaymentIntent :one
+UPDATE orders
+SET payment_intent_id = $2,
+    updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateOrderStatus :one
+UPDATE orders
+SET status = $2,
+    failure_reason = $3,
+    updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: CreateOrderItem :one
+INSERT INTO order_items (
+    order_id,
+    beat_id,
+    license_id,
+    beat_title,
+    tier,
+    price,
+    currency
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+RETURNING *;
+
+-- name: ListOrderItems :many
+SELECT * FROM order_items
+WHERE order_id = $1
+ORDER BY id;
+
+-- name: GetOrderByPaymentIntentForUpdate :one
+SELECT * FROM orders
+WHERE payment_intent_id = $1
+LIMIT 1
+FOR NO KEY UPDATE;
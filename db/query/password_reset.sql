@@ -0,0 +1,19 @@
+-- name: CreatePasswordReset :one
+INSERT INTO password_resets (
+    user_id,
+    hashed_token,
+    expires_at
+) VALUES (
+    $1, $2, $3
+) RETURNING *;
+
+-- name: GetPasswordResetByHashedToken :one
+SELECT * FROM password_resets
+WHERE hashed_token = $1
+LIMIT 1;
+
+-- name: UsePasswordReset :one
+UPDATE password_resets
+SET used_at = now()
+WHERE id = $1 AND used_at IS NULL
+RETURNING *;
@@ -0,0 +1,24 @@
+-- name: AddStreamedBytes :one
+-- Adds the bytes sent by one response to the total of the listening session.
+INSERT INTO stream_sessions (
+    beat_id,
+    session_id,
+    bytes_streamed
+) VALUES (
+    $1, $2, $3
+)
+ON CONFLICT (beat_id, session_id) DO UPDATE
+SET bytes_streamed = stream_sessions.bytes_streamed + EXCLUDED.bytes_streamed
+RETURNING *;
+
+-- name: GetStreamSession :one
+SELECT * FROM stream_sessions
+WHERE beat_id = $1 AND session_id = $2
+LIMIT 1;
+
+-- name: MarkStreamSessionPlayed :one
+-- Returns sql.ErrNoRows if the play of the session was already counted.
+UPDATE stream_sessions
+SET played_at = now()
+WHERE beat_id = $1 AND session_id = $2 AND played_at IS NULL
+RETURNING *;
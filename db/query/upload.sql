@@ -0,0 +1,55 @@
+-- name: CreateUpload :one
+INSERT INTO uploads (
+    id,
+    user_id,
+    beat_id,
+    file_type,
+    filename,
+    size,
+    checksum
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+RETURNING *;
+
+-- name: GetUpload :one
+SELECT * FROM uploads
+WHERE id = $1
+LIMIT 1;
+
+-- name: AdvanceUpload :one
+-- Counts a chunk received at chunk_offset. Returns sql.ErrNoRows if another chunk got there first,
+-- the chunk overruns the announced size or the upload is being finalized.
+UPDATE uploads
+SET received = received + sqlc.arg(length)::bigint,
+    updated_at = now()
+WHERE id = sqlc.arg(id)
+    AND received = sqlc.arg(chunk_offset)::bigint
+    AND received + sqlc.arg(length)::bigint <= size
+    AND NOT finalizing
+RETURNING *;
+
+-- name: StartFinalizingUpload :one
+-- Returns sql.ErrNoRows unless the upload is complete and not already being finalized.
+UPDATE uploads
+SET finalizing = true,
+    updated_at = now()
+WHERE id = $1 AND received = size AND NOT finalizing
+RETURNING *;
+
+-- name: StopFinalizingUpload :exec
+-- Lets the client retry a finalization which failed.
+UPDATE uploads
+SET finalizing = false,
+    updated_at = now()
+WHERE id = $1;
+
+-- name: ListStaleUploads :many
+SELECT * FROM uploads
+WHERE updated_at < $1
+ORDER BY updated_at
+LIMIT $2;
+
+-- name: DeleteUpload :exec
+DELETE FROM uploads
+WHERE id = $1;
@@ -1,10 +1,11 @@
 -- name: CreateUser :one
 INSERT INTO users (
     username, 
-    password, 
-    email
+    hashed_password, 
+    email,
+    role
 ) VALUES (
-    $1, $2, $3
+    $1, $2, $3, $4
 ) RETURNING *;
 
 -- name: GetUserById :one
@@ -12,11 +13,22 @@ SELECT * FROM users
 WHERE id = $1
 LIMIT 1;
 
+-- name: GetUserByIdForUpdate :one
+SELECT * FROM users
+WHERE id = $1
+LIMIT 1
+FOR NO KEY UPDATE;
+
 -- name: GetUserByUsername :one
 SELECT * FROM users
 WHERE username = $1
 LIMIT 1;
 
+-- name: GetUserByEmail :one
+SELECT * FROM users
+WHERE email = $1
+LIMIT 1;
+
 -- name: ListUsers :many
 SELECT * FROM users
 ORDER BY id
@@ -24,13 +36,67 @@ LIMIT $1
 OFFSET $2;
 
 -- name: UpdateUser :one
+-- Changes the profile of a user, the credentials are changed through UpdateUserCredentials.
+UPDATE users
+SET username = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateUserCredentials :one
+-- Changing the email address requires it to be verified again.
+UPDATE users
+SET hashed_password = $2,
+    email = $3,
+    password_changed_at = CASE WHEN hashed_password = $2 THEN password_changed_at ELSE now() END,
+    verified_at = CASE WHEN email = $3 THEN verified_at ELSE NULL END
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateUserHashedPassword :exec
+UPDATE users
+SET hashed_password = $2
+WHERE id = $1;
+
+-- name: ResetUserPassword :one
 UPDATE users
-SET username = $2,
-    password = $3,
-    email = $4
+SET hashed_password = $2,
+    password_changed_at = now()
 WHERE id = $1
 RETURNING *;
 
+-- name: UpdateUserRole :one
+UPDATE users
+SET role = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: VerifyUserEmail :one
+UPDATE users
+SET verified_at = now()
+WHERE id = $1 AND email = $2
+RETURNING *;
+
+-- name: UpdateUserTOTPSecret :one
+-- The secret of an enabled second factor cannot be replaced.
+UPDATE users
+SET totp_secret = $2
+WHERE id = $1 AND totp_enabled_at IS NULL
+RETURNING *;
+
+-- name: UseUserTOTPStep :one
+-- Records the time step of an accepted TOTP code.
+-- Returns sql.ErrNoRows if a code of the same or a later step was accepted before.
+UPDATE users
+SET totp_last_step = sqlc.arg(step)
+WHERE id = sqlc.arg(id) AND totp_last_step < sqlc.arg(step)
+RETURNING *;
+
+-- name: EnableUserTOTP :one
+UPDATE users
+SET totp_enabled_at = now()
+WHERE id = $1 AND totp_secret IS NOT NULL AND totp_enabled_at IS NULL
+RETURNING *;
+
 -- name: DeleteUser :exec
 DELETE FROM users
 WHERE id = $1;
\ No newline at end of file
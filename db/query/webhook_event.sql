@@ -0,0 +1,50 @@
+-- name: RecordWebhookEvent :one
+-- Records a received event. A redelivered event returns the row recorded first,
+-- its payload and status are left as they are.
+INSERT INTO webhook_events (
+    id,
+    type,
+    payload
+) VALUES (
+    $1, $2, $3
+)
+ON CONFLICT (id) DO UPDATE
+SET id = EXCLUDED.id
+RETURNING *;
+
+-- name: GetWebhookEvent :one
+SELECT * FROM webhook_events
+WHERE id = $1
+LIMIT 1;
+
+-- name: GetWebhookEventForUpdate :one
+SELECT * FROM webhook_events
+WHERE id = $1
+LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: ListWebhookEvents :many
+-- Lists the events in the given status, oldest first
+SELECT * FROM webhook_events
+WHERE status = $1
+ORDER BY received_at, id
+LIMIT $2
+OFFSET $3;
+
+-- name: MarkWebhookEventProcessed :one
+UPDATE webhook_events
+SET status = $2,
+    order_id = $3,
+    attempts = attempts + 1,
+    last_error = '',
+    processed_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: MarkWebhookEventFailed :one
+UPDATE webhook_events
+SET status = 'failed',
+    attempts = attempts + 1,
+    last_error = $2
+WHERE id = $1
+RETURNING *;
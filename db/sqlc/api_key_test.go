@@ -0,0 +1,115 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomAPIKey(t *testing.T, user User) ApiKey {
+	arg := CreateAPIKeyParams{
+		UserID:    user.ID,
+		Name:      util.RandomString(8),
+		Prefix:    "bsk_" + util.RandomString(12),
+		HashedKey: util.HashSecretToken(util.RandomString(32)),
+		Scopes:    []string{util.BeatsWriteScope, util.LikesWriteScope},
+		ExpiresAt: sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true},
+	}
+
+	key, err := testQueries.CreateAPIKey(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, key)
+
+	require.NotZero(t, key.ID)
+	require.Equal(t, arg.UserID, key.UserID)
+	require.Equal(t, arg.Name, key.Name)
+	require.Equal(t, arg.Prefix, key.Prefix)
+	require.Equal(t, arg.HashedKey, key.HashedKey)
+	require.Equal(t, arg.Scopes, key.Scopes)
+	require.WithinDuration(t, arg.ExpiresAt.Time, key.ExpiresAt.Time, time.Second)
+	require.False(t, key.LastUsedAt.Valid)
+	require.NotZero(t, key.CreatedAt)
+
+	return key
+}
+
+func TestCreateAPIKey(t *testing.T) {
+	user := createRandomUser(t)
+	createRandomAPIKey(t, user)
+	deleteRandomUser(t, user.ID)
+}
+
+func TestGetAPIKey(t *testing.T) {
+	user := createRandomUser(t)
+	key1 := createRandomAPIKey(t, user)
+
+	key2, err := testQueries.GetAPIKey(context.Background(), key1.ID)
+	require.NoError(t, err)
+	require.Equal(t, key1.ID, key2.ID)
+	require.Equal(t, key1.Prefix, key2.Prefix)
+	require.Equal(t, key1.Scopes, key2.Scopes)
+
+	deleteRandomUser(t, user.ID)
+}
+
+func TestGetAPIKeyByPrefix(t *testing.T) {
+	user := createRandomUser(t)
+	key := createRandomAPIKey(t, user)
+
+	row, err := testQueries.GetAPIKeyByPrefix(context.Background(), key.Prefix)
+	require.NoError(t, err)
+	require.Equal(t, key.ID, row.ID)
+	require.Equal(t, key.HashedKey, row.HashedKey)
+	require.Equal(t, user.Username, row.Username)
+	require.Equal(t, user.Role, row.Role)
+
+	deleteRandomUser(t, user.ID)
+}
+
+func TestListAPIKeysByUser(t *testing.T) {
+	user := createRandomUser(t)
+	for i := 0; i < 3; i++ {
+		createRandomAPIKey(t, user)
+	}
+
+	keys, err := testQueries.ListAPIKeysByUser(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.Len(t, keys, 3)
+	for _, key := range keys {
+		require.Equal(t, user.ID, key.UserID)
+	}
+
+	deleteRandomUser(t, user.ID)
+}
+
+func TestUpdateAPIKeyLastUsed(t *testing.T) {
+	user := createRandomUser(t)
+	key1 := createRandomAPIKey(t, user)
+
+	err := testQueries.UpdateAPIKeyLastUsed(context.Background(), key1.ID)
+	require.NoError(t, err)
+
+	key2, err := testQueries.GetAPIKey(context.Background(), key1.ID)
+	require.NoError(t, err)
+	require.True(t, key2.LastUsedAt.Valid)
+	require.WithinDuration(t, time.Now(), key2.LastUsedAt.Time, time.Second)
+
+	deleteRandomUser(t, user.ID)
+}
+
+func TestDeleteAPIKey(t *testing.T) {
+	user := createRandomUser(t)
+	key := createRandomAPIKey(t, user)
+
+	err := testQueries.DeleteAPIKey(context.Background(), key.ID)
+	require.NoError(t, err)
+
+	_, err = testQueries.GetAPIKey(context.Background(), key.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	deleteRandomUser(t, user.ID)
+}
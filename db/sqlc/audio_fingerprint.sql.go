@@ -0,0 +1,181 @@
+// Code generated by sqlc. DO NOT EDIT.
+// source: audio_fingerprint.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/lib/pq"
+)
+
+const getAudioFingerprint = `-- name: GetAudioFingerprint :one
+SELECT beat_id, s3_key, content_hash, fingerprint, hashes, created_at FROM audio_fingerprints
+WHERE beat_id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetAudioFingerprint(ctx context.Context, beatID int32) (AudioFingerprint, error) {
+	row := q.db.QueryRowContext(ctx, getAudioFingerprint, beatID)
+	var i AudioFingerprint
+	err := row.Scan(
+		&i.BeatID,
+		&i.S3Key,
+		&i.ContentHash,
+		&i.Fingerprint,
+		pq.Array(&i.Hashes),
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getFirstAudioByContentHash = `-- name: GetFirstAudioByContentHash :one
+SELECT audio_fingerprints.beat_id, beats.creator_id FROM audio_fingerprints
+JOIN beats ON beats.id = audio_fingerprints.beat_id
+WHERE audio_fingerprints.content_hash = $1
+ORDER BY audio_fingerprints.created_at, audio_fingerprints.beat_id
+LIMIT 1
+`
+
+type GetFirstAudioByContentHashRow struct {
+	BeatID    int32 `json:"beat_id"`
+	CreatorID int32 `json:"creator_id"`
+}
+
+// Returns the beat the content was uploaded to first, along with its creator
+func (q *Queries) GetFirstAudioByContentHash(ctx context.Context, contentHash string) (GetFirstAudioByContentHashRow, error) {
+	row := q.db.QueryRowContext(ctx, getFirstAudioByContentHash, contentHash)
+	var i GetFirstAudioByContentHashRow
+	err := row.Scan(&i.BeatID, &i.CreatorID)
+	return i, err
+}
+
+const listFingerprintCandidates = `-- name: ListFingerprintCandidates :many
+SELECT candidates.beat_id, candidates.fingerprint FROM audio_fingerprints candidates
+JOIN beats ON beats.id = candidates.beat_id
+WHERE candidates.hashes && $1::integer[]
+    AND beats.creator_id <> (SELECT uploaded.creator_id FROM beats uploaded WHERE uploaded.id = $2)
+    AND candidates.created_at < (SELECT current.created_at FROM audio_fingerprints current WHERE current.beat_id = $2)
+ORDER BY cardinality(ARRAY(
+        SELECT unnest(candidates.hashes)
+        INTERSECT
+        SELECT unnest($1::integer[])
+    )) DESC,
+    candidates.created_at
+LIMIT $3
+`
+
+type ListFingerprintCandidatesParams struct {
+	Hashes        []int32 `json:"hashes"`
+	BeatID        int32   `json:"beat_id"`
+	MaxCandidates int32   `json:"max_candidates"`
+}
+
+type ListFingerprintCandidatesRow struct {
+	BeatID      int32  `json:"beat_id"`
+	Fingerprint []byte `json:"fingerprint"`
+}
+
+// Lists the fingerprints sharing a sub-fingerprint with the given hashes
+// which were uploaded to beats of other creators before the audio of the given beat.
+// The ones sharing the most sub-fingerprints come first, so the limit cuts off the least likely matches.
+func (q *Queries) ListFingerprintCandidates(ctx context.Context, arg ListFingerprintCandidatesParams) ([]ListFingerprintCandidatesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listFingerprintCandidates, pq.Array(arg.Hashes), arg.BeatID, arg.MaxCandidates)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListFingerprintCandidatesRow{}
+	for rows.Next() {
+		var i ListFingerprintCandidatesRow
+		if err := rows.Scan(&i.BeatID, &i.Fingerprint); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateAudioFingerprint = `-- name: UpdateAudioFingerprint :one
+UPDATE audio_fingerprints
+SET fingerprint = $1,
+    hashes = $2::integer[]
+WHERE beat_id = $3 AND s3_key = $4
+RETURNING beat_id, s3_key, content_hash, fingerprint, hashes, created_at
+`
+
+type UpdateAudioFingerprintParams struct {
+	Fingerprint []byte  `json:"fingerprint"`
+	Hashes      []int32 `json:"hashes"`
+	BeatID      int32   `json:"beat_id"`
+	S3Key       string  `json:"s3_key"`
+}
+
+// Stores the fingerprint computed from the master under s3_key.
+// Returns sql.ErrNoRows if the beat got new audio in the meantime.
+func (q *Queries) UpdateAudioFingerprint(ctx context.Context, arg UpdateAudioFingerprintParams) (AudioFingerprint, error) {
+	row := q.db.QueryRowContext(ctx, updateAudioFingerprint,
+		arg.Fingerprint,
+		pq.Array(arg.Hashes),
+		arg.BeatID,
+		arg.S3Key,
+	)
+	var i AudioFingerprint
+	err := row.Scan(
+		&i.BeatID,
+		&i.S3Key,
+		&i.ContentHash,
+		&i.Fingerprint,
+		pq.Array(&i.Hashes),
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const upsertAudioFingerprint = `-- name: UpsertAudioFingerprint :one
+INSERT INTO audio_fingerprints (
+    beat_id,
+    s3_key,
+    content_hash
+) VALUES (
+    $1, $2, $3
+)
+ON CONFLICT (beat_id) DO UPDATE
+SET s3_key = EXCLUDED.s3_key,
+    content_hash = EXCLUDED.content_hash,
+    fingerprint = '',
+    hashes = '{}',
+    created_at = CASE
+        WHEN audio_fingerprints.content_hash = EXCLUDED.content_hash THEN audio_fingerprints.created_at
+        ELSE now()
+    END
+RETURNING beat_id, s3_key, content_hash, fingerprint, hashes, created_at
+`
+
+type UpsertAudioFingerprintParams struct {
+	BeatID      int32  `json:"beat_id"`
+	S3Key       string `json:"s3_key"`
+	ContentHash string `json:"content_hash"`
+}
+
+// Records the content hash of a newly attached master, its fingerprint is computed later.
+// Uploading the same content again keeps the time it was first uploaded.
+func (q *Queries) UpsertAudioFingerprint(ctx context.Context, arg UpsertAudioFingerprintParams) (AudioFingerprint, error) {
+	row := q.db.QueryRowContext(ctx, upsertAudioFingerprint, arg.BeatID, arg.S3Key, arg.ContentHash)
+	var i AudioFingerprint
+	err := row.Scan(
+		&i.BeatID,
+		&i.S3Key,
+		&i.ContentHash,
+		&i.Fingerprint,
+		pq.Array(&i.Hashes),
+		&i.CreatedAt,
+	)
+	return i, err
+}
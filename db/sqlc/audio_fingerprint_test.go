@@ -0,0 +1,176 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/stretchr/testify/require"
+)
+
+func upsertRandomAudioFingerprint(t *testing.T, beat Beat, contentHash string) AudioFingerprint {
+	arg := UpsertAudioFingerprintParams{
+		BeatID:      beat.ID,
+		S3Key:       util.RandomS3Key(),
+		ContentHash: contentHash,
+	}
+
+	fingerprint, err := testQueries.UpsertAudioFingerprint(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.BeatID, fingerprint.BeatID)
+	require.Equal(t, arg.S3Key, fingerprint.S3Key)
+	require.Equal(t, arg.ContentHash, fingerprint.ContentHash)
+	require.Empty(t, fingerprint.Fingerprint)
+	require.Empty(t, fingerprint.Hashes)
+	require.NotZero(t, fingerprint.CreatedAt)
+
+	return fingerprint
+}
+
+func TestUpsertAudioFingerprint(t *testing.T) {
+	beat := createRandomBeat(t)
+	contentHash := util.RandomString(64)
+	fingerprint1 := upsertRandomAudioFingerprint(t, beat, contentHash)
+
+	_, err := testQueries.UpdateAudioFingerprint(context.Background(), UpdateAudioFingerprintParams{
+		BeatID:      beat.ID,
+		S3Key:       fingerprint1.S3Key,
+		Fingerprint: []byte{1, 2, 3, 4},
+		Hashes:      []int32{0x01020304},
+	})
+	require.NoError(t, err)
+
+	// The same content uploaded again keeps its upload time, the fingerprint is computed again
+	time.Sleep(10 * time.Millisecond)
+	fingerprint2 := upsertRandomAudioFingerprint(t, beat, contentHash)
+	require.NotEqual(t, fingerprint1.S3Key, fingerprint2.S3Key)
+	require.WithinDuration(t, fingerprint1.CreatedAt, fingerprint2.CreatedAt, time.Microsecond)
+
+	// New content counts as uploaded now
+	fingerprint3 := upsertRandomAudioFingerprint(t, beat, util.RandomString(64))
+	require.True(t, fingerprint3.CreatedAt.After(fingerprint1.CreatedAt))
+
+	fingerprint4, err := testQueries.GetAudioFingerprint(context.Background(), beat.ID)
+	require.NoError(t, err)
+	require.Equal(t, fingerprint3.S3Key, fingerprint4.S3Key)
+	require.Equal(t, fingerprint3.ContentHash, fingerprint4.ContentHash)
+
+	deleteRandomBeat(t, beat.ID)
+	deleteRandomUser(t, beat.CreatorID)
+
+	// Fingerprints are deleted together with their beat
+	_, err = testQueries.GetAudioFingerprint(context.Background(), beat.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestGetFirstAudioByContentHash(t *testing.T) {
+	beat1 := createRandomBeat(t)
+	beat2 := createRandomBeat(t)
+	contentHash := util.RandomString(64)
+	upsertRandomAudioFingerprint(t, beat1, contentHash)
+	upsertRandomAudioFingerprint(t, beat2, contentHash)
+
+	first, err := testQueries.GetFirstAudioByContentHash(context.Background(), contentHash)
+	require.NoError(t, err)
+	require.Equal(t, beat1.ID, first.BeatID)
+	require.Equal(t, beat1.CreatorID, first.CreatorID)
+
+	_, err = testQueries.GetFirstAudioByContentHash(context.Background(), util.RandomString(64))
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	for _, beat := range []Beat{beat1, beat2} {
+		deleteRandomBeat(t, beat.ID)
+		deleteRandomUser(t, beat.CreatorID)
+	}
+}
+
+func TestUpdateAudioFingerprint(t *testing.T) {
+	beat := createRandomBeat(t)
+	fingerprint1 := upsertRandomAudioFingerprint(t, beat, util.RandomString(64))
+
+	arg := UpdateAudioFingerprintParams{
+		BeatID:      beat.ID,
+		S3Key:       fingerprint1.S3Key,
+		Fingerprint: []byte(util.RandomString(64)),
+		Hashes:      []int32{-7, 3, 42},
+	}
+	fingerprint2, err := testQueries.UpdateAudioFingerprint(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Fingerprint, fingerprint2.Fingerprint)
+	require.Equal(t, arg.Hashes, fingerprint2.Hashes)
+
+	// The fingerprint of replaced audio is thrown away
+	arg.S3Key = util.RandomS3Key()
+	_, err = testQueries.UpdateAudioFingerprint(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	deleteRandomBeat(t, beat.ID)
+	deleteRandomUser(t, beat.CreatorID)
+}
+
+func TestListFingerprintCandidates(t *testing.T) {
+	original := createRandomBeat(t)
+	closest := createRandomBeat(t)
+	unrelated := createRandomBeat(t)
+	upload := createRandomBeat(t)
+	own := createRandomBeatWithArgs(t, CreateBeatParams{
+		CreatorID: upload.CreatorID,
+		Title:     util.RandomTitle(),
+		Genre:     util.RandomGenre(),
+		Key:       util.RandomKey(),
+		Bpm:       util.RandomBpm(),
+		Tags:      util.RandomTags(),
+	})
+	later := createRandomBeat(t)
+
+	for _, beat := range []Beat{original, closest, own, unrelated, upload, later} {
+		hashes := []int32{11, 22, 33}
+		switch beat.ID {
+		case closest.ID:
+			hashes = []int32{22, 33, 66}
+		case unrelated.ID:
+			hashes = []int32{44, 55}
+		}
+		fingerprint := upsertRandomAudioFingerprint(t, beat, util.RandomString(64))
+		_, err := testQueries.UpdateAudioFingerprint(context.Background(), UpdateAudioFingerprintParams{
+			BeatID:      beat.ID,
+			S3Key:       fingerprint.S3Key,
+			Fingerprint: []byte{byte(beat.ID)},
+			Hashes:      hashes,
+		})
+		require.NoError(t, err)
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Only audio sharing a hash which was uploaded earlier by another creator is a candidate
+	candidates, err := testQueries.ListFingerprintCandidates(context.Background(), ListFingerprintCandidatesParams{
+		Hashes:        []int32{22, 66},
+		BeatID:        upload.ID,
+		MaxCandidates: 10,
+	})
+	require.NoError(t, err)
+	require.Len(t, candidates, 2)
+	// The candidate sharing the most hashes comes first even though it was uploaded later
+	require.Equal(t, closest.ID, candidates[0].BeatID)
+	require.Equal(t, original.ID, candidates[1].BeatID)
+	require.Equal(t, []byte{byte(original.ID)}, candidates[1].Fingerprint)
+
+	// The limit cuts off the candidates sharing fewer hashes
+	candidates, err = testQueries.ListFingerprintCandidates(context.Background(), ListFingerprintCandidatesParams{
+		Hashes:        []int32{22, 66},
+		BeatID:        upload.ID,
+		MaxCandidates: 1,
+	})
+	require.NoError(t, err)
+	require.Len(t, candidates, 1)
+	require.Equal(t, closest.ID, candidates[0].BeatID)
+
+	for _, beat := range []Beat{original, closest, own, unrelated, upload, later} {
+		deleteRandomBeat(t, beat.ID)
+	}
+	for _, beat := range []Beat{original, closest, unrelated, upload, later} {
+		deleteRandomUser(t, beat.CreatorID)
+	}
+}
@@ -0,0 +1,89 @@
+// Code generated by sqlc. DO NOT EDIT.
+// source: audit_log.sql
+
+package db
+
+import (
+	"context"
+)
+
+const createAuditLog = `-- name: CreateAuditLog :one
+INSERT INTO audit_logs (
+    actor_id,
+    action,
+    target_user_id,
+    details
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING id, actor_id, action, target_user_id, details, created_at
+`
+
+type CreateAuditLogParams struct {
+	ActorID      int32  `json:"actor_id"`
+	Action       string `json:"action"`
+	TargetUserID int32  `json:"target_user_id"`
+	Details      string `json:"details"`
+}
+
+func (q *Queries) CreateAuditLog(ctx context.Context, arg CreateAuditLogParams) (AuditLog, error) {
+	row := q.db.QueryRowContext(ctx, createAuditLog,
+		arg.ActorID,
+		arg.Action,
+		arg.TargetUserID,
+		arg.Details,
+	)
+	var i AuditLog
+	err := row.Scan(
+		&i.ID,
+		&i.ActorID,
+		&i.Action,
+		&i.TargetUserID,
+		&i.Details,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listAuditLogsByTargetUser = `-- name: ListAuditLogsByTargetUser :many
+SELECT id, actor_id, action, target_user_id, details, created_at FROM audit_logs
+WHERE target_user_id = $1
+ORDER BY id DESC
+LIMIT $2
+OFFSET $3
+`
+
+type ListAuditLogsByTargetUserParams struct {
+	TargetUserID int32 `json:"target_user_id"`
+	Limit        int32 `json:"limit"`
+	Offset       int32 `json:"offset"`
+}
+
+func (q *Queries) ListAuditLogsByTargetUser(ctx context.Context, arg ListAuditLogsByTargetUserParams) ([]AuditLog, error) {
+	rows, err := q.db.QueryContext(ctx, listAuditLogsByTargetUser, arg.TargetUserID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AuditLog{}
+	for rows.Next() {
+		var i AuditLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.ActorID,
+			&i.Action,
+			&i.TargetUserID,
+			&i.Details,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,65 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomAuditLog(t *testing.T, actor User, target User) AuditLog {
+	arg := CreateAuditLogParams{
+		ActorID:      actor.ID,
+		Action:       util.RandomString(8),
+		TargetUserID: target.ID,
+		Details:      util.RandomString(16),
+	}
+
+	auditLog, err := testQueries.CreateAuditLog(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, auditLog)
+
+	require.Equal(t, arg.ActorID, auditLog.ActorID)
+	require.Equal(t, arg.Action, auditLog.Action)
+	require.Equal(t, arg.TargetUserID, auditLog.TargetUserID)
+	require.Equal(t, arg.Details, auditLog.Details)
+	require.NotZero(t, auditLog.ID)
+	require.NotZero(t, auditLog.CreatedAt)
+
+	return auditLog
+}
+
+func TestCreateAuditLog(t *testing.T) {
+	actor := createRandomUser(t)
+	target := createRandomUser(t)
+	createRandomAuditLog(t, actor, target)
+
+	deleteRandomUser(t, actor.ID)
+	deleteRandomUser(t, target.ID)
+}
+
+func TestListAuditLogsByTargetUser(t *testing.T) {
+	actor := createRandomUser(t)
+	target := createRandomUser(t)
+	n := 5
+	for i := 0; i < n; i++ {
+		createRandomAuditLog(t, actor, target)
+	}
+
+	arg := ListAuditLogsByTargetUserParams{
+		TargetUserID: target.ID,
+		Limit:        int32(n),
+		Offset:       0,
+	}
+	auditLogs, err := testQueries.ListAuditLogsByTargetUser(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, auditLogs, n)
+
+	for _, auditLog := range auditLogs {
+		require.Equal(t, target.ID, auditLog.TargetUserID)
+	}
+
+	deleteRandomUser(t, actor.ID)
+	deleteRandomUser(t, target.ID)
+}
@@ -18,7 +18,7 @@ INSERT INTO beats (
     s3_key
 ) VALUES (
     $1, $2, $3, $4, $5, $6, $7
-) RETURNING id, creator_id, title, genre, key, bpm, tags, s3_key, created_at
+) RETURNING id, creator_id, title, genre, key, bpm, tags, s3_key, created_at, play_count, preview_key, detected_bpm, detected_bpm_confidence, detected_key, detected_key_confidence, artwork_key
 `
 
 type CreateBeatParams struct {
@@ -52,6 +52,13 @@ func (q *Queries) CreateBeat(ctx context.Context, arg CreateBeatParams) (Beat, e
 		&i.Tags,
 		&i.S3Key,
 		&i.CreatedAt,
+		&i.PlayCount,
+		&i.PreviewKey,
+		&i.DetectedBpm,
+		&i.DetectedBpmConfidence,
+		&i.DetectedKey,
+		&i.DetectedKeyConfidence,
+		&i.ArtworkKey,
 	)
 	return i, err
 }
@@ -67,7 +74,7 @@ func (q *Queries) DeleteBeat(ctx context.Context, id int32) error {
 }
 
 const getBeatById = `-- name: GetBeatById :one
-SELECT id, creator_id, title, genre, key, bpm, tags, s3_key, created_at FROM beats
+SELECT id, creator_id, title, genre, key, bpm, tags, s3_key, created_at, play_count, preview_key, detected_bpm, detected_bpm_confidence, detected_key, detected_key_confidence, artwork_key FROM beats
 WHERE id = $1
 LIMIT 1
 `
@@ -85,12 +92,81 @@ func (q *Queries) GetBeatById(ctx context.Context, id int32) (Beat, error) {
 		&i.Tags,
 		&i.S3Key,
 		&i.CreatedAt,
+		&i.PlayCount,
+		&i.PreviewKey,
+		&i.DetectedBpm,
+		&i.DetectedBpmConfidence,
+		&i.DetectedKey,
+		&i.DetectedKeyConfidence,
+		&i.ArtworkKey,
+	)
+	return i, err
+}
+
+const getBeatByIdForUpdate = `-- name: GetBeatByIdForUpdate :one
+SELECT id, creator_id, title, genre, key, bpm, tags, s3_key, created_at, play_count, preview_key, detected_bpm, detected_bpm_confidence, detected_key, detected_key_confidence, artwork_key FROM beats
+WHERE id = $1
+LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetBeatByIdForUpdate(ctx context.Context, id int32) (Beat, error) {
+	row := q.db.QueryRowContext(ctx, getBeatByIdForUpdate, id)
+	var i Beat
+	err := row.Scan(
+		&i.ID,
+		&i.CreatorID,
+		&i.Title,
+		&i.Genre,
+		&i.Key,
+		&i.Bpm,
+		&i.Tags,
+		&i.S3Key,
+		&i.CreatedAt,
+		&i.PlayCount,
+		&i.PreviewKey,
+		&i.DetectedBpm,
+		&i.DetectedBpmConfidence,
+		&i.DetectedKey,
+		&i.DetectedKeyConfidence,
+		&i.ArtworkKey,
+	)
+	return i, err
+}
+
+const incrementBeatPlayCount = `-- name: IncrementBeatPlayCount :one
+UPDATE beats
+SET play_count = play_count + 1
+WHERE id = $1
+RETURNING id, creator_id, title, genre, key, bpm, tags, s3_key, created_at, play_count, preview_key, detected_bpm, detected_bpm_confidence, detected_key, detected_key_confidence, artwork_key
+`
+
+func (q *Queries) IncrementBeatPlayCount(ctx context.Context, id int32) (Beat, error) {
+	row := q.db.QueryRowContext(ctx, incrementBeatPlayCount, id)
+	var i Beat
+	err := row.Scan(
+		&i.ID,
+		&i.CreatorID,
+		&i.Title,
+		&i.Genre,
+		&i.Key,
+		&i.Bpm,
+		&i.Tags,
+		&i.S3Key,
+		&i.CreatedAt,
+		&i.PlayCount,
+		&i.PreviewKey,
+		&i.DetectedBpm,
+		&i.DetectedBpmConfidence,
+		&i.DetectedKey,
+		&i.DetectedKeyConfidence,
+		&i.ArtworkKey,
 	)
 	return i, err
 }
 
 const listBeatsByBpmRange = `-- name: ListBeatsByBpmRange :many
-SELECT id, creator_id, title, genre, key, bpm, tags, s3_key, created_at FROM beats
+SELECT id, creator_id, title, genre, key, bpm, tags, s3_key, created_at, play_count, preview_key, detected_bpm, detected_bpm_confidence, detected_key, detected_key_confidence, artwork_key FROM beats
 WHERE bpm BETWEEN $1 AND $2
 ORDER BY id
 LIMIT $3
@@ -128,6 +204,13 @@ func (q *Queries) ListBeatsByBpmRange(ctx context.Context, arg ListBeatsByBpmRan
 			&i.Tags,
 			&i.S3Key,
 			&i.CreatedAt,
+			&i.PlayCount,
+			&i.PreviewKey,
+			&i.DetectedBpm,
+			&i.DetectedBpmConfidence,
+			&i.DetectedKey,
+			&i.DetectedKeyConfidence,
+			&i.ArtworkKey,
 		); err != nil {
 			return nil, err
 		}
@@ -143,7 +226,7 @@ func (q *Queries) ListBeatsByBpmRange(ctx context.Context, arg ListBeatsByBpmRan
 }
 
 const listBeatsByCreatorId = `-- name: ListBeatsByCreatorId :many
-SELECT id, creator_id, title, genre, key, bpm, tags, s3_key, created_at FROM beats
+SELECT id, creator_id, title, genre, key, bpm, tags, s3_key, created_at, play_count, preview_key, detected_bpm, detected_bpm_confidence, detected_key, detected_key_confidence, artwork_key FROM beats
 WHERE creator_id = $1
 ORDER BY id
 LIMIT $2
@@ -175,6 +258,13 @@ func (q *Queries) ListBeatsByCreatorId(ctx context.Context, arg ListBeatsByCreat
 			&i.Tags,
 			&i.S3Key,
 			&i.CreatedAt,
+			&i.PlayCount,
+			&i.PreviewKey,
+			&i.DetectedBpm,
+			&i.DetectedBpmConfidence,
+			&i.DetectedKey,
+			&i.DetectedKeyConfidence,
+			&i.ArtworkKey,
 		); err != nil {
 			return nil, err
 		}
@@ -190,7 +280,7 @@ func (q *Queries) ListBeatsByCreatorId(ctx context.Context, arg ListBeatsByCreat
 }
 
 const listBeatsByCreatorIdAndBpmRange = `-- name: ListBeatsByCreatorIdAndBpmRange :many
-SELECT id, creator_id, title, genre, key, bpm, tags, s3_key, created_at FROM beats
+SELECT id, creator_id, title, genre, key, bpm, tags, s3_key, created_at, play_count, preview_key, detected_bpm, detected_bpm_confidence, detected_key, detected_key_confidence, artwork_key FROM beats
 WHERE creator_id = $1 AND bpm BETWEEN $2 AND $3
 ORDER BY id
 LIMIT $4
@@ -230,6 +320,13 @@ func (q *Queries) ListBeatsByCreatorIdAndBpmRange(ctx context.Context, arg ListB
 			&i.Tags,
 			&i.S3Key,
 			&i.CreatedAt,
+			&i.PlayCount,
+			&i.PreviewKey,
+			&i.DetectedBpm,
+			&i.DetectedBpmConfidence,
+			&i.DetectedKey,
+			&i.DetectedKeyConfidence,
+			&i.ArtworkKey,
 		); err != nil {
 			return nil, err
 		}
@@ -245,7 +342,7 @@ func (q *Queries) ListBeatsByCreatorIdAndBpmRange(ctx context.Context, arg ListB
 }
 
 const listBeatsByCreatorIdAndGenre = `-- name: ListBeatsByCreatorIdAndGenre :many
-SELECT id, creator_id, title, genre, key, bpm, tags, s3_key, created_at FROM beats
+SELECT id, creator_id, title, genre, key, bpm, tags, s3_key, created_at, play_count, preview_key, detected_bpm, detected_bpm_confidence, detected_key, detected_key_confidence, artwork_key FROM beats
 WHERE creator_id = $1 AND genre = $2
 ORDER BY id
 LIMIT $3
@@ -283,6 +380,13 @@ func (q *Queries) ListBeatsByCreatorIdAndGenre(ctx context.Context, arg ListBeat
 			&i.Tags,
 			&i.S3Key,
 			&i.CreatedAt,
+			&i.PlayCount,
+			&i.PreviewKey,
+			&i.DetectedBpm,
+			&i.DetectedBpmConfidence,
+			&i.DetectedKey,
+			&i.DetectedKeyConfidence,
+			&i.ArtworkKey,
 		); err != nil {
 			return nil, err
 		}
@@ -298,7 +402,7 @@ func (q *Queries) ListBeatsByCreatorIdAndGenre(ctx context.Context, arg ListBeat
 }
 
 const listBeatsByCreatorIdAndKey = `-- name: ListBeatsByCreatorIdAndKey :many
-SELECT id, creator_id, title, genre, key, bpm, tags, s3_key, created_at FROM beats
+SELECT id, creator_id, title, genre, key, bpm, tags, s3_key, created_at, play_count, preview_key, detected_bpm, detected_bpm_confidence, detected_key, detected_key_confidence, artwork_key FROM beats
 WHERE creator_id = $1 AND key = $2
 ORDER BY id
 LIMIT $3
@@ -336,6 +440,13 @@ func (q *Queries) ListBeatsByCreatorIdAndKey(ctx context.Context, arg ListBeatsB
 			&i.Tags,
 			&i.S3Key,
 			&i.CreatedAt,
+			&i.PlayCount,
+			&i.PreviewKey,
+			&i.DetectedBpm,
+			&i.DetectedBpmConfidence,
+			&i.DetectedKey,
+			&i.DetectedKeyConfidence,
+			&i.ArtworkKey,
 		); err != nil {
 			return nil, err
 		}
@@ -351,7 +462,7 @@ func (q *Queries) ListBeatsByCreatorIdAndKey(ctx context.Context, arg ListBeatsB
 }
 
 const listBeatsByGenre = `-- name: ListBeatsByGenre :many
-SELECT id, creator_id, title, genre, key, bpm, tags, s3_key, created_at FROM beats
+SELECT id, creator_id, title, genre, key, bpm, tags, s3_key, created_at, play_count, preview_key, detected_bpm, detected_bpm_confidence, detected_key, detected_key_confidence, artwork_key FROM beats
 WHERE genre = $1
 ORDER BY id
 LIMIT $2
@@ -383,6 +494,13 @@ func (q *Queries) ListBeatsByGenre(ctx context.Context, arg ListBeatsByGenrePara
 			&i.Tags,
 			&i.S3Key,
 			&i.CreatedAt,
+			&i.PlayCount,
+			&i.PreviewKey,
+			&i.DetectedBpm,
+			&i.DetectedBpmConfidence,
+			&i.DetectedKey,
+			&i.DetectedKeyConfidence,
+			&i.ArtworkKey,
 		); err != nil {
 			return nil, err
 		}
@@ -398,7 +516,7 @@ func (q *Queries) ListBeatsByGenre(ctx context.Context, arg ListBeatsByGenrePara
 }
 
 const listBeatsById = `-- name: ListBeatsById :many
-SELECT id, creator_id, title, genre, key, bpm, tags, s3_key, created_at FROM beats
+SELECT id, creator_id, title, genre, key, bpm, tags, s3_key, created_at, play_count, preview_key, detected_bpm, detected_bpm_confidence, detected_key, detected_key_confidence, artwork_key FROM beats
 ORDER BY id
 LIMIT $1
 OFFSET $2
@@ -428,6 +546,13 @@ func (q *Queries) ListBeatsById(ctx context.Context, arg ListBeatsByIdParams) ([
 			&i.Tags,
 			&i.S3Key,
 			&i.CreatedAt,
+			&i.PlayCount,
+			&i.PreviewKey,
+			&i.DetectedBpm,
+			&i.DetectedBpmConfidence,
+			&i.DetectedKey,
+			&i.DetectedKeyConfidence,
+			&i.ArtworkKey,
 		); err != nil {
 			return nil, err
 		}
@@ -443,7 +568,7 @@ func (q *Queries) ListBeatsById(ctx context.Context, arg ListBeatsByIdParams) ([
 }
 
 const listBeatsByKey = `-- name: ListBeatsByKey :many
-SELECT id, creator_id, title, genre, key, bpm, tags, s3_key, created_at FROM beats
+SELECT id, creator_id, title, genre, key, bpm, tags, s3_key, created_at, play_count, preview_key, detected_bpm, detected_bpm_confidence, detected_key, detected_key_confidence, artwork_key FROM beats
 WHERE key = $1
 ORDER BY id
 LIMIT $2
@@ -475,6 +600,13 @@ func (q *Queries) ListBeatsByKey(ctx context.Context, arg ListBeatsByKeyParams)
 			&i.Tags,
 			&i.S3Key,
 			&i.CreatedAt,
+			&i.PlayCount,
+			&i.PreviewKey,
+			&i.DetectedBpm,
+			&i.DetectedBpmConfidence,
+			&i.DetectedKey,
+			&i.DetectedKeyConfidence,
+			&i.ArtworkKey,
 		); err != nil {
 			return nil, err
 		}
@@ -495,10 +627,9 @@ SET title = $2,
     genre = $3,
     key = $4,
     bpm = $5,
-    tags = $6,
-    s3_key = $7
+    tags = $6
 WHERE id = $1
-RETURNING id, creator_id, title, genre, key, bpm, tags, s3_key, created_at
+RETURNING id, creator_id, title, genre, key, bpm, tags, s3_key, created_at, play_count, preview_key, detected_bpm, detected_bpm_confidence, detected_key, detected_key_confidence, artwork_key
 `
 
 type UpdateBeatParams struct {
@@ -508,7 +639,6 @@ type UpdateBeatParams struct {
 	Key   string `json:"key"`
 	Bpm   int16  `json:"bpm"`
 	Tags  string `json:"tags"`
-	S3Key string `json:"s3_key"`
 }
 
 func (q *Queries) UpdateBeat(ctx context.Context, arg UpdateBeatParams) (Beat, error) {
@@ -519,6 +649,56 @@ func (q *Queries) UpdateBeat(ctx context.Context, arg UpdateBeatParams) (Beat, e
 		arg.Key,
 		arg.Bpm,
 		arg.Tags,
+	)
+	var i Beat
+	err := row.Scan(
+		&i.ID,
+		&i.CreatorID,
+		&i.Title,
+		&i.Genre,
+		&i.Key,
+		&i.Bpm,
+		&i.Tags,
+		&i.S3Key,
+		&i.CreatedAt,
+		&i.PlayCount,
+		&i.PreviewKey,
+		&i.DetectedBpm,
+		&i.DetectedBpmConfidence,
+		&i.DetectedKey,
+		&i.DetectedKeyConfidence,
+		&i.ArtworkKey,
+	)
+	return i, err
+}
+
+const updateBeatAnalysis = `-- name: UpdateBeatAnalysis :one
+UPDATE beats
+SET detected_bpm = $1,
+    detected_bpm_confidence = $2,
+    detected_key = $3,
+    detected_key_confidence = $4
+WHERE id = $5 AND s3_key = $6
+RETURNING id, creator_id, title, genre, key, bpm, tags, s3_key, created_at, play_count, preview_key, detected_bpm, detected_bpm_confidence, detected_key, detected_key_confidence, artwork_key
+`
+
+type UpdateBeatAnalysisParams struct {
+	DetectedBpm           float32 `json:"detected_bpm"`
+	DetectedBpmConfidence float32 `json:"detected_bpm_confidence"`
+	DetectedKey           string  `json:"detected_key"`
+	DetectedKeyConfidence float32 `json:"detected_key_confidence"`
+	ID                    int32   `json:"id"`
+	S3Key                 string  `json:"s3_key"`
+}
+
+// Returns sql.ErrNoRows if the analysed audio has been replaced since.
+func (q *Queries) UpdateBeatAnalysis(ctx context.Context, arg UpdateBeatAnalysisParams) (Beat, error) {
+	row := q.db.QueryRowContext(ctx, updateBeatAnalysis,
+		arg.DetectedBpm,
+		arg.DetectedBpmConfidence,
+		arg.DetectedKey,
+		arg.DetectedKeyConfidence,
+		arg.ID,
 		arg.S3Key,
 	)
 	var i Beat
@@ -532,6 +712,132 @@ func (q *Queries) UpdateBeat(ctx context.Context, arg UpdateBeatParams) (Beat, e
 		&i.Tags,
 		&i.S3Key,
 		&i.CreatedAt,
+		&i.PlayCount,
+		&i.PreviewKey,
+		&i.DetectedBpm,
+		&i.DetectedBpmConfidence,
+		&i.DetectedKey,
+		&i.DetectedKeyConfidence,
+		&i.ArtworkKey,
+	)
+	return i, err
+}
+
+const updateBeatArtworkKey = `-- name: UpdateBeatArtworkKey :one
+UPDATE beats
+SET artwork_key = $2
+WHERE id = $1
+RETURNING id, creator_id, title, genre, key, bpm, tags, s3_key, created_at, play_count, preview_key, detected_bpm, detected_bpm_confidence, detected_key, detected_key_confidence, artwork_key
+`
+
+type UpdateBeatArtworkKeyParams struct {
+	ID         int32  `json:"id"`
+	ArtworkKey string `json:"artwork_key"`
+}
+
+func (q *Queries) UpdateBeatArtworkKey(ctx context.Context, arg UpdateBeatArtworkKeyParams) (Beat, error) {
+	row := q.db.QueryRowContext(ctx, updateBeatArtworkKey, arg.ID, arg.ArtworkKey)
+	var i Beat
+	err := row.Scan(
+		&i.ID,
+		&i.CreatorID,
+		&i.Title,
+		&i.Genre,
+		&i.Key,
+		&i.Bpm,
+		&i.Tags,
+		&i.S3Key,
+		&i.CreatedAt,
+		&i.PlayCount,
+		&i.PreviewKey,
+		&i.DetectedBpm,
+		&i.DetectedBpmConfidence,
+		&i.DetectedKey,
+		&i.DetectedKeyConfidence,
+		&i.ArtworkKey,
+	)
+	return i, err
+}
+
+const updateBeatPreviewKey = `-- name: UpdateBeatPreviewKey :one
+UPDATE beats
+SET preview_key = $1
+WHERE id = $2 AND s3_key = $3
+RETURNING id, creator_id, title, genre, key, bpm, tags, s3_key, created_at, play_count, preview_key, detected_bpm, detected_bpm_confidence, detected_key, detected_key_confidence, artwork_key
+`
+
+type UpdateBeatPreviewKeyParams struct {
+	PreviewKey string `json:"preview_key"`
+	ID         int32  `json:"id"`
+	S3Key      string `json:"s3_key"`
+}
+
+// Returns sql.ErrNoRows if the audio the preview was generated from has been replaced since.
+func (q *Queries) UpdateBeatPreviewKey(ctx context.Context, arg UpdateBeatPreviewKeyParams) (Beat, error) {
+	row := q.db.QueryRowContext(ctx, updateBeatPreviewKey, arg.PreviewKey, arg.ID, arg.S3Key)
+	var i Beat
+	err := row.Scan(
+		&i.ID,
+		&i.CreatorID,
+		&i.Title,
+		&i.Genre,
+		&i.Key,
+		&i.Bpm,
+		&i.Tags,
+		&i.S3Key,
+		&i.CreatedAt,
+		&i.PlayCount,
+		&i.PreviewKey,
+		&i.DetectedBpm,
+		&i.DetectedBpmConfidence,
+		&i.DetectedKey,
+		&i.DetectedKeyConfidence,
+		&i.ArtworkKey,
+	)
+	return i, err
+}
+
+const updateBeatS3Key = `-- name: UpdateBeatS3Key :one
+UPDATE beats
+SET s3_key = $2,
+    preview_key = $3,
+    detected_bpm = 0,
+    detected_bpm_confidence = 0,
+    detected_key = '',
+    detected_key_confidence = 0
+WHERE id = $1
+RETURNING id, creator_id, title, genre, key, bpm, tags, s3_key, created_at, play_count, preview_key, detected_bpm, detected_bpm_confidence, detected_key, detected_key_confidence, artwork_key
+`
+
+type UpdateBeatS3KeyParams struct {
+	ID         int32  `json:"id"`
+	S3Key      string `json:"s3_key"`
+	PreviewKey string `json:"preview_key"`
+}
+
+// Points the beat at a newly uploaded audio object and the preview belonging to it,
+// an empty preview key means the preview is still being generated.
+// The analysis of the previous audio no longer applies and is reset.
+func (q *Queries) UpdateBeatS3Key(ctx context.Context, arg UpdateBeatS3KeyParams) (Beat, error) {
+	row := q.db.QueryRowContext(ctx, updateBeatS3Key, arg.ID, arg.S3Key, arg.PreviewKey)
+	var i Beat
+	err := row.Scan(
+		&i.ID,
+		&i.CreatorID,
+		&i.Title,
+		&i.Genre,
+		&i.Key,
+		&i.Bpm,
+		&i.Tags,
+		&i.S3Key,
+		&i.CreatedAt,
+		&i.PlayCount,
+		&i.PreviewKey,
+		&i.DetectedBpm,
+		&i.DetectedBpmConfidence,
+		&i.DetectedKey,
+		&i.DetectedKeyConfidence,
+		&i.ArtworkKey,
 	)
 	return i, err
 }
@@ -0,0 +1,193 @@
+// Code generated by sqlc. DO NOT EDIT.
+// source: beat_file.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/lib/pq"
+)
+
+const deleteBeatFile = `-- name: DeleteBeatFile :one
+DELETE FROM beat_files
+WHERE beat_id = $1 AND file_type = $2
+RETURNING beat_id, file_type, s3_key, content_type, size, checksum, created_at
+`
+
+type DeleteBeatFileParams struct {
+	BeatID   int32  `json:"beat_id"`
+	FileType string `json:"file_type"`
+}
+
+func (q *Queries) DeleteBeatFile(ctx context.Context, arg DeleteBeatFileParams) (BeatFile, error) {
+	row := q.db.QueryRowContext(ctx, deleteBeatFile, arg.BeatID, arg.FileType)
+	var i BeatFile
+	err := row.Scan(
+		&i.BeatID,
+		&i.FileType,
+		&i.S3Key,
+		&i.ContentType,
+		&i.Size,
+		&i.Checksum,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteBeatFilesByType = `-- name: DeleteBeatFilesByType :many
+DELETE FROM beat_files
+WHERE beat_id = $1 AND file_type = ANY($2::varchar[])
+RETURNING beat_id, file_type, s3_key, content_type, size, checksum, created_at
+`
+
+type DeleteBeatFilesByTypeParams struct {
+	BeatID    int32    `json:"beat_id"`
+	FileTypes []string `json:"file_types"`
+}
+
+func (q *Queries) DeleteBeatFilesByType(ctx context.Context, arg DeleteBeatFilesByTypeParams) ([]BeatFile, error) {
+	rows, err := q.db.QueryContext(ctx, deleteBeatFilesByType, arg.BeatID, pq.Array(arg.FileTypes))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []BeatFile{}
+	for rows.Next() {
+		var i BeatFile
+		if err := rows.Scan(
+			&i.BeatID,
+			&i.FileType,
+			&i.S3Key,
+			&i.ContentType,
+			&i.Size,
+			&i.Checksum,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getBeatFile = `-- name: GetBeatFile :one
+SELECT beat_id, file_type, s3_key, content_type, size, checksum, created_at FROM beat_files
+WHERE beat_id = $1 AND file_type = $2
+LIMIT 1
+`
+
+type GetBeatFileParams struct {
+	BeatID   int32  `json:"beat_id"`
+	FileType string `json:"file_type"`
+}
+
+func (q *Queries) GetBeatFile(ctx context.Context, arg GetBeatFileParams) (BeatFile, error) {
+	row := q.db.QueryRowContext(ctx, getBeatFile, arg.BeatID, arg.FileType)
+	var i BeatFile
+	err := row.Scan(
+		&i.BeatID,
+		&i.FileType,
+		&i.S3Key,
+		&i.ContentType,
+		&i.Size,
+		&i.Checksum,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listBeatFiles = `-- name: ListBeatFiles :many
+SELECT beat_id, file_type, s3_key, content_type, size, checksum, created_at FROM beat_files
+WHERE beat_id = $1
+ORDER BY file_type
+`
+
+func (q *Queries) ListBeatFiles(ctx context.Context, beatID int32) ([]BeatFile, error) {
+	rows, err := q.db.QueryContext(ctx, listBeatFiles, beatID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []BeatFile{}
+	for rows.Next() {
+		var i BeatFile
+		if err := rows.Scan(
+			&i.BeatID,
+			&i.FileType,
+			&i.S3Key,
+			&i.ContentType,
+			&i.Size,
+			&i.Checksum,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertBeatFile = `-- name: UpsertBeatFile :one
+INSERT INTO beat_files (
+    beat_id,
+    file_type,
+    s3_key,
+    content_type,
+    size,
+    checksum
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+)
+ON CONFLICT (beat_id, file_type) DO UPDATE
+SET s3_key = EXCLUDED.s3_key,
+    content_type = EXCLUDED.content_type,
+    size = EXCLUDED.size,
+    checksum = EXCLUDED.checksum,
+    created_at = now()
+RETURNING beat_id, file_type, s3_key, content_type, size, checksum, created_at
+`
+
+type UpsertBeatFileParams struct {
+	BeatID      int32  `json:"beat_id"`
+	FileType    string `json:"file_type"`
+	S3Key       string `json:"s3_key"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	Checksum    string `json:"checksum"`
+}
+
+// Attaches a file to a beat, replacing the file of the same type.
+func (q *Queries) UpsertBeatFile(ctx context.Context, arg UpsertBeatFileParams) (BeatFile, error) {
+	row := q.db.QueryRowContext(ctx, upsertBeatFile,
+		arg.BeatID,
+		arg.FileType,
+		arg.S3Key,
+		arg.ContentType,
+		arg.Size,
+		arg.Checksum,
+	)
+	var i BeatFile
+	err := row.Scan(
+		&i.BeatID,
+		&i.FileType,
+		&i.S3Key,
+		&i.ContentType,
+		&i.Size,
+		&i.Checksum,
+		&i.CreatedAt,
+	)
+	return i, err
+}
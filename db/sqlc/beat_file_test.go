@@ -0,0 +1,133 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/stretchr/testify/require"
+)
+
+func upsertRandomBeatFile(t *testing.T, beat Beat, fileType string) BeatFile {
+	arg := UpsertBeatFileParams{
+		BeatID:      beat.ID,
+		FileType:    fileType,
+		S3Key:       util.RandomS3Key(),
+		ContentType: "application/zip",
+		Size:        util.RandomInt(1, 1<<30),
+		Checksum:    util.RandomString(64),
+	}
+
+	file, err := testQueries.UpsertBeatFile(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.BeatID, file.BeatID)
+	require.Equal(t, arg.FileType, file.FileType)
+	require.Equal(t, arg.S3Key, file.S3Key)
+	require.Equal(t, arg.ContentType, file.ContentType)
+	require.Equal(t, arg.Size, file.Size)
+	require.Equal(t, arg.Checksum, file.Checksum)
+	require.NotZero(t, file.CreatedAt)
+
+	return file
+}
+
+func TestUpsertBeatFile(t *testing.T) {
+	beat := createRandomBeat(t)
+	file1 := upsertRandomBeatFile(t, beat, util.StemsFile)
+
+	// Uploading the same type again replaces the file
+	file2 := upsertRandomBeatFile(t, beat, util.StemsFile)
+	require.NotEqual(t, file1.S3Key, file2.S3Key)
+
+	files, err := testQueries.ListBeatFiles(context.Background(), beat.ID)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.Equal(t, file2.S3Key, files[0].S3Key)
+
+	deleteRandomBeat(t, beat.ID)
+	deleteRandomUser(t, beat.CreatorID)
+}
+
+func TestGetBeatFile(t *testing.T) {
+	beat := createRandomBeat(t)
+	file1 := upsertRandomBeatFile(t, beat, util.ProjectFile)
+
+	file2, err := testQueries.GetBeatFile(context.Background(), GetBeatFileParams{
+		BeatID:   beat.ID,
+		FileType: util.ProjectFile,
+	})
+	require.NoError(t, err)
+	require.Equal(t, file1.S3Key, file2.S3Key)
+	require.WithinDuration(t, file1.CreatedAt, file2.CreatedAt, time.Second)
+
+	_, err = testQueries.GetBeatFile(context.Background(), GetBeatFileParams{
+		BeatID:   beat.ID,
+		FileType: util.StemsFile,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	deleteRandomBeat(t, beat.ID)
+	deleteRandomUser(t, beat.CreatorID)
+}
+
+func TestListBeatFiles(t *testing.T) {
+	beat := createRandomBeat(t)
+	upsertRandomBeatFile(t, beat, util.StemsFile)
+	upsertRandomBeatFile(t, beat, util.MasterWAVFile)
+	upsertRandomBeatFile(t, beat, util.ProjectFile)
+
+	files, err := testQueries.ListBeatFiles(context.Background(), beat.ID)
+	require.NoError(t, err)
+	require.Len(t, files, 3)
+
+	// Ordered by type
+	require.Equal(t, util.MasterWAVFile, files[0].FileType)
+	require.Equal(t, util.ProjectFile, files[1].FileType)
+	require.Equal(t, util.StemsFile, files[2].FileType)
+
+	deleteRandomBeat(t, beat.ID)
+	deleteRandomUser(t, beat.CreatorID)
+}
+
+func TestDeleteBeatFile(t *testing.T) {
+	beat := createRandomBeat(t)
+	file1 := upsertRandomBeatFile(t, beat, util.StemsFile)
+
+	arg := DeleteBeatFileParams{
+		BeatID:   beat.ID,
+		FileType: util.StemsFile,
+	}
+	file2, err := testQueries.DeleteBeatFile(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, file1.S3Key, file2.S3Key)
+
+	_, err = testQueries.DeleteBeatFile(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	deleteRandomBeat(t, beat.ID)
+	deleteRandomUser(t, beat.CreatorID)
+}
+
+func TestDeleteBeatFilesByType(t *testing.T) {
+	beat := createRandomBeat(t)
+	wav := upsertRandomBeatFile(t, beat, util.MasterWAVFile)
+	upsertRandomBeatFile(t, beat, util.StemsFile)
+
+	removed, err := testQueries.DeleteBeatFilesByType(context.Background(), DeleteBeatFilesByTypeParams{
+		BeatID:    beat.ID,
+		FileTypes: []string{util.MasterWAVFile, util.MP3File},
+	})
+	require.NoError(t, err)
+	require.Len(t, removed, 1)
+	require.Equal(t, wav.S3Key, removed[0].S3Key)
+
+	files, err := testQueries.ListBeatFiles(context.Background(), beat.ID)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.Equal(t, util.StemsFile, files[0].FileType)
+
+	deleteRandomBeat(t, beat.ID)
+	deleteRandomUser(t, beat.CreatorID)
+}
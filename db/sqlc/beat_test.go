@@ -56,6 +56,7 @@ func createRandomBeatWithArgs(t *testing.T, arg CreateBeatParams) Beat {
 
 	require.NotZero(t, beat.ID)
 	require.NotZero(t, beat.CreatedAt)
+	require.Zero(t, beat.PlayCount)
 
 	return beat
 }
@@ -82,7 +83,6 @@ func TestUpdateBeat(t *testing.T) {
 		Key:   util.RandomKey(),
 		Bpm:   util.RandomBpm(),
 		Tags:  util.RandomTags(),
-		S3Key: util.RandomS3Key(),
 	}
 
 	beat2, err := testQueries.UpdateBeat(context.Background(), arg)
@@ -95,13 +95,133 @@ func TestUpdateBeat(t *testing.T) {
 	require.Equal(t, arg.Key, beat2.Key)
 	require.Equal(t, arg.Bpm, beat2.Bpm)
 	require.Equal(t, arg.Tags, beat2.Tags)
-	require.Equal(t, arg.S3Key, beat2.S3Key)
+	require.Equal(t, beat1.S3Key, beat2.S3Key)
 	require.WithinDuration(t, beat1.CreatedAt, beat2.CreatedAt, time.Second)
 
 	deleteRandomBeat(t, beat1.ID)
 	deleteRandomUser(t, beat1.CreatorID)
 }
 
+func TestIncrementBeatPlayCount(t *testing.T) {
+	beat1 := createRandomBeat(t)
+
+	beat2, err := testQueries.IncrementBeatPlayCount(context.Background(), beat1.ID)
+	require.NoError(t, err)
+	require.Equal(t, beat1.PlayCount+1, beat2.PlayCount)
+
+	beat3, err := testQueries.IncrementBeatPlayCount(context.Background(), beat1.ID)
+	require.NoError(t, err)
+	require.Equal(t, beat1.PlayCount+2, beat3.PlayCount)
+
+	deleteRandomBeat(t, beat1.ID)
+	deleteRandomUser(t, beat1.CreatorID)
+}
+
+func TestUpdateBeatS3Key(t *testing.T) {
+	beat1 := createRandomBeat(t)
+
+	arg := UpdateBeatS3KeyParams{
+		ID:         beat1.ID,
+		S3Key:      util.RandomS3Key(),
+		PreviewKey: util.RandomS3Key(),
+	}
+
+	beat2, err := testQueries.UpdateBeatS3Key(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, beat1.ID, beat2.ID)
+	require.Equal(t, beat1.Title, beat2.Title)
+	require.Equal(t, arg.S3Key, beat2.S3Key)
+	require.Equal(t, arg.PreviewKey, beat2.PreviewKey)
+
+	deleteRandomBeat(t, beat1.ID)
+	deleteRandomUser(t, beat1.CreatorID)
+}
+
+func TestUpdateBeatPreviewKey(t *testing.T) {
+	beat1 := createRandomBeat(t)
+	require.Empty(t, beat1.PreviewKey)
+
+	arg := UpdateBeatPreviewKeyParams{
+		ID:         beat1.ID,
+		S3Key:      beat1.S3Key,
+		PreviewKey: util.RandomS3Key(),
+	}
+
+	beat2, err := testQueries.UpdateBeatPreviewKey(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, beat1.S3Key, beat2.S3Key)
+	require.Equal(t, arg.PreviewKey, beat2.PreviewKey)
+
+	// A preview of audio which has been replaced in the meantime is rejected
+	arg.S3Key = util.RandomS3Key()
+	_, err = testQueries.UpdateBeatPreviewKey(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	deleteRandomBeat(t, beat1.ID)
+	deleteRandomUser(t, beat1.CreatorID)
+}
+
+func TestUpdateBeatAnalysis(t *testing.T) {
+	beat1 := createRandomBeat(t)
+	require.Zero(t, beat1.DetectedBpm)
+	require.Empty(t, beat1.DetectedKey)
+
+	arg := UpdateBeatAnalysisParams{
+		ID:                    beat1.ID,
+		S3Key:                 beat1.S3Key,
+		DetectedBpm:           float32(util.RandomBpm()),
+		DetectedBpmConfidence: 0.8,
+		DetectedKey:           util.RandomKey(),
+		DetectedKeyConfidence: 0.6,
+	}
+
+	beat2, err := testQueries.UpdateBeatAnalysis(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, beat1.Bpm, beat2.Bpm)
+	require.Equal(t, beat1.Key, beat2.Key)
+	require.Equal(t, arg.DetectedBpm, beat2.DetectedBpm)
+	require.Equal(t, arg.DetectedBpmConfidence, beat2.DetectedBpmConfidence)
+	require.Equal(t, arg.DetectedKey, beat2.DetectedKey)
+	require.Equal(t, arg.DetectedKeyConfidence, beat2.DetectedKeyConfidence)
+
+	// New audio resets the analysis
+	beat3, err := testQueries.UpdateBeatS3Key(context.Background(), UpdateBeatS3KeyParams{
+		ID:    beat1.ID,
+		S3Key: util.RandomS3Key(),
+	})
+	require.NoError(t, err)
+	require.Zero(t, beat3.DetectedBpm)
+	require.Zero(t, beat3.DetectedBpmConfidence)
+	require.Empty(t, beat3.DetectedKey)
+	require.Zero(t, beat3.DetectedKeyConfidence)
+
+	// The analysis of the replaced audio is rejected
+	_, err = testQueries.UpdateBeatAnalysis(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	deleteRandomBeat(t, beat1.ID)
+	deleteRandomUser(t, beat1.CreatorID)
+}
+
+func TestUpdateBeatArtworkKey(t *testing.T) {
+	beat1 := createRandomBeat(t)
+	require.Empty(t, beat1.ArtworkKey)
+
+	arg := UpdateBeatArtworkKeyParams{
+		ID:         beat1.ID,
+		ArtworkKey: util.RandomS3Key(),
+	}
+
+	beat2, err := testQueries.UpdateBeatArtworkKey(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.ArtworkKey, beat2.ArtworkKey)
+	require.Equal(t, beat1.S3Key, beat2.S3Key)
+	require.Equal(t, beat1.PreviewKey, beat2.PreviewKey)
+
+	deleteRandomBeat(t, beat1.ID)
+	deleteRandomUser(t, beat1.CreatorID)
+}
+
 func TestGetBeatById(t *testing.T) {
 	beat1 := createRandomBeat(t)
 
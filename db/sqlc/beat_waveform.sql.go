@@ -0,0 +1,84 @@
+// Code generated by sqlc. DO NOT EDIT.
+// source: beat_waveform.sql
+
+package db
+
+import (
+	"context"
+)
+
+const getBeatWaveform = `-- name: GetBeatWaveform :one
+SELECT beat_waveforms.beat_id, beat_waveforms.s3_key, beat_waveforms.resolution, beat_waveforms.duration_ms, beat_waveforms.peaks, beat_waveforms.created_at FROM beat_waveforms
+JOIN beats ON beats.id = beat_waveforms.beat_id AND beats.s3_key = beat_waveforms.s3_key
+WHERE beat_waveforms.beat_id = $1
+LIMIT 1
+`
+
+// Only returns the waveform if it belongs to the current audio of the beat.
+func (q *Queries) GetBeatWaveform(ctx context.Context, beatID int32) (BeatWaveform, error) {
+	row := q.db.QueryRowContext(ctx, getBeatWaveform, beatID)
+	var i BeatWaveform
+	err := row.Scan(
+		&i.BeatID,
+		&i.S3Key,
+		&i.Resolution,
+		&i.DurationMs,
+		&i.Peaks,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const upsertBeatWaveform = `-- name: UpsertBeatWaveform :one
+INSERT INTO beat_waveforms (
+    beat_id,
+    s3_key,
+    resolution,
+    duration_ms,
+    peaks
+)
+SELECT
+    beats.id,
+    beats.s3_key,
+    $1::integer,
+    $2::bigint,
+    $3::bytea
+FROM beats
+WHERE beats.id = $4 AND beats.s3_key = $5
+ON CONFLICT (beat_id) DO UPDATE
+SET s3_key = EXCLUDED.s3_key,
+    resolution = EXCLUDED.resolution,
+    duration_ms = EXCLUDED.duration_ms,
+    peaks = EXCLUDED.peaks,
+    created_at = now()
+RETURNING beat_id, s3_key, resolution, duration_ms, peaks, created_at
+`
+
+type UpsertBeatWaveformParams struct {
+	Resolution int32  `json:"resolution"`
+	DurationMs int64  `json:"duration_ms"`
+	Peaks      []byte `json:"peaks"`
+	BeatID     int32  `json:"beat_id"`
+	S3Key      string `json:"s3_key"`
+}
+
+// Returns sql.ErrNoRows if the audio the waveform was computed from has been replaced since.
+func (q *Queries) UpsertBeatWaveform(ctx context.Context, arg UpsertBeatWaveformParams) (BeatWaveform, error) {
+	row := q.db.QueryRowContext(ctx, upsertBeatWaveform,
+		arg.Resolution,
+		arg.DurationMs,
+		arg.Peaks,
+		arg.BeatID,
+		arg.S3Key,
+	)
+	var i BeatWaveform
+	err := row.Scan(
+		&i.BeatID,
+		&i.S3Key,
+		&i.Resolution,
+		&i.DurationMs,
+		&i.Peaks,
+		&i.CreatedAt,
+	)
+	return i, err
+}
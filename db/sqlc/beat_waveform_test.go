@@ -0,0 +1,76 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/stretchr/testify/require"
+)
+
+func upsertRandomBeatWaveform(t *testing.T, beat Beat) BeatWaveform {
+	arg := UpsertBeatWaveformParams{
+		BeatID:     beat.ID,
+		S3Key:      beat.S3Key,
+		Resolution: 4,
+		DurationMs: util.RandomInt(1000, 300000),
+		Peaks:      []byte(util.RandomString(8)),
+	}
+
+	waveform, err := testQueries.UpsertBeatWaveform(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.BeatID, waveform.BeatID)
+	require.Equal(t, arg.S3Key, waveform.S3Key)
+	require.Equal(t, arg.Resolution, waveform.Resolution)
+	require.Equal(t, arg.DurationMs, waveform.DurationMs)
+	require.Equal(t, arg.Peaks, waveform.Peaks)
+	require.NotZero(t, waveform.CreatedAt)
+
+	return waveform
+}
+
+func TestUpsertBeatWaveform(t *testing.T) {
+	beat := createRandomBeat(t)
+	waveform1 := upsertRandomBeatWaveform(t, beat)
+
+	// Regenerating replaces the waveform
+	waveform2 := upsertRandomBeatWaveform(t, beat)
+	require.NotEqual(t, waveform1.Peaks, waveform2.Peaks)
+
+	// A waveform of audio which has been replaced in the meantime is rejected
+	_, err := testQueries.UpsertBeatWaveform(context.Background(), UpsertBeatWaveformParams{
+		BeatID:     beat.ID,
+		S3Key:      util.RandomS3Key(),
+		Resolution: 4,
+		DurationMs: 1000,
+		Peaks:      []byte(util.RandomString(8)),
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	deleteRandomBeat(t, beat.ID)
+	deleteRandomUser(t, beat.CreatorID)
+}
+
+func TestGetBeatWaveform(t *testing.T) {
+	beat := createRandomBeat(t)
+	waveform1 := upsertRandomBeatWaveform(t, beat)
+
+	waveform2, err := testQueries.GetBeatWaveform(context.Background(), beat.ID)
+	require.NoError(t, err)
+	require.Equal(t, waveform1.Peaks, waveform2.Peaks)
+	require.WithinDuration(t, waveform1.CreatedAt, waveform2.CreatedAt, time.Second)
+
+	// The waveform of replaced audio is not returned
+	_, err = testQueries.UpdateBeatS3Key(context.Background(), UpdateBeatS3KeyParams{
+		ID:    beat.ID,
+		S3Key: util.RandomS3Key(),
+	})
+	require.NoError(t, err)
+	_, err = testQueries.GetBeatWaveform(context.Background(), beat.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	deleteRandomBeat(t, beat.ID)
+	deleteRandomUser(t, beat.CreatorID)
+}
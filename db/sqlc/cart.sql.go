@@ -0,0 +1,296 @@
+// Code generated by sqlc. DO NOT EDIT.
+// source: cart.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const createCart = `-- name: CreateCart :one
+INSERT INTO carts (
+    id,
+    user_id
+) VALUES (
+    $1, $2
+)
+RETURNING id, user_id, created_at
+`
+
+type CreateCartParams struct {
+	ID     uuid.UUID     `json:"id"`
+	UserID sql.NullInt32 `json:"user_id"`
+}
+
+func (q *Queries) CreateCart(ctx context.Context, arg CreateCartParams) (Cart, error) {
+	row := q.db.QueryRowContext(ctx, createCart, arg.ID, arg.UserID)
+	var i Cart
+	err := row.Scan(&i.ID, &i.UserID, &i.CreatedAt)
+	return i, err
+}
+
+const deleteCart = `-- name: DeleteCart :exec
+DELETE FROM carts
+WHERE id = $1
+`
+
+func (q *Queries) DeleteCart(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteCart, id)
+	return err
+}
+
+const deleteCartItem = `-- name: DeleteCartItem :one
+DELETE FROM cart_items
+WHERE id = $1 AND cart_id = $2
+RETURNING id, cart_id, beat_id, license_id, price, currency, created_at
+`
+
+type DeleteCartItemParams struct {
+	ID     int64     `json:"id"`
+	CartID uuid.UUID `json:"cart_id"`
+}
+
+func (q *Queries) DeleteCartItem(ctx context.Context, arg DeleteCartItemParams) (CartItem, error) {
+	row := q.db.QueryRowContext(ctx, deleteCartItem, arg.ID, arg.CartID)
+	var i CartItem
+	err := row.Scan(
+		&i.ID,
+		&i.CartID,
+		&i.BeatID,
+		&i.LicenseID,
+		&i.Price,
+		&i.Currency,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteOrderedCartItems = `-- name: DeleteOrderedCartItems :execrows
+DELETE FROM cart_items
+USING carts
+WHERE carts.id = cart_items.cart_id
+    AND carts.user_id = $1::integer
+    AND cart_items.license_id IN (
+        SELECT license_id FROM order_items
+        WHERE order_id = $2
+    )
+`
+
+type DeleteOrderedCartItemsParams struct {
+	BuyerID int32 `json:"buyer_id"`
+	OrderID int64 `json:"order_id"`
+}
+
+// Removes the licenses bought with an order from the cart of the buyer
+func (q *Queries) DeleteOrderedCartItems(ctx context.Context, arg DeleteOrderedCartItemsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteOrderedCartItems, arg.BuyerID, arg.OrderID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getCart = `-- name: GetCart :one
+SELECT id, user_id, created_at FROM carts
+WHERE id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetCart(ctx context.Context, id uuid.UUID) (Cart, error) {
+	row := q.db.QueryRowContext(ctx, getCart, id)
+	var i Cart
+	err := row.Scan(&i.ID, &i.UserID, &i.CreatedAt)
+	return i, err
+}
+
+const getCartByUser = `-- name: GetCartByUser :one
+SELECT id, user_id, created_at FROM carts
+WHERE user_id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetCartByUser(ctx context.Context, userID sql.NullInt32) (Cart, error) {
+	row := q.db.QueryRowContext(ctx, getCartByUser, userID)
+	var i Cart
+	err := row.Scan(&i.ID, &i.UserID, &i.CreatedAt)
+	return i, err
+}
+
+const listCartItems = `-- name: ListCartItems :many
+SELECT cart_items.id, cart_items.cart_id, cart_items.beat_id, cart_items.license_id, cart_items.price, cart_items.currency, cart_items.created_at,
+    beats.title AS beat_title,
+    licenses.tier,
+    licenses.price AS current_price,
+    licenses.currency AS current_currency
+FROM cart_items
+JOIN beats ON beats.id = cart_items.beat_id
+JOIN licenses ON licenses.id = cart_items.license_id
+WHERE cart_items.cart_id = $1
+ORDER BY cart_items.created_at, cart_items.id
+`
+
+type ListCartItemsRow struct {
+	ID              int64     `json:"id"`
+	CartID          uuid.UUID `json:"cart_id"`
+	BeatID          int32     `json:"beat_id"`
+	LicenseID       int64     `json:"license_id"`
+	Price           int64     `json:"price"`
+	Currency        string    `json:"currency"`
+	CreatedAt       time.Time `json:"created_at"`
+	BeatTitle       string    `json:"beat_title"`
+	Tier            string    `json:"tier"`
+	CurrentPrice    int64     `json:"current_price"`
+	CurrentCurrency string    `json:"current_currency"`
+}
+
+// Lists the items of a cart in the order they were added, along with the beat and the current price of the license
+func (q *Queries) ListCartItems(ctx context.Context, cartID uuid.UUID) ([]ListCartItemsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listCartItems, cartID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListCartItemsRow{}
+	for rows.Next() {
+		var i ListCartItemsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CartID,
+			&i.BeatID,
+			&i.LicenseID,
+			&i.Price,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.BeatTitle,
+			&i.Tier,
+			&i.CurrentPrice,
+			&i.CurrentCurrency,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const moveCartItems = `-- name: MoveCartItems :execrows
+INSERT INTO cart_items (
+    cart_id,
+    beat_id,
+    license_id,
+    price,
+    currency
+)
+SELECT $1::uuid,
+    moved.beat_id,
+    moved.license_id,
+    moved.price,
+    moved.currency
+FROM cart_items moved
+JOIN beats ON beats.id = moved.beat_id
+WHERE moved.cart_id = $2 AND beats.creator_id <> $3
+ON CONFLICT (cart_id, beat_id) DO UPDATE
+SET license_id = EXCLUDED.license_id,
+    price = EXCLUDED.price,
+    currency = EXCLUDED.currency,
+    created_at = now()
+`
+
+type MoveCartItemsParams struct {
+	ToCartID   uuid.UUID `json:"to_cart_id"`
+	FromCartID uuid.UUID `json:"from_cart_id"`
+	UserID     int32     `json:"user_id"`
+}
+
+// Moves the items of one cart into another, the moved items replace those of the target cart for the same beat.
+// Beats of the user owning the target cart are left behind, nobody buys their own beat.
+func (q *Queries) MoveCartItems(ctx context.Context, arg MoveCartItemsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, moveCartItems, arg.ToCartID, arg.FromCartID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const upsertCartItem = `-- name: UpsertCartItem :one
+INSERT INTO cart_items (
+    cart_id,
+    beat_id,
+    license_id,
+    price,
+    currency
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+ON CONFLICT (cart_id, beat_id) DO UPDATE
+SET license_id = EXCLUDED.license_id,
+    price = EXCLUDED.price,
+    currency = EXCLUDED.currency,
+    created_at = now()
+RETURNING id, cart_id, beat_id, license_id, price, currency, created_at
+`
+
+type UpsertCartItemParams struct {
+	CartID    uuid.UUID `json:"cart_id"`
+	BeatID    int32     `json:"beat_id"`
+	LicenseID int64     `json:"license_id"`
+	Price     int64     `json:"price"`
+	Currency  string    `json:"currency"`
+}
+
+// Adds a beat to a cart, a beat already in the cart gets the new license and price
+func (q *Queries) UpsertCartItem(ctx context.Context, arg UpsertCartItemParams) (CartItem, error) {
+	row := q.db.QueryRowContext(ctx, upsertCartItem,
+		arg.CartID,
+		arg.BeatID,
+		arg.LicenseID,
+		arg.Price,
+		arg.Currency,
+	)
+	var i CartItem
+	err := row.Scan(
+		&i.ID,
+		&i.CartID,
+		&i.BeatID,
+		&i.LicenseID,
+		&i.Price,
+		&i.Currency,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const upsertUserCart = `-- name: UpsertUserCart :one
+INSERT INTO carts (
+    id,
+    user_id
+) VALUES (
+    $1, $2
+)
+ON CONFLICT (user_id) DO UPDATE
+SET user_id = EXCLUDED.user_id
+RETURNING id, user_id, created_at
+`
+
+type UpsertUserCartParams struct {
+	ID     uuid.UUID     `json:"id"`
+	UserID sql.NullInt32 `json:"user_id"`
+}
+
+// Returns the cart of the user, creating it with the given ID if they don't have one yet
+func (q *Queries) UpsertUserCart(ctx context.Context, arg UpsertUserCartParams) (Cart, error) {
+	row := q.db.QueryRowContext(ctx, upsertUserCart, arg.ID, arg.UserID)
+	var i Cart
+	err := row.Scan(&i.ID, &i.UserID, &i.CreatedAt)
+	return i, err
+}
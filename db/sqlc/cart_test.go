@@ -0,0 +1,183 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomCart(t *testing.T) Cart {
+	arg := CreateCartParams{ID: uuid.New()}
+
+	cart, err := testQueries.CreateCart(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.ID, cart.ID)
+	require.False(t, cart.UserID.Valid)
+	require.NotZero(t, cart.CreatedAt)
+
+	return cart
+}
+
+func upsertRandomCartItem(t *testing.T, cart Cart, license License) CartItem {
+	arg := UpsertCartItemParams{
+		CartID:    cart.ID,
+		BeatID:    license.BeatID,
+		LicenseID: license.ID,
+		Price:     license.Price,
+		Currency:  license.Currency,
+	}
+
+	item, err := testQueries.UpsertCartItem(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, item.ID)
+	require.Equal(t, arg.CartID, item.CartID)
+	require.Equal(t, arg.BeatID, item.BeatID)
+	require.Equal(t, arg.LicenseID, item.LicenseID)
+	require.Equal(t, arg.Price, item.Price)
+	require.Equal(t, arg.Currency, item.Currency)
+	require.NotZero(t, item.CreatedAt)
+
+	return item
+}
+
+func deleteRandomCart(t *testing.T, id uuid.UUID) {
+	err := testQueries.DeleteCart(context.Background(), id)
+	require.NoError(t, err)
+}
+
+func TestCreateCart(t *testing.T) {
+	cart1 := createRandomCart(t)
+
+	cart2, err := testQueries.GetCart(context.Background(), cart1.ID)
+	require.NoError(t, err)
+	require.Equal(t, cart1, cart2)
+
+	deleteRandomCart(t, cart1.ID)
+
+	_, err = testQueries.GetCart(context.Background(), cart1.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestUpsertUserCart(t *testing.T) {
+	user := createRandomUser(t)
+	userID := sql.NullInt32{Int32: user.ID, Valid: true}
+
+	_, err := testQueries.GetCartByUser(context.Background(), userID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	cart1, err := testQueries.UpsertUserCart(context.Background(), UpsertUserCartParams{ID: uuid.New(), UserID: userID})
+	require.NoError(t, err)
+	require.Equal(t, userID, cart1.UserID)
+
+	// A user has a single cart
+	cart2, err := testQueries.UpsertUserCart(context.Background(), UpsertUserCartParams{ID: uuid.New(), UserID: userID})
+	require.NoError(t, err)
+	require.Equal(t, cart1, cart2)
+
+	cart3, err := testQueries.GetCartByUser(context.Background(), userID)
+	require.NoError(t, err)
+	require.Equal(t, cart1, cart3)
+
+	deleteRandomUser(t, user.ID)
+
+	// Carts are deleted together with their user
+	_, err = testQueries.GetCart(context.Background(), cart1.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestUpsertCartItem(t *testing.T) {
+	beat := createRandomBeat(t)
+	basic := createRandomLicense(t, beat, util.BasicLicense, 2999)
+	premium := createRandomLicense(t, beat, util.PremiumLicense, 7999)
+	cart := createRandomCart(t)
+
+	// Adding the beat again switches its license
+	item1 := upsertRandomCartItem(t, cart, basic)
+	item2 := upsertRandomCartItem(t, cart, premium)
+	require.Equal(t, item1.ID, item2.ID)
+
+	rows, err := testQueries.ListCartItems(context.Background(), cart.ID)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, premium.ID, rows[0].LicenseID)
+	require.Equal(t, premium.Price, rows[0].Price)
+
+	deleteRandomCart(t, cart.ID)
+	deleteRandomBeat(t, beat.ID)
+	deleteRandomUser(t, beat.CreatorID)
+}
+
+func TestListCartItems(t *testing.T) {
+	beat1 := createRandomBeat(t)
+	beat2 := createRandomBeat(t)
+	license1 := createRandomLicense(t, beat1, util.BasicLicense, 2999)
+	license2 := createRandomLicense(t, beat2, util.ExclusiveLicense, 49999)
+	cart := createRandomCart(t)
+	item1 := upsertRandomCartItem(t, cart, license1)
+	item2 := upsertRandomCartItem(t, cart, license2)
+
+	// The price of the item stays when the license gets more expensive
+	_, err := testQueries.UpdateLicense(context.Background(), UpdateLicenseParams{
+		ID:        license1.ID,
+		Tier:      license1.Tier,
+		Price:     license1.Price + 1000,
+		Currency:  license1.Currency,
+		FileTypes: license1.FileTypes,
+		Terms:     license1.Terms,
+	})
+	require.NoError(t, err)
+
+	rows, err := testQueries.ListCartItems(context.Background(), cart.ID)
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	require.Equal(t, item1.ID, rows[0].ID)
+	require.Equal(t, beat1.Title, rows[0].BeatTitle)
+	require.Equal(t, license1.Tier, rows[0].Tier)
+	require.Equal(t, license1.Price, rows[0].Price)
+	require.Equal(t, license1.Price+1000, rows[0].CurrentPrice)
+	require.Equal(t, item2.ID, rows[1].ID)
+	require.Equal(t, license2.Price, rows[1].CurrentPrice)
+
+	// Items go away with their license
+	err = testQueries.DeleteLicense(context.Background(), license2.ID)
+	require.NoError(t, err)
+	rows, err = testQueries.ListCartItems(context.Background(), cart.ID)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+
+	deleteRandomCart(t, cart.ID)
+	for _, beat := range []Beat{beat1, beat2} {
+		deleteRandomBeat(t, beat.ID)
+		deleteRandomUser(t, beat.CreatorID)
+	}
+}
+
+func TestDeleteCartItem(t *testing.T) {
+	beat := createRandomBeat(t)
+	license := createRandomLicense(t, beat, util.BasicLicense, 2999)
+	cart := createRandomCart(t)
+	other := createRandomCart(t)
+	item := upsertRandomCartItem(t, cart, license)
+
+	// Items can only be removed from their own cart
+	_, err := testQueries.DeleteCartItem(context.Background(), DeleteCartItemParams{ID: item.ID, CartID: other.ID})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	deleted, err := testQueries.DeleteCartItem(context.Background(), DeleteCartItemParams{ID: item.ID, CartID: cart.ID})
+	require.NoError(t, err)
+	require.Equal(t, item, deleted)
+
+	rows, err := testQueries.ListCartItems(context.Background(), cart.ID)
+	require.NoError(t, err)
+	require.Empty(t, rows)
+
+	for _, cart := range []Cart{cart, other} {
+		deleteRandomCart(t, cart.ID)
+	}
+	deleteRandomBeat(t, beat.ID)
+	deleteRandomUser(t, beat.CreatorID)
+}
@@ -0,0 +1,201 @@
+// Code generated by sqlc. DO NOT EDIT.
+// source: duplicate_match.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const createDuplicateMatch = `-- name: CreateDuplicateMatch :one
+INSERT INTO duplicate_matches (
+    beat_id,
+    matched_beat_id,
+    kind,
+    bit_error_rate,
+    rejected
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+ON CONFLICT (beat_id, matched_beat_id) DO UPDATE
+SET kind = EXCLUDED.kind,
+    bit_error_rate = EXCLUDED.bit_error_rate,
+    rejected = EXCLUDED.rejected,
+    status = 'pending',
+    reviewed_by = NULL,
+    reviewed_at = NULL,
+    created_at = now()
+RETURNING id, beat_id, matched_beat_id, kind, bit_error_rate, rejected, status, reviewed_by, reviewed_at, created_at
+`
+
+type CreateDuplicateMatchParams struct {
+	BeatID        int32   `json:"beat_id"`
+	MatchedBeatID int32   `json:"matched_beat_id"`
+	Kind          string  `json:"kind"`
+	BitErrorRate  float32 `json:"bit_error_rate"`
+	Rejected      bool    `json:"rejected"`
+}
+
+// Puts a match into the moderation queue. A beat matching the same beat again
+// replaces the earlier match and awaits a new review.
+func (q *Queries) CreateDuplicateMatch(ctx context.Context, arg CreateDuplicateMatchParams) (DuplicateMatch, error) {
+	row := q.db.QueryRowContext(ctx, createDuplicateMatch,
+		arg.BeatID,
+		arg.MatchedBeatID,
+		arg.Kind,
+		arg.BitErrorRate,
+		arg.Rejected,
+	)
+	var i DuplicateMatch
+	err := row.Scan(
+		&i.ID,
+		&i.BeatID,
+		&i.MatchedBeatID,
+		&i.Kind,
+		&i.BitErrorRate,
+		&i.Rejected,
+		&i.Status,
+		&i.ReviewedBy,
+		&i.ReviewedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getDuplicateMatchForUpdate = `-- name: GetDuplicateMatchForUpdate :one
+SELECT id, beat_id, matched_beat_id, kind, bit_error_rate, rejected, status, reviewed_by, reviewed_at, created_at FROM duplicate_matches
+WHERE id = $1
+LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetDuplicateMatchForUpdate(ctx context.Context, id int64) (DuplicateMatch, error) {
+	row := q.db.QueryRowContext(ctx, getDuplicateMatchForUpdate, id)
+	var i DuplicateMatch
+	err := row.Scan(
+		&i.ID,
+		&i.BeatID,
+		&i.MatchedBeatID,
+		&i.Kind,
+		&i.BitErrorRate,
+		&i.Rejected,
+		&i.Status,
+		&i.ReviewedBy,
+		&i.ReviewedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listDuplicateMatches = `-- name: ListDuplicateMatches :many
+SELECT
+    duplicate_matches.id, duplicate_matches.beat_id, duplicate_matches.matched_beat_id, duplicate_matches.kind, duplicate_matches.bit_error_rate, duplicate_matches.rejected, duplicate_matches.status, duplicate_matches.reviewed_by, duplicate_matches.reviewed_at, duplicate_matches.created_at,
+    beats.title AS beat_title,
+    beats.creator_id AS beat_creator_id,
+    matched.title AS matched_beat_title,
+    matched.creator_id AS matched_beat_creator_id
+FROM duplicate_matches
+JOIN beats ON beats.id = duplicate_matches.beat_id
+JOIN beats matched ON matched.id = duplicate_matches.matched_beat_id
+WHERE duplicate_matches.status = $1
+ORDER BY duplicate_matches.id
+LIMIT $2
+OFFSET $3
+`
+
+type ListDuplicateMatchesParams struct {
+	Status string `json:"status"`
+	Limit  int32  `json:"limit"`
+	Offset int32  `json:"offset"`
+}
+
+type ListDuplicateMatchesRow struct {
+	ID                   int64         `json:"id"`
+	BeatID               int32         `json:"beat_id"`
+	MatchedBeatID        int32         `json:"matched_beat_id"`
+	Kind                 string        `json:"kind"`
+	BitErrorRate         float32       `json:"bit_error_rate"`
+	Rejected             bool          `json:"rejected"`
+	Status               string        `json:"status"`
+	ReviewedBy           sql.NullInt32 `json:"reviewed_by"`
+	ReviewedAt           sql.NullTime  `json:"reviewed_at"`
+	CreatedAt            time.Time     `json:"created_at"`
+	BeatTitle            string        `json:"beat_title"`
+	BeatCreatorID        int32         `json:"beat_creator_id"`
+	MatchedBeatTitle     string        `json:"matched_beat_title"`
+	MatchedBeatCreatorID int32         `json:"matched_beat_creator_id"`
+}
+
+// Lists the matches in the given status along with both beats, oldest first
+func (q *Queries) ListDuplicateMatches(ctx context.Context, arg ListDuplicateMatchesParams) ([]ListDuplicateMatchesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listDuplicateMatches, arg.Status, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListDuplicateMatchesRow{}
+	for rows.Next() {
+		var i ListDuplicateMatchesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.BeatID,
+			&i.MatchedBeatID,
+			&i.Kind,
+			&i.BitErrorRate,
+			&i.Rejected,
+			&i.Status,
+			&i.ReviewedBy,
+			&i.ReviewedAt,
+			&i.CreatedAt,
+			&i.BeatTitle,
+			&i.BeatCreatorID,
+			&i.MatchedBeatTitle,
+			&i.MatchedBeatCreatorID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const reviewDuplicateMatch = `-- name: ReviewDuplicateMatch :one
+UPDATE duplicate_matches
+SET status = $2,
+    reviewed_by = $3,
+    reviewed_at = now()
+WHERE id = $1
+RETURNING id, beat_id, matched_beat_id, kind, bit_error_rate, rejected, status, reviewed_by, reviewed_at, created_at
+`
+
+type ReviewDuplicateMatchParams struct {
+	ID         int64         `json:"id"`
+	Status     string        `json:"status"`
+	ReviewedBy sql.NullInt32 `json:"reviewed_by"`
+}
+
+func (q *Queries) ReviewDuplicateMatch(ctx context.Context, arg ReviewDuplicateMatchParams) (DuplicateMatch, error) {
+	row := q.db.QueryRowContext(ctx, reviewDuplicateMatch, arg.ID, arg.Status, arg.ReviewedBy)
+	var i DuplicateMatch
+	err := row.Scan(
+		&i.ID,
+		&i.BeatID,
+		&i.MatchedBeatID,
+		&i.Kind,
+		&i.BitErrorRate,
+		&i.Rejected,
+		&i.Status,
+		&i.ReviewedBy,
+		&i.ReviewedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
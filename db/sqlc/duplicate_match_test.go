@@ -0,0 +1,112 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomDuplicateMatch(t *testing.T, beat, matched Beat) DuplicateMatch {
+	arg := CreateDuplicateMatchParams{
+		BeatID:        beat.ID,
+		MatchedBeatID: matched.ID,
+		Kind:          util.FingerprintMatch,
+		BitErrorRate:  0.12,
+	}
+
+	match, err := testQueries.CreateDuplicateMatch(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, match.ID)
+	require.Equal(t, arg.BeatID, match.BeatID)
+	require.Equal(t, arg.MatchedBeatID, match.MatchedBeatID)
+	require.Equal(t, arg.Kind, match.Kind)
+	require.Equal(t, arg.BitErrorRate, match.BitErrorRate)
+	require.False(t, match.Rejected)
+	require.Equal(t, util.PendingMatch, match.Status)
+	require.False(t, match.ReviewedBy.Valid)
+	require.False(t, match.ReviewedAt.Valid)
+	require.NotZero(t, match.CreatedAt)
+
+	return match
+}
+
+func TestCreateDuplicateMatch(t *testing.T) {
+	beat := createRandomBeat(t)
+	matched := createRandomBeat(t)
+	admin := createRandomUser(t)
+	match1 := createRandomDuplicateMatch(t, beat, matched)
+
+	_, err := testQueries.ReviewDuplicateMatch(context.Background(), ReviewDuplicateMatchParams{
+		ID:         match1.ID,
+		Status:     util.DismissedMatch,
+		ReviewedBy: sql.NullInt32{Int32: admin.ID, Valid: true},
+	})
+	require.NoError(t, err)
+
+	// Matching the same beat again replaces the match and awaits a new review
+	match2, err := testQueries.CreateDuplicateMatch(context.Background(), CreateDuplicateMatchParams{
+		BeatID:        beat.ID,
+		MatchedBeatID: matched.ID,
+		Kind:          util.ContentHashMatch,
+		Rejected:      true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, match1.ID, match2.ID)
+	require.Equal(t, util.ContentHashMatch, match2.Kind)
+	require.Zero(t, match2.BitErrorRate)
+	require.True(t, match2.Rejected)
+	require.Equal(t, util.PendingMatch, match2.Status)
+	require.False(t, match2.ReviewedBy.Valid)
+
+	for _, beat := range []Beat{beat, matched} {
+		deleteRandomBeat(t, beat.ID)
+		deleteRandomUser(t, beat.CreatorID)
+	}
+	deleteRandomUser(t, admin.ID)
+}
+
+func TestListDuplicateMatches(t *testing.T) {
+	matched := createRandomBeat(t)
+	var beats []Beat
+	var matches []DuplicateMatch
+	for i := 0; i < 3; i++ {
+		beat := createRandomBeat(t)
+		beats = append(beats, beat)
+		matches = append(matches, createRandomDuplicateMatch(t, beat, matched))
+	}
+
+	_, err := testQueries.ReviewDuplicateMatch(context.Background(), ReviewDuplicateMatchParams{
+		ID:     matches[1].ID,
+		Status: util.ConfirmedMatch,
+	})
+	require.NoError(t, err)
+
+	pending, err := testQueries.ListDuplicateMatches(context.Background(), ListDuplicateMatchesParams{
+		Status: util.PendingMatch,
+		Limit:  1000,
+	})
+	require.NoError(t, err)
+
+	var listed []ListDuplicateMatchesRow
+	for _, match := range pending {
+		if match.MatchedBeatID == matched.ID {
+			listed = append(listed, match)
+		}
+	}
+	require.Len(t, listed, 2)
+	for i, j := range []int{0, 2} {
+		require.Equal(t, matches[j].ID, listed[i].ID)
+		require.Equal(t, beats[j].Title, listed[i].BeatTitle)
+		require.Equal(t, beats[j].CreatorID, listed[i].BeatCreatorID)
+		require.Equal(t, matched.Title, listed[i].MatchedBeatTitle)
+		require.Equal(t, matched.CreatorID, listed[i].MatchedBeatCreatorID)
+	}
+
+	for _, beat := range append(beats, matched) {
+		deleteRandomBeat(t, beat.ID)
+		deleteRandomUser(t, beat.CreatorID)
+	}
+}
@@ -0,0 +1,90 @@
+// Code generated by sqlc. DO NOT EDIT.
+// source: email_verification.sql
+
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const createEmailVerification = `-- name: CreateEmailVerification :one
+INSERT INTO email_verifications (
+    user_id,
+    email,
+    hashed_token,
+    expires_at
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING id, user_id, email, hashed_token, expires_at, used_at, created_at
+`
+
+type CreateEmailVerificationParams struct {
+	UserID      int32     `json:"user_id"`
+	Email       string    `json:"email"`
+	HashedToken string    `json:"hashed_token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+func (q *Queries) CreateEmailVerification(ctx context.Context, arg CreateEmailVerificationParams) (EmailVerification, error) {
+	row := q.db.QueryRowContext(ctx, createEmailVerification,
+		arg.UserID,
+		arg.Email,
+		arg.HashedToken,
+		arg.ExpiresAt,
+	)
+	var i EmailVerification
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Email,
+		&i.HashedToken,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getEmailVerificationByHashedToken = `-- name: GetEmailVerificationByHashedToken :one
+SELECT id, user_id, email, hashed_token, expires_at, used_at, created_at FROM email_verifications
+WHERE hashed_token = $1
+LIMIT 1
+`
+
+func (q *Queries) GetEmailVerificationByHashedToken(ctx context.Context, hashedToken string) (EmailVerification, error) {
+	row := q.db.QueryRowContext(ctx, getEmailVerificationByHashedToken, hashedToken)
+	var i EmailVerification
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Email,
+		&i.HashedToken,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const useEmailVerification = `-- name: UseEmailVerification :one
+UPDATE email_verifications
+SET used_at = now()
+WHERE id = $1 AND used_at IS NULL
+RETURNING id, user_id, email, hashed_token, expires_at, used_at, created_at
+`
+
+func (q *Queries) UseEmailVerification(ctx context.Context, id int64) (EmailVerification, error) {
+	row := q.db.QueryRowContext(ctx, useEmailVerification, id)
+	var i EmailVerification
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Email,
+		&i.HashedToken,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
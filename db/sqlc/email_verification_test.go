@@ -0,0 +1,68 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomEmailVerification(t *testing.T, user User) EmailVerification {
+	arg := CreateEmailVerificationParams{
+		UserID:      user.ID,
+		Email:       user.Email,
+		HashedToken: util.HashSecretToken(util.RandomString(32)),
+		ExpiresAt:   time.Now().Add(time.Hour),
+	}
+
+	verification, err := testQueries.CreateEmailVerification(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, verification)
+
+	require.NotZero(t, verification.ID)
+	require.Equal(t, arg.UserID, verification.UserID)
+	require.Equal(t, arg.Email, verification.Email)
+	require.Equal(t, arg.HashedToken, verification.HashedToken)
+	require.WithinDuration(t, arg.ExpiresAt, verification.ExpiresAt, time.Second)
+	require.False(t, verification.UsedAt.Valid)
+	require.NotZero(t, verification.CreatedAt)
+
+	return verification
+}
+
+func TestCreateEmailVerification(t *testing.T) {
+	user := createRandomUser(t)
+	createRandomEmailVerification(t, user)
+	deleteRandomUser(t, user.ID)
+}
+
+func TestGetEmailVerificationByHashedToken(t *testing.T) {
+	user := createRandomUser(t)
+	verification1 := createRandomEmailVerification(t, user)
+
+	verification2, err := testQueries.GetEmailVerificationByHashedToken(context.Background(), verification1.HashedToken)
+	require.NoError(t, err)
+	require.Equal(t, verification1.ID, verification2.ID)
+	require.Equal(t, verification1.UserID, verification2.UserID)
+	require.Equal(t, verification1.Email, verification2.Email)
+
+	deleteRandomUser(t, user.ID)
+}
+
+func TestUseEmailVerification(t *testing.T) {
+	user := createRandomUser(t)
+	verification := createRandomEmailVerification(t, user)
+
+	used, err := testQueries.UseEmailVerification(context.Background(), verification.ID)
+	require.NoError(t, err)
+	require.True(t, used.UsedAt.Valid)
+
+	// A verification can only be used once
+	_, err = testQueries.UseEmailVerification(context.Background(), verification.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	deleteRandomUser(t, user.ID)
+}
@@ -0,0 +1,298 @@
+// Code generated by sqlc. DO NOT EDIT.
+// source: license.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+const countOrderedExclusives = `-- name: CountOrderedExclusives :one
+SELECT count(*) FROM order_items
+JOIN licenses ON licenses.id = order_items.license_id
+WHERE order_items.order_id = $1
+    AND licenses.tier = 'exclusive'
+`
+
+func (q *Queries) CountOrderedExclusives(ctx context.Context, orderID int64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countOrderedExclusives, orderID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createLicense = `-- name: CreateLicense :one
+INSERT INTO licenses (
+    beat_id,
+    tier,
+    price,
+    currency,
+    file_types,
+    distribution_cap,
+    stream_cap,
+    terms
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+RETURNING id, beat_id, tier, price, currency, file_types, distribution_cap, stream_cap, terms, created_at, updated_at, sold_at, reserved_order_id
+`
+
+type CreateLicenseParams struct {
+	BeatID          int32         `json:"beat_id"`
+	Tier            string        `json:"tier"`
+	Price           int64         `json:"price"`
+	Currency        string        `json:"currency"`
+	FileTypes       []string      `json:"file_types"`
+	DistributionCap sql.NullInt64 `json:"distribution_cap"`
+	StreamCap       sql.NullInt64 `json:"stream_cap"`
+	Terms           string        `json:"terms"`
+}
+
+func (q *Queries) CreateLicense(ctx context.Context, arg CreateLicenseParams) (License, error) {
+	row := q.db.QueryRowContext(ctx, createLicense,
+		arg.BeatID,
+		arg.Tier,
+		arg.Price,
+		arg.Currency,
+		pq.Array(arg.FileTypes),
+		arg.DistributionCap,
+		arg.StreamCap,
+		arg.Terms,
+	)
+	var i License
+	err := row.Scan(
+		&i.ID,
+		&i.BeatID,
+		&i.Tier,
+		&i.Price,
+		&i.Currency,
+		pq.Array(&i.FileTypes),
+		&i.DistributionCap,
+		&i.StreamCap,
+		&i.Terms,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.SoldAt,
+		&i.ReservedOrderID,
+	)
+	return i, err
+}
+
+const deleteLicense = `-- name: DeleteLicense :exec
+DELETE FROM licenses
+WHERE id = $1
+`
+
+func (q *Queries) DeleteLicense(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteLicense, id)
+	return err
+}
+
+const getLicense = `-- name: GetLicense :one
+SELECT id, beat_id, tier, price, currency, file_types, distribution_cap, stream_cap, terms, created_at, updated_at, sold_at, reserved_order_id FROM licenses
+WHERE id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetLicense(ctx context.Context, id int64) (License, error) {
+	row := q.db.QueryRowContext(ctx, getLicense, id)
+	var i License
+	err := row.Scan(
+		&i.ID,
+		&i.BeatID,
+		&i.Tier,
+		&i.Price,
+		&i.Currency,
+		pq.Array(&i.FileTypes),
+		&i.DistributionCap,
+		&i.StreamCap,
+		&i.Terms,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.SoldAt,
+		&i.ReservedOrderID,
+	)
+	return i, err
+}
+
+const getLicenseForUpdate = `-- name: GetLicenseForUpdate :one
+SELECT id, beat_id, tier, price, currency, file_types, distribution_cap, stream_cap, terms, created_at, updated_at, sold_at, reserved_order_id FROM licenses
+WHERE id = $1
+LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetLicenseForUpdate(ctx context.Context, id int64) (License, error) {
+	row := q.db.QueryRowContext(ctx, getLicenseForUpdate, id)
+	var i License
+	err := row.Scan(
+		&i.ID,
+		&i.BeatID,
+		&i.Tier,
+		&i.Price,
+		&i.Currency,
+		pq.Array(&i.FileTypes),
+		&i.DistributionCap,
+		&i.StreamCap,
+		&i.Terms,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.SoldAt,
+		&i.ReservedOrderID,
+	)
+	return i, err
+}
+
+const listLicensesByBeat = `-- name: ListLicensesByBeat :many
+SELECT id, beat_id, tier, price, currency, file_types, distribution_cap, stream_cap, terms, created_at, updated_at, sold_at, reserved_order_id FROM licenses
+WHERE beat_id = $1
+ORDER BY price, id
+`
+
+// Lists the license tiers of a beat, cheapest first
+func (q *Queries) ListLicensesByBeat(ctx context.Context, beatID int32) ([]License, error) {
+	rows, err := q.db.QueryContext(ctx, listLicensesByBeat, beatID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []License{}
+	for rows.Next() {
+		var i License
+		if err := rows.Scan(
+			&i.ID,
+			&i.BeatID,
+			&i.Tier,
+			&i.Price,
+			&i.Currency,
+			pq.Array(&i.FileTypes),
+			&i.DistributionCap,
+			&i.StreamCap,
+			&i.Terms,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.SoldAt,
+			&i.ReservedOrderID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markOrderedExclusivesSold = `-- name: MarkOrderedExclusivesSold :execrows
+UPDATE licenses
+SET sold_at = now()
+WHERE tier = 'exclusive'
+    AND sold_at IS NULL
+    AND (reserved_order_id IS NULL OR reserved_order_id = $1::bigint)
+    AND id IN (
+        SELECT license_id FROM order_items
+        WHERE order_id = $1
+    )
+`
+
+// Marks the exclusive licenses bought with an order as sold,
+// licenses which were sold or reserved for another order are left alone
+func (q *Queries) MarkOrderedExclusivesSold(ctx context.Context, orderID int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, markOrderedExclusivesSold, orderID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const releaseOrderedLicenses = `-- name: ReleaseOrderedLicenses :exec
+UPDATE licenses
+SET reserved_order_id = NULL
+WHERE reserved_order_id = $1::bigint
+`
+
+// Frees the licenses reserved for a failed order
+func (q *Queries) ReleaseOrderedLicenses(ctx context.Context, orderID int64) error {
+	_, err := q.db.ExecContext(ctx, releaseOrderedLicenses, orderID)
+	return err
+}
+
+const reserveLicense = `-- name: ReserveLicense :exec
+UPDATE licenses
+SET reserved_order_id = $1::bigint
+WHERE id = $2
+`
+
+type ReserveLicenseParams struct {
+	OrderID int64 `json:"order_id"`
+	ID      int64 `json:"id"`
+}
+
+// Holds an exclusive license for an order until it is paid or fails
+func (q *Queries) ReserveLicense(ctx context.Context, arg ReserveLicenseParams) error {
+	_, err := q.db.ExecContext(ctx, reserveLicense, arg.OrderID, arg.ID)
+	return err
+}
+
+const updateLicense = `-- name: UpdateLicense :one
+UPDATE licenses
+SET tier = $2,
+    price = $3,
+    currency = $4,
+    file_types = $5,
+    distribution_cap = $6,
+    stream_cap = $7,
+    terms = $8,
+    updated_at = now()
+WHERE id = $1 AND sold_at IS NULL
+RETURNING id, beat_id, tier, price, currency, file_types, distribution_cap, stream_cap, terms, created_at, updated_at, sold_at, reserved_order_id
+`
+
+type UpdateLicenseParams struct {
+	ID              int64         `json:"id"`
+	Tier            string        `json:"tier"`
+	Price           int64         `json:"price"`
+	Currency        string        `json:"currency"`
+	FileTypes       []string      `json:"file_types"`
+	DistributionCap sql.NullInt64 `json:"distribution_cap"`
+	StreamCap       sql.NullInt64 `json:"stream_cap"`
+	Terms           string        `json:"terms"`
+}
+
+// Sold licenses keep the terms they were bought under
+func (q *Queries) UpdateLicense(ctx context.Context, arg UpdateLicenseParams) (License, error) {
+	row := q.db.QueryRowContext(ctx, updateLicense,
+		arg.ID,
+		arg.Tier,
+		arg.Price,
+		arg.Currency,
+		pq.Array(arg.FileTypes),
+		arg.DistributionCap,
+		arg.StreamCap,
+		arg.Terms,
+	)
+	var i License
+	err := row.Scan(
+		&i.ID,
+		&i.BeatID,
+		&i.Tier,
+		&i.Price,
+		&i.Currency,
+		pq.Array(&i.FileTypes),
+		&i.DistributionCap,
+		&i.StreamCap,
+		&i.Terms,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.SoldAt,
+		&i.ReservedOrderID,
+	)
+	return i, err
+}
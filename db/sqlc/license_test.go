@@ -0,0 +1,134 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomLicense(t *testing.T, beat Beat, tier string, price int64) License {
+	arg := CreateLicenseParams{
+		BeatID:          beat.ID,
+		Tier:            tier,
+		Price:           price,
+		Currency:        "USD",
+		FileTypes:       []string{util.MP3File, util.MasterWAVFile},
+		DistributionCap: sql.NullInt64{Int64: util.RandomInt(1, 10000), Valid: true},
+		Terms:           util.RandomString(64),
+	}
+
+	license, err := testQueries.CreateLicense(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, license.ID)
+	require.Equal(t, arg.BeatID, license.BeatID)
+	require.Equal(t, arg.Tier, license.Tier)
+	require.Equal(t, arg.Price, license.Price)
+	require.Equal(t, arg.Currency, license.Currency)
+	require.Equal(t, arg.FileTypes, license.FileTypes)
+	require.Equal(t, arg.DistributionCap, license.DistributionCap)
+	require.False(t, license.StreamCap.Valid)
+	require.Equal(t, arg.Terms, license.Terms)
+	require.NotZero(t, license.CreatedAt)
+	require.NotZero(t, license.UpdatedAt)
+
+	return license
+}
+
+func TestCreateLicense(t *testing.T) {
+	beat := createRandomBeat(t)
+	createRandomLicense(t, beat, util.BasicLicense, 2999)
+
+	// A beat is offered at most once per tier
+	_, err := testQueries.CreateLicense(context.Background(), CreateLicenseParams{
+		BeatID:    beat.ID,
+		Tier:      util.BasicLicense,
+		Price:     4999,
+		Currency:  "USD",
+		FileTypes: []string{util.MP3File},
+		Terms:     util.RandomString(64),
+	})
+	var pqErr *pq.Error
+	require.ErrorAs(t, err, &pqErr)
+	require.Equal(t, "unique_violation", pqErr.Code.Name())
+
+	deleteRandomBeat(t, beat.ID)
+	deleteRandomUser(t, beat.CreatorID)
+}
+
+func TestListLicensesByBeat(t *testing.T) {
+	beat := createRandomBeat(t)
+	other := createRandomBeat(t)
+	exclusive := createRandomLicense(t, beat, util.ExclusiveLicense, 49999)
+	basic := createRandomLicense(t, beat, util.BasicLicense, 2999)
+	premium := createRandomLicense(t, beat, util.PremiumLicense, 7999)
+	createRandomLicense(t, other, util.BasicLicense, 999)
+
+	// Cheapest first
+	licenses, err := testQueries.ListLicensesByBeat(context.Background(), beat.ID)
+	require.NoError(t, err)
+	require.Len(t, licenses, 3)
+	require.Equal(t, basic.ID, licenses[0].ID)
+	require.Equal(t, premium.ID, licenses[1].ID)
+	require.Equal(t, exclusive.ID, licenses[2].ID)
+
+	for _, beat := range []Beat{beat, other} {
+		deleteRandomBeat(t, beat.ID)
+		deleteRandomUser(t, beat.CreatorID)
+	}
+
+	// Licenses are deleted together with their beat
+	_, err = testQueries.GetLicense(context.Background(), basic.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestUpdateLicense(t *testing.T) {
+	beat := createRandomBeat(t)
+	license1 := createRandomLicense(t, beat, util.PremiumLicense, 7999)
+
+	arg := UpdateLicenseParams{
+		ID:        license1.ID,
+		Tier:      util.UnlimitedLicense,
+		Price:     19999,
+		Currency:  "EUR",
+		FileTypes: []string{util.MasterWAVFile, util.StemsFile},
+		StreamCap: sql.NullInt64{Int64: 1000000, Valid: true},
+		Terms:     util.RandomString(64),
+	}
+	license2, err := testQueries.UpdateLicense(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, license1.ID, license2.ID)
+	require.Equal(t, arg.Tier, license2.Tier)
+	require.Equal(t, arg.Price, license2.Price)
+	require.Equal(t, arg.Currency, license2.Currency)
+	require.Equal(t, arg.FileTypes, license2.FileTypes)
+	require.False(t, license2.DistributionCap.Valid)
+	require.Equal(t, arg.StreamCap, license2.StreamCap)
+	require.Equal(t, arg.Terms, license2.Terms)
+	require.Equal(t, license1.CreatedAt, license2.CreatedAt)
+	require.True(t, license2.UpdatedAt.After(license1.UpdatedAt))
+
+	license3, err := testQueries.GetLicense(context.Background(), license1.ID)
+	require.NoError(t, err)
+	require.Equal(t, license2, license3)
+
+	deleteRandomBeat(t, beat.ID)
+	deleteRandomUser(t, beat.CreatorID)
+}
+
+func TestDeleteLicense(t *testing.T) {
+	beat := createRandomBeat(t)
+	license := createRandomLicense(t, beat, util.BasicLicense, 2999)
+
+	err := testQueries.DeleteLicense(context.Background(), license.ID)
+	require.NoError(t, err)
+
+	_, err = testQueries.GetLicense(context.Background(), license.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	deleteRandomBeat(t, beat.ID)
+	deleteRandomUser(t, beat.CreatorID)
+}
@@ -0,0 +1,90 @@
+// Code generated by sqlc. DO NOT EDIT.
+// source: login_event.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+const createLoginEvent = `-- name: CreateLoginEvent :one
+INSERT INTO login_events (
+    username,
+    client_ip,
+    event,
+    actor_id
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING id, username, client_ip, event, actor_id, created_at
+`
+
+type CreateLoginEventParams struct {
+	Username string        `json:"username"`
+	ClientIp string        `json:"client_ip"`
+	Event    string        `json:"event"`
+	ActorID  sql.NullInt32 `json:"actor_id"`
+}
+
+func (q *Queries) CreateLoginEvent(ctx context.Context, arg CreateLoginEventParams) (LoginEvent, error) {
+	row := q.db.QueryRowContext(ctx, createLoginEvent,
+		arg.Username,
+		arg.ClientIp,
+		arg.Event,
+		arg.ActorID,
+	)
+	var i LoginEvent
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.ClientIp,
+		&i.Event,
+		&i.ActorID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listLoginEventsByUsername = `-- name: ListLoginEventsByUsername :many
+SELECT id, username, client_ip, event, actor_id, created_at FROM login_events
+WHERE username = $1
+ORDER BY id DESC
+LIMIT $2
+OFFSET $3
+`
+
+type ListLoginEventsByUsernameParams struct {
+	Username string `json:"username"`
+	Limit    int32  `json:"limit"`
+	Offset   int32  `json:"offset"`
+}
+
+func (q *Queries) ListLoginEventsByUsername(ctx context.Context, arg ListLoginEventsByUsernameParams) ([]LoginEvent, error) {
+	rows, err := q.db.QueryContext(ctx, listLoginEventsByUsername, arg.Username, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []LoginEvent{}
+	for rows.Next() {
+		var i LoginEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.ClientIp,
+			&i.Event,
+			&i.ActorID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
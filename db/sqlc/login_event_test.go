@@ -0,0 +1,60 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomLoginEvent(t *testing.T, username string, actorID sql.NullInt32) LoginEvent {
+	arg := CreateLoginEventParams{
+		Username: username,
+		ClientIp: "192.0.2.1",
+		Event:    "login_failed",
+		ActorID:  actorID,
+	}
+
+	event, err := testQueries.CreateLoginEvent(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, event.ID)
+	require.Equal(t, arg.Username, event.Username)
+	require.Equal(t, arg.ClientIp, event.ClientIp)
+	require.Equal(t, arg.Event, event.Event)
+	require.Equal(t, arg.ActorID, event.ActorID)
+	require.NotZero(t, event.CreatedAt)
+
+	return event
+}
+
+func TestCreateLoginEvent(t *testing.T) {
+	admin := createRandomUser(t)
+	createRandomLoginEvent(t, util.RandomUsername(), sql.NullInt32{})
+	createRandomLoginEvent(t, util.RandomUsername(), sql.NullInt32{Int32: admin.ID, Valid: true})
+	deleteRandomUser(t, admin.ID)
+}
+
+func TestListLoginEventsByUsername(t *testing.T) {
+	username := util.RandomUsername()
+	var events []LoginEvent
+	for i := 0; i < 5; i++ {
+		events = append(events, createRandomLoginEvent(t, username, sql.NullInt32{}))
+	}
+
+	arg := ListLoginEventsByUsernameParams{
+		Username: username,
+		Limit:    3,
+		Offset:   1,
+	}
+
+	got, err := testQueries.ListLoginEventsByUsername(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, got, 3)
+
+	// Newest events come first
+	require.Equal(t, events[3].ID, got[0].ID)
+	require.Equal(t, events[2].ID, got[1].ID)
+	require.Equal(t, events[1].ID, got[2].ID)
+}
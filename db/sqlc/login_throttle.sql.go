@@ -0,0 +1,63 @@
+// Code generated by sqlc. DO NOT EDIT.
+// source: login_throttle.sql
+
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const deleteLoginThrottle = `-- name: DeleteLoginThrottle :exec
+DELETE FROM login_throttles
+WHERE key = $1
+`
+
+func (q *Queries) DeleteLoginThrottle(ctx context.Context, key string) error {
+	_, err := q.db.ExecContext(ctx, deleteLoginThrottle, key)
+	return err
+}
+
+const getLoginThrottle = `-- name: GetLoginThrottle :one
+SELECT key, failures, last_failure_at FROM login_throttles
+WHERE key = $1
+LIMIT 1
+`
+
+func (q *Queries) GetLoginThrottle(ctx context.Context, key string) (LoginThrottle, error) {
+	row := q.db.QueryRowContext(ctx, getLoginThrottle, key)
+	var i LoginThrottle
+	err := row.Scan(&i.Key, &i.Failures, &i.LastFailureAt)
+	return i, err
+}
+
+const recordLoginFailure = `-- name: RecordLoginFailure :one
+INSERT INTO login_throttles (
+    key,
+    failures,
+    last_failure_at
+) VALUES (
+    $1, 1, $2
+)
+ON CONFLICT (key) DO UPDATE
+SET failures = CASE
+        WHEN login_throttles.last_failure_at < $3 THEN 1
+        ELSE login_throttles.failures + 1
+    END,
+    last_failure_at = EXCLUDED.last_failure_at
+RETURNING key, failures, last_failure_at
+`
+
+type RecordLoginFailureParams struct {
+	Key         string    `json:"key"`
+	FailedAt    time.Time `json:"failed_at"`
+	WindowStart time.Time `json:"window_start"`
+}
+
+// Failures recorded before window_start are forgotten and counting starts over.
+func (q *Queries) RecordLoginFailure(ctx context.Context, arg RecordLoginFailureParams) (LoginThrottle, error) {
+	row := q.db.QueryRowContext(ctx, recordLoginFailure, arg.Key, arg.FailedAt, arg.WindowStart)
+	var i LoginThrottle
+	err := row.Scan(&i.Key, &i.Failures, &i.LastFailureAt)
+	return i, err
+}
@@ -0,0 +1,61 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/stretchr/testify/require"
+)
+
+func recordRandomLoginFailure(t *testing.T, key string, failedAt time.Time) LoginThrottle {
+	arg := RecordLoginFailureParams{
+		Key:         key,
+		FailedAt:    failedAt,
+		WindowStart: failedAt.Add(-15 * time.Minute),
+	}
+
+	throttle, err := testQueries.RecordLoginFailure(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Key, throttle.Key)
+	require.WithinDuration(t, arg.FailedAt, throttle.LastFailureAt, time.Second)
+
+	return throttle
+}
+
+func TestRecordLoginFailure(t *testing.T) {
+	key := "username:" + util.RandomUsername()
+	now := time.Now()
+
+	throttle := recordRandomLoginFailure(t, key, now)
+	require.Equal(t, int32(1), throttle.Failures)
+
+	throttle = recordRandomLoginFailure(t, key, now.Add(time.Minute))
+	require.Equal(t, int32(2), throttle.Failures)
+
+	// Failures outside of the window are forgotten
+	throttle = recordRandomLoginFailure(t, key, now.Add(time.Hour))
+	require.Equal(t, int32(1), throttle.Failures)
+
+	err := testQueries.DeleteLoginThrottle(context.Background(), key)
+	require.NoError(t, err)
+}
+
+func TestGetLoginThrottle(t *testing.T) {
+	key := "ip:" + util.RandomString(12)
+	throttle1 := recordRandomLoginFailure(t, key, time.Now())
+
+	throttle2, err := testQueries.GetLoginThrottle(context.Background(), key)
+	require.NoError(t, err)
+	require.Equal(t, throttle1.Key, throttle2.Key)
+	require.Equal(t, throttle1.Failures, throttle2.Failures)
+	require.WithinDuration(t, throttle1.LastFailureAt, throttle2.LastFailureAt, time.Second)
+
+	err = testQueries.DeleteLoginThrottle(context.Background(), key)
+	require.NoError(t, err)
+
+	_, err = testQueries.GetLoginThrottle(context.Background(), key)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
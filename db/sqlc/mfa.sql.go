@@ -0,0 +1,174 @@
+// Code generated by sqlc. DO NOT EDIT.
+// source: mfa.sql
+
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const createMFAChallenge = `-- name: CreateMFAChallenge :one
+INSERT INTO mfa_challenges (
+    user_id,
+    hashed_token,
+    expires_at
+) VALUES (
+    $1, $2, $3
+) RETURNING id, user_id, hashed_token, attempts, expires_at, used_at, created_at
+`
+
+type CreateMFAChallengeParams struct {
+	UserID      int32     `json:"user_id"`
+	HashedToken string    `json:"hashed_token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+func (q *Queries) CreateMFAChallenge(ctx context.Context, arg CreateMFAChallengeParams) (MfaChallenge, error) {
+	row := q.db.QueryRowContext(ctx, createMFAChallenge, arg.UserID, arg.HashedToken, arg.ExpiresAt)
+	var i MfaChallenge
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.HashedToken,
+		&i.Attempts,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createMFARecoveryCode = `-- name: CreateMFARecoveryCode :one
+INSERT INTO mfa_recovery_codes (
+    user_id,
+    hashed_code
+) VALUES (
+    $1, $2
+) RETURNING id, user_id, hashed_code, used_at, created_at
+`
+
+type CreateMFARecoveryCodeParams struct {
+	UserID     int32  `json:"user_id"`
+	HashedCode string `json:"hashed_code"`
+}
+
+func (q *Queries) CreateMFARecoveryCode(ctx context.Context, arg CreateMFARecoveryCodeParams) (MfaRecoveryCode, error) {
+	row := q.db.QueryRowContext(ctx, createMFARecoveryCode, arg.UserID, arg.HashedCode)
+	var i MfaRecoveryCode
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.HashedCode,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteMFARecoveryCodesByUser = `-- name: DeleteMFARecoveryCodesByUser :exec
+DELETE FROM mfa_recovery_codes
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteMFARecoveryCodesByUser(ctx context.Context, userID int32) error {
+	_, err := q.db.ExecContext(ctx, deleteMFARecoveryCodesByUser, userID)
+	return err
+}
+
+const getMFAChallengeByHashedToken = `-- name: GetMFAChallengeByHashedToken :one
+SELECT id, user_id, hashed_token, attempts, expires_at, used_at, created_at FROM mfa_challenges
+WHERE hashed_token = $1
+LIMIT 1
+`
+
+func (q *Queries) GetMFAChallengeByHashedToken(ctx context.Context, hashedToken string) (MfaChallenge, error) {
+	row := q.db.QueryRowContext(ctx, getMFAChallengeByHashedToken, hashedToken)
+	var i MfaChallenge
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.HashedToken,
+		&i.Attempts,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const incrementMFAChallengeAttempts = `-- name: IncrementMFAChallengeAttempts :one
+UPDATE mfa_challenges
+SET attempts = attempts + 1
+WHERE id = $1 AND attempts < $2 AND used_at IS NULL
+RETURNING id, user_id, hashed_token, attempts, expires_at, used_at, created_at
+`
+
+type IncrementMFAChallengeAttemptsParams struct {
+	ID          int64 `json:"id"`
+	MaxAttempts int32 `json:"max_attempts"`
+}
+
+// Counts an answer to a challenge before it is checked, concurrent answers can't exceed max_attempts.
+// Returns sql.ErrNoRows once the challenge was used or has no attempts left.
+func (q *Queries) IncrementMFAChallengeAttempts(ctx context.Context, arg IncrementMFAChallengeAttemptsParams) (MfaChallenge, error) {
+	row := q.db.QueryRowContext(ctx, incrementMFAChallengeAttempts, arg.ID, arg.MaxAttempts)
+	var i MfaChallenge
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.HashedToken,
+		&i.Attempts,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const useMFAChallenge = `-- name: UseMFAChallenge :one
+UPDATE mfa_challenges
+SET used_at = now()
+WHERE id = $1 AND used_at IS NULL
+RETURNING id, user_id, hashed_token, attempts, expires_at, used_at, created_at
+`
+
+func (q *Queries) UseMFAChallenge(ctx context.Context, id int64) (MfaChallenge, error) {
+	row := q.db.QueryRowContext(ctx, useMFAChallenge, id)
+	var i MfaChallenge
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.HashedToken,
+		&i.Attempts,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const useMFARecoveryCode = `-- name: UseMFARecoveryCode :one
+UPDATE mfa_recovery_codes
+SET used_at = now()
+WHERE user_id = $1 AND hashed_code = $2 AND used_at IS NULL
+RETURNING id, user_id, hashed_code, used_at, created_at
+`
+
+type UseMFARecoveryCodeParams struct {
+	UserID     int32  `json:"user_id"`
+	HashedCode string `json:"hashed_code"`
+}
+
+func (q *Queries) UseMFARecoveryCode(ctx context.Context, arg UseMFARecoveryCodeParams) (MfaRecoveryCode, error) {
+	row := q.db.QueryRowContext(ctx, useMFARecoveryCode, arg.UserID, arg.HashedCode)
+	var i MfaRecoveryCode
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.HashedCode,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,138 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomMFARecoveryCode(t *testing.T, user User) MfaRecoveryCode {
+	arg := CreateMFARecoveryCodeParams{
+		UserID:     user.ID,
+		HashedCode: util.HashSecretToken(util.RandomString(10)),
+	}
+
+	code, err := testQueries.CreateMFARecoveryCode(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, code.ID)
+	require.Equal(t, arg.UserID, code.UserID)
+	require.Equal(t, arg.HashedCode, code.HashedCode)
+	require.False(t, code.UsedAt.Valid)
+
+	return code
+}
+
+func createRandomMFAChallenge(t *testing.T, user User) MfaChallenge {
+	arg := CreateMFAChallengeParams{
+		UserID:      user.ID,
+		HashedToken: util.HashSecretToken(util.RandomString(32)),
+		ExpiresAt:   time.Now().Add(time.Minute),
+	}
+
+	challenge, err := testQueries.CreateMFAChallenge(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, challenge.ID)
+	require.Equal(t, arg.UserID, challenge.UserID)
+	require.Equal(t, arg.HashedToken, challenge.HashedToken)
+	require.Zero(t, challenge.Attempts)
+	require.WithinDuration(t, arg.ExpiresAt, challenge.ExpiresAt, time.Second)
+	require.False(t, challenge.UsedAt.Valid)
+
+	return challenge
+}
+
+func TestUseMFARecoveryCode(t *testing.T) {
+	user := createRandomUser(t)
+	code := createRandomMFARecoveryCode(t, user)
+
+	arg := UseMFARecoveryCodeParams{
+		UserID:     user.ID,
+		HashedCode: code.HashedCode,
+	}
+
+	used, err := testQueries.UseMFARecoveryCode(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, used.UsedAt.Valid)
+
+	// A recovery code can only be used once
+	_, err = testQueries.UseMFARecoveryCode(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	deleteRandomUser(t, user.ID)
+}
+
+func TestDeleteMFARecoveryCodesByUser(t *testing.T) {
+	user := createRandomUser(t)
+	code := createRandomMFARecoveryCode(t, user)
+
+	err := testQueries.DeleteMFARecoveryCodesByUser(context.Background(), user.ID)
+	require.NoError(t, err)
+
+	_, err = testQueries.UseMFARecoveryCode(context.Background(), UseMFARecoveryCodeParams{
+		UserID:     user.ID,
+		HashedCode: code.HashedCode,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	deleteRandomUser(t, user.ID)
+}
+
+func TestGetMFAChallengeByHashedToken(t *testing.T) {
+	user := createRandomUser(t)
+	challenge1 := createRandomMFAChallenge(t, user)
+
+	challenge2, err := testQueries.GetMFAChallengeByHashedToken(context.Background(), challenge1.HashedToken)
+	require.NoError(t, err)
+	require.Equal(t, challenge1.ID, challenge2.ID)
+	require.Equal(t, challenge1.UserID, challenge2.UserID)
+
+	deleteRandomUser(t, user.ID)
+}
+
+func TestIncrementMFAChallengeAttempts(t *testing.T) {
+	user := createRandomUser(t)
+	challenge := createRandomMFAChallenge(t, user)
+
+	arg := IncrementMFAChallengeAttemptsParams{
+		ID:          challenge.ID,
+		MaxAttempts: 3,
+	}
+	for i := 1; i <= 3; i++ {
+		updated, err := testQueries.IncrementMFAChallengeAttempts(context.Background(), arg)
+		require.NoError(t, err)
+		require.Equal(t, int32(i), updated.Attempts)
+	}
+
+	// No attempts are left
+	_, err := testQueries.IncrementMFAChallengeAttempts(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// Nor for a used challenge
+	used := createRandomMFAChallenge(t, user)
+	_, err = testQueries.UseMFAChallenge(context.Background(), used.ID)
+	require.NoError(t, err)
+	arg.ID = used.ID
+	_, err = testQueries.IncrementMFAChallengeAttempts(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	deleteRandomUser(t, user.ID)
+}
+
+func TestUseMFAChallenge(t *testing.T) {
+	user := createRandomUser(t)
+	challenge := createRandomMFAChallenge(t, user)
+
+	used, err := testQueries.UseMFAChallenge(context.Background(), challenge.ID)
+	require.NoError(t, err)
+	require.True(t, used.UsedAt.Valid)
+
+	// A challenge can only be answered once
+	_, err = testQueries.UseMFAChallenge(context.Background(), challenge.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	deleteRandomUser(t, user.ID)
+}
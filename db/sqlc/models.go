@@ -3,31 +3,262 @@
 package db
 
 import (
+	"database/sql"
+	"encoding/json"
 	"time"
+
+	"github.com/google/uuid"
 )
 
+type ApiKey struct {
+	ID         int64        `json:"id"`
+	UserID     int32        `json:"user_id"`
+	Name       string       `json:"name"`
+	Prefix     string       `json:"prefix"`
+	HashedKey  string       `json:"hashed_key"`
+	Scopes     []string     `json:"scopes"`
+	ExpiresAt  sql.NullTime `json:"expires_at"`
+	LastUsedAt sql.NullTime `json:"last_used_at"`
+	CreatedAt  time.Time    `json:"created_at"`
+}
+
+type AudioFingerprint struct {
+	BeatID      int32     `json:"beat_id"`
+	S3Key       string    `json:"s3_key"`
+	ContentHash string    `json:"content_hash"`
+	Fingerprint []byte    `json:"fingerprint"`
+	Hashes      []int32   `json:"hashes"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type AuditLog struct {
+	ID           int64     `json:"id"`
+	ActorID      int32     `json:"actor_id"`
+	Action       string    `json:"action"`
+	TargetUserID int32     `json:"target_user_id"`
+	Details      string    `json:"details"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
 type Beat struct {
-	ID        int32     `json:"id"`
-	CreatorID int32     `json:"creator_id"`
-	Title     string    `json:"title"`
-	Genre     string    `json:"genre"`
-	Key       string    `json:"key"`
-	Bpm       int16     `json:"bpm"`
-	Tags      string    `json:"tags"`
-	S3Key     string    `json:"s3_key"`
+	ID                    int32     `json:"id"`
+	CreatorID             int32     `json:"creator_id"`
+	Title                 string    `json:"title"`
+	Genre                 string    `json:"genre"`
+	Key                   string    `json:"key"`
+	Bpm                   int16     `json:"bpm"`
+	Tags                  string    `json:"tags"`
+	S3Key                 string    `json:"s3_key"`
+	CreatedAt             time.Time `json:"created_at"`
+	PlayCount             int64     `json:"play_count"`
+	PreviewKey            string    `json:"preview_key"`
+	DetectedBpm           float32   `json:"detected_bpm"`
+	DetectedBpmConfidence float32   `json:"detected_bpm_confidence"`
+	DetectedKey           string    `json:"detected_key"`
+	DetectedKeyConfidence float32   `json:"detected_key_confidence"`
+	ArtworkKey            string    `json:"artwork_key"`
+}
+
+type BeatFile struct {
+	BeatID      int32     `json:"beat_id"`
+	FileType    string    `json:"file_type"`
+	S3Key       string    `json:"s3_key"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	Checksum    string    `json:"checksum"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type BeatWaveform struct {
+	BeatID     int32     `json:"beat_id"`
+	S3Key      string    `json:"s3_key"`
+	Resolution int32     `json:"resolution"`
+	DurationMs int64     `json:"duration_ms"`
+	Peaks      []byte    `json:"peaks"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type Cart struct {
+	ID        uuid.UUID     `json:"id"`
+	UserID    sql.NullInt32 `json:"user_id"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+type CartItem struct {
+	ID        int64     `json:"id"`
+	CartID    uuid.UUID `json:"cart_id"`
+	BeatID    int32     `json:"beat_id"`
+	LicenseID int64     `json:"license_id"`
+	Price     int64     `json:"price"`
+	Currency  string    `json:"currency"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
+type DuplicateMatch struct {
+	ID            int64         `json:"id"`
+	BeatID        int32         `json:"beat_id"`
+	MatchedBeatID int32         `json:"matched_beat_id"`
+	Kind          string        `json:"kind"`
+	BitErrorRate  float32       `json:"bit_error_rate"`
+	Rejected      bool          `json:"rejected"`
+	Status        string        `json:"status"`
+	ReviewedBy    sql.NullInt32 `json:"reviewed_by"`
+	ReviewedAt    sql.NullTime  `json:"reviewed_at"`
+	CreatedAt     time.Time     `json:"created_at"`
+}
+
+type EmailVerification struct {
+	ID          int64        `json:"id"`
+	UserID      int32        `json:"user_id"`
+	Email       string       `json:"email"`
+	HashedToken string       `json:"hashed_token"`
+	ExpiresAt   time.Time    `json:"expires_at"`
+	UsedAt      sql.NullTime `json:"used_at"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
+
+type License struct {
+	ID              int64         `json:"id"`
+	BeatID          int32         `json:"beat_id"`
+	Tier            string        `json:"tier"`
+	Price           int64         `json:"price"`
+	Currency        string        `json:"currency"`
+	FileTypes       []string      `json:"file_types"`
+	DistributionCap sql.NullInt64 `json:"distribution_cap"`
+	StreamCap       sql.NullInt64 `json:"stream_cap"`
+	Terms           string        `json:"terms"`
+	CreatedAt       time.Time     `json:"created_at"`
+	UpdatedAt       time.Time     `json:"updated_at"`
+	SoldAt          sql.NullTime  `json:"sold_at"`
+	ReservedOrderID sql.NullInt64 `json:"reserved_order_id"`
+}
+
 type Like struct {
 	ID     int32 `json:"id"`
 	UserID int32 `json:"user_id"`
 	BeatID int32 `json:"beat_id"`
 }
 
+type LoginEvent struct {
+	ID        int64         `json:"id"`
+	Username  string        `json:"username"`
+	ClientIp  string        `json:"client_ip"`
+	Event     string        `json:"event"`
+	ActorID   sql.NullInt32 `json:"actor_id"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+type LoginThrottle struct {
+	Key           string    `json:"key"`
+	Failures      int32     `json:"failures"`
+	LastFailureAt time.Time `json:"last_failure_at"`
+}
+
+type MfaChallenge struct {
+	ID          int64        `json:"id"`
+	UserID      int32        `json:"user_id"`
+	HashedToken string       `json:"hashed_token"`
+	Attempts    int32        `json:"attempts"`
+	ExpiresAt   time.Time    `json:"expires_at"`
+	UsedAt      sql.NullTime `json:"used_at"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
+
+type MfaRecoveryCode struct {
+	ID         int64        `json:"id"`
+	UserID     int32        `json:"user_id"`
+	HashedCode string       `json:"hashed_code"`
+	UsedAt     sql.NullTime `json:"used_at"`
+	CreatedAt  time.Time    `json:"created_at"`
+}
+
+type Order struct {
+	ID              int64          `json:"id"`
+	BuyerID         int32          `json:"buyer_id"`
+	Status          string         `json:"status"`
+	Total           int64          `json:"total"`
+	Currency        string         `json:"currency"`
+	PaymentIntentID sql.NullString `json:"payment_intent_id"`
+	FailureReason   string         `json:"failure_reason"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+}
+
+type OrderItem struct {
+	ID        int64         `json:"id"`
+	OrderID   int64         `json:"order_id"`
+	BeatID    sql.NullInt32 `json:"beat_id"`
+	LicenseID sql.NullInt64 `json:"license_id"`
+	BeatTitle string        `json:"beat_title"`
+	Tier      string        `json:"tier"`
+	Price     int64         `json:"price"`
+	Currency  string        `json:"currency"`
+}
+
+type PasswordReset struct {
+	ID          int64        `json:"id"`
+	UserID      int32        `json:"user_id"`
+	HashedToken string       `json:"hashed_token"`
+	ExpiresAt   time.Time    `json:"expires_at"`
+	UsedAt      sql.NullTime `json:"used_at"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
+
+type Session struct {
+	ID           uuid.UUID `json:"id"`
+	UserID       int32     `json:"user_id"`
+	RefreshToken string    `json:"refresh_token"`
+	UserAgent    string    `json:"user_agent"`
+	ClientIp     string    `json:"client_ip"`
+	IsBlocked    bool      `json:"is_blocked"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type StreamSession struct {
+	BeatID        int32        `json:"beat_id"`
+	SessionID     uuid.UUID    `json:"session_id"`
+	BytesStreamed int64        `json:"bytes_streamed"`
+	PlayedAt      sql.NullTime `json:"played_at"`
+	CreatedAt     time.Time    `json:"created_at"`
+}
+
+type Upload struct {
+	ID         uuid.UUID `json:"id"`
+	UserID     int32     `json:"user_id"`
+	BeatID     int32     `json:"beat_id"`
+	FileType   string    `json:"file_type"`
+	Filename   string    `json:"filename"`
+	Size       int64     `json:"size"`
+	Received   int64     `json:"received"`
+	Checksum   string    `json:"checksum"`
+	Finalizing bool      `json:"finalizing"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
 type User struct {
-	ID        int32     `json:"id"`
-	Username  string    `json:"username"`
-	Password  string    `json:"password"`
-	Email     string    `json:"email"`
-	CreatedAt time.Time `json:"created_at"`
+	ID                int32          `json:"id"`
+	Username          string         `json:"username"`
+	HashedPassword    string         `json:"hashed_password"`
+	Email             string         `json:"email"`
+	CreatedAt         time.Time      `json:"created_at"`
+	PasswordChangedAt time.Time      `json:"password_changed_at"`
+	Role              string         `json:"role"`
+	VerifiedAt        sql.NullTime   `json:"verified_at"`
+	TotpSecret        sql.NullString `json:"totp_secret"`
+	TotpEnabledAt     sql.NullTime   `json:"totp_enabled_at"`
+	TotpLastStep      int64          `json:"totp_last_step"`
+}
+
+type WebhookEvent struct {
+	ID          string          `json:"id"`
+	Type        string          `json:"type"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"`
+	Attempts    int32           `json:"attempts"`
+	LastError   string          `json:"last_error"`
+	OrderID     sql.NullInt64   `json:"order_id"`
+	ReceivedAt  time.Time       `json:"received_at"`
+	ProcessedAt sql.NullTime    `json:"processed_at"`
 }
@@ -0,0 +1,263 @@
+// Code generated by sqlc. DO NOT EDIT.
+// source: order.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+const createOrder = `-- name: CreateOrder :one
+INSERT INTO orders (
+    buyer_id,
+    total,
+    currency
+) VALUES (
+    $1, $2, $3
+)
+RETURNING id, buyer_id, status, total, currency, payment_intent_id, failure_reason, created_at, updated_at
+`
+
+type CreateOrderParams struct {
+	BuyerID  int32  `json:"buyer_id"`
+	Total    int64  `json:"total"`
+	Currency string `json:"currency"`
+}
+
+func (q *Queries) CreateOrder(ctx context.Context, arg CreateOrderParams) (Order, error) {
+	row := q.db.QueryRowContext(ctx, createOrder, arg.BuyerID, arg.Total, arg.Currency)
+	var i Order
+	err := row.Scan(
+		&i.ID,
+		&i.BuyerID,
+		&i.Status,
+		&i.Total,
+		&i.Currency,
+		&i.PaymentIntentID,
+		&i.FailureReason,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createOrderItem = `-- name: CreateOrderItem :one
+INSERT INTO order_items (
+    order_id,
+    beat_id,
+    license_id,
+    beat_title,
+    tier,
+    price,
+    currency
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+RETURNING id, order_id, beat_id, license_id, beat_title, tier, price, currency
+`
+
+type CreateOrderItemParams struct {
+	OrderID   int64         `json:"order_id"`
+	BeatID    sql.NullInt32 `json:"beat_id"`
+	LicenseID sql.NullInt64 `json:"license_id"`
+	BeatTitle string        `json:"beat_title"`
+	Tier      string        `json:"tier"`
+	Price     int64         `json:"price"`
+	Currency  string        `json:"currency"`
+}
+
+func (q *Queries) CreateOrderItem(ctx context.Context, arg CreateOrderItemParams) (OrderItem, error) {
+	row := q.db.QueryRowContext(ctx, createOrderItem,
+		arg.OrderID,
+		arg.BeatID,
+		arg.LicenseID,
+		arg.BeatTitle,
+		arg.Tier,
+		arg.Price,
+		arg.Currency,
+	)
+	var i OrderItem
+	err := row.Scan(
+		&i.ID,
+		&i.OrderID,
+		&i.BeatID,
+		&i.LicenseID,
+		&i.BeatTitle,
+		&i.Tier,
+		&i.Price,
+		&i.Currency,
+	)
+	return i, err
+}
+
+const getOrder = `-- name: GetOrder :one
+SELECT id, buyer_id, status, total, currency, payment_intent_id, failure_reason, created_at, updated_at FROM orders
+WHERE id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetOrder(ctx context.Context, id int64) (Order, error) {
+	row := q.db.QueryRowContext(ctx, getOrder, id)
+	var i Order
+	err := row.Scan(
+		&i.ID,
+		&i.BuyerID,
+		&i.Status,
+		&i.Total,
+		&i.Currency,
+		&i.PaymentIntentID,
+		&i.FailureReason,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getOrderByPaymentIntentForUpdate = `-- name: GetOrderByPaymentIntentForUpdate :one
+SELECT id, buyer_id, status, total, currency, payment_intent_id, failure_reason, created_at, updated_at FROM orders
+WHERE payment_intent_id = $1
+LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetOrderByPaymentIntentForUpdate(ctx context.Context, paymentIntentID sql.NullString) (Order, error) {
+	row := q.db.QueryRowContext(ctx, getOrderByPaymentIntentForUpdate, paymentIntentID)
+	var i Order
+	err := row.Scan(
+		&i.ID,
+		&i.BuyerID,
+		&i.Status,
+		&i.Total,
+		&i.Currency,
+		&i.PaymentIntentID,
+		&i.FailureReason,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getOrderForUpdate = `-- name: GetOrderForUpdate :one
+SELECT id, buyer_id, status, total, currency, payment_intent_id, failure_reason, created_at, updated_at FROM orders
+WHERE id = $1
+LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetOrderForUpdate(ctx context.Context, id int64) (Order, error) {
+	row := q.db.QueryRowContext(ctx, getOrderForUpdate, id)
+	var i Order
+	err := row.Scan(
+		&i.ID,
+		&i.BuyerID,
+		&i.Status,
+		&i.Total,
+		&i.Currency,
+		&i.PaymentIntentID,
+		&i.FailureReason,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const listOrderItems = `-- name: ListOrderItems :many
+SELECT id, order_id, beat_id, license_id, beat_title, tier, price, currency FROM order_items
+WHERE order_id = $1
+ORDER BY id
+`
+
+func (q *Queries) ListOrderItems(ctx context.Context, orderID int64) ([]OrderItem, error) {
+	rows, err := q.db.QueryContext(ctx, listOrderItems, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []OrderItem{}
+	for rows.Next() {
+		var i OrderItem
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrderID,
+			&i.BeatID,
+			&i.LicenseID,
+			&i.BeatTitle,
+			&i.Tier,
+			&i.Price,
+			&i.Currency,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setOrderPaymentIntent = `-- name: SetOrderPaymentIntent :one
+UPDATE orders
+SET payment_intent_id = $2,
+    updated_at = now()
+WHERE id = $1
+RETURNING id, buyer_id, status, total, currency, payment_intent_id, failure_reason, created_at, updated_at
+`
+
+type SetOrderPaymentIntentParams struct {
+	ID              int64          `json:"id"`
+	PaymentIntentID sql.NullString `json:"payment_intent_id"`
+}
+
+func (q *Queries) SetOrderPaymentIntent(ctx context.Context, arg SetOrderPaymentIntentParams) (Order, error) {
+	row := q.db.QueryRowContext(ctx, setOrderPaymentIntent, arg.ID, arg.PaymentIntentID)
+	var i Order
+	err := row.Scan(
+		&i.ID,
+		&i.BuyerID,
+		&i.Status,
+		&i.Total,
+		&i.Currency,
+		&i.PaymentIntentID,
+		&i.FailureReason,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const updateOrderStatus = `-- name: UpdateOrderStatus :one
+UPDATE orders
+SET status = $2,
+    failure_reason = $3,
+    updated_at = now()
+WHERE id = $1
+RETURNING id, buyer_id, status, total, currency, payment_intent_id, failure_reason, created_at, updated_at
+`
+
+type UpdateOrderStatusParams struct {
+	ID            int64  `json:"id"`
+	Status        string `json:"status"`
+	FailureReason string `json:"failure_reason"`
+}
+
+func (q *Queries) UpdateOrderStatus(ctx context.Context, arg UpdateOrderStatusParams) (Order, error) {
+	row := q.db.QueryRowContext(ctx, updateOrderStatus, arg.ID, arg.Status, arg.FailureReason)
+	var i Order
+	err := row.Scan(
+		&i.ID,
+		&i.BuyerID,
+		&i.Status,
+		&i.Total,
+		&i.Currency,
+		&i.PaymentIntentID,
+		&i.FailureReason,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
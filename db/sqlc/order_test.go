@@ -0,0 +1,141 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomOrder(t *testing.T, buyer User) Order {
+	arg := CreateOrderParams{
+		BuyerID:  buyer.ID,
+		Total:    util.RandomInt(100, 100000),
+		Currency: "USD",
+	}
+
+	order, err := testQueries.CreateOrder(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, order.ID)
+	require.Equal(t, arg.BuyerID, order.BuyerID)
+	require.Equal(t, arg.Total, order.Total)
+	require.Equal(t, arg.Currency, order.Currency)
+	require.Equal(t, util.OrderPending, order.Status)
+	require.False(t, order.PaymentIntentID.Valid)
+	require.Empty(t, order.FailureReason)
+	require.NotZero(t, order.CreatedAt)
+	require.NotZero(t, order.UpdatedAt)
+
+	return order
+}
+
+func TestCreateOrder(t *testing.T) {
+	buyer := createRandomUser(t)
+	order1 := createRandomOrder(t, buyer)
+
+	order2, err := testQueries.GetOrder(context.Background(), order1.ID)
+	require.NoError(t, err)
+	require.Equal(t, order1, order2)
+
+	// Orders go away with their buyer
+	deleteRandomUser(t, buyer.ID)
+
+	_, err = testQueries.GetOrder(context.Background(), order1.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestSetOrderPaymentIntent(t *testing.T) {
+	buyer := createRandomUser(t)
+	order1 := createRandomOrder(t, buyer)
+	order2 := createRandomOrder(t, buyer)
+
+	intentID := sql.NullString{String: "pi_" + util.RandomString(16), Valid: true}
+	order3, err := testQueries.SetOrderPaymentIntent(context.Background(), SetOrderPaymentIntentParams{
+		ID:              order1.ID,
+		PaymentIntentID: intentID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, intentID, order3.PaymentIntentID)
+
+	// An intent pays for a single order
+	_, err = testQueries.SetOrderPaymentIntent(context.Background(), SetOrderPaymentIntentParams{
+		ID:              order2.ID,
+		PaymentIntentID: intentID,
+	})
+	require.Error(t, err)
+
+	deleteRandomUser(t, buyer.ID)
+}
+
+func TestUpdateOrderStatus(t *testing.T) {
+	buyer := createRandomUser(t)
+	order1 := createRandomOrder(t, buyer)
+
+	order2, err := testQueries.UpdateOrderStatus(context.Background(), UpdateOrderStatusParams{
+		ID:            order1.ID,
+		Status:        util.OrderFailed,
+		FailureReason: "payment was declined",
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.OrderFailed, order2.Status)
+	require.Equal(t, "payment was declined", order2.FailureReason)
+	require.Equal(t, order1.Total, order2.Total)
+	require.False(t, order2.UpdatedAt.Before(order1.UpdatedAt))
+
+	deleteRandomUser(t, buyer.ID)
+}
+
+func TestListOrderItems(t *testing.T) {
+	buyer := createRandomUser(t)
+	beat := createRandomBeat(t)
+	basic := createRandomLicense(t, beat, util.BasicLicense, 2999)
+	premium := createRandomLicense(t, beat, util.PremiumLicense, 7999)
+	order := createRandomOrder(t, buyer)
+
+	items := make([]OrderItem, 2)
+	for i, license := range []License{basic, premium} {
+		arg := CreateOrderItemParams{
+			OrderID:   order.ID,
+			BeatID:    sql.NullInt32{Int32: beat.ID, Valid: true},
+			LicenseID: sql.NullInt64{Int64: license.ID, Valid: true},
+			BeatTitle: beat.Title,
+			Tier:      license.Tier,
+			Price:     license.Price,
+			Currency:  license.Currency,
+		}
+
+		item, err := testQueries.CreateOrderItem(context.Background(), arg)
+		require.NoError(t, err)
+		require.NotZero(t, item.ID)
+		require.Equal(t, arg.OrderID, item.OrderID)
+		require.Equal(t, arg.BeatID, item.BeatID)
+		require.Equal(t, arg.LicenseID, item.LicenseID)
+		require.Equal(t, arg.BeatTitle, item.BeatTitle)
+		require.Equal(t, arg.Tier, item.Tier)
+		require.Equal(t, arg.Price, item.Price)
+		require.Equal(t, arg.Currency, item.Currency)
+		items[i] = item
+	}
+
+	got, err := testQueries.ListOrderItems(context.Background(), order.ID)
+	require.NoError(t, err)
+	require.Equal(t, items, got)
+
+	// Deleting the beat keeps what was bought
+	deleteRandomBeat(t, beat.ID)
+
+	got, err = testQueries.ListOrderItems(context.Background(), order.ID)
+	require.NoError(t, err)
+	require.Len(t, got, len(items))
+	for i, item := range got {
+		require.False(t, item.BeatID.Valid)
+		require.False(t, item.LicenseID.Valid)
+		require.Equal(t, items[i].BeatTitle, item.BeatTitle)
+		require.Equal(t, items[i].Price, item.Price)
+	}
+
+	deleteRandomUser(t, beat.CreatorID)
+	deleteRandomUser(t, buyer.ID)
+}
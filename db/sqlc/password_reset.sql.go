@@ -0,0 +1,80 @@
+// Code generated by sqlc. DO NOT EDIT.
+// source: password_reset.sql
+
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const createPasswordReset = `-- name: CreatePasswordReset :one
+INSERT INTO password_resets (
+    user_id,
+    hashed_token,
+    expires_at
+) VALUES (
+    $1, $2, $3
+) RETURNING id, user_id, hashed_token, expires_at, used_at, created_at
+`
+
+type CreatePasswordResetParams struct {
+	UserID      int32     `json:"user_id"`
+	HashedToken string    `json:"hashed_token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+func (q *Queries) CreatePasswordReset(ctx context.Context, arg CreatePasswordResetParams) (PasswordReset, error) {
+	row := q.db.QueryRowContext(ctx, createPasswordReset, arg.UserID, arg.HashedToken, arg.ExpiresAt)
+	var i PasswordReset
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.HashedToken,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getPasswordResetByHashedToken = `-- name: GetPasswordResetByHashedToken :one
+SELECT id, user_id, hashed_token, expires_at, used_at, created_at FROM password_resets
+WHERE hashed_token = $1
+LIMIT 1
+`
+
+func (q *Queries) GetPasswordResetByHashedToken(ctx context.Context, hashedToken string) (PasswordReset, error) {
+	row := q.db.QueryRowContext(ctx, getPasswordResetByHashedToken, hashedToken)
+	var i PasswordReset
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.HashedToken,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const usePasswordReset = `-- name: UsePasswordReset :one
+UPDATE password_resets
+SET used_at = now()
+WHERE id = $1 AND used_at IS NULL
+RETURNING id, user_id, hashed_token, expires_at, used_at, created_at
+`
+
+func (q *Queries) UsePasswordReset(ctx context.Context, id int64) (PasswordReset, error) {
+	row := q.db.QueryRowContext(ctx, usePasswordReset, id)
+	var i PasswordReset
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.HashedToken,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
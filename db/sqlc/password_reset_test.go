@@ -0,0 +1,65 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomPasswordReset(t *testing.T, user User) PasswordReset {
+	arg := CreatePasswordResetParams{
+		UserID:      user.ID,
+		HashedToken: util.HashSecretToken(util.RandomString(32)),
+		ExpiresAt:   time.Now().Add(time.Hour),
+	}
+
+	reset, err := testQueries.CreatePasswordReset(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, reset)
+
+	require.NotZero(t, reset.ID)
+	require.Equal(t, arg.UserID, reset.UserID)
+	require.Equal(t, arg.HashedToken, reset.HashedToken)
+	require.WithinDuration(t, arg.ExpiresAt, reset.ExpiresAt, time.Second)
+	require.False(t, reset.UsedAt.Valid)
+	require.NotZero(t, reset.CreatedAt)
+
+	return reset
+}
+
+func TestCreatePasswordReset(t *testing.T) {
+	user := createRandomUser(t)
+	createRandomPasswordReset(t, user)
+	deleteRandomUser(t, user.ID)
+}
+
+func TestGetPasswordResetByHashedToken(t *testing.T) {
+	user := createRandomUser(t)
+	reset1 := createRandomPasswordReset(t, user)
+
+	reset2, err := testQueries.GetPasswordResetByHashedToken(context.Background(), reset1.HashedToken)
+	require.NoError(t, err)
+	require.Equal(t, reset1.ID, reset2.ID)
+	require.Equal(t, reset1.UserID, reset2.UserID)
+
+	deleteRandomUser(t, user.ID)
+}
+
+func TestUsePasswordReset(t *testing.T) {
+	user := createRandomUser(t)
+	reset := createRandomPasswordReset(t, user)
+
+	used, err := testQueries.UsePasswordReset(context.Background(), reset.ID)
+	require.NoError(t, err)
+	require.True(t, used.UsedAt.Valid)
+
+	// A password reset can only be used once
+	_, err = testQueries.UsePasswordReset(context.Background(), reset.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	deleteRandomUser(t, user.ID)
+}
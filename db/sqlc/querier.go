@@ -4,19 +4,83 @@ package db
 
 import (
 	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
 )
 
 type Querier interface {
+	AddStreamedBytes(ctx context.Context, arg AddStreamedBytesParams) (StreamSession, error)
+	AdvanceUpload(ctx context.Context, arg AdvanceUploadParams) (Upload, error)
+	BlockSession(ctx context.Context, id uuid.UUID) error
+	BlockUserSessions(ctx context.Context, userID int32) error
+	CountOrderedExclusives(ctx context.Context, orderID int64) (int64, error)
+	CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (ApiKey, error)
+	CreateAuditLog(ctx context.Context, arg CreateAuditLogParams) (AuditLog, error)
 	CreateBeat(ctx context.Context, arg CreateBeatParams) (Beat, error)
+	CreateCart(ctx context.Context, arg CreateCartParams) (Cart, error)
+	CreateDuplicateMatch(ctx context.Context, arg CreateDuplicateMatchParams) (DuplicateMatch, error)
+	CreateEmailVerification(ctx context.Context, arg CreateEmailVerificationParams) (EmailVerification, error)
+	CreateLicense(ctx context.Context, arg CreateLicenseParams) (License, error)
 	CreateLike(ctx context.Context, arg CreateLikeParams) (Like, error)
+	CreateLoginEvent(ctx context.Context, arg CreateLoginEventParams) (LoginEvent, error)
+	CreateMFAChallenge(ctx context.Context, arg CreateMFAChallengeParams) (MfaChallenge, error)
+	CreateMFARecoveryCode(ctx context.Context, arg CreateMFARecoveryCodeParams) (MfaRecoveryCode, error)
+	CreateOrder(ctx context.Context, arg CreateOrderParams) (Order, error)
+	CreateOrderItem(ctx context.Context, arg CreateOrderItemParams) (OrderItem, error)
+	CreatePasswordReset(ctx context.Context, arg CreatePasswordResetParams) (PasswordReset, error)
+	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
+	CreateUpload(ctx context.Context, arg CreateUploadParams) (Upload, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	DeleteAPIKey(ctx context.Context, id int64) error
 	DeleteBeat(ctx context.Context, id int32) error
+	DeleteBeatFile(ctx context.Context, arg DeleteBeatFileParams) (BeatFile, error)
+	DeleteBeatFilesByType(ctx context.Context, arg DeleteBeatFilesByTypeParams) ([]BeatFile, error)
+	DeleteCart(ctx context.Context, id uuid.UUID) error
+	DeleteCartItem(ctx context.Context, arg DeleteCartItemParams) (CartItem, error)
+	DeleteLicense(ctx context.Context, id int64) error
 	DeleteLike(ctx context.Context, arg DeleteLikeParams) error
+	DeleteLoginThrottle(ctx context.Context, key string) error
+	DeleteMFARecoveryCodesByUser(ctx context.Context, userID int32) error
+	DeleteOrderedCartItems(ctx context.Context, arg DeleteOrderedCartItemsParams) (int64, error)
+	DeleteUpload(ctx context.Context, id uuid.UUID) error
 	DeleteUser(ctx context.Context, id int32) error
+	EnableUserTOTP(ctx context.Context, id int32) (User, error)
+	GetAPIKey(ctx context.Context, id int64) (ApiKey, error)
+	GetAPIKeyByPrefix(ctx context.Context, prefix string) (GetAPIKeyByPrefixRow, error)
+	GetAudioFingerprint(ctx context.Context, beatID int32) (AudioFingerprint, error)
 	GetBeatById(ctx context.Context, id int32) (Beat, error)
+	GetBeatByIdForUpdate(ctx context.Context, id int32) (Beat, error)
+	GetBeatFile(ctx context.Context, arg GetBeatFileParams) (BeatFile, error)
+	GetBeatWaveform(ctx context.Context, beatID int32) (BeatWaveform, error)
+	GetCart(ctx context.Context, id uuid.UUID) (Cart, error)
+	GetCartByUser(ctx context.Context, userID sql.NullInt32) (Cart, error)
+	GetDuplicateMatchForUpdate(ctx context.Context, id int64) (DuplicateMatch, error)
+	GetEmailVerificationByHashedToken(ctx context.Context, hashedToken string) (EmailVerification, error)
+	GetFirstAudioByContentHash(ctx context.Context, contentHash string) (GetFirstAudioByContentHashRow, error)
+	GetLicense(ctx context.Context, id int64) (License, error)
+	GetLicenseForUpdate(ctx context.Context, id int64) (License, error)
 	GetLikeByUserAndBeat(ctx context.Context, arg GetLikeByUserAndBeatParams) (Like, error)
+	GetLoginThrottle(ctx context.Context, key string) (LoginThrottle, error)
+	GetMFAChallengeByHashedToken(ctx context.Context, hashedToken string) (MfaChallenge, error)
+	GetOrder(ctx context.Context, id int64) (Order, error)
+	GetOrderByPaymentIntentForUpdate(ctx context.Context, paymentIntentID sql.NullString) (Order, error)
+	GetOrderForUpdate(ctx context.Context, id int64) (Order, error)
+	GetPasswordResetByHashedToken(ctx context.Context, hashedToken string) (PasswordReset, error)
+	GetSession(ctx context.Context, id uuid.UUID) (Session, error)
+	GetStreamSession(ctx context.Context, arg GetStreamSessionParams) (StreamSession, error)
+	GetUpload(ctx context.Context, id uuid.UUID) (Upload, error)
+	GetUserByEmail(ctx context.Context, email string) (User, error)
 	GetUserById(ctx context.Context, id int32) (User, error)
+	GetUserByIdForUpdate(ctx context.Context, id int32) (User, error)
 	GetUserByUsername(ctx context.Context, username string) (User, error)
+	GetWebhookEvent(ctx context.Context, id string) (WebhookEvent, error)
+	GetWebhookEventForUpdate(ctx context.Context, id string) (WebhookEvent, error)
+	IncrementBeatPlayCount(ctx context.Context, id int32) (Beat, error)
+	IncrementMFAChallengeAttempts(ctx context.Context, arg IncrementMFAChallengeAttemptsParams) (MfaChallenge, error)
+	ListAPIKeysByUser(ctx context.Context, userID int32) ([]ApiKey, error)
+	ListAuditLogsByTargetUser(ctx context.Context, arg ListAuditLogsByTargetUserParams) ([]AuditLog, error)
+	ListBeatFiles(ctx context.Context, beatID int32) ([]BeatFile, error)
 	ListBeatsByBpmRange(ctx context.Context, arg ListBeatsByBpmRangeParams) ([]Beat, error)
 	ListBeatsByCreatorId(ctx context.Context, arg ListBeatsByCreatorIdParams) ([]Beat, error)
 	ListBeatsByCreatorIdAndBpmRange(ctx context.Context, arg ListBeatsByCreatorIdAndBpmRangeParams) ([]Beat, error)
@@ -25,11 +89,56 @@ type Querier interface {
 	ListBeatsByGenre(ctx context.Context, arg ListBeatsByGenreParams) ([]Beat, error)
 	ListBeatsById(ctx context.Context, arg ListBeatsByIdParams) ([]Beat, error)
 	ListBeatsByKey(ctx context.Context, arg ListBeatsByKeyParams) ([]Beat, error)
+	ListCartItems(ctx context.Context, cartID uuid.UUID) ([]ListCartItemsRow, error)
+	ListDuplicateMatches(ctx context.Context, arg ListDuplicateMatchesParams) ([]ListDuplicateMatchesRow, error)
+	ListFingerprintCandidates(ctx context.Context, arg ListFingerprintCandidatesParams) ([]ListFingerprintCandidatesRow, error)
+	ListLicensesByBeat(ctx context.Context, beatID int32) ([]License, error)
 	ListLikesByBeat(ctx context.Context, arg ListLikesByBeatParams) ([]Like, error)
 	ListLikesByUser(ctx context.Context, arg ListLikesByUserParams) ([]Like, error)
+	ListLoginEventsByUsername(ctx context.Context, arg ListLoginEventsByUsernameParams) ([]LoginEvent, error)
+	ListOrderItems(ctx context.Context, orderID int64) ([]OrderItem, error)
+	ListStaleUploads(ctx context.Context, arg ListStaleUploadsParams) ([]Upload, error)
 	ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error)
+	ListWebhookEvents(ctx context.Context, arg ListWebhookEventsParams) ([]WebhookEvent, error)
+	MarkOrderedExclusivesSold(ctx context.Context, orderID int64) (int64, error)
+	MarkStreamSessionPlayed(ctx context.Context, arg MarkStreamSessionPlayedParams) (StreamSession, error)
+	MarkWebhookEventFailed(ctx context.Context, arg MarkWebhookEventFailedParams) (WebhookEvent, error)
+	MarkWebhookEventProcessed(ctx context.Context, arg MarkWebhookEventProcessedParams) (WebhookEvent, error)
+	MoveCartItems(ctx context.Context, arg MoveCartItemsParams) (int64, error)
+	RecordLoginFailure(ctx context.Context, arg RecordLoginFailureParams) (LoginThrottle, error)
+	RecordWebhookEvent(ctx context.Context, arg RecordWebhookEventParams) (WebhookEvent, error)
+	ReleaseOrderedLicenses(ctx context.Context, orderID int64) error
+	ReserveLicense(ctx context.Context, arg ReserveLicenseParams) error
+	ResetUserPassword(ctx context.Context, arg ResetUserPasswordParams) (User, error)
+	ReviewDuplicateMatch(ctx context.Context, arg ReviewDuplicateMatchParams) (DuplicateMatch, error)
+	SetOrderPaymentIntent(ctx context.Context, arg SetOrderPaymentIntentParams) (Order, error)
+	StartFinalizingUpload(ctx context.Context, id uuid.UUID) (Upload, error)
+	StopFinalizingUpload(ctx context.Context, id uuid.UUID) error
+	UpdateAPIKeyLastUsed(ctx context.Context, id int64) error
+	UpdateAudioFingerprint(ctx context.Context, arg UpdateAudioFingerprintParams) (AudioFingerprint, error)
 	UpdateBeat(ctx context.Context, arg UpdateBeatParams) (Beat, error)
+	UpdateBeatAnalysis(ctx context.Context, arg UpdateBeatAnalysisParams) (Beat, error)
+	UpdateBeatArtworkKey(ctx context.Context, arg UpdateBeatArtworkKeyParams) (Beat, error)
+	UpdateBeatPreviewKey(ctx context.Context, arg UpdateBeatPreviewKeyParams) (Beat, error)
+	UpdateBeatS3Key(ctx context.Context, arg UpdateBeatS3KeyParams) (Beat, error)
+	UpdateLicense(ctx context.Context, arg UpdateLicenseParams) (License, error)
+	UpdateOrderStatus(ctx context.Context, arg UpdateOrderStatusParams) (Order, error)
 	UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error)
+	UpdateUserCredentials(ctx context.Context, arg UpdateUserCredentialsParams) (User, error)
+	UpdateUserHashedPassword(ctx context.Context, arg UpdateUserHashedPasswordParams) error
+	UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error)
+	UpdateUserTOTPSecret(ctx context.Context, arg UpdateUserTOTPSecretParams) (User, error)
+	UpsertAudioFingerprint(ctx context.Context, arg UpsertAudioFingerprintParams) (AudioFingerprint, error)
+	UpsertBeatFile(ctx context.Context, arg UpsertBeatFileParams) (BeatFile, error)
+	UpsertBeatWaveform(ctx context.Context, arg UpsertBeatWaveformParams) (BeatWaveform, error)
+	UpsertCartItem(ctx context.Context, arg UpsertCartItemParams) (CartItem, error)
+	UpsertUserCart(ctx context.Context, arg UpsertUserCartParams) (Cart, error)
+	UseEmailVerification(ctx context.Context, id int64) (EmailVerification, error)
+	UseMFAChallenge(ctx context.Context, id int64) (MfaChallenge, error)
+	UseMFARecoveryCode(ctx context.Context, arg UseMFARecoveryCodeParams) (MfaRecoveryCode, error)
+	UsePasswordReset(ctx context.Context, id int64) (PasswordReset, error)
+	UseUserTOTPStep(ctx context.Context, arg UseUserTOTPStepParams) (User, error)
+	VerifyUserEmail(ctx context.Context, arg VerifyUserEmailParams) (User, error)
 }
 
 var _ Querier = (*Queries)(nil)
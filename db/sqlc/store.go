@@ -9,6 +9,21 @@ import (
 // Store provides all functions to execute queries and transactions
 type Store interface {
 	Querier
+	CompleteMFALoginTx(ctx context.Context, arg CompleteMFALoginTxParams) (CompleteMFALoginTxResult, error)
+	CreateOrderTx(ctx context.Context, arg CreateOrderTxParams) (CreateOrderTxResult, error)
+	CreateUserTx(ctx context.Context, arg CreateUserTxParams) (CreateUserTxResult, error)
+	EnableTOTPTx(ctx context.Context, arg EnableTOTPTxParams) (EnableTOTPTxResult, error)
+	MergeCartTx(ctx context.Context, arg MergeCartTxParams) (MergeCartTxResult, error)
+	ProcessWebhookEventTx(ctx context.Context, arg ProcessWebhookEventTxParams) (ProcessWebhookEventTxResult, error)
+	RecordStreamTx(ctx context.Context, arg RecordStreamTxParams) (RecordStreamTxResult, error)
+	ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (ResetPasswordTxResult, error)
+	ReviewDuplicateMatchTx(ctx context.Context, arg ReviewDuplicateMatchTxParams) (ReviewDuplicateMatchTxResult, error)
+	TransitionOrderTx(ctx context.Context, arg TransitionOrderTxParams) (TransitionOrderTxResult, error)
+	UpdateBeatAudioTx(ctx context.Context, arg UpdateBeatAudioTxParams) (UpdateBeatAudioTxResult, error)
+	UpdateBeatPreviewTx(ctx context.Context, arg UpdateBeatPreviewTxParams) (UpdateBeatPreviewTxResult, error)
+	UpdateUserCredentialsTx(ctx context.Context, arg UpdateUserCredentialsTxParams) (UpdateUserCredentialsTxResult, error)
+	UpdateUserRoleTx(ctx context.Context, arg UpdateUserRoleTxParams) (UpdateUserRoleTxResult, error)
+	VerifyEmailTx(ctx context.Context, arg VerifyEmailTxParams) (VerifyEmailTxResult, error)
 }
 
 // SQLStore provides all functions to execute SQL queries and transactions
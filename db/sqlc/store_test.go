@@ -1 +1,915 @@
 package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestUpdateUserRoleTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	admin := createRandomUser(t)
+	user := createRandomUser(t)
+
+	result, err := store.UpdateUserRoleTx(context.Background(), UpdateUserRoleTxParams{
+		ActorID: admin.ID,
+		UserID:  user.ID,
+		Role:    util.ProducerRole,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, result)
+
+	require.Equal(t, user.ID, result.User.ID)
+	require.Equal(t, util.ProducerRole, result.User.Role)
+
+	require.NotZero(t, result.AuditLog.ID)
+	require.Equal(t, admin.ID, result.AuditLog.ActorID)
+	require.Equal(t, user.ID, result.AuditLog.TargetUserID)
+	require.Equal(t, "listener -> producer", result.AuditLog.Details)
+
+	deleteRandomUser(t, admin.ID)
+	deleteRandomUser(t, user.ID)
+}
+
+func TestUpdateUserRoleTxRollback(t *testing.T) {
+	store := NewStore(testDB)
+
+	admin := createRandomUser(t)
+	user := createRandomUser(t)
+
+	_, err := store.UpdateUserRoleTx(context.Background(), UpdateUserRoleTxParams{
+		ActorID: admin.ID,
+		UserID:  user.ID,
+		Role:    util.RandomString(8),
+	})
+	require.Error(t, err)
+
+	unchanged, err := testQueries.GetUserById(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.Equal(t, util.ListenerRole, unchanged.Role)
+
+	deleteRandomUser(t, admin.ID)
+	deleteRandomUser(t, user.ID)
+}
+
+func TestCreateUserTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	hashedPassword, err := util.HashPassword(util.RandomPassword(), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	arg := CreateUserTxParams{
+		CreateUserParams: CreateUserParams{
+			Username:       util.RandomUsername(),
+			HashedPassword: hashedPassword,
+			Email:          util.RandomEmail(),
+			Role:           util.ListenerRole,
+		},
+		VerificationHashedToken: util.HashSecretToken(util.RandomString(32)),
+		VerificationExpiresAt:   time.Now().Add(time.Hour),
+	}
+
+	result, err := store.CreateUserTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.NotZero(t, result.User.ID)
+	require.Equal(t, arg.Username, result.User.Username)
+	require.False(t, result.User.VerifiedAt.Valid)
+
+	require.Equal(t, result.User.ID, result.EmailVerification.UserID)
+	require.Equal(t, arg.Email, result.EmailVerification.Email)
+	require.Equal(t, arg.VerificationHashedToken, result.EmailVerification.HashedToken)
+
+	deleteRandomUser(t, result.User.ID)
+}
+
+func TestVerifyEmailTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	user := createRandomUser(t)
+	verification := createRandomEmailVerification(t, user)
+
+	arg := VerifyEmailTxParams{
+		VerificationID: verification.ID,
+		UserID:         user.ID,
+		Email:          user.Email,
+	}
+
+	result, err := store.VerifyEmailTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, result.User.VerifiedAt.Valid)
+	require.True(t, result.EmailVerification.UsedAt.Valid)
+
+	// Using the same verification twice fails
+	_, err = store.VerifyEmailTx(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	deleteRandomUser(t, user.ID)
+}
+
+func TestVerifyEmailTxRollback(t *testing.T) {
+	store := NewStore(testDB)
+
+	user := createRandomUser(t)
+	verification := createRandomEmailVerification(t, user)
+
+	// The email was changed after the verification had been sent
+	_, err := store.VerifyEmailTx(context.Background(), VerifyEmailTxParams{
+		VerificationID: verification.ID,
+		UserID:         user.ID,
+		Email:          util.RandomEmail(),
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	unused, err := testQueries.GetEmailVerificationByHashedToken(context.Background(), verification.HashedToken)
+	require.NoError(t, err)
+	require.False(t, unused.UsedAt.Valid)
+
+	deleteRandomUser(t, user.ID)
+}
+
+func TestResetPasswordTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	user := createRandomUser(t)
+	session := createRandomSession(t, user)
+	reset := createRandomPasswordReset(t, user)
+
+	hashedPassword, err := util.HashPassword(util.RandomPassword(), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	arg := ResetPasswordTxParams{
+		PasswordResetID: reset.ID,
+		UserID:          user.ID,
+		HashedPassword:  hashedPassword,
+	}
+
+	result, err := store.ResetPasswordTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, hashedPassword, result.User.HashedPassword)
+	require.True(t, result.PasswordReset.UsedAt.Valid)
+
+	blocked, err := testQueries.GetSession(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.True(t, blocked.IsBlocked)
+
+	// A password reset can only be used once
+	_, err = store.ResetPasswordTx(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	deleteRandomUser(t, user.ID)
+}
+
+func TestUpdateUserCredentialsTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	user := createRandomUser(t)
+	session := createRandomSession(t, user)
+
+	hashedPassword, err := util.HashPassword(util.RandomPassword(), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	arg := UpdateUserCredentialsTxParams{
+		UpdateUserCredentialsParams: UpdateUserCredentialsParams{
+			ID:             user.ID,
+			HashedPassword: hashedPassword,
+			Email:          user.Email,
+		},
+	}
+
+	result, err := store.UpdateUserCredentialsTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, hashedPassword, result.User.HashedPassword)
+	require.True(t, result.User.PasswordChangedAt.After(user.PasswordChangedAt))
+
+	blocked, err := testQueries.GetSession(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.True(t, blocked.IsBlocked)
+
+	deleteRandomUser(t, user.ID)
+}
+
+func TestEnableTOTPTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	user := createRandomUser(t)
+	oldCode := createRandomMFARecoveryCode(t, user)
+
+	_, err := testQueries.UpdateUserTOTPSecret(context.Background(), UpdateUserTOTPSecretParams{
+		ID:         user.ID,
+		TotpSecret: sql.NullString{String: util.RandomString(32), Valid: true},
+	})
+	require.NoError(t, err)
+
+	arg := EnableTOTPTxParams{
+		UserID: user.ID,
+		HashedRecoveryCodes: []string{
+			util.HashSecretToken(util.RandomString(10)),
+			util.HashSecretToken(util.RandomString(10)),
+		},
+	}
+
+	result, err := store.EnableTOTPTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, result.User.TotpEnabledAt.Valid)
+	require.Len(t, result.RecoveryCodes, len(arg.HashedRecoveryCodes))
+
+	// Recovery codes from a previous enrollment are replaced
+	_, err = testQueries.UseMFARecoveryCode(context.Background(), UseMFARecoveryCodeParams{
+		UserID:     user.ID,
+		HashedCode: oldCode.HashedCode,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// TOTP cannot be enabled twice
+	_, err = store.EnableTOTPTx(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	deleteRandomUser(t, user.ID)
+}
+
+func TestCompleteMFALoginTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	user := createRandomUser(t)
+	code := createRandomMFARecoveryCode(t, user)
+	challenge1 := createRandomMFAChallenge(t, user)
+	challenge2 := createRandomMFAChallenge(t, user)
+
+	result, err := store.CompleteMFALoginTx(context.Background(), CompleteMFALoginTxParams{
+		ChallengeID:        challenge1.ID,
+		UserID:             user.ID,
+		HashedRecoveryCode: code.HashedCode,
+	})
+	require.NoError(t, err)
+	require.True(t, result.Challenge.UsedAt.Valid)
+	require.True(t, result.RecoveryCode.UsedAt.Valid)
+
+	// Reusing the recovery code fails and leaves the challenge unused
+	_, err = store.CompleteMFALoginTx(context.Background(), CompleteMFALoginTxParams{
+		ChallengeID:        challenge2.ID,
+		UserID:             user.ID,
+		HashedRecoveryCode: code.HashedCode,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	unused, err := testQueries.GetMFAChallengeByHashedToken(context.Background(), challenge2.HashedToken)
+	require.NoError(t, err)
+	require.False(t, unused.UsedAt.Valid)
+
+	// A TOTP code is accepted once, as are codes of earlier steps
+	step := time.Now().Unix() / 30
+	_, err = store.CompleteMFALoginTx(context.Background(), CompleteMFALoginTxParams{
+		ChallengeID: challenge2.ID,
+		UserID:      user.ID,
+		TOTPStep:    step,
+	})
+	require.NoError(t, err)
+
+	for _, reused := range []int64{step, step - 1} {
+		challenge := createRandomMFAChallenge(t, user)
+		_, err = store.CompleteMFALoginTx(context.Background(), CompleteMFALoginTxParams{
+			ChallengeID: challenge.ID,
+			UserID:      user.ID,
+			TOTPStep:    reused,
+		})
+		require.ErrorIs(t, err, sql.ErrNoRows)
+	}
+
+	deleteRandomUser(t, user.ID)
+}
+
+func TestRecordStreamTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	beat := createRandomBeat(t)
+	sessionID := uuid.New()
+
+	record := func(bytes int64) RecordStreamTxResult {
+		result, err := store.RecordStreamTx(context.Background(), RecordStreamTxParams{
+			BeatID:        beat.ID,
+			SessionID:     sessionID,
+			BytesStreamed: bytes,
+			PlayThreshold: 1000,
+		})
+		require.NoError(t, err)
+		return result
+	}
+
+	// Below the threshold nothing is counted
+	result := record(600)
+	require.False(t, result.Played)
+	require.False(t, result.StreamSession.PlayedAt.Valid)
+
+	// Crossing the threshold counts one play
+	result = record(600)
+	require.True(t, result.Played)
+	require.True(t, result.StreamSession.PlayedAt.Valid)
+	require.Equal(t, int64(1200), result.StreamSession.BytesStreamed)
+
+	// Seeking around in the same session doesn't count again
+	result = record(5000)
+	require.False(t, result.Played)
+	require.Equal(t, int64(6200), result.StreamSession.BytesStreamed)
+
+	got, err := testQueries.GetBeatById(context.Background(), beat.ID)
+	require.NoError(t, err)
+	require.Equal(t, beat.PlayCount+1, got.PlayCount)
+
+	deleteRandomBeat(t, beat.ID)
+	deleteRandomUser(t, beat.CreatorID)
+}
+
+func randomAudioFile(fileType string, contentType string) UpsertBeatFileParams {
+	return UpsertBeatFileParams{
+		FileType:    fileType,
+		S3Key:       util.RandomS3Key(),
+		ContentType: contentType,
+		Size:        util.RandomInt(1, 1<<20),
+		Checksum:    util.RandomString(64),
+	}
+}
+
+func TestUpdateBeatAudioTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	beat := createRandomBeat(t)
+	master := upsertRandomBeatFile(t, beat, util.MasterWAVFile)
+	preview := upsertRandomBeatFile(t, beat, util.TaggedPreviewFile)
+	stems := upsertRandomBeatFile(t, beat, util.StemsFile)
+
+	// An MP3 is attached next to the master WAV, the audio of the beat stays
+	arg := UpdateBeatAudioTxParams{
+		BeatID: beat.ID,
+		File:   randomAudioFile(util.MP3File, "audio/mpeg"),
+	}
+
+	result, err := store.UpdateBeatAudioTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, beat.S3Key, result.Beat.S3Key)
+	require.Equal(t, beat.PreviewKey, result.Beat.PreviewKey)
+	require.Equal(t, beat.ID, result.File.BeatID)
+	require.Equal(t, arg.File.S3Key, result.File.S3Key)
+	require.Empty(t, result.Fingerprint)
+	require.Empty(t, result.RemovedFiles)
+
+	mp3 := result.File
+	files, err := testQueries.ListBeatFiles(context.Background(), beat.ID)
+	require.NoError(t, err)
+	require.Len(t, files, 4)
+
+	// A new WAV master replaces the previous one and its preview, the MP3 and the stems stay
+	arg.File = randomAudioFile(util.MasterWAVFile, "audio/wav")
+
+	result, err = store.UpdateBeatAudioTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.File.S3Key, result.Beat.S3Key)
+	require.Empty(t, result.Beat.PreviewKey)
+	require.Equal(t, arg.File.S3Key, result.File.S3Key)
+	require.Equal(t, beat.ID, result.Fingerprint.BeatID)
+	require.Equal(t, arg.File.S3Key, result.Fingerprint.S3Key)
+	require.Equal(t, arg.File.Checksum, result.Fingerprint.ContentHash)
+
+	removed := make([]string, 0, len(result.RemovedFiles))
+	for _, file := range result.RemovedFiles {
+		removed = append(removed, file.S3Key)
+	}
+	require.ElementsMatch(t, []string{master.S3Key, preview.S3Key}, removed)
+
+	files, err = testQueries.ListBeatFiles(context.Background(), beat.ID)
+	require.NoError(t, err)
+	kept := make([]string, 0, len(files))
+	for _, file := range files {
+		kept = append(kept, file.S3Key)
+	}
+	require.ElementsMatch(t, []string{arg.File.S3Key, mp3.S3Key, stems.S3Key}, kept)
+
+	// A missing beat rolls everything back
+	arg.BeatID = beat.ID + 1000000
+	_, err = store.UpdateBeatAudioTx(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	deleteRandomBeat(t, beat.ID)
+	deleteRandomUser(t, beat.CreatorID)
+}
+
+func TestUpdateBeatAudioTxMP3Master(t *testing.T) {
+	store := NewStore(testDB)
+
+	beat := createRandomBeat(t)
+	upsertRandomBeatFile(t, beat, util.MP3File)
+
+	// Without a WAV master the MP3 is the audio of the beat
+	arg := UpdateBeatAudioTxParams{
+		BeatID: beat.ID,
+		File:   randomAudioFile(util.MP3File, "audio/mpeg"),
+	}
+
+	result, err := store.UpdateBeatAudioTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.File.S3Key, result.Beat.S3Key)
+	// The untagged MP3 is never the preview
+	require.Empty(t, result.Beat.PreviewKey)
+	require.Equal(t, arg.File.S3Key, result.Fingerprint.S3Key)
+	require.Equal(t, arg.File.Checksum, result.Fingerprint.ContentHash)
+	require.Len(t, result.RemovedFiles, 1)
+	require.Equal(t, util.MP3File, result.RemovedFiles[0].FileType)
+
+	deleteRandomBeat(t, beat.ID)
+	deleteRandomUser(t, beat.CreatorID)
+}
+
+func TestUpdateBeatPreviewTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	beat := createRandomBeat(t)
+
+	arg := UpdateBeatPreviewTxParams{
+		BeatID:      beat.ID,
+		S3Key:       beat.S3Key,
+		PreviewKey:  util.RandomS3Key(),
+		ContentType: "audio/wav",
+		Size:        util.RandomInt(1, 1<<20),
+		Checksum:    util.RandomString(64),
+	}
+
+	result, err := store.UpdateBeatPreviewTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.PreviewKey, result.Beat.PreviewKey)
+	require.Equal(t, util.TaggedPreviewFile, result.File.FileType)
+	require.Equal(t, arg.PreviewKey, result.File.S3Key)
+	require.Equal(t, arg.Size, result.File.Size)
+	require.Equal(t, arg.Checksum, result.File.Checksum)
+
+	// A preview of replaced audio is neither set nor attached
+	arg.S3Key = util.RandomS3Key()
+	arg.PreviewKey = util.RandomS3Key()
+	_, err = store.UpdateBeatPreviewTx(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	file, err := testQueries.GetBeatFile(context.Background(), GetBeatFileParams{
+		BeatID:   beat.ID,
+		FileType: util.TaggedPreviewFile,
+	})
+	require.NoError(t, err)
+	require.Equal(t, result.File.S3Key, file.S3Key)
+
+	deleteRandomBeat(t, beat.ID)
+	deleteRandomUser(t, beat.CreatorID)
+}
+
+func TestReviewDuplicateMatchTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	admin := createRandomUser(t)
+	beat := createRandomBeat(t)
+	matched := createRandomBeat(t)
+	match := createRandomDuplicateMatch(t, beat, matched)
+
+	result, err := store.ReviewDuplicateMatchTx(context.Background(), ReviewDuplicateMatchTxParams{
+		ActorID: admin.ID,
+		MatchID: match.ID,
+		Status:  util.ConfirmedMatch,
+	})
+	require.NoError(t, err)
+	require.Equal(t, match.ID, result.Match.ID)
+	require.Equal(t, util.ConfirmedMatch, result.Match.Status)
+	require.Equal(t, admin.ID, result.Match.ReviewedBy.Int32)
+	require.True(t, result.Match.ReviewedAt.Valid)
+
+	require.NotZero(t, result.AuditLog.ID)
+	require.Equal(t, admin.ID, result.AuditLog.ActorID)
+	require.Equal(t, beat.CreatorID, result.AuditLog.TargetUserID)
+	require.Equal(t, fmt.Sprintf("beat %d matching beat %d: pending -> confirmed", beat.ID, matched.ID), result.AuditLog.Details)
+
+	// A missing match changes nothing
+	_, err = store.ReviewDuplicateMatchTx(context.Background(), ReviewDuplicateMatchTxParams{
+		ActorID: admin.ID,
+		MatchID: match.ID + 1000000,
+		Status:  util.DismissedMatch,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	for _, beat := range []Beat{beat, matched} {
+		deleteRandomBeat(t, beat.ID)
+		deleteRandomUser(t, beat.CreatorID)
+	}
+	deleteRandomUser(t, admin.ID)
+}
+
+func TestMergeCartTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	user := createRandomUser(t)
+	beat1 := createRandomBeat(t)
+	beat2 := createRandomBeat(t)
+	own := createRandomBeatWithArgs(t, CreateBeatParams{
+		CreatorID: user.ID,
+		Title:     util.RandomTitle(),
+		Genre:     util.RandomGenre(),
+		Key:       util.RandomKey(),
+		Bpm:       util.RandomBpm(),
+		Tags:      util.RandomTags(),
+	})
+	basic1 := createRandomLicense(t, beat1, util.BasicLicense, 2999)
+	premium1 := createRandomLicense(t, beat1, util.PremiumLicense, 7999)
+	basic2 := createRandomLicense(t, beat2, util.BasicLicense, 1999)
+	basicOwn := createRandomLicense(t, own, util.BasicLicense, 999)
+
+	userCart, err := store.UpsertUserCart(context.Background(), UpsertUserCartParams{
+		ID:     uuid.New(),
+		UserID: sql.NullInt32{Int32: user.ID, Valid: true},
+	})
+	require.NoError(t, err)
+	upsertRandomCartItem(t, userCart, basic1)
+
+	anonymous := createRandomCart(t)
+	upsertRandomCartItem(t, anonymous, premium1)
+	upsertRandomCartItem(t, anonymous, basic2)
+	upsertRandomCartItem(t, anonymous, basicOwn)
+
+	result, err := store.MergeCartTx(context.Background(), MergeCartTxParams{
+		AnonymousCartID: anonymous.ID,
+		UserID:          user.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, userCart.ID, result.Cart.ID)
+	require.Equal(t, int64(2), result.Moved)
+
+	// The anonymous choice of license wins, the user's own beat is left out
+	rows, err := store.ListCartItems(context.Background(), userCart.ID)
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	licenses := map[int32]int64{}
+	for _, row := range rows {
+		licenses[row.BeatID] = row.LicenseID
+	}
+	require.Equal(t, map[int32]int64{beat1.ID: premium1.ID, beat2.ID: basic2.ID}, licenses)
+
+	_, err = store.GetCart(context.Background(), anonymous.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// Merging again, or merging the cart of a user, does nothing
+	result, err = store.MergeCartTx(context.Background(), MergeCartTxParams{AnonymousCartID: anonymous.ID, UserID: user.ID})
+	require.NoError(t, err)
+	require.Zero(t, result.Moved)
+	result, err = store.MergeCartTx(context.Background(), MergeCartTxParams{AnonymousCartID: userCart.ID, UserID: beat1.CreatorID})
+	require.NoError(t, err)
+	require.Zero(t, result.Moved)
+	rows, err = store.ListCartItems(context.Background(), userCart.ID)
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+
+	for _, beat := range []Beat{beat1, beat2, own} {
+		deleteRandomBeat(t, beat.ID)
+	}
+	for _, userID := range []int32{beat1.CreatorID, beat2.CreatorID, user.ID} {
+		deleteRandomUser(t, userID)
+	}
+}
+
+func TestCreateOrderTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	buyer := createRandomUser(t)
+	beat1 := createRandomBeat(t)
+	beat2 := createRandomBeat(t)
+	basic := createRandomLicense(t, beat1, util.BasicLicense, 2999)
+	premium := createRandomLicense(t, beat2, util.PremiumLicense, 7999)
+
+	cart, err := store.UpsertUserCart(context.Background(), UpsertUserCartParams{
+		ID:     uuid.New(),
+		UserID: sql.NullInt32{Int32: buyer.ID, Valid: true},
+	})
+	require.NoError(t, err)
+
+	_, err = store.CreateOrderTx(context.Background(), CreateOrderTxParams{BuyerID: buyer.ID, CartID: cart.ID})
+	require.ErrorIs(t, err, ErrEmptyCart)
+
+	upsertRandomCartItem(t, cart, basic)
+	upsertRandomCartItem(t, cart, premium)
+
+	// Nothing is ordered at a price the buyer didn't see
+	updateBasic := UpdateLicenseParams{
+		ID:        basic.ID,
+		Tier:      basic.Tier,
+		Price:     basic.Price + 1000,
+		Currency:  basic.Currency,
+		FileTypes: basic.FileTypes,
+		Terms:     basic.Terms,
+	}
+	_, err = store.UpdateLicense(context.Background(), updateBasic)
+	require.NoError(t, err)
+
+	_, err = store.CreateOrderTx(context.Background(), CreateOrderTxParams{BuyerID: buyer.ID, CartID: cart.ID})
+	require.ErrorIs(t, err, ErrPriceChanged)
+
+	updateBasic.Price = basic.Price
+	_, err = store.UpdateLicense(context.Background(), updateBasic)
+	require.NoError(t, err)
+
+	result, err := store.CreateOrderTx(context.Background(), CreateOrderTxParams{BuyerID: buyer.ID, CartID: cart.ID})
+	require.NoError(t, err)
+	require.Equal(t, buyer.ID, result.Order.BuyerID)
+	require.Equal(t, util.OrderPending, result.Order.Status)
+	require.Equal(t, basic.Price+premium.Price, result.Order.Total)
+	require.Equal(t, basic.Currency, result.Order.Currency)
+	require.Len(t, result.Items, 2)
+	for _, item := range result.Items {
+		require.Equal(t, result.Order.ID, item.OrderID)
+		switch item.LicenseID.Int64 {
+		case basic.ID:
+			require.Equal(t, basic.Price, item.Price)
+			require.Equal(t, beat1.Title, item.BeatTitle)
+		case premium.ID:
+			require.Equal(t, premium.Price, item.Price)
+			require.Equal(t, beat2.Title, item.BeatTitle)
+		default:
+			t.Fatalf("unexpected license %d", item.LicenseID.Int64)
+		}
+	}
+
+	// The cart is only emptied once the order is paid
+	rows, err := store.ListCartItems(context.Background(), cart.ID)
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+
+	for _, beat := range []Beat{beat1, beat2} {
+		deleteRandomBeat(t, beat.ID)
+	}
+	for _, userID := range []int32{beat1.CreatorID, beat2.CreatorID, buyer.ID} {
+		deleteRandomUser(t, userID)
+	}
+}
+
+func TestTransitionOrderTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	admin := createRandomUser(t)
+	buyer := createRandomUser(t)
+	beat1 := createRandomBeat(t)
+	beat2 := createRandomBeat(t)
+	basic1 := createRandomLicense(t, beat1, util.BasicLicense, 2999)
+	basic2 := createRandomLicense(t, beat2, util.BasicLicense, 1999)
+
+	cart, err := store.UpsertUserCart(context.Background(), UpsertUserCartParams{
+		ID:     uuid.New(),
+		UserID: sql.NullInt32{Int32: buyer.ID, Valid: true},
+	})
+	require.NoError(t, err)
+	upsertRandomCartItem(t, cart, basic1)
+
+	created, err := store.CreateOrderTx(context.Background(), CreateOrderTxParams{BuyerID: buyer.ID, CartID: cart.ID})
+	require.NoError(t, err)
+	order := created.Order
+
+	// Added after checking out, stays in the cart
+	upsertRandomCartItem(t, cart, basic2)
+
+	// Exclusives are sold once paid, checking out another order of one fails
+	exclusive := createRandomLicense(t, beat2, util.ExclusiveLicense, 49999)
+	_, err = testQueries.CreateOrderItem(context.Background(), CreateOrderItemParams{
+		OrderID:   order.ID,
+		BeatID:    sql.NullInt32{Int32: beat2.ID, Valid: true},
+		LicenseID: sql.NullInt64{Int64: exclusive.ID, Valid: true},
+		BeatTitle: beat2.Title,
+		Tier:      exclusive.Tier,
+		Price:     exclusive.Price,
+		Currency:  exclusive.Currency,
+	})
+	require.NoError(t, err)
+
+	_, err = store.TransitionOrderTx(context.Background(), TransitionOrderTxParams{OrderID: order.ID, Status: util.OrderRefunded})
+	require.ErrorIs(t, err, ErrInvalidOrderTransition)
+
+	result, err := store.TransitionOrderTx(context.Background(), TransitionOrderTxParams{
+		OrderID:       order.ID,
+		Status:        util.OrderPaid,
+		FailureReason: "ignored",
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.OrderPaid, result.Order.Status)
+	require.Empty(t, result.Order.FailureReason)
+	require.Zero(t, result.AuditLog.ID)
+
+	rows, err := store.ListCartItems(context.Background(), cart.ID)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, basic2.ID, rows[0].LicenseID)
+
+	sold, err := store.GetLicense(context.Background(), exclusive.ID)
+	require.NoError(t, err)
+	require.True(t, sold.SoldAt.Valid)
+	unsold, err := store.GetLicense(context.Background(), basic1.ID)
+	require.NoError(t, err)
+	require.False(t, unsold.SoldAt.Valid)
+
+	otherCart, err := store.CreateCart(context.Background(), CreateCartParams{ID: uuid.New()})
+	require.NoError(t, err)
+	upsertRandomCartItem(t, otherCart, sold)
+	_, err = store.CreateOrderTx(context.Background(), CreateOrderTxParams{BuyerID: admin.ID, CartID: otherCart.ID})
+	require.ErrorIs(t, err, ErrLicenseSold)
+	require.NoError(t, store.DeleteCart(context.Background(), otherCart.ID))
+
+	_, err = store.TransitionOrderTx(context.Background(), TransitionOrderTxParams{OrderID: order.ID, Status: util.OrderFailed})
+	require.ErrorIs(t, err, ErrInvalidOrderTransition)
+
+	result, err = store.TransitionOrderTx(context.Background(), TransitionOrderTxParams{
+		OrderID: order.ID,
+		Status:  util.OrderRefunded,
+		ActorID: admin.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.OrderRefunded, result.Order.Status)
+	require.NotZero(t, result.AuditLog.ID)
+	require.Equal(t, admin.ID, result.AuditLog.ActorID)
+	require.Equal(t, "transition_order", result.AuditLog.Action)
+	require.Equal(t, buyer.ID, result.AuditLog.TargetUserID)
+
+	for _, beat := range []Beat{beat1, beat2} {
+		deleteRandomBeat(t, beat.ID)
+	}
+	for _, userID := range []int32{beat1.CreatorID, beat2.CreatorID, buyer.ID, admin.ID} {
+		deleteRandomUser(t, userID)
+	}
+}
+
+func TestTransitionOrderTxExclusiveSoldOnce(t *testing.T) {
+	store := NewStore(testDB)
+
+	buyer1 := createRandomUser(t)
+	buyer2 := createRandomUser(t)
+	beat := createRandomBeat(t)
+	exclusive := createRandomLicense(t, beat, util.ExclusiveLicense, 49999)
+
+	carts := make([]Cart, 2)
+	for i, buyer := range []User{buyer1, buyer2} {
+		cart, err := store.UpsertUserCart(context.Background(), UpsertUserCartParams{
+			ID:     uuid.New(),
+			UserID: sql.NullInt32{Int32: buyer.ID, Valid: true},
+		})
+		require.NoError(t, err)
+		upsertRandomCartItem(t, cart, exclusive)
+		carts[i] = cart
+	}
+
+	// The first order reserves the exclusive, nobody else can order it meanwhile
+	order1, err := store.CreateOrderTx(context.Background(), CreateOrderTxParams{BuyerID: buyer1.ID, CartID: carts[0].ID})
+	require.NoError(t, err)
+	license, err := store.GetLicense(context.Background(), exclusive.ID)
+	require.NoError(t, err)
+	require.Equal(t, order1.Order.ID, license.ReservedOrderID.Int64)
+
+	_, err = store.CreateOrderTx(context.Background(), CreateOrderTxParams{BuyerID: buyer2.ID, CartID: carts[1].ID})
+	require.ErrorIs(t, err, ErrLicenseReserved)
+
+	// Failing the order releases the reservation
+	_, err = store.TransitionOrderTx(context.Background(), TransitionOrderTxParams{OrderID: order1.Order.ID, Status: util.OrderFailed})
+	require.NoError(t, err)
+	license, err = store.GetLicense(context.Background(), exclusive.ID)
+	require.NoError(t, err)
+	require.False(t, license.ReservedOrderID.Valid)
+
+	order2, err := store.CreateOrderTx(context.Background(), CreateOrderTxParams{BuyerID: buyer2.ID, CartID: carts[1].ID})
+	require.NoError(t, err)
+
+	// An order of the exclusive placed without a reservation, both are paid
+	order3, err := store.CreateOrder(context.Background(), CreateOrderParams{
+		BuyerID:  buyer1.ID,
+		Total:    exclusive.Price,
+		Currency: exclusive.Currency,
+	})
+	require.NoError(t, err)
+	_, err = store.CreateOrderItem(context.Background(), CreateOrderItemParams{
+		OrderID:   order3.ID,
+		BeatID:    sql.NullInt32{Int32: beat.ID, Valid: true},
+		LicenseID: sql.NullInt64{Int64: exclusive.ID, Valid: true},
+		BeatTitle: beat.Title,
+		Tier:      exclusive.Tier,
+		Price:     exclusive.Price,
+		Currency:  exclusive.Currency,
+	})
+	require.NoError(t, err)
+
+	result, err := store.TransitionOrderTx(context.Background(), TransitionOrderTxParams{OrderID: order2.Order.ID, Status: util.OrderPaid})
+	require.NoError(t, err)
+	require.Equal(t, util.OrderPaid, result.Order.Status)
+
+	_, err = store.TransitionOrderTx(context.Background(), TransitionOrderTxParams{OrderID: order3.ID, Status: util.OrderPaid})
+	require.ErrorIs(t, err, ErrLicenseSold)
+
+	// The terms of a sold license are final
+	_, err = store.UpdateLicense(context.Background(), UpdateLicenseParams{
+		ID:        exclusive.ID,
+		Tier:      exclusive.Tier,
+		Price:     exclusive.Price + 1000,
+		Currency:  exclusive.Currency,
+		FileTypes: exclusive.FileTypes,
+		Terms:     exclusive.Terms,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// Nothing changed, the order is left to be failed and refunded
+	order3, err = store.GetOrder(context.Background(), order3.ID)
+	require.NoError(t, err)
+	require.Equal(t, util.OrderPending, order3.Status)
+	rows, err := store.ListCartItems(context.Background(), carts[0].ID)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+
+	deleteRandomBeat(t, beat.ID)
+	for _, userID := range []int32{beat.CreatorID, buyer1.ID, buyer2.ID} {
+		deleteRandomUser(t, userID)
+	}
+}
+
+func TestProcessWebhookEventTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	buyer := createRandomUser(t)
+	order := createRandomOrder(t, buyer)
+	intentID := "pi_" + util.RandomString(16)
+
+	// Events for intents without an order change nothing
+	event := recordRandomWebhookEvent(t, intentID)
+	arg := ProcessWebhookEventTxParams{
+		EventID:     event.ID,
+		IntentID:    intentID,
+		OrderStatus: util.OrderPaid,
+	}
+	_, err := store.ProcessWebhookEventTx(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	event, err = store.GetWebhookEvent(context.Background(), event.ID)
+	require.NoError(t, err)
+	require.Equal(t, util.WebhookEventPending, event.Status)
+
+	_, err = store.SetOrderPaymentIntent(context.Background(), SetOrderPaymentIntentParams{
+		ID:              order.ID,
+		PaymentIntentID: sql.NullString{String: intentID, Valid: true},
+	})
+	require.NoError(t, err)
+
+	result, err := store.ProcessWebhookEventTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.False(t, result.Duplicate)
+	require.Equal(t, util.OrderPaid, result.Order.Status)
+	require.Equal(t, util.WebhookEventProcessed, result.Event.Status)
+	require.Equal(t, sql.NullInt64{Int64: order.ID, Valid: true}, result.Event.OrderID)
+	require.True(t, result.Event.ProcessedAt.Valid)
+
+	// Redeliveries, even with another payload, are processed once
+	result, err = store.ProcessWebhookEventTx(context.Background(), ProcessWebhookEventTxParams{
+		EventID:     event.ID,
+		IntentID:    intentID,
+		OrderStatus: util.OrderRefunded,
+	})
+	require.NoError(t, err)
+	require.True(t, result.Duplicate)
+	require.Equal(t, util.WebhookEventProcessed, result.Event.Status)
+	order, err = store.GetOrder(context.Background(), order.ID)
+	require.NoError(t, err)
+	require.Equal(t, util.OrderPaid, order.Status)
+
+	// Another event for a status the order is in already, like a paid order at checkout
+	paid := recordRandomWebhookEvent(t, intentID)
+	result, err = store.ProcessWebhookEventTx(context.Background(), ProcessWebhookEventTxParams{
+		EventID:     paid.ID,
+		IntentID:    intentID,
+		OrderStatus: util.OrderPaid,
+	})
+	require.NoError(t, err)
+	require.False(t, result.Duplicate)
+	require.Equal(t, util.WebhookEventProcessed, result.Event.Status)
+
+	failed := recordRandomWebhookEvent(t, intentID)
+	_, err = store.ProcessWebhookEventTx(context.Background(), ProcessWebhookEventTxParams{
+		EventID:       failed.ID,
+		IntentID:      intentID,
+		OrderStatus:   util.OrderFailed,
+		FailureReason: "insufficient funds",
+	})
+	require.ErrorIs(t, err, ErrInvalidOrderTransition)
+
+	ignored := recordRandomWebhookEvent(t, intentID)
+	result, err = store.ProcessWebhookEventTx(context.Background(), ProcessWebhookEventTxParams{
+		EventID:  ignored.ID,
+		IntentID: intentID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.WebhookEventIgnored, result.Event.Status)
+	require.False(t, result.Event.OrderID.Valid)
+
+	// Events outlive the orders they settled
+	deleteRandomUser(t, buyer.ID)
+	event, err = store.GetWebhookEvent(context.Background(), event.ID)
+	require.NoError(t, err)
+	require.False(t, event.OrderID.Valid)
+}
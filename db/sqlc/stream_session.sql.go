@@ -0,0 +1,93 @@
+// Code generated by sqlc. DO NOT EDIT.
+// source: stream_session.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const addStreamedBytes = `-- name: AddStreamedBytes :one
+INSERT INTO stream_sessions (
+    beat_id,
+    session_id,
+    bytes_streamed
+) VALUES (
+    $1, $2, $3
+)
+ON CONFLICT (beat_id, session_id) DO UPDATE
+SET bytes_streamed = stream_sessions.bytes_streamed + EXCLUDED.bytes_streamed
+RETURNING beat_id, session_id, bytes_streamed, played_at, created_at
+`
+
+type AddStreamedBytesParams struct {
+	BeatID        int32     `json:"beat_id"`
+	SessionID     uuid.UUID `json:"session_id"`
+	BytesStreamed int64     `json:"bytes_streamed"`
+}
+
+// Adds the bytes sent by one response to the total of the listening session.
+func (q *Queries) AddStreamedBytes(ctx context.Context, arg AddStreamedBytesParams) (StreamSession, error) {
+	row := q.db.QueryRowContext(ctx, addStreamedBytes, arg.BeatID, arg.SessionID, arg.BytesStreamed)
+	var i StreamSession
+	err := row.Scan(
+		&i.BeatID,
+		&i.SessionID,
+		&i.BytesStreamed,
+		&i.PlayedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getStreamSession = `-- name: GetStreamSession :one
+SELECT beat_id, session_id, bytes_streamed, played_at, created_at FROM stream_sessions
+WHERE beat_id = $1 AND session_id = $2
+LIMIT 1
+`
+
+type GetStreamSessionParams struct {
+	BeatID    int32     `json:"beat_id"`
+	SessionID uuid.UUID `json:"session_id"`
+}
+
+func (q *Queries) GetStreamSession(ctx context.Context, arg GetStreamSessionParams) (StreamSession, error) {
+	row := q.db.QueryRowContext(ctx, getStreamSession, arg.BeatID, arg.SessionID)
+	var i StreamSession
+	err := row.Scan(
+		&i.BeatID,
+		&i.SessionID,
+		&i.BytesStreamed,
+		&i.PlayedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const markStreamSessionPlayed = `-- name: MarkStreamSessionPlayed :one
+UPDATE stream_sessions
+SET played_at = now()
+WHERE beat_id = $1 AND session_id = $2 AND played_at IS NULL
+RETURNING beat_id, session_id, bytes_streamed, played_at, created_at
+`
+
+type MarkStreamSessionPlayedParams struct {
+	BeatID    int32     `json:"beat_id"`
+	SessionID uuid.UUID `json:"session_id"`
+}
+
+// Returns sql.ErrNoRows if the play of the session was already counted.
+func (q *Queries) MarkStreamSessionPlayed(ctx context.Context, arg MarkStreamSessionPlayedParams) (StreamSession, error) {
+	row := q.db.QueryRowContext(ctx, markStreamSessionPlayed, arg.BeatID, arg.SessionID)
+	var i StreamSession
+	err := row.Scan(
+		&i.BeatID,
+		&i.SessionID,
+		&i.BytesStreamed,
+		&i.PlayedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,81 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func addRandomStreamedBytes(t *testing.T, beat Beat, sessionID uuid.UUID, bytes int64) StreamSession {
+	arg := AddStreamedBytesParams{
+		BeatID:        beat.ID,
+		SessionID:     sessionID,
+		BytesStreamed: bytes,
+	}
+
+	session, err := testQueries.AddStreamedBytes(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.BeatID, session.BeatID)
+	require.Equal(t, arg.SessionID, session.SessionID)
+	require.NotZero(t, session.CreatedAt)
+
+	return session
+}
+
+func TestAddStreamedBytes(t *testing.T) {
+	beat := createRandomBeat(t)
+	sessionID := uuid.New()
+
+	session := addRandomStreamedBytes(t, beat, sessionID, 1000)
+	require.Equal(t, int64(1000), session.BytesStreamed)
+	require.False(t, session.PlayedAt.Valid)
+
+	// Bytes of later responses are added up
+	session = addRandomStreamedBytes(t, beat, sessionID, 500)
+	require.Equal(t, int64(1500), session.BytesStreamed)
+
+	// Other sessions are counted separately
+	other := addRandomStreamedBytes(t, beat, uuid.New(), 200)
+	require.Equal(t, int64(200), other.BytesStreamed)
+
+	got, err := testQueries.GetStreamSession(context.Background(), GetStreamSessionParams{
+		BeatID:    beat.ID,
+		SessionID: sessionID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, session.BytesStreamed, got.BytesStreamed)
+
+	deleteRandomBeat(t, beat.ID)
+	deleteRandomUser(t, beat.CreatorID)
+
+	// Sessions are deleted together with their beat
+	_, err = testQueries.GetStreamSession(context.Background(), GetStreamSessionParams{
+		BeatID:    beat.ID,
+		SessionID: sessionID,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestMarkStreamSessionPlayed(t *testing.T) {
+	beat := createRandomBeat(t)
+	session := addRandomStreamedBytes(t, beat, uuid.New(), 1000)
+
+	arg := MarkStreamSessionPlayedParams{
+		BeatID:    session.BeatID,
+		SessionID: session.SessionID,
+	}
+
+	played, err := testQueries.MarkStreamSessionPlayed(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, played.PlayedAt.Valid)
+
+	// A session is only played once
+	_, err = testQueries.MarkStreamSessionPlayed(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	deleteRandomBeat(t, beat.ID)
+	deleteRandomUser(t, beat.CreatorID)
+}
@@ -0,0 +1,51 @@
+package db
+
+import "context"
+
+// CompleteMFALoginTxParams contains the input parameters of the complete MFA login transaction
+type CompleteMFALoginTxParams struct {
+	ChallengeID int64 `json:"challenge_id"`
+	UserID      int32 `json:"user_id"`
+	// HashedRecoveryCode is empty when the user answered the challenge with a TOTP code
+	HashedRecoveryCode string `json:"hashed_recovery_code"`
+	// TOTPStep is the time step of the TOTP code answering the challenge
+	TOTPStep int64 `json:"totp_step"`
+}
+
+// CompleteMFALoginTxResult is the result of the complete MFA login transaction
+type CompleteMFALoginTxResult struct {
+	Challenge    MfaChallenge    `json:"challenge"`
+	RecoveryCode MfaRecoveryCode `json:"recovery_code"`
+}
+
+// CompleteMFALoginTx consumes an MFA challenge together with the recovery code or TOTP code used to answer it.
+// It returns sql.ErrNoRows if the challenge or the recovery code was already used, or if a TOTP code
+// of the same or a later time step was accepted before.
+func (store *SQLStore) CompleteMFALoginTx(ctx context.Context, arg CompleteMFALoginTxParams) (CompleteMFALoginTxResult, error) {
+	var result CompleteMFALoginTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.Challenge, err = q.UseMFAChallenge(ctx, arg.ChallengeID)
+		if err != nil {
+			return err
+		}
+
+		if arg.HashedRecoveryCode == "" {
+			_, err = q.UseUserTOTPStep(ctx, UseUserTOTPStepParams{
+				ID:   arg.UserID,
+				Step: arg.TOTPStep,
+			})
+			return err
+		}
+
+		result.RecoveryCode, err = q.UseMFARecoveryCode(ctx, UseMFARecoveryCodeParams{
+			UserID:     arg.UserID,
+			HashedCode: arg.HashedRecoveryCode,
+		})
+		return err
+	})
+
+	return result, err
+}
@@ -0,0 +1,119 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrEmptyCart is returned when checking out a cart without items
+	ErrEmptyCart = errors.New("cart is empty")
+	// ErrMixedCurrencies is returned when checking out a cart whose items are priced in different currencies
+	ErrMixedCurrencies = errors.New("cart items are priced in different currencies")
+	// ErrPriceChanged is returned when a license in the cart costs something else than when it was added
+	ErrPriceChanged = errors.New("the price of a license changed since it was added to the cart")
+	// ErrLicenseSold is returned when the cart holds an exclusive license somebody else already bought
+	ErrLicenseSold = errors.New("the exclusive license was already sold")
+	// ErrLicenseReserved is returned when the cart holds an exclusive license another order is waiting to pay for
+	ErrLicenseReserved = errors.New("the exclusive license is reserved by another order")
+)
+
+// CreateOrderTxParams contains the input parameters of the create order transaction
+type CreateOrderTxParams struct {
+	BuyerID int32     `json:"buyer_id"`
+	CartID  uuid.UUID `json:"cart_id"`
+}
+
+// CreateOrderTxResult is the result of the create order transaction
+type CreateOrderTxResult struct {
+	Order Order       `json:"order"`
+	Items []OrderItem `json:"items"`
+}
+
+// CreateOrderTx turns the items of a cart into a pending order. The licenses are locked and read again,
+// the order fails with ErrPriceChanged if one costs something else than the buyer saw in the cart
+// and with ErrLicenseSold or ErrLicenseReserved if an exclusive one was sold or ordered in the meantime.
+// Exclusive licenses are reserved for the new order, so only one order at a time can pay for them.
+// The cart is left as it is, the items are only removed once the order is paid.
+func (store *SQLStore) CreateOrderTx(ctx context.Context, arg CreateOrderTxParams) (CreateOrderTxResult, error) {
+	var result CreateOrderTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		cartItems, err := q.ListCartItems(ctx, arg.CartID)
+		if err != nil {
+			return err
+		}
+		if len(cartItems) == 0 {
+			return ErrEmptyCart
+		}
+
+		var total int64
+		var exclusives []int64
+		currency := cartItems[0].Currency
+		for _, item := range cartItems {
+			// Locked until the order is created, a concurrent change waits for it
+			license, err := q.GetLicenseForUpdate(ctx, item.LicenseID)
+			if err != nil {
+				return err
+			}
+			if license.SoldAt.Valid {
+				return ErrLicenseSold
+			}
+			if license.ReservedOrderID.Valid {
+				return ErrLicenseReserved
+			}
+			if license.Price != item.Price || license.Currency != item.Currency {
+				return ErrPriceChanged
+			}
+			if item.Currency != currency {
+				return ErrMixedCurrencies
+			}
+			if license.Tier == util.ExclusiveLicense {
+				exclusives = append(exclusives, license.ID)
+			}
+			total += item.Price
+		}
+
+		result.Order, err = q.CreateOrder(ctx, CreateOrderParams{
+			BuyerID:  arg.BuyerID,
+			Total:    total,
+			Currency: currency,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Items = make([]OrderItem, len(cartItems))
+		for i, item := range cartItems {
+			result.Items[i], err = q.CreateOrderItem(ctx, CreateOrderItemParams{
+				OrderID:   result.Order.ID,
+				BeatID:    sql.NullInt32{Int32: item.BeatID, Valid: true},
+				LicenseID: sql.NullInt64{Int64: item.LicenseID, Valid: true},
+				BeatTitle: item.BeatTitle,
+				Tier:      item.Tier,
+				Price:     item.Price,
+				Currency:  item.Currency,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, licenseID := range exclusives {
+			err = q.ReserveLicense(ctx, ReserveLicenseParams{
+				OrderID: result.Order.ID,
+				ID:      licenseID,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	return result, err
+}
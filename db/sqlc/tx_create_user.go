@@ -0,0 +1,43 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// CreateUserTxParams contains the input parameters of the create user transaction
+type CreateUserTxParams struct {
+	CreateUserParams
+	VerificationHashedToken string    `json:"verification_hashed_token"`
+	VerificationExpiresAt   time.Time `json:"verification_expires_at"`
+}
+
+// CreateUserTxResult is the result of the create user transaction
+type CreateUserTxResult struct {
+	User              User              `json:"user"`
+	EmailVerification EmailVerification `json:"email_verification"`
+}
+
+// CreateUserTx creates a new user together with the pending verification of their email address
+func (store *SQLStore) CreateUserTx(ctx context.Context, arg CreateUserTxParams) (CreateUserTxResult, error) {
+	var result CreateUserTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.User, err = q.CreateUser(ctx, arg.CreateUserParams)
+		if err != nil {
+			return err
+		}
+
+		result.EmailVerification, err = q.CreateEmailVerification(ctx, CreateEmailVerificationParams{
+			UserID:      result.User.ID,
+			Email:       result.User.Email,
+			HashedToken: arg.VerificationHashedToken,
+			ExpiresAt:   arg.VerificationExpiresAt,
+		})
+		return err
+	})
+
+	return result, err
+}
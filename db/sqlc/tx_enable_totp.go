@@ -0,0 +1,49 @@
+package db
+
+import "context"
+
+// EnableTOTPTxParams contains the input parameters of the enable TOTP transaction
+type EnableTOTPTxParams struct {
+	UserID              int32    `json:"user_id"`
+	HashedRecoveryCodes []string `json:"hashed_recovery_codes"`
+}
+
+// EnableTOTPTxResult is the result of the enable TOTP transaction
+type EnableTOTPTxResult struct {
+	User          User              `json:"user"`
+	RecoveryCodes []MfaRecoveryCode `json:"recovery_codes"`
+}
+
+// EnableTOTPTx turns on the second factor of a user and replaces their recovery codes.
+// It returns sql.ErrNoRows if the user has no pending secret or already enabled TOTP.
+func (store *SQLStore) EnableTOTPTx(ctx context.Context, arg EnableTOTPTxParams) (EnableTOTPTxResult, error) {
+	var result EnableTOTPTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.User, err = q.EnableUserTOTP(ctx, arg.UserID)
+		if err != nil {
+			return err
+		}
+
+		err = q.DeleteMFARecoveryCodesByUser(ctx, arg.UserID)
+		if err != nil {
+			return err
+		}
+
+		result.RecoveryCodes = make([]MfaRecoveryCode, len(arg.HashedRecoveryCodes))
+		for i, hashedCode := range arg.HashedRecoveryCodes {
+			result.RecoveryCodes[i], err = q.CreateMFARecoveryCode(ctx, CreateMFARecoveryCodeParams{
+				UserID:     arg.UserID,
+				HashedCode: hashedCode,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	return result, err
+}
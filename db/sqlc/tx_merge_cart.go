@@ -0,0 +1,62 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+// MergeCartTxParams contains the input parameters of the merge cart transaction
+type MergeCartTxParams struct {
+	AnonymousCartID uuid.UUID `json:"anonymous_cart_id"`
+	UserID          int32     `json:"user_id"`
+}
+
+// MergeCartTxResult is the result of the merge cart transaction
+type MergeCartTxResult struct {
+	// Cart is the cart of the user, it is left empty when there was nothing to merge
+	Cart  Cart  `json:"cart"`
+	Moved int64 `json:"moved"`
+}
+
+// MergeCartTx moves the items of the anonymous cart a client filled before logging in into the cart of the user
+// and deletes the anonymous cart. Items replace those for the same beat in the user's cart, the anonymous cart
+// being the more recent choice. Unknown carts and carts of users are left alone.
+func (store *SQLStore) MergeCartTx(ctx context.Context, arg MergeCartTxParams) (MergeCartTxResult, error) {
+	var result MergeCartTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		anonymous, err := q.GetCart(ctx, arg.AnonymousCartID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil
+			}
+			return err
+		}
+		if anonymous.UserID.Valid {
+			return nil
+		}
+
+		result.Cart, err = q.UpsertUserCart(ctx, UpsertUserCartParams{
+			ID:     uuid.New(),
+			UserID: sql.NullInt32{Int32: arg.UserID, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Moved, err = q.MoveCartItems(ctx, MoveCartItemsParams{
+			ToCartID:   result.Cart.ID,
+			FromCartID: anonymous.ID,
+			UserID:     arg.UserID,
+		})
+		if err != nil {
+			return err
+		}
+
+		return q.DeleteCart(ctx, anonymous.ID)
+	})
+
+	return result, err
+}
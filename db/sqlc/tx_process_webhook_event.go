@@ -0,0 +1,81 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/danglebary/beatstore-backend-go/util"
+)
+
+// ProcessWebhookEventTxParams contains the input parameters of the process webhook event transaction
+type ProcessWebhookEventTxParams struct {
+	EventID  string `json:"event_id"`
+	IntentID string `json:"intent_id"`
+	// OrderStatus is the status the event moves the order of the intent to,
+	// empty for events which don't settle orders
+	OrderStatus   string `json:"order_status"`
+	FailureReason string `json:"failure_reason"`
+}
+
+// ProcessWebhookEventTxResult is the result of the process webhook event transaction
+type ProcessWebhookEventTxResult struct {
+	Event WebhookEvent `json:"event"`
+	// Order is the order of the intent, empty for ignored events
+	Order Order `json:"order"`
+	// Duplicate reports an event which was processed before, nothing was changed
+	Duplicate bool `json:"duplicate"`
+}
+
+// ProcessWebhookEventTx applies a recorded webhook event to the order of its payment intent and marks the
+// event processed in the same transaction. The event is locked, concurrent deliveries of the same event
+// wait for each other and only the first one moves the order. Orders which are in the status already,
+// like orders marked paid at checkout, are left as they are. On error nothing is changed, recording the
+// failure is left to the caller.
+func (store *SQLStore) ProcessWebhookEventTx(ctx context.Context, arg ProcessWebhookEventTxParams) (ProcessWebhookEventTxResult, error) {
+	var result ProcessWebhookEventTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		event, err := q.GetWebhookEventForUpdate(ctx, arg.EventID)
+		if err != nil {
+			return err
+		}
+		if event.Status == util.WebhookEventProcessed || event.Status == util.WebhookEventIgnored {
+			result.Event = event
+			result.Duplicate = true
+			return nil
+		}
+
+		if arg.OrderStatus == "" {
+			result.Event, err = q.MarkWebhookEventProcessed(ctx, MarkWebhookEventProcessedParams{
+				ID:     event.ID,
+				Status: util.WebhookEventIgnored,
+			})
+			return err
+		}
+
+		result.Order, err = q.GetOrderByPaymentIntentForUpdate(ctx, sql.NullString{String: arg.IntentID, Valid: true})
+		if err != nil {
+			return err
+		}
+		if result.Order.Status != arg.OrderStatus {
+			transition, err := transitionOrder(ctx, q, result.Order, TransitionOrderTxParams{
+				OrderID:       result.Order.ID,
+				Status:        arg.OrderStatus,
+				FailureReason: arg.FailureReason,
+			})
+			if err != nil {
+				return err
+			}
+			result.Order = transition.Order
+		}
+
+		result.Event, err = q.MarkWebhookEventProcessed(ctx, MarkWebhookEventProcessedParams{
+			ID:      event.ID,
+			Status:  util.WebhookEventProcessed,
+			OrderID: sql.NullInt64{Int64: result.Order.ID, Valid: true},
+		})
+		return err
+	})
+
+	return result, err
+}
@@ -0,0 +1,66 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+// RecordStreamTxParams contains the input parameters of the record stream transaction
+type RecordStreamTxParams struct {
+	BeatID        int32     `json:"beat_id"`
+	SessionID     uuid.UUID `json:"session_id"`
+	BytesStreamed int64     `json:"bytes_streamed"`
+	// PlayThreshold is the number of bytes a session has to stream before it counts as a play
+	PlayThreshold int64 `json:"play_threshold"`
+}
+
+// RecordStreamTxResult is the result of the record stream transaction
+type RecordStreamTxResult struct {
+	StreamSession StreamSession `json:"stream_session"`
+	// Played is true if this transaction counted the play of the session
+	Played bool `json:"played"`
+}
+
+// RecordStreamTx adds the bytes of a stream response to its listening session.
+// Once the session reaches the play threshold the play count of the beat is incremented,
+// this happens only once per session no matter how many range requests follow.
+func (store *SQLStore) RecordStreamTx(ctx context.Context, arg RecordStreamTxParams) (RecordStreamTxResult, error) {
+	var result RecordStreamTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.StreamSession, err = q.AddStreamedBytes(ctx, AddStreamedBytesParams{
+			BeatID:        arg.BeatID,
+			SessionID:     arg.SessionID,
+			BytesStreamed: arg.BytesStreamed,
+		})
+		if err != nil {
+			return err
+		}
+		if result.StreamSession.PlayedAt.Valid || result.StreamSession.BytesStreamed < arg.PlayThreshold {
+			return nil
+		}
+
+		result.StreamSession, err = q.MarkStreamSessionPlayed(ctx, MarkStreamSessionPlayedParams{
+			BeatID:    arg.BeatID,
+			SessionID: arg.SessionID,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			// A concurrent request of the same session counted the play
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = q.IncrementBeatPlayCount(ctx, arg.BeatID)
+		result.Played = err == nil
+		return err
+	})
+
+	return result, err
+}
@@ -0,0 +1,44 @@
+package db
+
+import "context"
+
+// ResetPasswordTxParams contains the input parameters of the reset password transaction
+type ResetPasswordTxParams struct {
+	PasswordResetID int64  `json:"password_reset_id"`
+	UserID          int32  `json:"user_id"`
+	HashedPassword  string `json:"hashed_password"`
+}
+
+// ResetPasswordTxResult is the result of the reset password transaction
+type ResetPasswordTxResult struct {
+	User          User          `json:"user"`
+	PasswordReset PasswordReset `json:"password_reset"`
+}
+
+// ResetPasswordTx consumes a password reset, stores the new password
+// and blocks every session of the user.
+// It returns sql.ErrNoRows if the password reset was already used.
+func (store *SQLStore) ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (ResetPasswordTxResult, error) {
+	var result ResetPasswordTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.PasswordReset, err = q.UsePasswordReset(ctx, arg.PasswordResetID)
+		if err != nil {
+			return err
+		}
+
+		result.User, err = q.ResetUserPassword(ctx, ResetUserPasswordParams{
+			ID:             arg.UserID,
+			HashedPassword: arg.HashedPassword,
+		})
+		if err != nil {
+			return err
+		}
+
+		return q.BlockUserSessions(ctx, arg.UserID)
+	})
+
+	return result, err
+}
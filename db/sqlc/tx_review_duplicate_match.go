@@ -0,0 +1,58 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// ReviewDuplicateMatchTxParams contains the input parameters of the review duplicate match transaction
+type ReviewDuplicateMatchTxParams struct {
+	ActorID int32  `json:"actor_id"`
+	MatchID int64  `json:"match_id"`
+	Status  string `json:"status"`
+}
+
+// ReviewDuplicateMatchTxResult is the result of the review duplicate match transaction
+type ReviewDuplicateMatchTxResult struct {
+	Match    DuplicateMatch `json:"match"`
+	AuditLog AuditLog       `json:"audit_log"`
+}
+
+// ReviewDuplicateMatchTx records the decision of an admin on a match in the moderation queue
+// and logs it in the audit log against the creator of the matching beat, both writes succeed or fail together
+func (store *SQLStore) ReviewDuplicateMatchTx(ctx context.Context, arg ReviewDuplicateMatchTxParams) (ReviewDuplicateMatchTxResult, error) {
+	var result ReviewDuplicateMatchTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		match, err := q.GetDuplicateMatchForUpdate(ctx, arg.MatchID)
+		if err != nil {
+			return err
+		}
+
+		beat, err := q.GetBeatById(ctx, match.BeatID)
+		if err != nil {
+			return err
+		}
+
+		result.Match, err = q.ReviewDuplicateMatch(ctx, ReviewDuplicateMatchParams{
+			ID:         arg.MatchID,
+			Status:     arg.Status,
+			ReviewedBy: sql.NullInt32{Int32: arg.ActorID, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		result.AuditLog, err = q.CreateAuditLog(ctx, CreateAuditLogParams{
+			ActorID:      arg.ActorID,
+			Action:       "review_duplicate_match",
+			TargetUserID: beat.CreatorID,
+			Details: fmt.Sprintf("beat %d matching beat %d: %s -> %s",
+				match.BeatID, match.MatchedBeatID, match.Status, arg.Status),
+		})
+		return err
+	})
+
+	return result, err
+}
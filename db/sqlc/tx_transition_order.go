@@ -0,0 +1,111 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/danglebary/beatstore-backend-go/util"
+)
+
+// ErrInvalidOrderTransition is returned when an order can't move from its current status to the requested one
+var ErrInvalidOrderTransition = errors.New("order can't move to this status")
+
+// TransitionOrderTxParams contains the input parameters of the transition order transaction
+type TransitionOrderTxParams struct {
+	OrderID int64  `json:"order_id"`
+	Status  string `json:"status"`
+	// FailureReason is stored when the order fails
+	FailureReason string `json:"failure_reason"`
+	// ActorID is the admin moving the order, zero for transitions caused by the payment provider
+	ActorID int32 `json:"actor_id"`
+}
+
+// TransitionOrderTxResult is the result of the transition order transaction
+type TransitionOrderTxResult struct {
+	Order Order `json:"order"`
+	// AuditLog is only written for transitions made by an admin
+	AuditLog AuditLog `json:"audit_log"`
+}
+
+// TransitionOrderTx moves an order to a new status if util.CanTransitionOrder allows it,
+// the order is locked so concurrent transitions see each other's result. Once the order is paid
+// the bought licenses are taken out of the cart of the buyer and the exclusive ones are sold, it fails
+// with ErrLicenseSold if one of them went to another order. A failed order releases its reserved licenses.
+// Transitions made by an admin are recorded in the audit log against the buyer.
+func (store *SQLStore) TransitionOrderTx(ctx context.Context, arg TransitionOrderTxParams) (TransitionOrderTxResult, error) {
+	var result TransitionOrderTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		order, err := q.GetOrderForUpdate(ctx, arg.OrderID)
+		if err != nil {
+			return err
+		}
+
+		result, err = transitionOrder(ctx, q, order, arg)
+		return err
+	})
+
+	return result, err
+}
+
+// transitionOrder moves order, which must be locked by the caller, as described by TransitionOrderTx
+func transitionOrder(ctx context.Context, q *Queries, order Order, arg TransitionOrderTxParams) (TransitionOrderTxResult, error) {
+	var result TransitionOrderTxResult
+
+	if !util.CanTransitionOrder(order.Status, arg.Status) {
+		return result, ErrInvalidOrderTransition
+	}
+
+	failureReason := ""
+	if arg.Status == util.OrderFailed {
+		failureReason = arg.FailureReason
+	}
+	var err error
+	result.Order, err = q.UpdateOrderStatus(ctx, UpdateOrderStatusParams{
+		ID:            order.ID,
+		Status:        arg.Status,
+		FailureReason: failureReason,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	if arg.Status == util.OrderPaid {
+		_, err = q.DeleteOrderedCartItems(ctx, DeleteOrderedCartItemsParams{
+			BuyerID: order.BuyerID,
+			OrderID: order.ID,
+		})
+		if err != nil {
+			return result, err
+		}
+
+		exclusives, err := q.CountOrderedExclusives(ctx, order.ID)
+		if err != nil {
+			return result, err
+		}
+		sold, err := q.MarkOrderedExclusivesSold(ctx, order.ID)
+		if err != nil {
+			return result, err
+		}
+		if sold != exclusives {
+			return result, ErrLicenseSold
+		}
+	}
+
+	if arg.Status == util.OrderFailed {
+		if err := q.ReleaseOrderedLicenses(ctx, order.ID); err != nil {
+			return result, err
+		}
+	}
+
+	if arg.ActorID != 0 {
+		result.AuditLog, err = q.CreateAuditLog(ctx, CreateAuditLogParams{
+			ActorID:      arg.ActorID,
+			Action:       "transition_order",
+			TargetUserID: order.BuyerID,
+			Details:      fmt.Sprintf("order %d: %s -> %s", order.ID, order.Status, arg.Status),
+		})
+	}
+	return result, err
+}
@@ -0,0 +1,97 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/danglebary/beatstore-backend-go/util"
+)
+
+// UpdateBeatAudioTxParams contains the input parameters of the update beat audio transaction
+type UpdateBeatAudioTxParams struct {
+	BeatID int32 `json:"beat_id"`
+	// File describes the new audio, its beat id is ignored
+	File UpsertBeatFileParams `json:"file"`
+}
+
+// UpdateBeatAudioTxResult is the result of the update beat audio transaction
+type UpdateBeatAudioTxResult struct {
+	Beat Beat     `json:"beat"`
+	File BeatFile `json:"file"`
+	// Fingerprint holds the content hash of the new audio, its fingerprint is computed later.
+	// It is empty if the new audio didn't become the audio of the beat.
+	Fingerprint AudioFingerprint `json:"fingerprint"`
+	// RemovedFiles are the files replaced by the new audio, their objects are left to the caller
+	RemovedFiles []BeatFile `json:"removed_files"`
+}
+
+// UpdateBeatAudioTx attaches newly uploaded audio to the beat. A beat can come with a master WAV and an MP3,
+// the new audio only replaces the file of its own type. A WAV master becomes the audio of the beat and
+// replaces the tagged preview generated from the previous one. An MP3 only becomes the audio of beats
+// without a WAV master, next to one it is just attached. The content hash of the audio of the beat
+// is recorded for detecting re-uploads of it.
+func (store *SQLStore) UpdateBeatAudioTx(ctx context.Context, arg UpdateBeatAudioTxParams) (UpdateBeatAudioTxResult, error) {
+	var result UpdateBeatAudioTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		// Locks the beat, concurrent uploads to it wait for each other
+		result.Beat, err = q.GetBeatByIdForUpdate(ctx, arg.BeatID)
+		if err != nil {
+			return err
+		}
+
+		replacedTypes := []string{arg.File.FileType}
+		becomesAudio := true
+		if arg.File.FileType == util.MasterWAVFile {
+			replacedTypes = append(replacedTypes, util.TaggedPreviewFile)
+		} else {
+			_, err = q.GetBeatFile(ctx, GetBeatFileParams{
+				BeatID:   arg.BeatID,
+				FileType: util.MasterWAVFile,
+			})
+			if err != nil && err != sql.ErrNoRows {
+				return err
+			}
+			becomesAudio = err == sql.ErrNoRows
+		}
+
+		result.RemovedFiles, err = q.DeleteBeatFilesByType(ctx, DeleteBeatFilesByTypeParams{
+			BeatID:    arg.BeatID,
+			FileTypes: replacedTypes,
+		})
+		if err != nil {
+			return err
+		}
+
+		file := arg.File
+		file.BeatID = arg.BeatID
+		result.File, err = q.UpsertBeatFile(ctx, file)
+		if err != nil {
+			return err
+		}
+
+		if !becomesAudio {
+			return nil
+		}
+
+		// The preview of the new audio is generated later on, MP3 masters get none
+		result.Beat, err = q.UpdateBeatS3Key(ctx, UpdateBeatS3KeyParams{
+			ID:    arg.BeatID,
+			S3Key: file.S3Key,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Fingerprint, err = q.UpsertAudioFingerprint(ctx, UpsertAudioFingerprintParams{
+			BeatID:      arg.BeatID,
+			S3Key:       file.S3Key,
+			ContentHash: file.Checksum,
+		})
+		return err
+	})
+
+	return result, err
+}
@@ -0,0 +1,56 @@
+package db
+
+import (
+	"context"
+
+	"github.com/danglebary/beatstore-backend-go/util"
+)
+
+// UpdateBeatPreviewTxParams contains the input parameters of the update beat preview transaction
+type UpdateBeatPreviewTxParams struct {
+	BeatID int32 `json:"beat_id"`
+	// S3Key is the key of the audio the preview was generated from
+	S3Key       string `json:"s3_key"`
+	PreviewKey  string `json:"preview_key"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	Checksum    string `json:"checksum"`
+}
+
+// UpdateBeatPreviewTxResult is the result of the update beat preview transaction
+type UpdateBeatPreviewTxResult struct {
+	Beat Beat     `json:"beat"`
+	File BeatFile `json:"file"`
+}
+
+// UpdateBeatPreviewTx points the beat at a generated preview and attaches it as the tagged preview file.
+// Returns sql.ErrNoRows if the audio the preview was generated from has been replaced since.
+func (store *SQLStore) UpdateBeatPreviewTx(ctx context.Context, arg UpdateBeatPreviewTxParams) (UpdateBeatPreviewTxResult, error) {
+	var result UpdateBeatPreviewTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		// Locks the beat, new audio can't be uploaded until the file is attached
+		result.Beat, err = q.UpdateBeatPreviewKey(ctx, UpdateBeatPreviewKeyParams{
+			ID:         arg.BeatID,
+			S3Key:      arg.S3Key,
+			PreviewKey: arg.PreviewKey,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.File, err = q.UpsertBeatFile(ctx, UpsertBeatFileParams{
+			BeatID:      arg.BeatID,
+			FileType:    util.TaggedPreviewFile,
+			S3Key:       arg.PreviewKey,
+			ContentType: arg.ContentType,
+			Size:        arg.Size,
+			Checksum:    arg.Checksum,
+		})
+		return err
+	})
+
+	return result, err
+}
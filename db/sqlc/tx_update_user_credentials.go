@@ -0,0 +1,32 @@
+package db
+
+import "context"
+
+// UpdateUserCredentialsTxParams contains the input parameters of the update user credentials transaction
+type UpdateUserCredentialsTxParams struct {
+	UpdateUserCredentialsParams
+}
+
+// UpdateUserCredentialsTxResult is the result of the update user credentials transaction
+type UpdateUserCredentialsTxResult struct {
+	User User `json:"user"`
+}
+
+// UpdateUserCredentialsTx stores the new email address and password of a user
+// and blocks every session of the user, which were opened with the old credentials.
+func (store *SQLStore) UpdateUserCredentialsTx(ctx context.Context, arg UpdateUserCredentialsTxParams) (UpdateUserCredentialsTxResult, error) {
+	var result UpdateUserCredentialsTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.User, err = q.UpdateUserCredentials(ctx, arg.UpdateUserCredentialsParams)
+		if err != nil {
+			return err
+		}
+
+		return q.BlockUserSessions(ctx, arg.ID)
+	})
+
+	return result, err
+}
@@ -0,0 +1,50 @@
+package db
+
+import (
+	"context"
+	"fmt"
+)
+
+// UpdateUserRoleTxParams contains the input parameters of the update user role transaction
+type UpdateUserRoleTxParams struct {
+	ActorID int32  `json:"actor_id"`
+	UserID  int32  `json:"user_id"`
+	Role    string `json:"role"`
+}
+
+// UpdateUserRoleTxResult is the result of the update user role transaction
+type UpdateUserRoleTxResult struct {
+	User     User     `json:"user"`
+	AuditLog AuditLog `json:"audit_log"`
+}
+
+// UpdateUserRoleTx changes the role of a user and records who changed it
+// in the audit log, both writes succeed or fail together
+func (store *SQLStore) UpdateUserRoleTx(ctx context.Context, arg UpdateUserRoleTxParams) (UpdateUserRoleTxResult, error) {
+	var result UpdateUserRoleTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		user, err := q.GetUserByIdForUpdate(ctx, arg.UserID)
+		if err != nil {
+			return err
+		}
+
+		result.User, err = q.UpdateUserRole(ctx, UpdateUserRoleParams{
+			ID:   arg.UserID,
+			Role: arg.Role,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.AuditLog, err = q.CreateAuditLog(ctx, CreateAuditLogParams{
+			ActorID:      arg.ActorID,
+			Action:       "update_user_role",
+			TargetUserID: arg.UserID,
+			Details:      fmt.Sprintf("%s -> %s", user.Role, arg.Role),
+		})
+		return err
+	})
+
+	return result, err
+}
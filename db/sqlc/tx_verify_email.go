@@ -0,0 +1,40 @@
+package db
+
+import "context"
+
+// VerifyEmailTxParams contains the input parameters of the verify email transaction
+type VerifyEmailTxParams struct {
+	VerificationID int64  `json:"verification_id"`
+	UserID         int32  `json:"user_id"`
+	Email          string `json:"email"`
+}
+
+// VerifyEmailTxResult is the result of the verify email transaction
+type VerifyEmailTxResult struct {
+	User              User              `json:"user"`
+	EmailVerification EmailVerification `json:"email_verification"`
+}
+
+// VerifyEmailTx consumes an email verification and marks the user's email as verified.
+// It returns sql.ErrNoRows if the verification was already used
+// or if the user changed their email address since it was sent.
+func (store *SQLStore) VerifyEmailTx(ctx context.Context, arg VerifyEmailTxParams) (VerifyEmailTxResult, error) {
+	var result VerifyEmailTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.EmailVerification, err = q.UseEmailVerification(ctx, arg.VerificationID)
+		if err != nil {
+			return err
+		}
+
+		result.User, err = q.VerifyUserEmail(ctx, VerifyUserEmailParams{
+			ID:    arg.UserID,
+			Email: arg.Email,
+		})
+		return err
+	})
+
+	return result, err
+}
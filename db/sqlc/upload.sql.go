@@ -0,0 +1,224 @@
+// Code generated by sqlc. DO NOT EDIT.
+// source: upload.sql
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const advanceUpload = `-- name: AdvanceUpload :one
+UPDATE uploads
+SET received = received + $1::bigint,
+    updated_at = now()
+WHERE id = $2
+    AND received = $3::bigint
+    AND received + $1::bigint <= size
+    AND NOT finalizing
+RETURNING id, user_id, beat_id, file_type, filename, size, received, checksum, finalizing, created_at, updated_at
+`
+
+type AdvanceUploadParams struct {
+	Length      int64     `json:"length"`
+	ID          uuid.UUID `json:"id"`
+	ChunkOffset int64     `json:"chunk_offset"`
+}
+
+// Counts a chunk received at chunk_offset. Returns sql.ErrNoRows if another chunk got there first,
+// the chunk overruns the announced size or the upload is being finalized.
+func (q *Queries) AdvanceUpload(ctx context.Context, arg AdvanceUploadParams) (Upload, error) {
+	row := q.db.QueryRowContext(ctx, advanceUpload, arg.Length, arg.ID, arg.ChunkOffset)
+	var i Upload
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.BeatID,
+		&i.FileType,
+		&i.Filename,
+		&i.Size,
+		&i.Received,
+		&i.Checksum,
+		&i.Finalizing,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createUpload = `-- name: CreateUpload :one
+INSERT INTO uploads (
+    id,
+    user_id,
+    beat_id,
+    file_type,
+    filename,
+    size,
+    checksum
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+RETURNING id, user_id, beat_id, file_type, filename, size, received, checksum, finalizing, created_at, updated_at
+`
+
+type CreateUploadParams struct {
+	ID       uuid.UUID `json:"id"`
+	UserID   int32     `json:"user_id"`
+	BeatID   int32     `json:"beat_id"`
+	FileType string    `json:"file_type"`
+	Filename string    `json:"filename"`
+	Size     int64     `json:"size"`
+	Checksum string    `json:"checksum"`
+}
+
+func (q *Queries) CreateUpload(ctx context.Context, arg CreateUploadParams) (Upload, error) {
+	row := q.db.QueryRowContext(ctx, createUpload,
+		arg.ID,
+		arg.UserID,
+		arg.BeatID,
+		arg.FileType,
+		arg.Filename,
+		arg.Size,
+		arg.Checksum,
+	)
+	var i Upload
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.BeatID,
+		&i.FileType,
+		&i.Filename,
+		&i.Size,
+		&i.Received,
+		&i.Checksum,
+		&i.Finalizing,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const deleteUpload = `-- name: DeleteUpload :exec
+DELETE FROM uploads
+WHERE id = $1
+`
+
+func (q *Queries) DeleteUpload(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteUpload, id)
+	return err
+}
+
+const getUpload = `-- name: GetUpload :one
+SELECT id, user_id, beat_id, file_type, filename, size, received, checksum, finalizing, created_at, updated_at FROM uploads
+WHERE id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetUpload(ctx context.Context, id uuid.UUID) (Upload, error) {
+	row := q.db.QueryRowContext(ctx, getUpload, id)
+	var i Upload
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.BeatID,
+		&i.FileType,
+		&i.Filename,
+		&i.Size,
+		&i.Received,
+		&i.Checksum,
+		&i.Finalizing,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const listStaleUploads = `-- name: ListStaleUploads :many
+SELECT id, user_id, beat_id, file_type, filename, size, received, checksum, finalizing, created_at, updated_at FROM uploads
+WHERE updated_at < $1
+ORDER BY updated_at
+LIMIT $2
+`
+
+type ListStaleUploadsParams struct {
+	UpdatedAt time.Time `json:"updated_at"`
+	Limit     int32     `json:"limit"`
+}
+
+func (q *Queries) ListStaleUploads(ctx context.Context, arg ListStaleUploadsParams) ([]Upload, error) {
+	rows, err := q.db.QueryContext(ctx, listStaleUploads, arg.UpdatedAt, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Upload{}
+	for rows.Next() {
+		var i Upload
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.BeatID,
+			&i.FileType,
+			&i.Filename,
+			&i.Size,
+			&i.Received,
+			&i.Checksum,
+			&i.Finalizing,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const startFinalizingUpload = `-- name: StartFinalizingUpload :one
+UPDATE uploads
+SET finalizing = true,
+    updated_at = now()
+WHERE id = $1 AND received = size AND NOT finalizing
+RETURNING id, user_id, beat_id, file_type, filename, size, received, checksum, finalizing, created_at, updated_at
+`
+
+// Returns sql.ErrNoRows unless the upload is complete and not already being finalized.
+func (q *Queries) StartFinalizingUpload(ctx context.Context, id uuid.UUID) (Upload, error) {
+	row := q.db.QueryRowContext(ctx, startFinalizingUpload, id)
+	var i Upload
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.BeatID,
+		&i.FileType,
+		&i.Filename,
+		&i.Size,
+		&i.Received,
+		&i.Checksum,
+		&i.Finalizing,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const stopFinalizingUpload = `-- name: StopFinalizingUpload :exec
+UPDATE uploads
+SET finalizing = false,
+    updated_at = now()
+WHERE id = $1
+`
+
+// Lets the client retry a finalization which failed.
+func (q *Queries) StopFinalizingUpload(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, stopFinalizingUpload, id)
+	return err
+}
@@ -0,0 +1,168 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomUpload(t *testing.T, beat Beat) Upload {
+	arg := CreateUploadParams{
+		ID:       uuid.New(),
+		UserID:   beat.CreatorID,
+		BeatID:   beat.ID,
+		FileType: util.StemsFile,
+		Filename: util.RandomString(8) + ".zip",
+		Size:     util.RandomInt(1<<20, 1<<30),
+		Checksum: util.RandomString(64),
+	}
+
+	upload, err := testQueries.CreateUpload(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.ID, upload.ID)
+	require.Equal(t, arg.UserID, upload.UserID)
+	require.Equal(t, arg.BeatID, upload.BeatID)
+	require.Equal(t, arg.FileType, upload.FileType)
+	require.Equal(t, arg.Filename, upload.Filename)
+	require.Equal(t, arg.Size, upload.Size)
+	require.Equal(t, arg.Checksum, upload.Checksum)
+	require.Zero(t, upload.Received)
+	require.False(t, upload.Finalizing)
+	require.NotZero(t, upload.CreatedAt)
+	require.NotZero(t, upload.UpdatedAt)
+
+	return upload
+}
+
+func TestCreateUpload(t *testing.T) {
+	beat := createRandomBeat(t)
+	upload1 := createRandomUpload(t, beat)
+
+	upload2, err := testQueries.GetUpload(context.Background(), upload1.ID)
+	require.NoError(t, err)
+	require.Equal(t, upload1.ID, upload2.ID)
+	require.Equal(t, upload1.Size, upload2.Size)
+	require.WithinDuration(t, upload1.CreatedAt, upload2.CreatedAt, time.Second)
+
+	deleteRandomBeat(t, beat.ID)
+	deleteRandomUser(t, beat.CreatorID)
+
+	// Uploads are deleted together with their beat
+	_, err = testQueries.GetUpload(context.Background(), upload1.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestAdvanceUpload(t *testing.T) {
+	beat := createRandomBeat(t)
+	upload := createRandomUpload(t, beat)
+
+	advanced, err := testQueries.AdvanceUpload(context.Background(), AdvanceUploadParams{
+		ID:          upload.ID,
+		ChunkOffset: 0,
+		Length:      1000,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1000), advanced.Received)
+
+	// A chunk for an offset which was already received is rejected
+	_, err = testQueries.AdvanceUpload(context.Background(), AdvanceUploadParams{
+		ID:          upload.ID,
+		ChunkOffset: 0,
+		Length:      1000,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// So is a chunk past the announced size
+	_, err = testQueries.AdvanceUpload(context.Background(), AdvanceUploadParams{
+		ID:          upload.ID,
+		ChunkOffset: 1000,
+		Length:      upload.Size,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	advanced, err = testQueries.AdvanceUpload(context.Background(), AdvanceUploadParams{
+		ID:          upload.ID,
+		ChunkOffset: 1000,
+		Length:      upload.Size - 1000,
+	})
+	require.NoError(t, err)
+	require.Equal(t, upload.Size, advanced.Received)
+
+	deleteRandomBeat(t, beat.ID)
+	deleteRandomUser(t, beat.CreatorID)
+}
+
+func TestFinalizeUpload(t *testing.T) {
+	beat := createRandomBeat(t)
+	upload := createRandomUpload(t, beat)
+
+	// Incomplete uploads can't be finalized
+	_, err := testQueries.StartFinalizingUpload(context.Background(), upload.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = testQueries.AdvanceUpload(context.Background(), AdvanceUploadParams{
+		ID:          upload.ID,
+		ChunkOffset: 0,
+		Length:      upload.Size,
+	})
+	require.NoError(t, err)
+
+	finalizing, err := testQueries.StartFinalizingUpload(context.Background(), upload.ID)
+	require.NoError(t, err)
+	require.True(t, finalizing.Finalizing)
+
+	// Only one request finalizes an upload
+	_, err = testQueries.StartFinalizingUpload(context.Background(), upload.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	err = testQueries.StopFinalizingUpload(context.Background(), upload.ID)
+	require.NoError(t, err)
+
+	_, err = testQueries.StartFinalizingUpload(context.Background(), upload.ID)
+	require.NoError(t, err)
+
+	err = testQueries.DeleteUpload(context.Background(), upload.ID)
+	require.NoError(t, err)
+
+	_, err = testQueries.GetUpload(context.Background(), upload.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	deleteRandomBeat(t, beat.ID)
+	deleteRandomUser(t, beat.CreatorID)
+}
+
+func TestListStaleUploads(t *testing.T) {
+	beat := createRandomBeat(t)
+	upload := createRandomUpload(t, beat)
+
+	uploads, err := testQueries.ListStaleUploads(context.Background(), ListStaleUploadsParams{
+		UpdatedAt: upload.UpdatedAt.Add(time.Second),
+		Limit:     1000,
+	})
+	require.NoError(t, err)
+	require.Contains(t, uploadIDs(uploads), upload.ID)
+
+	// Uploads updated since the cutoff are still in progress
+	uploads, err = testQueries.ListStaleUploads(context.Background(), ListStaleUploadsParams{
+		UpdatedAt: upload.UpdatedAt.Add(-time.Second),
+		Limit:     1000,
+	})
+	require.NoError(t, err)
+	require.NotContains(t, uploadIDs(uploads), upload.ID)
+
+	deleteRandomBeat(t, beat.ID)
+	deleteRandomUser(t, beat.CreatorID)
+}
+
+func uploadIDs(uploads []Upload) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(uploads))
+	for _, upload := range uploads {
+		ids = append(ids, upload.ID)
+	}
+	return ids
+}
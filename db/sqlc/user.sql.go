@@ -5,33 +5,47 @@ package db
 
 import (
 	"context"
+	"database/sql"
 )
 
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (
     username, 
-    password, 
-    email
+    hashed_password, 
+    email,
+    role
 ) VALUES (
-    $1, $2, $3
-) RETURNING id, username, password, email, created_at
+    $1, $2, $3, $4
+) RETURNING id, username, hashed_password, email, created_at, password_changed_at, role, verified_at, totp_secret, totp_enabled_at, totp_last_step
 `
 
 type CreateUserParams struct {
-	Username string `json:"username"`
-	Password string `json:"password"`
-	Email    string `json:"email"`
+	Username       string `json:"username"`
+	HashedPassword string `json:"hashed_password"`
+	Email          string `json:"email"`
+	Role           string `json:"role"`
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, createUser, arg.Username, arg.Password, arg.Email)
+	row := q.db.QueryRowContext(ctx, createUser,
+		arg.Username,
+		arg.HashedPassword,
+		arg.Email,
+		arg.Role,
+	)
 	var i User
 	err := row.Scan(
 		&i.ID,
 		&i.Username,
-		&i.Password,
+		&i.HashedPassword,
 		&i.Email,
 		&i.CreatedAt,
+		&i.PasswordChangedAt,
+		&i.Role,
+		&i.VerifiedAt,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
 	)
 	return i, err
 }
@@ -46,8 +60,59 @@ func (q *Queries) DeleteUser(ctx context.Context, id int32) error {
 	return err
 }
 
+const enableUserTOTP = `-- name: EnableUserTOTP :one
+UPDATE users
+SET totp_enabled_at = now()
+WHERE id = $1 AND totp_secret IS NOT NULL AND totp_enabled_at IS NULL
+RETURNING id, username, hashed_password, email, created_at, password_changed_at, role, verified_at, totp_secret, totp_enabled_at, totp_last_step
+`
+
+func (q *Queries) EnableUserTOTP(ctx context.Context, id int32) (User, error) {
+	row := q.db.QueryRowContext(ctx, enableUserTOTP, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.HashedPassword,
+		&i.Email,
+		&i.CreatedAt,
+		&i.PasswordChangedAt,
+		&i.Role,
+		&i.VerifiedAt,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const getUserByEmail = `-- name: GetUserByEmail :one
+SELECT id, username, hashed_password, email, created_at, password_changed_at, role, verified_at, totp_secret, totp_enabled_at, totp_last_step FROM users
+WHERE email = $1
+LIMIT 1
+`
+
+func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByEmail, email)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.HashedPassword,
+		&i.Email,
+		&i.CreatedAt,
+		&i.PasswordChangedAt,
+		&i.Role,
+		&i.VerifiedAt,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
 const getUserById = `-- name: GetUserById :one
-SELECT id, username, password, email, created_at FROM users
+SELECT id, username, hashed_password, email, created_at, password_changed_at, role, verified_at, totp_secret, totp_enabled_at, totp_last_step FROM users
 WHERE id = $1
 LIMIT 1
 `
@@ -58,15 +123,47 @@ func (q *Queries) GetUserById(ctx context.Context, id int32) (User, error) {
 	err := row.Scan(
 		&i.ID,
 		&i.Username,
-		&i.Password,
+		&i.HashedPassword,
+		&i.Email,
+		&i.CreatedAt,
+		&i.PasswordChangedAt,
+		&i.Role,
+		&i.VerifiedAt,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const getUserByIdForUpdate = `-- name: GetUserByIdForUpdate :one
+SELECT id, username, hashed_password, email, created_at, password_changed_at, role, verified_at, totp_secret, totp_enabled_at, totp_last_step FROM users
+WHERE id = $1
+LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetUserByIdForUpdate(ctx context.Context, id int32) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByIdForUpdate, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.HashedPassword,
 		&i.Email,
 		&i.CreatedAt,
+		&i.PasswordChangedAt,
+		&i.Role,
+		&i.VerifiedAt,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
 	)
 	return i, err
 }
 
 const getUserByUsername = `-- name: GetUserByUsername :one
-SELECT id, username, password, email, created_at FROM users
+SELECT id, username, hashed_password, email, created_at, password_changed_at, role, verified_at, totp_secret, totp_enabled_at, totp_last_step FROM users
 WHERE username = $1
 LIMIT 1
 `
@@ -77,15 +174,21 @@ func (q *Queries) GetUserByUsername(ctx context.Context, username string) (User,
 	err := row.Scan(
 		&i.ID,
 		&i.Username,
-		&i.Password,
+		&i.HashedPassword,
 		&i.Email,
 		&i.CreatedAt,
+		&i.PasswordChangedAt,
+		&i.Role,
+		&i.VerifiedAt,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
 	)
 	return i, err
 }
 
 const listUsers = `-- name: ListUsers :many
-SELECT id, username, password, email, created_at FROM users
+SELECT id, username, hashed_password, email, created_at, password_changed_at, role, verified_at, totp_secret, totp_enabled_at, totp_last_step FROM users
 ORDER BY id
 LIMIT $1
 OFFSET $2
@@ -108,9 +211,15 @@ func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, e
 		if err := rows.Scan(
 			&i.ID,
 			&i.Username,
-			&i.Password,
+			&i.HashedPassword,
 			&i.Email,
 			&i.CreatedAt,
+			&i.PasswordChangedAt,
+			&i.Role,
+			&i.VerifiedAt,
+			&i.TotpSecret,
+			&i.TotpEnabledAt,
+			&i.TotpLastStep,
 		); err != nil {
 			return nil, err
 		}
@@ -125,36 +234,245 @@ func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, e
 	return items, nil
 }
 
+const resetUserPassword = `-- name: ResetUserPassword :one
+UPDATE users
+SET hashed_password = $2,
+    password_changed_at = now()
+WHERE id = $1
+RETURNING id, username, hashed_password, email, created_at, password_changed_at, role, verified_at, totp_secret, totp_enabled_at, totp_last_step
+`
+
+type ResetUserPasswordParams struct {
+	ID             int32  `json:"id"`
+	HashedPassword string `json:"hashed_password"`
+}
+
+func (q *Queries) ResetUserPassword(ctx context.Context, arg ResetUserPasswordParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, resetUserPassword, arg.ID, arg.HashedPassword)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.HashedPassword,
+		&i.Email,
+		&i.CreatedAt,
+		&i.PasswordChangedAt,
+		&i.Role,
+		&i.VerifiedAt,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
 const updateUser = `-- name: UpdateUser :one
 UPDATE users
-SET username = $2,
-    password = $3,
-    email = $4
+SET username = $2
 WHERE id = $1
-RETURNING id, username, password, email, created_at
+RETURNING id, username, hashed_password, email, created_at, password_changed_at, role, verified_at, totp_secret, totp_enabled_at, totp_last_step
 `
 
 type UpdateUserParams struct {
 	ID       int32  `json:"id"`
 	Username string `json:"username"`
-	Password string `json:"password"`
-	Email    string `json:"email"`
 }
 
+// Changes the profile of a user, the credentials are changed through UpdateUserCredentials.
 func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, updateUser,
-		arg.ID,
-		arg.Username,
-		arg.Password,
-		arg.Email,
+	row := q.db.QueryRowContext(ctx, updateUser, arg.ID, arg.Username)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.HashedPassword,
+		&i.Email,
+		&i.CreatedAt,
+		&i.PasswordChangedAt,
+		&i.Role,
+		&i.VerifiedAt,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
 	)
+	return i, err
+}
+
+const updateUserCredentials = `-- name: UpdateUserCredentials :one
+UPDATE users
+SET hashed_password = $2,
+    email = $3,
+    password_changed_at = CASE WHEN hashed_password = $2 THEN password_changed_at ELSE now() END,
+    verified_at = CASE WHEN email = $3 THEN verified_at ELSE NULL END
+WHERE id = $1
+RETURNING id, username, hashed_password, email, created_at, password_changed_at, role, verified_at, totp_secret, totp_enabled_at, totp_last_step
+`
+
+type UpdateUserCredentialsParams struct {
+	ID             int32  `json:"id"`
+	HashedPassword string `json:"hashed_password"`
+	Email          string `json:"email"`
+}
+
+// Changing the email address requires it to be verified again.
+func (q *Queries) UpdateUserCredentials(ctx context.Context, arg UpdateUserCredentialsParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserCredentials, arg.ID, arg.HashedPassword, arg.Email)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.HashedPassword,
+		&i.Email,
+		&i.CreatedAt,
+		&i.PasswordChangedAt,
+		&i.Role,
+		&i.VerifiedAt,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const updateUserHashedPassword = `-- name: UpdateUserHashedPassword :exec
+UPDATE users
+SET hashed_password = $2
+WHERE id = $1
+`
+
+type UpdateUserHashedPasswordParams struct {
+	ID             int32  `json:"id"`
+	HashedPassword string `json:"hashed_password"`
+}
+
+func (q *Queries) UpdateUserHashedPassword(ctx context.Context, arg UpdateUserHashedPasswordParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserHashedPassword, arg.ID, arg.HashedPassword)
+	return err
+}
+
+const updateUserRole = `-- name: UpdateUserRole :one
+UPDATE users
+SET role = $2
+WHERE id = $1
+RETURNING id, username, hashed_password, email, created_at, password_changed_at, role, verified_at, totp_secret, totp_enabled_at, totp_last_step
+`
+
+type UpdateUserRoleParams struct {
+	ID   int32  `json:"id"`
+	Role string `json:"role"`
+}
+
+func (q *Queries) UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserRole, arg.ID, arg.Role)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.HashedPassword,
+		&i.Email,
+		&i.CreatedAt,
+		&i.PasswordChangedAt,
+		&i.Role,
+		&i.VerifiedAt,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const updateUserTOTPSecret = `-- name: UpdateUserTOTPSecret :one
+UPDATE users
+SET totp_secret = $2
+WHERE id = $1 AND totp_enabled_at IS NULL
+RETURNING id, username, hashed_password, email, created_at, password_changed_at, role, verified_at, totp_secret, totp_enabled_at, totp_last_step
+`
+
+type UpdateUserTOTPSecretParams struct {
+	ID         int32          `json:"id"`
+	TotpSecret sql.NullString `json:"totp_secret"`
+}
+
+// The secret of an enabled second factor cannot be replaced.
+func (q *Queries) UpdateUserTOTPSecret(ctx context.Context, arg UpdateUserTOTPSecretParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserTOTPSecret, arg.ID, arg.TotpSecret)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.HashedPassword,
+		&i.Email,
+		&i.CreatedAt,
+		&i.PasswordChangedAt,
+		&i.Role,
+		&i.VerifiedAt,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const useUserTOTPStep = `-- name: UseUserTOTPStep :one
+UPDATE users
+SET totp_last_step = $1
+WHERE id = $2 AND totp_last_step < $1
+RETURNING id, username, hashed_password, email, created_at, password_changed_at, role, verified_at, totp_secret, totp_enabled_at, totp_last_step
+`
+
+type UseUserTOTPStepParams struct {
+	Step int64 `json:"step"`
+	ID   int32 `json:"id"`
+}
+
+// Records the time step of an accepted TOTP code.
+// Returns sql.ErrNoRows if a code of the same or a later step was accepted before.
+func (q *Queries) UseUserTOTPStep(ctx context.Context, arg UseUserTOTPStepParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, useUserTOTPStep, arg.Step, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.HashedPassword,
+		&i.Email,
+		&i.CreatedAt,
+		&i.PasswordChangedAt,
+		&i.Role,
+		&i.VerifiedAt,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const verifyUserEmail = `-- name: VerifyUserEmail :one
+UPDATE users
+SET verified_at = now()
+WHERE id = $1 AND email = $2
+RETURNING id, username, hashed_password, email, created_at, password_changed_at, role, verified_at, totp_secret, totp_enabled_at, totp_last_step
+`
+
+type VerifyUserEmailParams struct {
+	ID    int32  `json:"id"`
+	Email string `json:"email"`
+}
+
+func (q *Queries) VerifyUserEmail(ctx context.Context, arg VerifyUserEmailParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, verifyUserEmail, arg.ID, arg.Email)
 	var i User
 	err := row.Scan(
 		&i.ID,
 		&i.Username,
-		&i.Password,
+		&i.HashedPassword,
 		&i.Email,
 		&i.CreatedAt,
+		&i.PasswordChangedAt,
+		&i.Role,
+		&i.VerifiedAt,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
 	)
 	return i, err
 }
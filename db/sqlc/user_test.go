@@ -8,13 +8,18 @@ import (
 
 	"github.com/danglebary/beatstore-backend-go/util"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func createRandomUser(t *testing.T) User {
+	hashedPassword, err := util.HashPassword(util.RandomPassword(), bcrypt.MinCost)
+	require.NoError(t, err)
+
 	arg := CreateUserParams{
-		Username: util.RandomUsername(),
-		Password: util.RandomPassword(),
-		Email:    util.RandomEmail(),
+		Username:       util.RandomUsername(),
+		HashedPassword: hashedPassword,
+		Email:          util.RandomEmail(),
+		Role:           util.ListenerRole,
 	}
 
 	user, err := testQueries.CreateUser(context.Background(), arg)
@@ -22,11 +27,13 @@ func createRandomUser(t *testing.T) User {
 	require.NotEmpty(t, user)
 
 	require.Equal(t, arg.Username, user.Username)
-	require.Equal(t, arg.Password, user.Password)
+	require.Equal(t, arg.HashedPassword, user.HashedPassword)
 	require.Equal(t, arg.Email, user.Email)
 
 	require.NotZero(t, user.ID)
 	require.NotZero(t, user.CreatedAt)
+	require.True(t, user.PasswordChangedAt.IsZero())
+	require.Equal(t, arg.Role, user.Role)
 
 	return user
 }
@@ -49,7 +56,7 @@ func TestGetUserById(t *testing.T) {
 
 	require.Equal(t, user1.ID, user2.ID)
 	require.Equal(t, user1.Username, user2.Username)
-	require.Equal(t, user1.Password, user2.Password)
+	require.Equal(t, user1.HashedPassword, user2.HashedPassword)
 	require.Equal(t, user1.Email, user2.Email)
 	require.WithinDuration(t, user1.CreatedAt, user2.CreatedAt, time.Second)
 
@@ -64,7 +71,7 @@ func TestGetUserByUsername(t *testing.T) {
 
 	require.Equal(t, user1.ID, user2.ID)
 	require.Equal(t, user1.Username, user2.Username)
-	require.Equal(t, user1.Password, user2.Password)
+	require.Equal(t, user1.HashedPassword, user2.HashedPassword)
 	require.Equal(t, user1.Email, user2.Email)
 	require.WithinDuration(t, user1.CreatedAt, user2.CreatedAt, time.Second)
 
@@ -93,11 +100,10 @@ func TestListUsersById(t *testing.T) {
 
 func TestUpdateUser(t *testing.T) {
 	user1 := createRandomUser(t)
+
 	arg := UpdateUserParams{
 		ID:       user1.ID,
 		Username: util.RandomUsername(),
-		Password: util.RandomPassword(),
-		Email:    util.RandomEmail(),
 	}
 	user2, err := testQueries.UpdateUser(context.Background(), arg)
 	require.NoError(t, err)
@@ -105,9 +111,96 @@ func TestUpdateUser(t *testing.T) {
 
 	require.Equal(t, user1.ID, user2.ID)
 	require.Equal(t, arg.Username, user2.Username)
-	require.Equal(t, arg.Password, user2.Password)
-	require.Equal(t, arg.Email, user2.Email)
+	require.Equal(t, user1.HashedPassword, user2.HashedPassword)
+	require.Equal(t, user1.Email, user2.Email)
 	require.WithinDuration(t, user1.CreatedAt, user2.CreatedAt, time.Second)
+	require.WithinDuration(t, user1.PasswordChangedAt, user2.PasswordChangedAt, time.Second)
+
+	deleteRandomUser(t, user1.ID)
+}
+
+func TestUpdateUserCredentials(t *testing.T) {
+	user1 := createRandomUser(t)
+
+	// A new email address alone keeps the password
+	arg := UpdateUserCredentialsParams{
+		ID:             user1.ID,
+		HashedPassword: user1.HashedPassword,
+		Email:          util.RandomEmail(),
+	}
+	user2, err := testQueries.UpdateUserCredentials(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, user1.Username, user2.Username)
+	require.Equal(t, arg.Email, user2.Email)
+	require.False(t, user2.VerifiedAt.Valid)
+	require.WithinDuration(t, user1.PasswordChangedAt, user2.PasswordChangedAt, time.Second)
+
+	hashedPassword, err := util.HashPassword(util.RandomPassword(), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	arg.HashedPassword = hashedPassword
+	user3, err := testQueries.UpdateUserCredentials(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.HashedPassword, user3.HashedPassword)
+	require.Equal(t, arg.Email, user3.Email)
+	require.True(t, user3.PasswordChangedAt.After(user2.PasswordChangedAt))
+
+	deleteRandomUser(t, user1.ID)
+}
+
+func TestUpdateUserHashedPassword(t *testing.T) {
+	user1 := createRandomUser(t)
+
+	hashedPassword, err := util.HashPassword(util.RandomPassword(), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	arg := UpdateUserHashedPasswordParams{
+		ID:             user1.ID,
+		HashedPassword: hashedPassword,
+	}
+	err = testQueries.UpdateUserHashedPassword(context.Background(), arg)
+	require.NoError(t, err)
+
+	user2, err := testQueries.GetUserById(context.Background(), user1.ID)
+	require.NoError(t, err)
+	require.Equal(t, hashedPassword, user2.HashedPassword)
+	require.Equal(t, user1.PasswordChangedAt, user2.PasswordChangedAt)
+
+	deleteRandomUser(t, user1.ID)
+}
+
+func TestGetUserByIdForUpdate(t *testing.T) {
+	user1 := createRandomUser(t)
+	user2, err := testQueries.GetUserByIdForUpdate(context.Background(), user1.ID)
+	require.NoError(t, err)
+	require.Equal(t, user1.ID, user2.ID)
+	require.Equal(t, user1.Role, user2.Role)
+
+	deleteRandomUser(t, user1.ID)
+}
+
+func TestUpdateUserRole(t *testing.T) {
+	user1 := createRandomUser(t)
+	arg := UpdateUserRoleParams{
+		ID:   user1.ID,
+		Role: util.ProducerRole,
+	}
+	user2, err := testQueries.UpdateUserRole(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, user1.ID, user2.ID)
+	require.Equal(t, util.ProducerRole, user2.Role)
+
+	deleteRandomUser(t, user1.ID)
+}
+
+func TestUpdateUserInvalidRole(t *testing.T) {
+	user1 := createRandomUser(t)
+	arg := UpdateUserRoleParams{
+		ID:   user1.ID,
+		Role: util.RandomString(8),
+	}
+	_, err := testQueries.UpdateUserRole(context.Background(), arg)
+	require.Error(t, err)
 
 	deleteRandomUser(t, user1.ID)
 }
@@ -122,3 +215,85 @@ func TestDeleteUser(t *testing.T) {
 	require.EqualError(t, err, sql.ErrNoRows.Error())
 	require.Empty(t, user2)
 }
+
+func TestVerifyUserEmail(t *testing.T) {
+	user1 := createRandomUser(t)
+	require.False(t, user1.VerifiedAt.Valid)
+
+	// The address must still match the one that was verified
+	_, err := testQueries.VerifyUserEmail(context.Background(), VerifyUserEmailParams{
+		ID:    user1.ID,
+		Email: util.RandomEmail(),
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	user2, err := testQueries.VerifyUserEmail(context.Background(), VerifyUserEmailParams{
+		ID:    user1.ID,
+		Email: user1.Email,
+	})
+	require.NoError(t, err)
+	require.True(t, user2.VerifiedAt.Valid)
+
+	deleteRandomUser(t, user1.ID)
+}
+
+func TestGetUserByEmail(t *testing.T) {
+	user1 := createRandomUser(t)
+
+	user2, err := testQueries.GetUserByEmail(context.Background(), user1.Email)
+	require.NoError(t, err)
+	require.Equal(t, user1.ID, user2.ID)
+	require.Equal(t, user1.Username, user2.Username)
+
+	_, err = testQueries.GetUserByEmail(context.Background(), util.RandomEmail())
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	deleteRandomUser(t, user1.ID)
+}
+
+func TestResetUserPassword(t *testing.T) {
+	user1 := createRandomUser(t)
+
+	hashedPassword, err := util.HashPassword(util.RandomPassword(), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	user2, err := testQueries.ResetUserPassword(context.Background(), ResetUserPasswordParams{
+		ID:             user1.ID,
+		HashedPassword: hashedPassword,
+	})
+	require.NoError(t, err)
+	require.Equal(t, hashedPassword, user2.HashedPassword)
+	require.WithinDuration(t, time.Now(), user2.PasswordChangedAt, time.Second)
+
+	deleteRandomUser(t, user1.ID)
+}
+
+func TestEnableUserTOTP(t *testing.T) {
+	user1 := createRandomUser(t)
+
+	// A secret has to be enrolled first
+	_, err := testQueries.EnableUserTOTP(context.Background(), user1.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	secret := sql.NullString{String: util.RandomString(32), Valid: true}
+	user2, err := testQueries.UpdateUserTOTPSecret(context.Background(), UpdateUserTOTPSecretParams{
+		ID:         user1.ID,
+		TotpSecret: secret,
+	})
+	require.NoError(t, err)
+	require.Equal(t, secret, user2.TotpSecret)
+	require.False(t, user2.TotpEnabledAt.Valid)
+
+	user3, err := testQueries.EnableUserTOTP(context.Background(), user1.ID)
+	require.NoError(t, err)
+	require.True(t, user3.TotpEnabledAt.Valid)
+
+	// The secret of an enabled second factor cannot be replaced
+	_, err = testQueries.UpdateUserTOTPSecret(context.Background(), UpdateUserTOTPSecretParams{
+		ID:         user1.ID,
+		TotpSecret: sql.NullString{String: util.RandomString(32), Valid: true},
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	deleteRandomUser(t, user1.ID)
+}
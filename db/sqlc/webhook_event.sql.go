@@ -0,0 +1,208 @@
+// Code generated by sqlc. DO NOT EDIT.
+// source: webhook_event.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+)
+
+const getWebhookEvent = `-- name: GetWebhookEvent :one
+SELECT id, type, payload, status, attempts, last_error, order_id, received_at, processed_at FROM webhook_events
+WHERE id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetWebhookEvent(ctx context.Context, id string) (WebhookEvent, error) {
+	row := q.db.QueryRowContext(ctx, getWebhookEvent, id)
+	var i WebhookEvent
+	err := row.Scan(
+		&i.ID,
+		&i.Type,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.LastError,
+		&i.OrderID,
+		&i.ReceivedAt,
+		&i.ProcessedAt,
+	)
+	return i, err
+}
+
+const getWebhookEventForUpdate = `-- name: GetWebhookEventForUpdate :one
+SELECT id, type, payload, status, attempts, last_error, order_id, received_at, processed_at FROM webhook_events
+WHERE id = $1
+LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetWebhookEventForUpdate(ctx context.Context, id string) (WebhookEvent, error) {
+	row := q.db.QueryRowContext(ctx, getWebhookEventForUpdate, id)
+	var i WebhookEvent
+	err := row.Scan(
+		&i.ID,
+		&i.Type,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.LastError,
+		&i.OrderID,
+		&i.ReceivedAt,
+		&i.ProcessedAt,
+	)
+	return i, err
+}
+
+const listWebhookEvents = `-- name: ListWebhookEvents :many
+SELECT id, type, payload, status, attempts, last_error, order_id, received_at, processed_at FROM webhook_events
+WHERE status = $1
+ORDER BY received_at, id
+LIMIT $2
+OFFSET $3
+`
+
+type ListWebhookEventsParams struct {
+	Status string `json:"status"`
+	Limit  int32  `json:"limit"`
+	Offset int32  `json:"offset"`
+}
+
+// Lists the events in the given status, oldest first
+func (q *Queries) ListWebhookEvents(ctx context.Context, arg ListWebhookEventsParams) ([]WebhookEvent, error) {
+	rows, err := q.db.QueryContext(ctx, listWebhookEvents, arg.Status, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []WebhookEvent{}
+	for rows.Next() {
+		var i WebhookEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.Type,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.LastError,
+			&i.OrderID,
+			&i.ReceivedAt,
+			&i.ProcessedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markWebhookEventFailed = `-- name: MarkWebhookEventFailed :one
+UPDATE webhook_events
+SET status = 'failed',
+    attempts = attempts + 1,
+    last_error = $2
+WHERE id = $1
+RETURNING id, type, payload, status, attempts, last_error, order_id, received_at, processed_at
+`
+
+type MarkWebhookEventFailedParams struct {
+	ID        string `json:"id"`
+	LastError string `json:"last_error"`
+}
+
+func (q *Queries) MarkWebhookEventFailed(ctx context.Context, arg MarkWebhookEventFailedParams) (WebhookEvent, error) {
+	row := q.db.QueryRowContext(ctx, markWebhookEventFailed, arg.ID, arg.LastError)
+	var i WebhookEvent
+	err := row.Scan(
+		&i.ID,
+		&i.Type,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.LastError,
+		&i.OrderID,
+		&i.ReceivedAt,
+		&i.ProcessedAt,
+	)
+	return i, err
+}
+
+const markWebhookEventProcessed = `-- name: MarkWebhookEventProcessed :one
+UPDATE webhook_events
+SET status = $2,
+    order_id = $3,
+    attempts = attempts + 1,
+    last_error = '',
+    processed_at = now()
+WHERE id = $1
+RETURNING id, type, payload, status, attempts, last_error, order_id, received_at, processed_at
+`
+
+type MarkWebhookEventProcessedParams struct {
+	ID      string        `json:"id"`
+	Status  string        `json:"status"`
+	OrderID sql.NullInt64 `json:"order_id"`
+}
+
+func (q *Queries) MarkWebhookEventProcessed(ctx context.Context, arg MarkWebhookEventProcessedParams) (WebhookEvent, error) {
+	row := q.db.QueryRowContext(ctx, markWebhookEventProcessed, arg.ID, arg.Status, arg.OrderID)
+	var i WebhookEvent
+	err := row.Scan(
+		&i.ID,
+		&i.Type,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.LastError,
+		&i.OrderID,
+		&i.ReceivedAt,
+		&i.ProcessedAt,
+	)
+	return i, err
+}
+
+const recordWebhookEvent = `-- name: RecordWebhookEvent :one
+INSERT INTO webhook_events (
+    id,
+    type,
+    payload
+) VALUES (
+    $1, $2, $3
+)
+ON CONFLICT (id) DO UPDATE
+SET id = EXCLUDED.id
+RETURNING id, type, payload, status, attempts, last_error, order_id, received_at, processed_at
+`
+
+type RecordWebhookEventParams struct {
+	ID      string          `json:"id"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// Records a received event. A redelivered event returns the row recorded first,
+// its payload and status are left as they are.
+func (q *Queries) RecordWebhookEvent(ctx context.Context, arg RecordWebhookEventParams) (WebhookEvent, error) {
+	row := q.db.QueryRowContext(ctx, recordWebhookEvent, arg.ID, arg.Type, arg.Payload)
+	var i WebhookEvent
+	err := row.Scan(
+		&i.ID,
+		&i.Type,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.LastError,
+		&i.OrderID,
+		&i.ReceivedAt,
+		&i.ProcessedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,139 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/stretchr/testify/require"
+)
+
+func recordRandomWebhookEvent(t *testing.T, intentID string) WebhookEvent {
+	arg := RecordWebhookEventParams{
+		ID:      "evt_" + util.RandomString(16),
+		Type:    "payment_intent.succeeded",
+		Payload: json.RawMessage(fmt.Sprintf(`{"intent_id": %q}`, intentID)),
+	}
+
+	event, err := testQueries.RecordWebhookEvent(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.ID, event.ID)
+	require.Equal(t, arg.Type, event.Type)
+	require.JSONEq(t, string(arg.Payload), string(event.Payload))
+	require.Equal(t, util.WebhookEventPending, event.Status)
+	require.Zero(t, event.Attempts)
+	require.Empty(t, event.LastError)
+	require.False(t, event.OrderID.Valid)
+	require.NotZero(t, event.ReceivedAt)
+	require.False(t, event.ProcessedAt.Valid)
+
+	return event
+}
+
+func TestRecordWebhookEvent(t *testing.T) {
+	event1 := recordRandomWebhookEvent(t, "pi_"+util.RandomString(16))
+
+	event2, err := testQueries.GetWebhookEvent(context.Background(), event1.ID)
+	require.NoError(t, err)
+	require.Equal(t, event1, event2)
+
+	failed, err := testQueries.MarkWebhookEventFailed(context.Background(), MarkWebhookEventFailedParams{
+		ID:        event1.ID,
+		LastError: "sql: no rows in result set",
+	})
+	require.NoError(t, err)
+
+	// A redelivered event is recorded once, the first payload and the status are kept
+	event3, err := testQueries.RecordWebhookEvent(context.Background(), RecordWebhookEventParams{
+		ID:      event1.ID,
+		Type:    "payment_intent.refunded",
+		Payload: json.RawMessage(`{}`),
+	})
+	require.NoError(t, err)
+	require.Equal(t, failed, event3)
+}
+
+func TestMarkWebhookEvent(t *testing.T) {
+	event1 := recordRandomWebhookEvent(t, "pi_"+util.RandomString(16))
+
+	event2, err := testQueries.MarkWebhookEventFailed(context.Background(), MarkWebhookEventFailedParams{
+		ID:        event1.ID,
+		LastError: "order can't move to this status",
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.WebhookEventFailed, event2.Status)
+	require.Equal(t, int32(1), event2.Attempts)
+	require.Equal(t, "order can't move to this status", event2.LastError)
+	require.False(t, event2.ProcessedAt.Valid)
+
+	event3, err := testQueries.MarkWebhookEventProcessed(context.Background(), MarkWebhookEventProcessedParams{
+		ID:     event1.ID,
+		Status: util.WebhookEventIgnored,
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.WebhookEventIgnored, event3.Status)
+	require.Equal(t, int32(2), event3.Attempts)
+	require.Empty(t, event3.LastError)
+	require.False(t, event3.OrderID.Valid)
+	require.True(t, event3.ProcessedAt.Valid)
+}
+
+func TestListWebhookEvents(t *testing.T) {
+	status := util.WebhookEventFailed
+	var events []WebhookEvent
+	for i := 0; i < 3; i++ {
+		event := recordRandomWebhookEvent(t, "pi_"+util.RandomString(16))
+		event, err := testQueries.MarkWebhookEventFailed(context.Background(), MarkWebhookEventFailedParams{
+			ID:        event.ID,
+			LastError: "failed",
+		})
+		require.NoError(t, err)
+		events = append(events, event)
+	}
+
+	got, err := testQueries.ListWebhookEvents(context.Background(), ListWebhookEventsParams{
+		Status: status,
+		Limit:  1000,
+		Offset: 0,
+	})
+	require.NoError(t, err)
+
+	// Other tests leave failed events behind, ours are listed in the order they were received
+	var listed []WebhookEvent
+	for _, event := range got {
+		require.Equal(t, status, event.Status)
+		for _, ours := range events {
+			if event.ID == ours.ID {
+				listed = append(listed, event)
+			}
+		}
+	}
+	require.Len(t, listed, len(events))
+	for i := 1; i < len(listed); i++ {
+		require.False(t, listed[i].ReceivedAt.Before(listed[i-1].ReceivedAt))
+	}
+}
+
+func TestGetOrderByPaymentIntentForUpdate(t *testing.T) {
+	buyer := createRandomUser(t)
+	order1 := createRandomOrder(t, buyer)
+	intentID := sql.NullString{String: "pi_" + util.RandomString(16), Valid: true}
+
+	_, err := testQueries.GetOrderByPaymentIntentForUpdate(context.Background(), intentID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	order1, err = testQueries.SetOrderPaymentIntent(context.Background(), SetOrderPaymentIntentParams{
+		ID:              order1.ID,
+		PaymentIntentID: intentID,
+	})
+	require.NoError(t, err)
+
+	order2, err := testQueries.GetOrderByPaymentIntentForUpdate(context.Background(), intentID)
+	require.NoError(t, err)
+	require.Equal(t, order1, order2)
+
+	deleteRandomUser(t, buyer.ID)
+}
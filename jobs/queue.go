@@ -0,0 +1,164 @@
+// Package jobs runs background work, such as processing uploaded audio, outside of the request
+// which triggered it. Jobs live in memory, a restart drops the ones which haven't run yet.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// ErrQueueFull is returned when more jobs are waiting than the queue holds
+var ErrQueueFull = errors.New("job queue is full")
+
+// ErrQueueClosed is returned for jobs enqueued after Close
+var ErrQueueClosed = errors.New("job queue is closed")
+
+// Job is a named unit of background work
+type Job struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// Queue runs jobs on a fixed number of workers
+type Queue struct {
+	jobs    chan Job
+	timeout time.Duration
+	// pending counts the jobs which were enqueued but haven't finished
+	pending sync.WaitGroup
+	workers sync.WaitGroup
+	mu      sync.RWMutex
+	closed  bool
+	// done is closed by Close to stop the schedules started by Every
+	done      chan struct{}
+	schedules sync.WaitGroup
+	// logf reports failed jobs, tests replace it to collect the failures
+	logf func(format string, args ...interface{})
+}
+
+// NewQueue starts workers which run the jobs, up to size jobs can wait for a free worker.
+// Every job is canceled once it runs longer than timeout.
+func NewQueue(workers, size int, timeout time.Duration) (*Queue, error) {
+	if workers < 1 {
+		return nil, fmt.Errorf("at least one job worker is required")
+	}
+	if size < 0 {
+		return nil, fmt.Errorf("invalid job queue size %d", size)
+	}
+	if timeout <= 0 {
+		return nil, fmt.Errorf("invalid job timeout %s", timeout)
+	}
+
+	queue := &Queue{
+		jobs:    make(chan Job, size),
+		timeout: timeout,
+		done:    make(chan struct{}),
+		logf:    log.Printf,
+	}
+	queue.workers.Add(workers)
+	for i := 0; i < workers; i++ {
+		go queue.work()
+	}
+	return queue, nil
+}
+
+// Enqueue hands job to the next free worker without waiting for it
+func (queue *Queue) Enqueue(job Job) error {
+	queue.mu.RLock()
+	defer queue.mu.RUnlock()
+
+	if queue.closed {
+		return ErrQueueClosed
+	}
+
+	queue.pending.Add(1)
+	select {
+	case queue.jobs <- job:
+		return nil
+	default:
+		queue.pending.Done()
+		return ErrQueueFull
+	}
+}
+
+// Every enqueues job once per interval until the queue is closed.
+// A run is skipped while the queue is full, the next tick tries again.
+func (queue *Queue) Every(interval time.Duration, job Job) error {
+	if interval <= 0 {
+		return fmt.Errorf("invalid interval %s for job %s", interval, job.Name)
+	}
+
+	queue.mu.RLock()
+	defer queue.mu.RUnlock()
+
+	if queue.closed {
+		return ErrQueueClosed
+	}
+
+	queue.schedules.Add(1)
+	go func() {
+		defer queue.schedules.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-queue.done:
+				return
+			case <-ticker.C:
+				if err := queue.Enqueue(job); err != nil && err != ErrQueueClosed {
+					queue.logf("job %s skipped: %v", job.Name, err)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// Wait blocks until every enqueued job has finished
+func (queue *Queue) Wait() {
+	queue.pending.Wait()
+}
+
+// Close stops accepting jobs and the schedules, then waits for the jobs already enqueued
+func (queue *Queue) Close() {
+	queue.mu.Lock()
+	if !queue.closed {
+		queue.closed = true
+		close(queue.done)
+		close(queue.jobs)
+	}
+	queue.mu.Unlock()
+
+	queue.schedules.Wait()
+	queue.workers.Wait()
+}
+
+func (queue *Queue) work() {
+	defer queue.workers.Done()
+
+	for job := range queue.jobs {
+		queue.run(job)
+	}
+}
+
+// run executes job, a failing or panicking job is logged and doesn't affect the others
+func (queue *Queue) run(job Job) {
+	defer queue.pending.Done()
+	defer func() {
+		if r := recover(); r != nil {
+			queue.logf("job %s panicked: %v", job.Name, r)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), queue.timeout)
+	defer cancel()
+
+	if err := job.Run(ctx); err != nil {
+		queue.logf("job %s failed: %v", job.Name, err)
+	}
+}
@@ -0,0 +1,164 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// collectLogs makes queue record its log lines instead of printing them
+func collectLogs(queue *Queue) func() []string {
+	var mu sync.Mutex
+	var lines []string
+	queue.logf = func(format string, args ...interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		lines = append(lines, fmt.Sprintf(format, args...))
+	}
+	return func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return lines
+	}
+}
+
+func TestQueue(t *testing.T) {
+	queue, err := NewQueue(3, 10, time.Minute)
+	require.NoError(t, err)
+	logs := collectLogs(queue)
+
+	var ran int32
+	for i := 0; i < 10; i++ {
+		err := queue.Enqueue(Job{Name: "count", Run: func(ctx context.Context) error {
+			atomic.AddInt32(&ran, 1)
+			return nil
+		}})
+		require.NoError(t, err)
+	}
+
+	queue.Wait()
+	require.Equal(t, int32(10), atomic.LoadInt32(&ran))
+	require.Empty(t, logs())
+
+	queue.Close()
+	err = queue.Enqueue(Job{Name: "late", Run: func(ctx context.Context) error { return nil }})
+	require.ErrorIs(t, err, ErrQueueClosed)
+
+	// Closing twice is fine
+	queue.Close()
+}
+
+func TestQueueFailures(t *testing.T) {
+	queue, err := NewQueue(1, 10, time.Minute)
+	require.NoError(t, err)
+	logs := collectLogs(queue)
+
+	require.NoError(t, queue.Enqueue(Job{Name: "failing", Run: func(ctx context.Context) error {
+		return errors.New("broken file")
+	}}))
+	require.NoError(t, queue.Enqueue(Job{Name: "panicking", Run: func(ctx context.Context) error {
+		panic("index out of range")
+	}}))
+	ran := false
+	require.NoError(t, queue.Enqueue(Job{Name: "healthy", Run: func(ctx context.Context) error {
+		ran = true
+		return nil
+	}}))
+
+	queue.Close()
+	require.True(t, ran)
+	require.Equal(t, []string{
+		"job failing failed: broken file",
+		"job panicking panicked: index out of range",
+	}, logs())
+}
+
+func TestQueueFull(t *testing.T) {
+	queue, err := NewQueue(1, 1, time.Minute)
+	require.NoError(t, err)
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	blocking := Job{Name: "blocking", Run: func(ctx context.Context) error {
+		close(started)
+		<-release
+		return nil
+	}}
+	noop := Job{Name: "noop", Run: func(ctx context.Context) error { return nil }}
+
+	// One job runs, one waits and the next doesn't fit
+	require.NoError(t, queue.Enqueue(blocking))
+	<-started
+	require.NoError(t, queue.Enqueue(noop))
+	require.ErrorIs(t, queue.Enqueue(noop), ErrQueueFull)
+
+	close(release)
+	queue.Close()
+}
+
+func TestQueueTimeout(t *testing.T) {
+	queue, err := NewQueue(1, 1, 10*time.Millisecond)
+	require.NoError(t, err)
+	logs := collectLogs(queue)
+
+	require.NoError(t, queue.Enqueue(Job{Name: "slow", Run: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}}))
+
+	queue.Close()
+	require.Equal(t, []string{"job slow failed: context deadline exceeded"}, logs())
+}
+
+func TestNewQueue(t *testing.T) {
+	_, err := NewQueue(0, 10, time.Minute)
+	require.Error(t, err)
+
+	_, err = NewQueue(1, -1, time.Minute)
+	require.Error(t, err)
+
+	_, err = NewQueue(1, 10, 0)
+	require.Error(t, err)
+}
+
+func TestQueueEvery(t *testing.T) {
+	queue, err := NewQueue(1, 1, time.Minute)
+	require.NoError(t, err)
+	logs := collectLogs(queue)
+
+	ran := make(chan struct{}, 1)
+	err = queue.Every(time.Millisecond, Job{Name: "tick", Run: func(ctx context.Context) error {
+		select {
+		case ran <- struct{}{}:
+		default:
+		}
+		return nil
+	}})
+	require.NoError(t, err)
+
+	// The job keeps running until the queue is closed
+	for i := 0; i < 3; i++ {
+		select {
+		case <-ran:
+		case <-time.After(time.Second):
+			t.Fatal("scheduled job didn't run")
+		}
+	}
+
+	queue.Close()
+	for _, line := range logs() {
+		require.NotContains(t, line, "failed")
+	}
+
+	err = queue.Every(time.Millisecond, Job{Name: "late", Run: func(ctx context.Context) error { return nil }})
+	require.ErrorIs(t, err, ErrQueueClosed)
+
+	err = queue.Every(0, Job{Name: "invalid", Run: func(ctx context.Context) error { return nil }})
+	require.Error(t, err)
+}
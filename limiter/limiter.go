@@ -0,0 +1,89 @@
+// Package limiter counts failed login attempts so repeated failures can be slowed down
+// with an exponential backoff and eventually locked out for a while.
+package limiter
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/danglebary/beatstore-backend-go/util"
+)
+
+// Status describes the failed attempts recorded for a key
+type Status struct {
+	Failures      int
+	LastFailureAt time.Time
+}
+
+// Limiter stores failed attempts per key, e.g. per username or per client IP
+type Limiter interface {
+	// Get returns the failures recorded for key
+	Get(ctx context.Context, key string) (Status, error)
+	// Fail records a failed attempt at time at, failures older than window are forgotten
+	Fail(ctx context.Context, key string, at time.Time, window time.Duration) (Status, error)
+	// Reset forgets all failures of key
+	Reset(ctx context.Context, key string) error
+}
+
+// New creates the limiter selected by config.LoginLimiterBackend
+func New(config util.Config, store Store) (Limiter, error) {
+	switch config.LoginLimiterBackend {
+	case "", "memory":
+		return NewMemoryLimiter(), nil
+	case "postgres":
+		return NewPostgresLimiter(store), nil
+	default:
+		return nil, fmt.Errorf("unsupported login limiter backend %s", config.LoginLimiterBackend)
+	}
+}
+
+// Policy decides how long a key is blocked after a number of failures
+type Policy struct {
+	// LockoutThreshold is the number of failures after which the key is locked out,
+	// zero disables the lockout
+	LockoutThreshold int
+	LockoutDuration  time.Duration
+	// BackoffBase is the delay after the first failure, it doubles with every further failure
+	// up to BackoffMax. Zero disables the backoff.
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+	// Window is the time after which failures are forgotten
+	Window time.Duration
+}
+
+// IsLockedOut checks if status reached the lockout threshold
+func (policy Policy) IsLockedOut(status Status) bool {
+	return policy.LockoutThreshold > 0 && status.Failures >= policy.LockoutThreshold
+}
+
+// Delay returns how long to wait after the last failure of status before the next attempt
+func (policy Policy) Delay(status Status) time.Duration {
+	if status.Failures <= 0 {
+		return 0
+	}
+	if policy.IsLockedOut(status) {
+		return policy.LockoutDuration
+	}
+	if policy.BackoffBase <= 0 {
+		return 0
+	}
+
+	delay := policy.BackoffBase
+	for i := 1; i < status.Failures; i++ {
+		delay *= 2
+		if policy.BackoffMax > 0 && delay >= policy.BackoffMax {
+			return policy.BackoffMax
+		}
+	}
+	return delay
+}
+
+// BlockedUntil returns the time before which no attempt is allowed,
+// the key is not blocked if it is before now
+func (policy Policy) BlockedUntil(status Status) time.Time {
+	if status.Failures <= 0 {
+		return time.Time{}
+	}
+	return status.LastFailureAt.Add(policy.Delay(status))
+}
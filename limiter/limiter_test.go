@@ -0,0 +1,78 @@
+package limiter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/stretchr/testify/require"
+)
+
+func testPolicy() Policy {
+	return Policy{
+		LockoutThreshold: 5,
+		LockoutDuration:  15 * time.Minute,
+		BackoffBase:      time.Second,
+		BackoffMax:       5 * time.Second,
+		Window:           15 * time.Minute,
+	}
+}
+
+func TestPolicyDelay(t *testing.T) {
+	policy := testPolicy()
+
+	testCases := []struct {
+		failures int
+		delay    time.Duration
+	}{
+		{0, 0},
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		// Capped by BackoffMax
+		{4, 5 * time.Second},
+		// Locked out
+		{5, 15 * time.Minute},
+		{8, 15 * time.Minute},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.delay, policy.Delay(Status{Failures: tc.failures}), "failures: %d", tc.failures)
+	}
+}
+
+func TestPolicyDisabled(t *testing.T) {
+	var policy Policy
+	status := Status{Failures: 100, LastFailureAt: time.Now()}
+
+	require.False(t, policy.IsLockedOut(status))
+	require.Zero(t, policy.Delay(status))
+	require.Equal(t, status.LastFailureAt, policy.BlockedUntil(status))
+}
+
+func TestPolicyBlockedUntil(t *testing.T) {
+	policy := testPolicy()
+	now := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	require.True(t, policy.BlockedUntil(Status{}).IsZero())
+
+	status := Status{Failures: 2, LastFailureAt: now}
+	require.Equal(t, now.Add(2*time.Second), policy.BlockedUntil(status))
+
+	status = Status{Failures: 5, LastFailureAt: now}
+	require.True(t, policy.IsLockedOut(status))
+	require.Equal(t, now.Add(15*time.Minute), policy.BlockedUntil(status))
+}
+
+func TestNew(t *testing.T) {
+	limiter, err := New(util.Config{}, nil)
+	require.NoError(t, err)
+	require.IsType(t, &MemoryLimiter{}, limiter)
+
+	limiter, err = New(util.Config{LoginLimiterBackend: "postgres"}, nil)
+	require.NoError(t, err)
+	require.IsType(t, &PostgresLimiter{}, limiter)
+
+	_, err = New(util.Config{LoginLimiterBackend: "redis"}, nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,52 @@
+package limiter
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryLimiter keeps failures in memory, it is meant for tests
+// and deployments running a single instance
+type MemoryLimiter struct {
+	mu       sync.Mutex
+	statuses map[string]Status
+}
+
+// NewMemoryLimiter creates a new empty MemoryLimiter
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{statuses: make(map[string]Status)}
+}
+
+// Get returns the failures recorded for key
+func (limiter *MemoryLimiter) Get(ctx context.Context, key string) (Status, error) {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	return limiter.statuses[key], nil
+}
+
+// Fail records a failed attempt for key
+func (limiter *MemoryLimiter) Fail(ctx context.Context, key string, at time.Time, window time.Duration) (Status, error) {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	status := limiter.statuses[key]
+	if status.LastFailureAt.Before(at.Add(-window)) {
+		status.Failures = 0
+	}
+	status.Failures++
+	status.LastFailureAt = at
+
+	limiter.statuses[key] = status
+	return status, nil
+}
+
+// Reset forgets all failures of key
+func (limiter *MemoryLimiter) Reset(ctx context.Context, key string) error {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	delete(limiter.statuses, key)
+	return nil
+}
@@ -0,0 +1,52 @@
+package limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryLimiter(t *testing.T) {
+	limiter := NewMemoryLimiter()
+	ctx := context.Background()
+	key := util.RandomString(8)
+	now := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	window := time.Minute
+
+	status, err := limiter.Get(ctx, key)
+	require.NoError(t, err)
+	require.Zero(t, status.Failures)
+
+	for i := 1; i <= 3; i++ {
+		status, err = limiter.Fail(ctx, key, now, window)
+		require.NoError(t, err)
+		require.Equal(t, i, status.Failures)
+		require.Equal(t, now, status.LastFailureAt)
+	}
+
+	status, err = limiter.Get(ctx, key)
+	require.NoError(t, err)
+	require.Equal(t, 3, status.Failures)
+
+	// Other keys are not affected
+	status, err = limiter.Get(ctx, util.RandomString(8))
+	require.NoError(t, err)
+	require.Zero(t, status.Failures)
+
+	// Failures outside of the window are forgotten
+	later := now.Add(2 * window)
+	status, err = limiter.Fail(ctx, key, later, window)
+	require.NoError(t, err)
+	require.Equal(t, 1, status.Failures)
+	require.Equal(t, later, status.LastFailureAt)
+
+	err = limiter.Reset(ctx, key)
+	require.NoError(t, err)
+
+	status, err = limiter.Get(ctx, key)
+	require.NoError(t, err)
+	require.Zero(t, status.Failures)
+}
@@ -0,0 +1,64 @@
+package limiter
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
+)
+
+// Store is the subset of db.Store the Postgres limiter needs
+type Store interface {
+	GetLoginThrottle(ctx context.Context, key string) (db.LoginThrottle, error)
+	RecordLoginFailure(ctx context.Context, arg db.RecordLoginFailureParams) (db.LoginThrottle, error)
+	DeleteLoginThrottle(ctx context.Context, key string) error
+}
+
+// PostgresLimiter keeps failures in the login_throttles table,
+// so every instance of the server sees the same counts
+type PostgresLimiter struct {
+	store Store
+}
+
+// NewPostgresLimiter creates a new PostgresLimiter using store
+func NewPostgresLimiter(store Store) *PostgresLimiter {
+	return &PostgresLimiter{store: store}
+}
+
+// Get returns the failures recorded for key
+func (limiter *PostgresLimiter) Get(ctx context.Context, key string) (Status, error) {
+	throttle, err := limiter.store.GetLoginThrottle(ctx, key)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return Status{}, nil
+		}
+		return Status{}, err
+	}
+	return newStatus(throttle), nil
+}
+
+// Fail records a failed attempt for key, the count is updated atomically
+func (limiter *PostgresLimiter) Fail(ctx context.Context, key string, at time.Time, window time.Duration) (Status, error) {
+	throttle, err := limiter.store.RecordLoginFailure(ctx, db.RecordLoginFailureParams{
+		Key:         key,
+		FailedAt:    at,
+		WindowStart: at.Add(-window),
+	})
+	if err != nil {
+		return Status{}, err
+	}
+	return newStatus(throttle), nil
+}
+
+// Reset forgets all failures of key
+func (limiter *PostgresLimiter) Reset(ctx context.Context, key string) error {
+	return limiter.store.DeleteLoginThrottle(ctx, key)
+}
+
+func newStatus(throttle db.LoginThrottle) Status {
+	return Status{
+		Failures:      int(throttle.Failures),
+		LastFailureAt: throttle.LastFailureAt,
+	}
+}
@@ -0,0 +1,71 @@
+package limiter
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	mockdb "github.com/danglebary/beatstore-backend-go/db/mock"
+	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresLimiter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	limiter := NewPostgresLimiter(store)
+	ctx := context.Background()
+	key := util.RandomString(8)
+	now := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	window := time.Minute
+
+	throttle := db.LoginThrottle{
+		Key:           key,
+		Failures:      3,
+		LastFailureAt: now,
+	}
+
+	gomock.InOrder(
+		store.EXPECT().
+			GetLoginThrottle(gomock.Any(), gomock.Eq(key)).
+			Times(1).
+			Return(db.LoginThrottle{}, sql.ErrNoRows),
+		store.EXPECT().
+			RecordLoginFailure(gomock.Any(), gomock.Eq(db.RecordLoginFailureParams{
+				Key:         key,
+				FailedAt:    now,
+				WindowStart: now.Add(-window),
+			})).
+			Times(1).
+			Return(throttle, nil),
+		store.EXPECT().
+			GetLoginThrottle(gomock.Any(), gomock.Eq(key)).
+			Times(1).
+			Return(db.LoginThrottle{}, sql.ErrConnDone),
+		store.EXPECT().
+			DeleteLoginThrottle(gomock.Any(), gomock.Eq(key)).
+			Times(1).
+			Return(nil),
+	)
+
+	// Keys without a row have no failures
+	status, err := limiter.Get(ctx, key)
+	require.NoError(t, err)
+	require.Zero(t, status.Failures)
+
+	status, err = limiter.Fail(ctx, key, now, window)
+	require.NoError(t, err)
+	require.Equal(t, 3, status.Failures)
+	require.Equal(t, now, status.LastFailureAt)
+
+	_, err = limiter.Get(ctx, key)
+	require.ErrorIs(t, err, sql.ErrConnDone)
+
+	err = limiter.Reset(ctx, key)
+	require.NoError(t, err)
+}
@@ -0,0 +1,68 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// WriterMailer writes every message to an io.Writer instead of delivering it,
+// it is meant for local development and tests
+type WriterMailer struct {
+	from string
+	mu   sync.Mutex
+	w    io.Writer
+}
+
+// NewWriterMailer creates a new WriterMailer writing to w
+func NewWriterMailer(from string, w io.Writer) *WriterMailer {
+	return &WriterMailer{from: from, w: w}
+}
+
+// NewStdoutMailer creates a new WriterMailer writing to standard output
+func NewStdoutMailer(from string) *WriterMailer {
+	return NewWriterMailer(from, os.Stdout)
+}
+
+// Send writes msg to the underlying writer
+func (mailer *WriterMailer) Send(ctx context.Context, msg Message) error {
+	mailer.mu.Lock()
+	defer mailer.mu.Unlock()
+
+	_, err := mailer.w.Write(buildMessage(mailer.from, msg))
+	return err
+}
+
+// FileMailer stores every message as an .eml file in a directory,
+// which lets developers open the emails with any mail client
+type FileMailer struct {
+	from string
+	dir  string
+}
+
+// NewFileMailer creates a new FileMailer writing to dir, creating it if needed
+func NewFileMailer(from string, dir string) (*FileMailer, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("mail directory is not configured")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileMailer{from: from, dir: dir}, nil
+}
+
+// Send writes msg to a new file in the mail directory
+func (mailer *FileMailer) Send(ctx context.Context, msg Message) error {
+	file, err := ioutil.TempFile(mailer.dir, fmt.Sprintf("%d-*.eml", time.Now().UnixNano()))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(buildMessage(mailer.from, msg))
+	return err
+}
@@ -0,0 +1,83 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/stretchr/testify/require"
+)
+
+func randomMessage() Message {
+	return Message{
+		To:      util.RandomEmail(),
+		Subject: util.RandomString(12),
+		Body:    util.RandomString(64),
+	}
+}
+
+func TestWriterMailer(t *testing.T) {
+	var buf bytes.Buffer
+	from := util.RandomEmail()
+	mailer := NewWriterMailer(from, &buf)
+
+	msg := randomMessage()
+	err := mailer.Send(context.Background(), msg)
+	require.NoError(t, err)
+
+	out := buf.String()
+	require.Contains(t, out, "From: "+from)
+	require.Contains(t, out, "To: "+msg.To)
+	require.Contains(t, out, "Subject: "+msg.Subject)
+	require.Contains(t, out, msg.Body)
+}
+
+func TestFileMailer(t *testing.T) {
+	dir := t.TempDir()
+	mailer, err := NewFileMailer(util.RandomEmail(), dir)
+	require.NoError(t, err)
+
+	msg1 := randomMessage()
+	msg2 := randomMessage()
+	require.NoError(t, mailer.Send(context.Background(), msg1))
+	require.NoError(t, mailer.Send(context.Background(), msg2))
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	var contents string
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		require.NoError(t, err)
+		contents += string(data)
+	}
+	require.Contains(t, contents, msg1.Body)
+	require.Contains(t, contents, msg2.Body)
+}
+
+func TestFileMailerNoDir(t *testing.T) {
+	mailer, err := NewFileMailer(util.RandomEmail(), "")
+	require.Error(t, err)
+	require.Nil(t, mailer)
+}
+
+func TestNewMailer(t *testing.T) {
+	mailer, err := New(util.Config{MailerBackend: "stdout"})
+	require.NoError(t, err)
+	require.IsType(t, &WriterMailer{}, mailer)
+
+	mailer, err = New(util.Config{MailerBackend: "file", MailDir: t.TempDir()})
+	require.NoError(t, err)
+	require.IsType(t, &FileMailer{}, mailer)
+
+	mailer, err = New(util.Config{MailerBackend: "smtp", SMTPHost: "localhost", SMTPPort: 25})
+	require.NoError(t, err)
+	require.IsType(t, &SMTPMailer{}, mailer)
+
+	_, err = New(util.Config{MailerBackend: "carrier-pigeon"})
+	require.Error(t, err)
+}
@@ -0,0 +1,52 @@
+// Package mail sends transactional emails such as account verification links.
+package mail
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/danglebary/beatstore-backend-go/util"
+)
+
+// Message is a plain text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer is an interface for sending emails
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New creates the Mailer selected by config.MailerBackend
+func New(config util.Config) (Mailer, error) {
+	switch config.MailerBackend {
+	case "", "stdout":
+		return NewStdoutMailer(config.MailFrom), nil
+	case "file":
+		return NewFileMailer(config.MailFrom, config.MailDir)
+	case "smtp":
+		return NewSMTPMailer(config.SMTPHost, config.SMTPPort, config.SMTPUsername, config.SMTPPassword, config.MailFrom), nil
+	default:
+		return nil, fmt.Errorf("unknown mailer backend %q", config.MailerBackend)
+	}
+}
+
+// buildMessage renders msg as an RFC 5322 message
+func buildMessage(from string, msg Message) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(msg.Body)
+	b.WriteString("\r\n")
+	return b.Bytes()
+}
@@ -6,6 +6,9 @@ import (
 
 	"github.com/danglebary/beatstore-backend-go/api"
 	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
+	"github.com/danglebary/beatstore-backend-go/mail"
+	"github.com/danglebary/beatstore-backend-go/payment"
+	"github.com/danglebary/beatstore-backend-go/storage"
 	"github.com/danglebary/beatstore-backend-go/util"
 	_ "github.com/lib/pq"
 )
@@ -21,8 +24,26 @@ func main() {
 		log.Fatal("Failed to connect to db", err)
 	}
 
+	mailer, err := mail.New(config)
+	if err != nil {
+		log.Fatal("Failed to create the mailer", err)
+	}
+
+	blobs, err := storage.New(config)
+	if err != nil {
+		log.Fatal("Failed to create the blob store", err)
+	}
+
+	payments, err := payment.New(config)
+	if err != nil {
+		log.Fatal("Failed to create the payment provider", err)
+	}
+
 	store := db.NewStore(conn)
-	server := api.NewServer(store)
+	server, err := api.NewServer(config, store, mailer, blobs, payments)
+	if err != nil {
+		log.Fatal("Failed to create the server", err)
+	}
 
 	err = server.Start(config.ServerAddress)
 	if err != nil {
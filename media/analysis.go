@@ -0,0 +1,99 @@
+package media
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/danglebary/beatstore-backend-go/audio"
+	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
+	"github.com/danglebary/beatstore-backend-go/jobs"
+	"github.com/danglebary/beatstore-backend-go/util"
+)
+
+// analysisDuration is the length of audio analysed from the start of a master,
+// tempo and key rarely change after that and it keeps memory use bounded
+const analysisDuration = 2 * time.Minute
+
+// Detected values below mismatchConfidence are too unreliable to flag the entered ones
+const mismatchConfidence = 0.5
+
+// The entered tempo may be off by bpmTolerance of itself, or at least minBpmTolerance, before it is flagged
+const (
+	bpmTolerance    = 0.04
+	minBpmTolerance = 2
+)
+
+// AnalysisJob returns the job estimating tempo and key of the master WAV stored under masterKey
+func (processor *Processor) AnalysisJob(beatID int32, masterKey string) jobs.Job {
+	return jobs.Job{
+		Name: fmt.Sprintf("analysis of beat %d", beatID),
+		Run: func(ctx context.Context) error {
+			return processor.Analyze(ctx, beatID, masterKey)
+		},
+	}
+}
+
+// Analyze estimates tempo and key of the master WAV and stores them next to the entered ones.
+// If the beat got new audio in the meantime the result is thrown away.
+func (processor *Processor) Analyze(ctx context.Context, beatID int32, masterKey string) error {
+	r, err := processor.blobs.Get(ctx, masterKey)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	buf, err := audio.DecodeWAV(r, analysisDuration)
+	if err != nil {
+		return err
+	}
+
+	bpm, bpmConfidence := audio.DetectTempo(buf)
+	key, keyConfidence := audio.DetectKey(buf, util.MusicalKeys[:])
+
+	_, err = processor.store.UpdateBeatAnalysis(ctx, db.UpdateBeatAnalysisParams{
+		ID:                    beatID,
+		S3Key:                 masterKey,
+		DetectedBpm:           float32(bpm),
+		DetectedBpmConfidence: float32(bpmConfidence),
+		DetectedKey:           key,
+		DetectedKeyConfidence: float32(keyConfidence),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	return err
+}
+
+// BpmMismatch reports whether the detected tempo of beat confidently disagrees with the entered one.
+// Half and double the detected tempo are accepted, which of them is felt as the beat is a matter of taste.
+func BpmMismatch(beat db.Beat) bool {
+	if beat.DetectedBpm <= 0 || beat.DetectedBpmConfidence < mismatchConfidence {
+		return false
+	}
+
+	entered := float64(beat.Bpm)
+	tolerance := math.Max(minBpmTolerance, entered*bpmTolerance)
+	for _, detected := range []float64{float64(beat.DetectedBpm), float64(beat.DetectedBpm) * 2, float64(beat.DetectedBpm) / 2} {
+		if math.Abs(detected-entered) <= tolerance {
+			return false
+		}
+	}
+	return true
+}
+
+// KeyMismatch reports whether the detected key of beat confidently disagrees with the entered one.
+// The relative major or minor key shares all notes and is accepted.
+func KeyMismatch(beat db.Beat) bool {
+	if beat.DetectedKey == "" || beat.DetectedKeyConfidence < mismatchConfidence {
+		return false
+	}
+	if strings.EqualFold(beat.DetectedKey, beat.Key) {
+		return false
+	}
+	return !audio.RelativeKeys(beat.DetectedKey, beat.Key)
+}
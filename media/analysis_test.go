@@ -0,0 +1,162 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/danglebary/beatstore-backend-go/audio"
+	mockdb "github.com/danglebary/beatstore-backend-go/db/mock"
+	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
+	"github.com/danglebary/beatstore-backend-go/storage"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+// pulsingChord returns d of an A minor chord struck at bpm, each strike decaying until the next
+func pulsingChord(bpm float64, d time.Duration) *audio.Buffer {
+	const sampleRate = 44100
+	buf := audio.Tone(sampleRate, 1, 0, d, 0)
+	period := 60 / bpm * sampleRate
+	for _, freq := range []float64{220, 261.63, 329.63} {
+		for i := range buf.Samples {
+			sinceBeat := math.Mod(float64(i), period) / period
+			envelope := math.Exp(-sinceBeat * 4)
+			buf.Samples[i] += float32(0.25 * envelope * math.Sin(2*math.Pi*freq*float64(i)/sampleRate))
+		}
+	}
+	return buf
+}
+
+func TestAnalyze(t *testing.T) {
+	masterKey := "beats/7/audio/master.wav"
+
+	testCases := []struct {
+		name       string
+		master     []byte
+		buildStubs func(store *mockdb.MockStore)
+		checkError func(t *testing.T, err error)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateBeatAnalysis(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.UpdateBeatAnalysisParams) (db.Beat, error) {
+						require.Equal(t, int32(7), arg.ID)
+						require.Equal(t, masterKey, arg.S3Key)
+						require.InDelta(t, 100, arg.DetectedBpm, 1)
+						require.Greater(t, arg.DetectedBpmConfidence, float32(mismatchConfidence))
+						require.Equal(t, "A minor", arg.DetectedKey)
+						require.Greater(t, arg.DetectedKeyConfidence, float32(mismatchConfidence))
+						return db.Beat{}, nil
+					})
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:   "InvalidMaster",
+			master: []byte("RIFF\x24\x08\x00\x00WAVEfmt not really"),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateBeatAnalysis(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, audio.ErrInvalidWAV)
+			},
+		},
+		{
+			name: "MasterReplaced",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateBeatAnalysis(gomock.Any(), gomock.Any()).Times(1).Return(db.Beat{}, sql.ErrNoRows)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "InternalError",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateBeatAnalysis(gomock.Any(), gomock.Any()).Times(1).Return(db.Beat{}, sql.ErrConnDone)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, sql.ErrConnDone)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			blobs := storage.NewMemoryStore()
+			if tc.master != nil {
+				err := blobs.Put(context.Background(), masterKey, bytes.NewReader(tc.master), int64(len(tc.master)), audio.ContentTypeWAV)
+				require.NoError(t, err)
+			} else {
+				putWAV(t, blobs, masterKey, pulsingChord(100, 15*time.Second))
+			}
+
+			processor, err := NewProcessor(testConfig(), store, blobs)
+			require.NoError(t, err)
+			err = processor.AnalysisJob(7, masterKey).Run(context.Background())
+			tc.checkError(t, err)
+		})
+	}
+}
+
+func TestBpmMismatch(t *testing.T) {
+	testCases := []struct {
+		name       string
+		bpm        int16
+		detected   float32
+		confidence float32
+		mismatch   bool
+	}{
+		{name: "Match", bpm: 140, detected: 140.2, confidence: 0.9},
+		{name: "WithinTolerance", bpm: 140, detected: 145, confidence: 0.9},
+		{name: "HalfTime", bpm: 140, detected: 70.1, confidence: 0.9},
+		{name: "DoubleTime", bpm: 85, detected: 170, confidence: 0.9},
+		{name: "Mismatch", bpm: 140, detected: 128, confidence: 0.9, mismatch: true},
+		{name: "LowConfidence", bpm: 140, detected: 128, confidence: 0.3},
+		{name: "NotAnalyzed", bpm: 140},
+	}
+
+	for _, tc := range testCases {
+		beat := db.Beat{Bpm: tc.bpm, DetectedBpm: tc.detected, DetectedBpmConfidence: tc.confidence}
+		require.Equal(t, tc.mismatch, BpmMismatch(beat), tc.name)
+	}
+}
+
+func TestKeyMismatch(t *testing.T) {
+	testCases := []struct {
+		name       string
+		key        string
+		detected   string
+		confidence float32
+		mismatch   bool
+	}{
+		{name: "Match", key: "F# minor", detected: "F# minor", confidence: 0.9},
+		{name: "Case", key: "f# Minor", detected: "F# minor", confidence: 0.9},
+		{name: "RelativeKey", key: "A major", detected: "F# minor", confidence: 0.9},
+		{name: "Mismatch", key: "C major", detected: "F# minor", confidence: 0.9, mismatch: true},
+		{name: "ParallelKey", key: "F# major", detected: "F# minor", confidence: 0.9, mismatch: true},
+		{name: "LowConfidence", key: "C major", detected: "F# minor", confidence: 0.4},
+		{name: "NotAnalyzed", key: "C major"},
+	}
+
+	for _, tc := range testCases {
+		beat := db.Beat{Key: tc.key, DetectedKey: tc.detected, DetectedKeyConfidence: tc.confidence}
+		require.Equal(t, tc.mismatch, KeyMismatch(beat), tc.name)
+	}
+}
@@ -0,0 +1,96 @@
+package media
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/danglebary/beatstore-backend-go/audio"
+	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
+	"github.com/danglebary/beatstore-backend-go/jobs"
+	"github.com/danglebary/beatstore-backend-go/util"
+)
+
+// fingerprintDuration is the length of audio fingerprinted from the start of a master,
+// a re-upload shares minutes with the original even if the intro was cut
+const fingerprintDuration = 2 * time.Minute
+
+// maxFingerprintCandidates bounds the fingerprints compared with a new one
+const maxFingerprintCandidates = 100
+
+// FingerprintJob returns the job fingerprinting the master WAV stored under masterKey
+// and flagging beats of other creators it matches. MP3 masters get no fingerprint, only their content hash.
+func (processor *Processor) FingerprintJob(beatID int32, masterKey string) jobs.Job {
+	return jobs.Job{
+		Name: fmt.Sprintf("fingerprint of beat %d", beatID),
+		Run: func(ctx context.Context) error {
+			return processor.Fingerprint(ctx, beatID, masterKey)
+		},
+	}
+}
+
+// Fingerprint computes the acoustic fingerprint of the master WAV and stores it with the beat.
+// Audio uploaded earlier to beats of other creators which sounds the same is put into the moderation queue,
+// the upload itself stays attached until an admin decides. If the beat got new audio in the meantime
+// the fingerprint is thrown away.
+func (processor *Processor) Fingerprint(ctx context.Context, beatID int32, masterKey string) error {
+	r, err := processor.blobs.Get(ctx, masterKey)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	buf, err := audio.DecodeWAV(r, fingerprintDuration)
+	if err != nil {
+		return err
+	}
+
+	fingerprint := audio.NewFingerprint(buf)
+	hashes := fingerprint.Hashes()
+	_, err = processor.store.UpdateAudioFingerprint(ctx, db.UpdateAudioFingerprintParams{
+		BeatID:      beatID,
+		S3Key:       masterKey,
+		Fingerprint: audio.EncodeFingerprint(fingerprint),
+		Hashes:      hashes,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	// Silence matches nothing
+	if len(hashes) == 0 {
+		return nil
+	}
+
+	candidates, err := processor.store.ListFingerprintCandidates(ctx, db.ListFingerprintCandidatesParams{
+		Hashes:        hashes,
+		BeatID:        beatID,
+		MaxCandidates: maxFingerprintCandidates,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, candidate := range candidates {
+		bitErrorRate, match := audio.MatchFingerprints(fingerprint, audio.DecodeFingerprint(candidate.Fingerprint))
+		if !match {
+			continue
+		}
+
+		_, err := processor.store.CreateDuplicateMatch(ctx, db.CreateDuplicateMatchParams{
+			BeatID:        beatID,
+			MatchedBeatID: candidate.BeatID,
+			Kind:          util.FingerprintMatch,
+			BitErrorRate:  float32(bitErrorRate),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/danglebary/beatstore-backend-go/audio"
+	mockdb "github.com/danglebary/beatstore-backend-go/db/mock"
+	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
+	"github.com/danglebary/beatstore-backend-go/storage"
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+// randomNotes returns d of random notes, eight per second, the same for the same seed
+func randomNotes(seed int64, d time.Duration) *audio.Buffer {
+	const sampleRate = 44100
+	rng := rand.New(rand.NewSource(seed))
+	buf := audio.Tone(sampleRate, 1, 0, d, 0)
+
+	noteFrames := sampleRate / 8
+	freq := 0.0
+	for i := range buf.Samples {
+		if i%noteFrames == 0 {
+			freq = 200 * math.Pow(2, rng.Float64()*3.6)
+		}
+		envelope := math.Exp(-float64(i%noteFrames) / float64(noteFrames) * 3)
+		buf.Samples[i] = float32(0.5 * envelope * math.Sin(2*math.Pi*freq*float64(i)/sampleRate))
+	}
+	return buf
+}
+
+func TestFingerprint(t *testing.T) {
+	masterKey := "beats/7/audio/master.wav"
+	master := randomNotes(1, 20*time.Second)
+
+	// The master is fingerprinted as stored, with 16 bit samples
+	var stored bytes.Buffer
+	require.NoError(t, audio.EncodeWAV(&stored, master))
+	decoded, err := audio.DecodeWAV(&stored, 0)
+	require.NoError(t, err)
+	same := audio.EncodeFingerprint(audio.NewFingerprint(decoded))
+	different := audio.EncodeFingerprint(audio.NewFingerprint(randomNotes(2, 20*time.Second)))
+
+	updateFingerprint := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			UpdateAudioFingerprint(gomock.Any(), gomock.Any()).
+			Times(1).
+			DoAndReturn(func(_ context.Context, arg db.UpdateAudioFingerprintParams) (db.AudioFingerprint, error) {
+				require.Equal(t, int32(7), arg.BeatID)
+				require.Equal(t, masterKey, arg.S3Key)
+				require.Equal(t, same, arg.Fingerprint)
+				require.NotEmpty(t, arg.Hashes)
+				return db.AudioFingerprint{BeatID: arg.BeatID, S3Key: arg.S3Key}, nil
+			})
+	}
+
+	testCases := []struct {
+		name       string
+		master     []byte
+		buildStubs func(store *mockdb.MockStore)
+		checkError func(t *testing.T, err error)
+	}{
+		{
+			name: "Match",
+			buildStubs: func(store *mockdb.MockStore) {
+				updateFingerprint(store)
+				store.EXPECT().
+					ListFingerprintCandidates(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.ListFingerprintCandidatesParams) ([]db.ListFingerprintCandidatesRow, error) {
+						require.Equal(t, int32(7), arg.BeatID)
+						require.NotEmpty(t, arg.Hashes)
+						return []db.ListFingerprintCandidatesRow{
+							{BeatID: 3, Fingerprint: same},
+							{BeatID: 4, Fingerprint: different},
+						}, nil
+					})
+				store.EXPECT().
+					CreateDuplicateMatch(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateDuplicateMatchParams) (db.DuplicateMatch, error) {
+						require.Equal(t, int32(7), arg.BeatID)
+						require.Equal(t, int32(3), arg.MatchedBeatID)
+						require.Equal(t, util.FingerprintMatch, arg.Kind)
+						require.Less(t, arg.BitErrorRate, float32(audio.MaxBitErrorRate))
+						require.False(t, arg.Rejected)
+						return db.DuplicateMatch{}, nil
+					})
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "NoMatch",
+			buildStubs: func(store *mockdb.MockStore) {
+				updateFingerprint(store)
+				store.EXPECT().
+					ListFingerprintCandidates(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.ListFingerprintCandidatesRow{{BeatID: 4, Fingerprint: different}}, nil)
+				store.EXPECT().CreateDuplicateMatch(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:   "InvalidMaster",
+			master: []byte("RIFF\x24\x08\x00\x00WAVEfmt not really"),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateAudioFingerprint(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, audio.ErrInvalidWAV)
+			},
+		},
+		{
+			name: "MasterReplaced",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateAudioFingerprint(gomock.Any(), gomock.Any()).Times(1).Return(db.AudioFingerprint{}, sql.ErrNoRows)
+				store.EXPECT().ListFingerprintCandidates(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "InternalError",
+			buildStubs: func(store *mockdb.MockStore) {
+				updateFingerprint(store)
+				store.EXPECT().
+					ListFingerprintCandidates(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, sql.ErrConnDone)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			blobs := storage.NewMemoryStore()
+			if tc.master != nil {
+				err := blobs.Put(context.Background(), masterKey, bytes.NewReader(tc.master), int64(len(tc.master)), audio.ContentTypeWAV)
+				require.NoError(t, err)
+			} else {
+				putWAV(t, blobs, masterKey, master)
+			}
+
+			processor, err := NewProcessor(testConfig(), store, blobs)
+			require.NoError(t, err)
+			err = processor.FingerprintJob(7, masterKey).Run(context.Background())
+			tc.checkError(t, err)
+		})
+	}
+}
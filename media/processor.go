@@ -0,0 +1,151 @@
+// Package media processes uploaded beat audio in the background,
+// e.g. turning a master into the tagged preview listeners get to hear.
+package media
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/danglebary/beatstore-backend-go/audio"
+	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
+	"github.com/danglebary/beatstore-backend-go/jobs"
+	"github.com/danglebary/beatstore-backend-go/storage"
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/google/uuid"
+)
+
+// previewFadeOut is faded out at the end of every preview, so the cut doesn't click
+const previewFadeOut = 2 * time.Second
+
+// Processor generates derived files for uploaded beat audio
+type Processor struct {
+	store           db.Store
+	blobs           storage.BlobStore
+	tag             *audio.Buffer
+	previewDuration time.Duration
+	tagInterval     time.Duration
+	tagGain         float32
+}
+
+// NewProcessor creates a new Processor. The voice tag mixed into previews is read from
+// config.PreviewTagFile, without one a short two tone signal is used instead.
+func NewProcessor(config util.Config, store db.Store, blobs storage.BlobStore) (*Processor, error) {
+	if config.PreviewDuration <= 0 {
+		return nil, fmt.Errorf("invalid preview duration %s", config.PreviewDuration)
+	}
+	if config.PreviewTagInterval <= 0 {
+		return nil, fmt.Errorf("invalid preview tag interval %s", config.PreviewTagInterval)
+	}
+
+	tag := defaultTag()
+	if config.PreviewTagFile != "" {
+		var err error
+		tag, err = loadTag(config.PreviewTagFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load preview tag: %w", err)
+		}
+	}
+
+	return &Processor{
+		store:           store,
+		blobs:           blobs,
+		tag:             tag,
+		previewDuration: config.PreviewDuration,
+		tagInterval:     config.PreviewTagInterval,
+		tagGain:         float32(config.PreviewTagGain),
+	}, nil
+}
+
+func loadTag(name string) (*audio.Buffer, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return audio.DecodeWAV(file, 0)
+}
+
+// defaultTag is a rising pair of beeps
+func defaultTag() *audio.Buffer {
+	const sampleRate = 44100
+	tag := audio.Tone(sampleRate, 1, 660, 150*time.Millisecond, 0.8)
+	pause := audio.Tone(sampleRate, 1, 0, 100*time.Millisecond, 0)
+	tag.Samples = append(tag.Samples, pause.Samples...)
+	tag.Samples = append(tag.Samples, audio.Tone(sampleRate, 1, 880, 150*time.Millisecond, 0.8).Samples...)
+	return tag
+}
+
+// previewKey returns a new object key for the preview of a beat
+func previewKey(beatID int32) string {
+	return fmt.Sprintf("beats/%d/preview/%s.wav", beatID, uuid.New())
+}
+
+// PreviewJob returns the job generating the preview of the master WAV stored under masterKey
+func (processor *Processor) PreviewJob(beatID int32, masterKey string) jobs.Job {
+	return jobs.Job{
+		Name: fmt.Sprintf("preview of beat %d", beatID),
+		Run: func(ctx context.Context) error {
+			return processor.GeneratePreview(ctx, beatID, masterKey)
+		},
+	}
+}
+
+// GeneratePreview decodes the start of the master WAV, mixes in the voice tag at every tag interval
+// and stores the result as the preview of the beat, which is also attached as its tagged preview file.
+// If the beat got new audio in the meantime the preview is thrown away,
+// the job of the new audio takes care of its preview.
+func (processor *Processor) GeneratePreview(ctx context.Context, beatID int32, masterKey string) error {
+	r, err := processor.blobs.Get(ctx, masterKey)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	// Decoding stops at the preview length, the rest of the master is never read
+	buf, err := audio.DecodeWAV(r, processor.previewDuration)
+	if err != nil {
+		return err
+	}
+	buf.MixTag(processor.tag, 0, processor.tagInterval, processor.tagGain)
+	buf.FadeOut(previewFadeOut)
+
+	var out bytes.Buffer
+	if err := audio.EncodeWAV(&out, buf); err != nil {
+		return err
+	}
+
+	key := previewKey(beatID)
+	err = processor.blobs.Put(ctx, key, bytes.NewReader(out.Bytes()), int64(out.Len()), audio.ContentTypeWAV)
+	if err != nil {
+		return err
+	}
+
+	checksum := sha256.Sum256(out.Bytes())
+	_, err = processor.store.UpdateBeatPreviewTx(ctx, db.UpdateBeatPreviewTxParams{
+		BeatID:      beatID,
+		S3Key:       masterKey,
+		PreviewKey:  key,
+		ContentType: audio.ContentTypeWAV,
+		Size:        int64(out.Len()),
+		Checksum:    hex.EncodeToString(checksum[:]),
+	})
+	if err != nil {
+		// Don't leave an object behind which no beat points at
+		if deleteErr := processor.blobs.Delete(ctx, key); deleteErr != nil {
+			return fmt.Errorf("%v, cleanup failed: %w", err, deleteErr)
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
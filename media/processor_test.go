@@ -0,0 +1,204 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danglebary/beatstore-backend-go/audio"
+	mockdb "github.com/danglebary/beatstore-backend-go/db/mock"
+	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
+	"github.com/danglebary/beatstore-backend-go/storage"
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func testConfig() util.Config {
+	return util.Config{
+		PreviewDuration:    4 * time.Second,
+		PreviewTagInterval: time.Second,
+		PreviewTagGain:     0.5,
+	}
+}
+
+// putWAV stores buf as WAV file under key
+func putWAV(t *testing.T, blobs storage.BlobStore, key string, buf *audio.Buffer) {
+	var file bytes.Buffer
+	require.NoError(t, audio.EncodeWAV(&file, buf))
+	err := blobs.Put(context.Background(), key, &file, int64(file.Len()), audio.ContentTypeWAV)
+	require.NoError(t, err)
+}
+
+func TestGeneratePreview(t *testing.T) {
+	masterKey := "beats/7/audio/master.wav"
+
+	testCases := []struct {
+		name       string
+		master     []byte
+		buildStubs func(store *mockdb.MockStore)
+		check      func(t *testing.T, blobs storage.BlobStore, previewKey string, err error)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateBeatPreviewTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.UpdateBeatPreviewTxParams) (db.UpdateBeatPreviewTxResult, error) {
+						require.Equal(t, int32(7), arg.BeatID)
+						require.Equal(t, masterKey, arg.S3Key)
+						require.Equal(t, audio.ContentTypeWAV, arg.ContentType)
+						require.Len(t, arg.Checksum, 64)
+						return db.UpdateBeatPreviewTxResult{}, nil
+					})
+			},
+			check: func(t *testing.T, blobs storage.BlobStore, previewKey string, err error) {
+				require.NoError(t, err)
+				require.True(t, strings.HasPrefix(previewKey, "beats/7/preview/"))
+
+				info, err := blobs.Stat(context.Background(), previewKey)
+				require.NoError(t, err)
+				require.Equal(t, audio.ContentTypeWAV, info.ContentType)
+
+				r, err := blobs.Get(context.Background(), previewKey)
+				require.NoError(t, err)
+				defer r.Close()
+				preview, err := audio.DecodeWAV(r, 0)
+				require.NoError(t, err)
+
+				// Trimmed to the preview length, keeping the format of the master
+				require.Equal(t, 4*time.Second, preview.Duration())
+				require.Equal(t, 22050, preview.SampleRate)
+				require.Equal(t, 2, preview.Channels)
+
+				// The master is silent, so everything audible is the tag, once per second
+				for second := 0; second < 4; second++ {
+					start := second * 22050 * 2
+					require.NotZero(t, maxAbs(preview.Samples[start:start+22050]), "no tag at %ds", second)
+					require.Zero(t, maxAbs(preview.Samples[start+22050:start+2*22050]), "tag is longer than half a second")
+				}
+			},
+		},
+		{
+			name:   "InvalidMaster",
+			master: []byte("RIFF\x24\x08\x00\x00WAVEfmt not really"),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateBeatPreviewTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			check: func(t *testing.T, blobs storage.BlobStore, previewKey string, err error) {
+				require.ErrorIs(t, err, audio.ErrInvalidWAV)
+				require.Empty(t, previewKey)
+			},
+		},
+		{
+			name: "MasterReplaced",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateBeatPreviewTx(gomock.Any(), gomock.Any()).Times(1).Return(db.UpdateBeatPreviewTxResult{}, sql.ErrNoRows)
+			},
+			check: func(t *testing.T, blobs storage.BlobStore, previewKey string, err error) {
+				// Nothing went wrong, the preview just isn't needed anymore
+				require.NoError(t, err)
+				require.Empty(t, previewKey)
+			},
+		},
+		{
+			name: "InternalError",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateBeatPreviewTx(gomock.Any(), gomock.Any()).Times(1).Return(db.UpdateBeatPreviewTxResult{}, sql.ErrConnDone)
+			},
+			check: func(t *testing.T, blobs storage.BlobStore, previewKey string, err error) {
+				require.ErrorIs(t, err, sql.ErrConnDone)
+				require.Empty(t, previewKey)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			blobs := storage.NewMemoryStore()
+			if tc.master != nil {
+				err := blobs.Put(context.Background(), masterKey, bytes.NewReader(tc.master), int64(len(tc.master)), audio.ContentTypeWAV)
+				require.NoError(t, err)
+			} else {
+				// Ten seconds of silence
+				putWAV(t, blobs, masterKey, audio.Tone(22050, 2, 0, 10*time.Second, 0))
+			}
+
+			config := testConfig()
+			config.PreviewTagFile = filepath.Join(t.TempDir(), "tag.wav")
+			var tag bytes.Buffer
+			require.NoError(t, audio.EncodeWAV(&tag, audio.Tone(44100, 1, 1000, 400*time.Millisecond, 0.5)))
+			require.NoError(t, ioutil.WriteFile(config.PreviewTagFile, tag.Bytes(), 0o644))
+
+			processor, err := NewProcessor(config, store, blobs)
+			require.NoError(t, err)
+			err = processor.PreviewJob(7, masterKey).Run(context.Background())
+
+			// Only the master and a preview the beat points at may be left
+			infos, listErr := blobs.List(context.Background(), "beats/7/preview/")
+			require.NoError(t, listErr)
+			previewKey := ""
+			if len(infos) > 0 {
+				require.Len(t, infos, 1)
+				previewKey = infos[0].Key
+			}
+			tc.check(t, blobs, previewKey, err)
+		})
+	}
+}
+
+func maxAbs(samples []float32) float32 {
+	var max float32
+	for _, s := range samples {
+		if s < 0 {
+			s = -s
+		}
+		if s > max {
+			max = s
+		}
+	}
+	return max
+}
+
+func TestNewProcessor(t *testing.T) {
+	blobs := storage.NewMemoryStore()
+
+	// The built in tag is used without a tag file
+	processor, err := NewProcessor(testConfig(), nil, blobs)
+	require.NoError(t, err)
+	require.NotZero(t, processor.tag.Frames())
+
+	config := testConfig()
+	config.PreviewTagFile = filepath.Join(t.TempDir(), "missing.wav")
+	_, err = NewProcessor(config, nil, blobs)
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	config.PreviewTagFile = filepath.Join(t.TempDir(), "tag.mp3")
+	require.NoError(t, ioutil.WriteFile(config.PreviewTagFile, []byte("ID3\x04\x00\x00\x00\x00\x00\x00"), 0o644))
+	_, err = NewProcessor(config, nil, blobs)
+	require.ErrorIs(t, err, audio.ErrInvalidWAV)
+
+	config = testConfig()
+	config.PreviewDuration = 0
+	_, err = NewProcessor(config, nil, blobs)
+	require.Error(t, err)
+
+	config = testConfig()
+	config.PreviewTagInterval = 0
+	_, err = NewProcessor(config, nil, blobs)
+	require.Error(t, err)
+}
@@ -0,0 +1,53 @@
+package media
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/danglebary/beatstore-backend-go/audio"
+	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
+	"github.com/danglebary/beatstore-backend-go/jobs"
+)
+
+// WaveformResolution is the number of peaks stored per beat, very short audio gets fewer.
+// Clients ask for a lower resolution which is computed from the stored peaks.
+const WaveformResolution = 4096
+
+// WaveformJob returns the job computing the waveform of the master WAV stored under masterKey
+func (processor *Processor) WaveformJob(beatID int32, masterKey string) jobs.Job {
+	return jobs.Job{
+		Name: fmt.Sprintf("waveform of beat %d", beatID),
+		Run: func(ctx context.Context) error {
+			return processor.GenerateWaveform(ctx, beatID, masterKey)
+		},
+	}
+}
+
+// GenerateWaveform computes the peaks of the whole master WAV and stores them with the beat.
+// If the beat got new audio in the meantime the peaks are thrown away.
+func (processor *Processor) GenerateWaveform(ctx context.Context, beatID int32, masterKey string) error {
+	r, err := processor.blobs.Get(ctx, masterKey)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	peaks, duration, err := audio.ReadPeaks(r, WaveformResolution)
+	if err != nil {
+		return err
+	}
+
+	_, err = processor.store.UpsertBeatWaveform(ctx, db.UpsertBeatWaveformParams{
+		BeatID:     beatID,
+		S3Key:      masterKey,
+		Resolution: int32(len(peaks)),
+		DurationMs: duration.Milliseconds(),
+		Peaks:      audio.EncodePeaks(peaks),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	return err
+}
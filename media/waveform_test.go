@@ -0,0 +1,112 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/danglebary/beatstore-backend-go/audio"
+	mockdb "github.com/danglebary/beatstore-backend-go/db/mock"
+	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
+	"github.com/danglebary/beatstore-backend-go/storage"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateWaveform(t *testing.T) {
+	masterKey := "beats/7/audio/master.wav"
+
+	testCases := []struct {
+		name       string
+		master     []byte
+		buildStubs func(store *mockdb.MockStore)
+		checkError func(t *testing.T, err error)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpsertBeatWaveform(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.UpsertBeatWaveformParams) (db.BeatWaveform, error) {
+						require.Equal(t, int32(7), arg.BeatID)
+						require.Equal(t, masterKey, arg.S3Key)
+						require.Equal(t, int32(WaveformResolution), arg.Resolution)
+						require.Equal(t, int64(10000), arg.DurationMs)
+
+						// The master gets louder every second
+						peaks := audio.DecodePeaks(arg.Peaks)
+						require.Len(t, peaks, WaveformResolution)
+						for second, peak := range audio.DownsamplePeaks(peaks, 10) {
+							amplitude := float64(second+1) / 10
+							require.InDelta(t, amplitude, peak.Max, 0.01)
+							require.InDelta(t, -amplitude, peak.Min, 0.01)
+						}
+						return db.BeatWaveform{}, nil
+					})
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:   "InvalidMaster",
+			master: []byte("RIFF\x24\x08\x00\x00WAVEfmt not really"),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpsertBeatWaveform(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, audio.ErrInvalidWAV)
+			},
+		},
+		{
+			name: "MasterReplaced",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpsertBeatWaveform(gomock.Any(), gomock.Any()).Times(1).Return(db.BeatWaveform{}, sql.ErrNoRows)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "InternalError",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpsertBeatWaveform(gomock.Any(), gomock.Any()).Times(1).Return(db.BeatWaveform{}, sql.ErrConnDone)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, sql.ErrConnDone)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			blobs := storage.NewMemoryStore()
+			if tc.master != nil {
+				err := blobs.Put(context.Background(), masterKey, bytes.NewReader(tc.master), int64(len(tc.master)), audio.ContentTypeWAV)
+				require.NoError(t, err)
+			} else {
+				master := &audio.Buffer{SampleRate: 44100, Channels: 2}
+				for second := 1; second <= 10; second++ {
+					tone := audio.Tone(44100, 2, 440, time.Second, float32(second)/10)
+					master.Samples = append(master.Samples, tone.Samples...)
+				}
+				putWAV(t, blobs, masterKey, master)
+			}
+
+			processor, err := NewProcessor(testConfig(), store, blobs)
+			require.NoError(t, err)
+			err = processor.WaveformJob(7, masterKey).Run(context.Background())
+			tc.checkError(t, err)
+		})
+	}
+}
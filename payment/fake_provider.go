@@ -0,0 +1,121 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Payment methods understood by FakeProvider, like the test cards of real providers
+const (
+	FakeCardSucceeds = "fake_card_succeeds"
+	FakeCardDeclined = "fake_card_declined"
+	// FakeCardCaptureDeclined authorizes intents but declines capturing them
+	FakeCardCaptureDeclined = "fake_card_capture_declined"
+	// FakeCardUnavailable fails creating intents as if the provider couldn't be reached
+	FakeCardUnavailable = "fake_card_unavailable"
+)
+
+// ErrFakeUnavailable is returned by FakeProvider for intents paid with FakeCardUnavailable
+var ErrFakeUnavailable = errors.New("fake payment provider is unavailable")
+
+type fakeIntent struct {
+	intent        Intent
+	paymentMethod string
+}
+
+// FakeProvider processes payments in memory without talking to a provider. It is deterministic,
+// the outcome of a payment only depends on its payment method and intent IDs on the idempotency keys,
+// which lets the whole checkout run offline in development and tests.
+// Payment methods other than the fake cards are declined.
+type FakeProvider struct {
+	mu      sync.Mutex
+	intents map[string]*fakeIntent
+}
+
+// NewFakeProvider creates a new FakeProvider without intents
+func NewFakeProvider() *FakeProvider {
+	return &FakeProvider{intents: make(map[string]*fakeIntent)}
+}
+
+// CreateIntent authorizes the payment unless the payment method is declined.
+// An intent created before with the same idempotency key is returned as it is.
+func (provider *FakeProvider) CreateIntent(ctx context.Context, params IntentParams) (Intent, error) {
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+
+	id := fmt.Sprintf("pi_fake_%s", params.IdempotencyKey)
+	if existing, ok := provider.intents[id]; ok {
+		return existing.intent, nil
+	}
+
+	if params.PaymentMethod == FakeCardUnavailable {
+		return Intent{}, ErrFakeUnavailable
+	}
+	if params.PaymentMethod != FakeCardSucceeds && params.PaymentMethod != FakeCardCaptureDeclined {
+		return Intent{}, ErrDeclined
+	}
+
+	intent := Intent{
+		ID:       id,
+		Amount:   params.Amount,
+		Currency: params.Currency,
+		Status:   IntentRequiresCapture,
+	}
+	provider.intents[id] = &fakeIntent{intent: intent, paymentMethod: params.PaymentMethod}
+	return intent, nil
+}
+
+// Capture moves the money of an authorized intent
+func (provider *FakeProvider) Capture(ctx context.Context, intentID string) (Intent, error) {
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+
+	stored, ok := provider.intents[intentID]
+	if !ok {
+		return Intent{}, ErrIntentNotFound
+	}
+	if stored.intent.Status != IntentRequiresCapture {
+		return Intent{}, ErrInvalidIntentStatus
+	}
+	if stored.paymentMethod == FakeCardCaptureDeclined {
+		return Intent{}, ErrDeclined
+	}
+
+	stored.intent.Status = IntentSucceeded
+	return stored.intent, nil
+}
+
+// Refund returns the whole amount of a captured intent
+func (provider *FakeProvider) Refund(ctx context.Context, intentID string) (Refund, error) {
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+
+	stored, ok := provider.intents[intentID]
+	if !ok {
+		return Refund{}, ErrIntentNotFound
+	}
+	if stored.intent.Status != IntentSucceeded {
+		return Refund{}, ErrInvalidIntentStatus
+	}
+
+	stored.intent.Status = IntentRefunded
+	return Refund{
+		ID:       fmt.Sprintf("re_fake_%s", intentID),
+		IntentID: intentID,
+		Amount:   stored.intent.Amount,
+	}, nil
+}
+
+// Intent returns the intent with the given ID, tests use it to check what was charged
+func (provider *FakeProvider) Intent(intentID string) (Intent, bool) {
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+
+	stored, ok := provider.intents[intentID]
+	if !ok {
+		return Intent{}, false
+	}
+	return stored.intent, true
+}
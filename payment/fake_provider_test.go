@@ -0,0 +1,105 @@
+package payment
+
+import (
+	"context"
+	"testing"
+
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeProvider(t *testing.T) {
+	provider := NewFakeProvider()
+	params := IntentParams{
+		Amount:         util.RandomInt(1, 100000),
+		Currency:       "USD",
+		PaymentMethod:  FakeCardSucceeds,
+		IdempotencyKey: util.RandomString(12),
+	}
+
+	intent1, err := provider.CreateIntent(context.Background(), params)
+	require.NoError(t, err)
+	require.NotEmpty(t, intent1.ID)
+	require.Equal(t, params.Amount, intent1.Amount)
+	require.Equal(t, params.Currency, intent1.Currency)
+	require.Equal(t, IntentRequiresCapture, intent1.Status)
+
+	// Refunds need captured money
+	_, err = provider.Refund(context.Background(), intent1.ID)
+	require.ErrorIs(t, err, ErrInvalidIntentStatus)
+
+	// Retrying with the same key doesn't authorize twice
+	intent2, err := provider.CreateIntent(context.Background(), params)
+	require.NoError(t, err)
+	require.Equal(t, intent1, intent2)
+
+	intent3, err := provider.Capture(context.Background(), intent1.ID)
+	require.NoError(t, err)
+	require.Equal(t, IntentSucceeded, intent3.Status)
+
+	_, err = provider.Capture(context.Background(), intent1.ID)
+	require.ErrorIs(t, err, ErrInvalidIntentStatus)
+
+	refund, err := provider.Refund(context.Background(), intent1.ID)
+	require.NoError(t, err)
+	require.NotEmpty(t, refund.ID)
+	require.Equal(t, intent1.ID, refund.IntentID)
+	require.Equal(t, params.Amount, refund.Amount)
+
+	intent4, ok := provider.Intent(intent1.ID)
+	require.True(t, ok)
+	require.Equal(t, IntentRefunded, intent4.Status)
+
+	_, err = provider.Refund(context.Background(), intent1.ID)
+	require.ErrorIs(t, err, ErrInvalidIntentStatus)
+}
+
+func TestFakeProviderDeclined(t *testing.T) {
+	provider := NewFakeProvider()
+
+	for _, paymentMethod := range []string{FakeCardDeclined, util.RandomString(16)} {
+		_, err := provider.CreateIntent(context.Background(), IntentParams{
+			Amount:         100,
+			Currency:       "USD",
+			PaymentMethod:  paymentMethod,
+			IdempotencyKey: util.RandomString(12),
+		})
+		require.ErrorIs(t, err, ErrDeclined)
+	}
+
+	// The capture of an authorized intent can be declined as well
+	intent, err := provider.CreateIntent(context.Background(), IntentParams{
+		Amount:         100,
+		Currency:       "USD",
+		PaymentMethod:  FakeCardCaptureDeclined,
+		IdempotencyKey: util.RandomString(12),
+	})
+	require.NoError(t, err)
+	_, err = provider.Capture(context.Background(), intent.ID)
+	require.ErrorIs(t, err, ErrDeclined)
+
+	_, err = provider.Capture(context.Background(), "pi_unknown")
+	require.ErrorIs(t, err, ErrIntentNotFound)
+	_, err = provider.Refund(context.Background(), "pi_unknown")
+	require.ErrorIs(t, err, ErrIntentNotFound)
+
+	_, err = provider.CreateIntent(context.Background(), IntentParams{
+		Amount:         100,
+		Currency:       "USD",
+		PaymentMethod:  FakeCardUnavailable,
+		IdempotencyKey: util.RandomString(12),
+	})
+	require.ErrorIs(t, err, ErrFakeUnavailable)
+	require.NotErrorIs(t, err, ErrDeclined)
+}
+
+func TestNew(t *testing.T) {
+	provider, err := New(util.Config{PaymentProvider: "fake"})
+	require.NoError(t, err)
+	require.IsType(t, &FakeProvider{}, provider)
+
+	for _, name := range []string{"", "unknown"} {
+		_, err := New(util.Config{PaymentProvider: name})
+		require.Error(t, err)
+	}
+}
@@ -0,0 +1,74 @@
+// Package payment charges buyers through a payment service provider. Payments follow the flow of
+// card networks: an intent authorizes the amount, capturing it moves the money and a refund returns it.
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/danglebary/beatstore-backend-go/util"
+)
+
+// Statuses of a payment intent
+const (
+	// IntentRequiresCapture is an authorized intent whose money hasn't been moved yet
+	IntentRequiresCapture = "requires_capture"
+	IntentSucceeded       = "succeeded"
+	IntentRefunded        = "refunded"
+)
+
+var (
+	// ErrDeclined is returned when the payment method of the buyer is declined
+	ErrDeclined = errors.New("payment was declined")
+	// ErrIntentNotFound is returned for an intent the provider doesn't know about
+	ErrIntentNotFound = errors.New("payment intent not found")
+	// ErrInvalidIntentStatus is returned when an intent can't be captured or refunded in its status
+	ErrInvalidIntentStatus = errors.New("payment intent is not in a valid status for this operation")
+)
+
+// Intent is a payment of an amount in the minor unit of a currency
+type Intent struct {
+	ID       string
+	Amount   int64
+	Currency string
+	Status   string
+}
+
+// Refund returns the captured amount of an intent to the buyer
+type Refund struct {
+	ID       string
+	IntentID string
+	Amount   int64
+}
+
+// IntentParams describes the payment to authorize
+type IntentParams struct {
+	Amount   int64
+	Currency string
+	// PaymentMethod is the token the client got from the provider for the card of the buyer
+	PaymentMethod string
+	// IdempotencyKey makes retries return the intent created first instead of charging twice
+	IdempotencyKey string
+}
+
+// Provider is a payment service provider
+type Provider interface {
+	// CreateIntent authorizes a payment on the payment method, the money isn't moved until it is captured
+	CreateIntent(ctx context.Context, params IntentParams) (Intent, error)
+	// Capture moves the authorized money of an intent
+	Capture(ctx context.Context, intentID string) (Intent, error)
+	// Refund returns the money of a captured intent
+	Refund(ctx context.Context, intentID string) (Refund, error)
+}
+
+// New creates the Provider selected by config.PaymentProvider. There is no default,
+// a misconfigured server must not hand out beats through the fake provider.
+func New(config util.Config) (Provider, error) {
+	switch config.PaymentProvider {
+	case "fake":
+		return NewFakeProvider(), nil
+	default:
+		return nil, fmt.Errorf("unknown payment provider %q", config.PaymentProvider)
+	}
+}
@@ -0,0 +1,135 @@
+package payment
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignatureHeader is the request header carrying the signature of a webhook event
+const SignatureHeader = "Payment-Signature"
+
+const minWebhookSecretSize = 32
+
+// Types of the webhook events which settle payments
+const (
+	EventIntentSucceeded = "payment_intent.succeeded"
+	EventIntentFailed    = "payment_intent.payment_failed"
+	EventIntentRefunded  = "payment_intent.refunded"
+)
+
+// Errors returned by WebhookVerifier.Verify and ParseEvent
+var (
+	ErrInvalidSignature = errors.New("webhook signature is invalid")
+	ErrExpiredSignature = errors.New("webhook signature is outside of the tolerance")
+	ErrInvalidEvent     = errors.New("webhook event is invalid")
+)
+
+// Event is a notification of the provider about a change of an intent.
+// Events are delivered at least once, the ID is the same for every delivery.
+type Event struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	IntentID string `json:"intent_id"`
+	// FailureReason explains why the payment failed, only set for EventIntentFailed
+	FailureReason string `json:"failure_reason,omitempty"`
+}
+
+// ParseEvent decodes the payload of a webhook request. Events of types not listed above
+// are valid, receivers are expected to ignore them.
+func ParseEvent(payload []byte) (Event, error) {
+	var event Event
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return Event{}, fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+	}
+	if event.ID == "" || event.Type == "" || event.IntentID == "" {
+		return Event{}, fmt.Errorf("%w: id, type and intent_id are required", ErrInvalidEvent)
+	}
+	return event, nil
+}
+
+// WebhookVerifier signs and verifies webhook payloads with the secret shared with the provider.
+// The signature header has the form "t=<unix timestamp>,v1=<hex HMAC-SHA256 of timestamp.payload>",
+// signatures older or newer than the tolerance are rejected so captured requests can't be replayed.
+type WebhookVerifier struct {
+	secret    []byte
+	tolerance time.Duration
+}
+
+// NewWebhookVerifier creates a new WebhookVerifier for the shared secret
+func NewWebhookVerifier(secret string, tolerance time.Duration) (*WebhookVerifier, error) {
+	if len(secret) < minWebhookSecretSize {
+		return nil, fmt.Errorf("invalid secret size: must be at least %d characters", minWebhookSecretSize)
+	}
+	if tolerance <= 0 {
+		return nil, fmt.Errorf("invalid tolerance: must be positive")
+	}
+	return &WebhookVerifier{secret: []byte(secret), tolerance: tolerance}, nil
+}
+
+func (verifier *WebhookVerifier) mac(timestamp int64, payload []byte) []byte {
+	mac := hmac.New(sha256.New, verifier.secret)
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// Sign returns the signature header of payload sent at timestamp, like the provider computes it
+func (verifier *WebhookVerifier) Sign(payload []byte, timestamp time.Time) string {
+	return fmt.Sprintf("t=%d,v1=%s", timestamp.Unix(), hex.EncodeToString(verifier.mac(timestamp.Unix(), payload)))
+}
+
+// Verify checks that header is a signature of payload made within the tolerance of now.
+// Providers rotating their secret send several v1 signatures, one of them has to match.
+func (verifier *WebhookVerifier) Verify(payload []byte, header string, now time.Time) error {
+	var timestamp int64
+	var signatures [][]byte
+	for _, part := range strings.Split(header, ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			return ErrInvalidSignature
+		}
+		switch kv[0] {
+		case "t":
+			t, err := strconv.ParseInt(kv[1], 10, 64)
+			if err != nil {
+				return ErrInvalidSignature
+			}
+			timestamp = t
+		case "v1":
+			signature, err := hex.DecodeString(kv[1])
+			if err != nil {
+				return ErrInvalidSignature
+			}
+			signatures = append(signatures, signature)
+		}
+	}
+	if timestamp == 0 || len(signatures) == 0 {
+		return ErrInvalidSignature
+	}
+
+	expected := verifier.mac(timestamp, payload)
+	valid := false
+	for _, signature := range signatures {
+		if hmac.Equal(signature, expected) {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return ErrInvalidSignature
+	}
+
+	age := now.Sub(time.Unix(timestamp, 0))
+	if age > verifier.tolerance || age < -verifier.tolerance {
+		return ErrExpiredSignature
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package payment
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookVerifier(t *testing.T) {
+	verifier, err := NewWebhookVerifier(util.RandomString(32), 5*time.Minute)
+	require.NoError(t, err)
+
+	payload := []byte(`{"id":"evt_1","type":"payment_intent.succeeded","intent_id":"pi_1"}`)
+	now := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	header := verifier.Sign(payload, now)
+	require.True(t, strings.HasPrefix(header, "t="))
+	require.NoError(t, verifier.Verify(payload, header, now))
+	require.NoError(t, verifier.Verify(payload, header, now.Add(5*time.Minute)))
+	require.NoError(t, verifier.Verify(payload, header, now.Add(-5*time.Minute)))
+
+	// Captured requests can't be replayed later on
+	require.ErrorIs(t, verifier.Verify(payload, header, now.Add(6*time.Minute)), ErrExpiredSignature)
+	require.ErrorIs(t, verifier.Verify(payload, header, now.Add(-6*time.Minute)), ErrExpiredSignature)
+
+	// The payload and the timestamp are both signed
+	require.ErrorIs(t, verifier.Verify([]byte(`{"id":"evt_2"}`), header, now), ErrInvalidSignature)
+	forged := strings.Replace(header, "t=", "t=1", 1)
+	require.ErrorIs(t, verifier.Verify(payload, forged, now), ErrInvalidSignature)
+
+	// Signatures of another secret are rejected
+	other, err := NewWebhookVerifier(util.RandomString(32), 5*time.Minute)
+	require.NoError(t, err)
+	require.ErrorIs(t, other.Verify(payload, header, now), ErrInvalidSignature)
+
+	// One of several signatures has to match while the secret is rotated
+	rotated := other.Sign(payload, now) + "," + strings.Split(header, ",")[1]
+	require.NoError(t, verifier.Verify(payload, rotated, now))
+
+	for _, invalid := range []string{"", "t=abc", "v1=00", "t=1,v1=zz", "t=1;v1=00", "foo"} {
+		require.ErrorIs(t, verifier.Verify(payload, invalid, now), ErrInvalidSignature, invalid)
+	}
+}
+
+func TestNewWebhookVerifier(t *testing.T) {
+	_, err := NewWebhookVerifier(util.RandomString(31), time.Minute)
+	require.Error(t, err)
+
+	_, err = NewWebhookVerifier(util.RandomString(32), 0)
+	require.Error(t, err)
+}
+
+func TestParseEvent(t *testing.T) {
+	event, err := ParseEvent([]byte(`{"id":"evt_1","type":"payment_intent.payment_failed","intent_id":"pi_1","failure_reason":"insufficient funds"}`))
+	require.NoError(t, err)
+	require.Equal(t, Event{
+		ID:            "evt_1",
+		Type:          EventIntentFailed,
+		IntentID:      "pi_1",
+		FailureReason: "insufficient funds",
+	}, event)
+
+	for _, invalid := range []string{
+		``,
+		`[]`,
+		`{"type":"payment_intent.succeeded","intent_id":"pi_1"}`,
+		`{"id":"evt_1","intent_id":"pi_1"}`,
+		`{"id":"evt_1","type":"payment_intent.succeeded"}`,
+	} {
+		_, err := ParseEvent([]byte(invalid))
+		require.ErrorIs(t, err, ErrInvalidEvent, invalid)
+	}
+}
@@ -0,0 +1,82 @@
+// Package policy decides whether an authenticated user may mutate a resource.
+// Users may always mutate the resources they own, admins may mutate any resource
+// except for the credentials of other users.
+package policy
+
+import (
+	"errors"
+
+	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
+	"github.com/danglebary/beatstore-backend-go/util"
+)
+
+// ErrForbidden is returned when the subject is not allowed to mutate the resource
+var ErrForbidden = errors.New("user is not allowed to modify this resource")
+
+// Subject is the authenticated user performing an action
+type Subject struct {
+	UserID int32
+	Role   string
+}
+
+// CanModifyUser checks if subject may change the account with the given ID
+func CanModifyUser(subject Subject, userID int32) error {
+	return authorizeOwner(subject, userID)
+}
+
+// CanChangeCredentials checks if subject may change the email address or password of the account
+// with the given ID. Only the account owner may, an admin taking over an account would lock its owner out.
+func CanChangeCredentials(subject Subject, userID int32) error {
+	return authorizeSelf(subject, userID)
+}
+
+// CanModifyBeat checks if subject may change beat
+func CanModifyBeat(subject Subject, beat db.Beat) error {
+	return authorizeOwner(subject, beat.CreatorID)
+}
+
+// CanModifyLike checks if subject may change like
+func CanModifyLike(subject Subject, like db.Like) error {
+	return authorizeOwner(subject, like.UserID)
+}
+
+// CanModifyUpload checks if subject may continue, finalize or cancel upload
+func CanModifyUpload(subject Subject, upload db.Upload) error {
+	return authorizeOwner(subject, upload.UserID)
+}
+
+// CanCreateAPIKey checks if subject may create an API key for the account with the given ID.
+// Keys act on behalf of their owner, so not even admins may create them for other users.
+func CanCreateAPIKey(subject Subject, userID int32) error {
+	return authorizeSelf(subject, userID)
+}
+
+// CanModifyAPIKey checks if subject may see or revoke key
+func CanModifyAPIKey(subject Subject, key db.ApiKey) error {
+	return authorizeOwner(subject, key.UserID)
+}
+
+// CanManageMFA checks if subject may enroll a second factor for the account with the given ID,
+// only the account owner holds the authenticator so admins may not do it for them
+func CanManageMFA(subject Subject, userID int32) error {
+	return authorizeSelf(subject, userID)
+}
+
+// CanAccessOrder checks if subject may see order
+func CanAccessOrder(subject Subject, order db.Order) error {
+	return authorizeOwner(subject, order.BuyerID)
+}
+
+func authorizeOwner(subject Subject, ownerID int32) error {
+	if subject.Role == util.AdminRole || subject.UserID == ownerID {
+		return nil
+	}
+	return ErrForbidden
+}
+
+func authorizeSelf(subject Subject, userID int32) error {
+	if subject.UserID == userID {
+		return nil
+	}
+	return ErrForbidden
+}
@@ -0,0 +1,99 @@
+package policy
+
+import (
+	"testing"
+
+	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanModifyUser(t *testing.T) {
+	userID := int32(util.RandomInt(1, 1000))
+
+	require.NoError(t, CanModifyUser(Subject{UserID: userID, Role: util.ProducerRole}, userID))
+	require.NoError(t, CanModifyUser(Subject{UserID: userID + 1, Role: util.AdminRole}, userID))
+	require.ErrorIs(t, CanModifyUser(Subject{UserID: userID + 1, Role: util.ProducerRole}, userID), ErrForbidden)
+}
+
+func TestCanChangeCredentials(t *testing.T) {
+	userID := int32(util.RandomInt(1, 1000))
+
+	require.NoError(t, CanChangeCredentials(Subject{UserID: userID, Role: util.ListenerRole}, userID))
+	require.ErrorIs(t, CanChangeCredentials(Subject{UserID: userID + 1, Role: util.AdminRole}, userID), ErrForbidden)
+	require.ErrorIs(t, CanChangeCredentials(Subject{UserID: userID + 1, Role: util.ProducerRole}, userID), ErrForbidden)
+}
+
+func TestCanModifyBeat(t *testing.T) {
+	beat := db.Beat{
+		ID:        int32(util.RandomInt(1, 1000)),
+		CreatorID: int32(util.RandomInt(1, 1000)),
+	}
+
+	require.NoError(t, CanModifyBeat(Subject{UserID: beat.CreatorID, Role: util.ProducerRole}, beat))
+	require.NoError(t, CanModifyBeat(Subject{UserID: beat.CreatorID + 1, Role: util.AdminRole}, beat))
+	require.ErrorIs(t, CanModifyBeat(Subject{UserID: beat.CreatorID + 1, Role: util.ProducerRole}, beat), ErrForbidden)
+}
+
+func TestCanModifyLike(t *testing.T) {
+	like := db.Like{
+		ID:     int32(util.RandomInt(1, 1000)),
+		UserID: int32(util.RandomInt(1, 1000)),
+		BeatID: int32(util.RandomInt(1, 1000)),
+	}
+
+	require.NoError(t, CanModifyLike(Subject{UserID: like.UserID, Role: util.ProducerRole}, like))
+	require.NoError(t, CanModifyLike(Subject{UserID: like.UserID + 1, Role: util.AdminRole}, like))
+	require.ErrorIs(t, CanModifyLike(Subject{UserID: like.UserID + 1, Role: util.ProducerRole}, like), ErrForbidden)
+}
+
+func TestCanModifyUpload(t *testing.T) {
+	upload := db.Upload{
+		ID:     uuid.New(),
+		UserID: int32(util.RandomInt(1, 1000)),
+		BeatID: int32(util.RandomInt(1, 1000)),
+	}
+
+	require.NoError(t, CanModifyUpload(Subject{UserID: upload.UserID, Role: util.ProducerRole}, upload))
+	require.NoError(t, CanModifyUpload(Subject{UserID: upload.UserID + 1, Role: util.AdminRole}, upload))
+	require.ErrorIs(t, CanModifyUpload(Subject{UserID: upload.UserID + 1, Role: util.ProducerRole}, upload), ErrForbidden)
+}
+
+func TestCanCreateAPIKey(t *testing.T) {
+	userID := int32(util.RandomInt(1, 1000))
+
+	require.NoError(t, CanCreateAPIKey(Subject{UserID: userID, Role: util.ProducerRole}, userID))
+	require.ErrorIs(t, CanCreateAPIKey(Subject{UserID: userID + 1, Role: util.AdminRole}, userID), ErrForbidden)
+	require.ErrorIs(t, CanCreateAPIKey(Subject{UserID: userID + 1, Role: util.ProducerRole}, userID), ErrForbidden)
+}
+
+func TestCanModifyAPIKey(t *testing.T) {
+	key := db.ApiKey{
+		ID:     util.RandomInt(1, 1000),
+		UserID: int32(util.RandomInt(1, 1000)),
+	}
+
+	require.NoError(t, CanModifyAPIKey(Subject{UserID: key.UserID, Role: util.ProducerRole}, key))
+	require.NoError(t, CanModifyAPIKey(Subject{UserID: key.UserID + 1, Role: util.AdminRole}, key))
+	require.ErrorIs(t, CanModifyAPIKey(Subject{UserID: key.UserID + 1, Role: util.ProducerRole}, key), ErrForbidden)
+}
+
+func TestCanManageMFA(t *testing.T) {
+	userID := int32(util.RandomInt(1, 1000))
+
+	require.NoError(t, CanManageMFA(Subject{UserID: userID, Role: util.ProducerRole}, userID))
+	require.ErrorIs(t, CanManageMFA(Subject{UserID: userID + 1, Role: util.AdminRole}, userID), ErrForbidden)
+	require.ErrorIs(t, CanManageMFA(Subject{UserID: userID + 1, Role: util.ProducerRole}, userID), ErrForbidden)
+}
+
+func TestCanAccessOrder(t *testing.T) {
+	order := db.Order{
+		ID:      util.RandomInt(1, 1000),
+		BuyerID: int32(util.RandomInt(1, 1000)),
+	}
+
+	require.NoError(t, CanAccessOrder(Subject{UserID: order.BuyerID, Role: util.ListenerRole}, order))
+	require.NoError(t, CanAccessOrder(Subject{UserID: order.BuyerID + 1, Role: util.AdminRole}, order))
+	require.ErrorIs(t, CanAccessOrder(Subject{UserID: order.BuyerID + 1, Role: util.ListenerRole}, order), ErrForbidden)
+}
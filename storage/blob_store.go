@@ -0,0 +1,84 @@
+// Package storage keeps uploaded files such as beat audio outside of the database.
+// Objects are addressed by slash separated keys, e.g. "beats/42/audio/<uuid>.wav".
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/danglebary/beatstore-backend-go/util"
+)
+
+// ErrNotFound is returned when no object is stored under a key
+var ErrNotFound = errors.New("object not found")
+
+// ErrInvalidKey is returned for keys which could escape the storage root
+var ErrInvalidKey = errors.New("invalid object key")
+
+// ErrInvalidRange is returned by GetRange for ranges which don't start inside the object
+var ErrInvalidRange = errors.New("invalid object range")
+
+// ErrSignedURLNotSupported is returned by backends which cannot hand out URLs of their own,
+// the API serves their objects itself instead
+var ErrSignedURLNotSupported = errors.New("signed urls are not supported by this backend")
+
+// ObjectInfo describes a stored object
+type ObjectInfo struct {
+	Key         string
+	Size        int64
+	ContentType string
+	// ETag changes whenever the content of the object changes, it is not quoted
+	ETag    string
+	ModTime time.Time
+}
+
+// BlobStore is an interface for storing binary objects
+type BlobStore interface {
+	// Put stores size bytes read from r under key, replacing any existing object
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get opens the object stored under key, the caller must close it
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// GetRange opens length bytes of the object stored under key starting at offset,
+	// a range reaching past the end of the object is cut short. The caller must close it.
+	GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error)
+	// Stat returns the description of the object stored under key
+	Stat(ctx context.Context, key string) (ObjectInfo, error)
+	// Delete removes the object stored under key, deleting a missing object is not an error
+	Delete(ctx context.Context, key string) error
+	// List returns the objects whose key starts with prefix, ordered by key
+	List(ctx context.Context, prefix string) ([]ObjectInfo, error)
+	// SignedURL returns a URL which allows anyone to download the object until it expires
+	SignedURL(ctx context.Context, key string, expires time.Duration) (string, error)
+}
+
+// New creates the BlobStore selected by config.StorageBackend
+func New(config util.Config) (BlobStore, error) {
+	switch config.StorageBackend {
+	case "", "local":
+		return NewLocalStore(config.StorageDir)
+	case "memory":
+		return NewMemoryStore(), nil
+	case "s3":
+		return NewS3Store(config.S3Endpoint, config.S3Region, config.S3Bucket, config.S3AccessKeyID, config.S3SecretAccessKey)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", config.StorageBackend)
+	}
+}
+
+// validateKey rejects empty and absolute keys as well as segments starting with a dot,
+// which covers "." and ".." and keeps the names backends use internally free
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") {
+		return ErrInvalidKey
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || strings.HasPrefix(segment, ".") {
+			return ErrInvalidKey
+		}
+	}
+	return nil
+}
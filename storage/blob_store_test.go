@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"testing"
+
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/stretchr/testify/require"
+)
+
+// requireGetRange checks the GetRange contract every backend has to fulfill
+func requireGetRange(t *testing.T, store BlobStore) {
+	ctx := context.Background()
+	key := "beats/1/audio/" + util.RandomString(8) + ".mp3"
+	data := []byte(util.RandomString(64))
+
+	err := store.Put(ctx, key, bytes.NewReader(data), int64(len(data)), "audio/mpeg")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name   string
+		offset int64
+		length int64
+		want   []byte
+	}{
+		{name: "Start", offset: 0, length: 16, want: data[:16]},
+		{name: "Middle", offset: 10, length: 20, want: data[10:30]},
+		{name: "End", offset: 48, length: 16, want: data[48:]},
+		{name: "PastEnd", offset: 60, length: 100, want: data[60:]},
+		{name: "Whole", offset: 0, length: int64(len(data)), want: data},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := store.GetRange(ctx, key, tc.offset, tc.length)
+			require.NoError(t, err)
+			got, err := ioutil.ReadAll(r)
+			require.NoError(t, err)
+			require.NoError(t, r.Close())
+			require.Equal(t, tc.want, got)
+		})
+	}
+
+	_, err = store.GetRange(ctx, key, int64(len(data)), 1)
+	require.ErrorIs(t, err, ErrInvalidRange)
+
+	_, err = store.GetRange(ctx, key, 0, 0)
+	require.ErrorIs(t, err, ErrInvalidRange)
+
+	_, err = store.GetRange(ctx, "beats/1/audio/missing.mp3", 0, 1)
+	require.ErrorIs(t, err, ErrNotFound)
+}
@@ -0,0 +1,254 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// localMetaDir holds the metadata of every object below the storage root,
+// it can't clash with object keys because key segments never start with a dot
+const localMetaDir = ".meta"
+
+// localTempPrefix is the name prefix of files which are still being written
+const localTempPrefix = ".upload-"
+
+// localMeta is the metadata of an object which the file system doesn't record
+type localMeta struct {
+	ContentType string `json:"content_type"`
+}
+
+// LocalStore keeps objects as files below a root directory,
+// it is meant for local development and single instance deployments
+type LocalStore struct {
+	root string
+}
+
+// NewLocalStore creates a new LocalStore writing to root, creating it if needed
+func NewLocalStore(root string) (*LocalStore, error) {
+	if root == "" {
+		return nil, fmt.Errorf("storage directory is not configured")
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+	return &LocalStore{root: root}, nil
+}
+
+func (store *LocalStore) path(key string) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	return filepath.Join(store.root, filepath.FromSlash(key)), nil
+}
+
+func (store *LocalStore) metaPath(key string) string {
+	return filepath.Join(store.root, localMetaDir, filepath.FromSlash(key)+".json")
+}
+
+// Put writes the object to a temporary file first and renames it into place,
+// so readers never see a partially written object
+func (store *LocalStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	name, err := store.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+
+	file, err := ioutil.TempFile(filepath.Dir(name), localTempPrefix+"*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	written, err := io.Copy(file, r)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if written != size {
+		return fmt.Errorf("expected %d bytes, got %d", size, written)
+	}
+
+	if err := store.writeMeta(key, localMeta{ContentType: contentType}); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), name)
+}
+
+func (store *LocalStore) writeMeta(key string, meta localMeta) error {
+	name := store.metaPath(key)
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(name, data, 0o644)
+}
+
+// readMeta returns the metadata of key, objects without metadata are served as binary data
+func (store *LocalStore) readMeta(key string) localMeta {
+	meta := localMeta{ContentType: "application/octet-stream"}
+
+	data, err := ioutil.ReadFile(store.metaPath(key))
+	if err != nil {
+		return meta
+	}
+	_ = json.Unmarshal(data, &meta)
+	return meta
+}
+
+func (store *LocalStore) objectInfo(key string, fi os.FileInfo) ObjectInfo {
+	return ObjectInfo{
+		Key:         key,
+		Size:        fi.Size(),
+		ContentType: store.readMeta(key).ContentType,
+		// Same scheme as nginx, the file is replaced as a whole on every Put
+		ETag:    fmt.Sprintf("%x-%x", fi.ModTime().UnixNano(), fi.Size()),
+		ModTime: fi.ModTime().UTC(),
+	}
+}
+
+// Get opens the file of the object
+func (store *LocalStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	name, err := store.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return file, err
+}
+
+// localRange closes the file a range is read from
+type localRange struct {
+	io.Reader
+	file *os.File
+}
+
+func (r localRange) Close() error {
+	return r.file.Close()
+}
+
+// GetRange opens the file of the object and seeks to offset
+func (store *LocalStore) GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	if length <= 0 {
+		return nil, ErrInvalidRange
+	}
+
+	r, err := store.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	file := r.(*os.File)
+
+	fi, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if offset < 0 || offset >= fi.Size() {
+		file.Close()
+		return nil, ErrInvalidRange
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return localRange{Reader: io.LimitReader(file, length), file: file}, nil
+}
+
+// Stat describes the file of the object
+func (store *LocalStore) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	name, err := store.path(key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+
+	fi, err := os.Stat(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return ObjectInfo{}, ErrNotFound
+	}
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	return store.objectInfo(key, fi), nil
+}
+
+// Delete removes the file of the object and its metadata
+func (store *LocalStore) Delete(ctx context.Context, key string) error {
+	name, err := store.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(name)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	err = os.Remove(store.metaPath(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// List walks the storage root, skipping metadata and files which are still being written
+func (store *LocalStore) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	infos := []ObjectInfo{}
+
+	err := filepath.Walk(store.root, func(name string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(store.root, name)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+
+		if fi.IsDir() {
+			if key == localMetaDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasPrefix(fi.Name(), localTempPrefix) || !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		infos = append(infos, store.objectInfo(key, fi))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Key < infos[j].Key })
+	return infos, nil
+}
+
+// SignedURL is not supported, local files are only reachable through the API
+func (store *LocalStore) SignedURL(ctx context.Context, key string, expires time.Duration) (string, error) {
+	return "", ErrSignedURLNotSupported
+}
@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStore(t *testing.T) {
+	root := t.TempDir()
+	store, err := NewLocalStore(root)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	key := "beats/1/audio/" + util.RandomString(8) + ".wav"
+	data := []byte(util.RandomString(64))
+
+	err = store.Put(ctx, key, bytes.NewReader(data), int64(len(data)), "audio/wav")
+	require.NoError(t, err)
+
+	stored, err := ioutil.ReadFile(filepath.Join(root, filepath.FromSlash(key)))
+	require.NoError(t, err)
+	require.Equal(t, data, stored)
+
+	r, err := store.Get(ctx, key)
+	require.NoError(t, err)
+	got, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	require.Equal(t, data, got)
+
+	info, err := store.Stat(ctx, key)
+	require.NoError(t, err)
+	require.Equal(t, key, info.Key)
+	require.Equal(t, int64(len(data)), info.Size)
+	require.Equal(t, "audio/wav", info.ContentType)
+	require.NotEmpty(t, info.ETag)
+	require.WithinDuration(t, time.Now(), info.ModTime, time.Second)
+
+	err = store.Delete(ctx, key)
+	require.NoError(t, err)
+
+	_, err = store.Get(ctx, key)
+	require.ErrorIs(t, err, ErrNotFound)
+
+	_, err = store.Stat(ctx, key)
+	require.ErrorIs(t, err, ErrNotFound)
+
+	// Deleting a missing object is not an error
+	err = store.Delete(ctx, key)
+	require.NoError(t, err)
+}
+
+func TestLocalStoreShortWrite(t *testing.T) {
+	root := t.TempDir()
+	store, err := NewLocalStore(root)
+	require.NoError(t, err)
+
+	key := "beats/1/audio/short.wav"
+	err = store.Put(context.Background(), key, bytes.NewReader([]byte("abc")), 10, "audio/wav")
+	require.Error(t, err)
+
+	// Neither the object nor the temporary file are left behind
+	_, err = os.Stat(filepath.Join(root, "beats", "1", "audio", "short.wav"))
+	require.True(t, os.IsNotExist(err))
+	entries, err := ioutil.ReadDir(filepath.Join(root, "beats", "1", "audio"))
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+func TestLocalStoreInvalidKey(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	for _, key := range []string{"", "/etc/passwd", "../outside", "beats/../../outside", "beats//a.wav", "beats/./a.wav", ".meta/beats/a.wav.json"} {
+		err := store.Put(ctx, key, bytes.NewReader(nil), 0, "")
+		require.ErrorIs(t, err, ErrInvalidKey, key)
+
+		_, err = store.Get(ctx, key)
+		require.ErrorIs(t, err, ErrInvalidKey, key)
+
+		_, err = store.Stat(ctx, key)
+		require.ErrorIs(t, err, ErrInvalidKey, key)
+
+		err = store.Delete(ctx, key)
+		require.ErrorIs(t, err, ErrInvalidKey, key)
+	}
+}
+
+func TestLocalStoreList(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	keys := []string{"beats/1/audio/a.wav", "beats/1/audio/b.wav", "beats/1/artwork/c.png", "beats/10/audio/d.wav"}
+	for _, key := range keys {
+		data := []byte(util.RandomString(8))
+		err := store.Put(ctx, key, bytes.NewReader(data), int64(len(data)), "")
+		require.NoError(t, err)
+	}
+
+	// Metadata and files which are still being written are not listed
+	infos, err := store.List(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, infos, 4)
+
+	infos, err = store.List(ctx, "beats/1/")
+	require.NoError(t, err)
+	require.Len(t, infos, 3)
+	require.Equal(t, "beats/1/artwork/c.png", infos[0].Key)
+	require.Equal(t, "beats/1/audio/a.wav", infos[1].Key)
+	require.Equal(t, "beats/1/audio/b.wav", infos[2].Key)
+	require.Equal(t, int64(8), infos[0].Size)
+
+	infos, err = store.List(ctx, "stems/")
+	require.NoError(t, err)
+	require.Empty(t, infos)
+
+	_, err = store.SignedURL(ctx, keys[0], time.Minute)
+	require.ErrorIs(t, err, ErrSignedURLNotSupported)
+}
+
+func TestLocalStoreGetRange(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+
+	requireGetRange(t, store)
+}
+
+func TestNew(t *testing.T) {
+	store, err := New(util.Config{StorageDir: t.TempDir()})
+	require.NoError(t, err)
+	require.IsType(t, &LocalStore{}, store)
+
+	store, err = New(util.Config{StorageBackend: "memory"})
+	require.NoError(t, err)
+	require.IsType(t, &MemoryStore{}, store)
+
+	store, err = New(util.Config{StorageBackend: "s3", S3Endpoint: "http://localhost:9000", S3Bucket: "beatstore"})
+	require.NoError(t, err)
+	require.IsType(t, &S3Store{}, store)
+
+	_, err = New(util.Config{StorageBackend: "ftp"})
+	require.Error(t, err)
+}
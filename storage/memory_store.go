@@ -0,0 +1,157 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type memoryObject struct {
+	data []byte
+	info ObjectInfo
+}
+
+// MemoryStore keeps objects in memory. It is meant for tests, handlers can be exercised
+// against it and the stored objects inspected afterwards without touching disk or network.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	objects map[string]memoryObject
+	// now returns the current time, tests replace it to get a fixed clock
+	now func() time.Time
+}
+
+// NewMemoryStore creates a new empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		objects: make(map[string]memoryObject),
+		now:     time.Now,
+	}
+}
+
+// Put reads the whole object into memory, the ETag is the MD5 of its content like on S3
+func (store *MemoryStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if int64(len(data)) != size {
+		return fmt.Errorf("expected %d bytes, got %d", size, len(data))
+	}
+
+	sum := md5.Sum(data)
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.objects[key] = memoryObject{
+		data: data,
+		info: ObjectInfo{
+			Key:         key,
+			Size:        size,
+			ContentType: contentType,
+			ETag:        hex.EncodeToString(sum[:]),
+			ModTime:     store.now().UTC(),
+		},
+	}
+	return nil
+}
+
+// Get returns a reader over the stored content
+func (store *MemoryStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	object, ok := store.objects[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return ioutil.NopCloser(bytes.NewReader(object.data)), nil
+}
+
+// GetRange returns a reader over part of the stored content
+func (store *MemoryStore) GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	object, ok := store.objects[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	size := int64(len(object.data))
+	if offset < 0 || offset >= size || length <= 0 {
+		return nil, ErrInvalidRange
+	}
+	end := offset + length
+	if end > size {
+		end = size
+	}
+	return ioutil.NopCloser(bytes.NewReader(object.data[offset:end])), nil
+}
+
+// Stat returns the description recorded by Put
+func (store *MemoryStore) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	if err := validateKey(key); err != nil {
+		return ObjectInfo{}, err
+	}
+
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	object, ok := store.objects[key]
+	if !ok {
+		return ObjectInfo{}, ErrNotFound
+	}
+	return object.info, nil
+}
+
+// Delete forgets the object
+func (store *MemoryStore) Delete(ctx context.Context, key string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	delete(store.objects, key)
+	return nil
+}
+
+// List returns the objects whose key starts with prefix, ordered by key
+func (store *MemoryStore) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	infos := []ObjectInfo{}
+	for key, object := range store.objects {
+		if strings.HasPrefix(key, prefix) {
+			infos = append(infos, object.info)
+		}
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Key < infos[j].Key })
+	return infos, nil
+}
+
+// SignedURL is not supported, objects in memory are only reachable through the API
+func (store *MemoryStore) SignedURL(ctx context.Context, key string, expires time.Duration) (string, error) {
+	return "", ErrSignedURLNotSupported
+}
@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	key := "beats/1/audio/" + util.RandomString(8) + ".mp3"
+	data := []byte(util.RandomString(64))
+
+	err := store.Put(ctx, key, bytes.NewReader(data), int64(len(data)), "audio/mpeg")
+	require.NoError(t, err)
+
+	r, err := store.Get(ctx, key)
+	require.NoError(t, err)
+	got, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	require.Equal(t, data, got)
+
+	sum := md5.Sum(data)
+	info, err := store.Stat(ctx, key)
+	require.NoError(t, err)
+	require.Equal(t, ObjectInfo{
+		Key:         key,
+		Size:        int64(len(data)),
+		ContentType: "audio/mpeg",
+		ETag:        hex.EncodeToString(sum[:]),
+		ModTime:     now,
+	}, info)
+
+	infos, err := store.List(ctx, "beats/1/")
+	require.NoError(t, err)
+	require.Equal(t, []ObjectInfo{info}, infos)
+
+	_, err = store.SignedURL(ctx, key, time.Minute)
+	require.ErrorIs(t, err, ErrSignedURLNotSupported)
+
+	err = store.Delete(ctx, key)
+	require.NoError(t, err)
+
+	_, err = store.Get(ctx, key)
+	require.ErrorIs(t, err, ErrNotFound)
+
+	_, err = store.Stat(ctx, key)
+	require.ErrorIs(t, err, ErrNotFound)
+
+	infos, err = store.List(ctx, "")
+	require.NoError(t, err)
+	require.Empty(t, infos)
+
+	err = store.Put(ctx, "../outside", bytes.NewReader(nil), 0, "")
+	require.ErrorIs(t, err, ErrInvalidKey)
+
+	err = store.Put(ctx, key, bytes.NewReader(data), int64(len(data))+1, "audio/mpeg")
+	require.Error(t, err)
+}
+
+func TestMemoryStoreGetRange(t *testing.T) {
+	requireGetRange(t, NewMemoryStore())
+}
@@ -0,0 +1,439 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	s3Service         = "s3"
+	s3SigningAlgo     = "AWS4-HMAC-SHA256"
+	s3UnsignedPayload = "UNSIGNED-PAYLOAD"
+	s3DateFormat      = "20060102T150405Z"
+	// s3MaxPresignExpiry is the longest validity S3 accepts for presigned URLs
+	s3MaxPresignExpiry = 7 * 24 * time.Hour
+)
+
+// S3Store keeps objects in a bucket of an S3 compatible service such as AWS S3 or MinIO.
+// Requests use path style addressing and are signed with AWS Signature Version 4.
+type S3Store struct {
+	endpoint        *url.URL
+	region          string
+	bucket          string
+	accessKeyID     string
+	secretAccessKey string
+	client          *http.Client
+	// now returns the current time, tests replace it to get a fixed clock
+	now func() time.Time
+}
+
+// NewS3Store creates a new S3Store for bucket on the service at endpoint
+func NewS3Store(endpoint, region, bucket, accessKeyID, secretAccessKey string) (*S3Store, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid s3 endpoint: %w", err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid s3 endpoint %q", endpoint)
+	}
+	if bucket == "" {
+		return nil, fmt.Errorf("s3 bucket is not configured")
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	return &S3Store{
+		endpoint:        u,
+		region:          region,
+		bucket:          bucket,
+		accessKeyID:     accessKeyID,
+		secretAccessKey: secretAccessKey,
+		client:          http.DefaultClient,
+		now:             time.Now,
+	}, nil
+}
+
+// bucketURL returns the path style URL of the bucket
+func (store *S3Store) bucketURL() *url.URL {
+	u := *store.endpoint
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + store.bucket
+	u.RawPath = ""
+	return &u
+}
+
+// objectURL returns the path style URL of key
+func (store *S3Store) objectURL(key string) *url.URL {
+	u := store.bucketURL()
+	u.Path += "/" + key
+	u.RawPath = s3EscapePath(u.Path)
+	return u
+}
+
+func (store *S3Store) newRequest(ctx context.Context, method string, u *url.URL, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	store.sign(req)
+	return req, nil
+}
+
+func (store *S3Store) newObjectRequest(ctx context.Context, method, key string, body io.Reader) (*http.Request, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+	return store.newRequest(ctx, method, store.objectURL(key), body)
+}
+
+// Put uploads the object with a single PUT request
+func (store *S3Store) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	req, err := store.newObjectRequest(ctx, http.MethodPut, key, r)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	res, err := store.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return newS3Error(res)
+	}
+	return nil
+}
+
+// Get downloads the object, the body is streamed from the response
+func (store *S3Store) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := store.newObjectRequest(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := store.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		return res.Body, nil
+	case http.StatusNotFound:
+		res.Body.Close()
+		return nil, ErrNotFound
+	default:
+		defer res.Body.Close()
+		return nil, newS3Error(res)
+	}
+}
+
+// GetRange downloads part of the object with a Range request
+func (store *S3Store) GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	if offset < 0 || length <= 0 {
+		return nil, ErrInvalidRange
+	}
+
+	req, err := store.newObjectRequest(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+
+	res, err := store.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch res.StatusCode {
+	case http.StatusPartialContent:
+		return res.Body, nil
+	case http.StatusNotFound:
+		res.Body.Close()
+		return nil, ErrNotFound
+	case http.StatusRequestedRangeNotSatisfiable:
+		res.Body.Close()
+		return nil, ErrInvalidRange
+	default:
+		defer res.Body.Close()
+		return nil, newS3Error(res)
+	}
+}
+
+// Delete removes the object, S3 reports success for missing objects as well
+func (store *S3Store) Delete(ctx context.Context, key string) error {
+	req, err := store.newObjectRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := store.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusOK && res.StatusCode != http.StatusNotFound {
+		return newS3Error(res)
+	}
+	return nil
+}
+
+// Stat sends a HEAD request for the object
+func (store *S3Store) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	req, err := store.newObjectRequest(ctx, http.MethodHead, key, nil)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+
+	res, err := store.client.Do(req)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return ObjectInfo{}, ErrNotFound
+	default:
+		return ObjectInfo{}, newS3Error(res)
+	}
+
+	modTime, _ := http.ParseTime(res.Header.Get("Last-Modified"))
+	return ObjectInfo{
+		Key:         key,
+		Size:        res.ContentLength,
+		ContentType: res.Header.Get("Content-Type"),
+		ETag:        strings.Trim(res.Header.Get("ETag"), `"`),
+		ModTime:     modTime.UTC(),
+	}, nil
+}
+
+// s3ListResult is the response body of ListObjectsV2
+type s3ListResult struct {
+	IsTruncated           bool
+	NextContinuationToken string
+	Contents              []struct {
+		Key          string
+		LastModified time.Time
+		ETag         string
+		Size         int64
+	}
+}
+
+// List pages through ListObjectsV2. The listing doesn't include content types,
+// use Stat for the content type of a single object.
+func (store *S3Store) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	infos := []ObjectInfo{}
+	continuationToken := ""
+
+	for {
+		u := store.bucketURL()
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", prefix)
+		if continuationToken != "" {
+			query.Set("continuation-token", continuationToken)
+		}
+		u.RawQuery = s3CanonicalQuery(query)
+
+		req, err := store.newRequest(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := store.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		var result s3ListResult
+		if res.StatusCode != http.StatusOK {
+			err = newS3Error(res)
+		} else {
+			err = xml.NewDecoder(res.Body).Decode(&result)
+		}
+		res.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, object := range result.Contents {
+			infos = append(infos, ObjectInfo{
+				Key:     object.Key,
+				Size:    object.Size,
+				ETag:    strings.Trim(object.ETag, `"`),
+				ModTime: object.LastModified.UTC(),
+			})
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			break
+		}
+		continuationToken = result.NextContinuationToken
+	}
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Key < infos[j].Key })
+	return infos, nil
+}
+
+// SignedURL returns a presigned GET URL, the download goes straight to the S3 service
+func (store *S3Store) SignedURL(ctx context.Context, key string, expires time.Duration) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	if expires <= 0 || expires > s3MaxPresignExpiry {
+		return "", fmt.Errorf("expiry must be between 1s and %s", s3MaxPresignExpiry)
+	}
+
+	now := store.now().UTC()
+	u := store.objectURL(key)
+
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", s3SigningAlgo)
+	query.Set("X-Amz-Credential", store.accessKeyID+"/"+s3Scope(store.region, now))
+	query.Set("X-Amz-Date", now.Format(s3DateFormat))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expires.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+	u.RawQuery = s3CanonicalQuery(query)
+
+	signature := s3Signature(store.secretAccessKey, store.region, now, http.MethodGet, u, u.Host, map[string]string{})
+	u.RawQuery += "&X-Amz-Signature=" + signature
+	return u.String(), nil
+}
+
+func newS3Error(res *http.Response) error {
+	body, _ := ioutil.ReadAll(io.LimitReader(res.Body, 1024))
+	return fmt.Errorf("s3 request failed with status %d: %s", res.StatusCode, strings.TrimSpace(string(body)))
+}
+
+// sign adds the AWS Signature Version 4 headers to req.
+// The payload is not hashed so uploads can be streamed.
+func (store *S3Store) sign(req *http.Request) {
+	now := store.now().UTC()
+	amzDate := now.Format(s3DateFormat)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", s3UnsignedPayload)
+
+	signedHeaders := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	signature := s3Signature(store.secretAccessKey, store.region, now, req.Method, req.URL, req.URL.Host, map[string]string{
+		"x-amz-content-sha256": s3UnsignedPayload,
+		"x-amz-date":           amzDate,
+	})
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3SigningAlgo,
+		store.accessKeyID,
+		s3Scope(store.region, now),
+		strings.Join(signedHeaders, ";"),
+		signature,
+	))
+}
+
+func s3Scope(region string, t time.Time) string {
+	return fmt.Sprintf("%s/%s/%s/aws4_request", t.Format("20060102"), region, s3Service)
+}
+
+// s3Signature computes the signature of a request to u signed with host and headers,
+// headers must have lower case names
+func s3Signature(secretAccessKey, region string, t time.Time, method string, u *url.URL, host string, headers map[string]string) string {
+	headers["host"] = host
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		s3EscapePath(u.Path),
+		s3CanonicalQuery(u.Query()),
+		canonicalHeaders.String(),
+		strings.Join(names, ";"),
+		s3UnsignedPayload,
+	}, "\n")
+
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		s3SigningAlgo,
+		t.UTC().Format(s3DateFormat),
+		s3Scope(region, t.UTC()),
+		hex.EncodeToString(hash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+secretAccessKey), t.UTC().Format("20060102"))
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, s3Service)
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// s3EscapePath percent-encodes every byte of p except unreserved characters and slashes
+func s3EscapePath(p string) string {
+	var b strings.Builder
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		if c == '/' || isUnreserved(c) {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+func s3EscapeQuery(s string) string {
+	return strings.ReplaceAll(s3EscapePath(s), "/", "%2F")
+}
+
+func s3CanonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, key := range keys {
+		values := append([]string(nil), query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			parts = append(parts, s3EscapeQuery(key)+"="+s3EscapeQuery(value))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func isUnreserved(c byte) bool {
+	return 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '.' || c == '_' || c == '~'
+}
@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const minSigningKeySize = 32
+
+// Errors returned by URLSigner.Verify
+var (
+	ErrInvalidURLToken = errors.New("file url is invalid")
+	ErrExpiredURLToken = errors.New("file url has expired")
+)
+
+// URLSigner creates and verifies the tokens of time limited download URLs
+// for backends which can't sign URLs themselves.
+// A token carries the object key and its expiry, authenticated with HMAC-SHA256.
+type URLSigner struct {
+	key []byte
+}
+
+// NewURLSigner creates a new URLSigner using the symmetric signing key
+func NewURLSigner(signingKey string) (*URLSigner, error) {
+	if len(signingKey) < minSigningKeySize {
+		return nil, fmt.Errorf("invalid key size: must be at least %d characters", minSigningKeySize)
+	}
+	return &URLSigner{key: []byte(signingKey)}, nil
+}
+
+func (signer *URLSigner) mac(payload []byte) []byte {
+	mac := hmac.New(sha256.New, signer.key)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// Sign returns a URL safe token granting access to key until expiresAt
+func (signer *URLSigner) Sign(key string, expiresAt time.Time) string {
+	payload := make([]byte, 8, 8+len(key))
+	binary.BigEndian.PutUint64(payload, uint64(expiresAt.Unix()))
+	payload = append(payload, key...)
+
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(signer.mac(payload))
+}
+
+// Verify checks the signature and the expiry of token and returns the object key it grants access to
+func (signer *URLSigner) Verify(token string, now time.Time) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return "", ErrInvalidURLToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil || len(payload) <= 8 {
+		return "", ErrInvalidURLToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", ErrInvalidURLToken
+	}
+	if !hmac.Equal(signature, signer.mac(payload)) {
+		return "", ErrInvalidURLToken
+	}
+
+	expiresAt := time.Unix(int64(binary.BigEndian.Uint64(payload[:8])), 0)
+	if !now.Before(expiresAt) {
+		return "", ErrExpiredURLToken
+	}
+	return string(payload[8:]), nil
+}
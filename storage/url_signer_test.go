@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestURLSigner(t *testing.T) {
+	signer, err := NewURLSigner(util.RandomString(32))
+	require.NoError(t, err)
+
+	key := "beats/1/audio/" + util.RandomString(8) + ".wav"
+	now := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	token := signer.Sign(key, now.Add(time.Minute))
+	require.NotContains(t, token, "/")
+
+	got, err := signer.Verify(token, now)
+	require.NoError(t, err)
+	require.Equal(t, key, got)
+
+	_, err = signer.Verify(token, now.Add(time.Minute))
+	require.ErrorIs(t, err, ErrExpiredURLToken)
+
+	// Tokens of another key are rejected
+	other, err := NewURLSigner(util.RandomString(32))
+	require.NoError(t, err)
+	_, err = other.Verify(token, now)
+	require.ErrorIs(t, err, ErrInvalidURLToken)
+
+	// The payload can't be swapped
+	otherToken := signer.Sign("beats/2/audio/master.wav", now.Add(time.Hour))
+	forged := strings.Split(otherToken, ".")[0] + "." + strings.Split(token, ".")[1]
+	_, err = signer.Verify(forged, now)
+	require.ErrorIs(t, err, ErrInvalidURLToken)
+
+	for _, invalid := range []string{"", "abc", "a.b.c", "!!.!!", token + "x"} {
+		_, err = signer.Verify(invalid, now)
+		require.ErrorIs(t, err, ErrInvalidURLToken, invalid)
+	}
+}
+
+func TestNewURLSigner(t *testing.T) {
+	_, err := NewURLSigner(util.RandomString(31))
+	require.Error(t, err)
+}
@@ -0,0 +1,12 @@
+package token
+
+import "time"
+
+// Maker is an interface for managing tokens
+type Maker interface {
+	// CreateToken creates a new token of tokenType for a specific user and duration
+	CreateToken(userID int32, username string, role string, tokenType string, duration time.Duration) (string, *Payload, error)
+
+	// VerifyToken checks if the token is valid and of tokenType
+	VerifyToken(token string, tokenType string) (*Payload, error)
+}
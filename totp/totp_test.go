@@ -0,0 +1,97 @@
+package totp
+
+import (
+	"encoding/base32"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Test vectors of RFC 6238 appendix B for SHA-1, truncated to 6 digits
+func TestGenerateCodeRFC6238(t *testing.T) {
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+	testCases := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tc := range testCases {
+		code, err := GenerateCode(secret, time.Unix(tc.unix, 0))
+		require.NoError(t, err)
+		require.Equal(t, tc.code, code)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+
+	now := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	code, err := GenerateCode(secret, now)
+	require.NoError(t, err)
+
+	require.True(t, Validate(code, secret, now))
+	// Codes of the neighbouring steps are accepted to allow for clock drift
+	require.True(t, Validate(code, secret, now.Add(Period*time.Second)))
+	require.True(t, Validate(code, secret, now.Add(-Period*time.Second)))
+	require.False(t, Validate(code, secret, now.Add(2*Period*time.Second)))
+
+	require.False(t, Validate("", secret, now))
+	require.False(t, Validate(code+"0", secret, now))
+	require.False(t, Validate(code, "not base32!", now))
+}
+
+func TestValidateStep(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+
+	now := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	code, err := GenerateCode(secret, now)
+	require.NoError(t, err)
+
+	// The step is the one the code was generated for, not the one it is checked at
+	step, ok := ValidateStep(code, secret, now)
+	require.True(t, ok)
+	require.Equal(t, now.Unix()/Period, step)
+
+	later, ok := ValidateStep(code, secret, now.Add(Period*time.Second))
+	require.True(t, ok)
+	require.Equal(t, step, later)
+
+	_, ok = ValidateStep(code, secret, now.Add(2*Period*time.Second))
+	require.False(t, ok)
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret1, err := GenerateSecret()
+	require.NoError(t, err)
+	secret2, err := GenerateSecret()
+	require.NoError(t, err)
+
+	require.Len(t, secret1, 32)
+	require.NotEqual(t, secret1, secret2)
+}
+
+func TestURI(t *testing.T) {
+	uri := URI("BeatStore", "dj khaled", "JBSWY3DPEHPK3PXP")
+
+	u, err := url.Parse(uri)
+	require.NoError(t, err)
+	require.Equal(t, "otpauth", u.Scheme)
+	require.Equal(t, "totp", u.Host)
+	require.Equal(t, "/BeatStore:dj khaled", u.Path)
+	require.Equal(t, "JBSWY3DPEHPK3PXP", u.Query().Get("secret"))
+	require.Equal(t, "BeatStore", u.Query().Get("issuer"))
+	require.Equal(t, "6", u.Query().Get("digits"))
+	require.Equal(t, "30", u.Query().Get("period"))
+}
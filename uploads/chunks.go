@@ -0,0 +1,139 @@
+// Package uploads keeps the chunks of resumable uploads in the blob store until the client
+// finalizes the upload, and sweeps away the chunks of uploads which were abandoned.
+package uploads
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/danglebary/beatstore-backend-go/storage"
+	"github.com/google/uuid"
+)
+
+// chunkRoot is the key prefix of all chunks, the chunks of an upload share the prefix "uploads/<id>/"
+const chunkRoot = "uploads/"
+
+// ErrMissingChunk is returned when the stored chunks of an upload don't add up to the whole file
+var ErrMissingChunk = errors.New("upload is missing a chunk")
+
+// ChunkKey returns a new object key for a chunk of upload id starting at offset.
+// Offsets are zero padded so listing the chunks returns them in order,
+// the random suffix keeps a chunk sent twice from replacing the one which was counted.
+func ChunkKey(id uuid.UUID, offset int64) string {
+	return fmt.Sprintf("%s%020d-%s", chunkPrefix(id), offset, uuid.New())
+}
+
+func chunkPrefix(id uuid.UUID) string {
+	return chunkRoot + id.String() + "/"
+}
+
+// chunkOffset returns the offset encoded in the key of a chunk
+func chunkOffset(key string) (int64, error) {
+	name := key[strings.LastIndex(key, "/")+1:]
+	if i := strings.Index(name, "-"); i >= 0 {
+		name = name[:i]
+	}
+	offset, err := strconv.ParseInt(name, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid chunk key %q", key)
+	}
+	return offset, nil
+}
+
+// Open returns the chunks of upload id as one stream of size bytes, the caller must close it.
+// Chunks left behind by retried requests which start before the end of the previous chunk are skipped,
+// the checksum of the file tells whether they were the same.
+func Open(ctx context.Context, blobs storage.BlobStore, id uuid.UUID, size int64) (io.ReadCloser, error) {
+	objects, err := blobs.List(ctx, chunkPrefix(id))
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	next := int64(0)
+	for _, object := range objects {
+		if next == size {
+			break
+		}
+		offset, err := chunkOffset(object.Key)
+		if err != nil {
+			return nil, err
+		}
+		if offset < next {
+			continue
+		}
+		if offset > next || offset+object.Size > size {
+			return nil, ErrMissingChunk
+		}
+		keys = append(keys, object.Key)
+		next += object.Size
+	}
+	if next != size {
+		return nil, ErrMissingChunk
+	}
+
+	return &chunkReader{ctx: ctx, blobs: blobs, keys: keys}, nil
+}
+
+// DeleteChunks deletes every chunk of upload id, it keeps going past failed deletes
+// and returns the last error
+func DeleteChunks(ctx context.Context, blobs storage.BlobStore, id uuid.UUID) error {
+	objects, err := blobs.List(ctx, chunkPrefix(id))
+	if err != nil {
+		return err
+	}
+	for _, object := range objects {
+		if deleteErr := blobs.Delete(ctx, object.Key); deleteErr != nil {
+			err = deleteErr
+		}
+	}
+	return err
+}
+
+// chunkReader reads the chunks stored under keys one after another, opening each only once it is reached
+type chunkReader struct {
+	ctx     context.Context
+	blobs   storage.BlobStore
+	keys    []string
+	current io.ReadCloser
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	for {
+		if r.current == nil {
+			if len(r.keys) == 0 {
+				return 0, io.EOF
+			}
+			current, err := r.blobs.Get(r.ctx, r.keys[0])
+			if err != nil {
+				return 0, err
+			}
+			r.current = current
+			r.keys = r.keys[1:]
+		}
+
+		n, err := r.current.Read(p)
+		if err == io.EOF {
+			err = r.current.Close()
+			r.current = nil
+			if n > 0 || err != nil {
+				return n, err
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+func (r *chunkReader) Close() error {
+	if r.current == nil {
+		return nil
+	}
+	err := r.current.Close()
+	r.current = nil
+	return err
+}
@@ -0,0 +1,125 @@
+package uploads
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"testing"
+
+	"github.com/danglebary/beatstore-backend-go/storage"
+	"github.com/danglebary/beatstore-backend-go/util"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// putChunk stores data as the chunk of upload id starting at offset
+func putChunk(t *testing.T, blobs storage.BlobStore, id uuid.UUID, offset int64, data []byte) string {
+	key := ChunkKey(id, offset)
+	err := blobs.Put(context.Background(), key, bytes.NewReader(data), int64(len(data)), "application/octet-stream")
+	require.NoError(t, err)
+	return key
+}
+
+func TestChunkKey(t *testing.T) {
+	id := uuid.New()
+
+	key := ChunkKey(id, 1024)
+	require.Contains(t, key, "uploads/"+id.String()+"/")
+	require.NotEqual(t, key, ChunkKey(id, 1024))
+
+	offset, err := chunkOffset(key)
+	require.NoError(t, err)
+	require.Equal(t, int64(1024), offset)
+
+	// Keys sort like their offsets
+	require.Less(t, ChunkKey(id, 9), ChunkKey(id, 10))
+
+	_, err = chunkOffset("uploads/" + id.String() + "/chunk")
+	require.Error(t, err)
+}
+
+func TestOpen(t *testing.T) {
+	data := []byte(util.RandomString(100))
+
+	testCases := []struct {
+		name string
+		// chunks holds the start and end of every stored chunk within data
+		chunks  [][2]int64
+		wantErr error
+	}{
+		{
+			name:   "OK",
+			chunks: [][2]int64{{0, 30}, {30, 60}, {60, 100}},
+		},
+		{
+			name:   "SingleChunk",
+			chunks: [][2]int64{{0, 100}},
+		},
+		{
+			// A copy of the second chunk whose request failed after it was stored
+			name:   "RetriedChunk",
+			chunks: [][2]int64{{0, 30}, {30, 60}, {30, 60}, {60, 100}},
+		},
+		{
+			name:    "MissingChunk",
+			chunks:  [][2]int64{{0, 30}, {60, 100}},
+			wantErr: ErrMissingChunk,
+		},
+		{
+			name:    "MissingEnd",
+			chunks:  [][2]int64{{0, 30}, {30, 60}},
+			wantErr: ErrMissingChunk,
+		},
+		{
+			name:    "NoChunks",
+			wantErr: ErrMissingChunk,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			blobs := storage.NewMemoryStore()
+			id := uuid.New()
+
+			for _, chunk := range tc.chunks {
+				putChunk(t, blobs, id, chunk[0], data[chunk[0]:chunk[1]])
+			}
+			// Chunks of other uploads are ignored
+			putChunk(t, blobs, uuid.New(), 0, []byte("other"))
+
+			r, err := Open(context.Background(), blobs, id, int64(len(data)))
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			defer r.Close()
+
+			got, err := ioutil.ReadAll(r)
+			require.NoError(t, err)
+			require.Equal(t, data, got)
+		})
+	}
+}
+
+func TestDeleteChunks(t *testing.T) {
+	blobs := storage.NewMemoryStore()
+	id := uuid.New()
+	other := uuid.New()
+
+	putChunk(t, blobs, id, 0, []byte("first"))
+	putChunk(t, blobs, id, 5, []byte("second"))
+	otherKey := putChunk(t, blobs, other, 0, []byte("other"))
+
+	require.NoError(t, DeleteChunks(context.Background(), blobs, id))
+
+	objects, err := blobs.List(context.Background(), chunkRoot)
+	require.NoError(t, err)
+	require.Len(t, objects, 1)
+	require.Equal(t, otherKey, objects[0].Key)
+
+	// Deleting an upload without chunks is fine
+	require.NoError(t, DeleteChunks(context.Background(), blobs, id))
+}
@@ -0,0 +1,124 @@
+package uploads
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
+	"github.com/danglebary/beatstore-backend-go/jobs"
+	"github.com/danglebary/beatstore-backend-go/storage"
+	"github.com/google/uuid"
+)
+
+// sweepBatchSize is the number of stale uploads fetched at once
+const sweepBatchSize = 100
+
+// Sweeper deletes uploads which haven't made progress for longer than their expiry
+type Sweeper struct {
+	store  db.Store
+	blobs  storage.BlobStore
+	expiry time.Duration
+	// now returns the current time, tests replace it to get a fixed clock
+	now func() time.Time
+}
+
+// NewSweeper creates a Sweeper for uploads which received nothing for expiry
+func NewSweeper(store db.Store, blobs storage.BlobStore, expiry time.Duration) *Sweeper {
+	return &Sweeper{
+		store:  store,
+		blobs:  blobs,
+		expiry: expiry,
+		now:    time.Now,
+	}
+}
+
+// Job returns the job running Sweep, it is meant to be scheduled with jobs.Queue.Every
+func (sweeper *Sweeper) Job() jobs.Job {
+	return jobs.Job{
+		Name: "sweep of abandoned uploads",
+		Run:  sweeper.Sweep,
+	}
+}
+
+// Sweep deletes the stale uploads and their chunks. Afterwards it deletes stale chunks
+// whose upload is gone, like the ones of uploads deleted together with their beat
+// or of an earlier sweep which failed halfway.
+func (sweeper *Sweeper) Sweep(ctx context.Context) error {
+	cutoff := sweeper.now().Add(-sweeper.expiry)
+
+	for {
+		stale, err := sweeper.store.ListStaleUploads(ctx, db.ListStaleUploadsParams{
+			UpdatedAt: cutoff,
+			Limit:     sweepBatchSize,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, upload := range stale {
+			// Without the row nobody can resume the upload, chunks left behind are orphans
+			if err := sweeper.store.DeleteUpload(ctx, upload.ID); err != nil {
+				return err
+			}
+			if err := DeleteChunks(ctx, sweeper.blobs, upload.ID); err != nil {
+				return err
+			}
+		}
+
+		if len(stale) < sweepBatchSize {
+			break
+		}
+	}
+
+	return sweeper.sweepOrphans(ctx, cutoff)
+}
+
+// sweepOrphans deletes chunks stored before cutoff which belong to no upload
+func (sweeper *Sweeper) sweepOrphans(ctx context.Context, cutoff time.Time) error {
+	objects, err := sweeper.blobs.List(ctx, chunkRoot)
+	if err != nil {
+		return err
+	}
+
+	// Whether the upload named by the first segment of a key is gone, by upload
+	orphaned := make(map[string]bool)
+	for _, object := range objects {
+		if !object.ModTime.Before(cutoff) {
+			continue
+		}
+
+		name := strings.SplitN(strings.TrimPrefix(object.Key, chunkRoot), "/", 2)[0]
+		orphan, checked := orphaned[name]
+		if !checked {
+			orphan, err = sweeper.isOrphan(ctx, name)
+			if err != nil {
+				return err
+			}
+			orphaned[name] = orphan
+		}
+
+		if orphan {
+			if err := sweeper.blobs.Delete(ctx, object.Key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// isOrphan reports whether no upload with the given ID exists,
+// objects not named after an upload don't belong under chunkRoot at all
+func (sweeper *Sweeper) isOrphan(ctx context.Context, name string) (bool, error) {
+	id, err := uuid.Parse(name)
+	if err != nil {
+		return true, nil
+	}
+
+	_, err = sweeper.store.GetUpload(ctx, id)
+	if err == sql.ErrNoRows {
+		return true, nil
+	}
+	return false, err
+}
@@ -0,0 +1,176 @@
+package uploads
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	mockdb "github.com/danglebary/beatstore-backend-go/db/mock"
+	db "github.com/danglebary/beatstore-backend-go/db/sqlc"
+	"github.com/danglebary/beatstore-backend-go/storage"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSweep(t *testing.T) {
+	stale := db.Upload{ID: uuid.New()}
+	active := db.Upload{ID: uuid.New()}
+	orphan := uuid.New()
+
+	testCases := []struct {
+		name       string
+		buildStubs func(store *mockdb.MockStore)
+		// wantKept lists the uploads whose chunks are left alone
+		wantKept []uuid.UUID
+		wantErr  bool
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListStaleUploads(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.Upload{stale}, nil)
+				store.EXPECT().
+					DeleteUpload(gomock.Any(), gomock.Eq(stale.ID)).
+					Times(1).
+					Return(nil)
+				store.EXPECT().
+					GetUpload(gomock.Any(), gomock.Eq(active.ID)).
+					Times(1).
+					Return(active, nil)
+				store.EXPECT().
+					GetUpload(gomock.Any(), gomock.Eq(orphan)).
+					Times(1).
+					Return(db.Upload{}, sql.ErrNoRows)
+			},
+			wantKept: []uuid.UUID{active.ID},
+		},
+		{
+			name: "ListError",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListStaleUploads(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+				store.EXPECT().
+					DeleteUpload(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			wantKept: []uuid.UUID{stale.ID, active.ID, orphan},
+			wantErr:  true,
+		},
+		{
+			name: "GetUploadError",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListStaleUploads(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.Upload{}, nil)
+				store.EXPECT().
+					GetUpload(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Upload{}, sql.ErrConnDone)
+			},
+			wantKept: []uuid.UUID{stale.ID, active.ID, orphan},
+			wantErr:  true,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			blobs := storage.NewMemoryStore()
+			for _, id := range []uuid.UUID{stale.ID, active.ID, orphan} {
+				putChunk(t, blobs, id, 0, []byte("chunk"))
+			}
+
+			// Every chunk was stored longer than the expiry ago
+			sweeper := NewSweeper(store, blobs, time.Hour)
+			now := time.Now().Add(2 * time.Hour)
+			sweeper.now = func() time.Time { return now }
+
+			err := sweeper.Sweep(context.Background())
+			if tc.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			objects, err := blobs.List(context.Background(), chunkRoot)
+			require.NoError(t, err)
+			var kept []uuid.UUID
+			for _, object := range objects {
+				id, err := uuid.Parse(object.Key[len(chunkRoot) : len(chunkRoot)+36])
+				require.NoError(t, err)
+				kept = append(kept, id)
+			}
+			require.ElementsMatch(t, tc.wantKept, kept)
+		})
+	}
+}
+
+func TestSweepRecentChunks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		ListStaleUploads(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg db.ListStaleUploadsParams) ([]db.Upload, error) {
+			require.WithinDuration(t, time.Now().Add(-time.Hour), arg.UpdatedAt, time.Second)
+			return []db.Upload{}, nil
+		})
+	// Chunks stored within the expiry may belong to an upload which was only just created
+	store.EXPECT().
+		GetUpload(gomock.Any(), gomock.Any()).
+		Times(0)
+
+	blobs := storage.NewMemoryStore()
+	putChunk(t, blobs, uuid.New(), 0, []byte("chunk"))
+
+	err := NewSweeper(store, blobs, time.Hour).Sweep(context.Background())
+	require.NoError(t, err)
+
+	objects, err := blobs.List(context.Background(), chunkRoot)
+	require.NoError(t, err)
+	require.Len(t, objects, 1)
+}
+
+func TestSweepBatches(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	batch := make([]db.Upload, sweepBatchSize)
+	for i := range batch {
+		batch[i] = db.Upload{ID: uuid.New()}
+	}
+
+	store := mockdb.NewMockStore(ctrl)
+	// A full batch means more stale uploads may be waiting
+	gomock.InOrder(
+		store.EXPECT().
+			ListStaleUploads(gomock.Any(), gomock.Any()).
+			Return(batch, nil),
+		store.EXPECT().
+			ListStaleUploads(gomock.Any(), gomock.Any()).
+			Return([]db.Upload{}, nil),
+	)
+	store.EXPECT().
+		DeleteUpload(gomock.Any(), gomock.Any()).
+		Times(sweepBatchSize).
+		Return(nil)
+
+	err := NewSweeper(store, storage.NewMemoryStore(), time.Hour).Sweep(context.Background())
+	require.NoError(t, err)
+}
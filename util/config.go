@@ -1,13 +1,65 @@
 package util
 
-import "github.com/spf13/viper"
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
 
 // Config stores all configuration constants for the application.
 // The values are read by viper from a config file or from environement variables.
 type Config struct {
-	DBDriver      string `mapstructure:"DB_DRIVER"`
-	DBSource      string `mapstructure:"DB_SOURCE"`
-	ServerAddress string `mapstructure:"SERVER_ADDRESS"`
+	DBDriver                   string        `mapstructure:"DB_DRIVER"`
+	DBSource                   string        `mapstructure:"DB_SOURCE"`
+	ServerAddress              string        `mapstructure:"SERVER_ADDRESS"`
+	BcryptCost                 int           `mapstructure:"BCRYPT_COST"`
+	TokenSymmetricKey          string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
+	AccessTokenDuration        time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	RefreshTokenDuration       time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
+	AppBaseURL                 string        `mapstructure:"APP_BASE_URL"`
+	EmailTokenDuration         time.Duration `mapstructure:"EMAIL_TOKEN_DURATION"`
+	PasswordResetTokenDuration time.Duration `mapstructure:"PASSWORD_RESET_TOKEN_DURATION"`
+	MFATokenDuration           time.Duration `mapstructure:"MFA_TOKEN_DURATION"`
+	LoginLimiterBackend        string        `mapstructure:"LOGIN_LIMITER_BACKEND"`
+	LoginFailureWindow         time.Duration `mapstructure:"LOGIN_FAILURE_WINDOW"`
+	LoginBackoffBase           time.Duration `mapstructure:"LOGIN_BACKOFF_BASE"`
+	LoginBackoffMax            time.Duration `mapstructure:"LOGIN_BACKOFF_MAX"`
+	LoginLockoutDuration       time.Duration `mapstructure:"LOGIN_LOCKOUT_DURATION"`
+	LoginUserLockoutThreshold  int           `mapstructure:"LOGIN_USER_LOCKOUT_THRESHOLD"`
+	LoginIPLockoutThreshold    int           `mapstructure:"LOGIN_IP_LOCKOUT_THRESHOLD"`
+	StorageBackend             string        `mapstructure:"STORAGE_BACKEND"`
+	StorageDir                 string        `mapstructure:"STORAGE_DIR"`
+	S3Endpoint                 string        `mapstructure:"S3_ENDPOINT"`
+	S3Region                   string        `mapstructure:"S3_REGION"`
+	S3Bucket                   string        `mapstructure:"S3_BUCKET"`
+	S3AccessKeyID              string        `mapstructure:"S3_ACCESS_KEY_ID"`
+	S3SecretAccessKey          string        `mapstructure:"S3_SECRET_ACCESS_KEY"`
+	FileURLSigningKey          string        `mapstructure:"FILE_URL_SIGNING_KEY"`
+	FileURLDuration            time.Duration `mapstructure:"FILE_URL_DURATION"`
+	MaxAudioUploadSize         int64         `mapstructure:"MAX_AUDIO_UPLOAD_SIZE"`
+	MaxFileUploadSize          int64         `mapstructure:"MAX_FILE_UPLOAD_SIZE"`
+	MaxArtworkUploadSize       int64         `mapstructure:"MAX_ARTWORK_UPLOAD_SIZE"`
+	MaxUploadChunkSize         int64         `mapstructure:"MAX_UPLOAD_CHUNK_SIZE"`
+	UploadExpiry               time.Duration `mapstructure:"UPLOAD_EXPIRY"`
+	UploadSweepInterval        time.Duration `mapstructure:"UPLOAD_SWEEP_INTERVAL"`
+	PlayCountThreshold         int64         `mapstructure:"PLAY_COUNT_THRESHOLD"`
+	PreviewDuration            time.Duration `mapstructure:"PREVIEW_DURATION"`
+	PreviewTagFile             string        `mapstructure:"PREVIEW_TAG_FILE"`
+	PreviewTagInterval         time.Duration `mapstructure:"PREVIEW_TAG_INTERVAL"`
+	PreviewTagGain             float64       `mapstructure:"PREVIEW_TAG_GAIN"`
+	JobWorkers                 int           `mapstructure:"JOB_WORKERS"`
+	JobQueueSize               int           `mapstructure:"JOB_QUEUE_SIZE"`
+	JobTimeout                 time.Duration `mapstructure:"JOB_TIMEOUT"`
+	MailerBackend              string        `mapstructure:"MAILER_BACKEND"`
+	MailFrom                   string        `mapstructure:"MAIL_FROM"`
+	MailDir                    string        `mapstructure:"MAIL_DIR"`
+	SMTPHost                   string        `mapstructure:"SMTP_HOST"`
+	SMTPPort                   int           `mapstructure:"SMTP_PORT"`
+	SMTPUsername               string        `mapstructure:"SMTP_USERNAME"`
+	SMTPPassword               string        `mapstructure:"SMTP_PASSWORD"`
+	PaymentProvider            string        `mapstructure:"PAYMENT_PROVIDER"`
+	PaymentWebhookSecret       string        `mapstructure:"PAYMENT_WEBHOOK_SECRET"`
+	PaymentWebhookTolerance    time.Duration `mapstructure:"PAYMENT_WEBHOOK_TOLERANCE"`
 }
 
 // LoadConfig reads configuration settings from file or from environment variables.
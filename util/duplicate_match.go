@@ -0,0 +1,14 @@
+package util
+
+// Evidence a duplicate match is based on, stored in duplicate_matches.kind
+const (
+	ContentHashMatch = "content_hash"
+	FingerprintMatch = "fingerprint"
+)
+
+// Review states of a duplicate match, stored in duplicate_matches.status
+const (
+	PendingMatch   = "pending"
+	ConfirmedMatch = "confirmed"
+	DismissedMatch = "dismissed"
+)
@@ -0,0 +1,14 @@
+package util
+
+// Types of the files attached to a beat, stored in beat_files.file_type
+const (
+	MasterWAVFile     = "master_wav"
+	MP3File           = "mp3"
+	TaggedPreviewFile = "tagged_preview"
+	StemsFile         = "stems_zip"
+	ProjectFile       = "project_file"
+)
+
+// AudioUpload is the type of an upload which becomes the master WAV or MP3 of a beat,
+// depending on the format of the finished file
+const AudioUpload = "audio"
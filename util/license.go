@@ -0,0 +1,9 @@
+package util
+
+// Tiers a beat can be licensed in, stored in licenses.tier
+const (
+	BasicLicense     = "basic"
+	PremiumLicense   = "premium"
+	UnlimitedLicense = "unlimited"
+	ExclusiveLicense = "exclusive"
+)
@@ -0,0 +1,26 @@
+package util
+
+// Statuses of an order, stored in orders.status
+const (
+	OrderPending  = "pending"
+	OrderPaid     = "paid"
+	OrderFailed   = "failed"
+	OrderRefunded = "refunded"
+)
+
+// orderTransitions maps the status of an order to the statuses it may move to,
+// failed and refunded orders are final
+var orderTransitions = map[string][]string{
+	OrderPending: {OrderPaid, OrderFailed},
+	OrderPaid:    {OrderRefunded},
+}
+
+// CanTransitionOrder checks if an order in status from may move to status to
+func CanTransitionOrder(from string, to string) bool {
+	for _, status := range orderTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,20 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanTransitionOrder(t *testing.T) {
+	require.True(t, CanTransitionOrder(OrderPending, OrderPaid))
+	require.True(t, CanTransitionOrder(OrderPending, OrderFailed))
+	require.True(t, CanTransitionOrder(OrderPaid, OrderRefunded))
+
+	require.False(t, CanTransitionOrder(OrderPending, OrderRefunded))
+	require.False(t, CanTransitionOrder(OrderPending, OrderPending))
+	require.False(t, CanTransitionOrder(OrderPaid, OrderFailed))
+	require.False(t, CanTransitionOrder(OrderFailed, OrderPaid))
+	require.False(t, CanTransitionOrder(OrderRefunded, OrderPaid))
+	require.False(t, CanTransitionOrder("unknown", OrderPaid))
+}
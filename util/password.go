@@ -0,0 +1,38 @@
+package util
+
+import (
+	"fmt"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// HashPassword returns the bcrypt hash of password using the given cost.
+// A cost of 0 falls back to bcrypt.DefaultCost.
+func HashPassword(password string, cost int) (string, error) {
+	if cost == 0 {
+		cost = bcrypt.DefaultCost
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), cost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hashedPassword), nil
+}
+
+// CheckPassword checks if the provided password matches hashedPassword
+func CheckPassword(password string, hashedPassword string) error {
+	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+}
+
+// PasswordNeedsRehash reports whether hashedPassword was generated with a cost
+// other than the currently configured one and should be replaced on next login.
+func PasswordNeedsRehash(hashedPassword string, cost int) bool {
+	if cost == 0 {
+		cost = bcrypt.DefaultCost
+	}
+	hashCost, err := bcrypt.Cost([]byte(hashedPassword))
+	if err != nil {
+		return true
+	}
+	return hashCost != cost
+}
@@ -0,0 +1,37 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestHashPassword(t *testing.T) {
+	password := RandomPassword()
+
+	hashedPassword1, err := HashPassword(password, bcrypt.MinCost)
+	require.NoError(t, err)
+	require.NotEmpty(t, hashedPassword1)
+
+	err = CheckPassword(password, hashedPassword1)
+	require.NoError(t, err)
+
+	wrongPassword := RandomPassword()
+	err = CheckPassword(wrongPassword, hashedPassword1)
+	require.EqualError(t, err, bcrypt.ErrMismatchedHashAndPassword.Error())
+
+	hashedPassword2, err := HashPassword(password, bcrypt.MinCost)
+	require.NoError(t, err)
+	require.NotEmpty(t, hashedPassword2)
+	require.NotEqual(t, hashedPassword1, hashedPassword2)
+}
+
+func TestPasswordNeedsRehash(t *testing.T) {
+	hashedPassword, err := HashPassword(RandomPassword(), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	require.False(t, PasswordNeedsRehash(hashedPassword, bcrypt.MinCost))
+	require.True(t, PasswordNeedsRehash(hashedPassword, bcrypt.MinCost+1))
+	require.True(t, PasswordNeedsRehash("not a hash", bcrypt.MinCost))
+}
@@ -8,7 +8,7 @@ import (
 
 const alphabet = "abcdefghijklmnopqrstuvwxyz"
 
-var MusicalKeys [22]string = [22]string{
+var MusicalKeys [24]string = [24]string{
 	"C major",
 	"C minor",
 	"C# major",
@@ -21,6 +21,8 @@ var MusicalKeys [22]string = [22]string{
 	"E minor",
 	"F major",
 	"F minor",
+	"F# major",
+	"F# minor",
 	"G major",
 	"G minor",
 	"G# major",
@@ -0,0 +1,8 @@
+package util
+
+// Roles a user can have, stored in users.role
+const (
+	ListenerRole = "listener"
+	ProducerRole = "producer"
+	AdminRole    = "admin"
+)
@@ -0,0 +1,25 @@
+package util
+
+// Scopes an API key can be granted, stored in api_keys.scopes.
+// Reads are public, so there are only scopes for writes.
+const (
+	BeatsWriteScope = "beats:write"
+	LikesWriteScope = "likes:write"
+)
+
+// HasScopes checks if granted contains every one of the required scopes
+func HasScopes(granted []string, required ...string) bool {
+	for _, scope := range required {
+		found := false
+		for _, g := range granted {
+			if g == scope {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,18 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHasScopes(t *testing.T) {
+	granted := []string{BeatsWriteScope}
+
+	require.True(t, HasScopes(granted))
+	require.True(t, HasScopes(granted, BeatsWriteScope))
+	require.True(t, HasScopes([]string{LikesWriteScope, BeatsWriteScope}, BeatsWriteScope, LikesWriteScope))
+	require.False(t, HasScopes(granted, LikesWriteScope))
+	require.False(t, HasScopes(granted, BeatsWriteScope, LikesWriteScope))
+	require.False(t, HasScopes(nil, BeatsWriteScope))
+}
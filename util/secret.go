@@ -0,0 +1,25 @@
+package util
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// GenerateSecretToken returns a URL-safe token made of n cryptographically random bytes.
+// Unlike the Random* helpers it is suitable for one-time links sent to users.
+func GenerateSecretToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashSecretToken returns the hex encoded SHA-256 hash of token,
+// only the hash is stored so a database leak does not expose usable tokens
+func HashSecretToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,27 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateSecretToken(t *testing.T) {
+	token1, err := GenerateSecretToken(32)
+	require.NoError(t, err)
+	require.Len(t, token1, 43)
+
+	token2, err := GenerateSecretToken(32)
+	require.NoError(t, err)
+	require.NotEqual(t, token1, token2)
+}
+
+func TestHashSecretToken(t *testing.T) {
+	token, err := GenerateSecretToken(32)
+	require.NoError(t, err)
+
+	hash := HashSecretToken(token)
+	require.Len(t, hash, 64)
+	require.Equal(t, hash, HashSecretToken(token))
+	require.NotEqual(t, hash, HashSecretToken(token+"x"))
+}
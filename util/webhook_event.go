@@ -0,0 +1,10 @@
+package util
+
+// Processing states of a payment webhook event, stored in webhook_events.status
+const (
+	WebhookEventPending   = "pending"
+	WebhookEventProcessed = "processed"
+	// WebhookEventIgnored is an event of a type which doesn't settle orders
+	WebhookEventIgnored = "ignored"
+	WebhookEventFailed  = "failed"
+)